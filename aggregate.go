@@ -0,0 +1,149 @@
+package colly
+
+import (
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// AggregateCompleteFunc reports whether the parts collected for the correlation ID make a complete record.
+type AggregateCompleteFunc func(id string, parts []any) bool
+
+// AggregateCallback receives the parts of a record in arrival order.
+// expired is true if the record was emitted by the timeout before it was complete.
+type AggregateCallback func(id string, parts []any, expired bool)
+
+// Aggregator combines the partial results of multiple responses into one record per correlation ID.
+// A record is emitted once, when the completion predicate is satisfied, the record is flushed
+// or its timeout elapsed. A part arriving later starts a new record.
+type Aggregator struct {
+	complete AggregateCompleteFunc
+	emit     AggregateCallback
+	timeout  time.Duration
+	records  map[string]*aggregateRecord
+	lock     *sync.Mutex
+}
+
+// aggregateRecord holds the parts of a record.
+type aggregateRecord struct {
+	parts []any
+	timer *time.Timer // nil if the aggregator has no timeout
+}
+
+// ------------------------------------------------------------------------
+
+// NewAggregator returns a pointer to a newly created aggregator.
+// A nil completion predicate leaves the completion to Flush and FlushOnGroup.
+// A zero timeout keeps the incomplete records until they are flushed.
+func NewAggregator(complete AggregateCompleteFunc, emit AggregateCallback, timeout time.Duration) *Aggregator {
+	return &Aggregator{
+		complete: complete,
+		emit:     emit,
+		timeout:  timeout,
+		records:  map[string]*aggregateRecord{},
+		lock:     &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Put adds a part to the record of the correlation ID.
+// The record is emitted if it became complete.
+func (a *Aggregator) Put(id string, part any) {
+	a.lock.Lock()
+
+	rec, ok := a.records[id]
+	if !ok {
+		rec = &aggregateRecord{}
+		if a.timeout > 0 {
+			rec.timer = time.AfterFunc(a.timeout, func() { a.expire(id, rec) })
+		}
+		a.records[id] = rec
+	}
+	rec.parts = append(rec.parts, part)
+
+	if a.complete == nil || !a.complete(id, rec.parts) {
+		a.lock.Unlock()
+		return
+	}
+
+	a.remove(id)
+	a.lock.Unlock()
+
+	a.fire(id, rec.parts, false)
+}
+
+// PutRequest adds a part to the record of the request group of the request,
+// see Request.SetGroup. The part is dropped if the request has no group.
+func (a *Aggregator) PutRequest(r *Request, part any) {
+	if id := r.Group(); id != "" {
+		a.Put(id, part)
+	}
+}
+
+// Flush emits the record of the correlation ID as complete, if it has any parts.
+func (a *Aggregator) Flush(id string) {
+	a.lock.Lock()
+	rec := a.remove(id)
+	a.lock.Unlock()
+
+	if rec != nil {
+		a.fire(id, rec.parts, false)
+	}
+}
+
+// FlushOnGroup flushes the record of the request group when all requests of the group finished.
+// It returns immediately, the record is flushed in the background.
+func (a *Aggregator) FlushOnGroup(c *Collector, id string) {
+	go func() {
+		c.GroupWait(id)
+		a.Flush(id)
+	}()
+}
+
+// Pending returns the number of the records waiting for completion.
+func (a *Aggregator) Pending() int {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return len(a.records)
+}
+
+// ------------------------------------------------------------------------
+
+// expire emits the record by its timeout, unless it was emitted already.
+func (a *Aggregator) expire(id string, rec *aggregateRecord) {
+	a.lock.Lock()
+	if a.records[id] != rec {
+		a.lock.Unlock()
+		return
+	}
+	a.remove(id)
+	a.lock.Unlock()
+
+	a.fire(id, rec.parts, true)
+}
+
+// remove removes the record of the correlation ID and stops its timer.
+// The caller must hold the lock.
+func (a *Aggregator) remove(id string) *aggregateRecord {
+	rec, ok := a.records[id]
+	if !ok {
+		return nil
+	}
+
+	if rec.timer != nil {
+		rec.timer.Stop()
+	}
+	delete(a.records, id)
+
+	return rec
+}
+
+// fire calls the callback of the aggregator.
+func (a *Aggregator) fire(id string, parts []any, expired bool) {
+	if a.emit != nil {
+		a.emit(id, parts, expired)
+	}
+}
@@ -0,0 +1,83 @@
+package colly
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+type aggregateResult struct {
+	id      string
+	parts   []any
+	expired bool
+}
+
+func TestAggregator(t *testing.T) {
+	results := []aggregateResult{}
+	lock := &sync.Mutex{}
+	emitted := make(chan struct{}, 10)
+
+	a := NewAggregator(
+		func(_ string, parts []any) bool { return len(parts) == 2 },
+		func(id string, parts []any, expired bool) {
+			lock.Lock()
+			results = append(results, aggregateResult{id: id, parts: parts, expired: expired})
+			lock.Unlock()
+			emitted <- struct{}{}
+		},
+		20*time.Millisecond,
+	)
+
+	a.Put("a", "title")
+	a.Put("b", "title")
+	a.Put("a", "price")
+	a.Put("c", "title")
+	a.Flush("c")
+	a.Flush("unknown")
+
+	<-emitted
+	<-emitted
+	<-emitted // b expires
+	if n := a.Pending(); n != 0 {
+		t.Errorf("Pending() = %d, want 0", n)
+	}
+
+	want := []aggregateResult{
+		{id: "a", parts: []any{"title", "price"}},
+		{id: "c", parts: []any{"title"}},
+		{id: "b", parts: []any{"title"}, expired: true},
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("emitted records = %+v, want %+v", results, want)
+	}
+}
+
+func TestAggregator_FlushOnGroup(t *testing.T) {
+	c := NewCollector(nil, nil)
+	emitted := make(chan []any, 1)
+	a := NewAggregator(nil, func(_ string, parts []any, _ bool) { emitted <- parts }, 0)
+
+	root := &Request{collector: c}
+	root.SetGroup("product")
+	ctx, _ := c.groupSpawn(root)
+	r := &Request{Ctx: ctx, collector: c}
+
+	a.FlushOnGroup(c, "product")
+	a.PutRequest(r, "variant")
+	a.PutRequest(&Request{}, "ungrouped")
+	c.groupFinish(r)
+
+	select {
+	case parts := <-emitted:
+		if !reflect.DeepEqual(parts, []any{"variant"}) {
+			t.Errorf("emitted parts = %v, want [variant]", parts)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("FlushOnGroup() did not emit the record")
+	}
+}
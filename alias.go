@@ -0,0 +1,55 @@
+package colly
+
+import (
+	"strings"
+)
+
+// ------------------------------------------------------------------------
+
+// recordAlias stores the raw URL as an alias of the canonical key in the alias storage.
+// Nothing is stored if the two are the same.
+func (c *Collector) recordAlias(key string, raw string) {
+	stg := c.Config.AliasStorage
+	if stg == nil || key == "" || raw == "" || key == raw {
+		return
+	}
+
+	if err := stg.AddAlias(key, raw); err != nil {
+		c.handleOnStorageError(STORAGE_ALIASES, err)
+	}
+}
+
+// recordCanonical stores the URL of a HTML response as an alias of its canonical link,
+// taken from the Link header or the <link rel="canonical"> element.
+func (c *Collector) recordCanonical(resp *Response) {
+	if c.Config.AliasStorage == nil || resp.Request == nil || resp.Request.Req == nil || !strings.Contains(resp.ContentType(), "html") {
+		return
+	}
+
+	_, canonical := pageTitleAndCanonical(resp.Body)
+	if link := canonicalLink(resp.Resp.Header.Values("Link")); link != "" {
+		canonical = link
+	}
+	if canonical == "" {
+		return
+	}
+
+	if canonical = resp.Request.AbsoluteURL(canonical); canonical != "" {
+		c.recordAlias(canonical, resp.Request.Req.URL.String())
+	}
+}
+
+// aliases returns the raw URLs of the alias storage, mapped by the canonical keys.
+func (c *Collector) aliases() map[string][]string {
+	aliases := map[string][]string{}
+
+	err := c.Config.AliasStorage.ExportAliases(func(key string, raw []string) error {
+		aliases[key] = raw
+		return nil
+	})
+	if err != nil {
+		c.handleOnStorageError(STORAGE_ALIASES, err)
+	}
+
+	return aliases
+}
@@ -0,0 +1,61 @@
+package colly
+
+import (
+	"bytes"
+	"colly/storage/mem"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollector_aliases(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/print":
+			w.Header().Set("Link", `</page>; rel="canonical"`)
+			w.Write([]byte(`<html><body>Print view</body></html>`))
+		case "/amp":
+			w.Write([]byte(`<html><head><link rel="canonical" href="/page"></head><body>AMP view</body></html>`))
+		default:
+			w.Write([]byte(`<html><body>Page</body></html>`))
+		}
+	}))
+	defer srv.Close()
+
+	cfg := NewConfig()
+	cfg.AliasStorage = mem.NewAliasStorage()
+	c := NewCollector(cfg, nil)
+
+	// The normalized URL of the dot segments is an alias of the canonical page
+	for _, u := range []string{srv.URL + "/page", srv.URL + "/x/../print", srv.URL + "/amp"} {
+		if err := c.Visit(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string][]string{
+		srv.URL + "/page":  {srv.URL + "/amp", srv.URL + "/print"},
+		srv.URL + "/print": {srv.URL + "/x/../print"},
+	}
+	rep := c.Report()
+	if !reflect.DeepEqual(rep.Aliases, want) {
+		t.Errorf("Report().Aliases = %v, want %v", rep.Aliases, want)
+	}
+
+	if got, _ := cfg.AliasStorage.Canonical(srv.URL + "/amp"); got != srv.URL+"/page" {
+		t.Errorf("Canonical() = %q, want %q", got, srv.URL+"/page")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := rep.WriteHTML(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "URL Aliases") {
+		t.Error("WriteHTML() has no alias table")
+	}
+}
@@ -0,0 +1,72 @@
+package colly
+
+import (
+	"bytes"
+	"colly/warc"
+	"compress/gzip"
+	"io"
+	"net/http"
+)
+
+// ------------------------------------------------------------------------
+
+// NewWARCModule returns a module that archives the request/response records of the
+// parsed responses with the WARC writer as the crawl proceeds.
+// The response records hold the body as the collector received it after the decoding of
+// the content coding, e.g. gzip, and the truncation to MaxBodySize, so they are not
+// byte-identical to the transferred payload. The request records hold the replayable
+// request bodies uncompressed, the streamed bodies are not archived.
+// The writer is not closed by the collector, call its Flush, Rotate and Close methods as needed.
+func NewWARCModule(w *warc.Writer) Module {
+	return ModuleFunc(func(c *Collector) error {
+		if w == nil {
+			return ErrNoWARCWriter
+		}
+
+		c.OnResponse(func(resp *Response) {
+			if resp.Request == nil || resp.Request.Req == nil || resp.Resp == nil {
+				return
+			}
+
+			body, err := archivedRequestBody(resp.Request.Req)
+			if err != nil {
+				c.Config.logError(LOG_WARN_LEVEL, err)
+			}
+
+			if err := w.WriteExchange(resp.Request.Req, body, resp.Resp, resp.Body); err != nil {
+				c.Config.logError(LOG_WARN_LEVEL, err)
+			}
+		})
+
+		return nil
+	})
+}
+
+// ------------------------------------------------------------------------
+
+// archivedRequestBody returns the replayable body of the request, decompressed if it was
+// gzipped by the body compression, or nil if the body can't be replayed.
+func archivedRequestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody == nil {
+		return nil, nil
+	}
+
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	body, err := io.ReadAll(rc)
+	if err != nil || !hasHdrVal(req.Header, "Content-Encoding", "gzip") {
+		return body, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return io.ReadAll(zr)
+}
@@ -0,0 +1,36 @@
+package colly
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_archivedRequestBody(t *testing.T) {
+	get := httptest.NewRequest("GET", "https://example.com/", nil)
+	get.GetBody = nil
+	if body, err := archivedRequestBody(get); body != nil || err != nil {
+		t.Errorf("archivedRequestBody() without a body = %q, %v, want nil", body, err)
+	}
+
+	post := &Request{Req: httptest.NewRequest("POST", "https://example.com/", nil)}
+	post.setBody([]byte("name=colly"))
+	if body, err := archivedRequestBody(post.Req); string(body) != "name=colly" || err != nil {
+		t.Errorf("archivedRequestBody() = %q, %v, want name=colly", body, err)
+	}
+
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	zw.Write([]byte(strings.Repeat("compressed ", 10)))
+	zw.Close()
+
+	post.setBody(buf.Bytes())
+	post.Req.Header.Set("Content-Encoding", "gzip")
+	if body, err := archivedRequestBody(post.Req); string(body) != strings.Repeat("compressed ", 10) || err != nil {
+		t.Errorf("archivedRequestBody() of a compressed body = %q, %v", body, err)
+	}
+}
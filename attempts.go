@@ -0,0 +1,102 @@
+package colly
+
+import (
+	"context"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// AttemptInfo is the outcome of an attempt of a request.
+type AttemptInfo struct {
+	Attempt    uint          `json:"attempt" bson:"attempt"`                   // Attempt is 1 for the first attempt, 2 for the first retry, etc.
+	Started    time.Time     `json:"started" bson:"started,omitempty"`         // Started is when the attempt passed the OnRequest callbacks.
+	Duration   time.Duration `json:"duration" bson:"duration,omitempty"`       // Duration is the time between the start and the response or the error.
+	Proxy      string        `json:"proxy" bson:"proxy,omitempty"`             // Proxy is the address of the proxy used by the attempt, if known.
+	StatusCode int           `json:"status_code" bson:"status_code,omitempty"` // StatusCode is the response status code, zero if no response was received.
+	Error      string        `json:"error" bson:"error,omitempty"`             // Error is the message of the failure, blank for the successful attempts.
+}
+
+// attemptHistory is the history of the attempts of a request carried by the context of its retries.
+type attemptHistory struct {
+	request  string // method and URL of the request, the requests created from it don't inherit the history
+	attempts []AttemptInfo
+}
+
+// ------------------------------------------------------------------------
+
+// recordAttempt adds the outcome of the current attempt to the attempt history of the response.
+// The history is carried by the context of the request, so the retries created from the
+// request, by Request.Retry, the truncated response and the stuck request retries, continue it.
+func (c *Collector) recordAttempt(resp *Response, err error) {
+	if resp == nil || resp.Request == nil || resp.Request.Req == nil || resp.Attempts != nil {
+		return
+	}
+
+	r := resp.Request
+	info := AttemptInfo{
+		Attempt: r.Attempt(),
+		Started: r.started,
+		Proxy:   resp.proxyUsed(),
+	}
+	if !r.started.IsZero() {
+		info.Duration = time.Since(r.started)
+	}
+	if resp.Resp != nil {
+		info.StatusCode = resp.Resp.StatusCode
+	}
+	if err != nil {
+		info.Error = err.Error()
+	}
+
+	c.recordHealth(resp, err)
+
+	prev := r.attemptHistory()
+	resp.Attempts = append(prev[:len(prev):len(prev)], info)
+
+	parent := context.Background()
+	if r.Ctx != nil {
+		parent = *r.Ctx
+	}
+	ctx := context.WithValue(parent, AttemptHistoryKey, &attemptHistory{
+		request:  r.attemptKey(),
+		attempts: resp.Attempts,
+	})
+	r.Ctx = &ctx
+}
+
+// ------------------------------------------------------------------------
+
+// attemptHistory returns the outcomes of the earlier attempts of the request.
+func (r *Request) attemptHistory() []AttemptInfo {
+	if r.Ctx == nil {
+		return nil
+	}
+
+	h, ok := (*r.Ctx).Value(AttemptHistoryKey).(*attemptHistory)
+	if !ok || h.request != r.attemptKey() {
+		return nil
+	}
+
+	return h.attempts
+}
+
+// attemptKey identifies the retries of the request.
+func (r *Request) attemptKey() string {
+	return r.Req.Method + " " + r.Req.URL.String()
+}
+
+// proxyUsed returns the address of the proxy of the response set by the proxy switcher.
+func (r *Response) proxyUsed() string {
+	if r.Resp != nil && r.Resp.Request != nil {
+		if proxy, ok := r.Resp.Request.Context().Value(ProxyURLKey).(string); ok {
+			return proxy
+		}
+	}
+
+	if proxy, ok := r.Request.Req.Context().Value(ProxyURLKey).(string); ok {
+		return proxy
+	}
+
+	return ""
+}
@@ -0,0 +1,60 @@
+package colly
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollector_recordAttempt(t *testing.T) {
+	c := &Collector{}
+	bg := context.Background()
+
+	first := &Request{
+		Req:     httptest.NewRequest("GET", "https://example.com/page", nil),
+		Ctx:     &bg,
+		started: time.Now().Add(-50 * time.Millisecond),
+	}
+	proxied := first.Req.WithContext(context.WithValue(first.Req.Context(), ProxyURLKey, "http://proxy-1:8080"))
+	resp := &Response{Request: first, Resp: &http.Response{StatusCode: 503, Request: proxied}}
+	c.recordAttempt(resp, errors.New("Service Unavailable"))
+	c.recordAttempt(resp, errors.New("recorded twice"))
+
+	retry := &Request{
+		Req:     httptest.NewRequest("GET", "https://example.com/page", nil),
+		Ctx:     first.retryContext(),
+		started: time.Now(),
+	}
+	resp = &Response{Request: retry, Resp: &http.Response{StatusCode: 200}}
+	c.recordAttempt(resp, nil)
+
+	want := []AttemptInfo{
+		{Attempt: 1, Proxy: "http://proxy-1:8080", StatusCode: 503, Error: "Service Unavailable"},
+		{Attempt: 2, StatusCode: 200},
+	}
+	if len(resp.Attempts) != len(want) {
+		t.Fatalf("Attempts = %+v, want %d attempts", resp.Attempts, len(want))
+	}
+	for i, got := range resp.Attempts {
+		if got.Attempt != want[i].Attempt || got.Proxy != want[i].Proxy || got.StatusCode != want[i].StatusCode || got.Error != want[i].Error {
+			t.Errorf("Attempts[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if resp.Attempts[0].Duration < 50*time.Millisecond {
+		t.Errorf("Attempts[0].Duration = %v, want at least 50ms", resp.Attempts[0].Duration)
+	}
+
+	// the requests created from the response don't inherit the history
+	child := &Request{
+		Req: httptest.NewRequest("GET", "https://example.com/other", nil),
+		Ctx: retry.Ctx,
+	}
+	if h := child.attemptHistory(); h != nil {
+		t.Errorf("child attemptHistory() = %+v, want nil", h)
+	}
+}
@@ -0,0 +1,438 @@
+package colly
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// Credentials is a user name and password pair to answer the authentication challenges.
+type Credentials struct {
+	Username string `json:"username" bson:"username"` // Username is the user name.
+	Password string `json:"-" bson:"-"`               // Password is the password, it is never serialised.
+}
+
+// Authenticator answers the HTTP 401 and 407 authentication challenges with the configured credentials.
+// Basic and Digest (MD5, SHA-256 and their session variants) schemes are supported.
+// The Digest challenges are kept by host, so the next requests are authorised without another challenge.
+type Authenticator struct {
+	hosts   map[string]*Credentials   // credentials mapped by the lowercase host names or "*.domain" patterns
+	proxy   *Credentials              // credentials of the proxy
+	digests map[string]*digestSession // accepted Digest challenges mapped by the host names or the proxy key
+	lock    *sync.Mutex
+}
+
+// AuthError is returned when the server or the proxy rejected the credentials.
+type AuthError struct {
+	StatusCode int    // StatusCode is 401 for the servers and 407 for the proxies.
+	Host       string // Host is the host that rejected the credentials.
+	Scheme     string // Scheme is the authentication scheme, "Basic" or "Digest".
+}
+
+// authChallenge is a parsed WWW-Authenticate or Proxy-Authenticate challenge.
+type authChallenge struct {
+	scheme string
+	params map[string]string
+}
+
+// digestSession is an accepted Digest challenge with its nonce counter.
+type digestSession struct {
+	challenge *authChallenge
+	nc        uint32
+}
+
+// ------------------------------------------------------------------------
+
+const (
+	proxyAuthKey     = "\x00proxy" // digest session key of the proxy
+	maxAuthRetries   = 2           // answers of a challenge, the second one is only sent for stale nonces
+	maxAuthBodyDrain = 4096        // bytes read from the challenge responses to reuse the connection
+)
+
+// ------------------------------------------------------------------------
+
+// NewAuthenticator returns a pointer to a newly created authenticator.
+func NewAuthenticator() *Authenticator {
+	return &Authenticator{
+		hosts:   map[string]*Credentials{},
+		digests: map[string]*digestSession{},
+		lock:    &sync.Mutex{},
+	}
+}
+
+// AddCredentials sets the credentials of a host. The host may include a port, or be a
+// "*.example.com" pattern matching the subdomains. The credentials are never sent to other hosts.
+func (a *Authenticator) AddCredentials(host string, username string, password string) {
+	a.lock.Lock()
+	a.hosts[strings.ToLower(host)] = &Credentials{Username: username, Password: password}
+	a.lock.Unlock()
+}
+
+// SetProxyCredentials sets the credentials answering the 407 challenges of the proxies.
+func (a *Authenticator) SetProxyCredentials(username string, password string) {
+	a.lock.Lock()
+	a.proxy = &Credentials{Username: username, Password: password}
+	a.lock.Unlock()
+}
+
+// ------------------------------------------------------------------------
+
+// Error implements error interface.
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("%s: %s %s credentials rejected with status %d", ErrAuthFailed, e.Host, e.Scheme, e.StatusCode)
+}
+
+// Unwrap returns ErrAuthFailed, so the authentication errors can be checked by errors.Is.
+func (e *AuthError) Unwrap() error {
+	return ErrAuthFailed
+}
+
+// ------------------------------------------------------------------------
+
+// credentials returns the credentials of the host or the proxy.
+func (a *Authenticator) credentials(host string, proxy bool) *Credentials {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if proxy {
+		return a.proxy
+	}
+
+	host = strings.ToLower(host)
+	if cred, present := a.hosts[host]; present {
+		return cred
+	}
+
+	// Try without the port, then the subdomain patterns
+	name := host
+	if i := strings.LastIndexByte(name, ':'); i > strings.LastIndexByte(name, ']') {
+		name = name[:i]
+	}
+	if cred, present := a.hosts[name]; present {
+		return cred
+	}
+	for i := strings.IndexByte(name, '.'); i >= 0; i = strings.IndexByte(name, '.') {
+		name = name[i+1:]
+		if cred, present := a.hosts["*."+name]; present {
+			return cred
+		}
+	}
+
+	return nil
+}
+
+// authorize adds the Authorization headers of the accepted Digest challenges to the request,
+// so it is not challenged again.
+func (a *Authenticator) authorize(req *http.Request) {
+	if a == nil {
+		return
+	}
+
+	if cred := a.credentials(req.URL.Host, false); cred != nil && req.Header.Get("Authorization") == "" {
+		if hdr := a.digestHeader(req.URL.Host, cred, req); hdr != "" {
+			req.Header.Set("Authorization", hdr)
+		}
+	}
+	if cred := a.credentials("", true); cred != nil && req.Header.Get("Proxy-Authorization") == "" {
+		if hdr := a.digestHeader(proxyAuthKey, cred, req); hdr != "" {
+			req.Header.Set("Proxy-Authorization", hdr)
+		}
+	}
+}
+
+// answer sets the authorization header answering the challenge of the response.
+// It returns the scheme of the answered challenge, or a blank string if the challenge
+// can't be answered, e.g. no credentials of the host or an unsupported scheme.
+func (a *Authenticator) answer(req *http.Request, resp *http.Response) string {
+	if a == nil {
+		return ""
+	}
+
+	proxy := resp.StatusCode == http.StatusProxyAuthRequired
+	challengeHdr, authHdr, key := "WWW-Authenticate", "Authorization", req.URL.Host
+	if proxy {
+		challengeHdr, authHdr, key = "Proxy-Authenticate", "Proxy-Authorization", proxyAuthKey
+	}
+
+	cred := a.credentials(req.URL.Host, proxy)
+	if cred == nil {
+		return ""
+	}
+
+	ch := selectChallenge(parseChallenges(resp.Header.Values(challengeHdr)))
+	if ch == nil {
+		return ""
+	}
+
+	switch ch.scheme {
+	case "basic":
+		req.Header.Set(authHdr, "Basic "+base64.StdEncoding.EncodeToString([]byte(cred.Username+":"+cred.Password)))
+		return "Basic"
+	case "digest":
+		a.lock.Lock()
+		a.digests[key] = &digestSession{challenge: ch}
+		a.lock.Unlock()
+
+		hdr := a.digestHeader(key, cred, req)
+		if hdr == "" {
+			return ""
+		}
+		req.Header.Set(authHdr, hdr)
+		return "Digest"
+	}
+
+	return ""
+}
+
+// digestHeader returns the Digest authorization header of the request using the accepted
+// challenge of the key, or a blank string if no challenge was accepted.
+func (a *Authenticator) digestHeader(key string, cred *Credentials, req *http.Request) string {
+	a.lock.Lock()
+	sess, present := a.digests[key]
+	if !present {
+		a.lock.Unlock()
+		return ""
+	}
+	sess.nc++
+	nc := sess.nc
+	a.lock.Unlock()
+
+	return digestAuthorization(sess.challenge, cred, req.Method, req.URL.RequestURI(), nc, newCnonce())
+}
+
+// ------------------------------------------------------------------------
+
+// authenticate answers the 401 and 407 challenges of the response with the credentials
+// of the authenticator and resends the request. The responses without matching credentials
+// are returned as they are. It returns an AuthError if the answer was rejected, unless
+// the Digest nonce was stale.
+func (c *Client) authenticate(clt *http.Client, req *http.Request, resp *http.Response, a *Authenticator) (*http.Response, error) {
+	if a == nil {
+		return resp, nil
+	}
+
+	scheme, status := "", 0
+	for retries := 0; resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusProxyAuthRequired; retries++ {
+		if scheme != "" && resp.StatusCode == status && (retries >= maxAuthRetries || !staleChallenge(resp)) {
+			resp.Body.Close()
+			return nil, &AuthError{StatusCode: resp.StatusCode, Host: req.URL.Host, Scheme: scheme}
+		}
+
+		status = resp.StatusCode
+		if scheme = a.answer(req, resp); scheme == "" || !rewindBody(req) {
+			return resp, nil
+		}
+
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxAuthBodyDrain))
+		resp.Body.Close()
+
+		var err error
+		if resp, err = clt.Do(req); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// staleChallenge tells whether the Digest challenge of the response was sent for a stale nonce.
+func staleChallenge(resp *http.Response) bool {
+	hdr := "WWW-Authenticate"
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		hdr = "Proxy-Authenticate"
+	}
+
+	ch := selectChallenge(parseChallenges(resp.Header.Values(hdr)))
+
+	return ch != nil && ch.scheme == "digest" && strings.EqualFold(ch.params["stale"], "true")
+}
+
+// rewindBody resets the body of the request to resend it.
+// It returns false if the body can't be read again.
+func rewindBody(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody {
+		return true
+	}
+	if req.GetBody == nil {
+		return false
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return false
+	}
+	req.Body = body
+
+	return true
+}
+
+// ------------------------------------------------------------------------
+
+// digestAuthorization returns the Digest authorization header value by RFC 7616.
+func digestAuthorization(ch *authChallenge, cred *Credentials, method string, uri string, nc uint32, cnonce string) string {
+	algorithm := ch.params["algorithm"]
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+
+	var newHash func() hash.Hash
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return ""
+	}
+	h := func(s string) string {
+		hh := newHash()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	realm, nonce := ch.params["realm"], ch.params["nonce"]
+	ha1 := h(cred.Username + ":" + realm + ":" + cred.Password)
+	if strings.HasSuffix(strings.ToUpper(algorithm), "-SESS") {
+		ha1 = h(ha1 + ":" + nonce + ":" + cnonce)
+	}
+	ha2 := h(method + ":" + uri)
+
+	qop := ""
+	for _, q := range strings.Split(ch.params["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			qop = "auth"
+		}
+	}
+
+	ncValue := fmt.Sprintf("%08x", nc)
+	var response string
+	if qop != "" {
+		response = h(ha1 + ":" + nonce + ":" + ncValue + ":" + cnonce + ":" + qop + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + nonce + ":" + ha2)
+	}
+
+	parts := []string{
+		"username=" + strconv.Quote(cred.Username),
+		"realm=" + strconv.Quote(realm),
+		"nonce=" + strconv.Quote(nonce),
+		"uri=" + strconv.Quote(uri),
+		"algorithm=" + algorithm,
+		"response=" + strconv.Quote(response),
+	}
+	if opaque, present := ch.params["opaque"]; present {
+		parts = append(parts, "opaque="+strconv.Quote(opaque))
+	}
+	if qop != "" {
+		parts = append(parts, "qop="+qop, "nc="+ncValue, "cnonce="+strconv.Quote(cnonce))
+	}
+
+	return "Digest " + strings.Join(parts, ", ")
+}
+
+// newCnonce returns a random client nonce.
+func newCnonce() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// ------------------------------------------------------------------------
+
+// selectChallenge returns the strongest supported challenge, Digest is preferred to Basic.
+func selectChallenge(challenges []*authChallenge) *authChallenge {
+	var basic *authChallenge
+
+	for _, ch := range challenges {
+		switch ch.scheme {
+		case "digest":
+			return ch
+		case "basic":
+			if basic == nil {
+				basic = ch
+			}
+		}
+	}
+
+	return basic
+}
+
+// parseChallenges parses the challenges of the WWW-Authenticate or Proxy-Authenticate header values.
+// A header value may contain several comma separated challenges.
+func parseChallenges(values []string) []*authChallenge {
+	challenges := []*authChallenge{}
+
+	for _, v := range values {
+		var current *authChallenge
+
+		for len(v) > 0 {
+			v = strings.TrimLeft(v, " \t,")
+			if v == "" {
+				break
+			}
+
+			// Read a token, it is either a scheme or a parameter name
+			i := strings.IndexAny(v, " \t,=")
+			if i < 0 {
+				i = len(v)
+			}
+			token := v[:i]
+			v = strings.TrimLeft(v[i:], " \t")
+
+			// A token not followed by "=" starts a new challenge
+			if !strings.HasPrefix(v, "=") {
+				current = &authChallenge{scheme: strings.ToLower(token), params: map[string]string{}}
+				challenges = append(challenges, current)
+				continue
+			}
+
+			var val string
+			val, v = readAuthParam(strings.TrimLeft(v[1:], " \t"))
+			if current != nil {
+				current.params[strings.ToLower(token)] = val
+			}
+		}
+	}
+
+	return challenges
+}
+
+// readAuthParam reads a token or a quoted string and returns it with the rest of the header.
+func readAuthParam(v string) (string, string) {
+	if !strings.HasPrefix(v, `"`) {
+		i := strings.IndexAny(v, " \t,")
+		if i < 0 {
+			return v, ""
+		}
+		return v[:i], v[i:]
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(v); i++ {
+		switch v[i] {
+		case '\\':
+			if i+1 < len(v) {
+				i++
+				b.WriteByte(v[i])
+			}
+		case '"':
+			return b.String(), v[i+1:]
+		default:
+			b.WriteByte(v[i])
+		}
+	}
+
+	return b.String(), ""
+}
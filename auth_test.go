@@ -0,0 +1,130 @@
+package colly
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_parseChallenges(t *testing.T) {
+	got := parseChallenges([]string{
+		`Digest realm="test, realm", qop="auth,auth-int", nonce="abc", Basic realm="basic"`,
+		`Bearer`,
+	})
+	want := []*authChallenge{
+		{scheme: "digest", params: map[string]string{"realm": "test, realm", "qop": "auth,auth-int", "nonce": "abc"}},
+		{scheme: "basic", params: map[string]string{"realm": "basic"}},
+		{scheme: "bearer", params: map[string]string{}},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseChallenges() = %+v, want %+v", got, want)
+	}
+	if ch := selectChallenge(got); ch.scheme != "digest" {
+		t.Errorf("selectChallenge() = %q, want %q", ch.scheme, "digest")
+	}
+}
+
+func Test_digestAuthorization(t *testing.T) {
+	// RFC 2617 example
+	ch := &authChallenge{scheme: "digest", params: map[string]string{
+		"realm":  "testrealm@host.com",
+		"qop":    "auth,auth-int",
+		"nonce":  "dcd98b7102dd2f0e8b11d0f600bfb0c093",
+		"opaque": "5ccc069c403ebaf9f0171e9517f40e41",
+	}}
+	cred := &Credentials{Username: "Mufasa", Password: "Circle Of Life"}
+
+	got := digestAuthorization(ch, cred, "GET", "/dir/index.html", 1, "0a4f113b")
+	if !strings.Contains(got, `response="6629fae49393a05397450978507c4ef1"`) {
+		t.Errorf("digestAuthorization() = %s, want the RFC 2617 response", got)
+	}
+	if !strings.Contains(got, "nc=00000001") || !strings.Contains(got, `opaque="5ccc069c403ebaf9f0171e9517f40e41"`) {
+		t.Errorf("digestAuthorization() = %s, missing nc or opaque", got)
+	}
+}
+
+func Test_Authenticator_credentials(t *testing.T) {
+	a := NewAuthenticator()
+	a.AddCredentials("api.example.com", "api", "x")
+	a.AddCredentials("*.example.org", "org", "x")
+
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "api.example.com", want: "api"},
+		{host: "API.example.com:8443", want: "api"},
+		{host: "www.example.com"},
+		{host: "a.b.example.org", want: "org"},
+		{host: "example.org"},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if cred := a.credentials(tt.host, false); cred != nil {
+			got = cred.Username
+		}
+		if got != tt.want {
+			t.Errorf("credentials(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestClient_authenticate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); ok && user == "user" && pass == "secret" {
+			w.Write([]byte("ok"))
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	host := strings.TrimPrefix(ts.URL, "http://")
+	tests := []struct {
+		name       string
+		password   string
+		host       string
+		wantStatus int
+		wantErr    error
+	}{
+		{name: "accepted", password: "secret", host: host, wantStatus: http.StatusOK},
+		{name: "rejected", password: "wrong", host: host, wantErr: ErrAuthFailed},
+		{name: "no credentials", password: "secret", host: "other.com", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAuthenticator()
+			a.AddCredentials(tt.host, "user", tt.password)
+
+			req, _ := http.NewRequest("GET", ts.URL, nil)
+			resp, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err = (&Client{}).authenticate(ts.Client(), req, resp, a)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("authenticate() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
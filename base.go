@@ -33,21 +33,55 @@ type MaxVisitReachedError struct {
 // Errors
 var (
 	ErrAbortedAfterHeaders = errors.New("aborted after receiving response headers") // ErrAbortedAfterHeaders is returned when OnResponseHeaders aborts the transfer.
+	ErrArchiveNoStorage    = errors.New("missing archive storage")                  // ErrArchiveNoStorage is thrown when an attempt was made to create a body archiver without a storage.
+	ErrAuthFailed          = errors.New("authentication failed")                    // ErrAuthFailed is the class of the errors returned when the credentials were rejected.
+	ErrCacheBlobMissing    = errors.New("cached body not found in blob store")      // ErrCacheBlobMissing is thrown when a cache item references a body that is not in the blob store.
+	ErrCacheNoBlobStore    = errors.New("missing cache blob store")                 // ErrCacheNoBlobStore is thrown when a cache item references a body but the blob store is not set.
 	ErrCacheNoExpHandler   = errors.New("missing cache expiry handler")             // ErrCacheNoExpHandler is thrown when an attempt was made to create a Cache without an expiry handler.
 	ErrCacheNoPath         = errors.New("file cache path is blank")                 // ErrCacheNoPath is thrown when an attempt was made to create a file cache with a blank path.
 	ErrCacheNoStorage      = errors.New("missing cache storage")                    // ErrCacheNoStorage is thrown when an attempt was made to create a cache without a storage.
+	ErrComplianceNoContact = errors.New("invalid compliance contact URL")           // ErrComplianceNoContact is thrown when the compliance mode is set without a valid contact URL.
+	ErrCrawlLockLost       = errors.New("crawl lock lost")                          // ErrCrawlLockLost is thrown when the crawl lock could not be renewed.
+	ErrCrawlLocked         = errors.New("crawl is locked by another instance")      // ErrCrawlLocked is thrown when the crawl lock is held by another instance.
 	ErrDecodeNoData        = errors.New("nothing to decode")                        // ErrNoData is thrown when an attempt was made to decode nil data.
+	ErrEmptyArchive        = errors.New("archive has no files")                     // ErrEmptyArchive is thrown when a zip packed sitemap or feed contains no files.
 	ErrEmptyProxyURL       = errors.New("proxy URL list is empty")                  // ErrEmptyProxyURL is thrown for empty Proxy URL list.
 	ErrForbiddenDomain     = errors.New("forbidden domain")                         // ErrForbiddenDomain is thrown when visiting a domain that is not allowed.
+	ErrIdleTimeout         = errors.New("response idle timeout exceeded")           // ErrIdleTimeout is the class of the errors of the requests receiving no data for the idle timeout.
+	ErrInvalidContentRange = errors.New("invalid Content-Range header")             // ErrInvalidContentRange is thrown when the Content-Range header of a partial response can't be parsed.
+	ErrInvalidCrawlWindow  = errors.New("invalid crawl window")                     // ErrInvalidCrawlWindow is thrown when a crawl window specification can't be parsed.
+	ErrInvalidHostAlias    = errors.New("invalid host alias")                       // ErrInvalidHostAlias is thrown when a host alias has a blank host or an invalid address.
+	ErrInvalidRate         = errors.New("invalid rate")                             // ErrInvalidRate is thrown when a token bucket rule has no positive rate.
 	ErrMaxDepth            = errors.New("max depth limit reached")                  // ErrMaxDepth is thrown for exceeding max depth.
+	ErrMaxRedirects        = errors.New("maximum number of redirects reached")      // ErrMaxRedirects is thrown when a request exceeded the maximum number of redirects.
 	ErrMissingURL          = errors.New("missing URL")                              // ErrMissingURL is thrown when the URL is missing.
+	ErrModuleExists        = errors.New("module already registered")                // ErrModuleExists is thrown when a module was registered twice with the same name.
+	ErrModuleNotFound      = errors.New("module not found")                         // ErrModuleNotFound is thrown when an unregistered module was requested by name.
+	ErrNegativeCacheHit    = errors.New("cached permanent failure")                 // ErrNegativeCacheHit is returned for the requests of the URLs that failed permanently a short while ago.
 	ErrNoCollector         = errors.New("missing collector")                        // ErrNoCollector is thrown when the collector pointer is set to nil.
 	ErrNoCookieJar         = errors.New("cookie jar not available")                 // ErrNoCookieJar is thrown for missing cookie jar.
+	ErrNoCrawlLock         = errors.New("missing crawl lock")                       // ErrNoCrawlLock is thrown when an attempt was made to acquire a nil crawl lock.
+	ErrNoFailureJournal    = errors.New("missing failure journal")                  // ErrNoFailureJournal is thrown when failures are replayed without a failure journal.
 	ErrNoFilterDefined     = errors.New("no filter defined")                        // ErrNoFilterDefined is thrown when no valid filter was provided.
+	ErrNoGraphStorage      = errors.New("missing graph storage")                    // ErrNoGraphStorage is thrown when the graph module was created without a storage.
 	ErrNoHTTPRequest       = errors.New("HTTP Request reference is nil")            // ErrNoHTTPRequest is thrown when the HTTP request pointer is set to nil.
 	ErrNoJobDecoder        = errors.New("missing job decoder function")             // ErrNoJobDecoder is thrown when an attempt was made to create a job queue without a decoder function.
+	ErrNoModule            = errors.New("module is nil")                            // ErrNoModule is thrown when a nil module was given.
+	ErrNoWARCWriter        = errors.New("missing WARC writer")                      // ErrNoWARCWriter is thrown when the WARC module was created without a writer.
+	ErrNotPaused           = errors.New("collector is not paused")                  // ErrNotPaused is thrown when the state of a collector is exported without pausing it.
 	ErrQueueFull           = errors.New("maximum queue size reached")               // ErrQueueFull is returned when the queue is full.
+	ErrRangeIncomplete     = errors.New("ranges don't cover the whole body")        // ErrRangeIncomplete is thrown when the parts of a ranged download don't cover the whole body.
+	ErrRangeMismatch       = errors.New("parts of different resource versions")     // ErrRangeMismatch is thrown when the parts of a ranged download come from different versions of the resource.
+	ErrRequestStuck        = errors.New("request exceeded its lifetime")            // ErrRequestStuck is the class of the errors of the requests cancelled by the watchdog.
 	ErrRobotsTxtBlocked    = errors.New("URL blocked by robots.txt")                // ErrRobotsTxtBlocked is thrown for robots.txt errors.
+	ErrSamplerNoStorage    = errors.New("missing capture storage")                  // ErrSamplerNoStorage is thrown when an attempt was made to create a sampler without a storage.
+	ErrSpiderTrap          = errors.New("link of a spider trap")                    // ErrSpiderTrap is returned for the requests created from the responses of a detected spider trap.
+	ErrStateNoStorage      = errors.New("missing storage of the state")             // ErrStateNoStorage is thrown when a state bundle is loaded into a collector without the matching storage.
+	ErrStateVersion        = errors.New("unsupported state bundle version")         // ErrStateVersion is thrown when a state bundle of an unknown version is loaded.
+	ErrTableInvalidTarget  = errors.New("invalid table target")                     // ErrTableInvalidTarget is thrown when a table is unmarshaled into an unsupported type.
+	ErrTableNotFound       = errors.New("table not found")                          // ErrTableNotFound is thrown when the element is not and doesn't contain a table.
+	ErrVisitDuplicate      = errors.New("duplicate URL in the batch")               // ErrVisitDuplicate is the rejection reason of the URLs repeated in a VisitAll batch.
+	ErrVisitRejected       = errors.New("URLs rejected")                            // ErrVisitRejected is returned by VisitAll when some of the URLs were rejected.
 )
 
 // ------------------------------------------------------------------------
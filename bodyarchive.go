@@ -0,0 +1,182 @@
+package colly
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// ArchiveDecision decides whether the body of a scraped response is archived.
+type ArchiveDecision func(resp *Response) bool
+
+// ArchiveEntry is the metadata of an archived response body.
+type ArchiveEntry struct {
+	URL         string    `json:"url" bson:"url"`                             // URL is the URL of the response.
+	Method      string    `json:"method" bson:"method,omitempty"`             // Method is the HTTP method of the request.
+	StatusCode  int       `json:"status_code" bson:"status_code,omitempty"`   // StatusCode is the response status code.
+	ContentType string    `json:"content_type" bson:"content_type,omitempty"` // ContentType is the Content-Type header of the response.
+	Hash        string    `json:"hash" bson:"hash"`                           // Hash is the hex encoded SHA-256 hash of the body, the body is stored under ARCHIVE_BODY_PREFIX + Hash.
+	Size        int       `json:"size" bson:"size"`                           // Size is the length of the body in bytes.
+	Fetched     time.Time `json:"fetched" bson:"fetched,omitempty"`           // Fetched is the creation time of the response.
+	Archived    time.Time `json:"archived" bson:"archived"`                   // Archived is the time of the archiving.
+	RequestID   uint32    `json:"request_id" bson:"request_id,omitempty"`     // RequestID is the identifier of the request.
+	ConfigHash  string    `json:"config_hash" bson:"config_hash,omitempty"`   // ConfigHash identifies the configuration of the collector, see Collector.ConfigHash.
+}
+
+// BodyArchiver persists the bodies of the selected responses with their metadata in a blob store,
+// independently of the HTTP cache, e.g. to keep an audit trail of the pages the scraped data came from.
+// A response is archived after its OnScraped callbacks if it was marked by Response.Archive,
+// or it is allowed by the filter, or the decision function accepts it.
+type BodyArchiver struct {
+	sink   CaptureStorage
+	filter *Filter
+	decide ArchiveDecision
+	lock   *sync.RWMutex
+}
+
+// archiveHaser is implemented by the blob stores that can tell if a body is already stored.
+type archiveHaser interface {
+	Has(key string) bool
+}
+
+// ------------------------------------------------------------------------
+
+// Archive key prefixes
+const (
+	ARCHIVE_BODY_PREFIX = "archive:body:" // Prefix of the archived bodies, followed by the hash of the body.
+	ARCHIVE_META_PREFIX = "archive:meta:" // Prefix of the JSON encoded metadata, followed by the archiving time and the request ID.
+)
+
+// ------------------------------------------------------------------------
+
+// NewBodyArchiver returns a pointer to a newly created body archiver. The bodies are stored
+// once per content, keyed by their hash, if the storage implements the Has method like the cache storages.
+// If neither a filter nor a decision function is given, only the responses marked by Response.Archive are archived.
+func NewBodyArchiver(sink CaptureStorage, filter *Filter, decide ArchiveDecision) (*BodyArchiver, error) {
+	if sink == nil {
+		return nil, ErrArchiveNoStorage
+	}
+
+	return &BodyArchiver{
+		sink:   sink,
+		filter: filter,
+		decide: decide,
+		lock:   &sync.RWMutex{},
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// SetFilter sets the filter of the archived responses. Nil removes the filter.
+func (a *BodyArchiver) SetFilter(filter *Filter) {
+	a.lock.Lock()
+	a.filter = filter
+	a.lock.Unlock()
+}
+
+// SetDecision sets the decision function of the archived responses. Nil removes the function.
+func (a *BodyArchiver) SetDecision(decide ArchiveDecision) {
+	a.lock.Lock()
+	a.decide = decide
+	a.lock.Unlock()
+}
+
+// ------------------------------------------------------------------------
+
+// Archive marks the response to be archived by the body archiver of the collector after
+// the OnScraped callbacks, e.g. when the callbacks extracted data from the page.
+func (r *Response) Archive() {
+	r.archive = true
+}
+
+// ------------------------------------------------------------------------
+
+// selected returns true if the body of the response should be archived.
+func (a *BodyArchiver) selected(resp *Response) bool {
+	if resp.archive {
+		return true
+	}
+
+	a.lock.RLock()
+	filter, decide := a.filter, a.decide
+	a.lock.RUnlock()
+
+	if filter != nil && filter.Match(resp.Request) == nil {
+		return true
+	}
+
+	return decide != nil && decide(resp)
+}
+
+// archive stores the body and the metadata of the response, if it is selected.
+// The bodies are stored after the content decoding and the character set conversion,
+// as the callbacks of the collector processed them.
+func (a *BodyArchiver) archive(resp *Response, configHash string) (*ArchiveEntry, error) {
+	if resp == nil || resp.Request == nil || resp.Request.Req == nil || resp.Body == nil || !a.selected(resp) {
+		return nil, nil
+	}
+
+	sum := sha256.Sum256(resp.Body)
+	e := &ArchiveEntry{
+		URL:        resp.Request.Req.URL.String(),
+		Method:     resp.Request.Req.Method,
+		Hash:       hex.EncodeToString(sum[:]),
+		Size:       len(resp.Body),
+		Fetched:    resp.Created,
+		Archived:   time.Now().UTC(),
+		RequestID:  resp.Request.ID,
+		ConfigHash: configHash,
+	}
+	if resp.Resp != nil {
+		e.StatusCode = resp.Resp.StatusCode
+		e.ContentType = resp.Resp.Header.Get("Content-Type")
+	}
+
+	bodyKey := ARCHIVE_BODY_PREFIX + e.Hash
+	if h, ok := a.sink.(archiveHaser); !ok || !h.Has(bodyKey) {
+		if err := a.sink.Put(bodyKey, bytes.NewReader(resp.Body)); err != nil {
+			return nil, err
+		}
+	}
+
+	meta, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%s%s-%d", ARCHIVE_META_PREFIX, e.Archived.Format("20060102T150405.000000000"), e.RequestID)
+	if err := a.sink.Put(key, bytes.NewReader(meta)); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// ------------------------------------------------------------------------
+
+// archiveBody archives the body of a scraped response by the body archiver of the collector, if any.
+func (c *Collector) archiveBody(resp *Response) {
+	a := c.Config.Archiver
+	if a == nil {
+		return
+	}
+
+	e, err := a.archive(resp, c.ConfigHash())
+	if err != nil {
+		c.Config.logError(LOG_WARN_LEVEL, err)
+		return
+	}
+
+	if e != nil && c.Config.logEnabled(LOG_DEBUG_LEVEL) {
+		c.logEvent(LOG_DEBUG_LEVEL, "archive", e.RequestID, map[string]string{
+			"url":  e.URL,
+			"hash": e.Hash,
+		})
+	}
+}
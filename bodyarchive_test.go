@@ -0,0 +1,73 @@
+package colly
+
+import (
+	"colly/storage/mem"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestBodyArchiver_archive(t *testing.T) {
+	if _, err := NewBodyArchiver(nil, nil, nil); !errors.Is(err, ErrArchiveNoStorage) {
+		t.Fatalf("NewBodyArchiver(nil) error = %v, want %v", err, ErrArchiveNoStorage)
+	}
+
+	stg := mem.NewCacheStorage()
+	a, _ := NewBodyArchiver(stg, nil, func(resp *Response) bool {
+		return strings.HasSuffix(resp.Request.Req.URL.Path, ".pdf")
+	})
+
+	newResponse := func(u string, body string) *Response {
+		return &Response{
+			Request: &Request{ID: 5, Req: httptest.NewRequest("GET", u, nil)},
+			Resp:    &http.Response{StatusCode: 200, Header: http.Header{"Content-Type": {"text/html"}}},
+			Body:    []byte(body),
+		}
+	}
+
+	skipped := newResponse("https://example.com/page", "<p>skipped</p>")
+	if e, err := a.archive(skipped, "cfg"); e != nil || err != nil {
+		t.Errorf("archive() of an unselected response = %+v, %v, want nil", e, err)
+	}
+
+	marked := newResponse("https://example.com/page", "<p>data</p>")
+	marked.Archive()
+	e, err := a.archive(marked, "cfg")
+	if err != nil || e == nil {
+		t.Fatalf("archive() of a marked response = %+v, %v", e, err)
+	}
+	if e.URL != "https://example.com/page" || e.StatusCode != 200 || e.ContentType != "text/html" || e.Size != 11 || e.ConfigHash != "cfg" {
+		t.Errorf("archive() = %+v", e)
+	}
+
+	rdr, err := stg.Fetch(ARCHIVE_BODY_PREFIX + e.Hash)
+	if err != nil || rdr == nil {
+		t.Fatalf("archived body not found: %v", err)
+	}
+	if body, _ := io.ReadAll(rdr); string(body) != "<p>data</p>" {
+		t.Errorf("archived body = %q", body)
+	}
+
+	decided, err := a.archive(newResponse("https://example.com/report.pdf", "<p>data</p>"), "cfg")
+	if err != nil || decided == nil {
+		t.Fatalf("archive() of a response accepted by the decision = %+v, %v", decided, err)
+	}
+	if decided.Hash != e.Hash {
+		t.Errorf("hash of the same body = %s, want %s", decided.Hash, e.Hash)
+	}
+
+	rdr, _ = stg.Fetch(ARCHIVE_META_PREFIX + decided.Archived.Format("20060102T150405.000000000") + "-5")
+	if rdr == nil {
+		t.Fatal("archived metadata not found")
+	}
+	meta := &ArchiveEntry{}
+	if err := json.NewDecoder(rdr).Decode(meta); err != nil || meta.URL != "https://example.com/report.pdf" {
+		t.Errorf("archived metadata = %+v, %v", meta, err)
+	}
+}
@@ -0,0 +1,247 @@
+package colly
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// EventBus is an in-process publish/subscribe bus of the internal signals of a collector,
+// e.g. the rate limit waits, the received responses or the detected spider traps.
+// The core modules publish their signals on the bus instead of calling each other,
+// so user modules can observe them too. See Collector.Bus and the TOPIC_* names.
+type EventBus struct {
+	subs    []*Subscription
+	lock    *sync.RWMutex
+	dropped atomic.Uint64 // number of the messages dropped by the full queues of all subscriptions
+}
+
+// BusMessage is a signal published on the event bus.
+type BusMessage struct {
+	Topic   string    `json:"topic" bson:"topic"`               // Topic is the name of the signal, one of the TOPIC_* names or a custom one.
+	Time    time.Time `json:"time" bson:"time"`                 // Time is the date and time when the signal was published.
+	Payload any       `json:"payload" bson:"payload,omitempty"` // Payload is the typed data of the signal, e.g. a ResponseSignal.
+}
+
+// Subscription is a subscription to the topics of the event bus.
+type Subscription struct {
+	bus     *EventBus
+	topics  []string         // topic names, the ones ending with an asterisk match by prefix
+	queue   chan BusMessage  // bounded message queue, nil if the messages are handled synchronously
+	handler func(BusMessage) // synchronous handler, nil if the messages are queued
+	dropped atomic.Uint64    // number of the messages dropped because the queue was full
+	closed  bool
+}
+
+// RequestSignal is the payload of the signals of a single request.
+type RequestSignal struct {
+	RequestID uint32 `json:"request_id" bson:"request_id"` // RequestID identifies the request.
+	URL       string `json:"url" bson:"url"`               // URL is the URL of the request.
+}
+
+// ResponseSignal is the payload of the TOPIC_RESPONSE signal.
+type ResponseSignal struct {
+	RequestSignal `bson:",inline"`
+	StatusCode    int  `json:"status_code" bson:"status_code"`         // StatusCode is the HTTP status code of the response.
+	Size          int  `json:"size" bson:"size"`                       // Size is the size of the response body.
+	FromCache     bool `json:"from_cache" bson:"from_cache,omitempty"` // FromCache is true if the response was served from the cache.
+}
+
+// TrapSignal is the payload of the TOPIC_TRAP signal.
+type TrapSignal struct {
+	RequestSignal `bson:",inline"`
+	Host          string `json:"host" bson:"host"`           // Host is the host of the trapped request.
+	Identical     uint   `json:"identical" bson:"identical"` // Identical is the number of the identical responses in a row.
+}
+
+// RejectSignal is the payload of the TOPIC_REJECTED signal.
+type RejectSignal struct {
+	RequestSignal `bson:",inline"`
+	Error         string `json:"error" bson:"error"` // Error is the reason of the rejection.
+}
+
+// RateLimitSignal is the payload of the TOPIC_RATE_LIMIT signal.
+type RateLimitSignal struct {
+	RequestSignal `bson:",inline"`
+	Key           string        `json:"key" bson:"key"`     // Key is the rate limit bucket of the request.
+	Delay         time.Duration `json:"delay" bson:"delay"` // Delay is the delay of the bucket.
+	Wait          time.Duration `json:"wait" bson:"wait"`   // Wait is the time the request waited for the rate limit.
+}
+
+// ------------------------------------------------------------------------
+
+// Topics of the signals published by the collector
+const (
+	TOPIC_RESPONSE   = "response"   // A response was received, ResponseSignal.
+	TOPIC_SCRAPED    = "scraped"    // A response was completely processed, RequestSignal.
+	TOPIC_TRUNCATED  = "truncated"  // A truncated response was received, RequestSignal.
+	TOPIC_STUCK      = "stuck"      // A request was cancelled by the watchdog, RequestSignal.
+	TOPIC_TRAP       = "trap"       // A response was detected in a spider trap, TrapSignal.
+	TOPIC_RATE_LIMIT = "rate_limit" // A request passed the rate limit, RateLimitSignal.
+	TOPIC_REJECTED   = "rejected"   // A request was rejected by the depth limit, the filters or robots.txt, RejectSignal.
+	TOPIC_BACKOFF    = "backoff"    // A failing host started a backoff, HostHealth.
+
+	DEFAULT_BUS_QUEUE_SIZE = 64 // DEFAULT_BUS_QUEUE_SIZE is the queue size of the subscriptions without a size.
+)
+
+// ------------------------------------------------------------------------
+
+// NewEventBus returns a pointer to a newly created event bus.
+func NewEventBus() *EventBus {
+	return &EventBus{
+		lock: &sync.RWMutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Bus returns the event bus of the collector.
+func (c *Collector) Bus() *EventBus {
+	return c.bus
+}
+
+// ------------------------------------------------------------------------
+
+// Subscribe returns a subscription queueing the messages of the topics in a queue of the given size,
+// to be read from the channel returned by Subscription.C. The messages are dropped when the queue is full,
+// so a slow subscriber never blocks the crawl. Topics ending with an asterisk match by prefix,
+// no topic or "*" subscribes to all topics.
+func (b *EventBus) Subscribe(size int, topics ...string) *Subscription {
+	if size <= 0 {
+		size = DEFAULT_BUS_QUEUE_SIZE
+	}
+
+	return b.add(&Subscription{
+		topics: topics,
+		queue:  make(chan BusMessage, size),
+	})
+}
+
+// Handle returns a subscription calling the handler with the messages of the topics, see Subscribe.
+// The handler runs synchronously in the goroutine of the publisher, so it must be quick.
+func (b *EventBus) Handle(fn func(msg BusMessage), topics ...string) *Subscription {
+	return b.add(&Subscription{
+		topics:  topics,
+		handler: fn,
+	})
+}
+
+// HandleTyped returns a subscription calling the handler with the payloads of type T of the topics, see Handle.
+// The messages with other payload types are ignored.
+func HandleTyped[T any](b *EventBus, fn func(payload T), topics ...string) *Subscription {
+	return b.Handle(func(msg BusMessage) {
+		if payload, ok := msg.Payload.(T); ok {
+			fn(payload)
+		}
+	}, topics...)
+}
+
+// add registers a subscription on the bus.
+func (b *EventBus) add(s *Subscription) *Subscription {
+	s.bus = b
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.subs = append(b.subs, s)
+
+	return s
+}
+
+// ------------------------------------------------------------------------
+
+// Publish sends a message with the payload to the subscribers of the topic.
+// The synchronous handlers are called before Publish returns, the full queues drop the message.
+// The handlers are called without holding the lock of the bus, so they can publish and subscribe too.
+func (b *EventBus) Publish(topic string, payload any) {
+	if b == nil {
+		return
+	}
+
+	msg := BusMessage{
+		Topic:   topic,
+		Time:    time.Now(),
+		Payload: payload,
+	}
+
+	// The queues are filled under the lock, so Unsubscribe can't close them meanwhile
+	var handlers []func(BusMessage)
+	b.lock.RLock()
+	for _, s := range b.subs {
+		if !s.matches(topic) {
+			continue
+		}
+		if s.handler != nil {
+			handlers = append(handlers, s.handler)
+			continue
+		}
+		select {
+		case s.queue <- msg:
+		default:
+			s.dropped.Add(1)
+			b.dropped.Add(1)
+		}
+	}
+	b.lock.RUnlock()
+
+	for _, fn := range handlers {
+		fn(msg)
+	}
+}
+
+// Dropped returns the number of the messages dropped by the full queues of all subscriptions of the bus.
+func (b *EventBus) Dropped() uint64 {
+	return b.dropped.Load()
+}
+
+// ------------------------------------------------------------------------
+
+// C returns the message channel of a queued subscription, nil for a handler subscription.
+// The channel is closed by Unsubscribe.
+func (s *Subscription) C() <-chan BusMessage {
+	return s.queue
+}
+
+// Dropped returns the number of the messages dropped because the queue was full.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe removes the subscription from the bus and closes its channel.
+func (s *Subscription) Unsubscribe() {
+	b := s.bus
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+
+	for i, sub := range b.subs {
+		if sub == s {
+			b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+			break
+		}
+	}
+	if s.queue != nil {
+		close(s.queue)
+	}
+}
+
+// signal returns the payload of the signals of the request.
+func (r *Request) signal() RequestSignal {
+	if r == nil || r.Req == nil {
+		return RequestSignal{}
+	}
+
+	return RequestSignal{RequestID: r.ID, URL: r.Req.URL.String()}
+}
+
+// matches returns true if the subscription listens to the topic.
+func (s *Subscription) matches(topic string) bool {
+	return len(s.topics) == 0 || matchParam(topic, s.topics)
+}
@@ -0,0 +1,128 @@
+package colly
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestEventBus_Publish(t *testing.T) {
+	b := NewEventBus()
+
+	all := b.Subscribe(0)
+	limited := b.Subscribe(2, "rate_*")
+
+	var traps []TrapSignal
+	HandleTyped(b, func(p TrapSignal) { traps = append(traps, p) }, TOPIC_TRAP, TOPIC_RESPONSE)
+
+	b.Publish(TOPIC_TRAP, TrapSignal{Host: "example.com", Identical: 3})
+	b.Publish(TOPIC_RESPONSE, ResponseSignal{Size: 10})
+	for i := 0; i < 3; i++ {
+		b.Publish(TOPIC_RATE_LIMIT, RateLimitSignal{Key: "example.com"})
+	}
+
+	if len(traps) != 1 || traps[0].Identical != 3 {
+		t.Errorf("typed handler payloads = %v, want one trap", traps)
+	}
+	if got := len(all.C()); got != 5 {
+		t.Errorf("len(all) = %d, want 5", got)
+	}
+	if got := len(limited.C()); got != 2 || limited.Dropped() != 1 {
+		t.Errorf("len(limited) = %d, Dropped() = %d, want 2, 1", got, limited.Dropped())
+	}
+	if b.Dropped() != 1 {
+		t.Errorf("EventBus.Dropped() = %d, want 1", b.Dropped())
+	}
+	if msg := <-limited.C(); msg.Topic != TOPIC_RATE_LIMIT || msg.Payload.(RateLimitSignal).Key != "example.com" {
+		t.Errorf("message = %+v, want a rate limit signal", msg)
+	}
+
+	limited.Unsubscribe()
+	limited.Unsubscribe()
+	b.Publish(TOPIC_RATE_LIMIT, RateLimitSignal{})
+	<-limited.C()
+	if _, open := <-limited.C(); open {
+		t.Error("channel of the removed subscription is open")
+	}
+	if got := len(all.C()); got != 6 {
+		t.Errorf("len(all) = %d, want 6", got)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestEventBus_Publish_reentrant(t *testing.T) {
+	b := NewEventBus()
+
+	// The handlers can publish, subscribe and unsubscribe without a deadlock
+	var got []string
+	var sub *Subscription
+	sub = b.Handle(func(msg BusMessage) {
+		got = append(got, msg.Topic)
+		b.Publish("second", nil)
+		b.Handle(func(msg BusMessage) { got = append(got, "new "+msg.Topic) }, "second")
+		sub.Unsubscribe()
+	}, "first")
+
+	done := make(chan struct{})
+	go func() {
+		b.Publish("first", nil)
+		b.Publish("second", nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish() is deadlocked by a handler")
+	}
+
+	if want := []string{"first", "new second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("handled messages = %v, want %v", got, want)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_Bus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	c := NewCollector(nil, nil)
+	c.Config.LimitKeyCallback = func(r *Request) string { return "bucket" }
+
+	sub := c.Bus().Subscribe(0, TOPIC_RESPONSE, TOPIC_RATE_LIMIT, TOPIC_SCRAPED)
+
+	if err := c.Visit(srv.URL + "/"); err != nil {
+		t.Fatal(err)
+	}
+	c.Wait()
+
+	var topics []string
+	for len(sub.C()) > 0 {
+		msg := <-sub.C()
+		topics = append(topics, msg.Topic)
+		switch p := msg.Payload.(type) {
+		case RateLimitSignal:
+			if p.Key != "bucket" || p.URL != srv.URL+"/" {
+				t.Errorf("RateLimitSignal = %+v, want bucket %s", p, srv.URL)
+			}
+		case ResponseSignal:
+			if p.Size != 5 || p.StatusCode != http.StatusOK {
+				t.Errorf("ResponseSignal = %+v, want 200 with 5 bytes", p)
+			}
+		}
+	}
+
+	if want := []string{TOPIC_RATE_LIMIT, TOPIC_RESPONSE, TOPIC_SCRAPED}; !reflect.DeepEqual(topics, want) {
+		t.Errorf("topics = %v, want %v", topics, want)
+	}
+	if got := c.Stats(); got.Responses != 1 || got.Scraped != 1 || got.Bytes != 5 {
+		t.Errorf("Stats() = %+v, want 1 response of 5 bytes, 1 scraped", got)
+	}
+}
@@ -2,16 +2,39 @@ package colly
 
 import (
 	"bytes"
+	"colly/storage"
 	"crypto/sha1"
 	"encoding/gob"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"sync"
 	"time"
 )
 
 // ------------------------------------------------------------------------
 
+// DefaultCacheHeaders is the header allow-list of the compact cache profile.
+var DefaultCacheHeaders = []string{
+	"Age",
+	"Cache-Control",
+	"Content-Disposition",
+	"Content-Language",
+	"Content-Type",
+	"Etag",
+	"Expires",
+	"Last-Modified",
+	"Link",
+	"Location",
+}
+
+// compactCacheMagic identifies the cache items stored with the compact profile.
+var compactCacheMagic = []byte("CCP1")
+
+// ------------------------------------------------------------------------
+
 // Cache is a collection of functions to managed cached HTTP reponses.
 type Cache interface {
 	Set(*Response) error               // Set writes a response to the cache.
@@ -35,8 +58,27 @@ type CacheStorage interface {
 }
 
 type cache struct {
-	stg CacheStorage       // Data storage
-	exp CacheExpiryHandler // Item expiry handler
+	stg     CacheStorage       // Data storage
+	exp     CacheExpiryHandler // Item expiry handler
+	headers []string           // Header allow-list of the compact profile, nil stores the full response
+	codec   CacheCodec         // Payload compression, nil stores uncompressed payloads
+	blobs   CacheStorage       // Content-addressed body store, nil keeps the bodies in the items
+
+	blobLock *sync.Mutex // guards the blob store and the reference counters
+}
+
+// compactCacheItem is the cached form of a response using the compact profile.
+type compactCacheItem struct {
+	Method        string
+	URL           string
+	Proto         string
+	Status        string
+	StatusCode    int
+	ExtStatusCode uint
+	Header        http.Header
+	Body          []byte
+	Created       time.Time
+	Expiry        time.Time
 }
 
 // cacheExpByHeader checks the expiry by the page header
@@ -68,8 +110,9 @@ func NewCache(cs CacheStorage, exp CacheExpiryHandler) (*cache, error) {
 	}
 
 	c := &cache{
-		stg: cs,
-		exp: exp,
+		stg:      cs,
+		exp:      exp,
+		blobLock: &sync.Mutex{},
 	}
 
 	return c, nil
@@ -77,26 +120,65 @@ func NewCache(cs CacheStorage, exp CacheExpiryHandler) (*cache, error) {
 
 // ------------------------------------------------------------------------
 
+// SetHeaderAllowList switches the cache to the compact profile that stores only the
+// status, the body and the listed response headers instead of the full response.
+// If no header given, it will use DefaultCacheHeaders.
+// Items stored with either profile can be read after switching.
+func (c *cache) SetHeaderAllowList(headers ...string) {
+	if len(headers) == 0 {
+		headers = DefaultCacheHeaders
+	}
+
+	c.headers = make([]string, len(headers))
+	for i, h := range headers {
+		c.headers[i] = http.CanonicalHeaderKey(h)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// SetCodec sets the codec to compress the cached payloads, nil turns off the compression.
+// Items stored with any registered codec or without compression can be read after switching.
+func (c *cache) SetCodec(codec CacheCodec) {
+	c.codec = codec
+}
+
+// ------------------------------------------------------------------------
+
 // Set writes a response to the cache.
 func (c *cache) Set(resp *Response) error {
 	url := resp.Request.Req.URL.String()
 	key := c.keyFromURL(url)
 
+	c.blobLock.Lock()
+	dedup := c.blobs != nil && len(resp.Body) > 0
+	c.blobLock.Unlock()
+	if dedup {
+		return c.putWithBlob(key, resp)
+	}
+
 	data, err := c.encodeResponse(resp)
 	if err != nil {
 		return err
 	}
 
-	return c.stg.Put(key, data)
+	payload, err := c.compress(data)
+	if err != nil {
+		return err
+	}
+
+	return c.stg.Put(key, payload)
 }
 
 // ------------------------------------------------------------------------
 
 // Get retrieves a cached response.
 func (c *cache) Get(url string) (*Response, error) {
-	key := c.keyFromURL(url)
-
-	data, err := c.stg.Fetch(key)
+	data, err := c.stg.Fetch(c.keyFromURL(url))
+	if err == nil && data == nil {
+		// Items stored before the keys were scoped by domain
+		data, err = c.stg.Fetch(c.legacyKeyFromURL(url))
+	}
 	if err != nil || data == nil {
 		return nil, err
 	}
@@ -117,35 +199,192 @@ func (c *cache) Get(url string) (*Response, error) {
 
 // Remove removes a cache item by key.
 func (c *cache) Remove(url string) error {
-	return c.stg.Remove(c.keyFromURL(url))
+	c.blobLock.Lock()
+	dedup := c.blobs != nil
+	c.blobLock.Unlock()
+
+	for _, key := range []string{c.keyFromURL(url), c.legacyKeyFromURL(url)} {
+		remove := c.stg.Remove
+		if dedup {
+			remove = c.removeWithBlob
+		}
+		if err := remove(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // ------------------------------------------------------------------------
 
 // RemoveAll removes all cache items.
 func (c *cache) RemoveAll() error {
+	c.blobLock.Lock()
+	defer c.blobLock.Unlock()
+
+	if c.blobs != nil && c.blobs != c.stg {
+		if err := c.blobs.Clear(); err != nil {
+			return err
+		}
+	}
+
 	return c.stg.Clear()
 }
 
 // ------------------------------------------------------------------------
 
-func (c *cache) keyFromURL(url string) string {
-	sum := sha1.Sum([]byte(url))
+// ------------------------------------------------------------------------
+
+// Storage returns the data storage of the cache.
+func (c *cache) Storage() CacheStorage {
+	return c.stg
+}
+
+// ------------------------------------------------------------------------
+
+// keyFromURL returns the storage key of the URL, scoped by its host name
+// so the items of a domain can be counted and purged at once.
+func (c *cache) keyFromURL(u string) string {
+	host := ""
+	if parsed, err := url.Parse(u); err == nil {
+		host = parsed.Hostname()
+	}
+
+	return storage.DomainKey(host, c.legacyKeyFromURL(u))
+}
+
+// legacyKeyFromURL returns the unscoped storage key of the URL.
+func (c *cache) legacyKeyFromURL(u string) string {
+	sum := sha1.Sum([]byte(u))
 	return hex.EncodeToString(sum[:])
 }
 
-func (c *cache) encodeResponse(resp *Response) (io.Reader, error) {
+func (c *cache) encodeResponse(resp *Response) (*bytes.Buffer, error) {
 	data := &bytes.Buffer{}
-	err := gob.NewEncoder(data).Encode(resp)
+
+	if c.headers == nil {
+		err := gob.NewEncoder(data).Encode(cacheableResponse(resp))
+
+		return data, err
+	}
+
+	item := &compactCacheItem{
+		Body:          resp.Body,
+		ExtStatusCode: resp.ExtStatusCode,
+		Created:       resp.Created,
+		Expiry:        resp.Expiry,
+		Header:        http.Header{},
+	}
+
+	if resp.Request != nil && resp.Request.Req != nil {
+		item.Method = resp.Request.Req.Method
+		item.URL = resp.Request.Req.URL.String()
+	}
+
+	if resp.Resp != nil {
+		item.Proto = resp.Resp.Proto
+		item.Status = resp.Resp.Status
+		item.StatusCode = resp.Resp.StatusCode
+		for _, key := range c.headers {
+			if val := resp.Resp.Header.Values(key); len(val) > 0 {
+				item.Header[key] = val
+			}
+		}
+	}
+
+	data.Write(compactCacheMagic)
+	err := gob.NewEncoder(data).Encode(item)
 
 	return data, err
 }
 
 func (c *cache) decodeData(data io.Reader) (*Response, error) {
-	resp := &Response{}
-	err := gob.NewDecoder(data).Decode(resp)
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if b, err = decompressCacheItem(b); err != nil {
+		return nil, err
+	}
+
+	// The body of the item is kept in the blob store
+	if hash, rest, ok := splitBlobItem(b); ok {
+		body, err := c.blobBody(hash)
+		if err != nil {
+			return nil, err
+		}
 
-	return resp, err
+		resp, err := c.decodeData(bytes.NewReader(rest))
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = body
+
+		return resp, nil
+	}
+
+	if !bytes.HasPrefix(b, compactCacheMagic) {
+		resp := &Response{}
+		err := gob.NewDecoder(bytes.NewReader(b)).Decode(resp)
+
+		return resp, err
+	}
+
+	item := &compactCacheItem{}
+	if err := gob.NewDecoder(bytes.NewReader(b[len(compactCacheMagic):])).Decode(item); err != nil {
+		return nil, err
+	}
+
+	return item.response()
+}
+
+// cacheableResponse returns a copy of the response without the live state of the request
+// and the connection, which can't be encoded. The cached responses get the request they serve.
+func cacheableResponse(resp *Response) *Response {
+	stored := *resp
+	stored.Request = nil
+
+	if resp.Resp != nil {
+		httpResp := *resp.Resp
+		httpResp.Body = nil
+		httpResp.Request = nil
+		httpResp.TLS = nil
+		stored.Resp = &httpResp
+	}
+
+	return &stored
+}
+
+// response rebuilds the response from the compact cache item.
+func (i *compactCacheItem) response() (*Response, error) {
+	URL, err := url.Parse(i.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &http.Request{
+		Method: i.Method,
+		URL:    URL,
+		Header: http.Header{},
+		Host:   URL.Host,
+	}
+
+	return &Response{
+		Request: &Request{Req: req},
+		Resp: &http.Response{
+			Status:     i.Status,
+			StatusCode: i.StatusCode,
+			Proto:      i.Proto,
+			Header:     i.Header,
+			Request:    req,
+		},
+		ExtStatusCode: i.ExtStatusCode,
+		Body:          i.Body,
+		Created:       i.Created,
+		Expiry:        i.Expiry,
+	}, nil
 }
 
 // ------------------------------------------------------------------------
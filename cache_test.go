@@ -0,0 +1,79 @@
+package colly
+
+import (
+	"colly/storage/mem"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_cache_SetHeaderAllowList(t *testing.T) {
+	created := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	u, _ := url.Parse("https://example.com/page?id=1")
+
+	resp := &Response{
+		Request: &Request{Req: &http.Request{Method: "GET", URL: u}},
+		Resp: &http.Response{
+			Status:     "200 OK",
+			StatusCode: 200,
+			Proto:      "HTTP/1.1",
+			Header: http.Header{
+				"Content-Type": {"text/html"},
+				"Etag":         {`"abc"`},
+				"Set-Cookie":   {"a=1", "b=2"},
+				"Date":         {"Sun, 02 Jan 2022 03:04:05 GMT"},
+			},
+		},
+		ExtStatusCode: 200,
+		Body:          []byte("<html></html>"),
+		Created:       created,
+		Expiry:        created.Add(time.Hour),
+	}
+
+	tests := []struct {
+		name    string
+		headers []string
+		want    http.Header
+	}{
+		{
+			name:    "default headers",
+			headers: nil,
+			want:    http.Header{"Content-Type": {"text/html"}, "Etag": {`"abc"`}},
+		},
+		{
+			name:    "custom headers",
+			headers: []string{"date", "set-cookie"},
+			want:    http.Header{"Date": {"Sun, 02 Jan 2022 03:04:05 GMT"}, "Set-Cookie": {"a=1", "b=2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := NewCache(mem.NewCacheStorage(), NewCacheExpiryNever())
+			c.SetHeaderAllowList(tt.headers...)
+
+			if err := c.Set(resp); err != nil {
+				t.Fatalf("cache.Set() error = %v", err)
+			}
+
+			got, err := c.Get(u.String())
+			if err != nil || got == nil {
+				t.Fatalf("cache.Get() = %v, error = %v", got, err)
+			}
+
+			if !reflect.DeepEqual(got.Resp.Header, tt.want) {
+				t.Errorf("cache.Get() header = %v, want %v", got.Resp.Header, tt.want)
+			}
+			if string(got.Body) != string(resp.Body) || got.Resp.StatusCode != 200 || got.Resp.Status != "200 OK" {
+				t.Errorf("cache.Get() = %d %q %q, want 200 %q %q", got.Resp.StatusCode, got.Resp.Status, got.Body, "200 OK", resp.Body)
+			}
+			if got.Request.Req.URL.String() != u.String() || !got.Created.Equal(created) || !got.Expiry.Equal(resp.Expiry) {
+				t.Errorf("cache.Get() = %v %v %v, want %v %v %v", got.Request.Req.URL, got.Created, got.Expiry, u, created, resp.Expiry)
+			}
+		})
+	}
+}
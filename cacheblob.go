@@ -0,0 +1,220 @@
+package colly
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strconv"
+)
+
+// ------------------------------------------------------------------------
+
+// cacheBlobMagic identifies the cache items whose body is kept in the blob store.
+// It is followed by the hex encoded SHA-256 hash of the body.
+var cacheBlobMagic = []byte("CCB1")
+
+// Blob store key prefixes
+const (
+	CACHE_BLOB_PREFIX = "blob:"    // Prefix of the response bodies in the blob store.
+	CACHE_REFS_PREFIX = "blobref:" // Prefix of the reference counters in the blob store.
+)
+
+// cacheBlobHashLen is the length of the hex encoded body hash.
+const cacheBlobHashLen = 2 * sha256.Size
+
+// ------------------------------------------------------------------------
+
+// SetBlobStore turns on the deduplication of the cached bodies. Every distinct body is stored
+// once in the blob store keyed by its hash, and the cache items reference the hash.
+// The bodies are reference counted and removed with the last item referencing them.
+// The blob store may be the storage of the cache, nil turns off the deduplication.
+// Items stored with or without deduplication can be read after switching.
+func (c *cache) SetBlobStore(stg CacheStorage) {
+	c.blobLock.Lock()
+	c.blobs = stg
+	c.blobLock.Unlock()
+}
+
+// ------------------------------------------------------------------------
+
+// putWithBlob stores the body of the response in the blob store and the rest of the
+// response in the cache storage, replacing the item of the key.
+func (c *cache) putWithBlob(key string, resp *Response) error {
+	stripped := *resp
+	stripped.Body = nil
+
+	data, err := c.encodeResponse(&stripped)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(resp.Body)
+	hash := hex.EncodeToString(sum[:])
+
+	item := &bytes.Buffer{}
+	item.Write(cacheBlobMagic)
+	item.WriteString(hash)
+	item.Write(data.Bytes())
+
+	payload, err := c.compress(item)
+	if err != nil {
+		return err
+	}
+
+	c.blobLock.Lock()
+	defer c.blobLock.Unlock()
+
+	// Reference the new body before releasing the old one, they may be the same
+	if err := c.acquireBlob(hash, resp.Body); err != nil {
+		return err
+	}
+	if err := c.releaseItem(key); err != nil {
+		return err
+	}
+
+	return c.stg.Put(key, payload)
+}
+
+// removeWithBlob removes the cache item of the key and releases its body.
+func (c *cache) removeWithBlob(key string) error {
+	c.blobLock.Lock()
+	defer c.blobLock.Unlock()
+
+	if err := c.releaseItem(key); err != nil {
+		return err
+	}
+
+	return c.stg.Remove(key)
+}
+
+// ------------------------------------------------------------------------
+
+// acquireBlob stores the body if it is new and increments its reference counter.
+func (c *cache) acquireBlob(hash string, body []byte) error {
+	refs, err := c.blobRefs(hash)
+	if err != nil {
+		return err
+	}
+
+	if refs == 0 {
+		payload, err := c.compress(bytes.NewBuffer(body))
+		if err != nil {
+			return err
+		}
+		if err := c.blobs.Put(CACHE_BLOB_PREFIX+hash, payload); err != nil {
+			return err
+		}
+	}
+
+	return c.blobs.Put(CACHE_REFS_PREFIX+hash, bytes.NewBufferString(strconv.FormatUint(refs+1, 10)))
+}
+
+// releaseItem releases the body referenced by the cache item of the key, if any.
+func (c *cache) releaseItem(key string) error {
+	data, err := c.stg.Fetch(key)
+	if err != nil || data == nil {
+		return nil
+	}
+
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	if b, err = decompressCacheItem(b); err != nil {
+		return nil
+	}
+
+	hash, _, ok := splitBlobItem(b)
+	if !ok {
+		return nil
+	}
+
+	refs, err := c.blobRefs(hash)
+	if err != nil {
+		return err
+	}
+	if refs > 1 {
+		return c.blobs.Put(CACHE_REFS_PREFIX+hash, bytes.NewBufferString(strconv.FormatUint(refs-1, 10)))
+	}
+
+	if err := c.blobs.Remove(CACHE_BLOB_PREFIX + hash); err != nil {
+		return err
+	}
+
+	return c.blobs.Remove(CACHE_REFS_PREFIX + hash)
+}
+
+// blobRefs returns the reference counter of the body.
+func (c *cache) blobRefs(hash string) (uint64, error) {
+	data, err := c.blobs.Fetch(CACHE_REFS_PREFIX + hash)
+	if err != nil || data == nil {
+		return 0, nil
+	}
+
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return 0, err
+	}
+
+	refs, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0, nil
+	}
+
+	return refs, nil
+}
+
+// blobBody returns the body of the hash from the blob store.
+func (c *cache) blobBody(hash string) ([]byte, error) {
+	c.blobLock.Lock()
+	blobs := c.blobs
+	c.blobLock.Unlock()
+
+	if blobs == nil {
+		return nil, ErrCacheNoBlobStore
+	}
+
+	data, err := blobs.Fetch(CACHE_BLOB_PREFIX + hash)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrCacheBlobMissing
+	}
+
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return decompressCacheItem(b)
+}
+
+// ------------------------------------------------------------------------
+
+// compress returns the payload compressed by the codec of the cache, if any.
+func (c *cache) compress(data *bytes.Buffer) (io.Reader, error) {
+	if c.codec == nil {
+		return data, nil
+	}
+
+	b, err := compressCacheItem(c.codec, data.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewBuffer(b), nil
+}
+
+// splitBlobItem returns the body hash and the encoded response of a cache item
+// referencing a blob, or false if the item keeps its body.
+func splitBlobItem(b []byte) (string, []byte, bool) {
+	if !bytes.HasPrefix(b, cacheBlobMagic) || len(b) < len(cacheBlobMagic)+cacheBlobHashLen {
+		return "", b, false
+	}
+
+	b = b[len(cacheBlobMagic):]
+
+	return string(b[:cacheBlobHashLen]), b[cacheBlobHashLen:], true
+}
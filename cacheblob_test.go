@@ -0,0 +1,79 @@
+package colly
+
+import (
+	"colly/storage/mem"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_cache_SetBlobStore(t *testing.T) {
+	newResp := func(rawURL string, body string) *Response {
+		u, _ := url.Parse(rawURL)
+		return &Response{
+			Request: &Request{Req: &http.Request{Method: "GET", URL: u}},
+			Resp:    &http.Response{StatusCode: 200, Header: http.Header{}},
+			Body:    []byte(body),
+		}
+	}
+
+	for _, codec := range []CacheCodec{nil, CacheCodecByName(CACHE_CODEC_GZIP)} {
+		blobs := mem.NewCacheStorage()
+		c, _ := NewCache(mem.NewCacheStorage(), NewCacheExpiryNever())
+		c.SetCodec(codec)
+		c.SetBlobStore(blobs)
+
+		for _, u := range []string{"https://example.com/a", "https://example.com/b"} {
+			if err := c.Set(newResp(u, "<html>shared</html>")); err != nil {
+				t.Fatalf("cache.Set() error = %v", err)
+			}
+		}
+		// Replacing an item releases its old body
+		if err := c.Set(newResp("https://example.com/c", "<html>old</html>")); err != nil {
+			t.Fatalf("cache.Set() error = %v", err)
+		}
+		if err := c.Set(newResp("https://example.com/c", "<html>shared</html>")); err != nil {
+			t.Fatalf("cache.Set() error = %v", err)
+		}
+
+		if n, _ := blobs.Len(); n != 2 {
+			t.Errorf("blob store has %d entries, want a body and its counter", n)
+		}
+		if refs, _ := c.blobRefs(blobHash("<html>shared</html>")); refs != 3 {
+			t.Errorf("blobRefs() = %d, want 3", refs)
+		}
+
+		resp, err := c.Get("https://example.com/b")
+		if err != nil || resp == nil {
+			t.Fatalf("cache.Get() = %v, %v", resp, err)
+		}
+		if string(resp.Body) != "<html>shared</html>" || resp.Resp.StatusCode != 200 {
+			t.Errorf("cache.Get() body = %q, status = %d", resp.Body, resp.Resp.StatusCode)
+		}
+
+		for _, u := range []string{"https://example.com/a", "https://example.com/b"} {
+			if err := c.Remove(u); err != nil {
+				t.Fatalf("cache.Remove() error = %v", err)
+			}
+		}
+		if n, _ := blobs.Len(); n != 2 {
+			t.Errorf("blob store has %d entries, want the body still referenced", n)
+		}
+
+		if err := c.Remove("https://example.com/c"); err != nil {
+			t.Fatalf("cache.Remove() error = %v", err)
+		}
+		if n, _ := blobs.Len(); n != 0 {
+			t.Errorf("blob store has %d entries after removing the last reference, want 0", n)
+		}
+	}
+}
+
+func blobHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
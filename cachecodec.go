@@ -0,0 +1,199 @@
+package colly
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ------------------------------------------------------------------------
+
+// CacheCodec compresses the cached payloads.
+type CacheCodec interface {
+	Name() string                           // Name identifies the codec in the header of the cached payloads.
+	Compress(data []byte) ([]byte, error)   // Compress returns the compressed data.
+	Decompress(data []byte) ([]byte, error) // Decompress returns the original data.
+}
+
+// gzipCodec compresses the cached payloads with gzip
+type gzipCodec struct {
+	level int
+}
+
+// zstdCodec compresses the cached payloads with Zstandard
+type zstdCodec struct {
+	enc *zstd.Encoder
+	dec *zstd.Decoder
+}
+
+// ------------------------------------------------------------------------
+
+// Cache codec names
+const (
+	CACHE_CODEC_GZIP = "gzip"
+	CACHE_CODEC_ZSTD = "zstd"
+)
+
+// cacheCodecMagic identifies the compressed cache items.
+// It is followed by the length and the name of the codec.
+var cacheCodecMagic = []byte("CCZ1")
+
+// ErrUnknownCacheCodec is returned when a cache item was compressed by an unregistered codec.
+var ErrUnknownCacheCodec = errors.New("unknown cache codec")
+
+var (
+	cacheCodecs    = map[string]CacheCodec{}
+	cacheCodecLock = &sync.RWMutex{}
+)
+
+// ------------------------------------------------------------------------
+
+func init() {
+	RegisterCacheCodec(NewGzipCacheCodec(gzip.DefaultCompression))
+	if codec, err := NewZstdCacheCodec(); err == nil {
+		RegisterCacheCodec(codec)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// RegisterCacheCodec registers a codec to decompress the cached items.
+// Codecs are registered by their names, a codec replaces the previous one with the same name.
+func RegisterCacheCodec(codec CacheCodec) {
+	cacheCodecLock.Lock()
+	defer cacheCodecLock.Unlock()
+
+	cacheCodecs[codec.Name()] = codec
+}
+
+// CacheCodecByName returns a registered codec, or nil if the name is unknown.
+func CacheCodecByName(name string) CacheCodec {
+	cacheCodecLock.RLock()
+	defer cacheCodecLock.RUnlock()
+
+	return cacheCodecs[strings.TrimSpace(name)]
+}
+
+// ------------------------------------------------------------------------
+
+// NewGzipCacheCodec returns a gzip codec with the given compression level.
+func NewGzipCacheCodec(level int) *gzipCodec {
+	return &gzipCodec{level: level}
+}
+
+// Name implements the CacheCodec interface.
+func (c *gzipCodec) Name() string {
+	return CACHE_CODEC_GZIP
+}
+
+// Compress implements the CacheCodec interface.
+func (c *gzipCodec) Compress(data []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+
+	w, err := gzip.NewWriterLevel(buf, c.level)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decompress implements the CacheCodec interface.
+func (c *gzipCodec) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// ------------------------------------------------------------------------
+
+// NewZstdCacheCodec returns a Zstandard codec.
+func NewZstdCacheCodec() (*zstdCodec, error) {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &zstdCodec{enc: enc, dec: dec}, nil
+}
+
+// Name implements the CacheCodec interface.
+func (c *zstdCodec) Name() string {
+	return CACHE_CODEC_ZSTD
+}
+
+// Compress implements the CacheCodec interface.
+func (c *zstdCodec) Compress(data []byte) ([]byte, error) {
+	return c.enc.EncodeAll(data, nil), nil
+}
+
+// Decompress implements the CacheCodec interface.
+func (c *zstdCodec) Decompress(data []byte) ([]byte, error) {
+	return c.dec.DecodeAll(data, nil)
+}
+
+// ------------------------------------------------------------------------
+
+// compressCacheItem compresses the data and prepends the codec header.
+func compressCacheItem(codec CacheCodec, data []byte) ([]byte, error) {
+	name := codec.Name()
+	if len(name) == 0 || len(name) > 255 {
+		return nil, fmt.Errorf("invalid cache codec name %q", name)
+	}
+
+	compressed, err := codec.Compress(data)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 0, len(cacheCodecMagic)+1+len(name)+len(compressed))
+	b = append(b, cacheCodecMagic...)
+	b = append(b, byte(len(name)))
+	b = append(b, name...)
+
+	return append(b, compressed...), nil
+}
+
+// decompressCacheItem decompresses the data by the codec in the header.
+// Uncompressed legacy items are returned unchanged.
+func decompressCacheItem(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, cacheCodecMagic) {
+		return data, nil
+	}
+
+	data = data[len(cacheCodecMagic):]
+	if len(data) == 0 || len(data) < 1+int(data[0]) {
+		return nil, ErrUnknownCacheCodec
+	}
+
+	name := string(data[1 : 1+int(data[0])])
+	codec := CacheCodecByName(name)
+	if codec == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCacheCodec, name)
+	}
+
+	return codec.Decompress(data[1+len(name):])
+}
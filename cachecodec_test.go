@@ -0,0 +1,66 @@
+package colly
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCacheCodecs(t *testing.T) {
+	data := []byte(strings.Repeat("<html><body>cached page</body></html>", 100))
+
+	for _, name := range []string{CACHE_CODEC_GZIP, CACHE_CODEC_ZSTD} {
+		t.Run(name, func(t *testing.T) {
+			codec := CacheCodecByName(name)
+			if codec == nil {
+				t.Fatalf("CacheCodecByName(%q) = nil", name)
+			}
+
+			compressed, err := compressCacheItem(codec, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(compressed) >= len(data) {
+				t.Errorf("compressed size %d, want less than %d", len(compressed), len(data))
+			}
+
+			got, err := decompressCacheItem(compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("decompressCacheItem() returned different data")
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestDecompressCacheItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []byte
+		wantErr error
+	}{
+		{"legacy", []byte("gob data"), []byte("gob data"), nil},
+		{"legacy compact", []byte("CCP1gob data"), []byte("CCP1gob data"), nil},
+		{"unknown codec", append(append([]byte{}, cacheCodecMagic...), append([]byte{3}, "xyzdata"...)...), nil, ErrUnknownCacheCodec},
+		{"truncated header", append(append([]byte{}, cacheCodecMagic...), 9, 'g'), nil, ErrUnknownCacheCodec},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decompressCacheItem(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("decompressCacheItem() error = %v, want %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("decompressCacheItem() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package colly
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// CallbackContext returns the context of the running callback. It is cancelled when the
+// callback exceeded the CallbackTimeout of the configuration, so long running operations
+// of the callback, such as database writes, can be aborted.
+// Outside of the timed callbacks it returns the context of the HTTP request.
+func (r *Request) CallbackContext() context.Context {
+	if r.cbCtx != nil {
+		return r.cbCtx
+	}
+
+	if r.Req != nil {
+		return r.Req.Context()
+	}
+
+	return context.Background()
+}
+
+// ------------------------------------------------------------------------
+
+// runCallback calls a user callback of the event, measuring its execution time
+// if the configuration has a slow callback threshold or a callback timeout.
+func (c *Collector) runCallback(event uint8, r *Request, fn any, call func()) {
+	slow, timeout := c.Config.SlowCallback, c.Config.CallbackTimeout
+	if slow == 0 && timeout == 0 {
+		call()
+		return
+	}
+
+	var cancel context.CancelFunc
+	if timeout > 0 && r != nil {
+		parent := r.cbCtx
+		r.cbCtx, cancel = context.WithTimeout(r.CallbackContext(), timeout)
+		defer func() {
+			cancel()
+			r.cbCtx = parent
+		}()
+	}
+
+	start := time.Now()
+	call()
+	elapsed := time.Since(start)
+
+	switch {
+	case timeout > 0 && elapsed >= timeout:
+		c.logSlowCallback(LOG_ERR_LEVEL, "callback_timeout", event, r, fn, elapsed)
+	case slow > 0 && elapsed >= slow:
+		c.logSlowCallback(LOG_WARN_LEVEL, "slow_callback", event, r, fn, elapsed)
+	}
+}
+
+// logSlowCallback logs a callback exceeding the slow threshold or the timeout.
+func (c *Collector) logSlowCallback(level LogLevel, eventType string, event uint8, r *Request, fn any, elapsed time.Duration) {
+	if !c.HasLogger() {
+		return
+	}
+
+	var reqID uint32
+	args := map[string]string{
+		"event":    eventNames[event],
+		"site":     callbackSite(fn),
+		"duration": elapsed.String(),
+	}
+	if r != nil {
+		reqID = r.ID
+		if r.Req != nil && r.Req.URL != nil {
+			args["url"] = r.Req.URL.String()
+		}
+	}
+
+	c.logEvent(level, eventType, reqID, args)
+}
+
+// callbackSite returns the source location where the callback function was defined.
+func callbackSite(fn any) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return "unknown"
+	}
+
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return "unknown"
+	}
+
+	file, line := f.FileLine(v.Pointer())
+
+	return fmt.Sprintf("%s:%d (%s)", file, line, f.Name())
+}
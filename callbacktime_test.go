@@ -0,0 +1,81 @@
+package colly
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+type eventRecorder struct {
+	events []*LoggerEvent
+	lock   sync.Mutex
+}
+
+func (l *eventRecorder) LogEvent(_ LogLevel, e *LoggerEvent) {
+	l.lock.Lock()
+	l.events = append(l.events, e)
+	l.lock.Unlock()
+}
+
+func (l *eventRecorder) LogError(LogLevel, error) {}
+
+func (l *eventRecorder) find(eventType string) *LoggerEvent {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for _, e := range l.events {
+		if e.Type == eventType {
+			return e
+		}
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_runCallback(t *testing.T) {
+	logger := &eventRecorder{}
+	cfg := NewConfig()
+	cfg.Logger = logger
+	cfg.SlowCallback = 5 * time.Millisecond
+	cfg.CallbackTimeout = 20 * time.Millisecond
+	c := NewCollector(cfg, nil)
+
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	r := &Request{ID: 7, Req: req, collector: c}
+
+	slow := RequestCallback(func(*Request) { time.Sleep(10 * time.Millisecond) })
+	c.runCallback(ON_REQUEST, r, slow, func() { slow(r) })
+
+	e := logger.find("slow_callback")
+	if e == nil {
+		t.Fatal("runCallback() did not log the slow callback")
+	}
+	if e.RequestID != 7 || e.Values["event"] != "request" || !strings.Contains(e.Values["site"], "callbacktime_test.go") {
+		t.Errorf("slow_callback event = %+v", e)
+	}
+
+	cancelled := false
+	blocking := RequestCallback(func(r *Request) {
+		select {
+		case <-r.CallbackContext().Done():
+			cancelled = true
+		case <-time.After(time.Second):
+		}
+	})
+	c.runCallback(ON_REQUEST, r, blocking, func() { blocking(r) })
+
+	if !cancelled {
+		t.Error("the callback context was not cancelled after the timeout")
+	}
+	if logger.find("callback_timeout") == nil {
+		t.Error("runCallback() did not log the timeout")
+	}
+	if r.CallbackContext().Err() != nil {
+		t.Error("the callback context was not restored after the callback")
+	}
+}
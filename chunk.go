@@ -0,0 +1,94 @@
+package colly
+
+import (
+	"errors"
+	"io"
+)
+
+// ------------------------------------------------------------------------
+
+// BodyChunkCallback is a type alias for OnResponseBodyChunk callback functions.
+// It receives the response with the headers and the next decoded chunk of the body.
+// The chunk is only valid until the callback returns.
+type BodyChunkCallback func(*Response, []byte)
+
+// chunkReader calls the chunk handler for every chunk read from the underlying reader.
+type chunkReader struct {
+	rdr     io.Reader
+	handler func([]byte) bool // returns true if the download has to be aborted
+}
+
+// ------------------------------------------------------------------------
+
+// errBodyAborted stops reading the body when a chunk callback aborted the download.
+var errBodyAborted = errors.New("response body download aborted")
+
+// ------------------------------------------------------------------------
+
+// OnResponseBodyChunk is convenience method to register a function that will be executed
+// on every chunk of the response body while it is being downloaded.
+// The position identifies the execution order.
+// Call Request.Abort in the callback to stop the download, the response
+// will be processed with the truncated body and the Partial flag set.
+// Partial responses are not cached.
+func (c *Collector) OnResponseBodyChunk(fn BodyChunkCallback, position ...int) {
+	c.Callbacks.Add(ON_RESPONSE_CHUNK, NO_ARG, fn, position...)
+}
+
+// OnResponseBodyChunkDetach removes a number of registered body chunk callback functions.
+// If no position was given, all body chunk callback functions will be removed.
+func (c *Collector) OnResponseBodyChunkDetach(position ...int) {
+	c.Callbacks.Remove(ON_RESPONSE_CHUNK, NO_ARG, position...)
+}
+
+// handleOnResponseBodyChunk calls the chunk callbacks.
+// It returns true if the download was aborted by a callback.
+func (c *Collector) handleOnResponseBodyChunk(resp *Response, chunk []byte) bool {
+	for _, fn := range c.Callbacks.GetArg(ON_RESPONSE_CHUNK, NO_ARG) {
+		if callback, ok := fn.(BodyChunkCallback); ok {
+			c.runCallback(ON_RESPONSE_CHUNK, resp.Request, fn, func() { callback(resp, chunk) })
+		}
+	}
+
+	if !resp.Request.abort {
+		return false
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_INFO_LEVEL, "abort_body", resp.Request.ID, map[string]string{
+			"url": resp.Request.Req.URL.String(),
+		})
+	}
+
+	return true
+}
+
+// ------------------------------------------------------------------------
+
+// bodyReader returns the reader of the response body that feeds the chunk callbacks.
+func (r *Response) bodyReader(rdr io.Reader) io.Reader {
+	if r.Request == nil || r.Request.collector == nil || r.Request.collector.Callbacks.IsEmpty(ON_RESPONSE_CHUNK) {
+		return rdr
+	}
+
+	c := r.Request.collector
+
+	return &chunkReader{
+		rdr: rdr,
+		handler: func(chunk []byte) bool {
+			return c.handleOnResponseBodyChunk(r, chunk)
+		},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Read implements the io.Reader interface.
+func (cr *chunkReader) Read(p []byte) (int, error) {
+	n, err := cr.rdr.Read(p)
+	if n > 0 && cr.handler(p[:n]) {
+		return n, errBodyAborted
+	}
+
+	return n, err
+}
@@ -0,0 +1,73 @@
+package colly
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// ------------------------------------------------------------------------
+
+func TestChunkReader(t *testing.T) {
+	data := strings.Repeat("a", 100) + "STOP" + strings.Repeat("b", 100)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+		partial bool
+	}{
+		{"no abort", "", nil, false},
+		{"abort on token", "STOP", errBodyAborted, true},
+		{"token not found", "MISSING", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chunks int
+			seen := &bytes.Buffer{}
+			cr := &chunkReader{
+				rdr: iotest.OneByteReader(strings.NewReader(data)),
+				handler: func(chunk []byte) bool {
+					chunks++
+					seen.Write(chunk)
+					return tt.token != "" && strings.Contains(seen.String(), tt.token)
+				},
+			}
+
+			buf := &bytes.Buffer{}
+			_, err := buf.ReadFrom(cr)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadFrom() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.partial {
+				if want := data[:strings.Index(data, tt.token)+len(tt.token)]; buf.String() != want {
+					t.Errorf("partial body has %d bytes, want %d", buf.Len(), len(want))
+				}
+			} else if buf.String() != data {
+				t.Errorf("body has %d bytes, want %d", buf.Len(), len(data))
+			}
+
+			if chunks != buf.Len() {
+				t.Errorf("handler called %d times, want %d", chunks, buf.Len())
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestChunkReader_EOF(t *testing.T) {
+	cr := &chunkReader{
+		rdr:     strings.NewReader(""),
+		handler: func([]byte) bool { t.Error("handler called for empty body"); return true },
+	}
+
+	if n, err := cr.Read(make([]byte, 10)); n != 0 || err != io.EOF {
+		t.Errorf("Read() = %d, %v, want 0, EOF", n, err)
+	}
+}
@@ -1,9 +1,10 @@
 package colly
 
 import (
+	"context"
+	"fmt"
 	"math/rand"
 	"net/http"
-	"net/url"
 	"sync"
 	"time"
 )
@@ -24,12 +25,23 @@ type Client struct {
 	// Tracer attaches a tracing service to enable capturing and reporting request performance for crawler tuning.
 	Tracer `json:"tracer" bson:"tracer,omitempty"`
 
-	lock *sync.RWMutex
+	lock          *sync.RWMutex
+	noCompression map[string]bool               // hosts refusing compressed request bodies
+	sessions      map[string]*sessionClient     // session clients by host or identity
+	sessionTTL    time.Duration                 // idle time of the evicted session clients, 0 keeps them
+	sessionsSwept time.Time                     // time of the last eviction of the idle session clients
+	warmUpHosts   uint                          // number of the origins warmed up before the crawl
+	warmUpThreads uint                          // maximum number of concurrent warm-ups
+	limitKey      LimitKeyCallback              // rate limit bucket of the requests, nil uses the shared delay
+	limiter       *rateLimiter                  // rate limit buckets by key
+	negative      *negativeCache                // recent permanent failures, nil if disabled
+	downgraded    map[downgradeKey]*http.Client // clients of the protocol overrides, see ProtocolRules
+	http3         http.RoundTripper             // HTTP/3 transport of the protocol overrides, nil if disabled
 }
 
 // clientConfig is the internal representation of a specific client settings
 type clientConfig struct {
-	fc       *FilteredConfig
+	sc       *SubConfig
 	waitChan chan bool
 }
 
@@ -39,34 +51,57 @@ type hdrChecker func(req *http.Request, statusCode int, header http.Header) bool
 // ------------------------------------------------------------------------
 
 // NewClient returns a pointer to a newly created client.
-func NewClient(config *CollectorConfig) *Client {
+// The options tune the transport of the client, see ClientOption.
+func NewClient(config *CollectorConfig, opts ...ClientOption) *Client {
 	var configs []*clientConfig
 
-	for i := range config.FilteredConfigs {
+	for i := range config.SubConfigs {
 		configs = append(configs, &clientConfig{
-			fc:       config.FilteredConfigs[i],
+			sc:       config.SubConfigs[i],
 			waitChan: make(chan bool),
 		})
 	}
 
+	o := newClientOptions(config, opts)
+
+	var transport http.RoundTripper
+	if _, ok := o.transport.(*http.Transport); o.transport != nil && !ok {
+		// Custom round trippers are used as they are
+		transport = o.transport
+	} else {
+		base := config.aliasTransport(o.tune(config.tlsTransport(o.transport, o.tlsConfig)))
+		if order := config.headerOrder(); len(order) > 0 {
+			transport = NewHeaderOrderTransport(base, order)
+		} else if base != nil {
+			transport = base
+		}
+	}
+
 	return &Client{
 		DefConfig: &clientConfig{
-			fc: &FilteredConfig{
-				Filter:      config.Filter,
-				Delay:       config.Delay,
-				RandomDelay: config.RandomDelay,
-				MaxThreads:  config.MaxThreads,
-			},
+			sc:       config.mainConfig(),
 			waitChan: make(chan bool),
 		},
 		ConfigList: configs,
 		Clt: &http.Client{
-			Jar: config.CookieJar,
+			Transport:     transport,
+			Jar:           config.CookieJar,
+			CheckRedirect: redirectChecker(config.FollowRedirects, config.MaxRedirects),
 		},
-		Cache:  config.Cache,
-		Proxy:  config.Proxy,
-		Tracer: config.Tracer,
-		lock:   &sync.RWMutex{},
+		Cache:         config.Cache,
+		Proxy:         config.Proxy,
+		Tracer:        config.Tracer,
+		lock:          &sync.RWMutex{},
+		noCompression: map[string]bool{},
+		sessions:      map[string]*sessionClient{},
+		sessionTTL:    config.SessionIdleTimeout,
+		downgraded:    map[downgradeKey]*http.Client{},
+		http3:         config.HTTP3Transport,
+		warmUpHosts:   config.WarmUpHosts,
+		warmUpThreads: config.WarmUpThreads,
+		limitKey:      config.LimitKeyCallback,
+		limiter:       newRateLimiter(),
+		negative:      newNegativeCache(config.NegativeCacheTTL),
 	}
 }
 
@@ -75,36 +110,76 @@ func NewClient(config *CollectorConfig) *Client {
 // following policy (such as redirects, cookies, auth) as configured on the client.
 // If the response was a success, it also tries to cache the response.
 func (c *Client) Do(req *Request, bodySize int, checkHdrFunc hdrChecker) (*Response, error) {
-	useCache := req.Req.Method == "GET" && hdrVal(req.Req.Header, "Cache-Control") != "no-cache" && c.hasCache()
+	// The parts of the ranged downloads are not cached
+	useCache := req.Req.Method == "GET" && hdrVal(req.Req.Header, "Cache-Control") != "no-cache" && req.Req.Header.Get("Range") == "" && c.hasCache()
+
+	var stripParams []string
+	if req.collector != nil {
+		stripParams = req.collector.Config.StripParams
+	}
+
+	// Skip the URLs that failed permanently a short while ago
+	negKey := ""
+	if c.negative != nil && (req.Req.Method == "GET" || req.Req.Method == "HEAD") && hdrVal(req.Req.Header, "Cache-Control") != "no-cache" {
+		negKey = StripQueryParams(req.OriginalURL(), stripParams).String()
+		if reason, hit := c.negative.get(negKey, time.Now()); hit {
+			if req.collector != nil {
+				req.collector.negativeCacheHit(req, reason)
+			}
+			return nil, fmt.Errorf("%w: %s", ErrNegativeCacheHit, reason)
+		}
+	}
 
 	// Try to serve the response from cache
 	if useCache {
-		if resp, err := c.Cache.Get(req.Req.URL.String()); err == nil {
+		resp, err := c.Cache.Get(StripQueryParams(req.requestedURL(), stripParams).String())
+		if err != nil && req.collector != nil {
+			req.collector.handleOnStorageError(STORAGE_CACHE, err)
+		}
+		if err == nil && resp != nil {
+			// Cached responses flow through the same callbacks as the fresh ones
+			resp.Request = req
+			resp.FromCache = true
+			if resp.Resp != nil && !checkHdrFunc(req.Req, resp.Resp.StatusCode, resp.Resp.Header) {
+				return nil, ErrAbortedAfterHeaders
+			}
+
 			return resp, nil
 		}
 	}
 
 	resp, err := c.do(req, bodySize, checkHdrFunc)
-	if err != nil || resp.Resp.StatusCode >= 500 || !useCache {
+	if negKey != "" {
+		if reason := permanentFailure(resp, err); reason != "" {
+			c.negative.add(negKey, reason, time.Now())
+		}
+	}
+	if err != nil || resp.Resp.StatusCode >= 500 || resp.Partial || resp.Truncated || resp.NotModified || !useCache {
 		return resp, err
 	}
 
-	return resp, c.Cache.Set(resp)
+	err = c.Cache.Set(storedResponse(resp, stripParams))
+	if err != nil && req.collector != nil {
+		req.collector.handleOnStorageError(STORAGE_CACHE, err)
+	}
+
+	return resp, err
 }
 
 // ------------------------------------------------------------------------
 
 // Sleep pauses the execution for the duration in the client config,
-// or the default duration if the URL doesn't match any filter criteria.
-func (c *Client) Sleep(URL *url.URL) {
-	c.Match(URL).sleep()
+// or the default duration if the request doesn't match any filter criteria.
+// The pause ends early if the context of the request is done.
+func (c *Client) Sleep(req *Request) {
+	c.Match(req).sleep(req.Req.Context())
 }
 
 // ------------------------------------------------------------------------
 
-// Match returns the first client configuration settings where the URL matches the filter criteria.
+// Match returns the first client configuration settings where the request matches the filter criteria.
 // If there's no match, it returns the default client settings.
-func (c *Client) Match(URL *url.URL) *clientConfig {
+func (c *Client) Match(req *Request) *clientConfig {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 
@@ -113,7 +188,7 @@ func (c *Client) Match(URL *url.URL) *clientConfig {
 	}
 
 	for i := range c.ConfigList {
-		if c.ConfigList[i].fc.Match(URL) {
+		if sc := c.ConfigList[i].sc; sc.Filter != nil && sc.Filter.Match(req) == nil {
 			return c.ConfigList[i]
 		}
 	}
@@ -124,13 +199,129 @@ func (c *Client) Match(URL *url.URL) *clientConfig {
 // ------------------------------------------------------------------------
 
 func (c *Client) do(req *Request, bodySize int, checkHdrFunc hdrChecker) (*Response, error) {
-	defer c.Sleep(req.Req.URL)
+	cfg := req.collector.Config
+	req.ensureIdempotencyKey()
+
+	cc := c.Match(req)
+	delay := req.collector.throttle.scale(req.Req.URL.Host, cc.delay())
+
+	// The crawl-delay of the robots.txt is the minimum delay of the host
+	if cfg.RespectCrawlDelay {
+		if cd := req.collector.crawlDelay(req.Req.URL); cd > delay {
+			delay = cd
+		}
+	}
 
-	resp, err := c.Clt.Do(req.Req)
+	// The waits of the rate limits end when the context of the request is done
+	ctx := req.Req.Context()
+	if err := req.collector.waitHealth(ctx, req.Req.URL.Host); err != nil {
+		return nil, err
+	}
+
+	var release func()
+	var err error
+	key := req.Req.URL.Host
+	if c.limitKey != nil {
+		key = c.limitKey(req)
+	}
+	waitStart := time.Now()
+	switch {
+	case cfg.SharedLimiter != nil:
+		release, err = c.acquireShared(ctx, req, cfg.SharedLimiter, key, delay, cc.sc.MaxThreads)
+	case c.limitKey != nil:
+		release, err = c.limiter.Acquire(ctx, key, delay, cc.sc.MaxThreads)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if release != nil {
+		defer release()
+	}
+
+	// The limiter replaces the delays, the local delay is kept if it fails
+	if cfg.Limiter != nil {
+		if err := cfg.Limiter.Wait(ctx, req.Req.URL.Host); err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			cfg.logError(LOG_WARN_LEVEL, fmt.Errorf("rate limit of %q: %w", req.Req.URL.Host, err))
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if release != nil || cfg.Limiter != nil {
+		req.collector.bus.Publish(TOPIC_RATE_LIMIT, RateLimitSignal{
+			RequestSignal: req.signal(),
+			Key:           key,
+			Delay:         delay,
+			Wait:          time.Since(waitStart),
+		})
+	}
+
+	defer func() {
+		if c.limitKey == nil && cfg.SharedLimiter == nil && cfg.Limiter == nil && delay > 0 {
+			sleepContext(ctx, delay)
+		}
+	}()
+	host := req.Req.URL.Host
+
+	compressed, err := req.applyCompression(cfg.AcceptEncoding, cfg.CompressBodySize, c.compressionRejected(host))
+	if err != nil {
+		return nil, err
+	}
+	req.applyStreamLength()
+
+	var reqDump []byte
+	sampled := cfg.Sampler.sample(req)
+	if sampled {
+		if reqDump, err = cfg.Sampler.dumpRequest(req.Req); err != nil {
+			cfg.logError(LOG_WARN_LEVEL, err)
+			sampled = false
+		}
+	}
+
+	req.collector.applyValidators(req)
+	rules := c.protocolRules(req)
+	clt := c.downgrade(c.session(req, cfg.SessionAffinity), req, rules)
+	cfg.Authenticator.authorize(req.Req)
+
+	idle := req.watchIdle(cfg.IdleTimeout)
+	defer idle.stop()
+
+	resp, err := clt.Do(req.Req)
+	if err == nil {
+		resp, err = c.authenticate(clt, req.Req, resp, cfg.Authenticator)
+	}
+
+	// The request refused for its compressed body is sent once more uncompressed
+	if err == nil && compressed && resp.StatusCode == http.StatusUnsupportedMediaType {
+		c.rejectCompression(host)
+
+		if retry, uerr := req.uncompressBody(); uerr != nil {
+			cfg.logError(LOG_WARN_LEVEL, uerr)
+		} else if retry {
+			resp.Body.Close()
+			resp, err = clt.Do(req.Req)
+			if err == nil {
+				resp, err = c.authenticate(clt, req.Req, resp, cfg.Authenticator)
+			}
+		}
+	}
+	err = idle.err(err)
+	if err != nil {
+		if sampled {
+			if err := cfg.Sampler.capture(req, reqDump, nil); err != nil {
+				cfg.logError(LOG_WARN_LEVEL, err)
+			}
+		}
+		return nil, err
+	}
 	defer resp.Body.Close()
+	idle.touch()
+	traceProtocol(req, resp, rules)
+	resp.Body = idle.wrap(resp.Body)
 
 	httpReq := req.Req
 	if resp.Request != nil {
@@ -143,7 +334,20 @@ func (c *Client) do(req *Request, bodySize int, checkHdrFunc hdrChecker) (*Respo
 		return nil, ErrAbortedAfterHeaders
 	}
 
-	return NewResponse(req, resp, req.collector.Config.DetectCharset, bodySize)
+	req.collector.overrideContent(req, resp)
+
+	r, err := NewResponse(req, resp, req.collector.Config.DetectCharset, bodySize)
+	err = idle.err(err)
+	if r != nil {
+		req.collector.updateValidators(r)
+	}
+	if sampled && r != nil {
+		if err := cfg.Sampler.capture(req, reqDump, r); err != nil {
+			cfg.logError(LOG_WARN_LEVEL, err)
+		}
+	}
+
+	return r, err
 }
 
 func (c *Client) hasCache() bool {
@@ -152,20 +356,51 @@ func (c *Client) hasCache() bool {
 
 // ------------------------------------------------------------------------
 
-// The sleep method pauses the execution for a random delay that is calculateed
-// by combining the fix and a randomised delay of the client configuration settings.
-func (cc *clientConfig) sleep() {
-	delay := cc.fc.Delay
+// redirectChecker returns the redirect policy of the HTTP client.
+func redirectChecker(follow bool, maxRedirects uint) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if !follow {
+			return http.ErrUseLastResponse
+		}
 
-	if cc.fc.RandomDelay != 0 {
-		delay += time.Duration(rand.Int63n(int64(cc.fc.RandomDelay)))
+		// The refreshes followed before the request count as redirects too, see Request.RedirectCount
+		prior, _ := via[0].Context().Value(RedirectCountKey).(uint)
+		if maxRedirects > 0 && prior+uint(len(via)) > maxRedirects {
+			return ErrMaxRedirects
+		}
+
+		// Remove the Authorization header if the host has changed
+		if last := via[len(via)-1]; req.URL.Host != last.URL.Host {
+			req.Header.Del("Authorization")
+		}
+
+		return nil
 	}
+}
 
+// ------------------------------------------------------------------------
+
+// delay returns the fix delay of the client configuration settings plus a randomised delay.
+func (cc *clientConfig) delay() time.Duration {
+	delay := cc.sc.Delay
+
+	if cc.sc.RandomDelay != 0 {
+		delay += time.Duration(rand.Int63n(int64(cc.sc.RandomDelay)))
+	}
+
+	return delay
+}
+
+// The sleep method pauses the execution for a random delay that is calculateed
+// by combining the fix and a randomised delay of the client configuration settings,
+// or until the context is done.
+func (cc *clientConfig) sleep(ctx context.Context) {
+	delay := cc.delay()
 	if delay <= 0 {
 		return
 	}
 
-	time.Sleep(delay)
+	sleepContext(ctx, delay)
 
 	// if r != nil {
 	// 	r.waitChan <- true
@@ -0,0 +1,60 @@
+package colly
+
+import (
+	"colly/storage/mem"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestClient_Do_FromCache(t *testing.T) {
+	cache, _ := NewCache(mem.NewCacheStorage(), NewCacheExpiryNever())
+	clt := &Client{Cache: cache}
+
+	u, _ := url.Parse("https://example.com/page")
+	newReq := func() *Request {
+		return &Request{
+			ID:        7,
+			Req:       &http.Request{Method: "GET", URL: u, Header: http.Header{}},
+			collector: &Collector{Config: &CollectorConfig{}},
+		}
+	}
+
+	cached := &Response{
+		Request: newReq(),
+		Resp:    &http.Response{Status: "200 OK", StatusCode: 200, Header: http.Header{"Content-Type": {"text/html"}}},
+		Body:    []byte("<html></html>"),
+	}
+	if err := cache.Set(cached); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		accept  bool
+		wantErr error
+	}{
+		{"headers accepted", true, nil},
+		{"headers rejected", false, ErrAbortedAfterHeaders},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newReq()
+			checked := 0
+			resp, err := clt.Do(req, 0, func(_ *http.Request, statusCode int, _ http.Header) bool {
+				checked++
+				return tt.accept
+			})
+
+			if err != tt.wantErr || checked != 1 {
+				t.Fatalf("Do() error = %v, header checks = %d, want %v, 1", err, checked, tt.wantErr)
+			}
+			if err == nil && (!resp.FromCache || resp.Request != req || string(resp.Body) != "<html></html>") {
+				t.Errorf("Do() = %+v, want the cached response of the request", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package colly
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// ClientOption is a function to set a transport option of the HTTP client, see NewClient.
+type ClientOption func(o *clientOptions)
+
+// clientOptions is the transport configuration of a client
+type clientOptions struct {
+	transport      http.RoundTripper // custom round tripper, defaults to CollectorConfig.Transport
+	tlsConfig      *tls.Config       // TLS configuration, defaults to CollectorConfig.TLSConfig
+	dialTimeout    time.Duration     // connection timeout, zero keeps the one of the transport
+	noHTTP2        bool              // HTTP/2 is disabled
+	maxIdlePerHost int               // maximum idle keep-alive connections per host, zero keeps the one of the transport
+}
+
+// ------------------------------------------------------------------------
+
+// WithTransport sets the round tripper of the client, overriding CollectorConfig.Transport.
+// The other options only apply if it is an *http.Transport.
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(o *clientOptions) {
+		o.transport = rt
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the client, overriding CollectorConfig.TLSConfig,
+// e.g. to trust a private certificate authority or to skip the verification in tests.
+func WithTLSConfig(cfg *tls.Config) ClientOption {
+	return func(o *clientOptions) {
+		o.tlsConfig = cfg
+	}
+}
+
+// WithDialTimeout sets the maximum time to wait for the TCP connections of the client.
+func WithDialTimeout(d time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.dialTimeout = d
+	}
+}
+
+// WithHTTP2Disabled disables HTTP/2, so the client only speaks HTTP/1.1.
+func WithHTTP2Disabled() ClientOption {
+	return func(o *clientOptions) {
+		o.noHTTP2 = true
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the maximum number of the idle keep-alive connections kept per host.
+// Negative value disables the keep-alives.
+func WithMaxIdleConnsPerHost(n int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxIdlePerHost = n
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// newClientOptions returns the transport configuration of the collector configuration and the options.
+func newClientOptions(config *CollectorConfig, opts []ClientOption) *clientOptions {
+	o := &clientOptions{
+		transport: config.Transport,
+		tlsConfig: config.TLSConfig,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+
+	return o
+}
+
+// ------------------------------------------------------------------------
+
+// tune returns a copy of the transport with the connection options applied.
+// It returns the transport as it is if there are no connection options, nil uses the default transport.
+func (o *clientOptions) tune(base *http.Transport) *http.Transport {
+	if o.dialTimeout == 0 && !o.noHTTP2 && o.maxIdlePerHost == 0 {
+		return base
+	}
+
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	transport := base.Clone()
+
+	if o.dialTimeout != 0 {
+		transport.DialContext = (&net.Dialer{Timeout: o.dialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	}
+	if o.noHTTP2 {
+		// A non-nil empty map keeps the transport from configuring HTTP/2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		if transport.TLSClientConfig != nil {
+			transport.TLSClientConfig.NextProtos = withoutProto(transport.TLSClientConfig.NextProtos, "h2")
+		}
+	}
+	switch {
+	case o.maxIdlePerHost < 0:
+		transport.DisableKeepAlives = true
+	case o.maxIdlePerHost > 0:
+		transport.MaxIdleConnsPerHost = o.maxIdlePerHost
+	}
+
+	return transport
+}
+
+// withoutProto returns the list of the ALPN protocols without the given one.
+func withoutProto(protos []string, proto string) []string {
+	var kept []string
+	for _, p := range protos {
+		if p != proto {
+			kept = append(kept, p)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,70 @@
+package colly
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestNewClient_options(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	tlsConfig := &tls.Config{RootCAs: srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}
+
+	tests := []struct {
+		name      string
+		opts      []ClientOption
+		wantProto int
+	}{
+		{"HTTP/2", []ClientOption{WithTLSConfig(tlsConfig)}, 2},
+		{"HTTP/2 disabled", []ClientOption{WithTLSConfig(tlsConfig), WithHTTP2Disabled(), WithMaxIdleConnsPerHost(7), WithDialTimeout(time.Second)}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clt := NewClient(NewConfig(), tt.opts...)
+
+			resp, err := clt.Clt.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.ProtoMajor != tt.wantProto {
+				t.Errorf("ProtoMajor = %d, want %d", resp.ProtoMajor, tt.wantProto)
+			}
+		})
+	}
+
+	transport := NewClient(NewConfig(), WithMaxIdleConnsPerHost(7)).Clt.Transport.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != 7 {
+		t.Errorf("MaxIdleConnsPerHost = %d, want 7", transport.MaxIdleConnsPerHost)
+	}
+	if http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost == 7 {
+		t.Error("WithMaxIdleConnsPerHost() changed the default transport")
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestNewClient_WithTransport(t *testing.T) {
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return nil, http.ErrNotSupported
+	})
+
+	cfg := NewConfig()
+	cfg.Transport = http.DefaultTransport
+	if got := NewClient(cfg, WithTransport(rt)).Clt.Transport; got == nil {
+		t.Fatal("Transport = nil, want the custom round tripper")
+	} else if _, ok := got.(roundTripFunc); !ok {
+		t.Errorf("Transport = %T, want roundTripFunc", got)
+	}
+}
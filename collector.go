@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/antchfx/htmlquery"
@@ -38,13 +39,28 @@ type Collector struct {
 
 	sysCallbacks EventCallbacks // system callback functions will be called before other callbacks
 
-	store         storage.BaseStorage
-	robotsMap     map[string]*robotstxt.RobotsData
-	requestCount  uint32
-	responseCount uint32
-	backend       *httpBackend
-	wg            *sync.WaitGroup
-	lock          *sync.RWMutex
+	store      storage.BaseStorage
+	robotsMap  map[string]*robotstxt.RobotsData // guarded by lock
+	robotsText map[string]*robotsFile           // guarded by lock
+	client     *Client                          // created on the first request, see Client
+	stats      *collectorStats                  // atomic counters, safe without lock
+	reporter   *reporter                        // guarded by its own lock
+	paused     *domainPauser                    // guarded by its own lock
+	windows    *crawlWindows                    // guarded by its own lock
+	throttle   *hostThrottle                    // guarded by its own lock
+	dryRun     *dryRunPlan                      // guarded by its own lock
+	scheduler  *timerWheel                      // guarded by its own lock
+	parsePool  *parsePool                       // nil if the responses are parsed on the fetching goroutine
+	storages   *PersistentStorages              // nil if the storages are not owned by the collector
+	groups     *requestGroups                   // guarded by its own lock
+	inFlight   *inFlight                        // guarded by its own lock
+	selectors  *selectorPlans                   // guarded by its own lock
+	traps      *trapDetector                    // guarded by its own lock
+	bus        *EventBus                        // guarded by its own lock
+	health     *healthMonitor                   // guarded by its own lock
+	running    atomic.Bool                      // true between the first request and the end of Wait
+	wg         *jobGroup
+	lock       *sync.RWMutex
 }
 
 // ------------------------------------------------------------------------
@@ -58,6 +74,14 @@ const (
 	ON_HTML
 	ON_XML
 	ON_SCRAPED
+	ON_EXTRACT
+	ON_RESPONSE_CHUNK
+	ON_START
+	ON_IDLE
+	ON_FINISH
+	ON_STORAGE_ERROR
+	ON_CONTEXT_INIT
+	ON_ROBOTS_SITEMAP
 )
 
 // Empty event argument.
@@ -75,11 +99,35 @@ func NewCollector(config *CollectorConfig, callbacks EventCallbacks) *Collector
 		callbacks = NewEventList()
 	}
 
-	return &Collector{
+	c := &Collector{
 		Config:       config,
 		Callbacks:    callbacks,
 		sysCallbacks: NewEventList(),
+		robotsMap:    map[string]*robotstxt.RobotsData{},
+		robotsText:   map[string]*robotsFile{},
+		stats:        newCollectorStats(),
+		reporter:     newReporter(),
+		paused:       newDomainPauser(),
+		windows:      newCrawlWindows(),
+		throttle:     newHostThrottle(),
+		dryRun:       newDryRunPlan(),
+		groups:       newRequestGroups(),
+		inFlight:     newInFlight(),
+		selectors:    newSelectorPlans(),
+		traps:        newTrapDetector(),
+		bus:          NewEventBus(),
+		health:       newHealthMonitor(),
+		lock:         &sync.RWMutex{},
 	}
+	c.stats.subscribe(c.bus)
+	c.wg = newJobGroup(c.handleOnIdle, c.handleOnFinish)
+	c.scheduler = newTimerWheel(defWheelTick, defWheelSlots, c.fireScheduled)
+	c.setNormalizer()
+	c.setFragmentParser()
+	c.setParsePool()
+	c.logComplianceManifest()
+
+	return c
 }
 
 // ------------------------------------------------------------------------
@@ -98,7 +146,42 @@ func (c *Collector) OnRequestDetach(position ...int) {
 }
 
 func (c *Collector) handleOnRequest(r *Request) {
-	if c.HasLogger() {
+	// Aborted requests of a request group are finished
+	defer func() {
+		if r.abort {
+			c.groupFinish(r)
+			c.inFlight.finish(r)
+			c.reporter.requestAborted(r)
+		}
+	}()
+
+	// The values of the initialized contexts flow to the descendant requests
+	c.handleOnContextInit(r)
+
+	// Requests of paused hosts are parked until the host is resumed
+	if c.parkRequest(r) {
+		r.Abort()
+		return
+	}
+
+	// Requests outside the crawl windows of their hosts are held until a window opens
+	if !c.Config.DryRun && c.holdRequest(r) {
+		r.Abort()
+		return
+	}
+
+	// Dry runs check the filters and never send the requests
+	if c.Config.DryRun {
+		if !c.dryRunCheck(r) {
+			r.Abort()
+			return
+		}
+	} else {
+		c.handleOnStart()
+		c.reporter.requestStarted(r)
+	}
+
+	if c.Config.logEnabled(LOG_INFO_LEVEL) {
 		c.logEvent(LOG_INFO_LEVEL, "request", r.ID, map[string]string{
 			"url": r.Req.URL.String(),
 		})
@@ -106,9 +189,23 @@ func (c *Collector) handleOnRequest(r *Request) {
 
 	for _, fn := range c.Callbacks.GetArg(ON_REQUEST, NO_ARG) {
 		if callback, ok := fn.(RequestCallback); ok {
-			callback(r)
+			c.runCallback(ON_REQUEST, r, fn, func() { callback(r) })
 		}
 	}
+
+	if !r.abort {
+		c.rewriteURL(r)
+	}
+
+	if c.Config.DryRun {
+		c.dryRunFinish(r)
+		return
+	}
+
+	if !r.abort {
+		c.recordSharedVisit(r)
+		c.watchRequest(r)
+	}
 }
 
 // ------------------------------------------------------------------------
@@ -144,12 +241,18 @@ func (c *Collector) handleOnResponseHeaders(resp *Response) {
 			"url":         resp.Request.Req.URL.String(),
 			"status_code": strconv.Itoa(resp.Resp.StatusCode),
 			"status_msg":  resp.Resp.Status,
+			"from_cache":  strconv.FormatBool(resp.FromCache),
 		})
 	}
+	c.logDuplicateCookies(resp)
+
+	if c.skipCachedCallbacks(resp) {
+		return
+	}
 
 	for _, fn := range c.Callbacks.GetArg(ON_RESPONSE_HDR, NO_ARG) {
 		if callback, ok := fn.(ResponseHeadersCallback); ok {
-			callback(resp)
+			c.runCallback(ON_RESPONSE_HDR, resp.Request, fn, func() { callback(resp) })
 		}
 	}
 }
@@ -168,30 +271,68 @@ func (c *Collector) OnResponseDetach(position ...int) {
 	c.Callbacks.Remove(ON_RESPONSE, NO_ARG, position...)
 }
 
-func (c *Collector) handleOnResponse(resp *Response) {
+// handleOnResponse calls the response callbacks.
+// It returns false if the response was retried and must not be parsed.
+func (c *Collector) handleOnResponse(resp *Response) bool {
+	c.bus.Publish(TOPIC_RESPONSE, ResponseSignal{
+		RequestSignal: resp.Request.signal(),
+		StatusCode:    resp.Resp.StatusCode,
+		Size:          len(resp.Body),
+		FromCache:     resp.FromCache,
+	})
+	c.reporter.responseReceived(resp)
+	c.recordAttempt(resp, nil)
+
+	if resp.Truncated && c.handleTruncated(resp) {
+		c.inFlight.finish(resp.Request)
+		return false
+	}
+
+	c.sniffContentType(resp)
+	c.setLanguage(resp)
+
 	if !c.Config.ParseStatusCallback(resp.Resp.StatusCode) {
-		return
+		return true
 	}
 
-	if c.HasLogger() {
+	if c.Config.DuplicateAnalysis != DUPLICATE_NONE {
+		c.reporter.recordPage(resp)
+	}
+	c.recordCanonical(resp)
+	c.detectTrap(resp)
+
+	if c.Config.logEnabled(LOG_INFO_LEVEL) {
 		c.logEvent(LOG_INFO_LEVEL, "response", resp.Request.ID, map[string]string{
 			"url":         resp.Request.Req.URL.String(),
 			"status_code": strconv.Itoa(resp.Resp.StatusCode),
 			"status_msg":  resp.Resp.Status,
+			"from_cache":  strconv.FormatBool(resp.FromCache),
 		})
 	}
 
-	for _, fn := range c.Callbacks.GetArg(ON_RESPONSE, NO_ARG) {
+	callbacks := append([]any{}, c.sysCallbacks.GetArg(ON_RESPONSE, NO_ARG)...)
+	if !c.skipCachedCallbacks(resp) {
+		callbacks = append(callbacks, c.Callbacks.GetArg(ON_RESPONSE, NO_ARG)...)
+	}
+
+	for _, fn := range callbacks {
 		if callback, ok := fn.(ResponseCallback); ok {
-			callback(resp)
+			c.runCallback(ON_RESPONSE, resp.Request, fn, func() { callback(resp) })
 		}
 	}
+
+	if err := c.followRefresh(resp); err != nil {
+		c.Config.logError(LOG_WARN_LEVEL, err)
+	}
+
+	return true
 }
 
 // ------------------------------------------------------------------------
 
 // OnError is convenience method to register a function that will be executed
-// after an error occurs during the HTTP request.
+// after an error occurs during the HTTP request, or when the request is rejected
+// by the depth limit, the filters or robots.txt before it is sent.
 // The position identifies the execution order.
 func (c *Collector) OnError(fn ErrorCallback, position ...int) {
 	c.Callbacks.Add(ON_ERROR, NO_ARG, fn, position...)
@@ -203,41 +344,92 @@ func (c *Collector) OnErrorDetach(position ...int) {
 	c.Callbacks.Remove(ON_ERROR, NO_ARG, position...)
 }
 
-func (c *Collector) handleOnError(resp *Response, err error, ctx *Context) error {
+func (c *Collector) handleOnError(resp *Response, err error, req *Request) error {
 	if resp == nil {
-		response = &Response{
-			Request: request,
-			Ctx:     ctx,
+		resp = &Response{Request: req}
+	}
+	if resp.Request == nil {
+		resp.Request = req
+	}
+
+	// Stuck requests cancelled by the watchdog are requeued once if enabled
+	if err != nil && resp.Request != nil && resp.Request.stuck.Load() {
+		err = resp.Request.stuckError(err)
+		c.recordAttempt(resp, err)
+		if c.requeueStuck(resp.Request) {
+			c.inFlight.finish(resp.Request)
+			return nil
 		}
 	}
 
-	if err == nil && resp != nil && resp.Resp != nil && c.Config.ParseStatusCallback(resp.Resp.StatusCode) {
+	if err == nil && resp.Resp != nil && c.Config.ParseStatusCallback(resp.Resp.StatusCode) {
 		return nil
 	}
 
-	if err == nil && (c.ParseHTTPErrorResponse || resp.StatusCode < 203) {
+	// Unchanged resources of the conditional revisits are not errors
+	if err == nil && resp.NotModified {
 		return nil
 	}
-	if err == nil && resp.Resp.StatusCode >= 203 {
+
+	if err == nil {
+		if resp.Resp == nil {
+			return nil
+		}
 		err = errors.New(http.StatusText(resp.Resp.StatusCode))
 	}
-	if c.HasLogger() {
-		c.logEvent(LOG_WARN_LEVEL, "error", resp.Request.ID, map[string]string{
-			"url":         resp.Request.Req.URL.String(),
-			"status_code": strconv.Itoa(resp.Resp.StatusCode),
-			"status_msg":  resp.Resp.Status,
-		})
+
+	if c.Config.logEnabled(LOG_WARN_LEVEL) && resp.Request != nil {
+		args := map[string]string{
+			"url":   resp.Request.Req.URL.String(),
+			"error": err.Error(),
+		}
+		if resp.Resp != nil {
+			args["status_code"] = strconv.Itoa(resp.Resp.StatusCode)
+			args["status_msg"] = resp.Resp.Status
+		}
+		c.logEvent(LOG_WARN_LEVEL, "error", resp.Request.ID, args)
 	}
-	if resp.Request == nil {
-		resp.Request = request
+
+	c.recordAttempt(resp, err)
+	c.stats.errorOccurred()
+	c.reporter.errorOccurred(resp.Request, err)
+	c.recordFailure(resp, err)
+
+	callbacks := append([]any{}, c.sysCallbacks.GetArg(ON_ERROR, NO_ARG)...)
+	callbacks = append(callbacks, c.Callbacks.GetArg(ON_ERROR, NO_ARG)...)
+
+	for _, fn := range callbacks {
+		if callback, ok := fn.(ErrorCallback); ok {
+			c.runCallback(ON_ERROR, resp.Request, fn, func() { callback(resp, err) })
+		}
 	}
-	if response.Ctx == nil {
-		response.Ctx = request.Ctx
+	c.groupFinish(resp.Request)
+	c.inFlight.finish(resp.Request)
+
+	return err
+}
+
+// handleOnRejected runs the error callbacks of a request rejected by the depth limit, the filters
+// or the robots.txt rules before it was sent, and returns the rejection. The rejections are not
+// failures of the host, so they are not recorded as attempts, in the host health or the failure journal.
+func (c *Collector) handleOnRejected(r *Request, err error) error {
+	if c.Config.logEnabled(LOG_DEBUG_LEVEL) {
+		c.logEvent(LOG_DEBUG_LEVEL, "rejected", r.ID, map[string]string{
+			"url":   r.Req.URL.String(),
+			"error": err.Error(),
+		})
 	}
 
-	for _, fn := range c.Callbacks.GetArg(ON_ERROR, NO_ARG) {
+	c.bus.Publish(TOPIC_REJECTED, RejectSignal{RequestSignal: r.signal(), Error: err.Error()})
+	c.reporter.errorOccurred(r, err)
+
+	resp := &Response{Request: r}
+	callbacks := append([]any{}, c.sysCallbacks.GetArg(ON_ERROR, NO_ARG)...)
+	callbacks = append(callbacks, c.Callbacks.GetArg(ON_ERROR, NO_ARG)...)
+
+	for _, fn := range callbacks {
 		if callback, ok := fn.(ErrorCallback); ok {
-			callback(resp, err)
+			c.runCallback(ON_ERROR, r, fn, func() { callback(resp, err) })
 		}
 	}
 
@@ -260,7 +452,15 @@ func (c *Collector) OnHTMLDetach(goquerySelector string, position ...int) {
 }
 
 func (c *Collector) handleOnHTML(resp *Response) error {
-	if c.Callbacks.IsEmpty(ON_HTML) || !strings.Contains(strings.ToLower(resp.Resp.Header.Get("Content-Type")), "html") {
+	if c.languageExcluded(resp) {
+		return nil
+	}
+
+	if err := c.handleOnExtract(resp); err != nil {
+		return err
+	}
+
+	if c.Callbacks.IsEmpty(ON_HTML) || !strings.Contains(resp.ContentType(), "html") {
 		return nil
 	}
 
@@ -270,19 +470,34 @@ func (c *Collector) handleOnHTML(resp *Response) error {
 	}
 
 	if href, found := doc.Find("base[href]").Attr("href"); found {
-		baseURL, err := c.Config.Parser.ParseRef(resp.Request.Req.URL.String(), href)
+		baseURL, err := c.Config.Parser.ParseRef(resp.Request.OriginalURL().String(), href)
 		if err == nil {
 			resp.Request.baseURL = baseURL
 		}
 
 	}
+
+	var fingerprint uint64
+	if c.Config.CacheSelectorPlans && len(doc.Nodes) > 0 {
+		fingerprint = documentFingerprint(doc.Nodes[0])
+	}
+
 	for selector, fnList := range c.Callbacks.Get(ON_HTML) {
 		i := 0
-		doc.Find(selector).Each(func(_ int, s *goquery.Selection) {
+		sel := c.selectors.find(doc, fingerprint, selector)
+		if sel.Length() == 0 && c.Config.DebugSelectors {
+			c.logSelectorMiss(resp, doc, selector)
+		}
+		sel.Each(func(_ int, s *goquery.Selection) {
 			for _, n := range s.Nodes {
-				e := NewHTMLElementFromSelectionNode(resp, s, n, i)
+				var e *HTMLElement
+				if c.Config.ReuseMemory {
+					e = acquireHTMLElement(resp, s, n, i)
+				} else {
+					e = NewHTMLElementFromSelectionNode(resp, s, n, i)
+				}
 				i++
-				if c.HasLogger() {
+				if c.Config.logEnabled(LOG_INFO_LEVEL) {
 					c.logEvent(LOG_INFO_LEVEL, "html", resp.Request.ID, map[string]string{
 						"selector": selector,
 						"url":      resp.Request.Req.URL.String(),
@@ -291,12 +506,21 @@ func (c *Collector) handleOnHTML(resp *Response) error {
 
 				for _, fn := range fnList {
 					if callback, ok := fn.(HTMLCallback); ok {
-						callback(e)
+						c.runCallback(ON_HTML, resp.Request, fn, func() { callback(e) })
 					}
 				}
+
+				if c.Config.ReuseMemory {
+					releaseHTMLElement(e)
+				}
 			}
 		})
 	}
+
+	if c.Config.ParseNestedDocuments {
+		return c.handleNestedDocuments(resp, doc)
+	}
+
 	return nil
 }
 
@@ -316,11 +540,11 @@ func (c *Collector) OnXMLDetach(xpathQuery string, position ...int) {
 }
 
 func (c *Collector) handleOnXML(resp *Response) error {
-	if c.Callbacks.IsEmpty(ON_XML) {
+	if c.Callbacks.IsEmpty(ON_XML) || c.languageExcluded(resp) {
 		return nil
 	}
 
-	contentType := strings.ToLower(resp.Resp.Header.Get("Content-Type"))
+	contentType := resp.ContentType()
 	isXMLFile := isXML(resp.Request.Req.URL.Path)
 	if !strings.Contains(contentType, "html") && (!strings.Contains(contentType, "xml") && !isXMLFile) {
 		return nil
@@ -345,9 +569,14 @@ func (c *Collector) handleOnXML(resp *Response) error {
 
 		for query, fnList := range c.Callbacks.Get(ON_XML) {
 			for _, n := range htmlquery.Find(doc, query) {
-				e := NewXMLElementFromHTMLNode(resp, n)
+				var e *XMLElement
+				if c.Config.ReuseMemory {
+					e = acquireXMLElementFromHTMLNode(resp, n)
+				} else {
+					e = NewXMLElementFromHTMLNode(resp, n)
+				}
 
-				if c.HasLogger() {
+				if c.Config.logEnabled(LOG_INFO_LEVEL) {
 					c.logEvent(LOG_INFO_LEVEL, "xml", resp.Request.ID, map[string]string{
 						"selector": query,
 						"url":      resp.Request.Req.URL.String(),
@@ -356,9 +585,13 @@ func (c *Collector) handleOnXML(resp *Response) error {
 
 				for _, fn := range fnList {
 					if callback, ok := fn.(XMLCallback); ok {
-						callback(e)
+						c.runCallback(ON_XML, resp.Request, fn, func() { callback(e) })
 					}
 				}
+
+				if c.Config.ReuseMemory {
+					releaseXMLElement(e)
+				}
 			}
 		}
 	} else if strings.Contains(contentType, "xml") || isXMLFile {
@@ -369,9 +602,14 @@ func (c *Collector) handleOnXML(resp *Response) error {
 
 		for query, fnList := range c.Callbacks.Get(ON_XML) {
 			xmlquery.FindEach(doc, query, func(i int, n *xmlquery.Node) {
-				e := NewXMLElementFromXMLNode(resp, n)
+				var e *XMLElement
+				if c.Config.ReuseMemory {
+					e = acquireXMLElementFromXMLNode(resp, n)
+				} else {
+					e = NewXMLElementFromXMLNode(resp, n)
+				}
 
-				if c.HasLogger() {
+				if c.Config.logEnabled(LOG_INFO_LEVEL) {
 					c.logEvent(LOG_INFO_LEVEL, "xml", resp.Request.ID, map[string]string{
 						"selector": query,
 						"url":      resp.Request.Req.URL.String(),
@@ -380,9 +618,13 @@ func (c *Collector) handleOnXML(resp *Response) error {
 
 				for _, fn := range fnList {
 					if callback, ok := fn.(XMLCallback); ok {
-						callback(e)
+						c.runCallback(ON_XML, resp.Request, fn, func() { callback(e) })
 					}
 				}
+
+				if c.Config.ReuseMemory {
+					releaseXMLElement(e)
+				}
 			})
 		}
 	}
@@ -404,17 +646,34 @@ func (c *Collector) OnScrapedDetach(position ...int) {
 }
 
 func (c *Collector) handleOnScraped(resp *Response) {
-	if c.HasLogger() {
+	c.bus.Publish(TOPIC_SCRAPED, resp.Request.signal())
+
+	if c.Config.logEnabled(LOG_INFO_LEVEL) {
 		c.logEvent(LOG_INFO_LEVEL, "scraped", resp.Request.ID, map[string]string{
 			"url": resp.Request.Req.URL.String(),
 		})
 	}
 
-	for _, fn := range c.Callbacks.GetArg(ON_SCRAPED, NO_ARG) {
-		if callback, ok := fn.(ScrapedCallback); ok {
-			callback(resp)
+	if !c.skipCachedCallbacks(resp) {
+		for _, fn := range c.Callbacks.GetArg(ON_SCRAPED, NO_ARG) {
+			if callback, ok := fn.(ScrapedCallback); ok {
+				c.runCallback(ON_SCRAPED, resp.Request, fn, func() { callback(resp) })
+			}
 		}
+		c.archiveBody(resp)
 	}
+	c.groupFinish(resp.Request)
+	c.inFlight.finish(resp.Request)
+
+	if c.Config.ReuseMemory {
+		resp.Release()
+	}
+}
+
+// skipCachedCallbacks returns true if the user callbacks must be skipped for a response served from the cache,
+// or for a response of a conditional revisit that found the resource unchanged.
+func (c *Collector) skipCachedCallbacks(resp *Response) bool {
+	return resp.NotModified || (resp.FromCache && c.Config.SkipCachedCallbacks)
 }
 
 // ------------------------------------------------------------------------
@@ -432,9 +691,19 @@ func (c *Collector) HasLogger() bool {
 // ------------------------------------------------------------------------
 
 func (c *Collector) logEvent(level LogLevel, eventType string, requestID uint32, args map[string]string) {
-	if c.Config.hasLogger() {
-		c.Config.Logger.LogEvent(level, NewLoggerEvent(eventType, c.ID, requestID, args))
+	if !c.Config.logEnabled(level) {
+		return
+	}
+
+	// The events of the loggers that don't keep them are reused
+	if _, ok := c.Config.Logger.(transientLogger); ok {
+		e := acquireLoggerEvent(eventType, c.ID, requestID, args)
+		c.Config.Logger.LogEvent(level, e)
+		releaseLoggerEvent(e)
+		return
 	}
+
+	c.Config.Logger.LogEvent(level, NewLoggerEvent(eventType, c.ID, requestID, args))
 }
 
 // ------------------------------------------------------------------------
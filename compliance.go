@@ -0,0 +1,211 @@
+package colly
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// ComplianceManifest records the settings enforced by the compliance mode of a collector.
+type ComplianceManifest struct {
+	CollectorID       uint32    `json:"collector_id" bson:"collector_id,omitempty"`             // CollectorID identifies the collector.
+	Created           time.Time `json:"created" bson:"created,omitempty"`                       // Created is the date and time when the manifest was created.
+	UserAgent         string    `json:"user_agent" bson:"user_agent,omitempty"`                 // UserAgent is the identifying user agent of the collector.
+	RobotsTxt         bool      `json:"robots_txt" bson:"robots_txt,omitempty"`                 // RobotsTxt tells whether or not robots.txt is enforced.
+	CrawlDelay        bool      `json:"crawl_delay" bson:"crawl_delay,omitempty"`               // CrawlDelay tells whether or not the robots.txt crawl-delay is honored.
+	PersistentCookies bool      `json:"persistent_cookies" bson:"persistent_cookies,omitempty"` // PersistentCookies tells whether or not cookies may be persisted.
+	StrippedParams    []string  `json:"stripped_params" bson:"stripped_params,omitempty"`       // StrippedParams is the list of query parameters removed before storage.
+}
+
+// ------------------------------------------------------------------------
+
+// DefaultTrackingParams is the list of tracking query parameters stripped in compliance mode.
+// Parameters ending with an asterisk match by prefix.
+var DefaultTrackingParams = []string{
+	"utm_*",
+	"_ga",
+	"_gl",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"igshid",
+	"mc_cid",
+	"mc_eid",
+	"msclkid",
+	"yclid",
+}
+
+// ------------------------------------------------------------------------
+
+// SetCompliance switches the collector configuration to compliance mode.
+// It enforces robots.txt and its crawl-delay, sets a truthful identifying User-Agent
+// with the contact URL, keeps cookies in memory only and strips the tracking query
+// parameters from the URLs before storage. The manifest is logged when the collector is created.
+func (c *CollectorConfig) SetCompliance(botName string, contactURL string) error {
+	botName = strings.TrimSpace(botName)
+	if botName == "" {
+		botName = "colly"
+	}
+
+	if _, err := url.ParseRequestURI(contactURL); err != nil {
+		return fmt.Errorf("%w: %v", ErrComplianceNoContact, err)
+	}
+
+	jar, err := NewCookieJar(nil, nil)
+	if err != nil {
+		return err
+	}
+
+	userAgent := fmt.Sprintf("%s (+%s)", botName, contactURL)
+
+	c.Compliance = true
+	c.IgnoreRobotsTxt = false
+	c.RespectCrawlDelay = true
+	c.UserAgentCallback = func() string { return userAgent }
+	c.CookieJar = jar
+	c.StripParams = DefaultTrackingParams
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// ComplianceManifest returns the compliance settings of the collector.
+// It returns nil if the compliance mode is off.
+func (c *Collector) ComplianceManifest() *ComplianceManifest {
+	if !c.Config.Compliance {
+		return nil
+	}
+
+	// Storage based cookie jars may persist the cookies
+	_, persistent := c.Config.CookieJar.(*cookieJar)
+
+	m := &ComplianceManifest{
+		CollectorID:       c.ID,
+		Created:           time.Now(),
+		RobotsTxt:         !c.Config.IgnoreRobotsTxt,
+		CrawlDelay:        c.Config.RespectCrawlDelay,
+		PersistentCookies: persistent,
+		StrippedParams:    c.Config.StripParams,
+	}
+
+	if c.Config.UserAgentCallback != nil {
+		m.UserAgent = c.Config.UserAgentCallback()
+	}
+
+	return m
+}
+
+// logComplianceManifest logs the compliance settings of the collector.
+func (c *Collector) logComplianceManifest() {
+	m := c.ComplianceManifest()
+	if m == nil || !c.HasLogger() {
+		return
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		c.Config.logError(LOG_WARN_LEVEL, err)
+
+		return
+	}
+
+	c.logEvent(LOG_INFO_LEVEL, "compliance", 0, map[string]string{
+		"manifest": string(data),
+	})
+}
+
+// ------------------------------------------------------------------------
+
+// crawlDelay returns the robots.txt crawl-delay of the URL host for the collector's user agent.
+func (c *Collector) crawlDelay(u *url.URL) time.Duration {
+	c.lock.RLock()
+	robots, present := c.robotsMap[u.Host]
+	c.lock.RUnlock()
+
+	if !present || robots == nil {
+		return 0
+	}
+
+	userAgent := ""
+	if c.Config.UserAgentCallback != nil {
+		userAgent = c.Config.UserAgentCallback()
+	}
+
+	if group := robots.FindGroup(userAgent); group != nil {
+		return group.CrawlDelay
+	}
+
+	return 0
+}
+
+// ------------------------------------------------------------------------
+
+// StripQueryParams returns a copy of the URL without the listed query parameters.
+// Parameters ending with an asterisk match by prefix.
+func StripQueryParams(u *url.URL, params []string) *url.URL {
+	if u == nil || u.RawQuery == "" || len(params) == 0 {
+		return u
+	}
+
+	query := u.Query()
+	changed := false
+	for key := range query {
+		if matchParam(key, params) {
+			query.Del(key)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return u
+	}
+
+	stripped := *u
+	stripped.RawQuery = query.Encode()
+
+	return &stripped
+}
+
+// matchParam returns true if the query parameter matches any of the patterns.
+func matchParam(key string, patterns []string) bool {
+	key = strings.ToLower(key)
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		if prefix := strings.TrimSuffix(p, "*"); prefix != p {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// storedResponse returns a shallow copy of the response with the original, stripped request URL
+// that is used as the storage key.
+func storedResponse(resp *Response, params []string) *Response {
+	if resp.Request == nil || resp.Request.Req == nil {
+		return resp
+	}
+
+	u := StripQueryParams(resp.Request.requestedURL(), params)
+	if u == resp.Request.Req.URL {
+		return resp
+	}
+
+	// Only the embedded HTTP request of the request is stored
+	httpReq := *resp.Request.Req
+	httpReq.URL = u
+
+	stored := *resp
+	stored.Request = &Request{ID: resp.Request.ID, Depth: resp.Request.Depth, Req: &httpReq}
+
+	return &stored
+}
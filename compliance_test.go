@@ -0,0 +1,57 @@
+package colly
+
+import (
+	"net/url"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestStripQueryParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		rawURL string
+		params []string
+		want   string
+	}{
+		{"no params", "https://example.com/a?utm_source=x", nil, "https://example.com/a?utm_source=x"},
+		{"no query", "https://example.com/a", DefaultTrackingParams, "https://example.com/a"},
+		{"prefix", "https://example.com/a?UTM_Source=x&utm_medium=y&id=1", DefaultTrackingParams, "https://example.com/a?id=1"},
+		{"exact", "https://example.com/a?gclid=abc&fbclid=def&page=2", DefaultTrackingParams, "https://example.com/a?page=2"},
+		{"all stripped", "https://example.com/a?gclid=abc#top", DefaultTrackingParams, "https://example.com/a#top"},
+		{"no match", "https://example.com/a?b=2&a=1", DefaultTrackingParams, "https://example.com/a?b=2&a=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse(tt.rawURL)
+			if got := StripQueryParams(u, tt.params).String(); got != tt.want {
+				t.Errorf("StripQueryParams() = %v, want %v", got, tt.want)
+			}
+			if u.String() != tt.rawURL {
+				t.Errorf("StripQueryParams() changed the original URL to %v", u)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollectorConfig_SetCompliance(t *testing.T) {
+	c := NewConfig()
+	if err := c.SetCompliance("testbot", "not a URL"); err == nil {
+		t.Errorf("SetCompliance() error = nil, want error")
+	}
+
+	if err := c.SetCompliance("testbot", "https://example.com/bot"); err != nil {
+		t.Fatalf("SetCompliance() error = %v", err)
+	}
+
+	if !c.Compliance || c.IgnoreRobotsTxt || !c.RespectCrawlDelay || len(c.StripParams) == 0 {
+		t.Errorf("SetCompliance() = %+v, want compliance settings", c)
+	}
+
+	if got, want := c.UserAgentCallback(), "testbot (+https://example.com/bot)"; got != want {
+		t.Errorf("SetCompliance() user agent = %q, want %q", got, want)
+	}
+}
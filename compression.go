@@ -0,0 +1,167 @@
+package colly
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ------------------------------------------------------------------------
+
+// BodyCompression tells whether or not the request body should be gzip compressed.
+type BodyCompression uint8
+
+// ------------------------------------------------------------------------
+
+const (
+	BODY_COMPRESSION_AUTO BodyCompression = iota // Compress the body if it is larger than the configured size and the server supports it.
+	BODY_COMPRESSION_ON                          // Always compress the body.
+	BODY_COMPRESSION_OFF                         // Never compress the body.
+)
+
+// ------------------------------------------------------------------------
+
+// applyCompression sets the Accept-Encoding header and compresses the request body
+// based on the request overrides and the collector settings.
+// It returns true if the request body was compressed.
+func (r *Request) applyCompression(acceptEncoding []string, minSize uint, serverRejects bool) (bool, error) {
+	if len(r.AcceptEncoding) > 0 {
+		acceptEncoding = r.AcceptEncoding
+	}
+
+	if len(acceptEncoding) > 0 && r.Req.Header.Get("Accept-Encoding") == "" {
+		r.Req.Header.Set("Accept-Encoding", strings.Join(acceptEncoding, ", "))
+	}
+
+	if r.Req.Body == nil || r.Req.Body == http.NoBody || r.Req.Header.Get("Content-Encoding") != "" {
+		return false, nil
+	}
+
+	// Streamed bodies are never buffered, they are compressed on the fly if it is forced
+	if b, ok := r.Req.Body.(*streamBody); ok {
+		if r.CompressBody != BODY_COMPRESSION_ON {
+			return false, nil
+		}
+		r.Req.Body = b.gzipped()
+		r.Req.ContentLength = 0
+		r.Req.GetBody = nil
+		r.Req.Header.Set("Content-Encoding", "gzip")
+
+		return true, nil
+	}
+
+	switch r.CompressBody {
+	case BODY_COMPRESSION_OFF:
+		return false, nil
+	case BODY_COMPRESSION_AUTO:
+		if minSize == 0 || serverRejects || (r.Req.ContentLength > 0 && uint(r.Req.ContentLength) < minSize) {
+			return false, nil
+		}
+	}
+
+	body, err := io.ReadAll(r.Req.Body)
+	r.Req.Body.Close()
+	if err != nil {
+		return false, err
+	}
+
+	if r.CompressBody == BODY_COMPRESSION_AUTO && uint(len(body)) < minSize {
+		r.setBody(body)
+
+		return false, nil
+	}
+
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(body); err != nil {
+		return false, err
+	}
+	if err := zw.Close(); err != nil {
+		return false, err
+	}
+
+	r.setBody(buf.Bytes())
+	r.Req.Header.Set("Content-Encoding", "gzip")
+
+	return true, nil
+}
+
+// uncompressBody restores the uncompressed body of a request compressed by applyCompression.
+// It returns false if the body can't be replayed, e.g. a streamed body.
+func (r *Request) uncompressBody() (bool, error) {
+	if r.Req.GetBody == nil {
+		return false, nil
+	}
+
+	rc, err := r.Req.GetBody()
+	if err != nil {
+		return false, err
+	}
+	defer rc.Close()
+
+	zr, err := gzip.NewReader(rc)
+	if err != nil {
+		return false, err
+	}
+	defer zr.Close()
+
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		return false, err
+	}
+
+	r.setBody(body)
+	r.Req.Header.Del("Content-Encoding")
+
+	return true, nil
+}
+
+// setBody replaces the body of the embedded HTTP request.
+func (r *Request) setBody(body []byte) {
+	r.Req.Body = io.NopCloser(bytes.NewReader(body))
+	r.Req.ContentLength = int64(len(body))
+	r.Req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// decodeReader returns a reader that decodes the response body by the Content-Encoding header.
+// Unknown content codings are left untouched. The bodies of gzip files served without
+// Content-Encoding, e.g. sitemap.xml.gz, are decompressed if they start with the gzip header.
+func decodeReader(resp *http.Response, rdr io.Reader) (io.ReadCloser, error) {
+	if isCompressed(resp) {
+		return gzip.NewReader(rdr)
+	}
+
+	if !resp.Uncompressed && hasHdrVal(resp.Header, "Content-Encoding", "deflate") {
+		return flate.NewReader(rdr), nil
+	}
+
+	if maybeGzipped(resp) {
+		return sniffGzip(rdr)
+	}
+
+	return io.NopCloser(rdr), nil
+}
+
+// ------------------------------------------------------------------------
+
+// compressionRejected returns true if the host refused a compressed request body before.
+func (c *Client) compressionRejected(host string) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.noCompression[host]
+}
+
+// rejectCompression remembers the host that doesn't support compressed request bodies.
+func (c *Client) rejectCompression(host string) {
+	c.lock.Lock()
+	c.noCompression[host] = true
+	c.lock.Unlock()
+}
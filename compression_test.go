@@ -0,0 +1,92 @@
+package colly
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestClient_compressionRejected(t *testing.T) {
+	var (
+		lock      sync.Mutex
+		encodings []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := r.Header.Get("Content-Encoding")
+		lock.Lock()
+		encodings = append(encodings, enc)
+		lock.Unlock()
+
+		if enc == "gzip" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		io.Copy(w, r.Body)
+	}))
+	defer ts.Close()
+
+	cfg := NewConfig()
+	cfg.CompressBodySize = 1
+	c := NewCollector(cfg, nil)
+
+	var got []string
+	c.OnResponse(func(r *Response) { got = append(got, string(r.Body)) })
+
+	// The refused request is retried uncompressed, the next ones are not compressed
+	data := strings.Repeat("name=colly&", 10)
+	for i := 0; i < 2; i++ {
+		if err := c.Request("POST", ts.URL, strings.NewReader(data), nil, nil); err != nil {
+			t.Fatalf("Request() error = %v", err)
+		}
+	}
+
+	if want := []string{data, data}; !reflect.DeepEqual(got, want) {
+		t.Errorf("response bodies = %q, want the uncompressed body twice", got)
+	}
+	if want := []string{"gzip", "", ""}; !reflect.DeepEqual(encodings, want) {
+		t.Errorf("request encodings = %q, want %q", encodings, want)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestRequest_uncompressBody(t *testing.T) {
+	r := &Request{Req: httptest.NewRequest("POST", "https://example.com/", strings.NewReader("payload"))}
+	r.CompressBody = BODY_COMPRESSION_ON
+	if compressed, err := r.applyCompression(nil, 0, false); !compressed || err != nil {
+		t.Fatalf("applyCompression() = %v, %v", compressed, err)
+	}
+
+	if ok, err := r.uncompressBody(); !ok || err != nil {
+		t.Fatalf("uncompressBody() = %v, %v", ok, err)
+	}
+	if enc := r.Req.Header.Get("Content-Encoding"); enc != "" {
+		t.Errorf("Content-Encoding = %q, want none", enc)
+	}
+	if body, _ := io.ReadAll(r.Req.Body); string(body) != "payload" || r.Req.ContentLength != 7 {
+		t.Errorf("body = %q, %d bytes", body, r.Req.ContentLength)
+	}
+
+	// The streamed bodies can't be replayed
+	r.Req.GetBody = nil
+	if ok, err := r.uncompressBody(); ok || err != nil {
+		t.Errorf("uncompressBody() without GetBody = %v, %v, want false", ok, err)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestEnvMap_ACCEPT_ENCODING(t *testing.T) {
+	cfg := NewConfig()
+	EnvMap["ACCEPT_ENCODING"](cfg, "gzip, br ,, deflate")
+
+	if want := []string{"gzip", "br", "deflate"}; !reflect.DeepEqual(cfg.AcceptEncoding, want) {
+		t.Errorf("AcceptEncoding = %q, want %q", cfg.AcceptEncoding, want)
+	}
+}
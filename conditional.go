@@ -0,0 +1,117 @@
+package colly
+
+import (
+	"colly/filters"
+	"colly/storage/mem"
+	"net/http"
+)
+
+// ------------------------------------------------------------------------
+
+// SetConditionalRevisits sets how many times the same URL can be visited, like SetMaxRevisits,
+// but the URLs with a known ETag or Last-Modified header can always be revisited with
+// conditional requests. The unchanged resources are answered with 304 Not Modified,
+// which skip the downstream callbacks, see Response.NotModified.
+// If no validator storage is given, the validators are kept in the memory.
+// If no visit storage is given, the visits are kept in the memory.
+func (c *CollectorConfig) SetConditionalRevisits(maxRevisits uint, validators filters.ValidatorStorage, storage ...filters.VisitStorage) error {
+	var stg filters.VisitStorage
+
+	if len(storage) > 0 {
+		stg = storage[0]
+	}
+
+	stg, err := c.isolateVisits(stg)
+	if err != nil {
+		return err
+	}
+	if stg == nil {
+		stg = mem.NewVisitStorage()
+	}
+
+	if validators == nil {
+		validators = mem.NewCookieStorage()
+	}
+
+	store, err := filters.NewValidatorStore(validators)
+	if err != nil {
+		return err
+	}
+
+	engine, err := filters.NewConditionalVisitEngine(stg, store, maxRevisits)
+	if err != nil {
+		return err
+	}
+
+	if c.Filter == nil {
+		c.Filter = NewFilter()
+	}
+
+	c.Validators = store
+
+	return c.Filter.AddEngine(FILTER_METHOD_EXCLUDE, URL_FILTER, engine, ErrFilterNoRevisit, "conditional_revisit")
+}
+
+// ------------------------------------------------------------------------
+
+// applyValidators turns a GET request of a URL with known validators into a conditional request.
+// The conditional headers set by the user are kept.
+func (c *Collector) applyValidators(r *Request) {
+	if c.Config.Validators == nil || r.Req.Method != "GET" || isConditional(r.Req) {
+		return
+	}
+
+	v, err := c.Config.Validators.Get(r.requestedURL().String())
+	if err != nil {
+		c.handleOnStorageError(STORAGE_VALIDATORS, err)
+		return
+	}
+
+	if v.ETag != "" {
+		r.Req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		r.Req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+// updateValidators marks the unchanged responses of the conditional requests
+// and stores the validators of the successful responses.
+func (c *Collector) updateValidators(resp *Response) {
+	if c.Config.Validators == nil || resp.Resp == nil || resp.Request.Req.Method != "GET" {
+		return
+	}
+
+	if resp.Resp.StatusCode == http.StatusNotModified && isConditional(resp.Request.Req) {
+		resp.NotModified = true
+
+		if c.HasLogger() {
+			c.logEvent(LOG_INFO_LEVEL, "not_modified", resp.Request.ID, map[string]string{
+				"url": resp.Request.Req.URL.String(),
+			})
+		}
+
+		return
+	}
+
+	if resp.Resp.StatusCode < 200 || resp.Resp.StatusCode >= 300 {
+		return
+	}
+
+	v := filters.Validators{
+		ETag:         resp.Resp.Header.Get("ETag"),
+		LastModified: resp.Resp.Header.Get("Last-Modified"),
+	}
+	if v.IsEmpty() {
+		return
+	}
+
+	if err := c.Config.Validators.Set(resp.Request.requestedURL().String(), v); err != nil {
+		c.handleOnStorageError(STORAGE_VALIDATORS, err)
+	}
+}
+
+// isConditional returns true if the request has conditional headers.
+func isConditional(req *http.Request) bool {
+	return req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+}
@@ -0,0 +1,60 @@
+package colly
+
+import (
+	"colly/filters"
+	"colly/storage/mem"
+	"net/http"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollector_conditionalRevisit(t *testing.T) {
+	store, _ := filters.NewValidatorStore(mem.NewCookieStorage())
+	cfg := NewConfig()
+	cfg.Validators = store
+	c := NewCollector(cfg, nil)
+
+	newReq := func() *Request {
+		req, _ := http.NewRequest("GET", "https://example.com/page", nil)
+		return &Request{Req: req, collector: c}
+	}
+	newResp := func(r *Request, status int, hdr http.Header) *Response {
+		return &Response{Request: r, Resp: &http.Response{StatusCode: status, Header: hdr}}
+	}
+
+	// The first visit is unconditional and records the validators
+	first := newReq()
+	c.applyValidators(first)
+	if isConditional(first.Req) {
+		t.Fatalf("the first visit is conditional: %v", first.Req.Header)
+	}
+	c.updateValidators(newResp(first, http.StatusOK, http.Header{
+		"Etag":          {`"v1"`},
+		"Last-Modified": {"Mon, 02 Jan 2006 15:04:05 GMT"},
+	}))
+
+	// The revisit is conditional and the 304 marks the response unchanged
+	second := newReq()
+	c.applyValidators(second)
+	if got := second.Req.Header.Get("If-None-Match"); got != `"v1"` {
+		t.Errorf("If-None-Match = %q, want %q", got, `"v1"`)
+	}
+	if got := second.Req.Header.Get("If-Modified-Since"); got != "Mon, 02 Jan 2006 15:04:05 GMT" {
+		t.Errorf("If-Modified-Since = %q", got)
+	}
+
+	resp := newResp(second, http.StatusNotModified, http.Header{})
+	c.updateValidators(resp)
+	if !resp.NotModified || !c.skipCachedCallbacks(resp) {
+		t.Errorf("the 304 response of the revisit is not skipped as unchanged")
+	}
+
+	// A 304 of an unconditional request is not an unchanged revisit
+	plain := &Request{Req: &http.Request{Method: "GET", URL: second.Req.URL, Header: http.Header{}}, collector: c}
+	resp = newResp(plain, http.StatusNotModified, http.Header{})
+	c.updateValidators(resp)
+	if resp.NotModified {
+		t.Errorf("the 304 response of an unconditional request is marked unchanged")
+	}
+}
@@ -2,8 +2,10 @@ package colly
 
 import (
 	"colly/filters"
+	"colly/storage"
 	"colly/storage/filesys"
 	"colly/storage/mem"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net/http"
@@ -20,6 +22,7 @@ type (
 	ParseStatusCallback func(status int) bool                // ParseStatusCallback is a callback to enable or disable parsing the response, based on the status code.
 	UserAgentCallback   func() string                        // UserAgentCallback is a callback function to return a user agent string.
 	HeaderCallback      func() http.Header                   // HeaderCallback is a callback function to return a list of HTTP headers.
+	LimitKeyCallback    func(req *Request) string            // LimitKeyCallback is a callback function to return the rate limit bucket of a request.
 )
 
 // CollectorConfig is a list of collection settings.
@@ -37,16 +40,151 @@ type CollectorConfig struct {
 	// IgnoreRobotsTxt, if true, allows the Collector to ignore any restrictions set by the target
 	// host's robots.txt file.  See http://www.robotstxt.org/ for more information.
 	IgnoreRobotsTxt bool `json:"ignore_robots_txt" bson:"ignore_robots_txt,omitempty"`
+	// RespectCrawlDelay enables waiting for the crawl-delay of the robots.txt between the requests.
+	RespectCrawlDelay bool `json:"respect_crawl_delay" bson:"respect_crawl_delay,omitempty"`
+	// RobotsCache is a storage keeping the fetched robots.txt files, so they are not fetched again
+	// by the other collectors and the later runs. nil means the robots.txt files are only kept in memory.
+	RobotsCache CacheStorage `json:"-" bson:"-"`
+	// RobotsCacheTTL is the lifetime of the robots.txt files in the robots cache. 0 means DEFAULT_ROBOTS_CACHE_TTL.
+	RobotsCacheTTL time.Duration `json:"robots_cache_ttl" bson:"robots_cache_ttl,omitempty"`
+	// Compliance tells whether or not the collector runs in compliance mode. Use SetCompliance to turn it on.
+	Compliance bool `json:"compliance" bson:"compliance,omitempty"`
+	// StripParams is the list of query parameters removed from the URLs before storage.
+	// Parameters ending with an asterisk match by prefix.
+	StripParams []string `json:"strip_params" bson:"strip_params,omitempty"`
+	// LogStrippedParams enables logging the query parameters removed by the ParamStripper.
+	LogStrippedParams bool `json:"log_stripped_params" bson:"log_stripped_params,omitempty"`
 	// DetectCharset enables character encoding detection for non-UTF8 response bodies
 	// without explicit charset declaration. This feature uses https://github.com/saintfish/chardet.
 	DetectCharset bool `json:"detect_charset" bson:"detect_charset,omitempty"`
-	// TODO use this value, if false:
-	// c.redirectHandler = func(req *http.Request, via []*http.Request) error {
-	// 		return http.ErrUseLastResponse
-	// 	}
+	// DetectLanguage enables language detection for textual response bodies. See Response.Language.
+	DetectLanguage bool `json:"detect_language" bson:"detect_language,omitempty"`
+	// SniffContentType enables sniffing the media type of the response body if the Content-Type
+	// header is missing or text/plain. The HTML and XML callbacks use the sniffed type, see Response.SniffedType.
+	SniffContentType bool `json:"sniff_content_type" bson:"sniff_content_type,omitempty"`
+	// FollowRedirects, if false, prevents the HTTP client from following the HTTP redirects.
 	FollowRedirects bool `json:"follow_redirects" bson:"follow_redirects,omitempty"`
+	// MaxRedirects limits the number of redirects followed by a request. The HTTP redirects, the meta refresh
+	// and the script redirects of a chain are counted together. 0 means unlimited.
+	MaxRedirects uint `json:"max_redirects" bson:"max_redirects,omitempty"`
+	// FollowRefresh enables following the redirects made by Refresh headers,
+	// <meta http-equiv="refresh"> tags and trivial location scripts.
+	FollowRefresh bool `json:"follow_refresh" bson:"follow_refresh,omitempty"`
+	// MaxTruncatedRetries is the number of times a truncated response is requested again,
+	// see Response.Truncated. 0 means the truncated responses are not retried.
+	MaxTruncatedRetries uint `json:"max_truncated_retries" bson:"max_truncated_retries,omitempty"`
+	// MaxRequestLifetime is the longest time a request can be in flight, from the end of the OnRequest
+	// callbacks to the end of the response processing. The requests exceeding it are cancelled by a watchdog
+	// and fail with an ErrRequestStuck error, see Collector.Snapshot. 0 means no limit.
+	MaxRequestLifetime time.Duration `json:"max_request_lifetime" bson:"max_request_lifetime,omitempty"`
+	// IdleTimeout is the longest time without receiving data, the response headers or a chunk of the body.
+	// It is reset by every received chunk, so the slowly streaming endpoints, e.g. long-polling APIs, are
+	// not cancelled while they are sending data. The requests exceeding it fail with an ErrIdleTimeout error.
+	// Use it with no HTTP client timeout and MaxRequestLifetime as the total timeout. 0 means no idle timeout.
+	IdleTimeout time.Duration `json:"idle_timeout" bson:"idle_timeout,omitempty"`
+	// RequeueStuck submits the requests cancelled by the watchdog once more, instead of failing them.
+	RequeueStuck bool `json:"requeue_stuck" bson:"requeue_stuck,omitempty"`
+	// NegativeCacheTTL is how long the permanent failures of the URLs, the 404 and 410 responses
+	// and the unknown hosts, are remembered. The GET and HEAD requests of a remembered URL fail with
+	// ErrNegativeCacheHit without being sent, unless they have a "Cache-Control: no-cache" header.
+	// The transient failures are never remembered. 0 turns off the negative caching.
+	NegativeCacheTTL time.Duration `json:"negative_cache_ttl" bson:"negative_cache_ttl,omitempty"`
+	// HealthThreshold is the number of the consecutive failures of a host, the transport errors, the 429
+	// and the server error responses, after which the requests of the host wait for a backoff period.
+	// The period starts from HealthBackoff and doubles with every further failure. 0 turns off the failure memory.
+	HealthThreshold uint `json:"health_threshold" bson:"health_threshold,omitempty"`
+	// HealthBackoff is the first backoff period of a failing host. 0 means DEFAULT_HEALTH_BACKOFF.
+	HealthBackoff time.Duration `json:"health_backoff" bson:"health_backoff,omitempty"`
+	// HealthStorage keeps the failure memory of the hosts across the runs, see Collector.HostHealth.
+	HealthStorage CacheStorage `json:"-" bson:"-"`
+	// TrapWindow is the number of the consecutive byte-identical responses of a host that indicate
+	// a spider trap, e.g. an infinite calendar serving the same page for every date. The trapped responses
+	// are logged and counted, see Request.Trapped. 0 turns off the trap detection.
+	TrapWindow uint `json:"trap_window" bson:"trap_window,omitempty"`
+	// TrapStopFollow rejects the requests created from the trapped responses with ErrSpiderTrap,
+	// so the links of the trapped branch are not followed.
+	TrapStopFollow bool `json:"trap_stop_follow" bson:"trap_stop_follow,omitempty"`
+	// AcceptEncoding is the list of the content codings sent in the Accept-Encoding header.
+	// Leave it blank to let the HTTP transport request gzip compression transparently.
+	// Only gzip and deflate encoded responses will be decoded.
+	AcceptEncoding []string `json:"accept_encoding" bson:"accept_encoding,omitempty"`
+	// CompressBodySize is the minimum size of the request body in bytes to be gzip compressed.
+	// Bodies will not be compressed for the hosts that refused it before. 0 means no compression.
+	CompressBodySize uint `json:"compress_body_size" bson:"compress_body_size,omitempty"`
+	// ReuseMemory enables pooling the response body buffers and the HTML/XML elements.
+	// Responses are released after the OnScraped callbacks, so neither the response body nor
+	// the elements may be retained after the callbacks return. Use Response.RetainBody to keep a copy.
+	ReuseMemory bool `json:"reuse_memory" bson:"reuse_memory,omitempty"`
+	// DuplicateAnalysis groups the crawled HTML pages by canonical chains, duplicate titles
+	// or identical bodies in the crawl report. See Collector.Report.
+	DuplicateAnalysis DuplicateAnalysis `json:"duplicate_analysis" bson:"duplicate_analysis,omitempty"`
+	// SkipCachedCallbacks skips the user callbacks of the responses served from the cache,
+	// from the response header callbacks to the scraped callbacks. See Response.FromCache.
+	SkipCachedCallbacks bool `json:"skip_cached_callbacks" bson:"skip_cached_callbacks,omitempty"`
+	// IdempotencyKeys generates an Idempotency-Key header for the POST and PATCH requests.
+	// The key is stored with the request, so the retries can't duplicate the mutations.
+	IdempotencyKeys bool `json:"idempotency_keys" bson:"idempotency_keys,omitempty"`
+	// Role tags the visits of the collector in the shared visit registry, e.g. "listing" or "detail".
+	Role string `json:"role" bson:"role,omitempty"`
+	// VisitRegistry is the visit registry shared with other collectors, see SetSharedVisits.
+	VisitRegistry *filters.VisitRegistry `json:"-" bson:"-"`
+	// Validators keeps the ETag and Last-Modified headers of the visited URLs, see SetConditionalRevisits.
+	Validators *filters.ValidatorStore `json:"-" bson:"-"`
+	// AliasStorage records the raw URLs collapsed into each canonical page by the URL normalization
+	// and the canonical links, see CrawlReport.Aliases.
+	AliasStorage storage.AliasStorage `json:"-" bson:"-"`
+	// FailureJournal records the permanently failed requests, see Collector.ReplayFailures.
+	FailureJournal *FailureJournal `json:"-" bson:"-"`
+	// SharedLimiter shares the request delays with the other collector instances, so the instances
+	// crawling the same host keep the delay together. The buckets are keyed by LimitKeyCallback or the host.
+	SharedLimiter SharedRateLimiter `json:"-" bson:"-"`
+	// Limiter paces the requests of the hosts instead of the delays of the client configurations,
+	// e.g. a TokenBucketLimiter with the request budgets of the domains shared by several collectors.
+	Limiter Limiter `json:"-" bson:"-"`
+	// MemoryGovernor caps the memory of the job queue and the cache, see SetMemoryCap.
+	MemoryGovernor *MemoryGovernor `json:"-" bson:"-"`
+	// Authenticator answers the HTTP 401 and 407 challenges with the credentials of the matching hosts
+	// and the proxy. Rejected credentials are returned as errors of the ErrAuthFailed class.
+	Authenticator *Authenticator `json:"-" bson:"-"`
+	// DryRun runs the filters, the URL normalization, the robots.txt rules and the OnRequest callbacks
+	// of the requests, but never sends them. The requests that would be fetched and the skipped ones are logged,
+	// see Collector.DryRunPlan. The robots.txt rules are only checked for the hosts whose robots.txt was loaded.
+	DryRun bool `json:"dry_run" bson:"dry_run,omitempty"`
+	// DebugSelectors logs a DEBUG event with nearest-miss diagnostics for the OnHTML selectors
+	// that match nothing on a page, to help fixing the selectors after the markup was changed.
+	DebugSelectors bool `json:"debug_selectors" bson:"debug_selectors,omitempty"`
+	// CacheSelectorPlans caches the matches of the OnHTML selectors by the structure of the pages,
+	// so the pages generated from the same template are not matched again. Selectors with attribute
+	// conditions or text pseudo-classes like :contains are always matched. See Collector.SelectorPlanStats.
+	CacheSelectorPlans bool `json:"cache_selector_plans" bson:"cache_selector_plans,omitempty"`
+	// ParseNestedDocuments runs the OnHTML callbacks on the documents embedded by <iframe srcdoc>
+	// and by data: URLs of frames and objects, as if they were pages with a synthetic URL, see Response.IsNested.
+	ParseNestedDocuments bool `json:"parse_nested_documents" bson:"parse_nested_documents,omitempty"`
+	// SlowCallback is the execution time of a user callback that is logged as a WARN event
+	// with the source location of the callback. 0 turns off the callback timing.
+	SlowCallback time.Duration `json:"slow_callback" bson:"slow_callback,omitempty"`
+	// CallbackTimeout is the execution time budget of a user callback. The context returned by
+	// Request.CallbackContext is cancelled when it's exceeded and an ERROR event is logged. 0 means no timeout.
+	CallbackTimeout time.Duration `json:"callback_timeout" bson:"callback_timeout,omitempty"`
+	// SessionAffinity binds the requests of the same host or identity to a persistent client
+	// with its own connection, cookie jar and proxy, instead of the shared connection pool.
+	SessionAffinity SessionAffinity `json:"session_affinity" bson:"session_affinity,omitempty"`
+	// SessionIdleTimeout is how long an unused session client is kept. The evicted sessions lose
+	// their connection and pinned proxy, the cookies are kept by a cookie jar with storage. 0 keeps them.
+	SessionIdleTimeout time.Duration `json:"session_idle_timeout" bson:"session_idle_timeout,omitempty"`
 	// CheckHead performs a HEAD request before every GET to pre-validate the response.
 	CheckHead bool `json:"check_head" bson:"check_head,omitempty"`
+	// ParseWorkers is the number of the workers parsing the responses and running the HTML, XML
+	// and scraped callbacks, decoupled from the network I/O. 0 parses on the fetching goroutine.
+	ParseWorkers uint `json:"parse_workers" bson:"parse_workers,omitempty"`
+	// ParseQueueSize is the number of the responses waiting for a parse worker.
+	// The fetching goroutines are blocked while the queue is full.
+	ParseQueueSize uint `json:"parse_queue_size" bson:"parse_queue_size,omitempty"`
+	// WarmUpHosts is the number of the most frequent origins of the seed URLs whose connections
+	// are established before the crawl starts, see Client.WarmUp. 0 means no warm-up.
+	WarmUpHosts uint `json:"warm_up_hosts" bson:"warm_up_hosts,omitempty"`
+	// WarmUpThreads is the maximum number of concurrent connection warm-ups. 0 means the default of 4.
+	WarmUpThreads uint `json:"warm_up_threads" bson:"warm_up_threads,omitempty"`
 	// Async turns on asynchronous network communication. Use Collector.Wait() to
 	// be sure all requests have been finished.
 	Async bool `json:"async" bson:"async,omitempty"`
@@ -67,7 +205,37 @@ type CollectorConfig struct {
 	UserAgentCallback `json:"user_agent_callback" bson:"user_agent_callback,omitempty"`
 	// HeaderCallback is a callback to create common headers for each request.
 	HeaderCallback `json:"header_callback" bson:"header_callback,omitempty"`
+	// PriorityCallback sets the priority of the requests created from a scored response.
+	// If blank, the requests inherit the score of the parent, see InheritScore.
+	PriorityCallback `json:"priority_callback" bson:"priority_callback,omitempty"`
+	// LimitKeyCallback derives the rate limit bucket of the requests, e.g. from the auth token.
+	// The requests of the same bucket are paced by Delay and limited to MaxThreads of the matching
+	// settings. If blank, the Delay is applied to all requests alike. See LimitByHost, LimitByIdentity.
+	LimitKeyCallback `json:"limit_key_callback" bson:"limit_key_callback,omitempty"`
 
+	// HeaderProfile is a browser-like header profile. If set, the request headers are written
+	// in the order of the profile by an HTTP/1.1 transport. Use SetHeaderProfile to set it.
+	HeaderProfile *HeaderProfile `json:"header_profile" bson:"header_profile,omitempty"`
+	// Transport is a custom round tripper routing all requests of the collector, e.g. a recording proxy
+	// or a corporate gateway. The cache, the authentication, the retries and the tracing work on top of it.
+	// The TLS settings and the header order are only applied if it is an *http.Transport.
+	Transport http.RoundTripper `json:"-" bson:"-"`
+	// ClientOptions tune the transport of the HTTP client, e.g. the keep-alives or HTTP/2, see NewClient.
+	ClientOptions []ClientOption `json:"-" bson:"-"`
+	// HTTP3Transport is the HTTP/3 round tripper of the requests matching a protocol override with the HTTP3 rule,
+	// e.g. the transport of the http3 package. The requests fall back to the other transports if HTTP/3 fails.
+	HTTP3Transport http.RoundTripper `json:"-" bson:"-"`
+	// TLSConfig is the TLS configuration of all transports, including the session transports.
+	TLSConfig *tls.Config `json:"tls_config" bson:"tls_config,omitempty"`
+	// TLSSessionCache is a TLS session cache shared by all transports to resume the sessions
+	// across the connection pool, the sessions and the proxies.
+	TLSSessionCache *TLSSessionCache `json:"tls_session_cache" bson:"tls_session_cache,omitempty"`
+	// HostAliases redirects the connections of the hosts to other addresses, e.g. to run a crawl against
+	// a staging environment. The keys are host names or host:port pairs. Use SetHostAlias to add one.
+	HostAliases map[string]HostAlias `json:"host_aliases" bson:"host_aliases,omitempty"`
+	// Namespace isolates the data of the collector in shared cache, cookie, visit and queue storages.
+	// Use SetNamespace before attaching the storages.
+	Namespace string `json:"namespace" bson:"namespace,omitempty"`
 	// Queue is a the underlying storage of the job queue.
 	// If missing, an in-memory storage will be created.
 	Queue `json:"queue" bson:"queue,omitempty"`
@@ -77,12 +245,25 @@ type CollectorConfig struct {
 	CookieJar http.CookieJar `json:"cookie_jar" bson:"cookie_jar,omitempty"`
 	// Parser represents an URL parser service.
 	Parser `json:"parser" bson:"parser,omitempty"`
+	// URLRewriter rewrites the URLs of the requests right before they are sent, e.g. to use a mirror host.
+	// The original URLs are used for the visit accounting, the caching and the reports.
+	URLRewriter URLRewriter `json:"url_rewriter" bson:"url_rewriter,omitempty"`
+	// ParamStripper removes the query parameters from the URLs by global or domain specific rules.
+	// The parameters are stripped by the URL parser, before filtering, visiting and caching.
+	ParamStripper *ParamStripper `json:"param_stripper" bson:"param_stripper,omitempty"`
+	// FragmentMode tells how the URL fragments are handled by the URL parser, before filtering, visiting and caching.
+	// Single page applications encoding the routes in the fragments need FRAGMENT_KEEP or FRAGMENT_HASHBANG.
+	FragmentMode FragmentMode `json:"fragment_mode" bson:"fragment_mode,omitempty"`
 	// Proxy is a represents a web proxy service.
 	Proxy `json:"proxy" bson:"proxy,omitempty"`
 	// Tracer attaches a tracing service to enable capturing and reporting request performance for crawler tuning.
 	Tracer `json:"tracer" bson:"tracer,omitempty"`
 	// Logger logs the collector events.
 	Logger `json:"logger" bson:"logger,omitempty"`
+	// Sampler captures the request/response dumps of a sample of the requests for debugging.
+	Sampler *Sampler `json:"sampler" bson:"sampler,omitempty"`
+	// Archiver persists the bodies of the selected responses with their metadata, see BodyArchiver.
+	Archiver *BodyArchiver `json:"archiver" bson:"archiver,omitempty"`
 
 	// SubConfigs is a list of configuration settings that based on URL filter criteria.
 	SubConfigs []*SubConfig `json:"filtered_configs" bson:"filtered_configs,omitempty"`
@@ -102,6 +283,14 @@ type SubConfig struct {
 	RandomDelay time.Duration `json:"random_delay" bson:"random_delay,omitempty"`
 	// MaxThreads is the number of the maximum allowed concurrent requests of the matching domains.
 	MaxThreads uint `json:"max_threads" bson:"max_threads,omitempty"`
+	// ForceType replaces the Content-Type header of the matching responses, e.g. for hosts mislabelling their content.
+	// The HTML and XML callbacks use the forced type and no sniffing takes place.
+	ForceType string `json:"force_type" bson:"force_type,omitempty"`
+	// ForceCharset is the character set of the matching responses, skipping the charset detection.
+	ForceCharset string `json:"force_charset" bson:"force_charset,omitempty"`
+	// Protocol downgrades the HTTP and TLS protocols of the matching requests, e.g. for legacy hosts
+	// breaking on HTTP/2 or TLS 1.3. See NewProtocolOverride.
+	Protocol ProtocolRules `json:"protocol" bson:"protocol,omitempty"`
 }
 
 // ------------------------------------------------------------------------
@@ -110,6 +299,196 @@ var EnvMap = map[string]EnvConfigSetter{
 	"ALLOWED_DOMAINS":    func(c *CollectorConfig, val string) { c.SetAllowedDomains(strings.Split(val, ",")) },
 	"DISALLOWED_DOMAINS": func(c *CollectorConfig, val string) { c.SetDisallowedDomains(strings.Split(val, ",")) },
 	"USER_AGENT":         func(c *CollectorConfig, val string) { c.UserAgentCallback = func() string { return val } },
+	"DETECT_LANGUAGE": func(c *CollectorConfig, val string) {
+		if b, err := StrToBool(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("DETECT_LANGUAGE error: %v", err))
+		} else {
+			c.DetectLanguage = b
+		}
+	},
+	"DUPLICATE_ANALYSIS": func(c *CollectorConfig, val string) {
+		if a, err := ParseDuplicateAnalysis(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("DUPLICATE_ANALYSIS error: invalid value %q", val))
+		} else {
+			c.DuplicateAnalysis = a
+		}
+	},
+	"SKIP_CACHED_CALLBACKS": func(c *CollectorConfig, val string) {
+		if b, err := StrToBool(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("SKIP_CACHED_CALLBACKS error: %v", err))
+		} else {
+			c.SkipCachedCallbacks = b
+		}
+	},
+	"IDEMPOTENCY_KEYS": func(c *CollectorConfig, val string) {
+		if b, err := StrToBool(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("IDEMPOTENCY_KEYS error: %v", err))
+		} else {
+			c.IdempotencyKeys = b
+		}
+	},
+	"SLOW_CALLBACK": func(c *CollectorConfig, val string) {
+		if d, err := time.ParseDuration(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("SLOW_CALLBACK error: %v", err))
+		} else {
+			c.SlowCallback = d
+		}
+	},
+	"CALLBACK_TIMEOUT": func(c *CollectorConfig, val string) {
+		if d, err := time.ParseDuration(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("CALLBACK_TIMEOUT error: %v", err))
+		} else {
+			c.CallbackTimeout = d
+		}
+	},
+	"CACHE_SELECTOR_PLANS": func(c *CollectorConfig, val string) {
+		if b, err := StrToBool(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("CACHE_SELECTOR_PLANS error: %v", err))
+		} else {
+			c.CacheSelectorPlans = b
+		}
+	},
+	"PARSE_NESTED_DOCUMENTS": func(c *CollectorConfig, val string) {
+		if b, err := StrToBool(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("PARSE_NESTED_DOCUMENTS error: %v", err))
+		} else {
+			c.ParseNestedDocuments = b
+		}
+	},
+	"DEBUG_SELECTORS": func(c *CollectorConfig, val string) {
+		if b, err := StrToBool(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("DEBUG_SELECTORS error: %v", err))
+		} else {
+			c.DebugSelectors = b
+		}
+	},
+	"DRY_RUN": func(c *CollectorConfig, val string) {
+		if b, err := StrToBool(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("DRY_RUN error: %v", err))
+		} else {
+			c.DryRun = b
+		}
+	},
+	"SNIFF_CONTENT_TYPE": func(c *CollectorConfig, val string) {
+		if b, err := StrToBool(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("SNIFF_CONTENT_TYPE error: %v", err))
+		} else {
+			c.SniffContentType = b
+		}
+	},
+	"SESSION_AFFINITY": func(c *CollectorConfig, val string) {
+		switch strings.ToLower(strings.TrimSpace(val)) {
+		case "domain":
+			c.SessionAffinity = SESSION_AFFINITY_DOMAIN
+		case "identity":
+			c.SessionAffinity = SESSION_AFFINITY_IDENTITY
+		case "", "off":
+			c.SessionAffinity = SESSION_AFFINITY_OFF
+		default:
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("SESSION_AFFINITY error: invalid value %q", val))
+		}
+	},
+	"LIMIT_KEY": func(c *CollectorConfig, val string) {
+		switch strings.ToLower(strings.TrimSpace(val)) {
+		case "host":
+			c.LimitKeyCallback = LimitByHost
+		case "identity":
+			c.LimitKeyCallback = LimitByIdentity
+		case "", "off":
+			c.LimitKeyCallback = nil
+		default:
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("LIMIT_KEY error: invalid value %q", val))
+		}
+	},
+	"RESPECT_CRAWL_DELAY": func(c *CollectorConfig, val string) {
+		if b, err := StrToBool(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("RESPECT_CRAWL_DELAY error: %v", err))
+		} else {
+			c.RespectCrawlDelay = b
+		}
+	},
+	"ROBOTS_CACHE_TTL": func(c *CollectorConfig, val string) {
+		if d, err := time.ParseDuration(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("ROBOTS_CACHE_TTL error: %v", err))
+		} else {
+			c.RobotsCacheTTL = d
+		}
+	},
+	"STRIP_PARAMS": func(c *CollectorConfig, val string) { c.StripParams = strings.Split(val, ",") },
+	"LOG_STRIPPED_PARAMS": func(c *CollectorConfig, val string) {
+		if b, err := StrToBool(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("LOG_STRIPPED_PARAMS error: %v", err))
+		} else {
+			c.LogStrippedParams = b
+		}
+	},
+	"FRAGMENT_MODE": func(c *CollectorConfig, val string) {
+		switch strings.ToLower(strings.TrimSpace(val)) {
+		case "", "default":
+			c.FragmentMode = FRAGMENT_DEFAULT
+		case "strip":
+			c.FragmentMode = FRAGMENT_STRIP
+		case "keep":
+			c.FragmentMode = FRAGMENT_KEEP
+		case "hashbang":
+			c.FragmentMode = FRAGMENT_HASHBANG
+		default:
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("FRAGMENT_MODE error: invalid value %q", val))
+		}
+	},
+	"HEADER_PROFILE": func(c *CollectorConfig, val string) {
+		if p := HeaderProfileByName(val); p != nil {
+			c.SetHeaderProfile(p)
+		} else {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("HEADER_PROFILE error: unknown profile %q", val))
+		}
+	},
+	"HOST_ALIASES": func(c *CollectorConfig, val string) {
+		for _, pair := range strings.Split(val, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			host, target, found := strings.Cut(pair, "=")
+			if !found || target == "" {
+				c.logError(LOG_WARN_LEVEL, fmt.Errorf("HOST_ALIASES error: invalid value %q", pair))
+			} else if err := c.SetHostAlias(host, target); err != nil {
+				c.logError(LOG_WARN_LEVEL, fmt.Errorf("HOST_ALIASES error: %v", err))
+			}
+		}
+	},
+	"PARSE_WORKERS": func(c *CollectorConfig, val string) {
+		if n, err := StrToUInt(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("PARSE_WORKERS error: %v", err))
+		} else {
+			c.ParseWorkers = n
+		}
+	},
+	"PARSE_QUEUE_SIZE": func(c *CollectorConfig, val string) {
+		if n, err := StrToUInt(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("PARSE_QUEUE_SIZE error: %v", err))
+		} else {
+			c.ParseQueueSize = n
+		}
+	},
+	"WARM_UP_HOSTS": func(c *CollectorConfig, val string) {
+		if n, err := StrToUInt(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("WARM_UP_HOSTS error: %v", err))
+		} else {
+			c.WarmUpHosts = n
+		}
+	},
+	"WARM_UP_THREADS": func(c *CollectorConfig, val string) {
+		if n, err := StrToUInt(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("WARM_UP_THREADS error: %v", err))
+		} else {
+			c.WarmUpThreads = n
+		}
+	},
+	"NAMESPACE": func(c *CollectorConfig, val string) {
+		if err := c.SetNamespace(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("NAMESPACE error: %v", err))
+		}
+	},
 	"DETECT_CHARSET": func(c *CollectorConfig, val string) {
 		if b, err := StrToBool(val); err != nil {
 			c.logError(LOG_WARN_LEVEL, fmt.Errorf("DETECT_CHARSET error: %v", err))
@@ -131,10 +510,145 @@ var EnvMap = map[string]EnvConfigSetter{
 			c.FollowRedirects = b
 		}
 	},
+	"FOLLOW_REFRESH": func(c *CollectorConfig, val string) {
+		if b, err := StrToBool(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("FOLLOW_REFRESH error: %v", err))
+		} else {
+			c.FollowRefresh = b
+		}
+	},
+	"MAX_REDIRECTS": func(c *CollectorConfig, val string) {
+		if n, err := StrToUInt(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("MAX_REDIRECTS error: %v", err))
+		} else {
+			c.MaxRedirects = n
+		}
+	},
+	"MAX_TRUNCATED_RETRIES": func(c *CollectorConfig, val string) {
+		if n, err := StrToUInt(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("MAX_TRUNCATED_RETRIES error: %v", err))
+		} else {
+			c.MaxTruncatedRetries = n
+		}
+	},
+	"MAX_REQUEST_LIFETIME": func(c *CollectorConfig, val string) {
+		if d, err := time.ParseDuration(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("MAX_REQUEST_LIFETIME error: %v", err))
+		} else {
+			c.MaxRequestLifetime = d
+		}
+	},
+	"IDLE_TIMEOUT": func(c *CollectorConfig, val string) {
+		if d, err := time.ParseDuration(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("IDLE_TIMEOUT error: %v", err))
+		} else {
+			c.IdleTimeout = d
+		}
+	},
+	"REQUEUE_STUCK": func(c *CollectorConfig, val string) {
+		if b, err := StrToBool(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("REQUEUE_STUCK error: %v", err))
+		} else {
+			c.RequeueStuck = b
+		}
+	},
+	"SESSION_IDLE_TIMEOUT": func(c *CollectorConfig, val string) {
+		if d, err := time.ParseDuration(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("SESSION_IDLE_TIMEOUT error: %v", err))
+		} else {
+			c.SessionIdleTimeout = d
+		}
+	},
+	"NEGATIVE_CACHE_TTL": func(c *CollectorConfig, val string) {
+		if d, err := time.ParseDuration(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("NEGATIVE_CACHE_TTL error: %v", err))
+		} else {
+			c.NegativeCacheTTL = d
+		}
+	},
+	"HEALTH_THRESHOLD": func(c *CollectorConfig, val string) {
+		if n, err := StrToUInt(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("HEALTH_THRESHOLD error: %v", err))
+		} else {
+			c.HealthThreshold = n
+		}
+	},
+	"HEALTH_BACKOFF": func(c *CollectorConfig, val string) {
+		if d, err := time.ParseDuration(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("HEALTH_BACKOFF error: %v", err))
+		} else {
+			c.HealthBackoff = d
+		}
+	},
+	"TRAP_WINDOW": func(c *CollectorConfig, val string) {
+		if n, err := StrToUInt(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("TRAP_WINDOW error: %v", err))
+		} else {
+			c.TrapWindow = n
+		}
+	},
+	"TRAP_STOP_FOLLOW": func(c *CollectorConfig, val string) {
+		if b, err := StrToBool(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("TRAP_STOP_FOLLOW error: %v", err))
+		} else {
+			c.TrapStopFollow = b
+		}
+	},
+	"ACCEPT_ENCODING": func(c *CollectorConfig, val string) {
+		c.AcceptEncoding = nil
+		for _, enc := range strings.Split(val, ",") {
+			if enc = strings.TrimSpace(enc); enc != "" {
+				c.AcceptEncoding = append(c.AcceptEncoding, enc)
+			}
+		}
+	},
+	"COMPRESS_BODY_SIZE": func(c *CollectorConfig, val string) {
+		if n, err := StrToUInt(val); err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("COMPRESS_BODY_SIZE error: %v", err))
+		} else {
+			c.CompressBodySize = n
+		}
+	},
 	"CACHE_DIR": func(c *CollectorConfig, val string) {
 		// FIXME Create filesystem Cache and set the directory
 		// c.CacheDir = val
 	},
+	"CACHE_HEADERS": func(c *CollectorConfig, val string) {
+		if ch, ok := c.Cache.(*cache); ok {
+			var headers []string
+			for _, h := range strings.Split(val, ",") {
+				if h = strings.TrimSpace(h); h != "" {
+					headers = append(headers, h)
+				}
+			}
+			ch.SetHeaderAllowList(headers...)
+		}
+	},
+	"CACHE_CODEC": func(c *CollectorConfig, val string) {
+		if ch, ok := c.Cache.(*cache); ok {
+			if val == "" || val == "none" {
+				ch.SetCodec(nil)
+			} else if codec := CacheCodecByName(val); codec != nil {
+				ch.SetCodec(codec)
+			} else {
+				c.logError(LOG_WARN_LEVEL, fmt.Errorf("CACHE_CODEC error: unknown codec %q", val))
+			}
+		}
+	},
+	"CACHE_DEDUP": func(c *CollectorConfig, val string) {
+		b, err := StrToBool(val)
+		if err != nil {
+			c.logError(LOG_WARN_LEVEL, fmt.Errorf("CACHE_DEDUP error: %v", err))
+			return
+		}
+		if ch, ok := c.Cache.(*cache); ok {
+			if b {
+				ch.SetBlobStore(ch.stg)
+			} else {
+				ch.SetBlobStore(nil)
+			}
+		}
+	},
 	"DISABLE_COOKIES": func(c *CollectorConfig, _ string) {
 		// TODO Create CookieJar interface first
 		// FIXME c.CookieJar == nil
@@ -208,6 +722,8 @@ func NewConfig() *CollectorConfig {
 		Cache:               cache,
 		ParseStatusCallback: parseSuccessResponse,
 		FollowRedirects:     true,
+		MaxRedirects:        10,
+		SessionIdleTimeout:  5 * time.Minute,
 		CookieJar:           jar,
 		Parser:              NewWHATWGParser(),
 	}
@@ -326,6 +842,11 @@ func (c *CollectorConfig) SetCache(storage CacheStorage, expHandler CacheExpiryH
 		return ErrCacheNoExpHandler
 	}
 
+	storage, err := c.isolateCache(storage)
+	if err != nil {
+		return err
+	}
+
 	cache, err := NewCache(storage, expHandler)
 	if err != nil {
 		return err
@@ -360,6 +881,69 @@ func (c *CollectorConfig) SetFileCache(path string, expHandler CacheExpiryHandle
 	return nil
 }
 
+// SetCookieJar sets a cookie jar with the given storage and RFC 6265 compliance mode.
+// If no storage is given, the cookies will be stored in the memory.
+func (c *CollectorConfig) SetCookieJar(storage CookieStorage, mode CookieMode) error {
+	storage, err := c.isolateCookies(storage)
+	if err != nil {
+		return err
+	}
+
+	jar, err := NewCookieJar(storage, nil, mode)
+	if err != nil {
+		return err
+	}
+	c.CookieJar = jar
+
+	return nil
+}
+
+// SetCookiePolicy sets the policy consulted by the cookie jar for every received cookie.
+// Jars without policy support are wrapped, nil removes the policy of the built-in jar.
+func (c *CollectorConfig) SetCookiePolicy(policy CookiePolicy) error {
+	switch jar := c.CookieJar.(type) {
+	case nil:
+		return ErrNoCookieJar
+	case *cookieJar:
+		jar.SetPolicy(policy)
+	case *policyJar:
+		if policy == nil && jar.duplicates == nil {
+			c.CookieJar = jar.CookieJar
+		} else {
+			jar.policy = policy
+		}
+	default:
+		if policy != nil {
+			c.CookieJar = &policyJar{CookieJar: jar, policy: policy}
+		}
+	}
+
+	return nil
+}
+
+// SetDuplicateCookiePolicy sets the policy resolving the cookies of a response with the same name,
+// domain and path, e.g. FirstCookieWins. Jars without policy support are wrapped, nil keeps the last one.
+func (c *CollectorConfig) SetDuplicateCookiePolicy(policy DuplicateCookiePolicy) error {
+	switch jar := c.CookieJar.(type) {
+	case nil:
+		return ErrNoCookieJar
+	case *cookieJar:
+		jar.SetDuplicatePolicy(policy)
+	case *policyJar:
+		if policy == nil && jar.policy == nil {
+			c.CookieJar = jar.CookieJar
+		} else {
+			jar.duplicates = policy
+		}
+	default:
+		if policy != nil {
+			c.CookieJar = &policyJar{CookieJar: jar, duplicates: policy}
+		}
+	}
+
+	return nil
+}
+
 // SetMaxRevisits sets how many times the same URL can be visited.
 // The storage attribute, if not nil, will be used to store the number of visits.
 // If no storage is given, the visits will be used in the memory.
@@ -371,6 +955,11 @@ func (c *CollectorConfig) SetMaxRevisits(maxRevisits uint, storage ...filters.Vi
 		stg = storage[0]
 	}
 
+	stg, err := c.isolateVisits(stg)
+	if err != nil {
+		return err
+	}
+
 	if c.Filter == nil {
 		c.Filter = NewFilter()
 	}
@@ -378,6 +967,25 @@ func (c *CollectorConfig) SetMaxRevisits(maxRevisits uint, storage ...filters.Vi
 	return c.Filter.AddRevisit(maxRevisits, stg, "revisit")
 }
 
+// SetSharedVisits sets a visit registry shared with other collectors. The visits of the collector
+// are tagged by its role, and the scope tells whether the URLs visited by any collector or only the URLs
+// visited by the collectors of the same role are skipped after maxRevisits visits.
+func (c *CollectorConfig) SetSharedVisits(registry *filters.VisitRegistry, role string, scope filters.VisitScope, maxRevisits uint) error {
+	engine, err := filters.NewSharedVisitEngine(registry, role, scope, maxRevisits)
+	if err != nil {
+		return err
+	}
+
+	if c.Filter == nil {
+		c.Filter = NewFilter()
+	}
+
+	c.VisitRegistry = registry
+	c.Role = role
+
+	return c.Filter.AddEngine(FILTER_METHOD_EXCLUDE, URL_FILTER, engine, ErrFilterNoRevisit, "shared_visits")
+}
+
 // ------------------------------------------------------------------------
 
 // ParseSuccessResponse is a convenience method to enable parsing only the HTTP success responses.
@@ -402,11 +1010,24 @@ func (c *CollectorConfig) hasLogger() bool {
 }
 
 func (c *CollectorConfig) logError(level LogLevel, err error) {
-	if c.hasLogger() {
+	if c.logEnabled(level) {
 		c.Logger.LogError(level, err)
 	}
 }
 
+// logEnabled returns true if the logger logs the events of the level.
+func (c *CollectorConfig) logEnabled(level LogLevel) bool {
+	if !c.hasLogger() {
+		return false
+	}
+
+	if l, ok := c.Logger.(LevelLogger); ok {
+		return l.Enabled(level)
+	}
+
+	return true
+}
+
 func (c *CollectorConfig) mainConfig() *SubConfig {
 	return &SubConfig{
 		Filter:      c.Filter,
@@ -0,0 +1,212 @@
+package colly
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ------------------------------------------------------------------------
+
+// configSpecer is implemented by the configuration values whose state is not exported,
+// to describe their effective settings for the configuration hash.
+type configSpecer interface {
+	configSpec() string
+}
+
+// ------------------------------------------------------------------------
+
+// CONFIG_HASH_VERSION is the version of the configuration hash algorithm.
+// It is part of the hash, so the hashes of different versions never match.
+const CONFIG_HASH_VERSION = 1
+
+// configHashMaxDepth limits the nesting of the hashed configuration values.
+const configHashMaxDepth = 8
+
+// configHashEvents are the events whose callbacks make the extraction rules.
+var configHashEvents = []uint8{ON_HTML, ON_XML, ON_EXTRACT}
+
+// ------------------------------------------------------------------------
+
+// ConfigHash returns a short hash of the effective collector configuration and the extraction rules,
+// i.e. the selectors of the OnHTML, OnXML and OnExtract callbacks. The exported reports and records
+// are stamped with it, so a dataset can be traced back to the configuration that produced it.
+// Callback functions, storages and other pluggable services only contribute whether they are set
+// and their types. The hash changes with the configuration, so call it after setting up the collector.
+func (c *Collector) ConfigHash() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "version=%d\n", CONFIG_HASH_VERSION)
+
+	if c.Config != nil {
+		writeConfigValue(h, "config", reflect.ValueOf(c.Config).Elem(), 0)
+	}
+
+	if c.Callbacks != nil {
+		for _, event := range configHashEvents {
+			rules := c.Callbacks.Get(event)
+
+			selectors := make([]string, 0, len(rules))
+			for selector := range rules {
+				selectors = append(selectors, selector)
+			}
+			sort.Strings(selectors)
+
+			for _, selector := range selectors {
+				fmt.Fprintf(h, "%s[%q]=%d", eventNames[event], selector, len(rules[selector]))
+				for _, fn := range rules[selector] {
+					if ex, ok := fn.(*extractor); ok {
+						fmt.Fprintf(h, " %q", ex.attr)
+					}
+				}
+				io.WriteString(h, "\n")
+			}
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// ------------------------------------------------------------------------
+
+// writeConfigValue writes the canonical form of a configuration value, one line per scalar value.
+// Maps are written in key order, unexported struct fields and the fields excluded from JSON are skipped.
+func writeConfigValue(w io.Writer, path string, v reflect.Value, depth int) {
+	if depth > configHashMaxDepth {
+		return
+	}
+
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface || v.Kind() == reflect.Func {
+		if v.IsNil() {
+			fmt.Fprintf(w, "%s=nil\n", path)
+			return
+		}
+	}
+
+	// The structs embedding a configSpecer are walked field by field
+	if v.CanInterface() && !hasExportedFields(v.Type()) {
+		if s, ok := v.Interface().(configSpecer); ok {
+			fmt.Fprintf(w, "%s=%s\n", path, s.configSpec())
+			return
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		fmt.Fprintf(w, "%s=%v\n", path, v)
+	case reflect.Slice, reflect.Array:
+		fmt.Fprintf(w, "%s.len=%d\n", path, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			writeConfigValue(w, fmt.Sprintf("%s[%d]", path, i), v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]reflect.Value, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			k := fmt.Sprint(iter.Key())
+			keys = append(keys, k)
+			values[k] = iter.Value()
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintf(w, "%s.len=%d\n", path, v.Len())
+		for _, k := range keys {
+			writeConfigValue(w, fmt.Sprintf("%s[%q]", path, k), values[k], depth+1)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() || f.Tag.Get("json") == "-" {
+				continue
+			}
+			writeConfigValue(w, path+"."+f.Name, v.Field(i), depth+1)
+		}
+	case reflect.Ptr:
+		writeConfigValue(w, path, v.Elem(), depth+1)
+	case reflect.Interface:
+		fmt.Fprintf(w, "%s=%T\n", path, v.Elem().Interface())
+	case reflect.Func:
+		fmt.Fprintf(w, "%s=func\n", path)
+	}
+}
+
+// hasExportedFields tells whether the struct type, or the struct type the pointer type points to, has exported fields.
+func hasExportedFields(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ------------------------------------------------------------------------
+
+// configSpec describes the filter items in key order.
+func (f *Filter) configSpec() string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	var b strings.Builder
+	for _, list := range []struct {
+		method string
+		items  map[string]*filterItem
+	}{{"include", f.incl}, {"exclude", f.excl}} {
+		keys := make([]string, 0, len(list.items))
+		for key := range list.items {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			item := list.items[key]
+			fmt.Fprintf(&b, "%s[%q] %s ", list.method, key, item.scope)
+			if s, ok := item.engine.(fmt.Stringer); ok {
+				b.WriteString(s.String())
+			} else {
+				fmt.Fprintf(&b, "%T", item.engine)
+			}
+			b.WriteString("; ")
+		}
+	}
+
+	return b.String()
+}
+
+// configSpec describes the stripping rules in domain order.
+func (s *ParamStripper) configSpec() string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	domains := make([]string, 0, len(s.rules))
+	for domain := range s.rules {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	var b strings.Builder
+	for _, domain := range domains {
+		rule := s.rules[domain]
+		fmt.Fprintf(&b, "%q: %s", domain, strings.Join(rule.params, ", "))
+		for _, re := range rule.patterns {
+			fmt.Fprintf(&b, ", /%s/", re)
+		}
+		b.WriteString("; ")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package colly
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollector_ConfigHash(t *testing.T) {
+	newCollector := func() *Collector {
+		cfg := NewConfig()
+		cfg.MaxDepth = 3
+		cfg.Delay = time.Second
+		cfg.UserAgentCallback = func() string { return "bot" }
+		c := NewCollector(cfg, nil)
+		c.OnHTML("a[href]", func(e *HTMLElement) {})
+		c.OnExtract("title", "", func(r *Response, s string) {})
+		return c
+	}
+
+	base := newCollector().ConfigHash()
+	if len(base) != 16 {
+		t.Fatalf("ConfigHash() = %q, want 16 hex digits", base)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(c *Collector)
+		changed bool
+	}{
+		{"same configuration", func(c *Collector) {}, false},
+		{"other callback function", func(c *Collector) { c.Config.UserAgentCallback = func() string { return "other" } }, false},
+		{"config field", func(c *Collector) { c.Config.MaxDepth = 4 }, true},
+		{"duration", func(c *Collector) { c.Config.Delay = 2 * time.Second }, true},
+		{"callback unset", func(c *Collector) { c.Config.UserAgentCallback = nil }, true},
+		{"filter", func(c *Collector) { c.Config.SetAllowedDomains([]string{"example.com"}) }, true},
+		{"html selector", func(c *Collector) { c.OnHTML("title", func(e *HTMLElement) {}) }, true},
+		{"extract attribute", func(c *Collector) {
+			c.OnExtractDetach("title")
+			c.OnExtract("title", "lang", func(r *Response, s string) {})
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCollector()
+			tt.modify(c)
+			if got := c.ConfigHash(); (got != base) != tt.changed {
+				t.Errorf("ConfigHash() = %q, base %q, changed = %v", got, base, tt.changed)
+			}
+		})
+	}
+}
+
+func Test_writeConfigValue(t *testing.T) {
+	type inner struct {
+		Name string
+	}
+	type config struct {
+		Enabled  bool
+		Limit    uint
+		Hosts    map[string]inner
+		Patterns []string
+		Hook     func()
+		Ptr      *inner
+		Skipped  string `json:"-"`
+		hidden   string
+	}
+
+	cfg := config{
+		Enabled:  true,
+		Limit:    5,
+		Hosts:    map[string]inner{"b": {"two"}, "a": {"one"}},
+		Patterns: []string{"x"},
+		Hook:     func() {},
+		Skipped:  "skipped",
+		hidden:   "hidden",
+	}
+
+	var b strings.Builder
+	writeConfigValue(&b, "c", reflect.ValueOf(cfg), 0)
+
+	want := `c.Enabled=true
+c.Limit=5
+c.Hosts.len=2
+c.Hosts["a"].Name=one
+c.Hosts["b"].Name=two
+c.Patterns.len=1
+c.Patterns[0]=x
+c.Hook=func
+c.Ptr=nil
+`
+	if got := b.String(); got != want {
+		t.Errorf("writeConfigValue() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -15,15 +15,25 @@
 package colly
 
 import (
+	"bytes"
+	"context"
+	"encoding/gob"
 	"sync"
 )
 
-// Context provides a tiny layer for passing data between callbacks
+// Context provides a tiny layer for passing data between callbacks.
+// The context of a request is shared with its response and the requests created from it,
+// and it is serialized with the request, see Request.ToBytes.
+// The values of the serialized contexts must be gob encodable, the custom types must be
+// registered by gob.Register.
 type Context struct {
 	contextMap map[string]interface{}
 	lock       *sync.RWMutex
 }
 
+// contextValuesKey is the context key of the Context of a request.
+type contextValuesKey struct{}
+
 // NewContext initializes a new Context instance
 func NewContext() *Context {
 	return &Context{
@@ -32,16 +42,39 @@ func NewContext() *Context {
 	}
 }
 
-// UnmarshalBinary decodes Context value to nil
-// This function is used by request caching
-func (c *Context) UnmarshalBinary(_ []byte) error {
+// UnmarshalBinary decodes Context value
+// This function is used by request caching and queue storages
+func (c *Context) UnmarshalBinary(data []byte) error {
+	m := make(map[string]interface{})
+	if len(data) > 0 {
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&m); err != nil {
+			return err
+		}
+	}
+
+	if c.lock == nil {
+		c.lock = &sync.RWMutex{}
+	}
+
+	c.lock.Lock()
+	c.contextMap = m
+	c.lock.Unlock()
+
 	return nil
 }
 
 // MarshalBinary encodes Context value
-// This function is used by request caching
-func (c *Context) MarshalBinary() (_ []byte, _ error) {
-	return nil, nil
+// This function is used by request caching and queue storages
+func (c *Context) MarshalBinary() ([]byte, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	b := &bytes.Buffer{}
+	if err := gob.NewEncoder(b).Encode(c.contextMap); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
 }
 
 // Put stores a value of any type in Context
@@ -52,12 +85,12 @@ func (c *Context) Put(key string, value interface{}) {
 }
 
 // Get retrieves a string value from Context.
-// Get returns an empty string if key not found
+// Get returns an empty string if key not found or the value is not a string
 func (c *Context) Get(key string) string {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
-	if v, ok := c.contextMap[key]; ok {
-		return v.(string)
+	if v, ok := c.contextMap[key].(string); ok {
+		return v
 	}
 	return ""
 }
@@ -85,3 +118,29 @@ func (c *Context) ForEach(fn func(k string, v interface{}) interface{}) []interf
 
 	return ret
 }
+
+// ------------------------------------------------------------------------
+
+// Context returns the Context of the request of the response.
+func (r *Response) Context() *Context {
+	if r.Request == nil {
+		return nil
+	}
+
+	return r.Request.Context
+}
+
+// ------------------------------------------------------------------------
+
+// withValues returns the context carrying the Context of the values, so the requests
+// created with the context share the values.
+func withValues(ctx context.Context, values *Context) context.Context {
+	return context.WithValue(ctx, contextValuesKey{}, values)
+}
+
+// valuesOf returns the Context carried by the context, or nil if there is none.
+func valuesOf(ctx context.Context) *Context {
+	values, _ := ctx.Value(contextValuesKey{}).(*Context)
+
+	return values
+}
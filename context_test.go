@@ -15,7 +15,11 @@
 package colly
 
 import (
+	"io"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -37,3 +41,165 @@ func TestContextIteration(t *testing.T) {
 		}
 	}
 }
+
+// ------------------------------------------------------------------------
+
+type sourceListKey struct{}
+
+func TestTypedContextValues(t *testing.T) {
+	r := &Request{}
+
+	if _, ok := GetTyped[int](r, sourceListKey{}); ok {
+		t.Error("GetTyped() found a value in an empty context")
+	}
+
+	PutTyped(r, sourceListKey{}, 42)
+	shared := r.Ctx
+	PutTyped(r, "name", "seed")
+
+	if v, ok := GetTyped[int](r, sourceListKey{}); !ok || v != 42 {
+		t.Errorf("GetTyped[int]() = %v, %v, want 42, true", v, ok)
+	}
+	if v, ok := GetTyped[string](r, "name"); !ok || v != "seed" {
+		t.Errorf("GetTyped[string]() = %q, %v, want %q, true", v, ok, "seed")
+	}
+	if _, ok := GetTyped[string](r, sourceListKey{}); ok {
+		t.Error("GetTyped() returned a value of another type")
+	}
+
+	// The earlier contexts are not modified
+	if v := (*shared).Value("name"); v != nil {
+		t.Errorf("shared context value = %v, want nil", v)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_OnContextInit(t *testing.T) {
+	c := NewCollector(nil, nil)
+
+	calls := 0
+	c.OnContextInit(func(r *Request) {
+		calls++
+		PutTyped(r, sourceListKey{}, calls)
+	})
+
+	root := &Request{}
+	c.handleOnContextInit(root)
+
+	// The descendants inherit the context of the root request
+	child := &Request{Ctx: root.Ctx}
+	c.handleOnContextInit(child)
+
+	if calls != 1 {
+		t.Errorf("initializer called %d times, want 1", calls)
+	}
+	if v, ok := GetTyped[int](child, sourceListKey{}); !ok || v != 1 {
+		t.Errorf("GetTyped() of the child = %v, %v, want 1, true", v, ok)
+	}
+
+	other := &Request{}
+	c.handleOnContextInit(other)
+	if v, _ := GetTyped[int](other, sourceListKey{}); v != 2 {
+		t.Errorf("GetTyped() of another root = %v, want 2", v)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestContext_MarshalBinary(t *testing.T) {
+	ctx := NewContext()
+	ctx.Put("name", "seed")
+	ctx.Put("page", 3)
+
+	data, err := ctx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := &Context{}
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if got.Get("name") != "seed" || got.GetAny("page") != 3 || got.Get("page") != "" {
+		t.Errorf("UnmarshalBinary() = %v, %v", got.Get("name"), got.GetAny("page"))
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestRequest_ToBytes(t *testing.T) {
+	c := NewCollector(nil, nil)
+	r, err := c.newRequest("http://example.com/form", "POST", 2, strings.NewReader("a=1"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Priority = 1.5
+	r.Context.Put("category", "books")
+
+	data, err := r.ToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewRequestFromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := io.ReadAll(got.Req.Body)
+	if got.Req.URL.String() != "http://example.com/form" || got.Req.Method != "POST" || string(body) != "a=1" ||
+		got.Depth != 2 || got.Priority != 1.5 || got.ID != r.ID {
+		t.Errorf("NewRequestFromBytes() = %s %s %q depth %d priority %v", got.Req.Method, got.Req.URL, body, got.Depth, got.Priority)
+	}
+	if got.Context.Get("category") != "books" || valuesOf(*got.Ctx) != got.Context {
+		t.Errorf("decoded context = %v, want books", got.Context.Get("category"))
+	}
+
+	// The original request keeps its body
+	if body, _ := io.ReadAll(r.Req.Body); string(body) != "a=1" {
+		t.Errorf("original body = %q, want a=1", body)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_Context(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	c := NewCollector(nil, nil)
+
+	var got []string
+	c.OnRequest(func(r *Request) {
+		if r.Req.URL.Path == "/" {
+			r.Context.Put("source", "home")
+		}
+	})
+	c.OnHTML("title", func(e *HTMLElement) {
+		got = append(got, e.Text+" "+e.Response.Context().Get("source"))
+	})
+	c.OnHTML("a[href]", func(e *HTMLElement) {
+		e.Response.Request.Visit(e.Attr("href"))
+	})
+
+	if err := c.Visit(ts.URL + "/"); err != nil {
+		t.Fatal(err)
+	}
+	c.Wait()
+
+	// The values are shared by the requests created from the request,
+	// the order of the titles depends on the order of the selectors
+	sort.Strings(got)
+	if want := []string{"Home home", "Page home"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("values = %v, want %v", got, want)
+	}
+
+	// The requests without a parent have their own values
+	if err := c.Visit(ts.URL + "/page?other"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Page "; got[len(got)-1] != want {
+		t.Errorf("values of a new visit = %q, want %q", got[len(got)-1], want)
+	}
+}
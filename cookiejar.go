@@ -2,6 +2,8 @@ package colly
 
 import (
 	"bytes"
+	"colly/storage"
+	"colly/storage/mem"
 	"encoding/gob"
 	"errors"
 	"fmt"
@@ -28,15 +30,28 @@ type CookieStorage interface {
 	Clear() error                            // Clear deletes all stored items.
 }
 
+// CookieMode tells how strictly the cookie jar follows RFC 6265.
+type CookieMode uint8
+
 // cookieJar implements the http.CookieJar interface from the net/http package.
 type cookieJar struct {
 	psList cookiejar.PublicSuffixList
+	mode   CookieMode
 	lock   *sync.Mutex
 
 	// storage saves the set of entries, keyed by their eTLD+1 and subkeyed by
 	// their name/domain/path.
 	storage CookieStorage
 
+	// prefix is the key prefix of the entries of a session jar, blank in the jar of the collector.
+	prefix string
+
+	// policy is consulted for every received cookie, nil accepts all cookies.
+	policy CookiePolicy
+
+	// duplicates resolves the duplicate cookies of a response, nil keeps the last one.
+	duplicates DuplicateCookiePolicy
+
 	// nextSeqNum is the next sequence number assigned to a new cookie
 	// created SetCookies.
 	nextSeqNum uint64
@@ -62,7 +77,7 @@ type entry struct {
 	// seqNum is a sequence number so that Cookies returns cookies in a
 	// deterministic order, even for cookies that have equal Path length and
 	// equal Creation time. This simplifies testing.
-	seqNum uint64 `json:"seq_num" bson:"seq_num,omitempty"`
+	seqNum uint64
 }
 
 // entries is the internal representation of a submap.
@@ -70,6 +85,15 @@ type entries map[string]entry
 
 // ------------------------------------------------------------------------
 
+const (
+	COOKIE_MODE_STRICT  CookieMode = iota // Follow RFC 6265 strictly.
+	COOKIE_MODE_BROWSER                   // Mimic the leniency of the browsers.
+)
+
+// COOKIE_MAX_ATTEMPTS is the number of the attempts of a cookie update conflicting with
+// the concurrent updates of the other processes sharing a versioned cookie storage.
+const COOKIE_MAX_ATTEMPTS = 8
+
 // These parameter values are specified in section 5.
 // All computation is done with int32s, so that overflow behavior is identical
 // regardless of whether int is 32-bit or 64-bit.
@@ -95,18 +119,33 @@ var (
 // Go's time.Time) and should be far enough in the future.
 var endOfTime = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
 
+// intranetSuffixes are the top level domains of the private networks.
+// In browser mode the public suffix checks are skipped for these domains.
+var intranetSuffixes = []string{"corp", "home", "home.arpa", "internal", "intranet", "lan", "local", "localdomain", "localhost"}
+
 // ------------------------------------------------------------------------
 
 // NewCookieJar returns a pointer to a newly created cookie jar.
 // A nil *Options is equivalent to a zero Options.
+// The optional mode sets how strictly the jar follows RFC 6265, the default is COOKIE_MODE_STRICT.
 // If no storage was given, an in-memory cookie jar will be returned.
-func NewCookieJar(storage CookieStorage, o *cookiejar.Options) (http.CookieJar, error) {
+func NewCookieJar(storage CookieStorage, o *cookiejar.Options, mode ...CookieMode) (http.CookieJar, error) {
+	jarMode := COOKIE_MODE_STRICT
+	if len(mode) > 0 {
+		jarMode = mode[0]
+	}
+
 	if storage == nil {
-		return cookiejar.New(o)
+		if jarMode == COOKIE_MODE_STRICT {
+			return cookiejar.New(o)
+		}
+		storage = mem.NewCookieStorage()
 	}
 
 	jar := &cookieJar{
 		storage: storage,
+		mode:    jarMode,
+		lock:    &sync.Mutex{},
 	}
 
 	if o != nil {
@@ -124,6 +163,11 @@ func DecodeBinaryToEntries(data io.Reader) (entries, error) {
 	var e entries
 	err := gob.NewDecoder(data).Decode(&e)
 
+	// No entries stored yet
+	if err == io.EOF {
+		return entries{}, nil
+	}
+
 	return e, err
 }
 
@@ -144,31 +188,67 @@ func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
 	j.setCookies(u, cookies, time.Now())
 }
 
-// ------------------------------------------------------------------------
-
-// cookies is like Cookies but takes the current time as a parameter.
-func (j *cookieJar) cookies(u *url.URL, now time.Time) (cookies []*http.Cookie) {
-	if u.Scheme != "http" && u.Scheme != "https" {
-		return nil
-	}
-
-	host, err := canonicalHost(u.Host)
+// RemoveDomain removes the cookies stored for the domain.
+// The cookies are stored by eTLD+1, so the cookies of the sibling subdomains are removed as well.
+// The cookies of the session jars sharing the storage are removed too if the storage implements
+// the storage.Exporter interface.
+func (j *cookieJar) RemoveDomain(domain string) error {
+	host, err := canonicalHost(domain)
 	if err != nil {
-		return nil
+		return err
 	}
 	key := jarKey(host, j.psList)
 
 	j.lock.Lock()
 	defer j.lock.Unlock()
 
-	b, err := j.storage.Get(key)
+	if err := j.storage.Remove(j.prefix + key); err != nil {
+		return err
+	}
+
+	exporter, ok := j.storage.(storage.Exporter)
+	if !ok || j.prefix != "" {
+		return nil
+	}
+
+	var keys []string
+	err = exporter.ExportPrefix(SESSION_COOKIE_PREFIX, func(k string, data []byte) error {
+		if strings.HasSuffix(k, storage.NAMESPACE_SEPARATOR+key) {
+			keys = append(keys, k)
+		}
+		return nil
+	})
 	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err := j.storage.Remove(k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Storage returns the storage of the cookie entries.
+func (j *cookieJar) Storage() CookieStorage {
+	return j.storage
+}
+
+// ------------------------------------------------------------------------
+
+// cookies is like Cookies but takes the current time as a parameter.
+func (j *cookieJar) cookies(u *url.URL, now time.Time) (cookies []*http.Cookie) {
+	if u.Scheme != "http" && u.Scheme != "https" {
 		return nil
 	}
-	submap, err := DecodeBinaryToEntries(b)
-	if err != nil || submap == nil {
+
+	host, err := canonicalHost(u.Host)
+	if err != nil {
 		return nil
 	}
+	key := j.prefix + jarKey(host, j.psList)
 
 	https := u.Scheme == "https"
 	path := u.Path
@@ -176,33 +256,35 @@ func (j *cookieJar) cookies(u *url.URL, now time.Time) (cookies []*http.Cookie)
 		path = "/"
 	}
 
-	modified := false
-	var selected []entry
-	for id, e := range submap {
-		if e.Persistent && !e.Expires.After(now) {
-			delete(submap, id)
-			modified = true
-			continue
-		}
-
-		if !e.shouldSend(https, host, path) {
-			continue
-		}
+	j.lock.Lock()
+	defer j.lock.Unlock()
 
-		e.LastAccess = now
-		submap[id] = e
-		selected = append(selected, e)
-		modified = true
-	}
+	var selected []entry
+	err = j.updateEntries(key, func(submap entries) bool {
+		selected = nil
+
+		modified := false
+		for id, e := range submap {
+			if e.Persistent && !e.Expires.After(now) {
+				delete(submap, id)
+				modified = true
+				continue
+			}
 
-	if modified {
-		if len(submap) == 0 {
-			j.storage.Remove(key)
-		} else {
-			if data, err := submap.BinaryEncode(); err == nil {
-				j.storage.Set(key, data)
+			if !e.shouldSend(https, host, path) {
+				continue
 			}
+
+			e.LastAccess = now
+			submap[id] = e
+			selected = append(selected, e)
+			modified = true
 		}
+
+		return modified
+	})
+	if err != nil && len(selected) == 0 {
+		return nil
 	}
 
 	// sort according to RFC 6265 section 5.4 point 2: by longest
@@ -240,66 +322,121 @@ func (j *cookieJar) setCookies(u *url.URL, cookies []*http.Cookie, now time.Time
 	if err != nil {
 		return
 	}
-	key := jarKey(host, j.psList)
+	key := j.prefix + jarKey(host, j.psList)
 	defPath := defaultPath(u.Path)
 
 	j.lock.Lock()
 	defer j.lock.Unlock()
 
-	b, err := j.storage.Get(key)
-	if err != nil {
-		return
-	}
-	submap, err := DecodeBinaryToEntries(b)
-	if err != nil || submap == nil {
-		return
-	}
+	cookies = resolveDuplicateCookies(u, cookies, j.duplicates)
 
-	modified := false
-	for _, cookie := range cookies {
-		e, remove, err := j.newEntry(cookie, now, defPath, host)
-		if err != nil {
-			continue
-		}
-		id := e.id()
+	j.updateEntries(key, func(submap entries) bool {
+		modified := false
+		for _, cookie := range cookies {
+			e, remove, err := j.newEntry(cookie, now, defPath, host)
+			if err != nil {
+				continue
+			}
+			id := e.id()
 
-		if remove {
-			if submap != nil {
-				if _, ok := submap[id]; ok {
-					delete(submap, id)
-					modified = true
+			if j.policy != nil {
+				accepted := j.applyPolicy(u, cookie, submap, id)
+				if accepted == nil {
+					continue
+				}
+				if accepted != cookie {
+					if e, remove, err = j.newEntry(accepted, now, defPath, host); err != nil {
+						continue
+					}
+					id = e.id()
 				}
 			}
-			continue
-		}
 
-		if submap == nil {
-			submap = entries{}
+			if remove {
+				if submap != nil {
+					if _, ok := submap[id]; ok {
+						delete(submap, id)
+						modified = true
+					}
+				}
+				continue
+			}
+
+			if submap == nil {
+				submap = entries{}
+			}
+
+			if old, ok := submap[id]; ok {
+				e.Creation = old.Creation
+				e.seqNum = old.seqNum
+			} else {
+				e.Creation = now
+				e.seqNum = j.nextSeqNum
+				j.nextSeqNum++
+
+			}
+
+			e.LastAccess = now
+			submap[id] = e
+			modified = true
 		}
 
-		if old, ok := submap[id]; ok {
-			e.Creation = old.Creation
-			e.seqNum = old.seqNum
+		return modified
+	})
+}
+
+// updateEntries runs a read-modify-write cycle on the submap of the key. The modify function
+// returns false if it didn't modify the submap. With a storage.Versioned storage, shared by
+// several processes, the cycle is retried when the submap was modified concurrently.
+// The caller must hold the lock.
+func (j *cookieJar) updateEntries(key string, modify func(submap entries) bool) error {
+	versioned, ok := j.storage.(storage.Versioned)
+
+	for attempt := 1; ; attempt++ {
+		var b io.Reader
+		var version string
+		var err error
+
+		if ok {
+			b, version, err = versioned.GetVersion(key)
+			if errors.Is(err, storage.ErrNotImplemented) {
+				ok = false
+				b, err = j.storage.Get(key)
+			}
 		} else {
-			e.Creation = now
-			e.seqNum = j.nextSeqNum
-			j.nextSeqNum++
+			b, err = j.storage.Get(key)
+		}
+		if err != nil {
+			return err
+		}
 
+		submap, err := DecodeBinaryToEntries(b)
+		if err != nil || submap == nil {
+			return err
 		}
 
-		e.LastAccess = now
-		submap[id] = e
-		modified = true
-	}
+		if !modify(submap) {
+			return nil
+		}
 
-	if modified {
-		if len(submap) == 0 {
-			j.storage.Remove(key)
-		} else {
-			if data, err := submap.BinaryEncode(); err == nil {
-				j.storage.Set(key, data)
+		var data io.Reader
+		if len(submap) > 0 {
+			if data, err = submap.BinaryEncode(); err != nil {
+				return err
 			}
 		}
+
+		if !ok {
+			if data == nil {
+				return j.storage.Remove(key)
+			}
+			return j.storage.Set(key, data)
+		}
+
+		err = versioned.CompareAndSet(key, data, version)
+		if !errors.Is(err, storage.ErrVersionConflict) || attempt >= COOKIE_MAX_ATTEMPTS {
+			return err
+		}
 	}
 }
 
@@ -390,6 +527,9 @@ func (j *cookieJar) domainAndType(host, domain string) (string, bool, error) {
 		// It just doesn't make sense on IP addresses.
 		// The other processing and validation steps in RFC 6265 just
 		// collaps to:
+		if j.mode == COOKIE_MODE_BROWSER {
+			domain = strings.TrimPrefix(domain, ".")
+		}
 		if host != domain {
 			return "", false, errIllegalDomain
 		}
@@ -419,6 +559,14 @@ func (j *cookieJar) domainAndType(host, domain string) (string, bool, error) {
 		return "", false, errMalformedDomain
 	}
 
+	if j.mode == COOKIE_MODE_BROWSER {
+		// Browsers convert the internationalized domains and ignore the trailing dot.
+		var err error
+		if domain, err = toASCII(strings.TrimSuffix(domain, ".")); err != nil || domain == "" {
+			return "", false, errMalformedDomain
+		}
+	}
+
 	domain, isASCII := toLower(domain)
 	if !isASCII {
 		// Received non-ASCII domain, e.g. "perché.com" instead of "xn--perch-fsa.com"
@@ -436,7 +584,7 @@ func (j *cookieJar) domainAndType(host, domain string) (string, bool, error) {
 	}
 
 	// See RFC 6265 section 5.3 #5.
-	if j.psList != nil {
+	if j.psList != nil && !(j.mode == COOKIE_MODE_BROWSER && isIntranetDomain(domain)) {
 		if ps := j.psList.PublicSuffix(domain); ps != "" && !hasDotSuffix(domain, ps) {
 			if host == domain {
 				// This is the one exception in which a cookie
@@ -583,6 +731,21 @@ func jarKey(host string, psl cookiejar.PublicSuffixList) string {
 	return host[prevDot+1:]
 }
 
+// isIntranetDomain reports whether domain belongs to a private network.
+func isIntranetDomain(domain string) bool {
+	if !strings.Contains(domain, ".") {
+		return true
+	}
+
+	for _, suffix := range intranetSuffixes {
+		if hasDotSuffix(domain, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // isIP reports whether host is an IP address.
 func isIP(host string) bool {
 	return net.ParseIP(host) != nil
@@ -0,0 +1,68 @@
+package colly
+
+import (
+	"colly/storage"
+	"colly/storage/mem"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+// racingStorage runs a concurrent update once, between the read and the write of the first update.
+type racingStorage struct {
+	versionedCookieStorage
+	race func()
+}
+
+type versionedCookieStorage interface {
+	CookieStorage
+	storage.Versioned
+}
+
+func (s *racingStorage) CompareAndSet(key string, data io.Reader, version string) error {
+	if race := s.race; race != nil {
+		s.race = nil
+		race()
+	}
+
+	return s.versionedCookieStorage.CompareAndSet(key, data, version)
+}
+
+// ------------------------------------------------------------------------
+
+func Test_cookieJar_concurrentUpdates(t *testing.T) {
+	u, _ := url.Parse("https://www.example.com/")
+	shared := mem.NewCookieStorage()
+
+	other, err := NewCookieJar(shared, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stg := &racingStorage{versionedCookieStorage: shared}
+	stg.race = func() {
+		other.SetCookies(u, []*http.Cookie{{Name: "other", Value: "1"}})
+	}
+
+	jar, err := NewCookieJar(stg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "a"}})
+
+	got := []string{}
+	for _, c := range other.Cookies(u) {
+		got = append(got, c.Name+"="+c.Value)
+	}
+	sort.Strings(got)
+
+	want := []string{"other=1", "session=a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cookies = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,280 @@
+package colly
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ------------------------------------------------------------------------
+
+// CookiePolicy is consulted by the cookie jar for every cookie received from the URL.
+// stored is the number of the other cookies already stored for the domain of the URL,
+// a cookie replacing a stored one is not counted.
+// It returns the cookie to accept it, a modified copy to rewrite it, or nil to reject it.
+type CookiePolicy func(u *url.URL, cookie *http.Cookie, stored int) *http.Cookie
+
+// DuplicateCookiePolicy resolves the cookies of a response with the same name, domain and path.
+// The duplicates are in the order of their Set-Cookie headers. It returns the cookie to store,
+// or nil to store none of them. Without a policy the last one wins.
+type DuplicateCookiePolicy func(u *url.URL, duplicates []*http.Cookie) *http.Cookie
+
+// policyJar applies a cookie policy to a cookie jar that has no policy support.
+type policyJar struct {
+	http.CookieJar
+	policy     CookiePolicy          // cookie policy, nil accepts all cookies
+	duplicates DuplicateCookiePolicy // duplicate cookie policy, nil keeps the last duplicate
+}
+
+// ------------------------------------------------------------------------
+
+// SetPolicy sets the cookie policy of the jar, nil accepts all cookies.
+func (j *cookieJar) SetPolicy(policy CookiePolicy) {
+	j.lock.Lock()
+	j.policy = policy
+	j.lock.Unlock()
+}
+
+// SetDuplicatePolicy sets the policy resolving the duplicate cookies of a response, nil keeps the last one.
+func (j *cookieJar) SetDuplicatePolicy(policy DuplicateCookiePolicy) {
+	j.lock.Lock()
+	j.duplicates = policy
+	j.lock.Unlock()
+}
+
+// applyPolicy returns the cookie accepted by the policy of the jar, or nil if it was rejected.
+// The caller must hold the lock of the jar.
+func (j *cookieJar) applyPolicy(u *url.URL, cookie *http.Cookie, submap entries, id string) *http.Cookie {
+	if j.policy == nil {
+		return cookie
+	}
+
+	stored := len(submap)
+	if _, ok := submap[id]; ok {
+		stored--
+	}
+
+	return j.policy(u, cookie, stored)
+}
+
+// ------------------------------------------------------------------------
+
+// SetCookies implements the SetCookies method of the http.CookieJar interface.
+func (j *policyJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	cookies = resolveDuplicateCookies(u, cookies, j.duplicates)
+	if j.policy == nil {
+		j.CookieJar.SetCookies(u, cookies)
+		return
+	}
+
+	names := map[string]bool{}
+	for _, cookie := range j.CookieJar.Cookies(u) {
+		names[cookie.Name] = true
+	}
+
+	accepted := make([]*http.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		stored := len(names)
+		if names[cookie.Name] {
+			stored--
+		}
+
+		if cookie = j.policy(u, cookie, stored); cookie != nil {
+			accepted = append(accepted, cookie)
+			names[cookie.Name] = true
+		}
+	}
+
+	if len(accepted) > 0 {
+		j.CookieJar.SetCookies(u, accepted)
+	}
+}
+
+// RemoveDomain removes the cookies stored for the domain if the underlying jar supports it.
+func (j *policyJar) RemoveDomain(domain string) error {
+	if jar, ok := j.CookieJar.(interface{ RemoveDomain(string) error }); ok {
+		return jar.RemoveDomain(domain)
+	}
+
+	return ErrNoCookieJar
+}
+
+// Storage returns the storage of the underlying jar, or nil if it is unknown.
+func (j *policyJar) Storage() CookieStorage {
+	if jar, ok := j.CookieJar.(interface{ Storage() CookieStorage }); ok {
+		return jar.Storage()
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// SecureCookiePolicy returns a cookie policy that only accepts cookies with the Secure attribute.
+func SecureCookiePolicy() CookiePolicy {
+	return func(_ *url.URL, cookie *http.Cookie, _ int) *http.Cookie {
+		if !cookie.Secure {
+			return nil
+		}
+		return cookie
+	}
+}
+
+// CookieLimitPolicy returns a cookie policy that accepts at most max cookies per domain.
+func CookieLimitPolicy(max int) CookiePolicy {
+	return func(_ *url.URL, cookie *http.Cookie, stored int) *http.Cookie {
+		if stored >= max {
+			return nil
+		}
+		return cookie
+	}
+}
+
+// CookieNamePolicy returns a cookie policy that rejects the cookies with the given names.
+// A name ending with "*" rejects every cookie name with the prefix, e.g. "_ga*".
+func CookieNamePolicy(names ...string) CookiePolicy {
+	return func(_ *url.URL, cookie *http.Cookie, _ int) *http.Cookie {
+		for _, name := range names {
+			if strings.HasSuffix(name, "*") && strings.HasPrefix(cookie.Name, strings.TrimSuffix(name, "*")) {
+				return nil
+			}
+			if cookie.Name == name {
+				return nil
+			}
+		}
+		return cookie
+	}
+}
+
+// CookiePolicyChain returns a cookie policy that applies the policies in order.
+// The cookie is rejected as soon as a policy rejects it.
+func CookiePolicyChain(policies ...CookiePolicy) CookiePolicy {
+	return func(u *url.URL, cookie *http.Cookie, stored int) *http.Cookie {
+		for _, policy := range policies {
+			if policy == nil {
+				continue
+			}
+			if cookie = policy(u, cookie, stored); cookie == nil {
+				return nil
+			}
+		}
+		return cookie
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// FirstCookieWins returns a duplicate cookie policy that keeps the first duplicate.
+func FirstCookieWins() DuplicateCookiePolicy {
+	return func(_ *url.URL, duplicates []*http.Cookie) *http.Cookie {
+		return duplicates[0]
+	}
+}
+
+// LastCookieWins returns a duplicate cookie policy that keeps the last duplicate, like the jars do by default.
+func LastCookieWins() DuplicateCookiePolicy {
+	return func(_ *url.URL, duplicates []*http.Cookie) *http.Cookie {
+		return duplicates[len(duplicates)-1]
+	}
+}
+
+// MergeDuplicateCookies returns a duplicate cookie policy that keeps the value of the last duplicate
+// and completes its missing attributes, e.g. the expiry or the Secure flag, from the earlier ones.
+// The log function, if set, is called with the duplicates and the merged cookie.
+func MergeDuplicateCookies(log func(u *url.URL, duplicates []*http.Cookie, merged *http.Cookie)) DuplicateCookiePolicy {
+	return func(u *url.URL, duplicates []*http.Cookie) *http.Cookie {
+		merged := *duplicates[len(duplicates)-1]
+		for i := len(duplicates) - 2; i >= 0; i-- {
+			d := duplicates[i]
+			if merged.Expires.IsZero() && merged.MaxAge == 0 {
+				merged.Expires, merged.MaxAge = d.Expires, d.MaxAge
+			}
+			if merged.SameSite == http.SameSiteDefaultMode {
+				merged.SameSite = d.SameSite
+			}
+			merged.Secure = merged.Secure || d.Secure
+			merged.HttpOnly = merged.HttpOnly || d.HttpOnly
+		}
+
+		if log != nil {
+			log(u, duplicates, &merged)
+		}
+
+		return &merged
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// duplicateCookies returns the groups of the cookies with the same name, domain and path,
+// in the order of their first occurrence. Unique cookies are groups of one.
+func duplicateCookies(cookies []*http.Cookie) [][]*http.Cookie {
+	var groups [][]*http.Cookie
+	index := map[string]int{}
+
+	for _, cookie := range cookies {
+		id := cookie.Name + ";" + strings.TrimPrefix(strings.ToLower(cookie.Domain), ".") + ";" + cookie.Path
+		if i, ok := index[id]; ok {
+			groups[i] = append(groups[i], cookie)
+			continue
+		}
+		index[id] = len(groups)
+		groups = append(groups, []*http.Cookie{cookie})
+	}
+
+	return groups
+}
+
+// resolveDuplicateCookies returns the cookies with the duplicates resolved by the policy.
+// The cookies are returned as they are if there is no policy or no duplicates.
+func resolveDuplicateCookies(u *url.URL, cookies []*http.Cookie, policy DuplicateCookiePolicy) []*http.Cookie {
+	if policy == nil || len(cookies) < 2 {
+		return cookies
+	}
+
+	groups := duplicateCookies(cookies)
+	if len(groups) == len(cookies) {
+		return cookies
+	}
+
+	resolved := make([]*http.Cookie, 0, len(groups))
+	for _, group := range groups {
+		cookie := group[0]
+		if len(group) > 1 {
+			cookie = policy(u, group)
+		}
+		if cookie != nil {
+			resolved = append(resolved, cookie)
+		}
+	}
+
+	return resolved
+}
+
+// logDuplicateCookies logs the cookies of the response headers with the same name, domain and path
+// as DEBUG events, so the authentication edge cases can be diagnosed.
+func (c *Collector) logDuplicateCookies(resp *Response) {
+	if resp.FromCache || !c.Config.logEnabled(LOG_DEBUG_LEVEL) {
+		return
+	}
+
+	for _, group := range duplicateCookies(resp.Resp.Cookies()) {
+		if len(group) < 2 {
+			continue
+		}
+
+		values := make([]string, len(group))
+		for i, cookie := range group {
+			values[i] = cookie.Value
+		}
+		c.logEvent(LOG_DEBUG_LEVEL, "duplicate_cookie", resp.Request.ID, map[string]string{
+			"url":    resp.Request.Req.URL.String(),
+			"name":   group[0].Name,
+			"domain": group[0].Domain,
+			"path":   group[0].Path,
+			"count":  strconv.Itoa(len(group)),
+			"values": strings.Join(values, ", "),
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package colly
+
+import (
+	"bytes"
+	"colly/storage/mem"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollectorConfig_SetCookiePolicy(t *testing.T) {
+	rewrite := func(_ *url.URL, cookie *http.Cookie, _ int) *http.Cookie {
+		if cookie.Name != "lang" {
+			return cookie
+		}
+		c := *cookie
+		c.Value = "en"
+		return &c
+	}
+	policy := CookiePolicyChain(CookieNamePolicy("_g*", "fbp"), rewrite, CookieLimitPolicy(2))
+
+	u, _ := url.Parse("https://www.example.com/")
+	received := []*http.Cookie{
+		{Name: "_ga", Value: "1"},
+		{Name: "_gid", Value: "1"},
+		{Name: "session", Value: "a"},
+		{Name: "lang", Value: "de"},
+		{Name: "fbp", Value: "1"},
+		{Name: "extra", Value: "1"},
+		{Name: "session", Value: "b"},
+	}
+
+	for _, tt := range []struct {
+		name    string
+		storage CookieStorage
+	}{
+		{name: "built-in jar", storage: mem.NewCookieStorage()},
+		{name: "wrapped jar"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &CollectorConfig{}
+			if err := cfg.SetCookieJar(tt.storage, COOKIE_MODE_STRICT); err != nil {
+				t.Fatal(err)
+			}
+			if err := cfg.SetCookiePolicy(policy); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg.CookieJar.SetCookies(u, received)
+
+			got := []string{}
+			for _, c := range cfg.CookieJar.Cookies(u) {
+				got = append(got, c.Name+"="+c.Value)
+			}
+			sort.Strings(got)
+
+			want := []string{"lang=en", "session=b"}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Cookies() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSecureCookiePolicy(t *testing.T) {
+	policy := SecureCookiePolicy()
+	if policy(nil, &http.Cookie{Name: "a"}, 0) != nil {
+		t.Error("SecureCookiePolicy() accepted an insecure cookie")
+	}
+	if policy(nil, &http.Cookie{Name: "a", Secure: true}, 0) == nil {
+		t.Error("SecureCookiePolicy() rejected a secure cookie")
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollectorConfig_SetDuplicateCookiePolicy(t *testing.T) {
+	u, _ := url.Parse("https://www.example.com/")
+	expires := time.Now().Add(time.Hour)
+	received := func() []*http.Cookie {
+		return []*http.Cookie{
+			{Name: "session", Value: "a", Expires: expires, Secure: true},
+			{Name: "lang", Value: "de"},
+			{Name: "session", Value: "b"},
+			{Name: "session", Value: "c", Path: "/app"},
+		}
+	}
+
+	var merged []string
+	logMerge := func(_ *url.URL, duplicates []*http.Cookie, cookie *http.Cookie) {
+		merged = append(merged, cookie.Name)
+	}
+
+	tests := []struct {
+		name   string
+		policy DuplicateCookiePolicy
+		want   string
+	}{
+		{"default", nil, "b"},
+		{"first wins", FirstCookieWins(), "a"},
+		{"last wins", LastCookieWins(), "b"},
+		{"merge", MergeDuplicateCookies(logMerge), "b"},
+	}
+
+	for _, tt := range tests {
+		for _, wrapped := range []bool{false, true} {
+			cfg := &CollectorConfig{}
+			if wrapped {
+				cfg.CookieJar, _ = cookiejar.New(nil)
+			} else if err := cfg.SetCookieJar(mem.NewCookieStorage(), COOKIE_MODE_STRICT); err != nil {
+				t.Fatal(err)
+			}
+			if err := cfg.SetDuplicateCookiePolicy(tt.policy); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg.CookieJar.SetCookies(u, received())
+
+			got := ""
+			for _, c := range cfg.CookieJar.Cookies(u) {
+				if c.Name == "session" {
+					got = c.Value
+				}
+			}
+			if got != tt.want {
+				t.Errorf("%s (wrapped %v): session = %q, want %q", tt.name, wrapped, got, tt.want)
+			}
+		}
+	}
+
+	if want := []string{"session", "session"}; !reflect.DeepEqual(merged, want) {
+		t.Errorf("merge log = %v, want %v", merged, want)
+	}
+
+	m := MergeDuplicateCookies(nil)(u, received()[:3:3])
+	if m.Value != "b" || !m.Secure || !m.Expires.Equal(expires) {
+		t.Errorf("merged cookie = %+v, want value b with the expiry and the Secure flag of a", m)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_logDuplicateCookies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Set-Cookie", "token=first")
+		w.Header().Add("Set-Cookie", "token=second")
+		w.Header().Add("Set-Cookie", "other=1")
+	}))
+	defer srv.Close()
+
+	buf := &bytes.Buffer{}
+	l := NewStdLogger(buf, "", 0)
+	l.SetLevel(LOG_DEBUG_LEVEL)
+
+	cfg := NewConfig()
+	cfg.Logger = l
+	c := NewCollector(cfg, nil)
+	if err := c.Visit(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, "duplicate_cookie") {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) != 1 || !strings.Contains(lines[0], "first, second") {
+		t.Errorf("duplicate cookie events = %q, want one event of token", lines)
+	}
+}
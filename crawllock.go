@@ -0,0 +1,158 @@
+package colly
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// CrawlLock is a distributed lock to prevent running the same crawl job in multiple instances.
+// The lock expires after the time-to-live unless renewed by the owner.
+type CrawlLock interface {
+	Acquire(name string, owner string, ttl time.Duration) (bool, error) // Acquire takes the lock if it is free, expired or held by the owner.
+	Renew(name string, owner string, ttl time.Duration) (bool, error)   // Renew extends the lock if it is still held by the owner.
+	Release(name string, owner string) error                            // Release frees the lock if it is held by the owner.
+}
+
+// CrawlLease is an acquired crawl lock that is renewed in the background until released.
+type CrawlLease struct {
+	Name  string // Name is the name of the lock.
+	Owner string // Owner identifies the collector instance holding the lock.
+
+	lk     CrawlLock
+	ttl    time.Duration
+	lost   chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+	once   *sync.Once
+	logger func(error)
+}
+
+// ------------------------------------------------------------------------
+
+// AcquireCrawlLock acquires the named crawl lock and keeps renewing it as a heartbeat.
+// If standby is true, it waits until the lock becomes free or the context is cancelled,
+// otherwise it returns ErrCrawlLocked if another instance holds the lock.
+func (c *Collector) AcquireCrawlLock(ctx context.Context, lk CrawlLock, name string, ttl time.Duration, standby ...bool) (*CrawlLease, error) {
+	if lk == nil {
+		return nil, ErrNoCrawlLock
+	}
+
+	if ttl <= 0 {
+		return nil, fmt.Errorf("lock time-to-live must be positive: %s was given", ttl)
+	}
+
+	owner := crawlLockOwner()
+	wait := len(standby) > 0 && standby[0]
+
+	var acquired time.Time
+	for {
+		acquired = time.Now()
+		ok, err := lk.Acquire(name, owner, ttl)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		if !wait {
+			return nil, fmt.Errorf("%w: %q", ErrCrawlLocked, name)
+		}
+
+		if c.HasLogger() {
+			c.logEvent(LOG_INFO_LEVEL, "standby", 0, map[string]string{
+				"lock": name,
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(ttl / 2):
+		}
+	}
+
+	lease := &CrawlLease{
+		Name:  name,
+		Owner: owner,
+		lk:    lk,
+		ttl:   ttl,
+		lost:  make(chan struct{}),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+		once:  &sync.Once{},
+		logger: func(err error) {
+			c.Config.logError(LOG_WARN_LEVEL, err)
+		},
+	}
+	go lease.heartbeat(acquired)
+
+	return lease, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Lost returns a channel that is closed when the lease could not be renewed,
+// so another instance may take over the crawl.
+func (l *CrawlLease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Release stops the heartbeat and frees the lock.
+func (l *CrawlLease) Release() error {
+	l.once.Do(func() { close(l.stop) })
+	<-l.done
+
+	return l.lk.Release(l.Name, l.Owner)
+}
+
+// ------------------------------------------------------------------------
+
+// heartbeat renews the lock three times per time-to-live period. The failed renewals are retried
+// until the lock expires, then the lease is lost, as another instance may have acquired the lock.
+// The expiry is counted from the start of the last successful renewal.
+func (l *CrawlLease) heartbeat(acquired time.Time) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	lastRenew := acquired
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			start := time.Now()
+			ok, err := l.lk.Renew(l.Name, l.Owner, l.ttl)
+			if err != nil {
+				l.logger(fmt.Errorf("crawl lock %q renewal error: %w", l.Name, err))
+				if time.Since(lastRenew) < l.ttl {
+					continue
+				}
+			}
+			if err == nil && ok {
+				lastRenew = start
+				continue
+			}
+
+			l.logger(fmt.Errorf("%w: %q", ErrCrawlLockLost, l.Name))
+			close(l.lost)
+			return
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// crawlLockOwner returns a unique identifier of the collector instance.
+func crawlLockOwner() string {
+	host, _ := os.Hostname()
+
+	return fmt.Sprintf("%s-%d-%08x", host, os.Getpid(), rand.Uint32())
+}
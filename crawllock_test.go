@@ -0,0 +1,103 @@
+package colly
+
+import (
+	"colly/storage/mem"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollector_AcquireCrawlLock(t *testing.T) {
+	lk := mem.NewLockStorage()
+	c := NewCollector(nil, nil)
+	ttl := 30 * time.Millisecond
+
+	lease, err := c.AcquireCrawlLock(context.Background(), lk, "job", ttl)
+	if err != nil {
+		t.Fatalf("AcquireCrawlLock() error = %v", err)
+	}
+
+	// The heartbeat keeps the lock after the time-to-live
+	time.Sleep(2 * ttl)
+	if _, err := c.AcquireCrawlLock(context.Background(), lk, "job", ttl); !errors.Is(err, ErrCrawlLocked) {
+		t.Errorf("AcquireCrawlLock() error = %v, wantErr %v", err, ErrCrawlLocked)
+	}
+
+	// Standby waits until the context is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 2*ttl)
+	defer cancel()
+	if _, err := c.AcquireCrawlLock(ctx, lk, "job", ttl, true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("AcquireCrawlLock() standby error = %v, wantErr %v", err, context.DeadlineExceeded)
+	}
+
+	// Standby takes over the released lock
+	go func() {
+		time.Sleep(ttl)
+		_ = lease.Release()
+	}()
+	other, err := c.AcquireCrawlLock(context.Background(), lk, "job", ttl, true)
+	if err != nil {
+		t.Fatalf("AcquireCrawlLock() standby error = %v", err)
+	}
+
+	// The lease is lost if the lock is taken away
+	_ = lk.Clear()
+	_, _ = lk.Acquire("job", "intruder", time.Minute)
+	select {
+	case <-other.Lost():
+	case <-time.After(5 * ttl):
+		t.Errorf("CrawlLease.Lost() was not closed")
+	}
+	_ = other.Release()
+}
+
+// ------------------------------------------------------------------------
+
+// failingLock is a crawl lock whose renewals fail after a number of calls.
+type failingLock struct {
+	CrawlLock
+	renewals atomic.Int32
+	fail     int32
+}
+
+func (l *failingLock) Renew(name string, owner string, ttl time.Duration) (bool, error) {
+	if l.renewals.Add(1) > l.fail {
+		return false, errors.New("backend unreachable")
+	}
+
+	return l.CrawlLock.Renew(name, owner, ttl)
+}
+
+func TestCrawlLease_renewalErrors(t *testing.T) {
+	lk := &failingLock{CrawlLock: mem.NewLockStorage(), fail: 2}
+	c := NewCollector(nil, nil)
+	ttl := 60 * time.Millisecond
+
+	start := time.Now()
+	lease, err := c.AcquireCrawlLock(context.Background(), lk, "job", ttl)
+	if err != nil {
+		t.Fatalf("AcquireCrawlLock() error = %v", err)
+	}
+	defer lease.Release()
+
+	// The failed renewals are retried while the lock may still be held
+	select {
+	case <-lease.Lost():
+		t.Fatalf("CrawlLease.Lost() was closed after %v, before the lock expired", time.Since(start))
+	case <-time.After(ttl):
+	}
+
+	// The lease is lost once the last successful renewal expired
+	select {
+	case <-lease.Lost():
+	case <-time.After(3 * ttl):
+		t.Fatalf("CrawlLease.Lost() was not closed after %v of failing renewals", time.Since(start))
+	}
+	if n := lk.renewals.Load(); n <= lk.fail {
+		t.Errorf("Renew() was called %d times, want failing renewals", n)
+	}
+}
@@ -0,0 +1,425 @@
+package colly
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// CrawlWindow is a recurring daily time range when a host may be crawled.
+type CrawlWindow struct {
+	Days     [7]bool        // Days are the weekdays when the window opens, indexed by time.Weekday.
+	Start    time.Duration  // Start is the opening time from the midnight.
+	End      time.Duration  // End is the closing time from the midnight. The window closes the next day if End is not after Start.
+	Location *time.Location // Location is the time zone of the window. UTC is used if it is nil.
+}
+
+// CrawlWindowStatus is the state of the crawl windows of a host.
+type CrawlWindowStatus struct {
+	Open     bool      `json:"open" bson:"open,omitempty"`           // Open is true if a crawl window of the host is open.
+	NextOpen time.Time `json:"next_open" bson:"next_open,omitempty"` // NextOpen is the next opening of a crawl window if all windows are closed.
+	Held     uint      `json:"held" bson:"held,omitempty"`           // Held is the number of the requests waiting for the next opening.
+}
+
+// crawlWindows holds the crawl windows of the hosts.
+type crawlWindows struct {
+	hosts map[string]*hostWindows // crawl windows mapped by the host names
+	lock  *sync.Mutex
+}
+
+// hostWindows are the crawl windows of a host with its held requests.
+type hostWindows struct {
+	windows []CrawlWindow
+	held    uint      // number of the requests held until the due time
+	due     time.Time // the opening the held requests are waiting for
+}
+
+// ------------------------------------------------------------------------
+
+// weekdays maps the day names of the crawl window specifications to weekdays.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ------------------------------------------------------------------------
+
+// ParseCrawlWindow parses a crawl window specification in the "[days] HH:MM-HH:MM [timezone]" form.
+// The days are a comma separated list of day names and day ranges, e.g. "mon-fri,sun",
+// or "*" for every day, which is the default. The time zone is an IANA name, e.g. "Europe/Paris",
+// UTC is used if it is omitted. If the end is not after the start, the window closes the next day.
+//
+// Examples:
+//
+//	"22:00-06:00"                      every night in UTC
+//	"sat,sun 00:00-24:00"              all weekend
+//	"mon-fri 20:00-07:30 America/Chicago"
+func ParseCrawlWindow(spec string) (CrawlWindow, error) {
+	w := CrawlWindow{Location: time.UTC}
+
+	fields := strings.Fields(spec)
+	if len(fields) == 0 || len(fields) > 3 {
+		return w, fmt.Errorf("%w: %q", ErrInvalidCrawlWindow, spec)
+	}
+
+	// The time range is the only field starting with a digit
+	pos := -1
+	for i, f := range fields {
+		if f[0] >= '0' && f[0] <= '9' {
+			pos = i
+			break
+		}
+	}
+	if pos < 0 || pos > 1 {
+		return w, fmt.Errorf("%w: %q", ErrInvalidCrawlWindow, spec)
+	}
+
+	days := "*"
+	if pos == 1 {
+		days = fields[0]
+	}
+	if err := w.parseDays(days); err != nil {
+		return w, fmt.Errorf("%w: %q", ErrInvalidCrawlWindow, spec)
+	}
+
+	start, end, found := strings.Cut(fields[pos], "-")
+	if !found {
+		return w, fmt.Errorf("%w: %q", ErrInvalidCrawlWindow, spec)
+	}
+
+	var err error
+	if w.Start, err = parseClock(start); err != nil || w.Start == 24*time.Hour {
+		return w, fmt.Errorf("%w: %q", ErrInvalidCrawlWindow, spec)
+	}
+	if w.End, err = parseClock(end); err != nil {
+		return w, fmt.Errorf("%w: %q", ErrInvalidCrawlWindow, spec)
+	}
+
+	if pos+1 < len(fields) {
+		if w.Location, err = time.LoadLocation(fields[pos+1]); err != nil {
+			return w, fmt.Errorf("%w: %v", ErrInvalidCrawlWindow, err)
+		}
+	}
+
+	return w, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Contains returns true if the window is open at the time.
+func (w CrawlWindow) Contains(t time.Time) bool {
+	t = t.In(w.location())
+	y, m, d := t.Date()
+
+	// The window opened yesterday can still be open
+	for offset := -1; offset <= 0; offset++ {
+		open, closing := w.bounds(y, m, d+offset)
+		if w.Days[open.Weekday()] && !t.Before(open) && t.Before(closing) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Next returns the time when the window is open next, which is the time itself if the window is open.
+// It returns zero time if the window has no days.
+func (w CrawlWindow) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+
+	tl := t.In(w.location())
+	y, m, d := tl.Date()
+
+	for offset := 0; offset <= 7; offset++ {
+		open, _ := w.bounds(y, m, d+offset)
+		if w.Days[open.Weekday()] && !open.Before(t) {
+			return open
+		}
+	}
+
+	return time.Time{}
+}
+
+// ------------------------------------------------------------------------
+
+// parseDays sets the days of the window from a day list.
+func (w *CrawlWindow) parseDays(days string) error {
+	if days == "*" {
+		for i := range w.Days {
+			w.Days[i] = true
+		}
+		return nil
+	}
+
+	for _, item := range strings.Split(strings.ToLower(days), ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		if !isRange {
+			to = from
+		}
+
+		first, ok := weekdays[from]
+		if !ok {
+			return ErrInvalidCrawlWindow
+		}
+		last, ok := weekdays[to]
+		if !ok {
+			return ErrInvalidCrawlWindow
+		}
+
+		// Ranges can wrap around the week, e.g. "fri-mon"
+		for day := first; ; day = (day + 1) % 7 {
+			w.Days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// bounds returns the opening and the closing time of the window opening on the day.
+func (w CrawlWindow) bounds(y int, m time.Month, d int) (time.Time, time.Time) {
+	loc := w.location()
+	open := time.Date(y, m, d, 0, 0, int(w.Start/time.Second), 0, loc)
+
+	if w.End <= w.Start {
+		d++
+	}
+	closing := time.Date(y, m, d, 0, 0, int(w.End/time.Second), 0, loc)
+
+	return open, closing
+}
+
+// location returns the time zone of the window.
+func (w CrawlWindow) location() *time.Location {
+	if w.Location == nil {
+		return time.UTC
+	}
+
+	return w.Location
+}
+
+// parseClock parses a HH:MM time of the day, including 24:00.
+func parseClock(s string) (time.Duration, error) {
+	hh, mm, found := strings.Cut(s, ":")
+	if !found || len(mm) != 2 {
+		return 0, ErrInvalidCrawlWindow
+	}
+
+	h, err := strconv.Atoi(hh)
+	if err != nil || h < 0 || h > 24 {
+		return 0, ErrInvalidCrawlWindow
+	}
+
+	minute, err := strconv.Atoi(mm)
+	if err != nil || minute < 0 || minute > 59 || (h == 24 && minute > 0) {
+		return 0, ErrInvalidCrawlWindow
+	}
+
+	return time.Duration(h)*time.Hour + time.Duration(minute)*time.Minute, nil
+}
+
+// ------------------------------------------------------------------------
+
+// newCrawlWindows returns a pointer to a newly created crawl window registry.
+func newCrawlWindows() *crawlWindows {
+	return &crawlWindows{
+		hosts: map[string]*hostWindows{},
+		lock:  &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// SetCrawlWindows permits crawling the domain and its subdomains only in the crawl windows.
+// The requests made outside the windows are held by the scheduler of the collector and
+// dispatched when a window opens. The windows of a subdomain override the windows of its
+// parent domains. Calling it without windows removes the windows of the domain.
+func (c *Collector) SetCrawlWindows(domain string, windows ...CrawlWindow) {
+	c.windows.set(normalizeDomain(domain), windows)
+}
+
+// CrawlWindowStatus returns the state of the crawl windows of the host.
+// It returns false if the host has no crawl windows.
+func (c *Collector) CrawlWindowStatus(host string) (CrawlWindowStatus, bool) {
+	return c.windows.status(normalizeDomain(host), time.Now())
+}
+
+// ------------------------------------------------------------------------
+
+// holdRequest schedules the request for the next opening of the crawl windows of its host.
+// It returns true if the request was held and must not be dispatched now.
+func (c *Collector) holdRequest(r *Request) bool {
+	if r.Req == nil || r.Req.URL == nil {
+		return false
+	}
+
+	next, held := c.windows.hold(normalizeDomain(r.Req.URL.Hostname()), time.Now())
+	if !held {
+		return false
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_DEBUG_LEVEL, "crawl_window", r.ID, map[string]string{
+			"url":   r.Req.URL.String(),
+			"opens": next.Format(time.RFC3339),
+		})
+	}
+
+	r.NotBefore = next
+	if err := c.Schedule(r); err != nil {
+		c.Config.logError(LOG_WARN_LEVEL, err)
+	}
+
+	return true
+}
+
+// setCrawlWindowStatus adds the state of the crawl windows to the host reports.
+// The domains with crawl windows are reported even if none of their requests were dispatched.
+func (c *Collector) setCrawlWindowStatus(rep *CrawlReport) {
+	for _, domain := range c.windows.domains() {
+		if _, present := rep.Hosts[domain]; !present {
+			rep.Hosts[domain] = &HostReport{
+				Host:         domain,
+				Errors:       map[string]uint{},
+				ContentTypes: map[string]uint{},
+			}
+		}
+	}
+
+	now := time.Now()
+	for host, h := range rep.Hosts {
+		if status, ok := c.windows.status(host, now); ok {
+			h.Window = &status
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// set sets the crawl windows of the domain.
+func (cw *crawlWindows) set(domain string, windows []CrawlWindow) {
+	cw.lock.Lock()
+	defer cw.lock.Unlock()
+
+	if len(windows) == 0 {
+		delete(cw.hosts, domain)
+		return
+	}
+
+	if hw, present := cw.hosts[domain]; present {
+		hw.windows = windows
+		return
+	}
+
+	cw.hosts[domain] = &hostWindows{windows: windows}
+}
+
+// hold counts a request held until the next opening of the crawl windows of the host.
+// It returns false if the host has no windows or a window is open.
+func (cw *crawlWindows) hold(host string, now time.Time) (time.Time, bool) {
+	cw.lock.Lock()
+	defer cw.lock.Unlock()
+
+	hw := cw.lookup(host)
+	if hw == nil {
+		return time.Time{}, false
+	}
+
+	next := hw.next(now)
+	if next.IsZero() || !next.After(now) {
+		return time.Time{}, false
+	}
+
+	// The requests held for an earlier opening have been dispatched by now
+	if !hw.due.Equal(next) {
+		hw.held, hw.due = 0, next
+	}
+	hw.held++
+
+	return next, true
+}
+
+// status returns the state of the crawl windows of the host.
+func (cw *crawlWindows) status(host string, now time.Time) (CrawlWindowStatus, bool) {
+	cw.lock.Lock()
+	defer cw.lock.Unlock()
+
+	hw := cw.lookup(host)
+	if hw == nil {
+		return CrawlWindowStatus{}, false
+	}
+
+	status := CrawlWindowStatus{}
+	if next := hw.next(now); next.IsZero() || !next.After(now) {
+		status.Open = !next.IsZero()
+	} else {
+		status.NextOpen = next
+	}
+
+	if now.Before(hw.due) {
+		status.Held = hw.held
+	}
+
+	return status, true
+}
+
+// domains returns the domains with crawl windows.
+func (cw *crawlWindows) domains() []string {
+	cw.lock.Lock()
+	defer cw.lock.Unlock()
+
+	domains := make([]string, 0, len(cw.hosts))
+	for domain := range cw.hosts {
+		domains = append(domains, domain)
+	}
+
+	return domains
+}
+
+// lookup returns the windows of the host or its closest parent domain.
+// The caller must hold the lock.
+func (cw *crawlWindows) lookup(host string) *hostWindows {
+	if len(cw.hosts) == 0 {
+		return nil
+	}
+
+	for {
+		if hw, present := cw.hosts[host]; present {
+			return hw
+		}
+
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			return nil
+		}
+		host = parent
+	}
+}
+
+// next returns the earliest opening of the windows, which is the time itself if a window is open.
+// It returns zero time if no window has days.
+func (hw *hostWindows) next(now time.Time) time.Time {
+	var next time.Time
+
+	for _, w := range hw.windows {
+		t := w.Next(now)
+		if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+
+	return next
+}
@@ -0,0 +1,159 @@
+package colly
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestParseCrawlWindow(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+
+	tests := []struct {
+		spec    string
+		days    [7]bool
+		start   time.Duration
+		end     time.Duration
+		loc     *time.Location
+		wantErr bool
+	}{
+		{spec: "22:00-06:00", days: [7]bool{true, true, true, true, true, true, true}, start: 22 * time.Hour, end: 6 * time.Hour, loc: time.UTC},
+		{spec: "sat,sun 00:00-24:00", days: [7]bool{true, false, false, false, false, false, true}, end: 24 * time.Hour, loc: time.UTC},
+		{spec: "Mon-Wed,fri 20:30-07:15 Europe/Paris", days: [7]bool{false, true, true, true, false, true, false}, start: 20*time.Hour + 30*time.Minute, end: 7*time.Hour + 15*time.Minute, loc: paris},
+		{spec: "fri-mon 01:00-02:00", days: [7]bool{true, true, false, false, false, true, true}, start: time.Hour, end: 2 * time.Hour, loc: time.UTC},
+		{spec: "", wantErr: true},
+		{spec: "mon-fri", wantErr: true},
+		{spec: "foo 22:00-06:00", wantErr: true},
+		{spec: "22:00", wantErr: true},
+		{spec: "24:00-06:00", wantErr: true},
+		{spec: "22:60-06:00", wantErr: true},
+		{spec: "22:00-06:00 Nowhere/Land", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			w, err := ParseCrawlWindow(tt.spec)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCrawlWindow) {
+					t.Errorf("ParseCrawlWindow() error = %v, want %v", err, ErrInvalidCrawlWindow)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if w.Days != tt.days || w.Start != tt.start || w.End != tt.end || w.Location.String() != tt.loc.String() {
+				t.Errorf("ParseCrawlWindow() = %+v", w)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCrawlWindow_Next(t *testing.T) {
+	// 2024-01-05 is a Friday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, 1, day, hour, min, 0, 0, time.UTC)
+	}
+
+	night, _ := ParseCrawlWindow("mon-fri 22:00-06:00")
+	weekend, _ := ParseCrawlWindow("sat,sun 00:00-24:00")
+
+	tests := []struct {
+		name   string
+		window CrawlWindow
+		now    time.Time
+		open   bool
+		next   time.Time
+	}{
+		{name: "before the night", window: night, now: at(5, 12, 0), next: at(5, 22, 0)},
+		{name: "in the night", window: night, now: at(5, 23, 0), open: true, next: at(5, 23, 0)},
+		{name: "after midnight", window: night, now: at(6, 5, 59), open: true, next: at(6, 5, 59)},
+		{name: "weekend morning", window: night, now: at(6, 6, 0), next: at(8, 22, 0)},
+		{name: "weekend", window: weekend, now: at(7, 23, 59), open: true, next: at(7, 23, 59)},
+		{name: "weekday", window: weekend, now: at(8, 0, 0), next: at(13, 0, 0)},
+		{name: "no days", window: CrawlWindow{Start: time.Hour}, now: at(5, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if open := tt.window.Contains(tt.now); open != tt.open {
+				t.Errorf("Contains() = %v, want %v", open, tt.open)
+			}
+			if next := tt.window.Next(tt.now); !next.Equal(tt.next) {
+				t.Errorf("Next() = %v, want %v", next, tt.next)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_crawlWindows(t *testing.T) {
+	now := time.Date(2024, 1, 5, 12, 0, 0, 0, time.UTC)
+	night, _ := ParseCrawlWindow("22:00-06:00")
+	noon, _ := ParseCrawlWindow("11:00-13:00")
+
+	cw := newCrawlWindows()
+	cw.set("example.com", []CrawlWindow{night})
+	cw.set("open.example.com", []CrawlWindow{night, noon})
+
+	if _, held := cw.hold("other.com", now); held {
+		t.Error("hold() held a request of a host without windows")
+	}
+	if _, held := cw.hold("open.example.com", now); held {
+		t.Error("hold() held a request in an open window")
+	}
+
+	for i := 0; i < 3; i++ {
+		next, held := cw.hold("www.example.com", now)
+		if !held || !next.Equal(now.Add(10*time.Hour)) {
+			t.Fatalf("hold() = %v, %v, want the next opening", next, held)
+		}
+	}
+
+	status, ok := cw.status("www.example.com", now)
+	want := CrawlWindowStatus{NextOpen: now.Add(10 * time.Hour), Held: 3}
+	if !ok || status != want {
+		t.Errorf("status() = %+v, %v, want %+v", status, ok, want)
+	}
+
+	// The held requests are dispatched when the window opens
+	status, _ = cw.status("example.com", now.Add(11*time.Hour))
+	if want := (CrawlWindowStatus{Open: true}); status != want {
+		t.Errorf("status() after the opening = %+v, want %+v", status, want)
+	}
+
+	cw.set("example.com", nil)
+	if _, ok := cw.status("www.example.com", now); ok {
+		t.Error("status() returned the removed windows")
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_SetCrawlWindows(t *testing.T) {
+	c := NewCollector(nil, nil)
+
+	closed := CrawlWindow{Start: time.Hour}
+	always, _ := ParseCrawlWindow("00:00-00:00")
+
+	c.SetCrawlWindows("Closed.example.com", closed)
+	c.SetCrawlWindows("example.com", always)
+
+	rep := c.Report()
+	for host, open := range map[string]bool{"closed.example.com": false, "example.com": true} {
+		h, present := rep.Hosts[host]
+		if !present || h.Window == nil || h.Window.Open != open {
+			t.Errorf("Report().Hosts[%q] = %+v, want a window with Open = %v", host, h, open)
+		}
+	}
+
+	if status, ok := c.CrawlWindowStatus("www.example.com"); !ok || !status.Open {
+		t.Errorf("CrawlWindowStatus() = %+v, %v, want an open window", status, ok)
+	}
+}
@@ -0,0 +1,235 @@
+package colly
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// DebugInfo is a diagnostic snapshot of the effective state of a collector.
+type DebugInfo struct {
+	CollectorID uint32            `json:"collector_id" bson:"collector_id,omitempty"` // CollectorID identifies the collector.
+	Created     time.Time         `json:"created" bson:"created,omitempty"`           // Created is the date and time when the snapshot was created.
+	Settings    map[string]string `json:"settings" bson:"settings,omitempty"`         // Settings are the scalar configuration values, mapped by the setting names.
+	Services    map[string]string `json:"services" bson:"services,omitempty"`         // Services are the types of the attached services and storages, mapped by the setting names.
+	Filters     []FilterInfo      `json:"filters" bson:"filters,omitempty"`           // Filters is the list of the configured filters.
+	SubConfigs  int               `json:"sub_configs" bson:"sub_configs,omitempty"`   // SubConfigs is the number of the filtered configuration settings.
+	Callbacks   map[string]int    `json:"callbacks" bson:"callbacks,omitempty"`       // Callbacks is the number of the callback functions, mapped by the event names.
+	Handlers    []HandlerInfo     `json:"handlers" bson:"handlers,omitempty"`         // Handlers is the list of the registered callbacks, see Collector.Handlers.
+	Stats       CollectorStats    `json:"stats" bson:"stats,omitempty"`               // Stats is a snapshot of the collector counters.
+	Paused      []string          `json:"paused" bson:"paused,omitempty"`             // Paused is the list of the paused domains.
+	Scheduled   int               `json:"scheduled" bson:"scheduled,omitempty"`       // Scheduled is the number of the requests waiting for their execution time.
+}
+
+// FilterInfo describes a configured filter.
+type FilterInfo struct {
+	Label  string `json:"label" bson:"label,omitempty"`   // Label is the label of the filter.
+	Method string `json:"method" bson:"method,omitempty"` // Method is either "include" or "exclude".
+	Scope  string `json:"scope" bson:"scope,omitempty"`   // Scope is the part of the request matched by the filter.
+}
+
+// ------------------------------------------------------------------------
+
+// eventNames are the names of the collector events used in the diagnostics.
+var eventNames = map[uint8]string{
+	ON_REQUEST:        "request",
+	ON_RESPONSE_HDR:   "response_headers",
+	ON_RESPONSE:       "response",
+	ON_ERROR:          "error",
+	ON_HTML:           "html",
+	ON_XML:            "xml",
+	ON_SCRAPED:        "scraped",
+	ON_EXTRACT:        "extract",
+	ON_RESPONSE_CHUNK: "response_chunk",
+	ON_START:          "start",
+	ON_IDLE:           "idle",
+	ON_FINISH:         "finish",
+	ON_STORAGE_ERROR:  "storage_error",
+	ON_CONTEXT_INIT:   "context_init",
+	ON_ROBOTS_SITEMAP: "robots_sitemap",
+}
+
+// ------------------------------------------------------------------------
+
+// String returns a short summary of the collector.
+func (c *Collector) String() string {
+	stats := c.Stats()
+
+	return fmt.Sprintf(
+		"Requests made: %d (%d responses) | Callbacks: OnRequest: %d, OnHTML: %d, OnResponse: %d, OnError: %d",
+		stats.Requests,
+		stats.Responses,
+		c.Callbacks.Count(ON_REQUEST),
+		c.Callbacks.Count(ON_HTML),
+		c.Callbacks.Count(ON_RESPONSE),
+		c.Callbacks.Count(ON_ERROR),
+	)
+}
+
+// DebugDump returns a diagnostic snapshot of the collector, including the effective
+// configuration, the filters, the services in use and the callback counts.
+// Use DebugInfo.String to include it in bug reports.
+func (c *Collector) DebugDump() *DebugInfo {
+	d := &DebugInfo{
+		CollectorID: c.ID,
+		Created:     time.Now(),
+		Settings:    map[string]string{},
+		Services:    map[string]string{},
+		Callbacks:   map[string]int{},
+		Stats:       c.Stats(),
+		Paused:      c.PausedDomains(),
+		Scheduled:   c.ScheduledCount(),
+	}
+
+	if c.Config != nil {
+		d.setConfig(c.Config)
+		d.Filters = c.Config.Filter.describe()
+		d.SubConfigs = len(c.Config.SubConfigs)
+	}
+
+	if c.store != nil {
+		d.Services["store"] = fmt.Sprintf("%T", c.store)
+	}
+
+	for event, name := range eventNames {
+		if n := c.Callbacks.Count(event); n > 0 {
+			d.Callbacks[name] = n
+		}
+	}
+	d.Handlers = c.Handlers()
+
+	return d
+}
+
+// ------------------------------------------------------------------------
+
+// String returns the snapshot as a human readable text.
+func (d *DebugInfo) String() string {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "Collector #%d (%s)\n", d.CollectorID, d.Created.Format(time.RFC3339))
+
+	writeSection(b, "Settings", d.Settings)
+	writeSection(b, "Services", d.Services)
+
+	fmt.Fprintf(b, "Filters: %d\n", len(d.Filters))
+	for _, f := range d.Filters {
+		fmt.Fprintf(b, "  %s %s: %s\n", f.Method, f.Scope, f.Label)
+	}
+	fmt.Fprintf(b, "Sub-configurations: %d\n", d.SubConfigs)
+
+	callbacks := map[string]string{}
+	for name, n := range d.Callbacks {
+		callbacks[name] = fmt.Sprint(n)
+	}
+	writeSection(b, "Callbacks", callbacks)
+	for _, h := range d.Handlers {
+		fmt.Fprintf(b, "  %s[%q] #%d %s", h.Event, h.Arg, h.Position, h.Function)
+		if h.System {
+			b.WriteString(" (system)")
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(b, "Stats: requests=%d responses=%d errors=%d scraped=%d bytes=%d\n",
+		d.Stats.Requests, d.Stats.Responses, d.Stats.Errors, d.Stats.Scraped, d.Stats.Bytes)
+	fmt.Fprintf(b, "Paused domains: %s\n", strings.Join(d.Paused, ", "))
+	fmt.Fprintf(b, "Scheduled requests: %d\n", d.Scheduled)
+
+	return b.String()
+}
+
+// writeSection writes the key-value pairs sorted by the keys.
+func writeSection(b *strings.Builder, title string, values map[string]string) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(b, "%s:\n", title)
+	for _, k := range keys {
+		fmt.Fprintf(b, "  %s = %s\n", k, values[k])
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// setConfig records the configuration values by their JSON names.
+// Scalar values go to the settings, the attached services are recorded by their types.
+func (d *DebugInfo) setConfig(config *CollectorConfig) {
+	v := reflect.ValueOf(config).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			name = field.Name
+		}
+
+		switch value.Kind() {
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.String:
+			d.Settings[name] = fmt.Sprint(value.Interface())
+		case reflect.Slice:
+			if value.Type().Elem().Kind() == reflect.String {
+				items := make([]string, value.Len())
+				for j := range items {
+					items[j] = value.Index(j).String()
+				}
+				d.Settings[name] = strings.Join(items, ",")
+			}
+		case reflect.Interface, reflect.Pointer, reflect.Func:
+			if value.IsNil() || field.Name == "Filter" || field.Name == "SubConfigs" {
+				continue
+			}
+			if value.Kind() == reflect.Func {
+				d.Services[name] = "func"
+			} else {
+				d.Services[name] = fmt.Sprintf("%T", value.Interface())
+			}
+		}
+	}
+
+	if config.UserAgentCallback != nil {
+		d.Settings["user_agent"] = config.UserAgentCallback()
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// describe returns the list of the filters sorted by the method and the label.
+func (f *Filter) describe() []FilterInfo {
+	if f == nil {
+		return nil
+	}
+
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	list := make([]FilterInfo, 0, len(f.excl)+len(f.incl))
+	for label, item := range f.excl {
+		list = append(list, FilterInfo{Label: label, Method: "exclude", Scope: item.scope.String()})
+	}
+	for label, item := range f.incl {
+		list = append(list, FilterInfo{Label: label, Method: "include", Scope: item.scope.String()})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Method != list[j].Method {
+			return list[i].Method < list[j].Method
+		}
+		return list[i].Label < list[j].Label
+	})
+
+	return list
+}
@@ -0,0 +1,96 @@
+package colly
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestDebugInfo_SetConfig(t *testing.T) {
+	config := NewConfig()
+	config.MaxDepth = 3
+	config.AcceptEncoding = []string{"gzip", "br"}
+
+	d := &DebugInfo{Settings: map[string]string{}, Services: map[string]string{}}
+	d.setConfig(config)
+
+	settings := map[string]string{
+		"max_depth":         "3",
+		"max_body_size":     "10485760",
+		"ignore_robots_txt": "true",
+		"accept_encoding":   "gzip,br",
+		"user_agent":        "colly v3",
+	}
+	for name, want := range settings {
+		if got := d.Settings[name]; got != want {
+			t.Errorf("Settings[%q] = %q, want %q", name, got, want)
+		}
+	}
+
+	for _, name := range []string{"cache", "cookie_jar", "parser"} {
+		if d.Services[name] == "" {
+			t.Errorf("Services[%q] is missing", name)
+		}
+	}
+	if _, present := d.Services["filter"]; present {
+		t.Error("filters must not be listed as services")
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestFilter_Describe(t *testing.T) {
+	f := NewFilter()
+	if err := f.AddDomainGlob(FILTER_METHOD_INCLUDE, []string{"*.example.com"}, "allowed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.AddURLRegexp(FILTER_METHOD_EXCLUDE, []string{`\.pdf$`}, "no-pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.AddURLLength(0, 2048, "length"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []FilterInfo{
+		{Label: "length", Method: "exclude", Scope: "url"},
+		{Label: "no-pdf", Method: "exclude", Scope: "url"},
+		{Label: "allowed", Method: "include", Scope: "domain"},
+	}
+	if got := f.describe(); !reflect.DeepEqual(got, want) {
+		t.Errorf("describe() = %+v, want %+v", got, want)
+	}
+
+	var nilFilter *Filter
+	if got := nilFilter.describe(); got != nil {
+		t.Errorf("describe() of nil filter = %+v, want nil", got)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestDebugInfo_String(t *testing.T) {
+	d := &DebugInfo{
+		CollectorID: 7,
+		Settings:    map[string]string{"max_depth": "3"},
+		Services:    map[string]string{"cache": "*colly.cache"},
+		Filters:     []FilterInfo{{Label: "allowed", Method: "include", Scope: "domain"}},
+		Callbacks:   map[string]int{"html": 2},
+		Paused:      []string{"example.com"},
+	}
+
+	text := d.String()
+	for _, want := range []string{
+		"Collector #7",
+		"max_depth = 3",
+		"cache = *colly.cache",
+		"include domain: allowed",
+		"html = 2",
+		"Paused domains: example.com",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("String() is missing %q:\n%s", want, text)
+		}
+	}
+}
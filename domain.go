@@ -0,0 +1,133 @@
+package colly
+
+import (
+	"colly/storage"
+	"errors"
+)
+
+// ------------------------------------------------------------------------
+
+// DomainStats is the accounting of the data stored about a domain.
+type DomainStats struct {
+	Domain string              `json:"domain" bson:"domain"`           // Domain is the host name of the URLs.
+	Cache  storage.PrefixStats `json:"cache" bson:"cache,omitempty"`   // Cache is the accounting of the cached responses.
+	Visits storage.PrefixStats `json:"visits" bson:"visits,omitempty"` // Visits is the accounting of the visited URLs.
+}
+
+// ------------------------------------------------------------------------
+
+// DomainStats returns the number and the size of the cached responses and the visited URLs of the domain.
+// The domain is the host name of the URLs, without a port.
+// Storages that don't implement the storage.Counter interface are skipped.
+func (c *Collector) DomainStats(domain string) (*DomainStats, error) {
+	if _, err := storage.DomainPrefix(domain); err != nil {
+		return nil, err
+	}
+
+	stats := &DomainStats{Domain: domain}
+	if c.Config == nil {
+		return stats, nil
+	}
+
+	if stg := c.Config.cacheStorage(); stg != nil {
+		if s, err := storage.CountDomain(domain, stg); err == nil {
+			stats.Cache = s
+		} else if !errors.Is(err, storage.ErrNotImplemented) {
+			return nil, err
+		}
+	}
+
+	for _, stg := range c.Config.visitStorages() {
+		s, err := storage.CountDomain(domain, stg)
+		if errors.Is(err, storage.ErrNotImplemented) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		stats.Visits.Count += s.Count
+		stats.Visits.Bytes += s.Bytes
+	}
+
+	return stats, nil
+}
+
+// ------------------------------------------------------------------------
+
+// PurgeDomain removes the cached responses, the visits and the cookies of the domain
+// from the storages of the collector, e.g. to comply with a takedown request.
+// The domain is the host name of the URLs, without a port.
+// The cookies are stored by eTLD+1, so the cookies of the sibling subdomains are removed as well.
+// The storages must implement the storage.Purger interface. Every storage is purged even if another one
+// fails, the errors are joined.
+func (c *Collector) PurgeDomain(domain string) error {
+	if _, err := storage.DomainPrefix(domain); err != nil {
+		return err
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_INFO_LEVEL, "purge", 0, map[string]string{
+			"domain": domain,
+		})
+	}
+
+	if c.Config == nil {
+		return nil
+	}
+
+	stgs := []any{}
+	if stg := c.Config.cacheStorage(); stg != nil {
+		stgs = append(stgs, stg)
+	}
+	stgs = append(stgs, c.Config.visitStorages()...)
+
+	err := storage.PurgeDomain(domain, stgs...)
+
+	if jar, ok := c.Config.CookieJar.(interface{ RemoveDomain(string) error }); ok {
+		err = errors.Join(err, jar.RemoveDomain(domain))
+	}
+
+	return err
+}
+
+// ------------------------------------------------------------------------
+
+// cacheStorage returns the data storage of the cache, or nil if it is unknown.
+func (c *CollectorConfig) cacheStorage() CacheStorage {
+	if ch, ok := c.Cache.(interface{ Storage() CacheStorage }); ok {
+		return ch.Storage()
+	}
+
+	return nil
+}
+
+// cookieStorage returns the storage of the cookie jar, or nil if it is unknown.
+func (c *CollectorConfig) cookieStorage() CookieStorage {
+	if jar, ok := c.CookieJar.(interface{ Storage() CookieStorage }); ok {
+		return jar.Storage()
+	}
+
+	return nil
+}
+
+// visitStorages returns the storages of the revisit filters of the configuration and the sub-configurations.
+func (c *CollectorConfig) visitStorages() []any {
+	stgs := []any{}
+
+	if c.Filter != nil {
+		for _, stg := range c.Filter.VisitStorages() {
+			stgs = append(stgs, stg)
+		}
+	}
+
+	for _, sc := range c.SubConfigs {
+		if sc != nil && sc.Filter != nil {
+			for _, stg := range sc.Filter.VisitStorages() {
+				stgs = append(stgs, stg)
+			}
+		}
+	}
+
+	return stgs
+}
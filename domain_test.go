@@ -0,0 +1,122 @@
+package colly
+
+import (
+	"bytes"
+	"colly/filters"
+	"colly/storage"
+	"colly/storage/filesys"
+	"colly/storage/mem"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_cache_Domain(t *testing.T) {
+	stg := mem.NewCacheStorage()
+	c, _ := NewCache(stg, NewCacheExpiryNever())
+
+	for _, rawURL := range []string{"https://Example.com/a", "https://example.com:8080/b", "https://example.org/a"} {
+		u, _ := url.Parse(rawURL)
+		resp := &Response{
+			Request: &Request{Req: &http.Request{Method: "GET", URL: u}},
+			Resp:    &http.Response{Status: "200 OK", StatusCode: 200, Header: http.Header{}},
+			Body:    []byte("body"),
+		}
+		if err := c.Set(resp); err != nil {
+			t.Fatalf("cache.Set() error = %v", err)
+		}
+	}
+
+	stats, err := storage.CountDomain("example.com", c.Storage())
+	if err != nil || stats.Count != 2 {
+		t.Errorf("CountDomain() = %+v, error = %v, want 2 items", stats, err)
+	}
+
+	if err := storage.PurgeDomain("example.com", c.Storage()); err != nil {
+		t.Fatal(err)
+	}
+	for rawURL, want := range map[string]bool{"https://example.com/a": false, "https://example.com:8080/b": false, "https://example.org/a": true} {
+		if got, _ := c.Get(rawURL); (got != nil) != want {
+			t.Errorf("cache.Get(%q) found = %v, want %v", rawURL, got != nil, want)
+		}
+	}
+
+	// Items stored with the unscoped keys are still readable
+	legacy := "https://example.net/"
+	u, _ := url.Parse(legacy)
+	data, _ := c.encodeResponse(&Response{
+		Request: &Request{Req: &http.Request{Method: "GET", URL: u}},
+		Resp:    &http.Response{StatusCode: 200, Header: http.Header{}},
+	})
+	stg.Put(c.legacyKeyFromURL(legacy), bytes.NewReader(data.Bytes()))
+
+	if got, err := c.Get(legacy); err != nil || got == nil {
+		t.Errorf("cache.Get() legacy item = %v, error = %v", got, err)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_PurgeDomain(t *testing.T) {
+	cfg := NewConfig()
+	stg, err := filesys.NewCacheStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SetCache(stg, NewCacheExpiryNever()); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SetMaxRevisits(0, mem.NewVisitStorage()); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SetCookieJar(mem.NewCookieStorage(), COOKIE_MODE_STRICT); err != nil {
+		t.Fatal(err)
+	}
+	c := NewCollector(cfg, nil)
+
+	visits := c.Config.Filter.VisitStorages()[0]
+	for _, rawURL := range []string{"https://example.com/a", "https://example.org/a"} {
+		u, _ := url.Parse(rawURL)
+		resp := &Response{
+			Request: &Request{Req: &http.Request{Method: "GET", URL: u}},
+			Resp:    &http.Response{Status: "200 OK", StatusCode: 200, Header: http.Header{}},
+			Body:    []byte("body"),
+		}
+		if err := c.Config.Cache.Set(resp); err != nil {
+			t.Fatalf("cache.Set() error = %v", err)
+		}
+		visits.AddVisit(filters.VisitKey(rawURL))
+		c.Config.CookieJar.SetCookies(u, []*http.Cookie{{Name: "k", Value: "v"}})
+	}
+
+	if err := c.PurgeDomain("example.com"); err != nil {
+		t.Fatalf("PurgeDomain() error = %v", err)
+	}
+
+	for rawURL, want := range map[string]bool{"https://example.com/a": false, "https://example.org/a": true} {
+		u, _ := url.Parse(rawURL)
+		if got, _ := c.Config.Cache.Get(rawURL); (got != nil) != want {
+			t.Errorf("cache.Get(%q) found = %v, want %v", rawURL, got != nil, want)
+		}
+		if n, _ := visits.PastVisits(filters.VisitKey(rawURL)); (n > 0) != want {
+			t.Errorf("PastVisits(%q) = %d, want visited = %v", rawURL, n, want)
+		}
+		if got := c.Config.CookieJar.Cookies(u); (len(got) > 0) != want {
+			t.Errorf("Cookies(%q) = %v, want found = %v", rawURL, got, want)
+		}
+	}
+
+	// The storages that can't purge don't stop the others
+	cache := mem.NewCacheStorage()
+	cache.Put("example.com#0123abcd", bytes.NewReader([]byte("data")))
+	err = storage.PurgeDomain("example.com", struct{}{}, cache)
+	if !errors.Is(err, storage.ErrNotImplemented) {
+		t.Errorf("PurgeDomain() error = %v, want %v", err, storage.ErrNotImplemented)
+	}
+	if cache.Has("example.com#0123abcd") {
+		t.Error("PurgeDomain() skipped the storage after a failing one")
+	}
+}
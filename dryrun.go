@@ -0,0 +1,200 @@
+package colly
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// DryRunEntry is a request of a dry-run crawl, either planned or skipped.
+type DryRunEntry struct {
+	URL        string `json:"url" bson:"url"`                           // URL is the requested URL.
+	Rewritten  string `json:"rewritten" bson:"rewritten,omitempty"`     // Rewritten is the URL after the URL rewriter, if it was changed.
+	Method     string `json:"method" bson:"method,omitempty"`           // Method is the HTTP method of the request.
+	Depth      uint16 `json:"depth" bson:"depth,omitempty"`             // Depth is the depth of the request.
+	Skipped    bool   `json:"skipped" bson:"skipped,omitempty"`         // Skipped tells whether the request would not be fetched.
+	Reason     string `json:"reason,omitempty" bson:"reason,omitempty"` // Reason is why the request was skipped.
+	Robots     string `json:"robots,omitempty" bson:"robots,omitempty"` // Robots is "unknown" if the robots.txt of the host was not loaded.
+	RequestID  uint32 `json:"request_id" bson:"request_id,omitempty"`   // RequestID is the ID of the request.
+	ConfigHash string `json:"config_hash" bson:"config_hash,omitempty"` // ConfigHash identifies the configuration of the collector, see Collector.ConfigHash.
+}
+
+// dryRunPlan collects the entries of a dry-run crawl.
+type dryRunPlan struct {
+	entries []*DryRunEntry
+	lock    *sync.Mutex
+}
+
+// ------------------------------------------------------------------------
+
+// ErrDryRunAborted is the reason of the requests aborted by the OnRequest callbacks in a dry run.
+var ErrDryRunAborted = errors.New("aborted by an OnRequest callback")
+
+// ------------------------------------------------------------------------
+
+// newDryRunPlan returns a pointer to a newly created dry-run plan.
+func newDryRunPlan() *dryRunPlan {
+	return &dryRunPlan{
+		entries: []*DryRunEntry{},
+		lock:    &sync.Mutex{},
+	}
+}
+
+// add appends an entry to the plan.
+func (p *dryRunPlan) add(e *DryRunEntry) {
+	p.lock.Lock()
+	p.entries = append(p.entries, e)
+	p.lock.Unlock()
+}
+
+// list returns a copy of the entries.
+func (p *dryRunPlan) list() []DryRunEntry {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	entries := make([]DryRunEntry, len(p.entries))
+	for i, e := range p.entries {
+		entries[i] = *e
+	}
+
+	return entries
+}
+
+// ------------------------------------------------------------------------
+
+// DryRunPlan returns the requests of a dry-run crawl in the order they were dispatched,
+// both the ones that would be fetched and the skipped ones with the reason.
+func (c *Collector) DryRunPlan() []DryRunEntry {
+	return c.dryRun.list()
+}
+
+// ------------------------------------------------------------------------
+
+// dryRunCheck runs the filters and the robots.txt rules on a request of a dry run.
+// It returns false if the request is skipped. No network request is made, the robots.txt
+// rules are only checked for the hosts whose robots.txt was already loaded.
+func (c *Collector) dryRunCheck(r *Request) bool {
+	var err error
+	if c.Config.Filter != nil {
+		err = c.Config.Filter.Match(r)
+	}
+
+	if err == nil && !c.Config.IgnoreRobotsTxt && r.Req.Method != "HEAD" {
+		err = c.robotsAllowed(r)
+	}
+
+	if err != nil {
+		c.dryRunSkip(c.dryRunEntry(r), err)
+		return false
+	}
+
+	return true
+}
+
+// dryRunFinish records the outcome of a request of a dry run after the OnRequest callbacks
+// and aborts it, so it is never sent.
+func (c *Collector) dryRunFinish(r *Request) {
+	e := c.dryRunEntry(r)
+	if orig := r.OriginalURL(); orig != nil && orig.String() != r.Req.URL.String() {
+		e.URL = orig.String()
+		e.Rewritten = r.Req.URL.String()
+	}
+	// The robots.txt rules were checked against the URL before rewriting
+	if !c.Config.IgnoreRobotsTxt && !c.robotsLoaded(r.OriginalURL().Host) {
+		e.Robots = "unknown"
+	}
+
+	if r.abort {
+		c.dryRunSkip(e, ErrDryRunAborted)
+		return
+	}
+	r.Abort()
+
+	c.dryRun.add(e)
+
+	if c.HasLogger() {
+		args := map[string]string{
+			"url":    e.URL,
+			"method": e.Method,
+			"depth":  strconv.Itoa(int(e.Depth)),
+		}
+		if e.Rewritten != "" {
+			args["rewritten"] = e.Rewritten
+		}
+		if e.Robots != "" {
+			args["robots"] = e.Robots
+		}
+		c.logEvent(LOG_INFO_LEVEL, "dry_run", r.ID, args)
+	}
+}
+
+// dryRunSkip records and logs a skipped request of a dry run.
+func (c *Collector) dryRunSkip(e *DryRunEntry, reason error) {
+	e.Skipped = true
+	e.Reason = reason.Error()
+	c.dryRun.add(e)
+
+	if c.HasLogger() {
+		c.logEvent(LOG_INFO_LEVEL, "dry_run_skip", e.RequestID, map[string]string{
+			"url":    e.URL,
+			"method": e.Method,
+			"reason": e.Reason,
+		})
+	}
+}
+
+// dryRunEntry returns a new dry-run entry of the request.
+func (c *Collector) dryRunEntry(r *Request) *DryRunEntry {
+	return &DryRunEntry{
+		URL:        r.Req.URL.String(),
+		Method:     r.Req.Method,
+		Depth:      r.Depth,
+		RequestID:  r.ID,
+		ConfigHash: c.ConfigHash(),
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// robotsLoaded tells whether the robots.txt of the host was loaded.
+func (c *Collector) robotsLoaded(host string) bool {
+	c.lock.RLock()
+	_, present := c.robotsMap[host]
+	c.lock.RUnlock()
+
+	return present
+}
+
+// robotsAllowed checks the request against the loaded robots.txt of its host.
+// The requests of the hosts without a loaded robots.txt are allowed.
+func (c *Collector) robotsAllowed(r *Request) error {
+	c.lock.RLock()
+	robots, present := c.robotsMap[r.Req.URL.Host]
+	c.lock.RUnlock()
+
+	if !present || robots == nil {
+		return nil
+	}
+
+	userAgent := ""
+	if c.Config.UserAgentCallback != nil {
+		userAgent = c.Config.UserAgentCallback()
+	}
+
+	group := robots.FindGroup(userAgent)
+	if group == nil {
+		return nil
+	}
+
+	path := r.Req.URL.EscapedPath()
+	if r.Req.URL.RawQuery != "" {
+		path += "?" + r.Req.URL.RawQuery
+	}
+	if !group.Test(path) {
+		return ErrRobotsTxtBlocked
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package colly
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/temoto/robotstxt"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollector_DryRun(t *testing.T) {
+	cfg := NewConfig()
+	cfg.DryRun = true
+	cfg.IgnoreRobotsTxt = false
+	cfg.URLRewriter = NewHostRewriter(map[string]string{"example.com": "mirror.example.net"})
+	if err := cfg.SetDisallowedDomains([]string{"blocked.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCollector(cfg, nil)
+	c.OnRequest(func(r *Request) {
+		if r.Req.URL.Path == "/abort" {
+			r.Abort()
+		}
+	})
+
+	robots, _ := robotstxt.FromString("User-agent: *\nDisallow: /private")
+	c.robotsMap["example.com"] = robots
+
+	for i, rawURL := range []string{
+		"http://example.com/page",
+		"http://blocked.com/page",
+		"http://example.com/private/page",
+		"http://example.com/abort",
+		"http://other.com/page",
+	} {
+		req, err := http.NewRequest("GET", rawURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := &Request{ID: uint32(i + 1), Req: req, collector: c}
+		c.handleOnRequest(r)
+		if !r.abort {
+			t.Errorf("handleOnRequest(%q) did not abort the request", rawURL)
+		}
+	}
+
+	hash := c.ConfigHash()
+	want := []DryRunEntry{
+		{URL: "http://example.com/page", Rewritten: "http://mirror.example.net/page", Method: "GET", RequestID: 1, ConfigHash: hash},
+		{URL: "http://blocked.com/page", Method: "GET", Skipped: true, Reason: ErrFilterDomainDisallowed.Error(), RequestID: 2, ConfigHash: hash},
+		{URL: "http://example.com/private/page", Method: "GET", Skipped: true, Reason: ErrRobotsTxtBlocked.Error(), RequestID: 3, ConfigHash: hash},
+		{URL: "http://example.com/abort", Method: "GET", Skipped: true, Reason: ErrDryRunAborted.Error(), RequestID: 4, ConfigHash: hash},
+		{URL: "http://other.com/page", Method: "GET", Robots: "unknown", RequestID: 5, ConfigHash: hash},
+	}
+	if got := c.DryRunPlan(); !reflect.DeepEqual(got, want) {
+		t.Errorf("DryRunPlan() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,262 @@
+package colly
+
+import (
+	"bytes"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ------------------------------------------------------------------------
+
+// DuplicateAnalysis tells which duplicate-content groupings are included in the crawl report.
+type DuplicateAnalysis uint8
+
+// DuplicateReport lists the clusters of the crawled pages with duplicate content.
+type DuplicateReport struct {
+	Canonical []*DuplicateCluster `json:"canonical" bson:"canonical,omitempty"` // Canonical is the list of the pages grouped by their canonical URL, following the canonical chains.
+	Title     []*DuplicateCluster `json:"title" bson:"title,omitempty"`         // Title is the list of the pages grouped by their normalized title.
+	Content   []*DuplicateCluster `json:"content" bson:"content,omitempty"`     // Content is the list of the pages grouped by the hash of their body.
+}
+
+// DuplicateCluster is a group of pages sharing the same canonical URL, title or content.
+type DuplicateCluster struct {
+	Key     string   `json:"key" bson:"key,omitempty"`           // Key is the canonical URL, the normalized title or the content hash of the cluster.
+	URLs    []string `json:"urls" bson:"urls,omitempty"`         // URLs is the sorted list of the page URLs.
+	MaxHops int      `json:"max_hops" bson:"max_hops,omitempty"` // MaxHops is the length of the longest canonical chain of the cluster.
+}
+
+// pageSignature is the duplicate-content fingerprint of a page
+type pageSignature struct {
+	canonical string
+	title     string
+	hash      uint64
+}
+
+// ------------------------------------------------------------------------
+
+const (
+	DUPLICATE_CANONICAL DuplicateAnalysis = 1 << iota // Group the pages by canonical chains.
+	DUPLICATE_TITLE                                   // Group the pages by duplicate titles.
+	DUPLICATE_CONTENT                                 // Group the pages by identical bodies.
+
+	DUPLICATE_NONE DuplicateAnalysis = 0                                                         // No duplicate analysis.
+	DUPLICATE_ALL                    = DUPLICATE_CANONICAL | DUPLICATE_TITLE | DUPLICATE_CONTENT // All duplicate groupings.
+)
+
+// ------------------------------------------------------------------------
+
+// ParseDuplicateAnalysis parses a comma separated list of the "canonical", "title" and "content"
+// groupings, or "all" or "off".
+func ParseDuplicateAnalysis(val string) (DuplicateAnalysis, error) {
+	var a DuplicateAnalysis
+
+	for _, name := range strings.Split(val, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "canonical":
+			a |= DUPLICATE_CANONICAL
+		case "title":
+			a |= DUPLICATE_TITLE
+		case "content":
+			a |= DUPLICATE_CONTENT
+		case "all":
+			a |= DUPLICATE_ALL
+		case "", "off":
+		default:
+			return DUPLICATE_NONE, strconv.ErrSyntax
+		}
+	}
+
+	return a, nil
+}
+
+// ------------------------------------------------------------------------
+
+// recordPage stores the duplicate-content fingerprint of a HTML response.
+func (r *reporter) recordPage(resp *Response) {
+	if resp.Request == nil || resp.Request.Req == nil || !strings.Contains(resp.ContentType(), "html") {
+		return
+	}
+
+	sig := pageSignature{}
+	sig.title, sig.canonical = pageTitleAndCanonical(resp.Body)
+	if link := canonicalLink(resp.Resp.Header.Values("Link")); link != "" {
+		sig.canonical = link
+	}
+	if sig.canonical != "" {
+		sig.canonical = resp.Request.AbsoluteURL(sig.canonical)
+	}
+
+	h := fnv.New64a()
+	h.Write(resp.Body)
+	sig.hash = h.Sum64()
+
+	r.lock.Lock()
+	r.pages[resp.Request.OriginalURL().String()] = sig
+	r.lock.Unlock()
+}
+
+// duplicates returns the duplicate-content clusters of the recorded pages.
+func (r *reporter) duplicates(analysis DuplicateAnalysis) *DuplicateReport {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	rep := &DuplicateReport{}
+
+	if analysis&DUPLICATE_CANONICAL != 0 {
+		groups, hops := map[string][]string{}, map[string]int{}
+		for u := range r.pages {
+			root, n := r.canonicalRoot(u)
+			groups[root] = append(groups[root], u)
+			if n > hops[root] {
+				hops[root] = n
+			}
+		}
+		rep.Canonical = duplicateClusters(groups, hops)
+	}
+
+	if analysis&DUPLICATE_TITLE != 0 {
+		groups := map[string][]string{}
+		for u, sig := range r.pages {
+			if sig.title != "" {
+				groups[sig.title] = append(groups[sig.title], u)
+			}
+		}
+		rep.Title = duplicateClusters(groups, nil)
+	}
+
+	if analysis&DUPLICATE_CONTENT != 0 {
+		groups := map[string][]string{}
+		for u, sig := range r.pages {
+			key := strconv.FormatUint(sig.hash, 16)
+			groups[key] = append(groups[key], u)
+		}
+		rep.Content = duplicateClusters(groups, nil)
+	}
+
+	return rep
+}
+
+// canonicalRoot follows the canonical chain of the page and returns the last URL and the number of hops.
+// The chain stops at the pages that were not crawled, the self-references and the loops.
+func (r *reporter) canonicalRoot(u string) (string, int) {
+	seen := map[string]bool{u: true}
+	hops := 0
+
+	for {
+		next := r.pages[u].canonical
+		if next == "" || seen[next] {
+			return u, hops
+		}
+
+		seen[next] = true
+		u = next
+		hops++
+
+		if _, crawled := r.pages[u]; !crawled {
+			return u, hops
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// duplicateClusters returns the groups with more than one page, sorted by the size and the key.
+func duplicateClusters(groups map[string][]string, hops map[string]int) []*DuplicateCluster {
+	clusters := []*DuplicateCluster{}
+
+	for key, urls := range groups {
+		if len(urls) < 2 {
+			continue
+		}
+
+		sort.Strings(urls)
+		clusters = append(clusters, &DuplicateCluster{Key: key, URLs: urls, MaxHops: hops[key]})
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].URLs) == len(clusters[j].URLs) {
+			return clusters[i].Key < clusters[j].Key
+		}
+		return len(clusters[i].URLs) > len(clusters[j].URLs)
+	})
+
+	return clusters
+}
+
+// pageTitleAndCanonical returns the normalized title and the canonical link of a HTML page.
+// Only the head of the page is tokenized, up to the body or the first content element.
+func pageTitleAndCanonical(body []byte) (title string, canonical string) {
+	z := html.NewTokenizer(bytes.NewReader(body))
+	inTitle := false
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return title, canonical
+		case html.TextToken:
+			if inTitle {
+				title += string(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+				title = strings.ToLower(strings.Join(strings.Fields(title), " "))
+			case "head":
+				return title, canonical
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = title == ""
+			case "html", "head", "meta", "base", "script", "style", "noscript", "template":
+			case "link":
+				var rel, href string
+				for hasAttr {
+					var key, val []byte
+					key, val, hasAttr = z.TagAttr()
+					switch string(key) {
+					case "rel":
+						rel = strings.ToLower(string(val))
+					case "href":
+						href = strings.TrimSpace(string(val))
+					}
+				}
+				if canonical == "" && rel == "canonical" {
+					canonical = href
+				}
+			default:
+				// The head ends at the first content element
+				return title, canonical
+			}
+		}
+	}
+}
+
+// canonicalLink returns the canonical URL of the Link response headers.
+func canonicalLink(values []string) string {
+	for _, v := range values {
+		for _, link := range strings.Split(v, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+
+			for _, param := range parts[1:] {
+				param = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(param), `"`, ""))
+				if param == "rel=canonical" {
+					return target[1 : len(target)-1]
+				}
+			}
+		}
+	}
+
+	return ""
+}
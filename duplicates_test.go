@@ -0,0 +1,95 @@
+package colly
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_reporter_duplicates(t *testing.T) {
+	r := newReporter()
+	r.pages = map[string]pageSignature{
+		"https://a.com/1":     {canonical: "https://a.com/2", title: "home", hash: 1},
+		"https://a.com/2":     {canonical: "https://a.com/3", title: "home", hash: 2},
+		"https://a.com/3":     {canonical: "https://a.com/3", title: "start", hash: 1},
+		"https://a.com/4":     {canonical: "https://a.com/gone", title: "", hash: 4},
+		"https://a.com/5":     {canonical: "https://a.com/gone", title: "", hash: 5},
+		"https://a.com/alone": {title: "alone", hash: 6},
+	}
+
+	want := &DuplicateReport{
+		Canonical: []*DuplicateCluster{
+			{Key: "https://a.com/3", URLs: []string{"https://a.com/1", "https://a.com/2", "https://a.com/3"}, MaxHops: 2},
+			{Key: "https://a.com/gone", URLs: []string{"https://a.com/4", "https://a.com/5"}, MaxHops: 1},
+		},
+		Title: []*DuplicateCluster{
+			{Key: "home", URLs: []string{"https://a.com/1", "https://a.com/2"}},
+		},
+		Content: []*DuplicateCluster{
+			{Key: "1", URLs: []string{"https://a.com/1", "https://a.com/3"}},
+		},
+	}
+
+	if got := r.duplicates(DUPLICATE_ALL); !reflect.DeepEqual(got, want) {
+		t.Errorf("duplicates() = %+v, want %+v", got, want)
+	}
+
+	if got := r.duplicates(DUPLICATE_TITLE); got.Canonical != nil || got.Content != nil || len(got.Title) != 1 {
+		t.Errorf("duplicates(DUPLICATE_TITLE) = %+v, want title clusters only", got)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_pageTitleAndCanonical(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		wantTitle     string
+		wantCanonical string
+	}{
+		{"head", `<html><head><title>  My
+			Page </title><link rel="Canonical" href=" /page "></head><body><title>x</title></body></html>`, "my page", "/page"},
+		{"no head", `<title>Only</title><p><link rel="canonical" href="/late"></p>`, "only", ""},
+		{"empty", ``, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, canonical := pageTitleAndCanonical([]byte(tt.body))
+			if title != tt.wantTitle || canonical != tt.wantCanonical {
+				t.Errorf("pageTitleAndCanonical() = %q, %q, want %q, %q", title, canonical, tt.wantTitle, tt.wantCanonical)
+			}
+		})
+	}
+}
+
+func Test_canonicalLink(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{[]string{`<https://a.com/next>; rel="next", <https://a.com/page>; rel="canonical"`}, "https://a.com/page"},
+		{[]string{`<https://a.com/next>; rel=next`}, ""},
+		{nil, ""},
+	}
+
+	for _, tt := range tests {
+		if got := canonicalLink(tt.values); got != tt.want {
+			t.Errorf("canonicalLink(%q) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestParseDuplicateAnalysis(t *testing.T) {
+	if a, err := ParseDuplicateAnalysis("canonical, Title"); err != nil || a != DUPLICATE_CANONICAL|DUPLICATE_TITLE {
+		t.Errorf("ParseDuplicateAnalysis() = %v, %v", a, err)
+	}
+	if a, err := ParseDuplicateAnalysis("all"); err != nil || a != DUPLICATE_ALL {
+		t.Errorf("ParseDuplicateAnalysis() = %v, %v", a, err)
+	}
+	if _, err := ParseDuplicateAnalysis("titles"); err == nil {
+		t.Errorf("ParseDuplicateAnalysis() error = nil, want an error")
+	}
+}
@@ -138,6 +138,26 @@ func (el *eventList) IsEmpty(event uint8, arg ...string) bool {
 
 // ------------------------------------------------------------------------
 
+// Positions returns the positions of the event argument items in their sorted order.
+func (el *eventList) Positions(event uint8, arg string) []int {
+	el.lock.RLock()
+	defer el.lock.RUnlock()
+
+	al, present := el.events[event]
+	if !present {
+		return nil
+	}
+
+	il, present := al.args[arg]
+	if !present {
+		return nil
+	}
+
+	return il.positions()
+}
+
+// ------------------------------------------------------------------------
+
 func newArgList() *evenArgList {
 	return &evenArgList{
 		args:    map[string]*eventArgItemList{},
@@ -353,6 +373,18 @@ func (il *eventArgItemList) sort() {
 
 // --------------------------------
 
+func (il *eventArgItemList) positions() []int {
+	keys := make([]int, 0, len(il.original))
+	for k := range il.original {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	return keys
+}
+
+// --------------------------------
+
 func (il *eventArgItemList) count() int {
 	return len(il.original)
 }
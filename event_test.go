@@ -3186,3 +3186,31 @@ func Test_itemList_isEmpty(t *testing.T) {
 		})
 	}
 }
+
+// ------------------------------------------------------------------------
+
+func Test_eventList_Positions(t *testing.T) {
+	el := NewEventList()
+	el.Add(ON_HTML, "a", "first", 7)
+	el.Add(ON_HTML, "a", "second", -1)
+	el.Add(ON_HTML, "a", "third")
+	el.Add(ON_HTML, "b", "other")
+
+	tests := []struct {
+		name  string
+		event uint8
+		arg   string
+		want  []int
+	}{
+		{"sorted positions", ON_HTML, "a", []int{-1, 7, 8}},
+		{"missing argument", ON_HTML, "c", nil},
+		{"missing event", ON_XML, "a", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := el.Positions(tt.event, tt.arg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eventList.Positions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package colly
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// ------------------------------------------------------------------------
+
+// ExtractCallback is a type alias for OnExtract callback functions.
+// It receives the text or the attribute value of a matching element.
+type ExtractCallback func(*Response, string)
+
+// extractor is a registered extraction of a selector.
+type extractor struct {
+	attr string
+	fn   ExtractCallback
+}
+
+// simpleSelector is a selector that can be matched against a single start tag.
+// It has the form of tag#id.class[attr=value], where every part is optional.
+type simpleSelector struct {
+	tag     string
+	id      string
+	classes []string
+	attr    string
+	value   string
+	hasAttr bool
+}
+
+// capture is an element being captured by the tokenizer.
+type capture struct {
+	depth int // position of the element in the stack of open elements
+	ex    []*extractor
+	text  *strings.Builder
+}
+
+// ------------------------------------------------------------------------
+
+var simpleSelectorRegexp = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9-]*|\*)?(#[\w-]+)?((?:\.[\w-]+)*)(?:\[([\w-]+)(?:=["']?([^"'\]]*)["']?)?\])?$`)
+
+// voidElements are the HTML elements without end tags.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// impliedEndTags maps the open elements to the start tags that close them implicitly.
+var impliedEndTags = map[string]map[string]bool{
+	"p": {
+		"p": true, "div": true, "ul": true, "ol": true, "dl": true, "table": true, "form": true, "pre": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "section": true, "article": true,
+		"header": true, "footer": true, "nav": true, "aside": true, "blockquote": true, "hr": true,
+	},
+	"li":     {"li": true},
+	"dt":     {"dt": true, "dd": true},
+	"dd":     {"dt": true, "dd": true},
+	"tr":     {"tr": true},
+	"td":     {"td": true, "th": true, "tr": true},
+	"th":     {"td": true, "th": true, "tr": true},
+	"option": {"option": true, "optgroup": true},
+}
+
+// ------------------------------------------------------------------------
+
+// OnExtract is convenience method to register a function that will be executed
+// on the text (if attrName is blank) or the attribute value of every HTML element
+// matched by the selector.
+// Simple selectors like "a[href]", "div.price" or "#title" are matched by a streaming
+// tokenizer without building the DOM. Other selectors fall back to goquery.
+func (c *Collector) OnExtract(selector string, attrName string, fn ExtractCallback, position ...int) {
+	c.Callbacks.Add(ON_EXTRACT, selector, &extractor{attr: attrName, fn: fn}, position...)
+}
+
+// OnExtractDetach removes a number of registered extract callback functions.
+// If no position was given, all functions will be removed for the given selector.
+func (c *Collector) OnExtractDetach(selector string, position ...int) {
+	c.Callbacks.Remove(ON_EXTRACT, selector, position...)
+}
+
+func (c *Collector) handleOnExtract(resp *Response) error {
+	if c.Callbacks.IsEmpty(ON_EXTRACT) || !strings.Contains(resp.ContentType(), "html") {
+		return nil
+	}
+
+	simpleSel := map[*simpleSelector][]*extractor{}
+	complexSel := map[string][]*extractor{}
+
+	for selector, fnList := range c.Callbacks.Get(ON_EXTRACT) {
+		var list []*extractor
+		for _, fn := range fnList {
+			if ex, ok := fn.(*extractor); ok {
+				list = append(list, ex)
+			}
+		}
+
+		if sel := parseSimpleSelector(selector); sel != nil {
+			simpleSel[sel] = list
+		} else {
+			complexSel[selector] = list
+		}
+	}
+
+	if len(simpleSel) > 0 {
+		c.extractSimple(resp, simpleSel)
+	}
+
+	if len(complexSel) > 0 {
+		return c.extractComplex(resp, complexSel)
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// parseSimpleSelector returns nil if the selector is not a simple selector.
+func parseSimpleSelector(selector string) *simpleSelector {
+	m := simpleSelectorRegexp.FindStringSubmatch(strings.TrimSpace(selector))
+	if m == nil || m[0] == "" {
+		return nil
+	}
+
+	sel := &simpleSelector{
+		tag:     strings.ToLower(strings.TrimPrefix(m[1], "*")),
+		id:      strings.TrimPrefix(m[2], "#"),
+		attr:    strings.ToLower(m[4]),
+		value:   m[5],
+		hasAttr: m[4] != "",
+	}
+
+	if m[3] != "" {
+		sel.classes = strings.Split(m[3][1:], ".")
+	}
+
+	return sel
+}
+
+// match returns true if the start tag matches the selector.
+func (s *simpleSelector) match(tag string, attrs []html.Attribute) bool {
+	if s.tag != "" && s.tag != tag {
+		return false
+	}
+
+	if s.id != "" && attrVal(attrs, "id") != s.id {
+		return false
+	}
+
+	if len(s.classes) > 0 {
+		classes := strings.Fields(attrVal(attrs, "class"))
+		for _, c := range s.classes {
+			if !InSlice(c, classes) {
+				return false
+			}
+		}
+	}
+
+	if s.hasAttr {
+		found := false
+		for _, a := range attrs {
+			if a.Key == s.attr {
+				found = s.value == "" || a.Val == s.value
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ------------------------------------------------------------------------
+
+// extractSimple matches the simple selectors by tokenizing the response body.
+// The elements still open at the end of the body are closed implicitly.
+func (c *Collector) extractSimple(resp *Response, selectors map[*simpleSelector][]*extractor) {
+	z := html.NewTokenizer(bytes.NewReader(resp.Body))
+	var (
+		stack    []string
+		captures []*capture
+	)
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			c.flushCaptures(resp, captures, 0)
+			return
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			t := z.Token()
+			var textEx []*extractor
+
+			for sel, list := range selectors {
+				if !sel.match(t.Data, t.Attr) {
+					continue
+				}
+				for _, ex := range list {
+					if ex.attr != "" {
+						if val, ok := attrLookup(t.Attr, ex.attr); ok {
+							c.extract(resp, ex, val)
+						}
+						continue
+					}
+					textEx = append(textEx, ex)
+				}
+			}
+
+			if tt == html.SelfClosingTagToken || voidElements[t.Data] {
+				for _, ex := range textEx {
+					c.extract(resp, ex, "")
+				}
+				continue
+			}
+
+			// Close the elements that are ended implicitly by this start tag
+			for len(stack) > 0 && impliedEndTags[stack[len(stack)-1]][t.Data] {
+				stack = stack[:len(stack)-1]
+				captures = c.flushCaptures(resp, captures, len(stack))
+			}
+
+			stack = append(stack, t.Data)
+			if len(textEx) > 0 {
+				captures = append(captures, &capture{depth: len(stack), ex: textEx, text: &strings.Builder{}})
+			}
+
+		case html.TextToken:
+			if len(captures) == 0 {
+				continue
+			}
+			text := z.Text()
+			for _, cp := range captures {
+				cp.text.Write(text)
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+
+			// Find the matching open element, unclosed elements are closed implicitly
+			i := len(stack) - 1
+			for i >= 0 && stack[i] != string(name) {
+				i--
+			}
+			if i < 0 {
+				continue
+			}
+			stack = stack[:i]
+			captures = c.flushCaptures(resp, captures, len(stack))
+		}
+	}
+}
+
+// flushCaptures calls the callbacks of the captured elements that are not open any more.
+func (c *Collector) flushCaptures(resp *Response, captures []*capture, depth int) []*capture {
+	for len(captures) > 0 && captures[len(captures)-1].depth > depth {
+		cp := captures[len(captures)-1]
+		captures = captures[:len(captures)-1]
+		for _, ex := range cp.ex {
+			c.extract(resp, ex, cp.text.String())
+		}
+	}
+
+	return captures
+}
+
+// extractComplex matches the selectors using a goquery document.
+func (c *Collector) extractComplex(resp *Response, selectors map[string][]*extractor) error {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(resp.Body))
+	if err != nil {
+		return err
+	}
+
+	for selector, list := range selectors {
+		doc.Find(selector).Each(func(_ int, s *goquery.Selection) {
+			for _, ex := range list {
+				if ex.attr == "" {
+					c.extract(resp, ex, s.Text())
+				} else if val, ok := s.Attr(ex.attr); ok {
+					c.extract(resp, ex, val)
+				}
+			}
+		})
+	}
+
+	return nil
+}
+
+// extract calls the callback of the extractor with the trimmed value.
+func (c *Collector) extract(resp *Response, ex *extractor, val string) {
+	c.runCallback(ON_EXTRACT, resp.Request, ex.fn, func() { ex.fn(resp, strings.TrimSpace(val)) })
+}
+
+// ------------------------------------------------------------------------
+
+func attrLookup(attrs []html.Attribute, key string) (string, bool) {
+	for _, a := range attrs {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+
+	return "", false
+}
+
+func attrVal(attrs []html.Attribute, key string) string {
+	val, _ := attrLookup(attrs, key)
+
+	return val
+}
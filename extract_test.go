@@ -0,0 +1,112 @@
+package colly
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_parseSimpleSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		want     *simpleSelector
+	}{
+		{name: "tag", selector: "a", want: &simpleSelector{tag: "a"}},
+		{name: "id", selector: "#title", want: &simpleSelector{id: "title"}},
+		{name: "tag with classes", selector: "div.price.sale", want: &simpleSelector{tag: "div", classes: []string{"price", "sale"}}},
+		{name: "attribute", selector: "a[href]", want: &simpleSelector{tag: "a", attr: "href", hasAttr: true}},
+		{name: "attribute value", selector: `meta[name="description"]`, want: &simpleSelector{tag: "meta", attr: "name", value: "description", hasAttr: true}},
+		{name: "descendant", selector: "div a", want: nil},
+		{name: "pseudo class", selector: "li:first-child", want: nil},
+		{name: "blank", selector: "", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSimpleSelector(tt.selector); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSimpleSelector() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_extractSimple(t *testing.T) {
+	body := `<html><body>
+		<h1 id="title">Hello <b>World</b></h1>
+		<p class="price">10<p class="price">20</p>
+		<a href="/one">One</a><a href="/two">Two</a>
+		<img src="/img.png">
+	</body></html>
+	<div class="footer">Open <span>until the end`
+	tests := []struct {
+		name     string
+		selector string
+		attr     string
+		want     []string
+	}{
+		{name: "nested text", selector: "#title", want: []string{"Hello World"}},
+		{name: "unclosed elements", selector: "p.price", want: []string{"10", "20"}},
+		{name: "attribute", selector: "a", attr: "href", want: []string{"/one", "/two"}},
+		{name: "void element attribute", selector: "img[src]", attr: "src", want: []string{"/img.png"}},
+		{name: "unclosed at the end", selector: "div.footer", want: []string{"Open until the end"}},
+		{name: "nested unclosed at the end", selector: "span", want: []string{"until the end"}},
+	}
+	c := NewCollector(nil, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			ex := &extractor{attr: tt.attr, fn: func(_ *Response, val string) { got = append(got, val) }}
+			c.extractSimple(&Response{Body: []byte(body)}, map[*simpleSelector][]*extractor{
+				parseSimpleSelector(tt.selector): {ex},
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractSimple() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func BenchmarkExtract(b *testing.B) {
+	var body strings.Builder
+	body.WriteString("<html><body>")
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&body, `<div class="item"><a href="/item/%d">Item %d</a><span class="price">%d.99</span></div>`, i, i, i)
+	}
+	body.WriteString("</body></html>")
+	resp := &Response{Body: []byte(body.String())}
+
+	c := NewCollector(nil, nil)
+	ex := &extractor{fn: func(*Response, string) {}}
+	hrefEx := &extractor{attr: "href", fn: func(*Response, string) {}}
+
+	b.Run("tokenizer", func(b *testing.B) {
+		selectors := map[*simpleSelector][]*extractor{
+			parseSimpleSelector("span.price"): {ex},
+			parseSimpleSelector("a[href]"):    {hrefEx},
+		}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.extractSimple(resp, selectors)
+		}
+	})
+
+	b.Run("goquery", func(b *testing.B) {
+		selectors := map[string][]*extractor{
+			"span.price": {ex},
+			"a[href]":    {hrefEx},
+		}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := c.extractComplex(resp, selectors); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package colly
+
+import (
+	"bytes"
+	"colly/storage"
+	"colly/storage/mem"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// FailureEntry is a permanently failed request recorded in the failure journal.
+type FailureEntry struct {
+	URL        string        `json:"url" bson:"url,omitempty"`                 // URL is the requested URL before rewriting.
+	Method     string        `json:"method" bson:"method,omitempty"`           // Method is the HTTP method of the request.
+	Depth      uint16        `json:"depth" bson:"depth,omitempty"`             // Depth is the depth of the request.
+	StatusCode int           `json:"status_code" bson:"status_code,omitempty"` // StatusCode is the response status code, zero if no response was received.
+	Error      string        `json:"error" bson:"error,omitempty"`             // Error is the message of the failure.
+	RequestID  uint32        `json:"request_id" bson:"request_id,omitempty"`   // RequestID is the identifier of the failed request.
+	Failed     time.Time     `json:"failed" bson:"failed,omitempty"`           // Failed is the time of the failure.
+	ConfigHash string        `json:"config_hash" bson:"config_hash,omitempty"` // ConfigHash identifies the configuration of the collector, see Collector.ConfigHash.
+	Attempts   []AttemptInfo `json:"attempts" bson:"attempts,omitempty"`       // Attempts are the outcomes of the attempts of the request.
+}
+
+// FailureFilter selects the journal entries to replay.
+type FailureFilter func(*FailureEntry) bool
+
+// FailureJournal records the permanently failed requests in a queue storage,
+// so they can be replayed after the crawl. The request bodies are not recorded.
+type FailureJournal struct {
+	stg  Queue
+	lock *sync.Mutex
+}
+
+// ------------------------------------------------------------------------
+
+// FAILURE_JOURNAL_ID is the dispatch queue of the failure journal in its storage.
+const FAILURE_JOURNAL_ID uint32 = 0
+
+// ------------------------------------------------------------------------
+
+// NewFailureJournal returns a pointer to a newly created failure journal.
+// If no storage was given, the entries are kept in the memory.
+// Use a dedicated queue storage, other dispatch queues of the storage are not affected.
+func NewFailureJournal(stg Queue) *FailureJournal {
+	if stg == nil {
+		stg = mem.NewFIFOStorage(defJobQueueCapacity)
+	}
+
+	return &FailureJournal{
+		stg:  stg,
+		lock: &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Record appends an entry to the journal.
+func (j *FailureJournal) Record(e *FailureEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	return j.stg.Push(FAILURE_JOURNAL_ID, bytes.NewReader(data))
+}
+
+// Len returns the number of the journal entries.
+func (j *FailureJournal) Len() (uint, error) {
+	return j.stg.Len(FAILURE_JOURNAL_ID)
+}
+
+// Entries returns the journal entries in the order of the failures.
+func (j *FailureJournal) Entries() ([]*FailureEntry, error) {
+	var entries []*FailureEntry
+
+	err := j.take(func(e *FailureEntry) bool {
+		entries = append(entries, e)
+		return false
+	})
+
+	return entries, err
+}
+
+// Clear removes all entries of the journal.
+func (j *FailureJournal) Clear() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	return j.stg.Clear(FAILURE_JOURNAL_ID)
+}
+
+// take removes the entries selected by the function and keeps the others in their original order.
+func (j *FailureJournal) take(selected func(*FailureEntry) bool) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	n, err := j.stg.Len(FAILURE_JOURNAL_ID)
+	if err != nil {
+		return err
+	}
+
+	for i := uint(0); i < n; i++ {
+		rdr, err := j.stg.Pop(FAILURE_JOURNAL_ID)
+		if errors.Is(err, storage.ErrStorageEmpty) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		buf := &bytes.Buffer{}
+		if _, err := buf.ReadFrom(rdr); err != nil {
+			return err
+		}
+
+		e := &FailureEntry{}
+		if err := json.Unmarshal(buf.Bytes(), e); err != nil || selected(e) {
+			continue
+		}
+
+		if err := j.stg.Push(FAILURE_JOURNAL_ID, buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// ReplayFailures removes the journal entries selected by the filter and submits their requests again.
+// The replayed requests are not blocked by the visited state. A nil filter replays all entries.
+// It returns the number of the submitted requests and the first submission error.
+// Requests failing again are recorded again.
+func (c *Collector) ReplayFailures(filter FailureFilter) (int, error) {
+	j := c.Config.FailureJournal
+	if j == nil {
+		return 0, ErrNoFailureJournal
+	}
+
+	var replay []*FailureEntry
+	err := j.take(func(e *FailureEntry) bool {
+		if filter != nil && !filter(e) {
+			return false
+		}
+		replay = append(replay, e)
+		return true
+	})
+
+	if c.HasLogger() {
+		c.logEvent(LOG_INFO_LEVEL, "replay_failures", 0, map[string]string{
+			"entries": strconv.Itoa(len(replay)),
+		})
+	}
+
+	count := 0
+	for _, e := range replay {
+		serr := c.scrape(e.URL, e.Method, int(e.Depth), nil, nil, nil, false)
+		if serr == nil {
+			count++
+		} else if err == nil {
+			err = serr
+		}
+	}
+
+	return count, err
+}
+
+// recordFailure records the failed request in the failure journal.
+func (c *Collector) recordFailure(resp *Response, err error) {
+	j := c.Config.FailureJournal
+	if j == nil || err == nil || resp == nil || resp.Request == nil || resp.Request.Req == nil {
+		return
+	}
+
+	e := &FailureEntry{
+		URL:        resp.Request.OriginalURL().String(),
+		Method:     resp.Request.Req.Method,
+		Depth:      resp.Request.Depth,
+		Error:      err.Error(),
+		RequestID:  resp.Request.ID,
+		Failed:     time.Now(),
+		ConfigHash: c.ConfigHash(),
+		Attempts:   resp.Attempts,
+	}
+	if resp.Resp != nil {
+		e.StatusCode = resp.Resp.StatusCode
+	}
+
+	if err := j.Record(e); err != nil {
+		c.handleOnStorageError(STORAGE_FAILURES, err)
+	}
+}
@@ -0,0 +1,60 @@
+package colly
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestFailureJournal(t *testing.T) {
+	j := NewFailureJournal(nil)
+
+	for _, e := range []*FailureEntry{
+		{URL: "https://example.com/a", Method: "GET", StatusCode: 500, Error: "Internal Server Error"},
+		{URL: "https://example.com/b", Method: "GET", Error: "timeout"},
+		{URL: "https://example.com/c", Method: "POST", StatusCode: 404, Error: "Not Found"},
+	} {
+		if err := j.Record(e); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	var taken []string
+	err := j.take(func(e *FailureEntry) bool {
+		if e.StatusCode >= 500 || e.StatusCode == 0 {
+			taken = append(taken, e.URL)
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		t.Fatalf("take() error = %v", err)
+	}
+	if want := []string{"https://example.com/a", "https://example.com/b"}; !reflect.DeepEqual(taken, want) {
+		t.Errorf("take() = %v, want %v", taken, want)
+	}
+
+	entries, err := j.Entries()
+	if err != nil || len(entries) != 1 || entries[0].URL != "https://example.com/c" {
+		t.Errorf("Entries() = %+v, %v, want the kept entry", entries, err)
+	}
+	if n, _ := j.Len(); n != 1 {
+		t.Errorf("Len() = %d, want 1", n)
+	}
+
+	if err := j.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if n, _ := j.Len(); n != 0 {
+		t.Errorf("Len() after Clear() = %d, want 0", n)
+	}
+}
+
+func TestCollector_ReplayFailures_noJournal(t *testing.T) {
+	c := NewCollector(nil, nil)
+	if _, err := c.ReplayFailures(nil); !errors.Is(err, ErrNoFailureJournal) {
+		t.Errorf("ReplayFailures() error = %v, want %v", err, ErrNoFailureJournal)
+	}
+}
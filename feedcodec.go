@@ -0,0 +1,122 @@
+package colly
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// ------------------------------------------------------------------------
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}           // leading bytes of the gzip streams
+	zipMagic  = []byte{'P', 'K', 0x03, 0x04} // leading bytes of the zip archives
+)
+
+// ------------------------------------------------------------------------
+
+// openFeed returns a reader of the decompressed sitemap or feed. The compression is detected by the content,
+// not by the URL or the headers, as many servers mislabel the compressed files or decompress them on the fly.
+// Gzipped data is decompressed as a stream. Of the zip archives, which are read into the memory,
+// the first file is returned.
+func openFeed(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zipMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, zipMagic):
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range zr.File {
+			if !f.FileInfo().IsDir() {
+				return f.Open()
+			}
+		}
+
+		return nil, ErrEmptyArchive
+	}
+
+	return io.NopCloser(br), nil
+}
+
+// textFeed skips the leading white space and byte order mark of the feed and tells whether
+// the feed is a text sitemap, i.e. a list of URLs, one per line, instead of an XML document.
+func textFeed(br *bufio.Reader) bool {
+	for {
+		r, _, err := br.ReadRune()
+		if err != nil {
+			return false
+		}
+		if !feedTrim(r) {
+			br.UnreadRune()
+			return r != '<'
+		}
+	}
+}
+
+// feedTrim reports whether the character is skipped around the content, i.e. white space and byte order marks.
+func feedTrim(r rune) bool {
+	return r == '\uFEFF' || unicode.IsSpace(r)
+}
+
+// streamTextSitemap calls the function for every URL of a text sitemap.
+// Blank lines and lines starting with # are skipped.
+func streamTextSitemap(r io.Reader, fn SitemapEntryFunc) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimFunc(scanner.Text(), feedTrim)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if err := fn(PollEntry{URL: line}, false); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// ------------------------------------------------------------------------
+
+// maybeGzipped tells whether the response without Content-Encoding may carry a gzip file,
+// judging by its content type or the URL, e.g. application/gzip or sitemap.xml.gz.
+// These bodies are only decompressed if they start with the gzip header.
+func maybeGzipped(resp *http.Response) bool {
+	if resp.Uncompressed || hdrVal(resp.Header, "Content-Encoding") != "" {
+		return false
+	}
+
+	if hasHdrVal(resp.Header, "Content-Type", "gzip") {
+		return true
+	}
+
+	return resp.Request != nil && path.Ext(strings.ToLower(resp.Request.URL.Path)) == ".gz"
+}
+
+// sniffGzip returns a reader decompressing the data if it starts with the gzip header,
+// otherwise a reader of the data as it is.
+func sniffGzip(rdr io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(rdr)
+	if magic, _ := br.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		return gzip.NewReader(br)
+	}
+
+	return io.NopCloser(br), nil
+}
@@ -0,0 +1,72 @@
+package colly
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_decodeReader_gzipFiles(t *testing.T) {
+	const body = "<urlset></urlset>"
+
+	gz := &bytes.Buffer{}
+	w := gzip.NewWriter(gz)
+	w.Write([]byte(body))
+	w.Close()
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		encoding    string
+		data        []byte
+	}{
+		{"gzipped sitemap", "/sitemap.xml.gz", "application/xml", "", gz.Bytes()},
+		{"decompressed by the server", "/sitemap.xml.gz", "application/xml", "", []byte(body)},
+		{"gzipped text sitemap", "/sitemap.txt.gz", "text/plain", "", gz.Bytes()},
+		{"gzip content type", "/export", "application/x-gzip", "", gz.Bytes()},
+		{"content encoding", "/sitemap.xml", "application/xml", "gzip", gz.Bytes()},
+		{"plain sitemap", "/sitemap.xml", "application/xml", "", []byte(body)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				Header:  http.Header{"Content-Type": {tt.contentType}},
+				Request: &http.Request{URL: &url.URL{Path: tt.path}},
+			}
+			if tt.encoding != "" {
+				resp.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			rdr, err := decodeReader(resp, bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("decodeReader() error = %v", err)
+			}
+			defer rdr.Close()
+
+			got, err := io.ReadAll(rdr)
+			if err != nil {
+				t.Fatalf("decodeReader() read error = %v", err)
+			}
+			if string(got) != body {
+				t.Errorf("decodeReader() = %q, want %q", got, body)
+			}
+		})
+	}
+}
+
+func TestParsePollEntries_text(t *testing.T) {
+	entries, sitemaps, err := ParsePollEntries([]byte("https://example.com/a\n\nhttps://example.com/b\n"))
+	if err != nil {
+		t.Fatalf("ParsePollEntries() error = %v", err)
+	}
+	if len(entries) != 2 || entries[1].URL != "https://example.com/b" || len(sitemaps) != 0 {
+		t.Errorf("ParsePollEntries() = %v, %v", entries, sitemaps)
+	}
+}
@@ -29,6 +29,11 @@ type FilterMethod bool
 // FilterScope points out which part of the URL will be matched.
 type FilterScope uint8
 
+// visitEngine is a filter engine limiting the visits of the URLs, see AddRevisit.
+type visitEngine interface {
+	Storage() filters.VisitStorage // Storage returns the storage of the visits.
+}
+
 // filterItem represent an including/excluding URL filter
 type filterItem struct {
 	scope  FilterScope
@@ -48,6 +53,7 @@ const (
 	URL_FILTER
 	DEPTH_FILTER
 	REQUEST_FILTER
+	LANGUAGE_FILTER
 )
 
 // ------------------------------------------------------------------------
@@ -62,6 +68,7 @@ var (
 	ErrFilterNoRevisit        = errors.New("the URL cannot be revisited")                       // ErrFilterNoRevisit is thrown when the number of revisits exhausted.
 	ErrFilterNoRequest        = errors.New("request is missing, nothing to check")              // ErrFilterNoRequest is thrown when the request attribute of the Match function is nil.
 	ErrFilterMaxDepth         = errors.New("maximum request depth limit reached")               // ErrFilterMaxDepth is thrown when the maximum request depth limit reached.
+	ErrFilterLanguage         = errors.New("page language is not allowed")                      // ErrFilterLanguage is thrown when the language of the source page is not a target language.
 )
 
 // ------------------------------------------------------------------------
@@ -155,29 +162,52 @@ func (f *Filter) AddRevisit(maxRevisits uint, storage filters.VisitStorage, labe
 
 // ------------------------------------------------------------------------
 
+// VisitStorages returns the storages of the revisit engines of the filter.
+func (f *Filter) VisitStorages() []filters.VisitStorage {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	stgs := []filters.VisitStorage{}
+	for _, item := range f.excl {
+		if engine, ok := item.engine.(visitEngine); ok {
+			stgs = append(stgs, engine.Storage())
+		}
+	}
+
+	return stgs
+}
+
+// ------------------------------------------------------------------------
+
+// AddLanguage is a convenience method to add page language engine to the filter.
+// Requests found on pages of other known languages will not be followed and the
+// HTML/XML callbacks will be skipped for these pages. Language detection must be enabled.
+func (f *Filter) AddLanguage(languages []string, label ...string) error {
+	return f.AddEngine(FILTER_METHOD_EXCLUDE, LANGUAGE_FILTER, filters.NewLanguageEngine(languages), ErrFilterLanguage, label...)
+}
+
+// ------------------------------------------------------------------------
+
 // Add adds a new filter item to the filter.
 func (f *Filter) AddEngine(method FilterMethod, scope FilterScope, engine FilterEngine, err error, label ...string) error {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
-	key, err := f.setKey(method, label)
-
-	if method == FILTER_METHOD_INCLUDE {
-		f.incl[key] = &filterItem{
-			scope:  scope,
-			engine: engine,
-			err:    err,
-		}
-
-		return err
-	}
+	key, keyErr := f.setKey(method, label)
 
-	f.excl[key] = &filterItem{
+	item := &filterItem{
 		scope:  scope,
 		engine: engine,
+		err:    err,
+	}
+
+	if method == FILTER_METHOD_INCLUDE {
+		f.incl[key] = item
+	} else {
+		f.excl[key] = item
 	}
 
-	return err
+	return keyErr
 }
 
 // ------------------------------------------------------------------------
@@ -252,6 +282,11 @@ func (f *Filter) RemoveByScope(scope FilterScope, method ...FilterMethod) {
 // Excluding filters will be evaluated before including filters.
 // The optional tags will only check filters with matching tag.
 func (f *Filter) Match(req *Request, tags ...string) error {
+	return f.match(req, false, tags...)
+}
+
+// match is Match with the option to skip the filters limiting the visits of the URLs.
+func (f *Filter) match(req *Request, skipVisits bool, tags ...string) error {
 	if req == nil {
 		return ErrFilterNoRequest
 	}
@@ -267,6 +302,9 @@ func (f *Filter) Match(req *Request, tags ...string) error {
 		if checkTag && !InSlice(key, tags) {
 			continue
 		}
+		if _, ok := item.engine.(visitEngine); ok && skipVisits {
+			continue
+		}
 
 		if _, present := segments[item.scope]; !present {
 			segments[item.scope] = item.segment(req)
@@ -303,6 +341,22 @@ func (f *Filter) Match(req *Request, tags ...string) error {
 
 // ------------------------------------------------------------------------
 
+// matchScope returns the error of the first exclusive filter with the given scope that matches the request.
+func (f *Filter) matchScope(req *Request, scope FilterScope) error {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	for _, item := range f.excl {
+		if item.scope == scope && item.engine.Match(item.segment(req)) {
+			return item.err
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
 // Count returns the number of filter items attached to this filter.
 func (f *Filter) Count() int {
 	return len(f.incl) + len(f.excl)
@@ -317,6 +371,26 @@ func (f *Filter) IsEmpty() bool {
 
 // ------------------------------------------------------------------------
 
+// String returns the name of the filter scope.
+func (s FilterScope) String() string {
+	switch s {
+	case DOMAIN_FILTER:
+		return "domain"
+	case URL_FILTER:
+		return "url"
+	case DEPTH_FILTER:
+		return "depth"
+	case REQUEST_FILTER:
+		return "request"
+	case LANGUAGE_FILTER:
+		return "language"
+	}
+
+	return "scope(" + strconv.Itoa(int(s)) + ")"
+}
+
+// ------------------------------------------------------------------------
+
 func (f *Filter) setKey(method FilterMethod, label []string) (string, error) {
 	var (
 		key  string
@@ -358,6 +432,8 @@ func (i *filterItem) segment(req *Request) any {
 		return req.Req.URL.String()
 	case DEPTH_FILTER:
 		return req.Depth
+	case LANGUAGE_FILTER:
+		return req.SourceLanguage()
 	default:
 		return req
 	}
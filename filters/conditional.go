@@ -0,0 +1,130 @@
+package filters
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// ------------------------------------------------------------------------
+
+// ValidatorStorage is a key-value storage of the cache validators, such as the cookie storages.
+type ValidatorStorage interface {
+	Set(key string, data io.Reader) error // Set stores the data by key.
+	Get(key string) (io.Reader, error)    // Get retrieves the data by key.
+}
+
+// Validators are the cache validators of a visited URL, used to make conditional revisits.
+type Validators struct {
+	ETag         string `json:"etag,omitempty" bson:"etag,omitempty"`                   // ETag is the entity tag of the last response.
+	LastModified string `json:"last_modified,omitempty" bson:"last_modified,omitempty"` // LastModified is the Last-Modified header of the last response.
+}
+
+// ValidatorStore keeps the cache validators of the visited URLs.
+type ValidatorStore struct {
+	stg ValidatorStorage
+}
+
+// conditionalVisitFilter represents a filter that lets the URLs with known validators
+// be revisited with conditional requests
+type conditionalVisitFilter struct {
+	revisit    *revisitFilter
+	validators *ValidatorStore
+}
+
+// ------------------------------------------------------------------------
+
+// NewValidatorStore returns a pointer to a newly created validator store.
+func NewValidatorStore(stg ValidatorStorage) (*ValidatorStore, error) {
+	if stg == nil {
+		return nil, ErrFilterNoStorage
+	}
+
+	return &ValidatorStore{
+		stg: stg,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Get returns the validators of the URL. It returns empty validators if the URL is unknown.
+func (s *ValidatorStore) Get(u string) (Validators, error) {
+	v := Validators{}
+
+	rdr, err := s.stg.Get(VisitKey(u))
+	if err != nil || rdr == nil {
+		return v, err
+	}
+
+	data, err := io.ReadAll(rdr)
+	if err != nil || len(data) == 0 {
+		return v, err
+	}
+
+	err = json.Unmarshal(data, &v)
+
+	return v, err
+}
+
+// Set stores the validators of the URL.
+func (s *ValidatorStore) Set(u string, v Validators) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.stg.Set(VisitKey(u), bytes.NewReader(data))
+}
+
+// Storage returns the storage of the validators.
+func (s *ValidatorStore) Storage() ValidatorStorage {
+	return s.stg
+}
+
+// ------------------------------------------------------------------------
+
+// IsEmpty returns true if there is no validator to make a conditional request.
+func (v Validators) IsEmpty() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// ------------------------------------------------------------------------
+
+// NewConditionalVisitEngine returns a pointer to a newly created filter that checks whether or not
+// the URL is eligible for a new visit. The URLs visited more than maxRevisits times are still
+// eligible if their validators are known, so they can be revisited with conditional requests.
+// This filter should be used with FILTER_METHOD_EXCLUDE method.
+func NewConditionalVisitEngine(storage VisitStorage, validators *ValidatorStore, maxRevisits uint) (*conditionalVisitFilter, error) {
+	if validators == nil {
+		return nil, ErrFilterNoStorage
+	}
+
+	revisit, err := NewRevisitEngine(storage, maxRevisits)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conditionalVisitFilter{
+		revisit:    revisit,
+		validators: validators,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Match returns false if the URL can be revisited.
+func (f *conditionalVisitFilter) Match(u any) bool {
+	if !f.revisit.Match(u) {
+		return false
+	}
+
+	str, _ := u.(string)
+	v, err := f.validators.Get(str)
+
+	return err != nil || v.IsEmpty()
+}
+
+// Storage returns the visit storage of the filter.
+func (f *conditionalVisitFilter) Storage() VisitStorage {
+	return f.revisit.stg
+}
@@ -0,0 +1,57 @@
+package filters
+
+import (
+	"colly/storage/mem"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestConditionalVisitEngine(t *testing.T) {
+	const (
+		tagged = "https://example.com/tagged"
+		plain  = "https://example.com/plain"
+	)
+
+	visits := mem.NewVisitStorage()
+	store, err := NewValidatorStore(mem.NewCookieStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []string{tagged, plain} {
+		if err := visits.AddVisit(VisitKey(u)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Set(tagged, Validators{ETag: `"v1"`}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewConditionalVisitEngine(visits, store, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		want bool
+	}{
+		{name: "known validators", url: tagged, want: false},
+		{name: "no validators", url: plain, want: true},
+		{name: "unvisited", url: "https://example.com/new", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.Match(tt.url); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if v, err := store.Get(tagged); err != nil || v.ETag != `"v1"` {
+		t.Errorf("Get() = %+v, %v", v, err)
+	}
+}
@@ -1,5 +1,7 @@
 package filters
 
+import "strconv"
+
 // ------------------------------------------------------------------------
 
 // depthFilter represents a request depth filter
@@ -28,3 +30,8 @@ func (f *depthFilter) Match(d any) bool {
 
 	return uint(depth) > f.limit
 }
+
+// String returns the depth limit of the filter.
+func (f *depthFilter) String() string {
+	return "depth(" + strconv.FormatUint(uint64(f.limit), 10) + ")"
+}
@@ -11,7 +11,8 @@ import (
 
 // globFilter represents a number of glob expression filters
 type globFilter struct {
-	globs []glob.Glob
+	globs    []glob.Glob
+	patterns []string // source patterns of the globs
 }
 
 // ------------------------------------------------------------------------
@@ -37,6 +38,7 @@ func NewGlobEngine(filters []string) (*globFilter, error) {
 		}
 
 		f.globs = append(f.globs, glb)
+		f.patterns = append(f.patterns, fltr)
 	}
 
 	if len(errList) > 0 {
@@ -63,3 +65,8 @@ func (f *globFilter) Match(u any) bool {
 
 	return false
 }
+
+// String returns the glob patterns of the filter.
+func (f *globFilter) String() string {
+	return "glob(" + strings.Join(f.patterns, ", ") + ")"
+}
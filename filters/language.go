@@ -0,0 +1,70 @@
+package filters
+
+import (
+	"sort"
+	"strings"
+)
+
+// ------------------------------------------------------------------------
+
+// languageFilter represents a page language filter
+type languageFilter struct {
+	languages map[string]bool
+}
+
+// ------------------------------------------------------------------------
+
+// NewLanguageEngine returns a pointer to a newly created page language filter.
+// It matches the known languages that are not in the list of target languages,
+// the pages with unknown language are not matched.
+// This filter should be used with FILTER_METHOD_EXCLUDE method.
+func NewLanguageEngine(languages []string) *languageFilter {
+	f := &languageFilter{
+		languages: map[string]bool{},
+	}
+
+	for _, lang := range languages {
+		if lang = PrimaryLanguage(lang); lang != "" {
+			f.languages[lang] = true
+		}
+	}
+
+	return f
+}
+
+// ------------------------------------------------------------------------
+
+// Match reports whether the language is known and it is not a target language.
+func (f *languageFilter) Match(l any) bool {
+	str, ok := l.(string)
+	if !ok {
+		return false
+	}
+
+	lang := PrimaryLanguage(str)
+
+	return lang != "" && !f.languages[lang]
+}
+
+// String returns the target languages of the filter, sorted.
+func (f *languageFilter) String() string {
+	list := make([]string, 0, len(f.languages))
+	for lang := range f.languages {
+		list = append(list, lang)
+	}
+	sort.Strings(list)
+
+	return "language(" + strings.Join(list, ", ") + ")"
+}
+
+// ------------------------------------------------------------------------
+
+// PrimaryLanguage returns the lowercase primary subtag of a language tag, e.g. "en" of "en-GB".
+func PrimaryLanguage(tag string) string {
+	tag = strings.TrimSpace(tag)
+	if i := strings.IndexAny(tag, "-_,;"); i >= 0 {
+		tag = tag[:i]
+	}
+
+	return strings.ToLower(strings.TrimSpace(tag))
+}
@@ -62,3 +62,13 @@ func (f *regexpFilter) Match(u any) bool {
 
 	return false
 }
+
+// String returns the regular expressions of the filter.
+func (f *regexpFilter) String() string {
+	list := make([]string, len(f.re))
+	for i, re := range f.re {
+		list[i] = re.String()
+	}
+
+	return "regexp(" + strings.Join(list, ", ") + ")"
+}
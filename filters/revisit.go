@@ -1,6 +1,11 @@
 package filters
 
-import "errors"
+import (
+	"colly/storage"
+	"errors"
+	"net/url"
+	"strconv"
+)
 
 // ------------------------------------------------------------------------
 
@@ -48,7 +53,32 @@ func (f *revisitFilter) Match(u any) bool {
 		return false
 	}
 
-	visited, err := f.stg.PastVisits(str)
+	visited, err := f.stg.PastVisits(VisitKey(str))
 
-	return err == nil || visited > f.maxRevisits
+	return err != nil || visited > f.maxRevisits
+}
+
+// ------------------------------------------------------------------------
+
+// String returns the revisit limit of the filter.
+func (f *revisitFilter) String() string {
+	return "revisit(" + strconv.FormatUint(uint64(f.maxRevisits), 10) + ")"
+}
+
+// Storage returns the visit storage of the filter.
+func (f *revisitFilter) Storage() VisitStorage {
+	return f.stg
+}
+
+// ------------------------------------------------------------------------
+
+// VisitKey returns the visit storage key of the URL, scoped by its host name
+// so the visits of a domain can be counted and purged at once.
+func VisitKey(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+
+	return storage.DomainKey(parsed.Hostname(), u)
 }
@@ -0,0 +1,130 @@
+package filters
+
+import (
+	"colly/storage"
+	"net/url"
+)
+
+// ------------------------------------------------------------------------
+
+// VisitScope tells whose visits are counted by a shared visit filter.
+type VisitScope uint8
+
+// VisitRegistry is a visit storage shared by a number of collectors, e.g. the listing and
+// the detail collectors of a pipeline. The visits are tagged by the role of the collector.
+type VisitRegistry struct {
+	stg VisitStorage
+}
+
+// sharedVisitFilter represents a filter that checks the visits of a shared visit registry
+type sharedVisitFilter struct {
+	registry    *VisitRegistry
+	role        string
+	scope       VisitScope
+	maxRevisits uint
+}
+
+// ------------------------------------------------------------------------
+
+const (
+	VISIT_SCOPE_ANY VisitScope = iota // Skip the URLs visited by any collector.
+	VISIT_SCOPE_OWN                   // Skip the URLs visited by the collectors of the same role.
+)
+
+// VISIT_ROLE_SEPARATOR separates the role from the URL in the role-tagged visit keys.
+const VISIT_ROLE_SEPARATOR = "@"
+
+// ------------------------------------------------------------------------
+
+// NewVisitRegistry returns a pointer to a newly created shared visit registry.
+func NewVisitRegistry(stg VisitStorage) (*VisitRegistry, error) {
+	if stg == nil {
+		return nil, ErrFilterNoStorage
+	}
+
+	return &VisitRegistry{
+		stg: stg,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// AddVisit records a visit of the URL by a collector of the role.
+// The visit is counted both for the role and for all collectors.
+func (r *VisitRegistry) AddVisit(role string, u string) error {
+	if err := r.stg.AddVisit(VisitKey(u)); err != nil {
+		return err
+	}
+
+	if role == "" {
+		return nil
+	}
+
+	return r.stg.AddVisit(RoleVisitKey(role, u))
+}
+
+// PastVisits returns how many times the URL was visited by the collectors of the role
+// or by any collector.
+func (r *VisitRegistry) PastVisits(role string, u string, scope VisitScope) (uint, error) {
+	if scope == VISIT_SCOPE_OWN {
+		return r.stg.PastVisits(RoleVisitKey(role, u))
+	}
+
+	return r.stg.PastVisits(VisitKey(u))
+}
+
+// Storage returns the visit storage of the registry.
+func (r *VisitRegistry) Storage() VisitStorage {
+	return r.stg
+}
+
+// ------------------------------------------------------------------------
+
+// RoleVisitKey returns the visit storage key of the URL visited by a collector of the role.
+// It is scoped by the host name like VisitKey, so the domain purges remove the tagged visits too.
+func RoleVisitKey(role string, u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return role + VISIT_ROLE_SEPARATOR + u
+	}
+
+	return storage.DomainKey(parsed.Hostname(), role+VISIT_ROLE_SEPARATOR+u)
+}
+
+// ------------------------------------------------------------------------
+
+// NewSharedVisitEngine returns a pointer to a newly created filter that checks whether or not
+// the URL is eligible for a new visit, counting the visits of any collector or only the visits
+// of the collectors with the same role, depending on the scope.
+// This filter should be used with FILTER_METHOD_EXCLUDE method.
+func NewSharedVisitEngine(registry *VisitRegistry, role string, scope VisitScope, maxRevisits uint) (*sharedVisitFilter, error) {
+	if registry == nil {
+		return nil, ErrFilterNoStorage
+	}
+
+	return &sharedVisitFilter{
+		registry:    registry,
+		role:        role,
+		scope:       scope,
+		maxRevisits: maxRevisits,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Match returns false if the URL can be revisited.
+func (f *sharedVisitFilter) Match(u any) bool {
+	str, ok := u.(string)
+	if !ok {
+		return false
+	}
+
+	visited, err := f.registry.PastVisits(f.role, str, f.scope)
+
+	return err != nil || visited > f.maxRevisits
+}
+
+// Storage returns the visit storage of the shared registry.
+func (f *sharedVisitFilter) Storage() VisitStorage {
+	return f.registry.stg
+}
@@ -0,0 +1,46 @@
+package filters
+
+import (
+	"colly/storage/mem"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestSharedVisitEngine(t *testing.T) {
+	const u = "https://example.com/item/1"
+
+	registry, err := NewVisitRegistry(mem.NewVisitStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.AddVisit("listing", u); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		role  string
+		scope VisitScope
+		want  bool
+	}{
+		{name: "any collector", role: "detail", scope: VISIT_SCOPE_ANY, want: true},
+		{name: "other role", role: "detail", scope: VISIT_SCOPE_OWN, want: false},
+		{name: "same role", role: "listing", scope: VISIT_SCOPE_OWN, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewSharedVisitEngine(registry, tt.role, tt.scope, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.Match(u); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+			if f.Match("https://example.com/item/2") {
+				t.Errorf("Match() of an unvisited URL = true, want false")
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package filters
 
+import "strconv"
+
 // ------------------------------------------------------------------------
 
 // urlLengthFilter represents an URL length filter
@@ -32,3 +34,8 @@ func (f *urlLengthFilter) Match(u any) bool {
 
 	return len < int(f.min) || len > int(f.max)
 }
+
+// String returns the length limits of the filter.
+func (f *urlLengthFilter) String() string {
+	return "length(" + strconv.FormatUint(uint64(f.min), 10) + ", " + strconv.FormatUint(uint64(f.max), 10) + ")"
+}
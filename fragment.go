@@ -0,0 +1,125 @@
+package colly
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ------------------------------------------------------------------------
+
+// FragmentMode tells how the URL fragments are handled by the URL parser.
+type FragmentMode uint8
+
+// fragmentParser is an URL parser that handles the fragments of the parsed URLs.
+type fragmentParser struct {
+	parser Parser
+	mode   FragmentMode
+}
+
+// ------------------------------------------------------------------------
+
+const (
+	FRAGMENT_DEFAULT  FragmentMode = iota // The same-page "#" links are ignored, the other fragments are kept as parsed.
+	FRAGMENT_STRIP                        // The fragments are removed, so the URLs differing only in fragments are visited once.
+	FRAGMENT_KEEP                         // The fragments are kept and the "#" links are visited as distinct URLs.
+	FRAGMENT_HASHBANG                     // The "#!" routes are translated to the _escaped_fragment_ query parameter, the other fragments are removed.
+)
+
+// HASHBANG_PARAM is the query parameter of the translated hash-bang routes.
+const HASHBANG_PARAM = "_escaped_fragment_"
+
+// ------------------------------------------------------------------------
+
+// NewFragmentParser returns an URL parser that handles the fragments of the URLs
+// parsed by the underlying parser according to the mode.
+func NewFragmentParser(parser Parser, mode FragmentMode) Parser {
+	if parser == nil {
+		parser = NewWHATWGParser()
+	}
+
+	return &fragmentParser{
+		parser: parser,
+		mode:   mode,
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Parse parses a raw url into a URL structure with the fragment handled.
+func (p *fragmentParser) Parse(rawURL string) (*url.URL, error) {
+	u, err := p.parser.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.mode.apply(u), nil
+}
+
+// ParseRef parses a raw url with a reference into a URL structure with the fragment handled.
+func (p *fragmentParser) ParseRef(rawURL string, ref string) (*url.URL, error) {
+	u, err := p.parser.ParseRef(rawURL, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.mode.apply(u), nil
+}
+
+// ------------------------------------------------------------------------
+
+// apply returns the URL with the fragment handled according to the mode.
+func (m FragmentMode) apply(u *url.URL) *url.URL {
+	if u == nil || (u.Fragment == "" && u.RawFragment == "") {
+		return u
+	}
+
+	switch m {
+	case FRAGMENT_STRIP:
+		v := *u
+		v.Fragment, v.RawFragment = "", ""
+		return &v
+
+	case FRAGMENT_HASHBANG:
+		v := *u
+		if strings.HasPrefix(u.Fragment, "!") {
+			param := HASHBANG_PARAM + "=" + url.QueryEscape(u.Fragment[1:])
+			if v.RawQuery == "" {
+				v.RawQuery = param
+			} else {
+				v.RawQuery += "&" + param
+			}
+		}
+		v.Fragment, v.RawFragment = "", ""
+		return &v
+	}
+
+	return u
+}
+
+// follows returns true if the same-page link is followed in the mode.
+func (m FragmentMode) follows(link string) bool {
+	switch m {
+	case FRAGMENT_KEEP:
+		return true
+	case FRAGMENT_HASHBANG:
+		return strings.HasPrefix(link, "#!")
+	}
+
+	return false
+}
+
+// ------------------------------------------------------------------------
+
+// setFragmentParser wraps the URL parser of the configuration with the fragment handler,
+// so the fragments are handled before filtering, visiting and caching.
+func (c *Collector) setFragmentParser() {
+	if c.Config.FragmentMode == FRAGMENT_DEFAULT {
+		return
+	}
+
+	if _, ok := c.Config.Parser.(*fragmentParser); ok {
+		return
+	}
+
+	c.Config.Parser = NewFragmentParser(c.Config.Parser, c.Config.FragmentMode)
+}
@@ -0,0 +1,56 @@
+package colly
+
+import (
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestFragmentParser(t *testing.T) {
+	const base = "https://example.com/app?lang=en"
+
+	tests := []struct {
+		name string
+		mode FragmentMode
+		ref  string
+		want string
+	}{
+		{"default keeps", FRAGMENT_DEFAULT, "/page#top", "https://example.com/page#top"},
+		{"strip", FRAGMENT_STRIP, "/page#top", "https://example.com/page"},
+		{"keep", FRAGMENT_KEEP, "#!/items/1", "https://example.com/app?lang=en#!/items/1"},
+		{"hashbang route", FRAGMENT_HASHBANG, "#!/items/1", "https://example.com/app?lang=en&_escaped_fragment_=%2Fitems%2F1"},
+		{"hashbang no query", FRAGMENT_HASHBANG, "/list#!/a", "https://example.com/list?_escaped_fragment_=%2Fa"},
+		{"hashbang plain fragment", FRAGMENT_HASHBANG, "/page#top", "https://example.com/page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewFragmentParser(NewSimpleParser(), tt.mode)
+			u, err := p.ParseRef(base, tt.ref)
+			if err != nil {
+				t.Fatalf("ParseRef() error = %v", err)
+			}
+			if got := u.String(); got != tt.want {
+				t.Errorf("ParseRef() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFragmentMode_follows(t *testing.T) {
+	tests := []struct {
+		mode FragmentMode
+		link string
+		want bool
+	}{
+		{FRAGMENT_DEFAULT, "#top", false},
+		{FRAGMENT_STRIP, "#!/a", false},
+		{FRAGMENT_KEEP, "#top", true},
+		{FRAGMENT_HASHBANG, "#!/a", true},
+		{FRAGMENT_HASHBANG, "#top", false},
+	}
+	for _, tt := range tests {
+		if got := tt.mode.follows(tt.link); got != tt.want {
+			t.Errorf("%d.follows(%q) = %v, want %v", tt.mode, tt.link, got, tt.want)
+		}
+	}
+}
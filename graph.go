@@ -0,0 +1,306 @@
+package colly
+
+import (
+	"bufio"
+	"colly/storage"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// BrokenLink is a link of the crawl graph pointing to a failed URL.
+type BrokenLink struct {
+	Edge       *storage.GraphEdge `json:"edge" bson:"edge"`                         // Edge is the link of the page.
+	StatusCode int                `json:"status_code" bson:"status_code,omitempty"` // StatusCode is the response status code of the link target, zero if no response was received.
+	Error      string             `json:"error" bson:"error,omitempty"`             // Error is the failure message of the link target.
+}
+
+// ndjsonGraph writes the crawl graph as newline delimited JSON records.
+type ndjsonGraph struct {
+	enc  *json.Encoder
+	lock *sync.Mutex
+}
+
+// graphRecord is a line of the NDJSON graph, either an edge or a node.
+type graphRecord struct {
+	Edge *storage.GraphEdge `json:"edge,omitempty"`
+	Node *storage.GraphNode `json:"node,omitempty"`
+}
+
+// ------------------------------------------------------------------------
+
+// GRAPH_LINK_SELECTOR selects the links recorded by the graph module.
+const GRAPH_LINK_SELECTOR = "a[href], area[href]"
+
+// ------------------------------------------------------------------------
+
+// NewGraphModule returns a module that records the link graph of the crawl in the graph storage.
+// Every HTTP(S) link of the parsed HTML pages is recorded as an edge from the page to the absolute
+// link target with its anchor text, whether the target is visited or not. The outcome of every request
+// is recorded as a node, so the storage can report the broken links, see BrokenLinks.
+// The URLs are recorded before rewriting. The storage is not closed by the collector.
+func NewGraphModule(stg storage.GraphStorage) Module {
+	return ModuleFunc(func(c *Collector) error {
+		if stg == nil {
+			return ErrNoGraphStorage
+		}
+
+		c.OnHTML(GRAPH_LINK_SELECTOR, func(e *HTMLElement) {
+			if edge := newGraphEdge(e); edge != nil {
+				if err := stg.AddEdge(edge); err != nil {
+					c.Config.logError(LOG_WARN_LEVEL, err)
+				}
+			}
+		})
+
+		c.OnResponse(func(resp *Response) {
+			if node := newGraphNode(resp, nil); node != nil {
+				if err := stg.AddNode(node); err != nil {
+					c.Config.logError(LOG_WARN_LEVEL, err)
+				}
+			}
+		})
+
+		c.OnError(func(resp *Response, err error) {
+			if node := newGraphNode(resp, err); node != nil {
+				if err := stg.AddNode(node); err != nil {
+					c.Config.logError(LOG_WARN_LEVEL, err)
+				}
+			}
+		})
+
+		return nil
+	})
+}
+
+// ------------------------------------------------------------------------
+
+// NewNDJSONGraphStorage returns a graph storage writing the edges and the nodes as newline delimited JSON
+// records, e.g. {"edge":{"from":"...","to":"...","depth":1,"anchor":"..."}} and {"node":{"url":"...","status_code":404}}.
+// The storage is write-only, the writer is not closed by the storage.
+func NewNDJSONGraphStorage(w io.Writer) storage.GraphStorage {
+	return &ndjsonGraph{
+		enc:  json.NewEncoder(w),
+		lock: &sync.Mutex{},
+	}
+}
+
+// Close implements the storage.BaseStorage interface, the writer is left open.
+func (g *ndjsonGraph) Close() error {
+	return nil
+}
+
+// Clear implements the storage.BaseStorage interface, the written records can't be removed.
+func (g *ndjsonGraph) Clear() error {
+	return storage.ErrNotImplemented
+}
+
+// AddEdge writes an edge record.
+func (g *ndjsonGraph) AddEdge(edge *storage.GraphEdge) error {
+	return g.write(&graphRecord{Edge: edge})
+}
+
+// AddNode writes a node record.
+func (g *ndjsonGraph) AddNode(node *storage.GraphNode) error {
+	return g.write(&graphRecord{Node: node})
+}
+
+// write encodes a record on a separate line.
+func (g *ndjsonGraph) write(rec *graphRecord) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.enc.Encode(rec)
+}
+
+// ------------------------------------------------------------------------
+
+// BrokenLinks returns the links of the graph pointing to the URLs that failed or
+// responded with an error status code, in the recording order of the links.
+// The links of the URLs that were not requested are not reported.
+func BrokenLinks(g storage.GraphReader) ([]*BrokenLink, error) {
+	failed, edges, err := readGraph(g)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []*BrokenLink
+	for _, e := range edges {
+		if n, present := failed[e.To]; present {
+			links = append(links, &BrokenLink{Edge: e, StatusCode: n.StatusCode, Error: n.Error})
+		}
+	}
+
+	return links, nil
+}
+
+// WriteGraphDOT writes the graph in the Graphviz DOT format. The repeated links of a page
+// are drawn once, labelled with the first anchor text, and the failed URLs are drawn in red.
+func WriteGraphDOT(w io.Writer, g storage.GraphReader) error {
+	failed, edges, err := readGraph(g)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph crawl {")
+
+	for _, n := range sortedFailures(failed) {
+		fmt.Fprintf(bw, "\t%s [color=red, tooltip=%s];\n", dotQuote(n.URL), dotQuote(graphNodeStatus(n)))
+	}
+
+	type link struct{ from, to string }
+	drawn := map[link]bool{}
+	for _, e := range edges {
+		if drawn[link{e.From, e.To}] {
+			continue
+		}
+		drawn[link{e.From, e.To}] = true
+
+		if e.Anchor == "" {
+			fmt.Fprintf(bw, "\t%s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+		} else {
+			fmt.Fprintf(bw, "\t%s -> %s [label=%s];\n", dotQuote(e.From), dotQuote(e.To), dotQuote(e.Anchor))
+		}
+	}
+
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
+
+// ------------------------------------------------------------------------
+
+// newGraphEdge returns the edge of a link element, or nil if the link doesn't point to an HTTP(S) URL.
+func newGraphEdge(e *HTMLElement) *storage.GraphEdge {
+	if e.Response == nil || e.Response.Request == nil {
+		return nil
+	}
+	req := e.Response.Request
+
+	to := req.AbsoluteURL(e.Attr("href"))
+	if to == "" {
+		return nil
+	}
+	if u, err := url.Parse(to); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil
+	}
+
+	from := req.OriginalURL()
+	if from == nil {
+		return nil
+	}
+
+	return &storage.GraphEdge{
+		From:   from.String(),
+		To:     to,
+		Depth:  req.Depth + 1,
+		Anchor: anchorText(e),
+	}
+}
+
+// newGraphNode returns the node of a response, or nil for the responses of the nested documents.
+func newGraphNode(resp *Response, err error) *storage.GraphNode {
+	if resp == nil || resp.Request == nil || resp.IsNested() {
+		return nil
+	}
+
+	u := resp.Request.OriginalURL()
+	if u == nil {
+		return nil
+	}
+
+	node := &storage.GraphNode{
+		URL:   u.String(),
+		Depth: resp.Request.Depth,
+	}
+	if resp.Resp != nil {
+		node.StatusCode = resp.Resp.StatusCode
+	}
+	if err != nil {
+		node.Error = err.Error()
+	}
+
+	return node
+}
+
+// anchorText returns the whitespace normalized text of a link,
+// or the alternative text of its image, or its title.
+func anchorText(e *HTMLElement) string {
+	text := strings.Join(strings.Fields(e.Text), " ")
+	if text == "" {
+		text = strings.Join(strings.Fields(e.ChildAttr("img[alt]", "alt")), " ")
+	}
+	if text == "" {
+		text = strings.Join(strings.Fields(e.Attr("title")), " ")
+	}
+	if text == "" {
+		text = strings.Join(strings.Fields(e.Attr("alt")), " ")
+	}
+
+	return text
+}
+
+// ------------------------------------------------------------------------
+
+// readGraph returns the failed nodes by URL and the edges of the graph.
+func readGraph(g storage.GraphReader) (map[string]*storage.GraphNode, []*storage.GraphEdge, error) {
+	if g == nil {
+		return nil, nil, ErrNoGraphStorage
+	}
+
+	nodes, err := g.Nodes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	failed := map[string]*storage.GraphNode{}
+	for _, n := range nodes {
+		if n.Error != "" || n.StatusCode >= 400 {
+			failed[n.URL] = n
+		}
+	}
+
+	edges, err := g.Edges()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return failed, edges, nil
+}
+
+// sortedFailures returns the failed nodes sorted by their URL.
+func sortedFailures(failed map[string]*storage.GraphNode) []*storage.GraphNode {
+	nodes := make([]*storage.GraphNode, 0, len(failed))
+	for _, n := range failed {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].URL < nodes[j].URL })
+
+	return nodes
+}
+
+// graphNodeStatus returns the status code or the error of a failed node.
+func graphNodeStatus(n *storage.GraphNode) string {
+	if n.Error != "" {
+		return n.Error
+	}
+
+	return strconv.Itoa(n.StatusCode)
+}
+
+// dotQuote returns the string as a quoted DOT identifier.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.NewReplacer("\r", "", "\n", " ").Replace(s)
+
+	return `"` + s + `"`
+}
@@ -0,0 +1,158 @@
+package colly
+
+import (
+	"bytes"
+	"colly/storage"
+	"colly/storage/mem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_newGraphEdge(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body>
+		<a href="/a">  Page
+			A </a>
+		<a href="b.html"><img src="b.png" alt="Logo B"></a>
+		<a href="https://other.com/" title="Other"></a>
+		<a href="mailto:info@example.com">Mail</a>
+		<a href="#top">Top</a>
+		<map><area href="/c" alt="Area C"></map>
+	</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := &Response{Request: &Request{
+		Depth:  1,
+		Req:    httptest.NewRequest("GET", "https://example.com/dir/", nil),
+		Parser: NewWHATWGParser(),
+	}}
+
+	var got []*storage.GraphEdge
+	doc.Find(GRAPH_LINK_SELECTOR).Each(func(i int, s *goquery.Selection) {
+		if edge := newGraphEdge(NewHTMLElementFromSelectionNode(resp, s, s.Nodes[0], i)); edge != nil {
+			got = append(got, edge)
+		}
+	})
+
+	want := []*storage.GraphEdge{
+		{From: "https://example.com/dir/", To: "https://example.com/a", Depth: 2, Anchor: "Page A"},
+		{From: "https://example.com/dir/", To: "https://example.com/dir/b.html", Depth: 2, Anchor: "Logo B"},
+		{From: "https://example.com/dir/", To: "https://other.com/", Depth: 2, Anchor: "Other"},
+		{From: "https://example.com/dir/", To: "https://example.com/c", Depth: 2, Anchor: "Area C"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("newGraphEdge() = %v, want %v", got, want)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_newGraphNode(t *testing.T) {
+	req := &Request{Depth: 3, Req: httptest.NewRequest("GET", "https://example.com/x", nil)}
+	resp := &Response{Request: req, Resp: &http.Response{StatusCode: 404}}
+
+	want := &storage.GraphNode{URL: "https://example.com/x", Depth: 3, StatusCode: 404, Error: "Not Found"}
+	if got := newGraphNode(resp, errors.New("Not Found")); !reflect.DeepEqual(got, want) {
+		t.Errorf("newGraphNode() = %+v, want %+v", got, want)
+	}
+
+	if got := newGraphNode(&Response{Request: req, nested: 1}, nil); got != nil {
+		t.Errorf("newGraphNode() of a nested document = %+v, want nil", got)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestBrokenLinks(t *testing.T) {
+	g := newTestGraph()
+
+	got, err := BrokenLinks(g)
+	if err != nil {
+		t.Fatalf("BrokenLinks() error = %v", err)
+	}
+
+	want := []*BrokenLink{
+		{Edge: &storage.GraphEdge{From: "https://example.com/", To: "https://example.com/gone", Depth: 1, Anchor: "Gone"}, StatusCode: 404},
+		{Edge: &storage.GraphEdge{From: "https://example.com/ok", To: "https://example.com/gone", Depth: 2}, StatusCode: 404},
+		{Edge: &storage.GraphEdge{From: "https://example.com/", To: "https://down.com/", Depth: 1, Anchor: `Say "hi"`}, Error: "connection refused"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BrokenLinks() = %v, want %v", got, want)
+	}
+
+	if _, err := BrokenLinks(nil); !errors.Is(err, ErrNoGraphStorage) {
+		t.Errorf("BrokenLinks(nil) error = %v, want %v", err, ErrNoGraphStorage)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestWriteGraphDOT(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := WriteGraphDOT(buf, newTestGraph()); err != nil {
+		t.Fatalf("WriteGraphDOT() error = %v", err)
+	}
+
+	want := `digraph crawl {
+	"https://down.com/" [color=red, tooltip="connection refused"];
+	"https://example.com/gone" [color=red, tooltip="404"];
+	"https://example.com/" -> "https://example.com/ok" [label="OK"];
+	"https://example.com/" -> "https://example.com/gone" [label="Gone"];
+	"https://example.com/ok" -> "https://example.com/gone";
+	"https://example.com/" -> "https://down.com/" [label="Say \"hi\""];
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteGraphDOT() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestNewNDJSONGraphStorage(t *testing.T) {
+	buf := &bytes.Buffer{}
+	g := NewNDJSONGraphStorage(buf)
+
+	g.AddEdge(&storage.GraphEdge{From: "https://example.com/", To: "https://example.com/a", Depth: 1, Anchor: "A"})
+	g.AddNode(&storage.GraphNode{URL: "https://example.com/a", Depth: 1, StatusCode: 200})
+
+	want := `{"edge":{"from":"https://example.com/","to":"https://example.com/a","depth":1,"anchor":"A"}}
+{"node":{"url":"https://example.com/a","depth":1,"status_code":200,"error":""}}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("NDJSON graph =\n%s\nwant\n%s", got, want)
+	}
+
+	if err := g.Clear(); !errors.Is(err, storage.ErrNotImplemented) {
+		t.Errorf("Clear() error = %v, want %v", err, storage.ErrNotImplemented)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// newTestGraph returns a graph with a page linking to a working, a missing and an unreachable URL.
+func newTestGraph() storage.GraphReader {
+	g := mem.NewGraphStorage()
+
+	g.AddEdge(&storage.GraphEdge{From: "https://example.com/", To: "https://example.com/ok", Depth: 1, Anchor: "OK"})
+	g.AddEdge(&storage.GraphEdge{From: "https://example.com/", To: "https://example.com/gone", Depth: 1, Anchor: "Gone"})
+	g.AddEdge(&storage.GraphEdge{From: "https://example.com/", To: "https://example.com/ok", Depth: 1, Anchor: "OK again"})
+	g.AddEdge(&storage.GraphEdge{From: "https://example.com/ok", To: "https://example.com/gone", Depth: 2})
+	g.AddEdge(&storage.GraphEdge{From: "https://example.com/", To: "https://down.com/", Depth: 1, Anchor: `Say "hi"`})
+
+	g.AddNode(&storage.GraphNode{URL: "https://example.com/", StatusCode: 200})
+	g.AddNode(&storage.GraphNode{URL: "https://example.com/ok", Depth: 1, StatusCode: 200})
+	g.AddNode(&storage.GraphNode{URL: "https://example.com/gone", Depth: 1, StatusCode: 404})
+	g.AddNode(&storage.GraphNode{URL: "https://down.com/", Depth: 1, Error: "connection refused"})
+
+	return g
+}
@@ -0,0 +1,162 @@
+package colly
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// ------------------------------------------------------------------------
+
+// requestGroups keeps count of the pending requests of the request groups.
+type requestGroups struct {
+	groups map[string]*requestGroup
+	lock   *sync.Mutex
+}
+
+// requestGroup is a request and all requests transitively spawned from it.
+type requestGroup struct {
+	pending int           // number of the submitted and not yet finished requests
+	done    chan struct{} // closed when no request is pending
+}
+
+// groupToken is a pending request of a group.
+// It is carried by the context of the submitted request and finished only once.
+type groupToken struct {
+	id       string
+	finished atomic.Bool
+}
+
+// Context keys of the request groups
+type (
+	groupIDKey    struct{}
+	groupTokenKey struct{}
+)
+
+// ------------------------------------------------------------------------
+
+// newRequestGroups returns a pointer to a newly created set of request groups.
+func newRequestGroups() *requestGroups {
+	return &requestGroups{
+		groups: map[string]*requestGroup{},
+		lock:   &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// SetGroup tags the request as the root of a request group. The requests created by Visit,
+// Post, PostRaw and PostMultipart of the request and of its descendants join the same group.
+// Tag the request before submitting it, so the request itself is part of the group.
+func (r *Request) SetGroup(id string) {
+	parent := context.Background()
+	if r.Ctx != nil {
+		parent = *r.Ctx
+	}
+
+	ctx := context.WithValue(parent, groupIDKey{}, id)
+	r.Ctx = &ctx
+}
+
+// Group returns the identifier of the request group, or an empty string if the request has no group.
+func (r *Request) Group() string {
+	if r.Ctx == nil {
+		return ""
+	}
+
+	id, _ := (*r.Ctx).Value(groupIDKey{}).(string)
+
+	return id
+}
+
+// ------------------------------------------------------------------------
+
+// GroupWait blocks until all requests of the group have finished.
+// It returns immediately if the group has no pending request.
+func (c *Collector) GroupWait(id string) {
+	c.groups.lock.Lock()
+	g, ok := c.groups.groups[id]
+	c.groups.lock.Unlock()
+
+	if ok {
+		<-g.done
+	}
+}
+
+// GroupPending returns the number of the pending requests of the group.
+func (c *Collector) GroupPending(id string) int {
+	c.groups.lock.Lock()
+	defer c.groups.lock.Unlock()
+
+	if g, ok := c.groups.groups[id]; ok {
+		return g.pending
+	}
+
+	return 0
+}
+
+// ------------------------------------------------------------------------
+
+// groupSpawn registers a new pending request of the group of the parent request, if any.
+// It returns the context of the new request carrying its token, and a function
+// finishing the token if the request could not be submitted.
+func (c *Collector) groupSpawn(parent *Request) (*context.Context, func()) {
+	id := parent.Group()
+	if id == "" {
+		return parent.Ctx, func() {}
+	}
+
+	tok := &groupToken{id: id}
+	c.groups.add(id)
+
+	ctx := context.WithValue(*parent.Ctx, groupTokenKey{}, tok)
+
+	return &ctx, func() { c.groups.finish(tok) }
+}
+
+// groupFinish finishes the pending request of the group, if any.
+func (c *Collector) groupFinish(r *Request) {
+	if r == nil || r.Ctx == nil {
+		return
+	}
+
+	if tok, ok := (*r.Ctx).Value(groupTokenKey{}).(*groupToken); ok {
+		c.groups.finish(tok)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// add increments the pending requests of the group, creating the group if needed.
+func (gs *requestGroups) add(id string) {
+	gs.lock.Lock()
+	defer gs.lock.Unlock()
+
+	g, ok := gs.groups[id]
+	if !ok {
+		g = &requestGroup{done: make(chan struct{})}
+		gs.groups[id] = g
+	}
+	g.pending++
+}
+
+// finish decrements the pending requests of the group of the token.
+// The group is removed and its waiters are released with the last request.
+func (gs *requestGroups) finish(tok *groupToken) {
+	if !tok.finished.CompareAndSwap(false, true) {
+		return
+	}
+
+	gs.lock.Lock()
+	defer gs.lock.Unlock()
+
+	g, ok := gs.groups[tok.id]
+	if !ok {
+		return
+	}
+
+	if g.pending--; g.pending == 0 {
+		close(g.done)
+		delete(gs.groups, tok.id)
+	}
+}
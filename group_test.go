@@ -0,0 +1,70 @@
+package colly
+
+import (
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollector_GroupWait(t *testing.T) {
+	c := NewCollector(nil, nil)
+
+	root := &Request{collector: c}
+	root.SetGroup("product")
+
+	// The root request and its two variants
+	rootCtx, _ := c.groupSpawn(root)
+	parent := &Request{Ctx: rootCtx, collector: c}
+	ctx1, cancel1 := c.groupSpawn(parent)
+	ctx2, _ := c.groupSpawn(parent)
+
+	if got := (&Request{Ctx: ctx2}).Group(); got != "product" {
+		t.Errorf("Group() = %q, want %q", got, "product")
+	}
+	if got := c.GroupPending("product"); got != 3 {
+		t.Fatalf("GroupPending() = %d, want 3", got)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.GroupWait("product")
+		close(done)
+	}()
+
+	cancel1()
+	cancel1()
+	c.groupFinish(&Request{Ctx: ctx1})
+	c.groupFinish(parent)
+	if got := c.GroupPending("product"); got != 1 {
+		t.Errorf("GroupPending() = %d, want 1", got)
+	}
+
+	select {
+	case <-done:
+		t.Fatal("GroupWait() returned with a pending request")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	c.groupFinish(&Request{Ctx: ctx2})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("GroupWait() did not return after the last request")
+	}
+
+	// Unknown and completed groups don't block
+	c.GroupWait("product")
+	c.GroupWait("unknown")
+}
+
+func TestCollector_groupSpawn_noGroup(t *testing.T) {
+	c := NewCollector(nil, nil)
+	r := &Request{collector: c}
+
+	ctx, cancel := c.groupSpawn(r)
+	cancel()
+	if ctx != r.Ctx || r.Group() != "" {
+		t.Errorf("groupSpawn() changed the context of an ungrouped request")
+	}
+}
@@ -0,0 +1,118 @@
+package colly
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+)
+
+// ------------------------------------------------------------------------
+
+// HandlerInfo describes a registered callback function.
+type HandlerInfo struct {
+	Event    string `json:"event" bson:"event"`                 // Event is the name of the event, e.g. "html".
+	Arg      string `json:"arg" bson:"arg,omitempty"`           // Arg is the selector or the argument of the callback, blank for the events without argument.
+	Position int    `json:"position" bson:"position"`           // Position is the position of the callback, the callbacks of an argument run in position order.
+	Function string `json:"function" bson:"function,omitempty"` // Function is the name of the function, or the type of the callback if it is not a function.
+	System   bool   `json:"system" bson:"system,omitempty"`     // System is true for the callbacks registered by the collector, they run before the others.
+}
+
+// eventPositioner is implemented by the event lists that can report the positions of the callbacks.
+type eventPositioner interface {
+	Positions(event uint8, arg string) []int
+}
+
+// ------------------------------------------------------------------------
+
+// Handlers returns the registered callbacks, ordered by the events, the arguments and the execution order.
+// The system callbacks of each event and argument precede the user callbacks, like they run.
+// The function names are resolved by runtime.FuncForPC, anonymous functions are named after
+// their enclosing functions, e.g. main.main.func1.
+func (c *Collector) Handlers() []HandlerInfo {
+	events := make([]uint8, 0, len(eventNames))
+	for event := range eventNames {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i] < events[j] })
+
+	var list []HandlerInfo
+	for _, event := range events {
+		args := map[string]bool{}
+		for _, callbacks := range []EventCallbacks{c.sysCallbacks, c.Callbacks} {
+			if callbacks == nil {
+				continue
+			}
+			for arg := range callbacks.Get(event) {
+				args[arg] = true
+			}
+		}
+
+		sorted := make([]string, 0, len(args))
+		for arg := range args {
+			sorted = append(sorted, arg)
+		}
+		sort.Strings(sorted)
+
+		for _, arg := range sorted {
+			list = appendHandlers(list, c.sysCallbacks, event, arg, true)
+			list = appendHandlers(list, c.Callbacks, event, arg, false)
+		}
+	}
+
+	return list
+}
+
+// ------------------------------------------------------------------------
+
+// appendHandlers appends the callbacks of an event argument to the list.
+// The positions are the indexes of the callbacks if the event list can't report them.
+func appendHandlers(list []HandlerInfo, callbacks EventCallbacks, event uint8, arg string, system bool) []HandlerInfo {
+	if callbacks == nil {
+		return list
+	}
+
+	fns := callbacks.GetArg(event, arg)
+
+	var positions []int
+	if p, ok := callbacks.(eventPositioner); ok {
+		positions = p.Positions(event, arg)
+	}
+
+	for i, fn := range fns {
+		h := HandlerInfo{
+			Event:    eventNames[event],
+			Arg:      arg,
+			Position: i,
+			Function: funcName(fn),
+			System:   system,
+		}
+		if len(positions) == len(fns) {
+			h.Position = positions[i]
+		}
+		list = append(list, h)
+	}
+
+	return list
+}
+
+// funcName returns the name of a callback function, or its type if it is not a function.
+func funcName(fn any) string {
+	if ex, ok := fn.(*extractor); ok {
+		fn = ex.fn
+	}
+
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return fmt.Sprintf("%T", fn)
+	}
+	if v.IsNil() {
+		return "nil"
+	}
+
+	if f := runtime.FuncForPC(v.Pointer()); f != nil {
+		return f.Name()
+	}
+
+	return fmt.Sprintf("%T", fn)
+}
@@ -0,0 +1,73 @@
+package colly
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func handlersTestRequest(r *Request) {}
+
+func TestCollector_Handlers(t *testing.T) {
+	c := NewCollector(nil, nil)
+	c.OnRequest(handlersTestRequest)
+	c.OnHTML("a[href]", func(e *HTMLElement) {}, 5)
+	c.OnHTML("a[href]", func(e *HTMLElement) {}, 2)
+	c.OnHTML("title", func(e *HTMLElement) {})
+	c.OnExtract("h1", "", func(r *Response, s string) {})
+
+	var got []HandlerInfo
+	for _, h := range c.Handlers() {
+		if !h.System {
+			got = append(got, h)
+		}
+	}
+
+	want := []HandlerInfo{
+		{Event: "request", Position: 0, Function: "colly.handlersTestRequest"},
+		{Event: "html", Arg: "a[href]", Position: 2, Function: "colly.TestCollector_Handlers.func2"},
+		{Event: "html", Arg: "a[href]", Position: 5, Function: "colly.TestCollector_Handlers.func1"},
+		{Event: "html", Arg: "title", Position: 0, Function: "colly.TestCollector_Handlers.func3"},
+		{Event: "extract", Arg: "h1", Position: 0, Function: "colly.TestCollector_Handlers.func4"},
+	}
+	for i := range got {
+		// Strip the module path of the function names
+		if j := strings.LastIndex(got[i].Function, "/"); j >= 0 {
+			got[i].Function = got[i].Function[j+1:]
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Handlers() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_funcName(t *testing.T) {
+	var nilFn RequestCallback
+
+	tests := []struct {
+		name string
+		fn   any
+		want string
+	}{
+		{"function", RequestCallback(handlersTestRequest), "colly.handlersTestRequest"},
+		{"extractor", &extractor{fn: func(*Response, string) {}}, "colly.Test_funcName.func1"},
+		{"nil function", nilFn, "nil"},
+		{"not a function", 42, "int"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := funcName(tt.fn)
+			if j := strings.LastIndex(got, "/"); j >= 0 {
+				got = got[j+1:]
+			}
+			if got != tt.want {
+				t.Errorf("funcName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
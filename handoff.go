@@ -0,0 +1,372 @@
+package colly
+
+import (
+	"bytes"
+	"colly/filters"
+	"colly/storage"
+	"colly/storage/filesys"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// StateBundle is a portable snapshot of a paused crawl. It packages the parked requests,
+// the job queue, the visited URLs and the cookies, so another process can continue the crawl
+// by LoadState, e.g. during a rolling deploy.
+type StateBundle struct {
+	Version    int               `json:"version" bson:"version"`                   // Version is the format version of the bundle.
+	Created    time.Time         `json:"created" bson:"created,omitempty"`         // Created is the time of the export.
+	ConfigHash string            `json:"config_hash" bson:"config_hash,omitempty"` // ConfigHash identifies the configuration of the exporting collector, see Collector.ConfigHash.
+	Requests   []*StateRequest   `json:"requests" bson:"requests,omitempty"`       // Requests are the parked requests in their dispatch order.
+	Queue      [][]byte          `json:"queue" bson:"queue,omitempty"`             // Queue holds the encoded jobs of the job queue in their order.
+	Priorities []float64         `json:"priorities" bson:"priorities,omitempty"`   // Priorities holds the priorities of the jobs if the job queue is a priority queue.
+	Visits     map[string]uint   `json:"visits" bson:"visits,omitempty"`           // Visits maps the visit keys of the visited URLs to the number of the visits.
+	Cookies    map[string][]byte `json:"cookies" bson:"cookies,omitempty"`         // Cookies maps the cookie jar keys to the encoded cookie entries.
+}
+
+// StateRequest is a parked request of a state bundle.
+type StateRequest struct {
+	URL     string      `json:"url" bson:"url,omitempty"`         // URL is the requested URL.
+	Method  string      `json:"method" bson:"method,omitempty"`   // Method is the HTTP method of the request.
+	Depth   uint16      `json:"depth" bson:"depth,omitempty"`     // Depth is the depth of the request.
+	Header  http.Header `json:"header" bson:"header,omitempty"`   // Header is the request header without cookies, they are restored from the cookie jar.
+	Body    []byte      `json:"body" bson:"body,omitempty"`       // Body is the request body, if it could be replayed.
+	Context []byte      `json:"context" bson:"context,omitempty"` // Context holds the encoded values of the request context, see Context.MarshalBinary.
+}
+
+// ------------------------------------------------------------------------
+
+// STATE_BUNDLE_VERSION is the format version of the state bundles.
+const STATE_BUNDLE_VERSION = 1
+
+// ------------------------------------------------------------------------
+
+// ExportState writes the state bundle of the paused collector in JSON format.
+// Pause the collector and Wait for the requests in progress before exporting the state,
+// the requests parked afterwards are not part of the bundle. The export doesn't modify the state,
+// so the exporting process must not resume the crawl after the handoff.
+// The visit, cookie and queue storages must implement the storage.VisitExporter, storage.Exporter
+// and storage.QueueExporter interfaces.
+func (c *Collector) ExportState(w io.Writer) error {
+	b, err := c.stateBundle()
+	if err != nil {
+		return err
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_INFO_LEVEL, "export_state", 0, b.logArgs())
+	}
+
+	return json.NewEncoder(w).Encode(b)
+}
+
+// SaveState writes the state bundle of the paused collector to a file, see ExportState.
+// The file is written atomically, a crash during the save leaves the previous state file intact.
+func (c *Collector) SaveState(path string) error {
+	return filesys.WriteAtomic(path, filesys.FILE_PERM, c.ExportState)
+}
+
+// LoadState continues the crawl of a state bundle written by ExportState. The visits, the cookies and
+// the jobs are added to the storages of the collector with their priorities, then the parked requests are submitted again
+// with their context values, without checking the visited state. Load the state into a paused collector
+// to hold the requests until Resume.
+// It returns the first error of the submitted requests.
+func (c *Collector) LoadState(r io.Reader) error {
+	b := &StateBundle{}
+	if err := json.NewDecoder(r).Decode(b); err != nil {
+		return err
+	}
+
+	if b.Version != STATE_BUNDLE_VERSION {
+		return fmt.Errorf("%w: %d", ErrStateVersion, b.Version)
+	}
+
+	if c.HasLogger() {
+		args := b.logArgs()
+		if hash := c.ConfigHash(); b.ConfigHash != hash {
+			args["config_hash"] = hash
+			args["bundle_config_hash"] = b.ConfigHash
+		}
+		c.logEvent(LOG_INFO_LEVEL, "load_state", 0, args)
+	}
+
+	if err := c.loadVisits(b.Visits); err != nil {
+		return err
+	}
+
+	if err := c.loadCookies(b.Cookies); err != nil {
+		return err
+	}
+
+	if err := c.loadQueue(b.Queue, b.Priorities); err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, sr := range b.Requests {
+		var body io.Reader
+		if sr.Body != nil {
+			body = bytes.NewReader(sr.Body)
+		}
+
+		ctx, err := c.stateContext(sr.Context)
+		if err == nil {
+			err = c.scrape(sr.URL, sr.Method, int(sr.Depth), body, ctx, sr.Header, false)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// ------------------------------------------------------------------------
+
+// stateBundle collects the state of the paused collector.
+func (c *Collector) stateBundle() (*StateBundle, error) {
+	if !c.IsPaused() {
+		return nil, ErrNotPaused
+	}
+
+	b := &StateBundle{
+		Version:    STATE_BUNDLE_VERSION,
+		Created:    time.Now(),
+		ConfigHash: c.ConfigHash(),
+		Visits:     map[string]uint{},
+		Cookies:    map[string][]byte{},
+	}
+
+	for _, r := range c.paused.requests() {
+		sr, err := newStateRequest(r)
+		if err != nil {
+			return nil, err
+		}
+		b.Requests = append(b.Requests, sr)
+	}
+
+	if c.Config.Queue != nil {
+		jobs, priorities, err := exportQueue(c.Config.Queue, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		b.Queue = jobs
+		b.Priorities = priorities
+	}
+
+	// The visits of several revisit filters are merged, the highest count wins
+	for _, stg := range c.Config.visitStorages() {
+		exporter, ok := stg.(storage.VisitExporter)
+		if !ok {
+			return nil, fmt.Errorf("%w: visit storage %T", storage.ErrNotImplemented, stg)
+		}
+
+		err := exporter.ExportVisits("", func(key string, visits uint) error {
+			if visits > b.Visits[key] {
+				b.Visits[key] = visits
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if stg := c.Config.cookieStorage(); stg != nil {
+		exporter, ok := stg.(storage.Exporter)
+		if !ok {
+			return nil, fmt.Errorf("%w: cookie storage %T", storage.ErrNotImplemented, stg)
+		}
+
+		err := exporter.ExportPrefix("", func(key string, data []byte) error {
+			b.Cookies[key] = data
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// loadVisits adds the visits to the storages of the revisit filters, up to the exported counts.
+func (c *Collector) loadVisits(visits map[string]uint) error {
+	if len(visits) == 0 {
+		return nil
+	}
+
+	stgs := c.Config.visitStorages()
+	if len(stgs) == 0 {
+		return fmt.Errorf("%w: visits", ErrStateNoStorage)
+	}
+
+	for _, s := range stgs {
+		stg, ok := s.(filters.VisitStorage)
+		if !ok {
+			continue
+		}
+
+		for key, n := range visits {
+			past, _ := stg.PastVisits(key)
+			for ; past < n; past++ {
+				if err := stg.AddVisit(key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadCookies stores the cookie entries in the storage of the cookie jar.
+func (c *Collector) loadCookies(cookies map[string][]byte) error {
+	if len(cookies) == 0 {
+		return nil
+	}
+
+	stg := c.Config.cookieStorage()
+	if stg == nil {
+		return fmt.Errorf("%w: cookies", ErrStateNoStorage)
+	}
+
+	for key, data := range cookies {
+		if err := stg.Set(key, bytes.NewReader(data)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadQueue appends the jobs to the job queue. The jobs are added with their priorities
+// if the bundle has them and the job queue is a priority queue.
+func (c *Collector) loadQueue(jobs [][]byte, priorities []float64) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	if c.Config.Queue == nil {
+		return fmt.Errorf("%w: queue", ErrStateNoStorage)
+	}
+
+	pq, ok := c.Config.Queue.(PriorityQueue)
+	if len(priorities) != len(jobs) {
+		ok = false
+	}
+
+	for i, job := range jobs {
+		var err error
+		if ok {
+			err = pq.PushPriority(c.ID, priorities[i], bytes.NewReader(job))
+		} else {
+			err = c.Config.Queue.Push(c.ID, bytes.NewReader(job))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// newStateRequest returns the bundle entry of a parked request.
+// The body is only exported if the request can replay it.
+func newStateRequest(r *Request) (*StateRequest, error) {
+	sr := &StateRequest{
+		URL:    r.Req.URL.String(),
+		Method: r.Req.Method,
+		Depth:  r.Depth,
+		Header: r.Req.Header.Clone(),
+	}
+	sr.Header.Del("Cookie")
+
+	if r.Context != nil {
+		var err error
+		if sr.Context, err = r.Context.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Req.GetBody != nil {
+		body, err := r.Req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+
+		if sr.Body, err = io.ReadAll(body); err != nil {
+			return nil, err
+		}
+	}
+
+	return sr, nil
+}
+
+// stateContext returns the context carrying the decoded values of a parked request,
+// or nil if the request had no values. It inherits the context of the collector, if any.
+func (c *Collector) stateContext(data []byte) (*context.Context, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	values := NewContext()
+	if err := values.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+
+	parent := context.Background()
+	if c.Ctx != nil {
+		parent = *c.Ctx
+	}
+	ctx := withValues(parent, values)
+
+	return &ctx, nil
+}
+
+// exportQueue returns the items of a dispatch queue in their pop order without removing them.
+// The priorities of the items are only returned if the queue is a priority queue.
+func exportQueue(q Queue, id uint32) ([][]byte, []float64, error) {
+	exporter, ok := q.(storage.QueueExporter)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: queue storage %T", storage.ErrNotImplemented, q)
+	}
+
+	var (
+		items      [][]byte
+		priorities []float64
+	)
+	err := exporter.ExportQueue(id, func(priority float64, data []byte) error {
+		items = append(items, data)
+		priorities = append(priorities, priority)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, ok := q.(PriorityQueue); !ok {
+		priorities = nil
+	}
+
+	return items, priorities, nil
+}
+
+// logArgs returns the sizes of the bundle for the log events.
+func (b *StateBundle) logArgs() map[string]string {
+	return map[string]string{
+		"requests": strconv.Itoa(len(b.Requests)),
+		"queue":    strconv.Itoa(len(b.Queue)),
+		"visits":   strconv.Itoa(len(b.Visits)),
+		"cookies":  strconv.Itoa(len(b.Cookies)),
+	}
+}
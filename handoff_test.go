@@ -0,0 +1,246 @@
+package colly
+
+import (
+	"bytes"
+	"colly/filters"
+	"colly/storage/mem"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollector_ExportState(t *testing.T) {
+	newCollector := func() *Collector {
+		cfg := NewConfig()
+		if err := cfg.SetMaxRevisits(0, mem.NewVisitStorage()); err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.SetCookieJar(mem.NewCookieStorage(), COOKIE_MODE_STRICT); err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.SetQueue(mem.NewFIFOStorage(10)); err != nil {
+			t.Fatal(err)
+		}
+		return NewCollector(cfg, nil)
+	}
+
+	src := newCollector()
+	if err := src.ExportState(io.Discard); !errors.Is(err, ErrNotPaused) {
+		t.Fatalf("ExportState() of a running collector error = %v, want %v", err, ErrNotPaused)
+	}
+
+	visits := src.Config.Filter.VisitStorages()[0]
+	visits.AddVisit(filters.VisitKey("https://example.com/a"))
+	visits.AddVisit(filters.VisitKey("https://example.com/a"))
+	src.Config.cookieStorage().Set("example.com", strings.NewReader(`{"k":"v"}`))
+	src.Config.Queue.Push(src.ID, strings.NewReader("job-1"))
+	src.Config.Queue.Push(src.ID, strings.NewReader("job-2"))
+
+	src.Pause()
+	req, _ := http.NewRequest("POST", "https://example.com/form", strings.NewReader("q=1"))
+	req.Header.Set("Cookie", "a=b")
+	req.Header.Set("X-Test", "yes")
+	values := NewContext()
+	values.Put("category", "shoes")
+	src.paused.park(&Request{Req: req, Depth: 2, Context: values})
+
+	buf := &bytes.Buffer{}
+	if err := src.ExportState(buf); err != nil {
+		t.Fatalf("ExportState() error = %v", err)
+	}
+
+	if n, _ := src.Config.Queue.Len(src.ID); n != 2 {
+		t.Errorf("queue length after ExportState() = %d, want 2", n)
+	}
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := src.SaveState(path); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	saved := &StateBundle{}
+	if data, err := os.ReadFile(path); err != nil || json.Unmarshal(data, saved) != nil || len(saved.Requests) != 1 {
+		t.Errorf("SaveState() = %+v, %v, want 1 request", saved, err)
+	}
+
+	b := &StateBundle{}
+	if err := json.Unmarshal(buf.Bytes(), b); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Requests) != 1 {
+		t.Fatalf("Requests = %+v, want 1 request", b.Requests)
+	}
+	sr := b.Requests[0]
+	if sr.URL != "https://example.com/form" || sr.Method != "POST" || sr.Depth != 2 || string(sr.Body) != "q=1" {
+		t.Errorf("Requests[0] = %+v", sr)
+	}
+	if sr.Header.Get("Cookie") != "" || sr.Header.Get("X-Test") != "yes" {
+		t.Errorf("Requests[0].Header = %v, want X-Test without Cookie", sr.Header)
+	}
+	if ctx := NewContext(); ctx.UnmarshalBinary(sr.Context) != nil || ctx.Get("category") != "shoes" {
+		t.Errorf("Requests[0].Context = %q, want the category value", sr.Context)
+	}
+	if want := [][]byte{[]byte("job-1"), []byte("job-2")}; !reflect.DeepEqual(b.Queue, want) {
+		t.Errorf("Queue = %q, want %q", b.Queue, want)
+	}
+	if want := map[string]uint{filters.VisitKey("https://example.com/a"): 2}; !reflect.DeepEqual(b.Visits, want) {
+		t.Errorf("Visits = %v, want %v", b.Visits, want)
+	}
+
+	// The parked requests are not loaded, they would be sent
+	b.Requests = nil
+	data, _ := json.Marshal(b)
+
+	dst := newCollector()
+	if err := dst.LoadState(bytes.NewReader(data)); err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+
+	if n, _ := dst.Config.Filter.VisitStorages()[0].PastVisits(filters.VisitKey("https://example.com/a")); n != 2 {
+		t.Errorf("PastVisits() after LoadState() = %d, want 2", n)
+	}
+	if rdr, _ := dst.Config.cookieStorage().Get("example.com"); rdr == nil {
+		t.Error("cookies after LoadState() = nil")
+	} else if got, _ := io.ReadAll(rdr); string(got) != `{"k":"v"}` {
+		t.Errorf("cookies after LoadState() = %q", got)
+	}
+	if n, _ := dst.Config.Queue.Len(dst.ID); n != 2 {
+		t.Errorf("queue length after LoadState() = %d, want 2", n)
+	}
+
+	if err := dst.LoadState(strings.NewReader(`{"version":99}`)); !errors.Is(err, ErrStateVersion) {
+		t.Errorf("LoadState() of an unknown version error = %v, want %v", err, ErrStateVersion)
+	}
+	if err := NewCollector(nil, nil).LoadState(bytes.NewReader(data)); !errors.Is(err, ErrStateNoStorage) {
+		t.Errorf("LoadState() without storages error = %v, want %v", err, ErrStateNoStorage)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_LoadState_requests(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	values := NewContext()
+	values.Put("category", "shoes")
+	data, _ := values.MarshalBinary()
+
+	bundle, _ := json.Marshal(&StateBundle{
+		Version: STATE_BUNDLE_VERSION,
+		Requests: []*StateRequest{
+			{URL: ts.URL + "/echo", Method: "POST", Body: []byte("q=1"), Context: data},
+			{URL: ts.URL + "/page", Method: "GET"},
+		},
+	})
+
+	c := NewCollector(nil, nil)
+	got := map[string]string{}
+	c.OnResponse(func(r *Response) {
+		got[r.Request.Req.URL.Path] = r.Request.Context.Get("category") + " " + string(r.Body)
+	})
+
+	if err := c.LoadState(bytes.NewReader(bundle)); err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	c.Wait()
+
+	echo := got["/echo"]
+	if !strings.HasPrefix(echo, "shoes POST ") || !strings.HasSuffix(echo, " q=1") || !strings.HasPrefix(got["/page"], " <html>") {
+		t.Errorf("responses after LoadState() = %q, want the restored context and body", got)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_exportQueue(t *testing.T) {
+	q := mem.NewFIFOStorage(10)
+	for _, job := range []string{"a", "b", "c"} {
+		q.Push(1, strings.NewReader(job))
+	}
+	q.Push(2, strings.NewReader("other"))
+
+	got, priorities, err := exportQueue(q, 1)
+	if err != nil {
+		t.Fatalf("exportQueue() error = %v", err)
+	}
+	if want := [][]byte{[]byte("a"), []byte("b"), []byte("c")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("exportQueue() = %q, want %q", got, want)
+	}
+	if priorities != nil {
+		t.Errorf("exportQueue() priorities of a FIFO queue = %v, want nil", priorities)
+	}
+
+	for _, want := range []string{"a", "b", "c"} {
+		rdr, err := q.Pop(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job, _ := io.ReadAll(rdr); string(job) != want {
+			t.Errorf("Pop() after exportQueue() = %q, want %q", job, want)
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_ExportState_priorityQueue(t *testing.T) {
+	newCollector := func() *Collector {
+		cfg := NewConfig()
+		if err := cfg.SetQueue(mem.NewPriorityStorage(10)); err != nil {
+			t.Fatal(err)
+		}
+		return NewCollector(cfg, nil)
+	}
+
+	src := newCollector()
+	pq := src.Config.Queue.(PriorityQueue)
+	pq.PushPriority(src.ID, -2, strings.NewReader("last"))
+	pq.PushPriority(src.ID, 0, strings.NewReader("middle"))
+	pq.PushPriority(src.ID, 3, strings.NewReader("first"))
+	pq.PushPriority(src.ID, -2, strings.NewReader("after-last"))
+
+	src.Pause()
+	buf := &bytes.Buffer{}
+	if err := src.ExportState(buf); err != nil {
+		t.Fatalf("ExportState() error = %v", err)
+	}
+
+	want := []string{"first", "middle", "last", "after-last"}
+	popAll := func(q Queue, id uint32) []string {
+		var jobs []string
+		for {
+			rdr, err := q.Pop(id)
+			if err != nil {
+				return jobs
+			}
+			job, _ := io.ReadAll(rdr)
+			jobs = append(jobs, string(job))
+		}
+	}
+
+	// The export leaves the queue intact
+	bundle := buf.Bytes()
+	if got := popAll(src.Config.Queue, src.ID); !reflect.DeepEqual(got, want) {
+		t.Errorf("queue after ExportState() = %q, want %q", got, want)
+	}
+
+	dst := newCollector()
+	// The loaded jobs keep their priorities among the jobs already queued
+	dst.Config.Queue.(PriorityQueue).PushPriority(dst.ID, 1, strings.NewReader("queued"))
+	if err := dst.LoadState(bytes.NewReader(bundle)); err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+
+	want = []string{"first", "queued", "middle", "last", "after-last"}
+	if got := popAll(dst.Config.Queue, dst.ID); !reflect.DeepEqual(got, want) {
+		t.Errorf("queue after LoadState() = %q, want %q", got, want)
+	}
+}
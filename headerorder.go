@@ -0,0 +1,326 @@
+package colly
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// HeaderProfile is a browser-like set of request headers, emitted in a fixed order.
+type HeaderProfile struct {
+	Name      string            `json:"name" bson:"name,omitempty"`             // Name identifies the profile.
+	UserAgent string            `json:"user_agent" bson:"user_agent,omitempty"` // UserAgent is the user agent string of the profile.
+	Headers   map[string]string `json:"headers" bson:"headers,omitempty"`       // Headers are the default headers sent with every request.
+	Order     []string          `json:"order" bson:"order,omitempty"`           // Order is the list of the header names in the order of emission, with their casing.
+}
+
+// headerOrderTransport is an HTTP/1.1 transport writing the request headers in a fixed order
+type headerOrderTransport struct {
+	base  *http.Transport // fallback transport for proxied and non-HTTP requests
+	order []string
+}
+
+// bodyConn closes the connection with the response body
+type bodyConn struct {
+	io.ReadCloser
+	conn net.Conn
+}
+
+// ------------------------------------------------------------------------
+
+const defDialTimeout = 30 * time.Second
+
+// ------------------------------------------------------------------------
+
+// ChromeHeaderProfile returns the header profile of a desktop Chrome browser.
+func ChromeHeaderProfile() *HeaderProfile {
+	return &HeaderProfile{
+		Name:      "chrome",
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36",
+		Headers: map[string]string{
+			"Accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8",
+			"Accept-Language":           "en-US,en;q=0.9",
+			"Upgrade-Insecure-Requests": "1",
+		},
+		Order: []string{
+			"Host", "Connection", "Content-Length", "Cache-Control", "Upgrade-Insecure-Requests", "User-Agent",
+			"Content-Type", "Accept", "Sec-Fetch-Site", "Sec-Fetch-Mode", "Sec-Fetch-User", "Sec-Fetch-Dest",
+			"Referer", "Accept-Encoding", "Accept-Language", "Cookie",
+		},
+	}
+}
+
+// FirefoxHeaderProfile returns the header profile of a desktop Firefox browser.
+func FirefoxHeaderProfile() *HeaderProfile {
+	return &HeaderProfile{
+		Name:      "firefox",
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:109.0) Gecko/20100101 Firefox/118.0",
+		Headers: map[string]string{
+			"Accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8",
+			"Accept-Language":           "en-US,en;q=0.5",
+			"Upgrade-Insecure-Requests": "1",
+		},
+		Order: []string{
+			"Host", "User-Agent", "Accept", "Accept-Language", "Accept-Encoding", "Content-Type", "Content-Length",
+			"Referer", "Connection", "Cookie", "Upgrade-Insecure-Requests", "Sec-Fetch-Dest", "Sec-Fetch-Mode",
+			"Sec-Fetch-Site", "Sec-Fetch-User", "Cache-Control",
+		},
+	}
+}
+
+// HeaderProfileByName returns a predefined header profile, or nil if the name is unknown.
+func HeaderProfileByName(name string) *HeaderProfile {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "chrome":
+		return ChromeHeaderProfile()
+	case "firefox":
+		return FirefoxHeaderProfile()
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// NewHeaderOrderTransport returns an HTTP/1.1 transport that writes the request headers
+// in the given order, keeping the casing of the names in the list. Headers missing from
+// the list follow them in alphabetical order. Proxied requests are sent by the base transport,
+// which defaults to http.DefaultTransport. Connections are not reused.
+func NewHeaderOrderTransport(base *http.Transport, order []string) *headerOrderTransport {
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	// The Host header is written first, unless the order tells otherwise
+	hasHost := false
+	for _, name := range order {
+		if http.CanonicalHeaderKey(name) == "Host" {
+			hasHost = true
+			break
+		}
+	}
+	if !hasHost {
+		order = append([]string{"Host"}, order...)
+	}
+
+	return &headerOrderTransport{
+		base:  base,
+		order: order,
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *headerOrderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.handles(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+
+	conn, err := t.dial(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if err := t.writeRequest(conn, req); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body = &bodyConn{ReadCloser: resp.Body, conn: conn}
+
+	return resp, nil
+}
+
+// handles returns true if the request is sent directly by the transport.
+func (t *headerOrderTransport) handles(req *http.Request) bool {
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return false
+	}
+
+	if t.base.Proxy != nil {
+		if proxyURL, err := t.base.Proxy(req); err != nil || proxyURL != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dial opens a connection to the host of the URL.
+func (t *headerOrderTransport) dial(ctx context.Context, u *url.URL) (net.Conn, error) {
+	addr := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	if u.Scheme == "https" && t.base.DialTLSContext != nil {
+		return t.base.DialTLSContext(ctx, "tcp", addr)
+	}
+
+	dial := t.base.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: defDialTimeout}).DialContext
+	}
+
+	conn, err := dial(ctx, "tcp", addr)
+	if err != nil || u.Scheme != "https" {
+		return conn, err
+	}
+
+	cfg := &tls.Config{}
+	if t.base.TLSClientConfig != nil {
+		cfg = t.base.TLSClientConfig.Clone()
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = u.Hostname()
+	}
+	cfg.NextProtos = []string{"http/1.1"}
+
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
+
+// writeRequest writes the request line, the ordered headers and the body.
+func (t *headerOrderTransport) writeRequest(w io.Writer, req *http.Request) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	hdr := req.Header.Clone()
+	if hdr == nil {
+		hdr = http.Header{}
+	}
+	hdr.Set("Host", host)
+	if len(body) > 0 || req.Method == "POST" || req.Method == "PUT" || req.Method == "PATCH" {
+		hdr.Set("Content-Length", fmt.Sprint(len(body)))
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s %s HTTP/1.1\r\n", req.Method, req.URL.RequestURI())
+	writeOrderedHeader(bw, hdr, t.order)
+	bw.WriteString("\r\n")
+	bw.Write(body)
+
+	return bw.Flush()
+}
+
+// ------------------------------------------------------------------------
+
+// writeOrderedHeader writes the headers in the given order, followed by the rest of the headers
+// in alphabetical order. Names in the order list keep their casing.
+func writeOrderedHeader(w io.Writer, hdr http.Header, order []string) {
+	written := map[string]bool{}
+
+	for _, name := range order {
+		key := http.CanonicalHeaderKey(name)
+		if written[key] {
+			continue
+		}
+		written[key] = true
+
+		for _, v := range hdr[key] {
+			fmt.Fprintf(w, "%s: %s\r\n", name, cleanHeaderValue(v))
+		}
+	}
+
+	keys := make([]string, 0, len(hdr))
+	for key := range hdr {
+		if !written[http.CanonicalHeaderKey(key)] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, v := range hdr[key] {
+			fmt.Fprintf(w, "%s: %s\r\n", key, cleanHeaderValue(v))
+		}
+	}
+}
+
+// cleanHeaderValue removes the line breaks from a header value.
+func cleanHeaderValue(v string) string {
+	return strings.TrimSpace(strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(v))
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the response body and the connection.
+func (b *bodyConn) Close() error {
+	err := b.ReadCloser.Close()
+	b.conn.Close()
+
+	return err
+}
+
+// ------------------------------------------------------------------------
+
+// SetHeaderProfile sets the user agent, the default headers and the header order of a browser profile.
+// The headers are written in the order of the profile by an HTTP/1.1 transport.
+func (c *CollectorConfig) SetHeaderProfile(profile *HeaderProfile) {
+	c.HeaderProfile = profile
+	if profile == nil {
+		return
+	}
+
+	if profile.UserAgent != "" {
+		c.SetUserAgent(profile.UserAgent)
+	}
+
+	if len(profile.Headers) > 0 {
+		c.SetCustomHeaders(profile.Headers)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// headerOrder returns the header order of the profile, or nil if no profile was set.
+func (c *CollectorConfig) headerOrder() []string {
+	if c.HeaderProfile == nil {
+		return nil
+	}
+
+	return c.HeaderProfile.Order
+}
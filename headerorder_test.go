@@ -0,0 +1,100 @@
+package colly
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestWriteOrderedHeader(t *testing.T) {
+	hdr := http.Header{}
+	hdr.Set("Accept", "*/*")
+	hdr.Set("User-Agent", "test")
+	hdr.Set("X-Zeta", "z")
+	hdr.Set("X-Alpha", "a")
+	hdr.Set("Host", "example.com")
+
+	tests := []struct {
+		name  string
+		order []string
+		want  string
+	}{
+		{
+			name:  "no order",
+			order: nil,
+			want:  "Accept: */*\r\nHost: example.com\r\nUser-Agent: test\r\nX-Alpha: a\r\nX-Zeta: z\r\n",
+		},
+		{
+			name:  "ordered with casing",
+			order: []string{"Host", "user-agent", "X-Missing", "Accept"},
+			want:  "Host: example.com\r\nuser-agent: test\r\nAccept: */*\r\nX-Alpha: a\r\nX-Zeta: z\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			writeOrderedHeader(b, hdr, tt.order)
+			if got := b.String(); got != tt.want {
+				t.Errorf("writeOrderedHeader() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestHeaderOrderTransport(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var lines []string
+		rdr := bufio.NewReader(conn)
+		for {
+			line, err := rdr.ReadString('\n')
+			if err != nil || line == "\r\n" {
+				break
+			}
+			lines = append(lines, strings.TrimRight(line, "\r\n"))
+		}
+		received <- strings.Join(lines, "\n")
+
+		io.WriteString(conn, "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok")
+	}()
+
+	req, _ := http.NewRequest("GET", "http://"+ln.Addr().String()+"/path?q=1", nil)
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "test")
+
+	tr := NewHeaderOrderTransport(&http.Transport{}, []string{"user-agent", "Accept"})
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	want := "GET /path?q=1 HTTP/1.1\nHost: " + ln.Addr().String() + "\nuser-agent: test\nAccept: text/html"
+	if got := <-received; got != want {
+		t.Errorf("request = %q, want %q", got, want)
+	}
+
+	if body, _ := io.ReadAll(resp.Body); string(body) != "ok" {
+		t.Errorf("body = %q, want %q", body, "ok")
+	}
+}
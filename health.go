@@ -0,0 +1,301 @@
+package colly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// HostHealth is the failure memory of a host: the recent error rate, the consecutive failures,
+// the backoff of the host and its adaptive delay factor. It is kept in CollectorConfig.HealthStorage
+// when a backoff starts or ends, so a restarted crawler backs off the hosts that were failing
+// or rate limiting it before the restart.
+type HostHealth struct {
+	Host         string        `json:"host" bson:"host"`                             // Host is the lowercase host name.
+	ErrorRate    float64       `json:"error_rate" bson:"error_rate,omitempty"`       // ErrorRate is the moving average of the failures, from 0 to 1.
+	Failures     uint          `json:"failures" bson:"failures,omitempty"`           // Failures is the number of the consecutive failures.
+	Backoff      time.Duration `json:"backoff" bson:"backoff,omitempty"`             // Backoff is the last backoff period, doubled by every further backoff.
+	BackoffUntil time.Time     `json:"backoff_until" bson:"backoff_until,omitempty"` // BackoffUntil is the end of the backoff, the requests of the host wait until then.
+	RateFactor   float64       `json:"rate_factor" bson:"rate_factor,omitempty"`     // RateFactor is the adaptive delay factor of the host, see Collector.AdjustRate.
+	Updated      time.Time     `json:"updated" bson:"updated,omitempty"`             // Updated is the date and time of the last outcome of the host.
+}
+
+// healthMonitor keeps the failure memory of the hosts.
+type healthMonitor struct {
+	hosts    map[string]*HostHealth // failure memory by the lowercase host names, nil entries were not found in the storage
+	lock     *sync.Mutex
+	saveLock *sync.Mutex // serializes the writes of the health storage, see saveHealth
+}
+
+// ------------------------------------------------------------------------
+
+// Host health defaults
+const (
+	DEFAULT_HEALTH_BACKOFF = 30 * time.Second // DEFAULT_HEALTH_BACKOFF is the first backoff period of a failing host.
+	HEALTH_MAX_BACKOFF     = time.Hour        // HEALTH_MAX_BACKOFF is the longest backoff period of a failing host.
+	HEALTH_ERROR_WEIGHT    = 0.2              // HEALTH_ERROR_WEIGHT is the weight of the last outcome in the error rate.
+)
+
+// ------------------------------------------------------------------------
+
+// newHealthMonitor returns a pointer to a newly created host failure memory.
+func newHealthMonitor() *healthMonitor {
+	return &healthMonitor{
+		hosts:    map[string]*HostHealth{},
+		lock:     &sync.Mutex{},
+		saveLock: &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// HostHealth returns a copy of the failure memory of the host, or false if the host has none.
+func (c *Collector) HostHealth(host string) (HostHealth, bool) {
+	c.health.lock.Lock()
+	defer c.health.lock.Unlock()
+
+	if h := c.loadHealth(strings.ToLower(host)); h != nil {
+		return *h, true
+	}
+
+	return HostHealth{}, false
+}
+
+// HostHealths returns a copy of the failure memory of the hosts seen by the collector, sorted by the hosts.
+func (c *Collector) HostHealths() []HostHealth {
+	c.health.lock.Lock()
+	defer c.health.lock.Unlock()
+
+	healths := make([]HostHealth, 0, len(c.health.hosts))
+	for _, h := range c.health.hosts {
+		if h != nil {
+			healths = append(healths, *h)
+		}
+	}
+
+	sort.Slice(healths, func(i, j int) bool {
+		return healths[i].Host < healths[j].Host
+	})
+
+	return healths
+}
+
+// ResetHostHealth forgets the failures of the host, ends its backoff and restores its configured delay.
+func (c *Collector) ResetHostHealth(host string) error {
+	host = strings.ToLower(host)
+	c.throttle.reset(host)
+
+	// A pending write must not store the host again
+	c.health.saveLock.Lock()
+	defer c.health.saveLock.Unlock()
+	c.health.lock.Lock()
+	defer c.health.lock.Unlock()
+
+	c.health.hosts[host] = nil
+	if stg := c.Config.HealthStorage; stg != nil && stg.Has(healthKey(host)) {
+		return stg.Remove(healthKey(host))
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// waitHealth waits until the backoff of the host is over. The wait is cut short if the context is done.
+func (c *Collector) waitHealth(ctx context.Context, host string) error {
+	if c.Config.HealthThreshold == 0 {
+		return nil
+	}
+
+	c.health.lock.Lock()
+	var until time.Time
+	if h := c.loadHealth(strings.ToLower(host)); h != nil {
+		until = h.BackoffUntil
+	}
+	c.health.lock.Unlock()
+
+	if wait := time.Until(until); wait > 0 {
+		if c.Config.logEnabled(LOG_DEBUG_LEVEL) {
+			c.logEvent(LOG_DEBUG_LEVEL, "backoff_wait", 0, map[string]string{
+				"host": host,
+				"wait": wait.String(),
+			})
+		}
+		return sleepContext(ctx, wait)
+	}
+
+	return nil
+}
+
+// recordHealth updates the failure memory of the host of the response. The transport errors,
+// the 429 and the server error responses are failures. After HealthThreshold consecutive failures
+// the host backs off for a period doubled by every further failure up to HEALTH_MAX_BACKOFF.
+// A success ends the backoff. The failure memory is stored only when the backoff starts or ends.
+func (c *Collector) recordHealth(resp *Response, err error) {
+	if c.health == nil || c.Config.HealthThreshold == 0 || resp.FromCache || resp.Request == nil || resp.Request.Req == nil {
+		return
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrNegativeCacheHit) {
+		return
+	}
+
+	failed := false
+	switch {
+	case resp.Resp != nil:
+		failed = resp.Resp.StatusCode == http.StatusTooManyRequests || resp.Resp.StatusCode >= http.StatusInternalServerError
+	case err != nil:
+		failed = true
+	default:
+		return
+	}
+
+	host := strings.ToLower(resp.Request.Req.URL.Host)
+	now := time.Now()
+
+	c.health.lock.Lock()
+	h := c.loadHealth(host)
+	if h == nil {
+		h = &HostHealth{Host: host}
+		c.health.hosts[host] = h
+	}
+
+	outcome := 0.0
+	if failed {
+		outcome = 1
+	}
+	h.ErrorRate += HEALTH_ERROR_WEIGHT * (outcome - h.ErrorRate)
+	h.RateFactor = c.throttle.factor(host)
+	h.Updated = now
+
+	opened, closed := false, false
+	if failed {
+		h.Failures++
+		if h.Failures >= c.Config.HealthThreshold {
+			h.Backoff = c.nextBackoff(h.Backoff)
+			h.BackoffUntil = now.Add(h.Backoff)
+			opened = true
+		}
+	} else {
+		closed = h.Backoff > 0
+		h.Failures = 0
+		h.Backoff = 0
+		h.BackoffUntil = time.Time{}
+	}
+
+	snapshot := *h
+	c.health.lock.Unlock()
+
+	if opened || closed {
+		c.saveHealth(host)
+	}
+
+	if opened {
+		c.bus.Publish(TOPIC_BACKOFF, snapshot)
+		if c.Config.logEnabled(LOG_WARN_LEVEL) {
+			c.logEvent(LOG_WARN_LEVEL, "backoff", resp.Request.ID, map[string]string{
+				"host":       host,
+				"failures":   strconv.FormatUint(uint64(snapshot.Failures), 10),
+				"backoff":    snapshot.Backoff.String(),
+				"error_rate": strconv.FormatFloat(snapshot.ErrorRate, 'f', 2, 64),
+			})
+		}
+	}
+}
+
+// nextBackoff returns the backoff period following the last one.
+func (c *Collector) nextBackoff(last time.Duration) time.Duration {
+	if last <= 0 {
+		if last = c.Config.HealthBackoff; last <= 0 {
+			last = DEFAULT_HEALTH_BACKOFF
+		}
+		return last
+	}
+
+	if last *= 2; last > HEALTH_MAX_BACKOFF {
+		last = HEALTH_MAX_BACKOFF
+	}
+
+	return last
+}
+
+// ------------------------------------------------------------------------
+
+// loadHealth returns the failure memory of the host, loading it from the health storage on first use.
+// The adaptive delay factor of the loaded host is restored. The caller must hold the lock.
+func (c *Collector) loadHealth(host string) *HostHealth {
+	if h, present := c.health.hosts[host]; present {
+		return h
+	}
+
+	c.health.hosts[host] = nil
+
+	stg := c.Config.HealthStorage
+	if stg == nil || !stg.Has(healthKey(host)) {
+		return nil
+	}
+
+	rdr, err := stg.Fetch(healthKey(host))
+	if err != nil {
+		c.handleOnStorageError(STORAGE_HEALTH, err)
+		return nil
+	}
+
+	h := &HostHealth{}
+	if err := json.NewDecoder(rdr).Decode(h); err != nil {
+		c.handleOnStorageError(STORAGE_HEALTH, err)
+		return nil
+	}
+	c.health.hosts[host] = h
+
+	if h.RateFactor > 0 && h.RateFactor != 1 && c.throttle.factor(host) == 1 {
+		c.throttle.adjust(host, h.RateFactor)
+	}
+
+	return h
+}
+
+// saveHealth stores the failure memory of the host in the health storage.
+// The writes are serialized and they store the current state, so a slow write never overwrites a newer one.
+// The caller must not hold the lock.
+func (c *Collector) saveHealth(host string) {
+	stg := c.Config.HealthStorage
+	if stg == nil {
+		return
+	}
+
+	c.health.saveLock.Lock()
+	defer c.health.saveLock.Unlock()
+
+	c.health.lock.Lock()
+	h := c.health.hosts[host]
+	var snapshot HostHealth
+	if h != nil {
+		snapshot = *h
+	}
+	c.health.lock.Unlock()
+
+	// The host was reset meanwhile
+	if h == nil {
+		return
+	}
+
+	data, err := json.Marshal(&snapshot)
+	if err == nil {
+		err = stg.Put(healthKey(host), bytes.NewReader(data))
+	}
+	c.handleOnStorageError(STORAGE_HEALTH, err)
+}
+
+// healthKey returns the health storage key of the host.
+func healthKey(host string) string {
+	return "health:" + host
+}
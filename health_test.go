@@ -0,0 +1,85 @@
+package colly
+
+import (
+	"colly/storage/mem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollector_recordHealth(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	stg := mem.NewCacheStorage()
+	newCollector := func() *Collector {
+		cfg := NewConfig()
+		cfg.HealthThreshold = 2
+		cfg.HealthBackoff = 50 * time.Millisecond
+		cfg.HealthStorage = stg
+		return NewCollector(cfg, nil)
+	}
+
+	c := newCollector()
+	backoffs := c.Bus().Subscribe(0, TOPIC_BACKOFF)
+	c.AdjustRate(host, 2)
+	for i := 0; i < 3; i++ {
+		c.Request("GET", srv.URL+"/"+string(rune('a'+i)), nil, nil, nil)
+
+		// The failures below the threshold are not stored
+		if stored := stg.Has(healthKey(host)); stored != (i > 0) {
+			t.Errorf("health stored after %d failures = %v, want %v", i+1, stored, i > 0)
+		}
+	}
+
+	h, ok := c.HostHealth(host)
+	if !ok || h.Failures != 3 || h.Backoff != 100*time.Millisecond || h.RateFactor != 2 {
+		t.Fatalf("HostHealth() = %+v, %v, want 3 failures, 100ms backoff, rate factor 2", h, ok)
+	}
+	if len(backoffs.C()) != 2 {
+		t.Errorf("%d backoff signals, want 2", len(backoffs.C()))
+	}
+
+	// The restarted collector waits for the backoff and restores the delay factor
+	c = newCollector()
+	if got := c.RateFactor(host); got != 1 {
+		t.Errorf("RateFactor() before the first request = %v, want 1", got)
+	}
+	status = http.StatusOK
+	start := time.Now()
+	if err := c.Visit(srv.URL + "/d"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("request was sent after %v, want it to wait for the backoff", elapsed)
+	}
+	if got := c.RateFactor(host); got != 2 {
+		t.Errorf("RateFactor() = %v, want 2", got)
+	}
+
+	h, _ = c.HostHealth(host)
+	if h.Failures != 0 || !h.BackoffUntil.IsZero() || h.ErrorRate <= 0 || h.ErrorRate >= 1 {
+		t.Errorf("HostHealth() after a success = %+v, want no failures and backoff", h)
+	}
+	if h, ok := newCollector().HostHealth(host); !ok || h.Backoff != 0 || !h.BackoffUntil.IsZero() {
+		t.Errorf("stored HostHealth() after a success = %+v, %v, want the end of the backoff", h, ok)
+	}
+
+	if err := c.ResetHostHealth(host); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.HostHealth(host); ok || c.RateFactor(host) != 1 {
+		t.Error("ResetHostHealth() kept the failure memory of the host")
+	}
+	if _, ok := newCollector().HostHealth(host); ok {
+		t.Error("ResetHostHealth() kept the stored failure memory of the host")
+	}
+}
@@ -0,0 +1,159 @@
+package colly
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ------------------------------------------------------------------------
+
+// HostAlias redirects the connections of a host to another address, e.g. to a staging server.
+// The URLs, the Host header and the cookies of the requests keep using the aliased host.
+type HostAlias struct {
+	Target     string `json:"target" bson:"target,omitempty"`           // Target is the host[:port] dialed instead, the port of the request is kept if missing.
+	ServerName string `json:"server_name" bson:"server_name,omitempty"` // ServerName is the TLS server name sent and verified, blank keeps the aliased host.
+}
+
+// hostAliasDialer dials the targets of the host aliases
+type hostAliasDialer struct {
+	aliases   map[string]HostAlias                                              // aliases by lowercase host or host:port
+	dial      func(ctx context.Context, network, addr string) (net.Conn, error) // underlying dialer
+	tlsConfig *tls.Config                                                       // TLS configuration of the transport
+	nextProto []string                                                          // ALPN protocols offered over TLS
+}
+
+// ------------------------------------------------------------------------
+
+// SetHostAlias makes the collector dial target instead of host, keeping the Host header and the
+// TLS server name of host. host can be a host name or a host:port pair, the latter having priority.
+// target is a host or a host:port pair. A blank target removes the alias.
+// The aliases apply to the direct connections of *http.Transport based transports, not to the proxied ones.
+func (c *CollectorConfig) SetHostAlias(host string, target string) error {
+	host = strings.ToLower(strings.TrimSpace(host))
+	target = strings.TrimSpace(target)
+	if host == "" || strings.ContainsAny(host, "/ ") || strings.ContainsAny(target, "/ ") {
+		return fmt.Errorf("%w: %q", ErrInvalidHostAlias, host+"="+target)
+	}
+
+	if target == "" {
+		delete(c.HostAliases, host)
+		return nil
+	}
+
+	if c.HostAliases == nil {
+		c.HostAliases = map[string]HostAlias{}
+	}
+	alias := c.HostAliases[host]
+	alias.Target = target
+	c.HostAliases[host] = alias
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// aliasTransport returns a copy of the transport dialing the targets of the host aliases,
+// or the transport itself if there are no aliases. A nil transport stands for the default one.
+func (c *CollectorConfig) aliasTransport(base *http.Transport) *http.Transport {
+	if len(c.HostAliases) == 0 {
+		return base
+	}
+
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	transport := base.Clone()
+
+	d := &hostAliasDialer{
+		aliases:   make(map[string]HostAlias, len(c.HostAliases)),
+		dial:      transport.DialContext,
+		tlsConfig: transport.TLSClientConfig,
+		nextProto: []string{"http/1.1"},
+	}
+	if d.dial == nil {
+		d.dial = (&net.Dialer{Timeout: defDialTimeout}).DialContext
+	}
+	if transport.ForceAttemptHTTP2 && len(c.headerOrder()) == 0 {
+		d.nextProto = []string{"h2", "http/1.1"}
+	}
+
+	sni := false
+	for host, alias := range c.HostAliases {
+		d.aliases[strings.ToLower(host)] = alias
+		sni = sni || alias.ServerName != ""
+	}
+
+	transport.DialContext = d.DialContext
+	if sni && transport.DialTLSContext == nil && transport.DialTLS == nil {
+		transport.DialTLSContext = d.DialTLSContext
+	}
+
+	return transport
+}
+
+// ------------------------------------------------------------------------
+
+// resolve returns the address to dial for addr and its alias.
+func (d *hostAliasDialer) resolve(addr string) (string, HostAlias, bool) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, HostAlias{}, false
+	}
+	host = strings.ToLower(host)
+
+	alias, present := d.aliases[net.JoinHostPort(host, port)]
+	if !present {
+		if alias, present = d.aliases[host]; !present {
+			return addr, alias, false
+		}
+	}
+
+	if _, _, err := net.SplitHostPort(alias.Target); err == nil {
+		return alias.Target, alias, true
+	}
+
+	return net.JoinHostPort(alias.Target, port), alias, true
+}
+
+// DialContext dials the target of the aliased address, or the address itself.
+func (d *hostAliasDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	target, _, _ := d.resolve(addr)
+
+	return d.dial(ctx, network, target)
+}
+
+// DialTLSContext dials the target of the aliased address and makes the TLS handshake
+// with the server name of the alias or the host of the address.
+func (d *hostAliasDialer) DialTLSContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	target, alias, _ := d.resolve(addr)
+
+	conn, err := d.dial(ctx, network, target)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{}
+	if d.tlsConfig != nil {
+		cfg = d.tlsConfig.Clone()
+	}
+	if alias.ServerName != "" {
+		cfg.ServerName = alias.ServerName
+	} else if cfg.ServerName == "" {
+		cfg.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+	if len(cfg.NextProtos) == 0 {
+		cfg.NextProtos = d.nextProto
+	}
+
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
@@ -0,0 +1,111 @@
+package colly
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollectorConfig_SetHostAlias(t *testing.T) {
+	c := &CollectorConfig{}
+
+	tests := []struct {
+		host    string
+		target  string
+		want    map[string]HostAlias
+		wantErr bool
+	}{
+		{host: "Example.com", target: "staging.internal:8443", want: map[string]HostAlias{"example.com": {Target: "staging.internal:8443"}}},
+		{host: "example.com:80", target: "127.0.0.1", want: map[string]HostAlias{"example.com": {Target: "staging.internal:8443"}, "example.com:80": {Target: "127.0.0.1"}}},
+		{host: "example.com", target: "", want: map[string]HostAlias{"example.com:80": {Target: "127.0.0.1"}}},
+		{host: "", target: "127.0.0.1", wantErr: true},
+		{host: "example.com", target: "http://127.0.0.1/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host+"="+tt.target, func(t *testing.T) {
+			err := c.SetHostAlias(tt.host, tt.target)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidHostAlias) {
+					t.Errorf("SetHostAlias() error = %v, want %v", err, ErrInvalidHostAlias)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(c.HostAliases) != len(tt.want) {
+				t.Fatalf("HostAliases = %v, want %v", c.HostAliases, tt.want)
+			}
+			for host, alias := range tt.want {
+				if c.HostAliases[host] != alias {
+					t.Errorf("HostAliases[%q] = %+v, want %+v", host, c.HostAliases[host], alias)
+				}
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollectorConfig_aliasTransport(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Host)
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	tlsSrv := httptest.NewTLSServer(handler)
+	defer tlsSrv.Close()
+
+	// The certificate of the test server is valid for example.com
+	trusted := tlsSrv.Client().Transport.(*http.Transport)
+
+	tests := []struct {
+		name    string
+		base    *http.Transport
+		aliases map[string]HostAlias
+		url     string
+		wantErr bool
+	}{
+		{name: "plain", aliases: map[string]HostAlias{"example.com": {Target: srv.Listener.Addr().String()}}, url: "http://example.com/"},
+		{name: "port", aliases: map[string]HostAlias{"example.com:8080": {Target: srv.Listener.Addr().String()}}, url: "http://EXAMPLE.com:8080/"},
+		{name: "tls", base: trusted, aliases: map[string]HostAlias{"example.com": {Target: tlsSrv.Listener.Addr().String()}}, url: "https://example.com/"},
+		{name: "server name", base: trusted, aliases: map[string]HostAlias{"staging.test": {Target: tlsSrv.Listener.Addr().String(), ServerName: "example.com"}}, url: "https://staging.test/"},
+		{name: "invalid certificate", base: trusted, aliases: map[string]HostAlias{"staging.test": {Target: tlsSrv.Listener.Addr().String()}}, url: "https://staging.test/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &CollectorConfig{HostAliases: tt.aliases}
+			clt := &http.Client{Transport: c.aliasTransport(tt.base)}
+
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			resp, err := clt.Do(req)
+			if tt.wantErr {
+				if err == nil {
+					resp.Body.Close()
+					t.Error("Do() should fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if body, _ := io.ReadAll(resp.Body); string(body) != req.URL.Host {
+				t.Errorf("Host = %q, want %q", body, req.URL.Host)
+			}
+		})
+	}
+
+	if tr := (&CollectorConfig{}).aliasTransport(trusted); tr != trusted {
+		t.Error("aliasTransport() copied the transport without aliases")
+	}
+}
@@ -0,0 +1,148 @@
+package colly
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// http3Fallback sends the HTTPS requests over HTTP/3 and falls back to the TCP transport
+// if HTTP/3 fails, e.g. a firewall drops the UDP packets. The hosts failing over HTTP/3
+// are sent over TCP until HTTP3_RETRY_INTERVAL has elapsed.
+type http3Fallback struct {
+	h3     http.RoundTripper
+	tcp    http.RoundTripper
+	lock   *sync.Mutex
+	broken map[string]time.Time // time of the last HTTP/3 failure by host
+}
+
+// ------------------------------------------------------------------------
+
+// HTTP3_RETRY_INTERVAL is the duration after a failure before HTTP/3 is tried again with a host.
+const HTTP3_RETRY_INTERVAL = 10 * time.Minute
+
+// ------------------------------------------------------------------------
+
+// newHTTP3Fallback returns a pointer to a newly created HTTP/3 transport with a TCP fallback.
+// A nil TCP transport falls back to the default transport.
+func newHTTP3Fallback(h3 http.RoundTripper, tcp http.RoundTripper) *http3Fallback {
+	if tcp == nil {
+		tcp = http.DefaultTransport
+	}
+
+	return &http3Fallback{
+		h3:     h3,
+		tcp:    tcp,
+		lock:   &sync.Mutex{},
+		broken: map[string]time.Time{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// RoundTrip executes a single HTTP transaction over HTTP/3, or over TCP if HTTP/3 fails.
+// The requests with a body are only retried over TCP if the body can be replayed.
+func (t *http3Fallback) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	if req.URL.Scheme != "https" || t.isBroken(host, time.Now()) {
+		return t.tcp.RoundTrip(req)
+	}
+
+	// The HTTP/3 transport doesn't report to the client trace, the timings are taken here
+	trace := httptrace.ContextClientTrace(req.Context())
+	if trace != nil && trace.GetConn != nil {
+		hostPort := host
+		if req.URL.Port() == "" {
+			hostPort = net.JoinHostPort(req.URL.Hostname(), "443")
+		}
+		trace.GetConn(hostPort)
+	}
+
+	resp, err := t.h3.RoundTrip(req)
+	if err == nil {
+		if trace != nil && trace.GotFirstResponseByte != nil {
+			trace.GotFirstResponseByte()
+		}
+		return resp, nil
+	}
+	if req.Context().Err() != nil {
+		return nil, err
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, err
+		}
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+
+	t.markBroken(host, time.Now())
+
+	return t.tcp.RoundTrip(req)
+}
+
+// ------------------------------------------------------------------------
+
+// CloseIdleConnections closes the idle connections of both transports.
+func (t *http3Fallback) CloseIdleConnections() {
+	type closeIdler interface {
+		CloseIdleConnections()
+	}
+
+	if c, ok := t.h3.(closeIdler); ok {
+		c.CloseIdleConnections()
+	}
+	if c, ok := t.tcp.(closeIdler); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// isBroken returns true if HTTP/3 failed with the host within the retry interval.
+func (t *http3Fallback) isBroken(host string, now time.Time) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	failed, present := t.broken[host]
+	if !present {
+		return false
+	}
+	if now.Sub(failed) >= HTTP3_RETRY_INTERVAL {
+		delete(t.broken, host)
+		return false
+	}
+
+	return true
+}
+
+// markBroken records an HTTP/3 failure of the host.
+func (t *http3Fallback) markBroken(host string, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.broken[host] = now
+}
+
+// ------------------------------------------------------------------------
+
+// traceProtocol reports the protocol of the response to the tracer of the request if it is a ProtocolTracer.
+// The response is a fallback if HTTP/3 was requested but the response was received over another protocol.
+func traceProtocol(req *Request, resp *http.Response, rules ProtocolRules) {
+	pt, ok := req.Tracer.(ProtocolTracer)
+	if !ok {
+		return
+	}
+
+	fallback := rules.HTTP3 && req.Req.URL.Scheme == "https" && resp.ProtoMajor != 3
+	pt.TraceProtocol(resp.Proto, fallback)
+}
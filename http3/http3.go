@@ -0,0 +1,69 @@
+// Package http3 implements the HTTP/3 (QUIC) transport of the collectors, see CollectorConfig.HTTP3Transport.
+package http3
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	quichttp3 "github.com/quic-go/quic-go/http3"
+)
+
+// ------------------------------------------------------------------------
+
+// Transport is an HTTP/3 round tripper. The connections are kept by host and reused by the requests.
+type Transport struct {
+	rt *quichttp3.Transport
+}
+
+// ------------------------------------------------------------------------
+
+// DEFAULT_HANDSHAKE_TIMEOUT is the handshake timeout of the transports created with 0 timeout.
+// It is kept short, so the requests of the hosts blocking UDP fall back to TCP quickly.
+const DEFAULT_HANDSHAKE_TIMEOUT = 3 * time.Second
+
+// ------------------------------------------------------------------------
+
+// NewTransport returns a pointer to a newly created HTTP/3 transport.
+// The TLS configuration is cloned, nil uses the default configuration.
+// The handshake timeout limits the connection setup, 0 means DEFAULT_HANDSHAKE_TIMEOUT.
+func NewTransport(tlsConfig *tls.Config, handshakeTimeout time.Duration) *Transport {
+	if tlsConfig != nil {
+		tlsConfig = tlsConfig.Clone()
+	}
+	if handshakeTimeout <= 0 {
+		handshakeTimeout = DEFAULT_HANDSHAKE_TIMEOUT
+	}
+
+	return &Transport{
+		rt: &quichttp3.Transport{
+			TLSClientConfig: tlsConfig,
+			QUICConfig: &quic.Config{
+				HandshakeIdleTimeout: handshakeTimeout,
+			},
+		},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// RoundTrip executes a single HTTP transaction over HTTP/3.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.rt.RoundTrip(req)
+}
+
+// ------------------------------------------------------------------------
+
+// CloseIdleConnections closes the idle QUIC connections of the transport.
+// The connections are reopened by the next requests.
+func (t *Transport) CloseIdleConnections() {
+	t.rt.CloseIdleConnections()
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the QUIC connections of the transport.
+func (t *Transport) Close() error {
+	return t.rt.Close()
+}
@@ -0,0 +1,60 @@
+package http3
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	quichttp3 "github.com/quic-go/quic-go/http3"
+)
+
+// ------------------------------------------------------------------------
+
+func TestTransport_RoundTrip(t *testing.T) {
+	// The certificate of a TLS test server is reused by the HTTP/3 server
+	certSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer certSrv.Close()
+	cert := certSrv.TLS.Certificates[0]
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("UDP is not available: %v", err)
+	}
+	srv := &quichttp3.Server{
+		TLSConfig: quichttp3.ConfigureTLSConfig(&tls.Config{Certificates: []tls.Certificate{cert}}),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Write([]byte(r.Proto + " " + string(body)))
+		}),
+	}
+	go srv.Serve(conn)
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(certSrv.Certificate())
+	tr := NewTransport(&tls.Config{RootCAs: pool}, time.Second)
+	defer tr.Close()
+
+	client := &http.Client{Transport: tr, Timeout: 5 * time.Second}
+	url := "https://" + conn.LocalAddr().String() + "/"
+	for i := 0; i < 2; i++ {
+		resp, err := client.Post(url, "text/plain", strings.NewReader("hello"))
+		if err != nil {
+			t.Fatalf("RoundTrip() error = %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "HTTP/3.0 hello" {
+			t.Errorf("response = %q, want HTTP/3.0 hello", body)
+		}
+
+		// The next request opens a new connection
+		tr.CloseIdleConnections()
+	}
+}
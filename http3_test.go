@@ -0,0 +1,150 @@
+package colly
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestHTTP3Fallback_RoundTrip(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Proto + " " + string(body)))
+	}))
+	defer srv.Close()
+
+	h3Calls := 0
+	h3Err := errors.New("udp blocked")
+	h3 := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		h3Calls++
+		if h3Err != nil {
+			return nil, h3Err
+		}
+		return &http.Response{
+			Proto:      "HTTP/3.0",
+			ProtoMajor: 3,
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("h3")),
+			Request:    req,
+		}, nil
+	})
+	tr := newHTTP3Fallback(h3, srv.Client().Transport)
+
+	send := func(body string) (*http.Response, string) {
+		t.Helper()
+		req, _ := http.NewRequest("POST", srv.URL, strings.NewReader(body))
+		resp, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp, string(b)
+	}
+
+	// The failing request is replayed over TCP with its body
+	if resp, body := send("payload"); resp.ProtoMajor != 1 || body != "HTTP/1.1 payload" {
+		t.Errorf("fallback response = %s %q, want HTTP/1.1 with the body", resp.Proto, body)
+	}
+
+	// The broken host is not tried again within the retry interval
+	h3Err = nil
+	if resp, _ := send(""); resp.ProtoMajor != 1 || h3Calls != 1 {
+		t.Errorf("broken host response = %s after %d HTTP/3 calls, want HTTP/1.1 after 1", resp.Proto, h3Calls)
+	}
+
+	tr.markBroken(strings.TrimPrefix(srv.URL, "https://"), time.Now().Add(-HTTP3_RETRY_INTERVAL))
+	if resp, body := send(""); resp.ProtoMajor != 3 || body != "h3" {
+		t.Errorf("retried host response = %s %q, want HTTP/3", resp.Proto, body)
+	}
+
+	// The bodies that can't be replayed are not sent twice
+	h3Err = errors.New("stream reset")
+	tr = newHTTP3Fallback(h3, srv.Client().Transport)
+	req, _ := http.NewRequest("POST", srv.URL, io.NopCloser(strings.NewReader("once")))
+	if _, err := tr.RoundTrip(req); !errors.Is(err, h3Err) {
+		t.Errorf("RoundTrip() error = %v, want %v", err, h3Err)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestHTTP3Fallback_trace(t *testing.T) {
+	h3 := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{Proto: "HTTP/3.0", ProtoMajor: 3, Body: http.NoBody, Request: req}, nil
+	})
+	tr := newHTTP3Fallback(h3, nil)
+
+	var hostPort string
+	var firstByte bool
+	trace := &httptrace.ClientTrace{
+		GetConn:              func(hp string) { hostPort = hp },
+		GotFirstResponseByte: func() { firstByte = true },
+	}
+
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	if _, err := tr.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if hostPort != "example.com:443" || !firstByte {
+		t.Errorf("trace = %q, %v, want example.com:443, true", hostPort, firstByte)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestClient_downgradeHTTP3(t *testing.T) {
+	h3 := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("not used")
+	})
+	req := &Request{Req: httptest.NewRequest("GET", "https://example.com/", nil)}
+	base := &http.Client{}
+
+	c := &Client{
+		lock:       &sync.RWMutex{},
+		downgraded: map[downgradeKey]*http.Client{},
+	}
+	if clt := c.downgrade(base, req, ProtocolRules{HTTP3: true}); clt != base {
+		t.Error("downgrade() without an HTTP/3 transport returned a new client")
+	}
+
+	c.http3 = h3
+	clt := c.downgrade(base, req, ProtocolRules{HTTP3: true})
+	if _, ok := clt.Transport.(*http3Fallback); !ok {
+		t.Fatalf("downgrade() transport = %T, want *http3Fallback", clt.Transport)
+	}
+
+	// Custom round trippers are kept as the fallback
+	custom := &http.Client{Transport: h3}
+	clt = c.downgrade(custom, req, ProtocolRules{HTTP3: true})
+	if tr, ok := clt.Transport.(*http3Fallback); !ok || tr.tcp == nil {
+		t.Errorf("downgrade() of a custom round tripper transport = %T, want *http3Fallback", clt.Transport)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestTraceProtocol(t *testing.T) {
+	tracer := NewSimpleTracer()
+	req := &Request{Req: httptest.NewRequest("GET", "https://example.com/", nil), Tracer: tracer}
+
+	traceProtocol(req, &http.Response{Proto: "HTTP/2.0", ProtoMajor: 2}, ProtocolRules{HTTP3: true})
+	if tracer.protocol != "HTTP/2.0" || !tracer.fallback {
+		t.Errorf("traced = %s, %v, want HTTP/2.0 fallback", tracer.protocol, tracer.fallback)
+	}
+
+	traceProtocol(req, &http.Response{Proto: "HTTP/3.0", ProtoMajor: 3}, ProtocolRules{HTTP3: true})
+	if tracer.protocol != "HTTP/3.0" || tracer.fallback {
+		t.Errorf("traced = %s, %v, want HTTP/3.0 without fallback", tracer.protocol, tracer.fallback)
+	}
+}
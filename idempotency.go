@@ -0,0 +1,80 @@
+package colly
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// ------------------------------------------------------------------------
+
+// IDEMPOTENCY_KEY_HEADER is the request header carrying the idempotency key.
+const IDEMPOTENCY_KEY_HEADER = "Idempotency-Key"
+
+// ------------------------------------------------------------------------
+
+// NewIdempotencyKey returns a new random (version 4) UUID to be used as an idempotency key.
+func NewIdempotencyKey() string {
+	var u [16]byte
+	_, _ = rand.Read(u[:])
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+// ------------------------------------------------------------------------
+
+// SetIdempotencyKey sets the idempotency key and the Idempotency-Key header of the request.
+// A blank key removes both.
+func (r *Request) SetIdempotencyKey(key string) {
+	r.IdempotencyKey = key
+
+	if r.Req == nil {
+		return
+	}
+
+	if key == "" {
+		r.Req.Header.Del(IDEMPOTENCY_KEY_HEADER)
+	} else {
+		r.Req.Header.Set(IDEMPOTENCY_KEY_HEADER, key)
+	}
+}
+
+// ensureIdempotencyKey keeps the idempotency key and the header of the request in sync.
+// A retried request adopts the key of its header, a new POST or PATCH request gets
+// a new key if the collector generates them.
+func (r *Request) ensureIdempotencyKey() {
+	if r.Req == nil {
+		return
+	}
+
+	if r.IdempotencyKey != "" {
+		r.Req.Header.Set(IDEMPOTENCY_KEY_HEADER, r.IdempotencyKey)
+		return
+	}
+
+	if key := r.Req.Header.Get(IDEMPOTENCY_KEY_HEADER); key != "" {
+		r.IdempotencyKey = key
+		return
+	}
+
+	if r.collector == nil || r.collector.Config == nil || !r.collector.Config.IdempotencyKeys {
+		return
+	}
+
+	if r.Req.Method == "POST" || r.Req.Method == "PATCH" {
+		r.SetIdempotencyKey(NewIdempotencyKey())
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// IdempotencyKey returns the idempotency key of the request, to reconcile the response
+// with the mutation. It returns a blank string if the request had no key.
+func (r *Response) IdempotencyKey() string {
+	if r.Request == nil {
+		return ""
+	}
+
+	return r.Request.IdempotencyKey
+}
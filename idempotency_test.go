@@ -0,0 +1,64 @@
+package colly
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestNewIdempotencyKey(t *testing.T) {
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	a, b := NewIdempotencyKey(), NewIdempotencyKey()
+	if !re.MatchString(a) || a == b {
+		t.Errorf("NewIdempotencyKey() = %q, %q, want different UUIDs", a, b)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestRequest_ensureIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		enabled bool
+		header  string
+		key     string
+		want    string // "new" means a generated key
+	}{
+		{"disabled", "POST", false, "", "", ""},
+		{"POST", "POST", true, "", "", "new"},
+		{"PATCH", "PATCH", true, "", "", "new"},
+		{"GET", "GET", true, "", "", ""},
+		{"retried header", "POST", true, "abc", "", "abc"},
+		{"persisted key", "POST", false, "", "def", "def"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &Request{
+				Req:            &http.Request{Method: tt.method, Header: http.Header{}},
+				IdempotencyKey: tt.key,
+				collector:      &Collector{Config: &CollectorConfig{IdempotencyKeys: tt.enabled}},
+			}
+			if tt.header != "" {
+				req.Req.Header.Set(IDEMPOTENCY_KEY_HEADER, tt.header)
+			}
+
+			req.ensureIdempotencyKey()
+			first := req.IdempotencyKey
+			req.ensureIdempotencyKey()
+
+			resp := &Response{Request: req}
+			got := resp.IdempotencyKey()
+			if got != first || req.Req.Header.Get(IDEMPOTENCY_KEY_HEADER) != got {
+				t.Errorf("key = %q, then %q, header %q", first, got, req.Req.Header.Get(IDEMPOTENCY_KEY_HEADER))
+			}
+			if (tt.want == "new" && got == "") || (tt.want != "new" && got != tt.want) {
+				t.Errorf("IdempotencyKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
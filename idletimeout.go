@@ -0,0 +1,102 @@
+package colly
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// idleWatch cancels a request when no data was received for the idle timeout.
+// The timer is reset by the response headers and by every received chunk of the body.
+type idleWatch struct {
+	timeout time.Duration
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	expired atomic.Bool // set when the request was cancelled by the timer
+}
+
+// idleBody is a response body resetting the idle timer on every received chunk.
+type idleBody struct {
+	io.ReadCloser
+	watch *idleWatch
+}
+
+// ------------------------------------------------------------------------
+
+// watchIdle arms the idle timeout of the request, Request.IdleTimeout or the default timeout.
+// The request gets a cancellable context. It returns nil if the request has no idle timeout.
+func (r *Request) watchIdle(defTimeout time.Duration) *idleWatch {
+	timeout := r.IdleTimeout
+	if timeout <= 0 {
+		timeout = defTimeout
+	}
+	if timeout <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(r.Req.Context())
+	r.Req = r.Req.WithContext(ctx)
+
+	w := &idleWatch{
+		timeout: timeout,
+		cancel:  cancel,
+	}
+	w.timer = time.AfterFunc(timeout, func() {
+		w.expired.Store(true)
+		cancel()
+	})
+
+	return w
+}
+
+// ------------------------------------------------------------------------
+
+// touch resets the idle timer after receiving data.
+func (w *idleWatch) touch() {
+	if w != nil && !w.expired.Load() {
+		w.timer.Reset(w.timeout)
+	}
+}
+
+// stop releases the timer and the context of the request.
+func (w *idleWatch) stop() {
+	if w != nil {
+		w.timer.Stop()
+		w.cancel()
+	}
+}
+
+// wrap returns the response body resetting the idle timer.
+func (w *idleWatch) wrap(body io.ReadCloser) io.ReadCloser {
+	if w == nil {
+		return body
+	}
+
+	return &idleBody{ReadCloser: body, watch: w}
+}
+
+// err returns the error of a request cancelled by the idle timer as an error
+// of the ErrIdleTimeout class, or the error itself otherwise.
+func (w *idleWatch) err(err error) error {
+	if w == nil || err == nil || !w.expired.Load() {
+		return err
+	}
+
+	return fmt.Errorf("%w: no data for %s: %v", ErrIdleTimeout, w.timeout, err)
+}
+
+// ------------------------------------------------------------------------
+
+// Read implements the io.Reader interface.
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.watch.touch()
+	}
+
+	return n, err
+}
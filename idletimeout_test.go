@@ -0,0 +1,76 @@
+package colly
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestRequest_watchIdle(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pause, _ := time.ParseDuration(r.URL.Query().Get("pause"))
+		for i := 0; i < 5; i++ {
+			w.Write([]byte("chunk\n"))
+			w.(http.Flusher).Flush()
+			select {
+			case <-time.After(pause):
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		pause   string
+		timeout time.Duration
+		wantErr error
+	}{
+		{"no timeout", "20ms", 0, nil},
+		{"slow stream within idle timeout", "40ms", 100 * time.Millisecond, nil},
+		{"stalled stream", "300ms", 100 * time.Millisecond, ErrIdleTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &Request{Req: httptest.NewRequest("GET", ts.URL+"/?pause="+tt.pause, nil)}
+			req.Req.RequestURI = ""
+
+			idle := req.watchIdle(tt.timeout)
+			defer idle.stop()
+
+			resp, err := http.DefaultClient.Do(req.Req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			defer resp.Body.Close()
+			idle.touch()
+
+			_, err = io.ReadAll(idle.wrap(resp.Body))
+			err = idle.err(err)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("read error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequest_watchIdle_override(t *testing.T) {
+	req := &Request{
+		Req:         httptest.NewRequest("GET", "http://example.com/", nil),
+		IdleTimeout: time.Minute,
+	}
+
+	idle := req.watchIdle(time.Second)
+	defer idle.stop()
+
+	if idle == nil || idle.timeout != time.Minute {
+		t.Errorf("watchIdle() timeout = %v, want %v", idle.timeout, time.Minute)
+	}
+}
@@ -0,0 +1,180 @@
+package colly
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// retryKey is the context key type of the values used for the request retries.
+type retryKey uint8
+
+// InFlightRequest is a request that was sent and not finished yet.
+type InFlightRequest struct {
+	ID      uint32        `json:"id" bson:"id"`                   // ID is the identifier of the request.
+	Method  string        `json:"method" bson:"method,omitempty"` // Method is the HTTP method of the request.
+	URL     string        `json:"url" bson:"url"`                 // URL is the requested URL.
+	Host    string        `json:"host" bson:"host"`               // Host is the host of the URL.
+	Started time.Time     `json:"started" bson:"started"`         // Started is when the request passed the OnRequest callbacks.
+	Elapsed time.Duration `json:"elapsed" bson:"elapsed"`         // Elapsed is the time since the start at the time of the snapshot.
+	Attempt uint          `json:"attempt" bson:"attempt"`         // Attempt is 1 for the first attempt, 2 for the first retry, etc.
+}
+
+// ConcurrencySnapshot is a point-in-time view of the in-flight requests.
+type ConcurrencySnapshot struct {
+	Taken    time.Time         `json:"taken" bson:"taken"`           // Taken is the time of the snapshot.
+	InFlight []InFlightRequest `json:"in_flight" bson:"in_flight"`   // InFlight are the in-flight requests, the oldest first.
+	Hosts    map[string]int    `json:"hosts" bson:"hosts,omitempty"` // Hosts is the number of the in-flight requests by host.
+}
+
+// inFlight keeps track of the in-flight requests.
+type inFlight struct {
+	requests map[uint32]*inFlightEntry // in-flight requests by ID
+	lock     *sync.Mutex
+}
+
+// inFlightEntry is a tracked in-flight request.
+type inFlightEntry struct {
+	info InFlightRequest
+	stop func() // releases the watchdog of the request, nil if not watched
+}
+
+// ------------------------------------------------------------------------
+
+const (
+	// RetryCountKey is the context key for the number of times the request was retried by Request.Retry.
+	RetryCountKey retryKey = iota
+	// StuckRetryKey is the context key set on the request requeued after it was stuck, see CollectorConfig.RequeueStuck.
+	StuckRetryKey
+	// AttemptHistoryKey is the context key for the outcomes of the earlier attempts of the request, see Response.Attempts.
+	AttemptHistoryKey
+)
+
+// ------------------------------------------------------------------------
+
+// newInFlight returns a pointer to a newly created in-flight request tracker.
+func newInFlight() *inFlight {
+	return &inFlight{
+		requests: map[uint32]*inFlightEntry{},
+		lock:     &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Snapshot returns the requests of the collector that are in flight, with the number
+// of the in-flight requests by host. It is safe to call Snapshot while the collector is running.
+func (c *Collector) Snapshot() ConcurrencySnapshot {
+	return c.inFlight.snapshot(time.Now())
+}
+
+// ------------------------------------------------------------------------
+
+// Attempt returns the attempt number of the request: 1 for the first attempt, incremented by
+// every retry, including the retries of Request.Retry, the ones of the truncated responses and
+// the requeued stuck requests.
+func (r *Request) Attempt() uint {
+	attempt := 1 + r.TruncatedRetries()
+	if r.Ctx != nil {
+		if n, ok := (*r.Ctx).Value(RetryCountKey).(uint); ok {
+			attempt += n
+		}
+		if requeued, _ := (*r.Ctx).Value(StuckRetryKey).(bool); requeued {
+			attempt++
+		}
+	}
+
+	return attempt
+}
+
+// ------------------------------------------------------------------------
+
+// start registers the request as in flight. The optional stop function is called
+// when the request is finished.
+func (f *inFlight) start(r *Request, now time.Time, stop func()) {
+	if r == nil || r.Req == nil {
+		return
+	}
+
+	e := &inFlightEntry{
+		info: InFlightRequest{
+			ID:      r.ID,
+			Method:  r.Req.Method,
+			URL:     r.Req.URL.String(),
+			Host:    r.Req.URL.Host,
+			Started: now,
+			Attempt: r.Attempt(),
+		},
+		stop: stop,
+	}
+
+	f.lock.Lock()
+	f.requests[r.ID] = e
+	f.lock.Unlock()
+}
+
+// finish removes the request from the in-flight requests.
+func (f *inFlight) finish(r *Request) {
+	if r == nil {
+		return
+	}
+
+	f.lock.Lock()
+	e, present := f.requests[r.ID]
+	delete(f.requests, r.ID)
+	f.lock.Unlock()
+
+	if present && e.stop != nil {
+		e.stop()
+	}
+}
+
+// snapshot returns the copies of the in-flight requests, the oldest first.
+func (f *inFlight) snapshot(now time.Time) ConcurrencySnapshot {
+	s := ConcurrencySnapshot{
+		Taken: now,
+		Hosts: map[string]int{},
+	}
+
+	f.lock.Lock()
+	s.InFlight = make([]InFlightRequest, 0, len(f.requests))
+	for _, e := range f.requests {
+		s.InFlight = append(s.InFlight, e.info)
+	}
+	f.lock.Unlock()
+
+	sort.Slice(s.InFlight, func(i, j int) bool {
+		if s.InFlight[i].Started.Equal(s.InFlight[j].Started) {
+			return s.InFlight[i].ID < s.InFlight[j].ID
+		}
+		return s.InFlight[i].Started.Before(s.InFlight[j].Started)
+	})
+
+	for i := range s.InFlight {
+		s.InFlight[i].Elapsed = now.Sub(s.InFlight[i].Started)
+		s.Hosts[s.InFlight[i].Host]++
+	}
+
+	return s
+}
+
+// ------------------------------------------------------------------------
+
+// retryContext returns the context of the next attempt of the request.
+func (r *Request) retryContext() *context.Context {
+	parent := context.Background()
+	if r.Ctx != nil {
+		parent = *r.Ctx
+	}
+
+	var count uint
+	if n, ok := parent.Value(RetryCountKey).(uint); ok {
+		count = n
+	}
+	ctx := context.WithValue(parent, RetryCountKey, count+1)
+
+	return &ctx
+}
@@ -0,0 +1,70 @@
+package colly
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_inFlight(t *testing.T) {
+	f := newInFlight()
+	now := time.Now()
+	stopped := false
+
+	newReq := func(id uint32, rawURL string) *Request {
+		req, _ := http.NewRequest("GET", rawURL, nil)
+		return &Request{ID: id, Req: req}
+	}
+
+	first := newReq(1, "http://example.com/a")
+	second := newReq(2, "http://example.com:8080/b")
+	third := newReq(3, "http://example.com/c")
+
+	f.start(first, now, nil)
+	f.start(second, now.Add(time.Second), nil)
+	f.start(third, now.Add(2*time.Second), func() { stopped = true })
+	f.finish(third)
+	if !stopped {
+		t.Error("finish() didn't call the stop function")
+	}
+
+	s := f.snapshot(now.Add(3 * time.Second))
+	if len(s.InFlight) != 2 {
+		t.Fatalf("snapshot() = %+v, want 2 requests", s.InFlight)
+	}
+
+	want := InFlightRequest{ID: 1, Method: "GET", URL: "http://example.com/a", Host: "example.com", Started: now, Elapsed: 3 * time.Second, Attempt: 1}
+	if s.InFlight[0] != want {
+		t.Errorf("InFlight[0] = %+v, want %+v", s.InFlight[0], want)
+	}
+	if s.InFlight[1].ID != 2 || s.InFlight[1].Elapsed != 2*time.Second {
+		t.Errorf("InFlight[1] = %+v", s.InFlight[1])
+	}
+	if s.Hosts["example.com"] != 1 || s.Hosts["example.com:8080"] != 1 {
+		t.Errorf("Hosts = %v", s.Hosts)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestRequest_Attempt(t *testing.T) {
+	r := &Request{}
+	if n := r.Attempt(); n != 1 {
+		t.Errorf("Attempt() = %d, want 1", n)
+	}
+
+	r.Ctx = r.retryContext()
+	r.Ctx = r.retryContext()
+	if n := r.Attempt(); n != 3 {
+		t.Errorf("Attempt() after 2 retries = %d, want 3", n)
+	}
+
+	ctx := context.WithValue(*r.Ctx, TruncatedRetryKey, uint(1))
+	r.Ctx = &ctx
+	if n := r.Attempt(); n != 4 {
+		t.Errorf("Attempt() with a truncated retry = %d, want 4", n)
+	}
+}
@@ -98,18 +98,26 @@ func (q *jobQueue) IsEmpty() bool {
 // ------------------------------------------------------------------------
 
 // Push appends a job at the end/tail of the queue.
+// Jobs with priority are ordered by their priority if the storage is a priority queue.
 func (q *jobQueue) Push(job Job) error {
 	rdr, err := job.Encode()
 	if err != nil {
 		return err
 	}
 
+	if pj, ok := job.(PriorityJob); ok {
+		if pq, ok := q.stg.(PriorityQueue); ok {
+			return pq.PushPriority(q.id, pj.JobPriority(), rdr)
+		}
+	}
+
 	return q.stg.Push(q.id, rdr)
 }
 
 // ------------------------------------------------------------------------
 
-// Pop removes and returns the oldest job in the queue.
+// Pop removes and returns the oldest job in the queue. The jobs are returned even if they are not due,
+// submit the requests with Collector.Schedule to hold them until their NotBefore time.
 func (q *jobQueue) Pop() (any, error) {
 	rdr, err := q.stg.Pop(q.id)
 	if err != nil {
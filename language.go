@@ -0,0 +1,154 @@
+package colly
+
+import (
+	"colly/filters"
+	"context"
+	"regexp"
+	"strings"
+)
+
+// ------------------------------------------------------------------------
+
+// languageKey is the context key type of the page language.
+type languageKey uint8
+
+// ------------------------------------------------------------------------
+
+const (
+	// LanguageKey is the context key for the language of the page where the request was found.
+	LanguageKey languageKey = iota
+)
+
+// languageSampleSize is the maximum number of body bytes used for stopword based detection.
+const languageSampleSize = 64 * 1024
+
+// ------------------------------------------------------------------------
+
+var (
+	htmlLangRegexp = regexp.MustCompile(`(?is)<html\s[^>]*\blang\s*=\s*["']?([a-zA-Z]{2,3}(?:[-_][a-zA-Z0-9]+)*)`)
+	htmlTagRegexp  = regexp.MustCompile(`(?s)<script.*?</script>|<style.*?</style>|<[^>]*>`)
+	wordRegexp     = regexp.MustCompile(`\pL+`)
+)
+
+// stopwords are the most frequent words of the detectable languages.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "was", "on", "are", "this", "you"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "den", "ein", "eine", "auf", "sich", "auch", "dem", "ich"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "que", "dans", "pour", "pas", "sur", "qui", "du", "au"},
+	"es": {"el", "los", "las", "que", "del", "una", "por", "con", "para", "es", "como", "pero", "sus", "al", "muy"},
+	"it": {"il", "che", "della", "per", "sono", "una", "non", "gli", "con", "del", "nel", "alla", "anche", "come", "questo"},
+	"pt": {"os", "que", "não", "uma", "para", "com", "por", "mais", "das", "dos", "como", "ao", "foi", "seu", "sua"},
+	"nl": {"de", "het", "een", "van", "en", "dat", "niet", "zijn", "op", "voor", "met", "ook", "maar", "wordt", "deze"},
+}
+
+// stopwordIndex maps the stopwords to their languages.
+var stopwordIndex = func() map[string][]string {
+	index := map[string][]string{}
+	for lang, words := range stopwords {
+		for _, w := range words {
+			index[w] = append(index[w], lang)
+		}
+	}
+
+	return index
+}()
+
+// ------------------------------------------------------------------------
+
+// DetectLanguage returns the primary language subtag of a textual response, e.g. "en".
+// It checks the Content-Language header and the lang attribute of the html element
+// first, then it guesses the language by counting the stopwords of the text.
+// It returns empty string if the language is unknown.
+func DetectLanguage(resp *Response) string {
+	if resp == nil || resp.Resp == nil {
+		return ""
+	}
+
+	if lang := filters.PrimaryLanguage(resp.Resp.Header.Get("Content-Language")); lang != "" {
+		return lang
+	}
+
+	contentType := resp.ContentType()
+	if len(resp.Body) == 0 || noTextualData(contentType) {
+		return ""
+	}
+
+	body := resp.Body
+	if len(body) > languageSampleSize {
+		body = body[:languageSampleSize]
+	}
+
+	if m := htmlLangRegexp.FindSubmatch(body); m != nil {
+		return filters.PrimaryLanguage(string(m[1]))
+	}
+
+	if strings.Contains(strings.ToLower(contentType), "html") {
+		body = htmlTagRegexp.ReplaceAll(body, []byte(" "))
+	}
+
+	return guessLanguage(string(body))
+}
+
+// guessLanguage returns the language with the most stopwords in the text.
+// At least three stopwords are needed to make a guess.
+func guessLanguage(text string) string {
+	scores := map[string]int{}
+	for _, w := range wordRegexp.FindAllString(strings.ToLower(text), -1) {
+		for _, lang := range stopwordIndex[w] {
+			scores[lang]++
+		}
+	}
+
+	best, bestScore := "", 2
+	for lang, score := range scores {
+		if score > bestScore || (score == bestScore && best != "" && lang < best) {
+			best, bestScore = lang, score
+		}
+	}
+
+	return best
+}
+
+// ------------------------------------------------------------------------
+
+// SourceLanguage returns the language of the page where the request was found.
+func (r *Request) SourceLanguage() string {
+	if r.Ctx == nil {
+		return ""
+	}
+
+	lang, _ := (*r.Ctx).Value(LanguageKey).(string)
+
+	return lang
+}
+
+// ------------------------------------------------------------------------
+
+// setLanguage detects the language of the response and stores it in the request context,
+// so the requests created from the response can be filtered by the page language.
+func (c *Collector) setLanguage(resp *Response) {
+	if !c.Config.DetectLanguage || resp.Request == nil {
+		return
+	}
+
+	resp.Language = DetectLanguage(resp)
+	if resp.Language == "" {
+		return
+	}
+
+	parent := context.Background()
+	if resp.Request.Ctx != nil {
+		parent = *resp.Request.Ctx
+	}
+	ctx := context.WithValue(parent, LanguageKey, resp.Language)
+	resp.Request.Ctx = &ctx
+}
+
+// languageExcluded returns true if the language of the response is excluded by a language filter.
+func (c *Collector) languageExcluded(resp *Response) bool {
+	if c.Config.Filter == nil || resp.Language == "" {
+		return false
+	}
+
+	return c.Config.Filter.matchScope(resp.Request, LANGUAGE_FILTER) != nil
+}
@@ -0,0 +1,66 @@
+package colly
+
+import (
+	"net/http"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+		want   string
+	}{
+		{
+			name:   "content language header",
+			header: http.Header{"Content-Language": {"de-AT, en"}},
+			body:   "<html><body>The quick brown fox</body></html>",
+			want:   "de",
+		},
+		{
+			name:   "html lang attribute",
+			header: http.Header{"Content-Type": {"text/html"}},
+			body:   `<!DOCTYPE html><html class="no-js" lang="fr-CA"><body>Hello</body></html>`,
+			want:   "fr",
+		},
+		{
+			name:   "english stopwords",
+			header: http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+			body:   "<html><body><p>This is the story of a fox and the dog that lived in the woods.</p></body></html>",
+			want:   "en",
+		},
+		{
+			name:   "spanish stopwords",
+			header: http.Header{"Content-Type": {"text/plain"}},
+			body:   "El perro y el gato viven en una casa con las flores para los niños.",
+			want:   "es",
+		},
+		{
+			name:   "stopwords in scripts are ignored",
+			header: http.Header{"Content-Type": {"text/html"}},
+			body:   "<html><script>var the = 'and of to is in that';</script><body>12345</body></html>",
+			want:   "",
+		},
+		{
+			name:   "binary content",
+			header: http.Header{"Content-Type": {"image/png"}},
+			body:   "the and of to is in that",
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &Response{
+				Resp: &http.Response{Header: tt.header},
+				Body: []byte(tt.body),
+			}
+			if got := DetectLanguage(resp); got != tt.want {
+				t.Errorf("DetectLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
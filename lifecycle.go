@@ -0,0 +1,202 @@
+package colly
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ------------------------------------------------------------------------
+
+// Lifecycle callback functions
+type (
+	LifecycleCallback    func(*Collector)                // LifecycleCallback is a type alias for OnStart, OnIdle and OnFinish callback functions.
+	StorageErrorCallback func(storage string, err error) // StorageErrorCallback is a type alias for OnStorageError callback functions.
+)
+
+// jobGroup is a wait group that keeps count of the running jobs of the collector.
+// The idle handler is called whenever the last running job is done,
+// the finish handler whenever Wait returns.
+type jobGroup struct {
+	wg       sync.WaitGroup
+	active   atomic.Int64 // number of running jobs
+	onIdle   func()       // called before the last job is marked as done
+	onFinish func()       // called after Wait returned
+}
+
+// ------------------------------------------------------------------------
+
+// Storage names of the storage error events
+const (
+	STORAGE_CACHE      = "cache"      // Response cache.
+	STORAGE_VISITS     = "visits"     // Visit storages and the shared visit registry.
+	STORAGE_FAILURES   = "failures"   // Failure journal.
+	STORAGE_VALIDATORS = "validators" // Cache validators of the conditional revisits.
+	STORAGE_ROBOTS     = "robots"     // Robots.txt cache.
+	STORAGE_ALIASES    = "aliases"    // Alias storage of the canonical pages.
+	STORAGE_HEALTH     = "health"     // Failure memory of the hosts.
+)
+
+// ------------------------------------------------------------------------
+
+// newJobGroup returns a pointer to a newly created job group.
+func newJobGroup(onIdle func(), onFinish func()) *jobGroup {
+	return &jobGroup{
+		onIdle:   onIdle,
+		onFinish: onFinish,
+	}
+}
+
+// Add adds delta to the number of running jobs.
+func (g *jobGroup) Add(delta int) {
+	g.active.Add(int64(delta))
+	g.wg.Add(delta)
+}
+
+// Done marks a job as done.
+func (g *jobGroup) Done() {
+	// The job is still counted by the wait group while the idle handler runs,
+	// so Wait doesn't return before the handler finished or scheduled new jobs.
+	if g.active.Add(-1) == 0 && g.onIdle != nil {
+		g.onIdle()
+	}
+	g.wg.Done()
+}
+
+// Wait blocks until all jobs are done.
+func (g *jobGroup) Wait() {
+	g.wg.Wait()
+
+	if g.onFinish != nil {
+		g.onFinish()
+	}
+}
+
+// Active returns the number of running jobs.
+func (g *jobGroup) Active() int {
+	return int(g.active.Load())
+}
+
+// ------------------------------------------------------------------------
+
+// OnStart is convenience method to register a function that will be executed
+// when the collector starts a run with its first request.
+// The position identifies the execution order.
+func (c *Collector) OnStart(fn LifecycleCallback, position ...int) {
+	c.Callbacks.Add(ON_START, NO_ARG, fn, position...)
+}
+
+// OnStartDetach removes a number of registered start callback functions.
+// If no position was given, all start callback functions will be removed.
+func (c *Collector) OnStartDetach(position ...int) {
+	c.Callbacks.Remove(ON_START, NO_ARG, position...)
+}
+
+// OnIdle is convenience method to register a function that will be executed
+// whenever an asynchronous collector runs out of jobs.
+// New requests may be submitted in the callback, e.g. from an external queue.
+// The position identifies the execution order.
+func (c *Collector) OnIdle(fn LifecycleCallback, position ...int) {
+	c.Callbacks.Add(ON_IDLE, NO_ARG, fn, position...)
+}
+
+// OnIdleDetach removes a number of registered idle callback functions.
+// If no position was given, all idle callback functions will be removed.
+func (c *Collector) OnIdleDetach(position ...int) {
+	c.Callbacks.Remove(ON_IDLE, NO_ARG, position...)
+}
+
+// OnFinish is convenience method to register a function that will be executed
+// when Wait returns after a run. A new request starts a new run.
+// The position identifies the execution order.
+func (c *Collector) OnFinish(fn LifecycleCallback, position ...int) {
+	c.Callbacks.Add(ON_FINISH, NO_ARG, fn, position...)
+}
+
+// OnFinishDetach removes a number of registered finish callback functions.
+// If no position was given, all finish callback functions will be removed.
+func (c *Collector) OnFinishDetach(position ...int) {
+	c.Callbacks.Remove(ON_FINISH, NO_ARG, position...)
+}
+
+// OnStorageError is convenience method to register a function that will be executed
+// when a storage of the collector fails. The storage is one of the STORAGE_* names.
+// The position identifies the execution order.
+func (c *Collector) OnStorageError(fn StorageErrorCallback, position ...int) {
+	c.Callbacks.Add(ON_STORAGE_ERROR, NO_ARG, fn, position...)
+}
+
+// OnStorageErrorDetach removes a number of registered storage error callback functions.
+// If no position was given, all storage error callback functions will be removed.
+func (c *Collector) OnStorageErrorDetach(position ...int) {
+	c.Callbacks.Remove(ON_STORAGE_ERROR, NO_ARG, position...)
+}
+
+// ------------------------------------------------------------------------
+
+// handleOnStart calls the start callbacks if the request is the first one of a run.
+func (c *Collector) handleOnStart() {
+	if !c.running.CompareAndSwap(false, true) {
+		return
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_INFO_LEVEL, "start", 0, map[string]string{})
+	}
+
+	c.callLifecycle(ON_START)
+}
+
+// handleOnIdle calls the idle callbacks of an asynchronous collector.
+func (c *Collector) handleOnIdle() {
+	if !c.Config.Async || !c.running.Load() {
+		return
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_DEBUG_LEVEL, "idle", 0, map[string]string{})
+	}
+
+	c.callLifecycle(ON_IDLE)
+}
+
+// handleOnFinish calls the finish callbacks if a run was started.
+func (c *Collector) handleOnFinish() {
+	if !c.running.CompareAndSwap(true, false) {
+		return
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_INFO_LEVEL, "finish", 0, map[string]string{})
+	}
+
+	c.callLifecycle(ON_FINISH)
+}
+
+// handleOnStorageError logs the storage error and calls the storage error callbacks.
+func (c *Collector) handleOnStorageError(storage string, err error) {
+	if err == nil {
+		return
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_ERR_LEVEL, "storage_error", 0, map[string]string{
+			"storage": storage,
+			"error":   err.Error(),
+		})
+	}
+
+	for _, fn := range c.Callbacks.GetArg(ON_STORAGE_ERROR, NO_ARG) {
+		if callback, ok := fn.(StorageErrorCallback); ok {
+			callback(storage, err)
+		}
+	}
+}
+
+// callLifecycle calls the lifecycle callbacks of the event.
+func (c *Collector) callLifecycle(event uint8) {
+	for _, fn := range c.Callbacks.GetArg(event, NO_ARG) {
+		if callback, ok := fn.(LifecycleCallback); ok {
+			callback(c)
+		}
+	}
+}
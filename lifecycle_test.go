@@ -0,0 +1,66 @@
+package colly
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_jobGroup(t *testing.T) {
+	var idle, finish int
+	g := newJobGroup(func() { idle++ }, func() { finish++ })
+
+	g.Add(2)
+	g.Done()
+	if idle != 0 || g.Active() != 1 {
+		t.Errorf("after the first job: idle = %d, active = %d, want 0, 1", idle, g.Active())
+	}
+
+	g.Done()
+	g.Wait()
+	if idle != 1 || finish != 1 || g.Active() != 0 {
+		t.Errorf("after the last job: idle = %d, finish = %d, active = %d, want 1, 1, 0", idle, finish, g.Active())
+	}
+}
+
+func TestCollector_lifecycle(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Async = true
+	c := NewCollector(cfg, nil)
+
+	events := []string{}
+	lock := &sync.Mutex{}
+	record := func(name string) LifecycleCallback {
+		return func(*Collector) {
+			lock.Lock()
+			events = append(events, name)
+			lock.Unlock()
+		}
+	}
+	c.OnStart(record("start"))
+	c.OnIdle(record("idle"))
+	c.OnFinish(record("finish"))
+	c.OnStorageError(func(storage string, err error) {
+		events = append(events, storage+": "+err.Error())
+	})
+
+	// Jobs before the first request don't belong to a run
+	c.wg.Add(1)
+	c.wg.Done()
+
+	c.handleOnStart()
+	c.handleOnStart()
+	c.wg.Add(1)
+	c.handleOnStorageError(STORAGE_CACHE, errors.New("disk full"))
+	c.wg.Done()
+	c.Wait()
+	c.Wait()
+
+	want := []string{"start", "cache: disk full", "idle", "finish"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
@@ -0,0 +1,145 @@
+package colly
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// rateLimiter paces the requests and limits their concurrency by bucket.
+type rateLimiter struct {
+	lock    *sync.Mutex
+	buckets map[string]*limitBucket
+}
+
+// limitBucket is the rate limit state of a key.
+type limitBucket struct {
+	lock  *sync.Mutex
+	slots chan struct{} // concurrent requests, nil means no limit
+	next  time.Time     // earliest start of the next request
+}
+
+// ------------------------------------------------------------------------
+
+// newRateLimiter returns a pointer to a newly created rate limiter.
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		lock:    &sync.Mutex{},
+		buckets: map[string]*limitBucket{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Acquire blocks until a request of the bucket can be started, keeping the delay between
+// the request starts and limiting the concurrent requests to maxThreads. It returns a function
+// to release the bucket after the request was finished. The maximum threads are set when the bucket is created.
+// If the context is done while waiting, the bucket is released and the error of the context is returned.
+func (l *rateLimiter) Acquire(ctx context.Context, key string, delay time.Duration, maxThreads uint) (func(), error) {
+	b := l.bucket(key, maxThreads)
+
+	if b.slots != nil {
+		select {
+		case b.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	release := func() {
+		if b.slots != nil {
+			<-b.slots
+		}
+	}
+
+	b.lock.Lock()
+	now := time.Now()
+	start := b.next
+	if start.Before(now) {
+		start = now
+	}
+	b.next = start.Add(delay)
+	b.lock.Unlock()
+
+	if err := sleepContext(ctx, start.Sub(now)); err != nil {
+		release()
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// bucket returns the bucket of the key, creating it if it doesn't exist.
+func (l *rateLimiter) bucket(key string, maxThreads uint) *limitBucket {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	b, present := l.buckets[key]
+	if !present {
+		b = &limitBucket{lock: &sync.Mutex{}}
+		if maxThreads > 0 {
+			b.slots = make(chan struct{}, maxThreads)
+		}
+		l.buckets[key] = b
+	}
+
+	return b
+}
+
+// ------------------------------------------------------------------------
+
+// sleepContext pauses the execution for the duration or until the context is done.
+// It returns the error of the context if the pause was cut short.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// LimitByHost is a LimitKeyCallback that throttles the requests by host.
+func LimitByHost(req *Request) string {
+	return req.Req.URL.Host
+}
+
+// LimitByIdentity is a LimitKeyCallback that throttles the requests by their session identity,
+// e.g. the auth token or the proxy bound to the identity. The host is used if the identity is blank.
+// See Request.SetIdentity.
+func LimitByIdentity(req *Request) string {
+	if identity := req.Identity(); identity != "" {
+		return "identity:" + identity
+	}
+
+	return LimitByHost(req)
+}
+
+// LimitByHeader returns a LimitKeyCallback that throttles the requests by the value of a header,
+// e.g. "Authorization", so multiple tokens against the same host are throttled independently.
+// The host is used if the header is missing. The header values are not kept in memory.
+func LimitByHeader(name string) LimitKeyCallback {
+	return func(req *Request) string {
+		val := req.Req.Header.Get(name)
+		if val == "" {
+			return LimitByHost(req)
+		}
+
+		sum := sha256.Sum256([]byte(val))
+
+		return "header:" + hex.EncodeToString(sum[:])
+	}
+}
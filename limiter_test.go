@@ -0,0 +1,122 @@
+package colly
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_rateLimiter_Acquire(t *testing.T) {
+	const delay = 30 * time.Millisecond
+
+	l := newRateLimiter()
+
+	start := time.Now()
+	wg := &sync.WaitGroup{}
+	for _, key := range []string{"a", "a", "a", "b", "b", "b"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			release, _ := l.Acquire(context.Background(), key, delay, 0)
+			release()
+		}(key)
+	}
+	wg.Wait()
+
+	// Every bucket waits twice, the buckets are paced independently
+	if elapsed := time.Since(start); elapsed < 2*delay || elapsed >= 4*delay {
+		t.Errorf("Acquire() took %v, want between %v and %v", elapsed, 2*delay, 4*delay)
+	}
+}
+
+func Test_rateLimiter_MaxThreads(t *testing.T) {
+	l := newRateLimiter()
+
+	var active, maxActive int32
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, _ := l.Acquire(context.Background(), "key", 0, 2)
+			defer release()
+
+			n := atomic.AddInt32(&active, 1)
+			for {
+				m := atomic.LoadInt32(&maxActive)
+				if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&active, -1)
+		}()
+	}
+	wg.Wait()
+
+	if m := atomic.LoadInt32(&maxActive); m > 2 {
+		t.Errorf("%d concurrent requests, want at most 2", m)
+	}
+}
+
+func Test_rateLimiter_cancel(t *testing.T) {
+	l := newRateLimiter()
+
+	release, _ := l.Acquire(context.Background(), "key", time.Minute, 1)
+
+	// The waiting request gives up when its context is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, "key", time.Minute, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// The slot of the cancelled request is not kept
+	release()
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, "key", 0, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("paced Acquire() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestLimitKeyCallbacks(t *testing.T) {
+	newReq := func(token, identity string) *Request {
+		u, _ := url.Parse("https://api.example.com/items")
+		req := &Request{Req: &http.Request{URL: u, Header: http.Header{}}}
+		if token != "" {
+			req.Req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if identity != "" {
+			req.SetIdentity(identity)
+		}
+		return req
+	}
+
+	byToken := LimitByHeader("Authorization")
+	if byToken(newReq("t1", "")) == byToken(newReq("t2", "")) {
+		t.Errorf("LimitByHeader() returned the same bucket for different tokens")
+	}
+	if byToken(newReq("t1", "")) != byToken(newReq("t1", "")) {
+		t.Errorf("LimitByHeader() returned different buckets for the same token")
+	}
+	if got := byToken(newReq("", "")); got != "api.example.com" {
+		t.Errorf("LimitByHeader() = %q, want the host", got)
+	}
+
+	if got := LimitByIdentity(newReq("", "user1")); got != "identity:user1" {
+		t.Errorf("LimitByIdentity() = %q, want %q", got, "identity:user1")
+	}
+	if got := LimitByIdentity(newReq("", "")); got != "api.example.com" {
+		t.Errorf("LimitByIdentity() = %q, want the host", got)
+	}
+}
@@ -0,0 +1,308 @@
+package colly
+
+import (
+	"bytes"
+	"colly/storage"
+	"colly/storage/mem"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ------------------------------------------------------------------------
+
+// LinkReport lists the broken links found by a link checker, grouped by the linking pages.
+type LinkReport struct {
+	Created time.Time    `json:"created" bson:"created,omitempty"` // Created is the date and time when the report was created.
+	Checked uint         `json:"checked" bson:"checked,omitempty"` // Checked is the number of the checked URLs.
+	Skipped uint         `json:"skipped" bson:"skipped,omitempty"` // Skipped is the number of the link targets rejected by the filters of the collector.
+	Broken  uint         `json:"broken" bson:"broken,omitempty"`   // Broken is the number of the broken links.
+	Pages   []*PageLinks `json:"pages" bson:"pages,omitempty"`     // Pages are the pages with broken links, sorted by their URL.
+}
+
+// PageLinks are the broken links of a page.
+type PageLinks struct {
+	URL   string        `json:"url" bson:"url"`               // URL is the URL of the linking page.
+	Links []*BrokenLink `json:"links" bson:"links,omitempty"` // Links are the broken links of the page in document order.
+}
+
+// LinkChecker crawls the pages of the start hosts and checks every link of them. The pages of the
+// start hosts are fetched with GET and their links are followed, the other link targets are only
+// checked with HEAD, falling back to GET if the HEAD request fails, as some servers reject HEAD.
+// Every link target is checked once, the requests are subject to the filters of the collector.
+type LinkChecker struct {
+	collector *Collector
+	stg       storage.GraphStorage
+	hosts     map[string]bool   // hosts of the crawled pages
+	seen      map[string]bool   // submitted link targets
+	done      map[string]bool   // link targets with a recorded outcome
+	skipped   map[string]string // link targets rejected by the filters, with the reason
+	lock      *sync.Mutex
+}
+
+// ------------------------------------------------------------------------
+
+// NewLinkChecker returns a pointer to a newly created link checker of the collector.
+// The links and the outcomes of the checks are recorded in the graph storage,
+// if no storage is given, they are kept in the memory. The storage must implement
+// the storage.GraphReader interface to create the report.
+// The callbacks of the collector are called for the responses of the checks as well.
+func (c *Collector) NewLinkChecker(stg storage.GraphStorage) *LinkChecker {
+	if stg == nil {
+		stg = mem.NewGraphStorage()
+	}
+
+	l := &LinkChecker{
+		collector: c,
+		stg:       stg,
+		hosts:     map[string]bool{},
+		seen:      map[string]bool{},
+		done:      map[string]bool{},
+		skipped:   map[string]string{},
+		lock:      &sync.Mutex{},
+	}
+	c.sysCallbacks.Add(ON_RESPONSE, NO_ARG, ResponseCallback(l.handleResponse))
+	c.sysCallbacks.Add(ON_ERROR, NO_ARG, ErrorCallback(l.handleError))
+
+	return l
+}
+
+// ------------------------------------------------------------------------
+
+// Check crawls the start pages and checks their links. The hosts of the start pages are crawled,
+// so their pages are checked with GET and parsed for further links, up to the depth limit of the collector.
+// Call Wait to finish the checks of an asynchronous collector before creating the report.
+func (l *LinkChecker) Check(pages ...string) error {
+	l.lock.Lock()
+	for _, u := range pages {
+		if host := linkHost(u); host != "" {
+			l.hosts[host] = true
+		}
+	}
+	l.lock.Unlock()
+
+	for _, u := range pages {
+		if err := l.check(u, 1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Report returns the broken links recorded by the checker, grouped by the linking pages.
+func (l *LinkChecker) Report() (*LinkReport, error) {
+	g, ok := l.stg.(storage.GraphReader)
+	if !ok {
+		return nil, fmt.Errorf("%w: graph storage %T", storage.ErrNotImplemented, l.stg)
+	}
+
+	links, err := BrokenLinks(g)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &LinkReport{
+		Created: time.Now(),
+		Broken:  uint(len(links)),
+	}
+
+	l.lock.Lock()
+	rep.Checked = uint(len(l.done))
+	rep.Skipped = uint(len(l.skipped))
+	l.lock.Unlock()
+
+	pages := map[string]*PageLinks{}
+	for _, link := range links {
+		p, present := pages[link.Edge.From]
+		if !present {
+			p = &PageLinks{URL: link.Edge.From}
+			pages[link.Edge.From] = p
+			rep.Pages = append(rep.Pages, p)
+		}
+		p.Links = append(p.Links, link)
+	}
+
+	sort.Slice(rep.Pages, func(i, j int) bool { return rep.Pages[i].URL < rep.Pages[j].URL })
+
+	return rep, nil
+}
+
+// Skipped returns the link targets rejected by the filters of the collector, mapped to the reasons.
+func (l *LinkChecker) Skipped() map[string]string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	skipped := make(map[string]string, len(l.skipped))
+	for u, reason := range l.skipped {
+		skipped[u] = reason
+	}
+
+	return skipped
+}
+
+// WriteJSON writes the JSON encoded report to w.
+func (r *LinkReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+// ------------------------------------------------------------------------
+
+// check submits a link target once. The pages of the crawled hosts are fetched with GET,
+// the other targets with HEAD. The targets rejected by the filters are recorded as skipped,
+// it returns the reason of the rejection.
+func (l *LinkChecker) check(u string, depth uint16) error {
+	l.lock.Lock()
+	if l.seen[u] {
+		l.lock.Unlock()
+		return nil
+	}
+	l.seen[u] = true
+	method := "HEAD"
+	if l.hosts[linkHost(u)] {
+		method = "GET"
+	}
+	l.lock.Unlock()
+
+	err := l.collector.scrape(u, method, int(depth), nil, nil, nil, false)
+	if err == nil {
+		return nil
+	}
+
+	// The synchronous requests return the errors of the checks as well, they are already recorded
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.done[u] {
+		return nil
+	}
+	l.skipped[u] = err.Error()
+
+	return err
+}
+
+// handleResponse records the outcome of a successful check
+// and checks the links of the pages of the crawled hosts.
+func (l *LinkChecker) handleResponse(resp *Response) {
+	if !l.tracked(resp) {
+		return
+	}
+
+	node := newGraphNode(resp, nil)
+	l.record(node)
+
+	if resp.Request.Req.Method != "GET" || !strings.Contains(resp.ContentType(), "html") {
+		return
+	}
+
+	l.lock.Lock()
+	crawled := l.hosts[linkHost(node.URL)]
+	l.lock.Unlock()
+
+	if !crawled {
+		return
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(resp.Body))
+	if err != nil {
+		l.collector.Config.logError(LOG_WARN_LEVEL, err)
+		return
+	}
+
+	var edges []*storage.GraphEdge
+	doc.Find(GRAPH_LINK_SELECTOR).Each(func(i int, s *goquery.Selection) {
+		if edge := newGraphEdge(NewHTMLElementFromSelectionNode(resp, s, s.Nodes[0], i)); edge != nil {
+			edges = append(edges, edge)
+		}
+	})
+
+	for _, edge := range edges {
+		if err := l.stg.AddEdge(edge); err != nil {
+			l.collector.Config.logError(LOG_WARN_LEVEL, err)
+		}
+		// The rejected targets are recorded as skipped
+		l.check(edge.To, edge.Depth)
+	}
+}
+
+// handleError records the outcome of a failed check.
+// The failed HEAD requests are checked again with GET.
+func (l *LinkChecker) handleError(resp *Response, err error) {
+	if !l.tracked(resp) {
+		return
+	}
+
+	node := newGraphNode(resp, err)
+
+	if resp.Request.Req.Method == "HEAD" {
+		gerr := l.collector.scrape(node.URL, "GET", int(node.Depth), nil, nil, nil, false)
+		if gerr == nil || l.isDone(node.URL) {
+			return
+		}
+		node.Error = gerr.Error()
+	}
+
+	l.record(node)
+}
+
+// tracked returns true if the response is the outcome of a check.
+func (l *LinkChecker) tracked(resp *Response) bool {
+	if resp == nil || resp.Request == nil || resp.Request.Req == nil || resp.IsNested() {
+		return false
+	}
+
+	u := resp.Request.OriginalURL()
+	if u == nil {
+		return false
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.seen[u.String()]
+}
+
+// record stores the outcome of a check.
+func (l *LinkChecker) record(node *storage.GraphNode) {
+	if node == nil {
+		return
+	}
+
+	l.lock.Lock()
+	l.done[node.URL] = true
+	l.lock.Unlock()
+
+	if err := l.stg.AddNode(node); err != nil {
+		l.collector.Config.logError(LOG_WARN_LEVEL, err)
+	}
+}
+
+// isDone returns true if the outcome of the link target is recorded.
+func (l *LinkChecker) isDone(u string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.done[u]
+}
+
+// ------------------------------------------------------------------------
+
+// linkHost returns the lower case host of a URL with the port, blank for invalid URLs.
+func linkHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(u.Host)
+}
@@ -0,0 +1,105 @@
+package colly
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestLinkChecker(t *testing.T) {
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/no-head" && r.Method == "HEAD":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case r.URL.Path == "/gone":
+			w.WriteHeader(http.StatusGone)
+		default:
+			w.Write([]byte(`<a href="/never-checked">not crawled</a>`))
+		}
+	}))
+	defer external.Close()
+
+	var methods []string
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "text/html")
+
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><body>
+				<a href="/about">About</a>
+				<a href="/missing">Missing page</a>
+				<a href="` + external.URL + `/ok">External</a>
+				<a href="` + external.URL + `/no-head">No HEAD</a>
+				<a href="` + external.URL + `/gone">Gone</a>
+				<a href="mailto:info@example.com">Mail</a>
+			</body></html>`))
+		case "/about":
+			w.Write([]byte(`<a href="/">Home</a> <a href="/missing">Missing again</a>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer site.Close()
+
+	c := NewCollector(nil, nil)
+	l := c.NewLinkChecker(nil)
+
+	if err := l.Check(site.URL + "/"); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	c.Wait()
+
+	rep, err := l.Report()
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+
+	if rep.Checked != 6 || rep.Broken != 3 {
+		t.Errorf("Report() checked %d, broken %d, want 6, 3", rep.Checked, rep.Broken)
+	}
+	if len(rep.Pages) != 2 || rep.Pages[0].URL != site.URL+"/" || rep.Pages[1].URL != site.URL+"/about" {
+		t.Fatalf("Report().Pages = %+v", rep.Pages)
+	}
+
+	var broken []string
+	for _, link := range rep.Pages[0].Links {
+		broken = append(broken, link.Edge.Anchor)
+	}
+	if want := []string{"Missing page", "Gone"}; !reflect.DeepEqual(broken, want) {
+		t.Errorf("broken links of the start page = %v, want %v", broken, want)
+	}
+	if link := rep.Pages[1].Links[0]; link.Edge.Anchor != "Missing again" || link.StatusCode != http.StatusNotFound {
+		t.Errorf("broken link of the about page = %+v", link)
+	}
+
+	// The pages of the site are fetched once with GET
+	if want := []string{"GET /", "GET /about", "GET /missing"}; !reflect.DeepEqual(methods, want) {
+		t.Errorf("site requests = %v, want %v", methods, want)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := rep.WriteJSON(buf); err != nil || !strings.Contains(buf.String(), `"anchor": "Gone"`) {
+		t.Errorf("WriteJSON() = %s, %v", buf, err)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_linkHost(t *testing.T) {
+	tests := map[string]string{
+		"https://Example.COM:8080/a": "example.com:8080",
+		"http://example.com":         "example.com",
+		"::invalid":                  "",
+	}
+	for u, want := range tests {
+		if got := linkHost(u); got != want {
+			t.Errorf("linkHost(%q) = %q, want %q", u, got, want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,6 +21,12 @@ type Logger interface {
 	LogError(level LogLevel, e error)        // LogError logs an error.
 }
 
+// LevelLogger is implemented by the loggers that filter the events by level.
+// The collector doesn't create the events of the disabled levels.
+type LevelLogger interface {
+	Enabled(level LogLevel) bool // Enabled returns true if the events of the level are logged.
+}
+
 // A LogLevel is a logging priority. Higher levels are more important.
 type LogLevel uint8
 
@@ -31,11 +38,18 @@ type LoggerEvent struct {
 	Values      map[string]string // Values contains the logger event's key-value pairs.
 }
 
+// transientLogger is implemented by the loggers that don't keep the events after LogEvent returns,
+// so the collector can reuse the events.
+type transientLogger interface {
+	transientEvents()
+}
+
 // stdLogger is the internal structure of an embedded standard logger.
 type stdLogger struct {
 	l       *log.Logger
 	counter int32
 	start   time.Time
+	level   atomic.Uint32 // minimum logged level
 }
 
 // webLogger is a web based logger frontend.
@@ -127,7 +141,7 @@ const webLoggerPage = `<!DOCTYPE html>
 
 // ------------------------------------------------------------------------
 
-var logLevelNames = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+var logLevelNames = []string{"DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
 
 // ------------------------------------------------------------------------
 
@@ -180,17 +194,59 @@ func NewWebLogger(address string) *webLogger {
 // ------------------------------------------------------------------------
 
 // LogEvent logs a logger event.
+// The line is built in a pooled buffer, the values are written in the order of their keys.
 func (l *stdLogger) LogEvent(level LogLevel, e *LoggerEvent) {
+	if !l.Enabled(level) {
+		return
+	}
+
 	i := atomic.AddInt32(&l.counter, 1)
-	l.l.Printf("%s: [%06d] %d [%6d - %s] %q (%s)\n", logLevelNames[level], i, e.CollectorID, e.RequestID, e.Type, e.Values, time.Since(l.start))
+
+	buf := acquireLogBuffer()
+	b := append(*buf, logLevelName(level)...)
+	b = append(b, ": ["...)
+	b = appendPaddedInt(b, int64(i), 6, '0')
+	b = append(b, "] "...)
+	b = strconv.AppendUint(b, uint64(e.CollectorID), 10)
+	b = append(b, " ["...)
+	b = appendPaddedInt(b, int64(e.RequestID), 6, ' ')
+	b = append(b, " - "...)
+	b = append(b, e.Type...)
+	b = append(b, "] "...)
+	b = appendQuotedMap(b, e.Values)
+	b = append(b, " ("...)
+	b = append(b, time.Since(l.start).String()...)
+	b = append(b, ")\n"...)
+
+	l.l.Output(2, string(b))
+
+	*buf = b
+	releaseLogBuffer(buf)
 }
 
 // LogError logs an error.
 func (l *stdLogger) LogError(level LogLevel, e error) {
+	if !l.Enabled(level) {
+		return
+	}
+
 	i := atomic.AddInt32(&l.counter, 1)
-	l.l.Printf("%s: [%06d]  %s (%s)\n", logLevelNames[level], i, e.Error(), time.Since(l.start))
+	l.l.Printf("%s: [%06d]  %s (%s)\n", logLevelName(level), i, e.Error(), time.Since(l.start))
 }
 
+// SetLevel sets the minimum level of the logged events and errors.
+func (l *stdLogger) SetLevel(level LogLevel) {
+	l.level.Store(uint32(level))
+}
+
+// Enabled returns true if the events of the level are logged.
+func (l *stdLogger) Enabled(level LogLevel) bool {
+	return uint32(level) >= l.level.Load()
+}
+
+// transientEvents marks the standard logger as a logger that doesn't keep the events.
+func (l *stdLogger) transientEvents() {}
+
 // ------------------------------------------------------------------------
 
 // LogEvent logs an event.
@@ -223,6 +279,9 @@ func (l *webLogger) LogError(level LogLevel, e error) {
 	// Nothing to do
 }
 
+// transientEvents marks the web logger as a logger that doesn't keep the events.
+func (w *webLogger) transientEvents() {}
+
 func (w *webLogger) indexHandler(wr http.ResponseWriter, r *http.Request) {
 	wr.Write([]byte(webLoggerPage))
 }
@@ -235,3 +294,54 @@ func (w *webLogger) statusHandler(wr http.ResponseWriter, r *http.Request) {
 		wr.Write(jsonData)
 	}
 }
+
+// ------------------------------------------------------------------------
+
+// logLevelName returns the name of the level.
+func logLevelName(level LogLevel) string {
+	if int(level) < len(logLevelNames) {
+		return logLevelNames[level]
+	}
+
+	return strconv.Itoa(int(level))
+}
+
+// appendPaddedInt appends the integer padded to the width, like the %06d and %6d verbs.
+func appendPaddedInt(b []byte, n int64, width int, pad byte) []byte {
+	var digits [20]byte
+	d := strconv.AppendInt(digits[:0], n, 10)
+
+	for i := len(d); i < width; i++ {
+		b = append(b, pad)
+	}
+
+	return append(b, d...)
+}
+
+// appendQuotedMap appends the map like the %q verb, with the keys sorted.
+func appendQuotedMap(b []byte, m map[string]string) []byte {
+	var arr [16]string
+	keys := arr[:0]
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	// Insertion sort, the maps are small
+	for i := 1; i < len(keys); i++ {
+		for j := i; j > 0 && keys[j] < keys[j-1]; j-- {
+			keys[j], keys[j-1] = keys[j-1], keys[j]
+		}
+	}
+
+	b = append(b, "map["...)
+	for i, k := range keys {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = strconv.AppendQuote(b, k)
+		b = append(b, ':')
+		b = strconv.AppendQuote(b, m[k])
+	}
+
+	return append(b, ']')
+}
@@ -0,0 +1,113 @@
+package colly
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stdLogger_LogEvent(t *testing.T) {
+	values := map[string]string{
+		"url":         "http://example.com/?q=\"a\"",
+		"status_code": "200",
+		"from_cache":  "false",
+	}
+
+	for _, tt := range []struct {
+		name  string
+		level LogLevel
+		event *LoggerEvent
+	}{
+		{name: "values", level: LOG_INFO_LEVEL, event: NewLoggerEvent("response", 3, 42, values)},
+		{name: "no values", level: LOG_FATAL_LEVEL, event: NewLoggerEvent("start", 1, 1234567, nil)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			l := NewStdLogger(buf, "", 0)
+			l.LogEvent(tt.level, tt.event)
+
+			e := tt.event
+			want := fmt.Sprintf("%s: [%06d] %d [%6d - %s] %q (", logLevelNames[tt.level], 1, e.CollectorID, e.RequestID, e.Type, e.Values)
+			if got := buf.String(); !strings.HasPrefix(got, want) || !strings.HasSuffix(got, ")\n") {
+				t.Errorf("LogEvent() = %q, want prefix %q", got, want)
+			}
+		})
+	}
+}
+
+func Test_stdLogger_SetLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewStdLogger(buf, "", 0)
+	l.SetLevel(LOG_WARN_LEVEL)
+
+	l.LogEvent(LOG_INFO_LEVEL, NewLoggerEvent("request", 1, 1, nil))
+	l.LogError(LOG_DEBUG_LEVEL, io.EOF)
+	if buf.Len() > 0 {
+		t.Errorf("disabled levels were logged: %q", buf.String())
+	}
+
+	l.LogEvent(LOG_WARN_LEVEL, NewLoggerEvent("error", 1, 1, nil))
+	l.LogError(LOG_ERR_LEVEL, io.EOF)
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Errorf("%d lines were logged, want 2: %q", n, buf.String())
+	}
+
+	cfg := &CollectorConfig{Logger: l}
+	if cfg.logEnabled(LOG_INFO_LEVEL) || !cfg.logEnabled(LOG_ERR_LEVEL) {
+		t.Error("logEnabled() doesn't follow the level of the logger")
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// nopWriter discards the log output without the log.Logger noticing it.
+type nopWriter struct{}
+
+func (nopWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+// ------------------------------------------------------------------------
+
+func BenchmarkStdLogger_LogEvent(b *testing.B) {
+	values := map[string]string{
+		"url":         "http://example.com/some/path?q=1",
+		"status_code": "200",
+		"status_msg":  "200 OK",
+		"from_cache":  "false",
+	}
+
+	b.Run("formatted", func(b *testing.B) {
+		l := log.New(nopWriter{}, "", log.LstdFlags)
+		e := NewLoggerEvent("response", 1, 42, values)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Printf("%s: [%06d] %d [%6d - %s] %q (%s)\n", logLevelNames[LOG_INFO_LEVEL], i, e.CollectorID, e.RequestID, e.Type, e.Values, "1s")
+		}
+	})
+
+	b.Run("fast path", func(b *testing.B) {
+		l := NewStdLogger(nopWriter{}, "", log.LstdFlags)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			e := acquireLoggerEvent("response", 1, 42, values)
+			l.LogEvent(LOG_INFO_LEVEL, e)
+			releaseLoggerEvent(e)
+		}
+	})
+
+	b.Run("disabled level", func(b *testing.B) {
+		l := NewStdLogger(nopWriter{}, "", log.LstdFlags)
+		l.SetLevel(LOG_WARN_LEVEL)
+		cfg := &CollectorConfig{Logger: l}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if cfg.logEnabled(LOG_INFO_LEVEL) {
+				l.LogEvent(LOG_INFO_LEVEL, NewLoggerEvent("response", 1, 42, values))
+			}
+		}
+	})
+}
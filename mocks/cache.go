@@ -0,0 +1,91 @@
+package mocks
+
+import (
+	"colly"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// Cache is a recording in-memory fake of colly.Cache. The responses are kept by their request URLs.
+type Cache struct {
+	*Recorder
+	responses map[string]*colly.Response
+	lock      *sync.RWMutex
+}
+
+// ------------------------------------------------------------------------
+
+// NewCache returns a pointer to a newly created cache fake.
+func NewCache() *Cache {
+	return &Cache{
+		Recorder:  newRecorder(),
+		responses: map[string]*colly.Response{},
+		lock:      &sync.RWMutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Set stores a response. The call is recorded with the request URL.
+func (c *Cache) Set(resp *colly.Response) error {
+	url := responseURL(resp)
+	if err := c.record("Set", url); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.responses[url] = resp
+	c.lock.Unlock()
+
+	return nil
+}
+
+// Get retrieves a response, or nil if the URL isn't cached.
+func (c *Cache) Get(url string) (*colly.Response, error) {
+	if err := c.record("Get", url); err != nil {
+		return nil, err
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.responses[url], nil
+}
+
+// Remove removes a cached response.
+func (c *Cache) Remove(url string) error {
+	if err := c.record("Remove", url); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	delete(c.responses, url)
+	c.lock.Unlock()
+
+	return nil
+}
+
+// RemoveAll removes all cached responses.
+func (c *Cache) RemoveAll() error {
+	if err := c.record("RemoveAll"); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.responses = map[string]*colly.Response{}
+	c.lock.Unlock()
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// responseURL returns the request URL of the response, or empty string if it has none.
+func responseURL(resp *colly.Response) string {
+	if resp == nil || resp.Request == nil || resp.Request.Req == nil || resp.Request.Req.URL == nil {
+		return ""
+	}
+
+	return resp.Request.Req.URL.String()
+}
@@ -0,0 +1,89 @@
+package mocks
+
+import (
+	"colly"
+	"context"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+// Logger is a recording fake of colly.Logger.
+// The events are recorded as LogEvent calls with the level and the event,
+// the errors as LogError calls with the level and the error.
+type Logger struct {
+	*Recorder
+}
+
+// Tracer is a recording fake of colly.Tracer. The contexts are returned unchanged.
+type Tracer struct {
+	*Recorder
+}
+
+// ------------------------------------------------------------------------
+
+// NewLogger returns a pointer to a newly created logger fake.
+func NewLogger() *Logger {
+	return &Logger{
+		Recorder: newRecorder(),
+	}
+}
+
+// LogEvent records an event.
+func (l *Logger) LogEvent(level colly.LogLevel, e *colly.LoggerEvent) {
+	l.record("LogEvent", level, e)
+}
+
+// LogError records an error.
+func (l *Logger) LogError(level colly.LogLevel, err error) {
+	l.record("LogError", level, err)
+}
+
+// Events returns the logged events of the types, or all logged events if no type was given.
+func (l *Logger) Events(types ...string) []*colly.LoggerEvent {
+	events := []*colly.LoggerEvent{}
+	for _, c := range l.Calls("LogEvent") {
+		if e, ok := c.Args[1].(*colly.LoggerEvent); ok && (len(types) == 0 || contains(types, e.Type)) {
+			events = append(events, e)
+		}
+	}
+
+	return events
+}
+
+// Errors returns the logged errors.
+func (l *Logger) Errors() []error {
+	errs := []error{}
+	for _, c := range l.Calls("LogError") {
+		if err, ok := c.Args[1].(error); ok {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// AssertLogged fails the test if no event of the type was logged.
+func (l *Logger) AssertLogged(t testing.TB, eventType string) {
+	t.Helper()
+
+	if len(l.Events(eventType)) == 0 {
+		t.Errorf("no %q event was logged, events: %v", eventType, l.Calls("LogEvent"))
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// NewTracer returns a pointer to a newly created tracer fake.
+func NewTracer() *Tracer {
+	return &Tracer{
+		Recorder: newRecorder(),
+	}
+}
+
+// WithContext records the call and returns the parent context.
+func (t *Tracer) WithContext(ctx context.Context) context.Context {
+	t.record("WithContext", ctx)
+
+	return ctx
+}
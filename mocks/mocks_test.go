@@ -0,0 +1,80 @@
+package mocks
+
+import (
+	"colly"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCacheStorage(t *testing.T) {
+	s := NewCacheStorage()
+
+	if err := s.Put("a", strings.NewReader("data")); err != nil {
+		t.Fatal(err)
+	}
+	rdr, err := s.Fetch("a")
+	if err != nil || rdr == nil {
+		t.Fatalf("Fetch() = %v, %v", rdr, err)
+	}
+	if b, _ := io.ReadAll(rdr); string(b) != "data" {
+		t.Errorf("Fetch() = %q, want %q", b, "data")
+	}
+
+	s.AssertCalled(t, "Put", "a", "data")
+	s.AssertCallCount(t, "Fetch", 1)
+	s.AssertNotCalled(t, "Clear")
+
+	failure := errors.New("disk full")
+	s.FailWith("Put", failure)
+	if err := s.Put("b", strings.NewReader("data")); !errors.Is(err, failure) {
+		t.Errorf("Put() error = %v, want %v", err, failure)
+	}
+	if s.Has("b") {
+		t.Errorf("the failed Put() stored the item")
+	}
+
+	s.FailWith("Put", nil)
+	if err := s.Put("b", strings.NewReader("data")); err != nil {
+		t.Errorf("Put() error = %v after the failure was reset", err)
+	}
+}
+
+func TestVisitStorage(t *testing.T) {
+	s := NewVisitStorage()
+	s.AddVisit("a")
+	s.AddVisit("a")
+
+	if n, _ := s.PastVisits("a"); n != 2 {
+		t.Errorf("PastVisits() = %d, want 2", n)
+	}
+
+	want := []Call{{"AddVisit", []any{"a"}}, {"AddVisit", []any{"a"}}, {"PastVisits", []any{"a"}}}
+	if got := s.Calls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Calls() = %v, want %v", got, want)
+	}
+
+	s.Reset()
+	if got := s.Calls(); len(got) != 0 {
+		t.Errorf("Calls() after Reset() = %v", got)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	l := NewLogger()
+	l.LogEvent(colly.LOG_INFO_LEVEL, &colly.LoggerEvent{Type: "request"})
+	l.LogEvent(colly.LOG_INFO_LEVEL, &colly.LoggerEvent{Type: "response"})
+	l.LogError(colly.LOG_WARN_LEVEL, errors.New("oops"))
+
+	if got := l.Events("response"); len(got) != 1 || got[0].Type != "response" {
+		t.Errorf("Events() = %v", got)
+	}
+	if got := l.Errors(); len(got) != 1 || got[0].Error() != "oops" {
+		t.Errorf("Errors() = %v", got)
+	}
+	l.AssertLogged(t, "request")
+}
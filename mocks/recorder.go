@@ -0,0 +1,148 @@
+// Package mocks implements recording test doubles of the colly storage, cache,
+// logger and tracer interfaces, so the crawler wiring can be unit-tested
+// without real storage backends.
+package mocks
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+// Call is a recorded method call of a test double.
+type Call struct {
+	Method string // Method is the name of the called method.
+	Args   []any  // Args are the arguments of the call.
+}
+
+// Recorder records the method calls of a test double and injects the errors of the failing methods.
+type Recorder struct {
+	calls []Call
+	fails map[string]error // injected errors by method name
+	lock  *sync.Mutex
+}
+
+// ------------------------------------------------------------------------
+
+// newRecorder returns a pointer to a newly created call recorder.
+func newRecorder() *Recorder {
+	return &Recorder{
+		fails: map[string]error{},
+		lock:  &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// FailWith makes the method return the error until it is called with a nil error.
+func (r *Recorder) FailWith(method string, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err == nil {
+		delete(r.fails, method)
+		return
+	}
+	r.fails[method] = err
+}
+
+// Calls returns the recorded calls of the methods, or all recorded calls if no method was given.
+func (r *Recorder) Calls(methods ...string) []Call {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	calls := []Call{}
+	for _, c := range r.calls {
+		if len(methods) == 0 || contains(methods, c.Method) {
+			calls = append(calls, c)
+		}
+	}
+
+	return calls
+}
+
+// Reset forgets the recorded calls. The injected errors are kept.
+func (r *Recorder) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.calls = nil
+}
+
+// ------------------------------------------------------------------------
+
+// AssertCalled fails the test if the method was never called with the arguments.
+// If no argument was given, any call of the method matches.
+func (r *Recorder) AssertCalled(t testing.TB, method string, args ...any) {
+	t.Helper()
+
+	for _, c := range r.Calls(method) {
+		if len(args) == 0 || reflect.DeepEqual(c.Args, args) {
+			return
+		}
+	}
+
+	t.Errorf("%s(%s) was not called, calls: %v", method, formatArgs(args), r.Calls(method))
+}
+
+// AssertNotCalled fails the test if the method was called.
+func (r *Recorder) AssertNotCalled(t testing.TB, method string) {
+	t.Helper()
+
+	if calls := r.Calls(method); len(calls) > 0 {
+		t.Errorf("%s was called %d times, want none, calls: %v", method, len(calls), calls)
+	}
+}
+
+// AssertCallCount fails the test if the method was not called exactly n times.
+func (r *Recorder) AssertCallCount(t testing.TB, method string, n int) {
+	t.Helper()
+
+	if calls := r.Calls(method); len(calls) != n {
+		t.Errorf("%s was called %d times, want %d", method, len(calls), n)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// record records a call and returns the injected error of the method.
+func (r *Recorder) record(method string, args ...any) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+
+	return r.fails[method]
+}
+
+// String returns the call in Go syntax.
+func (c Call) String() string {
+	return fmt.Sprintf("%s(%s)", c.Method, formatArgs(c.Args))
+}
+
+// formatArgs returns the arguments as a comma separated list.
+func formatArgs(args []any) string {
+	s := ""
+	for i, arg := range args {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%#v", arg)
+	}
+
+	return s
+}
+
+// contains returns true if the list contains the string.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,257 @@
+package mocks
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// CacheStorage is a recording in-memory fake of colly.CacheStorage.
+type CacheStorage struct {
+	*Recorder
+	items map[string][]byte
+	lock  *sync.RWMutex
+}
+
+// CookieStorage is a recording in-memory fake of colly.CookieStorage.
+// It can be used as a poll storage and a validator storage too.
+type CookieStorage struct {
+	*Recorder
+	entries map[string][]byte
+	lock    *sync.RWMutex
+}
+
+// VisitStorage is a recording in-memory fake of filters.VisitStorage.
+type VisitStorage struct {
+	*Recorder
+	visits map[string]uint
+	lock   *sync.RWMutex
+}
+
+// ------------------------------------------------------------------------
+
+// NewCacheStorage returns a pointer to a newly created cache storage fake.
+func NewCacheStorage() *CacheStorage {
+	return &CacheStorage{
+		Recorder: newRecorder(),
+		items:    map[string][]byte{},
+		lock:     &sync.RWMutex{},
+	}
+}
+
+// Put stores an item. The call is recorded with the key and the data as a string.
+func (s *CacheStorage) Put(key string, data io.Reader) error {
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+
+	if err := s.record("Put", key, string(b)); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.items[key] = b
+	s.lock.Unlock()
+
+	return nil
+}
+
+// Fetch retrieves an item, or nil if the key doesn't exist.
+func (s *CacheStorage) Fetch(key string) (io.Reader, error) {
+	if err := s.record("Fetch", key); err != nil {
+		return nil, err
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	b, ok := s.items[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return bytes.NewReader(b), nil
+}
+
+// Has returns true if the key exists.
+func (s *CacheStorage) Has(key string) bool {
+	s.record("Has", key)
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	_, ok := s.items[key]
+
+	return ok
+}
+
+// Remove deletes an item.
+func (s *CacheStorage) Remove(key string) error {
+	if err := s.record("Remove", key); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	delete(s.items, key)
+	s.lock.Unlock()
+
+	return nil
+}
+
+// Clear deletes all items.
+func (s *CacheStorage) Clear() error {
+	if err := s.record("Clear"); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.items = map[string][]byte{}
+	s.lock.Unlock()
+
+	return nil
+}
+
+// Len returns the number of stored items.
+func (s *CacheStorage) Len() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return len(s.items)
+}
+
+// ------------------------------------------------------------------------
+
+// NewCookieStorage returns a pointer to a newly created cookie storage fake.
+func NewCookieStorage() *CookieStorage {
+	return &CookieStorage{
+		Recorder: newRecorder(),
+		entries:  map[string][]byte{},
+		lock:     &sync.RWMutex{},
+	}
+}
+
+// Set stores the entries. The call is recorded with the key and the entries as a string.
+func (s *CookieStorage) Set(key string, entries io.Reader) error {
+	b, err := io.ReadAll(entries)
+	if err != nil {
+		return err
+	}
+
+	if err := s.record("Set", key, string(b)); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.entries[key] = b
+	s.lock.Unlock()
+
+	return nil
+}
+
+// Get retrieves the entries, or nil if the key doesn't exist.
+func (s *CookieStorage) Get(key string) (io.Reader, error) {
+	if err := s.record("Get", key); err != nil {
+		return nil, err
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	b, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return bytes.NewReader(b), nil
+}
+
+// Remove removes an entry.
+func (s *CookieStorage) Remove(key string) error {
+	if err := s.record("Remove", key); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	delete(s.entries, key)
+	s.lock.Unlock()
+
+	return nil
+}
+
+// Clear deletes all entries.
+func (s *CookieStorage) Clear() error {
+	if err := s.record("Clear"); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.entries = map[string][]byte{}
+	s.lock.Unlock()
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// NewVisitStorage returns a pointer to a newly created visit storage fake.
+func NewVisitStorage() *VisitStorage {
+	return &VisitStorage{
+		Recorder: newRecorder(),
+		visits:   map[string]uint{},
+		lock:     &sync.RWMutex{},
+	}
+}
+
+// AddVisit counts a visit.
+func (s *VisitStorage) AddVisit(key string) error {
+	if err := s.record("AddVisit", key); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.visits[key]++
+	s.lock.Unlock()
+
+	return nil
+}
+
+// PastVisits returns the number of the visits.
+func (s *VisitStorage) PastVisits(key string) (uint, error) {
+	if err := s.record("PastVisits", key); err != nil {
+		return 0, err
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.visits[key], nil
+}
+
+// Remove removes the visits of a key.
+func (s *VisitStorage) Remove(key string) error {
+	if err := s.record("Remove", key); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	delete(s.visits, key)
+	s.lock.Unlock()
+
+	return nil
+}
+
+// Clear deletes all visits.
+func (s *VisitStorage) Clear() error {
+	if err := s.record("Clear"); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.visits = map[string]uint{}
+	s.lock.Unlock()
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package colly
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// Module is a reusable extension of the collector.
+// A module can attach filters, headers and callbacks to the collector in its Register method.
+type Module interface {
+	Register(c *Collector) error // Register attaches the module to the collector.
+}
+
+// ModuleFunc is an adapter to use an ordinary function as a Module.
+type ModuleFunc func(c *Collector) error
+
+// moduleRegistry holds the modules registered by name.
+type moduleRegistry struct {
+	modules map[string]Module
+	lock    *sync.RWMutex
+}
+
+// ------------------------------------------------------------------------
+
+var modules = &moduleRegistry{
+	modules: map[string]Module{},
+	lock:    &sync.RWMutex{},
+}
+
+// ------------------------------------------------------------------------
+
+// Register implements the Module interface.
+func (fn ModuleFunc) Register(c *Collector) error {
+	return fn(c)
+}
+
+// ------------------------------------------------------------------------
+
+// RegisterModule makes a module available by name.
+// It is intended to be called from the init function of the third party package,
+// so the module can be attached by UseModule after importing the package.
+func RegisterModule(name string, m Module) error {
+	if m == nil {
+		return ErrNoModule
+	}
+
+	modules.lock.Lock()
+	defer modules.lock.Unlock()
+
+	if _, present := modules.modules[name]; present {
+		return fmt.Errorf("%w: %q", ErrModuleExists, name)
+	}
+	modules.modules[name] = m
+
+	return nil
+}
+
+// LookupModule returns the module registered with the given name.
+func LookupModule(name string) (Module, bool) {
+	modules.lock.RLock()
+	defer modules.lock.RUnlock()
+
+	m, present := modules.modules[name]
+
+	return m, present
+}
+
+// Modules returns the sorted names of the registered modules.
+func Modules() []string {
+	modules.lock.RLock()
+	defer modules.lock.RUnlock()
+
+	names := make([]string, 0, len(modules.modules))
+	for name := range modules.modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ------------------------------------------------------------------------
+
+// Use attaches the modules to the collector in the given order.
+// It stops at the first module that fails to register.
+func (c *Collector) Use(m ...Module) error {
+	for _, module := range m {
+		if module == nil {
+			return ErrNoModule
+		}
+		if err := module.Register(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UseModule attaches the registered modules to the collector by name.
+func (c *Collector) UseModule(names ...string) error {
+	for _, name := range names {
+		m, present := LookupModule(name)
+		if !present {
+			return fmt.Errorf("%w: %q", ErrModuleNotFound, name)
+		}
+		if err := m.Register(c); err != nil {
+			return fmt.Errorf("module %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package colly
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestRegisterModule(t *testing.T) {
+	var calls []string
+	newModule := func(name string) Module {
+		return ModuleFunc(func(c *Collector) error {
+			calls = append(calls, name)
+			return nil
+		})
+	}
+
+	// The registry is global, the modules of the test are removed for the next runs
+	t.Cleanup(func() {
+		modules.lock.Lock()
+		defer modules.lock.Unlock()
+
+		delete(modules.modules, "test-a")
+		delete(modules.modules, "test-b")
+	})
+
+	if err := RegisterModule("test-a", newModule("a")); err != nil {
+		t.Fatalf("RegisterModule() error = %v", err)
+	}
+	if err := RegisterModule("test-b", newModule("b")); err != nil {
+		t.Fatalf("RegisterModule() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		module  string
+		m       Module
+		wantErr error
+	}{
+		{"duplicate", "test-a", newModule("x"), ErrModuleExists},
+		{"nil module", "test-nil", nil, ErrNoModule},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RegisterModule(tt.module, tt.m); !errors.Is(err, tt.wantErr) {
+				t.Errorf("RegisterModule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	c := &Collector{}
+	if err := c.UseModule("test-b", "test-a"); err != nil {
+		t.Fatalf("UseModule() error = %v", err)
+	}
+	if want := []string{"b", "a"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("UseModule() calls = %v, want %v", calls, want)
+	}
+	if err := c.UseModule("test-missing"); !errors.Is(err, ErrModuleNotFound) {
+		t.Errorf("UseModule() error = %v, wantErr %v", err, ErrModuleNotFound)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_Use(t *testing.T) {
+	errFailed := errors.New("failed")
+	var calls int
+	ok := ModuleFunc(func(c *Collector) error { calls++; return nil })
+	fail := ModuleFunc(func(c *Collector) error { return errFailed })
+
+	c := &Collector{}
+	if err := c.Use(ok, fail, ok); !errors.Is(err, errFailed) {
+		t.Errorf("Use() error = %v, wantErr %v", err, errFailed)
+	}
+	if calls != 1 {
+		t.Errorf("Use() calls = %d, want 1", calls)
+	}
+}
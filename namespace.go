@@ -0,0 +1,134 @@
+package colly
+
+import (
+	"colly/filters"
+	"colly/storage"
+	"colly/storage/namespace"
+	"fmt"
+	"strconv"
+)
+
+// ------------------------------------------------------------------------
+
+// CollectorNamespace returns the default storage namespace of a collector, derived from its ID.
+func CollectorNamespace(id uint32) string {
+	return "collector-" + strconv.FormatUint(uint64(id), 10)
+}
+
+// ------------------------------------------------------------------------
+
+// SetNamespace sets the namespace isolating the data of the collector in shared storages.
+// The cache, cookie, visit and queue storages attached after this call will be prefixed by the namespace.
+// The storages must implement the storage.Purger interface, the queue storages the namespace.QueueStorage interface.
+func (c *CollectorConfig) SetNamespace(namespace string) error {
+	if _, err := storage.NamespacePrefix(namespace); err != nil {
+		return err
+	}
+	c.Namespace = namespace
+
+	return nil
+}
+
+// SetQueue sets the underlying storage of the job queue.
+func (c *CollectorConfig) SetQueue(stg Queue) error {
+	if stg == nil {
+		return storage.ErrMissingParams
+	}
+
+	stg, err := c.isolateQueue(stg)
+	if err != nil {
+		return err
+	}
+	c.Queue = stg
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// isolateCache wraps a shared cache storage to prefix the keys with the namespace.
+func (c *CollectorConfig) isolateCache(stg CacheStorage) (CacheStorage, error) {
+	if c.Namespace == "" || stg == nil {
+		return stg, nil
+	}
+
+	shared, ok := stg.(namespace.CacheStorage)
+	if !ok {
+		return nil, errNoNamespace(stg)
+	}
+
+	return namespace.NewCacheStorage(c.Namespace, shared)
+}
+
+// isolateCookies wraps a shared cookie storage to prefix the keys with the namespace.
+func (c *CollectorConfig) isolateCookies(stg CookieStorage) (CookieStorage, error) {
+	if c.Namespace == "" || stg == nil {
+		return stg, nil
+	}
+
+	shared, ok := stg.(namespace.CookieStorage)
+	if !ok {
+		return nil, errNoNamespace(stg)
+	}
+
+	return namespace.NewCookieStorage(c.Namespace, shared)
+}
+
+// isolateVisits wraps a shared visit storage to prefix the keys with the namespace.
+func (c *CollectorConfig) isolateVisits(stg filters.VisitStorage) (filters.VisitStorage, error) {
+	if c.Namespace == "" || stg == nil {
+		return stg, nil
+	}
+
+	shared, ok := stg.(namespace.VisitStorage)
+	if !ok {
+		return nil, errNoNamespace(stg)
+	}
+
+	return namespace.NewVisitStorage(c.Namespace, shared)
+}
+
+// isolateQueue wraps a shared queue storage to keep the dispatch queues in the scope of the namespace.
+func (c *CollectorConfig) isolateQueue(stg Queue) (Queue, error) {
+	if c.Namespace == "" {
+		return stg, nil
+	}
+
+	shared, ok := stg.(namespace.QueueStorage)
+	if !ok {
+		return nil, errNoNamespace(stg)
+	}
+
+	return namespace.NewQueueStorage(c.Namespace, shared)
+}
+
+// errNoNamespace returns an error for storages without namespace support.
+func errNoNamespace(stg any) error {
+	return fmt.Errorf("%w: %T cannot isolate a namespace", storage.ErrNotImplemented, stg)
+}
+
+// ------------------------------------------------------------------------
+
+// Namespace returns the namespace of the collector in shared storages.
+// If no namespace was configured, it is derived from the collector ID.
+func (c *Collector) Namespace() string {
+	if c.Config != nil && c.Config.Namespace != "" {
+		return c.Config.Namespace
+	}
+
+	return CollectorNamespace(c.ID)
+}
+
+// PurgeNamespace removes all data of the collector from the shared storages.
+// The storages must implement the storage.Purger interface.
+func (c *Collector) PurgeNamespace(storages ...any) error {
+	ns := c.Namespace()
+
+	if c.HasLogger() {
+		c.logEvent(LOG_INFO_LEVEL, "purge", 0, map[string]string{
+			"namespace": ns,
+		})
+	}
+
+	return storage.PurgeNamespace(ns, storages...)
+}
@@ -0,0 +1,132 @@
+package colly
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// negativeCache remembers the permanent failures of the URLs for a short time,
+// so the dead links discovered again and again are not requested repeatedly.
+type negativeCache struct {
+	ttl     time.Duration
+	entries map[string]negativeEntry // failures by URL
+	lock    *sync.Mutex
+}
+
+// negativeEntry is a remembered permanent failure.
+type negativeEntry struct {
+	reason  string    // status line or error message of the failure
+	expires time.Time // end of the TTL
+}
+
+// ------------------------------------------------------------------------
+
+// NEGATIVE_CACHE_MAX_ENTRIES is the maximum number of the remembered failures.
+// New failures are not remembered while the cache is full of unexpired entries.
+const NEGATIVE_CACHE_MAX_ENTRIES = 100000
+
+// ------------------------------------------------------------------------
+
+// newNegativeCache returns a pointer to a newly created negative cache,
+// or nil if the TTL is not positive.
+func newNegativeCache(ttl time.Duration) *negativeCache {
+	if ttl <= 0 {
+		return nil
+	}
+
+	return &negativeCache{
+		ttl:     ttl,
+		entries: map[string]negativeEntry{},
+		lock:    &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// get returns the reason of the remembered failure of the URL.
+func (nc *negativeCache) get(key string, now time.Time) (string, bool) {
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+
+	e, present := nc.entries[key]
+	if !present {
+		return "", false
+	}
+
+	if !now.Before(e.expires) {
+		delete(nc.entries, key)
+		return "", false
+	}
+
+	return e.reason, true
+}
+
+// add remembers the failure of the URL until the TTL expires.
+func (nc *negativeCache) add(key string, reason string, now time.Time) {
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+
+	if _, present := nc.entries[key]; !present && len(nc.entries) >= NEGATIVE_CACHE_MAX_ENTRIES {
+		for k, e := range nc.entries {
+			if !now.Before(e.expires) {
+				delete(nc.entries, k)
+			}
+		}
+		if len(nc.entries) >= NEGATIVE_CACHE_MAX_ENTRIES {
+			return
+		}
+	}
+
+	nc.entries[key] = negativeEntry{
+		reason:  reason,
+		expires: now.Add(nc.ttl),
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// permanentFailure returns the reason of a failure that is not going to be fixed by a retry:
+// 404 and 410 responses and unknown hosts. It returns a blank string for the successful
+// requests and for the transient failures, like timeouts and server errors.
+func permanentFailure(resp *Response, err error) string {
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return dnsErr.Error()
+		}
+		return ""
+	}
+
+	if resp == nil || resp.Resp == nil {
+		return ""
+	}
+
+	switch resp.Resp.StatusCode {
+	case http.StatusNotFound, http.StatusGone:
+		if resp.Resp.Status != "" {
+			return resp.Resp.Status
+		}
+		return http.StatusText(resp.Resp.StatusCode)
+	}
+
+	return ""
+}
+
+// ------------------------------------------------------------------------
+
+// negativeCacheHit counts and logs a request skipped because of a remembered failure.
+func (c *Collector) negativeCacheHit(req *Request, reason string) {
+	c.stats.negativeCacheHit()
+
+	if c.Config.logEnabled(LOG_DEBUG_LEVEL) {
+		c.logEvent(LOG_DEBUG_LEVEL, "negative_cache_hit", req.ID, map[string]string{
+			"url":    req.Req.URL.String(),
+			"reason": reason,
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package colly
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestPermanentFailure(t *testing.T) {
+	status := func(code int) *Response {
+		return &Response{Resp: &http.Response{StatusCode: code, Status: strconv.Itoa(code) + " " + http.StatusText(code)}}
+	}
+	nxdomain := &net.DNSError{Err: "no such host", Name: "dead.example.com", IsNotFound: true}
+
+	tests := []struct {
+		name string
+		resp *Response
+		err  error
+		want string
+	}{
+		{name: "ok", resp: status(http.StatusOK)},
+		{name: "not found", resp: status(http.StatusNotFound), want: "404 Not Found"},
+		{name: "gone", resp: status(http.StatusGone), want: "410 Gone"},
+		{name: "server error", resp: status(http.StatusServiceUnavailable)},
+		{name: "nxdomain", err: fmt.Errorf("dial: %w", nxdomain), want: nxdomain.Error()},
+		{name: "dns timeout", err: &net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}},
+		{name: "other error", err: errors.New("connection reset")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := permanentFailure(tt.resp, tt.err); got != tt.want {
+				t.Errorf("permanentFailure() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_negativeCache(t *testing.T) {
+	if nc := newNegativeCache(0); nc != nil {
+		t.Error("newNegativeCache(0) should turn off the negative caching")
+	}
+
+	now := time.Now()
+	nc := newNegativeCache(time.Minute)
+	nc.add("http://example.com/dead", "404 Not Found", now)
+
+	if reason, hit := nc.get("http://example.com/dead", now.Add(30*time.Second)); !hit || reason != "404 Not Found" {
+		t.Errorf("get() = %q, %v, want a hit", reason, hit)
+	}
+	if _, hit := nc.get("http://example.com/alive", now); hit {
+		t.Error("get() returned a failure of an unknown URL")
+	}
+	if _, hit := nc.get("http://example.com/dead", now.Add(time.Minute)); hit {
+		t.Error("get() returned an expired failure")
+	}
+	if len(nc.entries) != 0 {
+		t.Errorf("%d entries left, the expired entry should be removed", len(nc.entries))
+	}
+
+	// Full of unexpired entries
+	for i := 0; i < NEGATIVE_CACHE_MAX_ENTRIES; i++ {
+		nc.add(strconv.Itoa(i), "410 Gone", now)
+	}
+	nc.add("http://example.com/dead", "404 Not Found", now)
+	if _, hit := nc.get("http://example.com/dead", now); hit {
+		t.Error("add() exceeded the maximum number of entries")
+	}
+
+	// The expired entries make room
+	nc.add("http://example.com/dead", "404 Not Found", now.Add(time.Hour))
+	if _, hit := nc.get("http://example.com/dead", now.Add(time.Hour)); !hit || len(nc.entries) != 1 {
+		t.Errorf("add() didn't replace the expired entries, %d entries", len(nc.entries))
+	}
+}
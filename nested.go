@@ -0,0 +1,209 @@
+package colly
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html/charset"
+)
+
+// ------------------------------------------------------------------------
+
+// nestedDocument is a document embedded in a page.
+type nestedDocument struct {
+	kind string // "srcdoc" or "data"
+	body []byte // UTF-8 encoded HTML
+}
+
+// ------------------------------------------------------------------------
+
+// NESTED_DOCUMENT_MAX_DEPTH limits the nesting of the parsed embedded documents.
+const NESTED_DOCUMENT_MAX_DEPTH = 3
+
+// NESTED_FRAGMENT_PREFIX starts the URL fragment of the synthetic URLs of the embedded documents,
+// e.g. https://example.com/page#nested-srcdoc-1 for the first srcdoc document of the page.
+const NESTED_FRAGMENT_PREFIX = "nested-"
+
+// nestedSelector matches the elements embedding documents.
+const nestedSelector = `iframe[srcdoc], iframe[src^="data:" i], frame[src^="data:" i], object[data^="data:" i]`
+
+// ------------------------------------------------------------------------
+
+// IsNested returns true if the response is a document embedded in a page, see ParseNestedDocuments.
+func (r *Response) IsNested() bool {
+	return r.nested > 0
+}
+
+// ------------------------------------------------------------------------
+
+// handleNestedDocuments runs the OnHTML pipeline on the documents embedded in the page.
+// The links of the embedded documents are resolved against the URL of the page.
+func (c *Collector) handleNestedDocuments(resp *Response, doc *goquery.Document) error {
+	if resp.nested >= NESTED_DOCUMENT_MAX_DEPTH {
+		return nil
+	}
+
+	counts := map[string]int{}
+	for _, nd := range findNestedDocuments(doc) {
+		counts[nd.kind]++
+		nested := resp.nestedResponse(nd, counts[nd.kind])
+
+		if c.Config.logEnabled(LOG_DEBUG_LEVEL) {
+			c.logEvent(LOG_DEBUG_LEVEL, "nested_document", resp.Request.ID, map[string]string{
+				"url":  nested.Request.Req.URL.String(),
+				"size": strconv.Itoa(len(nd.body)),
+			})
+		}
+
+		if err := c.handleOnHTML(nested); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nestedResponse returns the synthetic response of an embedded document.
+// The request shares the identity, the depth and the context of the request of the page.
+func (r *Response) nestedResponse(nd nestedDocument, n int) *Response {
+	parent := r.Request
+
+	fragment := NESTED_FRAGMENT_PREFIX + nd.kind + "-" + strconv.Itoa(n)
+	if r.nested > 0 {
+		fragment = parent.Req.URL.Fragment + "." + nd.kind + "-" + strconv.Itoa(n)
+	}
+
+	u := *parent.Req.URL
+	u.Fragment, u.RawFragment = fragment, ""
+
+	httpReq := parent.Req.Clone(parent.Req.Context())
+	httpReq.URL = &u
+
+	req := &Request{
+		ID:           parent.ID,
+		Depth:        parent.Depth,
+		Req:          httpReq,
+		Ctx:          parent.Ctx,
+		Parser:       parent.Parser,
+		Tracer:       parent.Tracer,
+		CharEncoding: parent.CharEncoding,
+		Priority:     parent.Priority,
+		collector:    parent.collector,
+		originalURL:  parent.OriginalURL(),
+	}
+
+	httpResp := &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Request:    httpReq,
+	}
+	if r.Resp != nil {
+		httpResp.Status, httpResp.StatusCode = r.Resp.Status, r.Resp.StatusCode
+	}
+
+	return &Response{
+		Request:       req,
+		Resp:          httpResp,
+		ExtStatusCode: r.ExtStatusCode,
+		Body:          nd.body,
+		Created:       r.Created,
+		Expiry:        r.Expiry,
+		Language:      r.Language,
+		FromCache:     r.FromCache,
+		nested:        r.nested + 1,
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// findNestedDocuments returns the documents embedded in the page in document order.
+func findNestedDocuments(doc *goquery.Document) []nestedDocument {
+	var docs []nestedDocument
+
+	doc.Find(nestedSelector).Each(func(_ int, s *goquery.Selection) {
+		if srcdoc, found := s.Attr("srcdoc"); found {
+			// The srcdoc attribute takes precedence over the src attribute, the value is already decoded
+			if strings.TrimSpace(srcdoc) != "" {
+				docs = append(docs, nestedDocument{kind: "srcdoc", body: []byte(srcdoc)})
+			}
+			return
+		}
+
+		attr := "src"
+		if goquery.NodeName(s) == "object" {
+			attr = "data"
+		}
+		if body, ok := decodeDataHTML(s.AttrOr(attr, "")); ok {
+			docs = append(docs, nestedDocument{kind: "data", body: body})
+		}
+	})
+
+	return docs
+}
+
+// decodeDataHTML returns the UTF-8 encoded HTML document of a data: URL.
+// The charset parameter of the media type is used, or the encoding is detected from the content.
+// It returns false if the URL is not a valid data: URL of an HTML document.
+func decodeDataHTML(rawURL string) ([]byte, bool) {
+	rawURL = strings.TrimSpace(rawURL)
+	if len(rawURL) < 5 || !strings.EqualFold(rawURL[:5], "data:") {
+		return nil, false
+	}
+
+	meta, data, found := strings.Cut(rawURL[5:], ",")
+	if !found {
+		return nil, false
+	}
+
+	params := strings.Split(meta, ";")
+	isBase64 := len(params) > 1 && strings.EqualFold(strings.TrimSpace(params[len(params)-1]), "base64")
+	if isBase64 {
+		params = params[:len(params)-1]
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, false
+	}
+
+	raw := []byte(data)
+	if unescaped, err := url.PathUnescape(data); err == nil {
+		raw = []byte(unescaped)
+	}
+
+	if isBase64 {
+		enc := strings.Map(func(r rune) rune {
+			if feedTrim(r) {
+				return -1
+			}
+			return r
+		}, string(raw))
+
+		decoded, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			if decoded, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(enc, "=")); err != nil {
+				return nil, false
+			}
+		}
+		raw = decoded
+	}
+
+	rdr, err := charset.NewReader(bytes.NewReader(raw), strings.Join(params, ";"))
+	if err != nil {
+		return nil, false
+	}
+
+	body, err := io.ReadAll(rdr)
+	if err != nil || len(bytes.TrimSpace(body)) == 0 {
+		return nil, false
+	}
+
+	return body, true
+}
@@ -0,0 +1,113 @@
+package colly
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_decodeDataHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		rawURL string
+		want   string
+		wantOk bool
+	}{
+		{"percent encoded", "data:text/html,%3Cp%3Ehi%3C%2Fp%3E", "<p>hi</p>", true},
+		{"plain", "data:text/html;charset=utf-8,<b>x</b>", "<b>x</b>", true},
+		{"base64", "data:text/html;base64,PHA+aGk8L3A+", "<p>hi</p>", true},
+		{"base64 without padding", "DATA:text/html;base64,PGk+eDwvaT4", "<i>x</i>", true},
+		{"latin-1", "data:text/html;charset=iso-8859-1,caf%E9", "café", true},
+		{"xhtml", "data:application/xhtml+xml,<p/>", "<p/>", true},
+		{"not html", "data:image/png;base64,iVBORw0KGgo=", "", false},
+		{"no media type", "data:,hello", "", false},
+		{"no comma", "data:text/html", "", false},
+		{"empty", "data:text/html,", "", false},
+		{"bad base64", "data:text/html;base64,!!!", "", false},
+		{"not data", "https://example.com/", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := decodeDataHTML(tt.rawURL)
+			if ok != tt.wantOk || string(got) != tt.want {
+				t.Errorf("decodeDataHTML() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_findNestedDocuments(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body>
+		<iframe srcdoc="&lt;p&gt;one&lt;/p&gt;" src="data:text/html,ignored"></iframe>
+		<iframe srcdoc="  "></iframe>
+		<iframe src="https://example.com/frame"></iframe>
+		<iframe src="data:text/html,%3Cp%3Etwo%3C%2Fp%3E"></iframe>
+		<object data="data:text/html;base64,PHA+dGhyZWU8L3A+"></object>
+		<object data="data:image/png;base64,iVBORw0KGgo="></object>
+	</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []nestedDocument{
+		{kind: "srcdoc", body: []byte("<p>one</p>")},
+		{kind: "data", body: []byte("<p>two</p>")},
+		{kind: "data", body: []byte("<p>three</p>")},
+	}
+
+	got := findNestedDocuments(doc)
+	if len(got) != len(want) {
+		t.Fatalf("findNestedDocuments() = %d documents, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].kind != want[i].kind || string(got[i].body) != string(want[i].body) {
+			t.Errorf("findNestedDocuments()[%d] = %s %q, want %s %q", i, got[i].kind, got[i].body, want[i].kind, want[i].body)
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestResponse_nestedResponse(t *testing.T) {
+	httpReq := httptest.NewRequest("GET", "https://example.com/dir/page", nil)
+	parent := &Response{
+		Request: &Request{ID: 7, Depth: 2, Req: httpReq},
+		Resp:    &http.Response{Status: "200 OK", StatusCode: http.StatusOK, Header: http.Header{}},
+	}
+
+	first := parent.nestedResponse(nestedDocument{kind: "srcdoc", body: []byte("<p>x</p>")}, 1)
+	if !first.IsNested() || parent.IsNested() {
+		t.Error("IsNested() mismatch")
+	}
+	if got := first.Request.Req.URL.String(); got != "https://example.com/dir/page#nested-srcdoc-1" {
+		t.Errorf("URL = %s", got)
+	}
+	if got := first.Request.OriginalURL().String(); got != "https://example.com/dir/page" {
+		t.Errorf("OriginalURL() = %s, want the URL of the page", got)
+	}
+	if first.Request.ID != 7 || first.Request.Depth != 2 {
+		t.Errorf("Request = %d depth %d, want 7 depth 2", first.Request.ID, first.Request.Depth)
+	}
+	if ct := first.Resp.Header.Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %s", ct)
+	}
+	if parent.Request.Req.URL.Fragment != "" {
+		t.Error("nestedResponse() modified the URL of the page")
+	}
+
+	second := first.nestedResponse(nestedDocument{kind: "data"}, 2)
+	if got := second.Request.Req.URL.Fragment; got != "nested-srcdoc-1.data-2" {
+		t.Errorf("Fragment = %s, want nested-srcdoc-1.data-2", got)
+	}
+	if second.nested != 2 {
+		t.Errorf("nested = %d, want 2", second.nested)
+	}
+}
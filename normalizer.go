@@ -0,0 +1,266 @@
+package colly
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// StripCallback is a callback function to audit the query parameters removed from an URL.
+type StripCallback func(u *url.URL, removed []string)
+
+// ParamStripper removes query parameters from the URLs by global or domain specific rules.
+type ParamStripper struct {
+	rules map[string]*stripRule // rules mapped by the domain names, blank key means global
+	lock  *sync.RWMutex
+}
+
+// stripRule is a list of query parameter patterns.
+type stripRule struct {
+	params   []string         // parameter names, the ones ending with an asterisk match by prefix
+	patterns []*regexp.Regexp // regular expressions matching the parameter names
+}
+
+// normalizingParser is an URL parser that normalizes the parsed URLs.
+type normalizingParser struct {
+	parser   Parser
+	stripper *ParamStripper
+	onStrip  StripCallback
+}
+
+// ------------------------------------------------------------------------
+
+// NewParamStripper returns a pointer to a newly created query parameter stripper.
+// The given parameters are stripped from all URLs, see AddParams for the syntax.
+func NewParamStripper(params ...string) *ParamStripper {
+	s := &ParamStripper{
+		rules: map[string]*stripRule{},
+		lock:  &sync.RWMutex{},
+	}
+	s.AddParams("", params...)
+
+	return s
+}
+
+// ------------------------------------------------------------------------
+
+// AddParams adds parameter names to strip from the URLs of the domain and its subdomains.
+// Blank domain means all domains. Parameters ending with an asterisk match by prefix, e.g. "utm_*".
+func (s *ParamStripper) AddParams(domain string, params ...string) {
+	if len(params) == 0 {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	rule := s.rule(domain)
+	for _, p := range params {
+		if p = strings.TrimSpace(p); p != "" {
+			rule.params = append(rule.params, p)
+		}
+	}
+}
+
+// AddRegexp adds a regular expression to strip the matching parameters from the URLs
+// of the domain and its subdomains, e.g. `(?i)^(phpsessid|jsessionid|sid)$`. Blank domain means all domains.
+func (s *ParamStripper) AddRegexp(domain string, expr string) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	rule := s.rule(domain)
+	rule.patterns = append(rule.patterns, re)
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Strip returns a copy of the URL without the parameters matching the rules of the URL host,
+// along with the sorted names of the removed parameters.
+// The original URL is returned if nothing was removed.
+func (s *ParamStripper) Strip(u *url.URL) (*url.URL, []string) {
+	if s == nil || u == nil || u.RawQuery == "" {
+		return u, nil
+	}
+
+	rules := s.match(u.Hostname())
+	if len(rules) == 0 {
+		return u, nil
+	}
+
+	query := u.Query()
+	var removed []string
+	for key := range query {
+		for _, rule := range rules {
+			if rule.matches(key) {
+				query.Del(key)
+				removed = append(removed, key)
+				break
+			}
+		}
+	}
+
+	if len(removed) == 0 {
+		return u, nil
+	}
+	sort.Strings(removed)
+
+	stripped := *u
+	stripped.RawQuery = query.Encode()
+
+	return &stripped, removed
+}
+
+// ------------------------------------------------------------------------
+
+// rule returns the rule of the domain, creating it if necessary. The caller must hold the lock.
+func (s *ParamStripper) rule(domain string) *stripRule {
+	domain = normalizeDomain(domain)
+
+	rule, present := s.rules[domain]
+	if !present {
+		rule = &stripRule{}
+		s.rules[domain] = rule
+	}
+
+	return rule
+}
+
+// match returns the global rule and the rules of the host and its parent domains.
+func (s *ParamStripper) match(host string) []*stripRule {
+	host = strings.ToLower(host)
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var rules []*stripRule
+	if rule, present := s.rules[""]; present {
+		rules = append(rules, rule)
+	}
+
+	for domain := host; domain != ""; {
+		if rule, present := s.rules[domain]; present {
+			rules = append(rules, rule)
+		}
+
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+
+	return rules
+}
+
+// matches returns true if the parameter name matches the rule.
+func (r *stripRule) matches(key string) bool {
+	if matchParam(key, r.params) {
+		return true
+	}
+
+	for _, re := range r.patterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ------------------------------------------------------------------------
+
+// NewNormalizingParser returns an URL parser that strips the query parameters
+// from the URLs parsed by the underlying parser.
+// The optional callback is called with the removed parameters of each stripped URL.
+func NewNormalizingParser(parser Parser, stripper *ParamStripper, onStrip ...StripCallback) Parser {
+	if parser == nil {
+		parser = NewWHATWGParser()
+	}
+
+	p := &normalizingParser{
+		parser:   parser,
+		stripper: stripper,
+	}
+
+	if len(onStrip) > 0 {
+		p.onStrip = onStrip[0]
+	}
+
+	return p
+}
+
+// ------------------------------------------------------------------------
+
+// Parse parses a raw url into a normalized URL structure.
+func (p *normalizingParser) Parse(rawURL string) (*url.URL, error) {
+	u, err := p.parser.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.normalize(u), nil
+}
+
+// ParseRef parses a raw url with a reference into a normalized URL structure.
+func (p *normalizingParser) ParseRef(rawURL string, ref string) (*url.URL, error) {
+	u, err := p.parser.ParseRef(rawURL, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.normalize(u), nil
+}
+
+// normalize strips the query parameters of the URL.
+func (p *normalizingParser) normalize(u *url.URL) *url.URL {
+	stripped, removed := p.stripper.Strip(u)
+	if len(removed) > 0 && p.onStrip != nil {
+		p.onStrip(u, removed)
+	}
+
+	return stripped
+}
+
+// ------------------------------------------------------------------------
+
+// setNormalizer wraps the URL parser of the configuration with the query parameter stripper,
+// so the parameters are removed before filtering, visiting and caching.
+func (c *Collector) setNormalizer() {
+	if c.Config.ParamStripper == nil {
+		return
+	}
+
+	if _, ok := c.Config.Parser.(*normalizingParser); ok {
+		return
+	}
+
+	var onStrip StripCallback
+	if c.Config.LogStrippedParams {
+		onStrip = c.logStrip
+	}
+
+	c.Config.Parser = NewNormalizingParser(c.Config.Parser, c.Config.ParamStripper, onStrip)
+}
+
+// logStrip logs the query parameters removed from the URL.
+func (c *Collector) logStrip(u *url.URL, removed []string) {
+	if !c.HasLogger() {
+		return
+	}
+
+	c.logEvent(LOG_INFO_LEVEL, "strip", 0, map[string]string{
+		"url":     u.String(),
+		"removed": strings.Join(removed, ","),
+	})
+}
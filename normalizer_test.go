@@ -0,0 +1,86 @@
+package colly
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestParamStripper_Strip(t *testing.T) {
+	s := NewParamStripper("utm_*", "gclid")
+	s.AddParams("example.com", "ref")
+	if err := s.AddRegexp("", `(?i)^(phpsessid|jsessionid|sid)$`); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddRegexp("", `(`); err == nil {
+		t.Error("AddRegexp() with invalid expression returned no error")
+	}
+
+	tests := []struct {
+		name    string
+		rawURL  string
+		want    string
+		removed []string
+	}{
+		{"no query", "http://example.com/a", "http://example.com/a", nil},
+		{"nothing to strip", "http://example.com/a?id=1", "http://example.com/a?id=1", nil},
+		{"global", "http://other.com/?utm_source=x&id=1&gclid=2", "http://other.com/?id=1", []string{"gclid", "utm_source"}},
+		{"regexp", "http://other.com/?PHPSESSID=abc&page=2", "http://other.com/?page=2", []string{"PHPSESSID"}},
+		{"domain", "http://example.com/?ref=home&id=1", "http://example.com/?id=1", []string{"ref"}},
+		{"subdomain", "http://www.Example.com:8080/?ref=home", "http://www.Example.com:8080/", []string{"ref"}},
+		{"other domain", "http://other.com/?ref=home", "http://other.com/?ref=home", nil},
+		{"similar domain", "http://notexample.com/?ref=home", "http://notexample.com/?ref=home", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.rawURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, removed := s.Strip(u)
+			if got.String() != tt.want {
+				t.Errorf("Strip() URL = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(removed, tt.removed) {
+				t.Errorf("Strip() removed = %v, want %v", removed, tt.removed)
+			}
+			if u.String() != tt.rawURL {
+				t.Errorf("Strip() modified the original URL: %q", u)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestNormalizingParser(t *testing.T) {
+	var audit []string
+	p := NewNormalizingParser(NewSimpleParser(), NewParamStripper("fbclid"), func(u *url.URL, removed []string) {
+		audit = append(audit, u.String())
+	})
+
+	u, err := p.Parse("http://example.com/?fbclid=1&q=colly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "http://example.com/?q=colly"; u.String() != want {
+		t.Errorf("Parse() = %q, want %q", u, want)
+	}
+
+	u, err = p.ParseRef("http://example.com/a/", "b?fbclid=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "http://example.com/a/b"; u.String() != want {
+		t.Errorf("ParseRef() = %q, want %q", u, want)
+	}
+
+	want := []string{"http://example.com/?fbclid=1&q=colly", "http://example.com/a/b?fbclid=2"}
+	if !reflect.DeepEqual(audit, want) {
+		t.Errorf("strip callback got %v, want %v", audit, want)
+	}
+}
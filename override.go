@@ -0,0 +1,86 @@
+package colly
+
+import (
+	"mime"
+	"net/http"
+)
+
+// ------------------------------------------------------------------------
+
+// NewContentOverride returns a pointer to a newly created configuration settings that forces
+// the content type and/or the character set of the responses matching the filter.
+// Either value may be empty to keep the declared one.
+func NewContentOverride(filter *Filter, contentType string, charset string) (*SubConfig, error) {
+	if filter == nil {
+		return nil, ErrNoFilterDefined
+	}
+
+	return &SubConfig{
+		Filter:       filter,
+		ForceType:    contentType,
+		ForceCharset: charset,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// contentOverride returns the forced content type and character set of the first
+// sub-configurations matching the request. Empty values mean no override.
+func (c *CollectorConfig) contentOverride(req *Request) (contentType string, charset string) {
+	for _, sc := range c.SubConfigs {
+		if sc == nil || sc.Filter == nil || (sc.ForceType == "" && sc.ForceCharset == "") {
+			continue
+		}
+		if sc.Filter.Match(req) != nil {
+			continue
+		}
+
+		if contentType == "" {
+			contentType = sc.ForceType
+		}
+		if charset == "" {
+			charset = sc.ForceCharset
+		}
+		if contentType != "" && charset != "" {
+			break
+		}
+	}
+
+	return contentType, charset
+}
+
+// overrideContent rewrites the Content-Type header of the response and sets the character
+// encoding of the request according to the matching override rules.
+// The character encoding explicitly set on the request takes precedence.
+func (c *Collector) overrideContent(req *Request, resp *http.Response) {
+	contentType, charset := c.Config.contentOverride(req)
+	if contentType == "" && charset == "" {
+		return
+	}
+
+	if contentType == "" {
+		contentType = hdrVal(resp.Header, "Content-Type")
+	}
+	if charset != "" {
+		if req.CharEncoding == "" {
+			req.CharEncoding = charset
+		}
+		if mediaType, params, err := mime.ParseMediaType(contentType); err == nil {
+			params["charset"] = charset
+			contentType = mime.FormatMediaType(mediaType, params)
+		}
+	}
+
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+	resp.Header.Set("Content-Type", contentType)
+
+	if c.HasLogger() {
+		c.logEvent(LOG_DEBUG_LEVEL, "content_override", req.ID, map[string]string{
+			"url":          req.Req.URL.String(),
+			"content_type": contentType,
+			"charset":      req.CharEncoding,
+		})
+	}
+}
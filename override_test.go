@@ -0,0 +1,56 @@
+package colly
+
+import (
+	"net/http"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollector_overrideContent(t *testing.T) {
+	latin := NewFilter()
+	if err := latin.AddDomainGlob(FILTER_METHOD_INCLUDE, []string{"latin.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	feeds := NewFilter()
+	if err := feeds.AddDomainGlob(FILTER_METHOD_INCLUDE, []string{"*.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := NewConfig()
+	sc1, _ := NewContentOverride(latin, "", "iso-8859-1")
+	sc2, _ := NewContentOverride(feeds, "application/xml", "")
+	cfg.SubConfigs = []*SubConfig{sc1, sc2}
+	c := NewCollector(cfg, nil)
+
+	tests := []struct {
+		name         string
+		url          string
+		header       string
+		charEncoding string
+		wantType     string
+		wantEncoding string
+	}{
+		{name: "both rules", url: "http://latin.example.com/feed", header: "text/plain", wantType: "application/xml; charset=iso-8859-1", wantEncoding: "iso-8859-1"},
+		{name: "request encoding wins", url: "http://latin.example.com/feed", header: "text/plain", charEncoding: "utf-8", wantType: "application/xml; charset=iso-8859-1", wantEncoding: "utf-8"},
+		{name: "type only", url: "http://news.example.com/feed", header: "text/html; charset=utf-8", wantType: "application/xml"},
+		{name: "no match", url: "http://other.com/feed", header: "text/plain", wantType: "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			r := &Request{Req: req, CharEncoding: tt.charEncoding, collector: c}
+			resp := &http.Response{Header: http.Header{"Content-Type": []string{tt.header}}}
+
+			c.overrideContent(r, resp)
+
+			if got := resp.Header.Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if r.CharEncoding != tt.wantEncoding {
+				t.Errorf("CharEncoding = %q, want %q", r.CharEncoding, tt.wantEncoding)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package colly
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// APIPaginator follows the pages of JSON APIs. The next page is taken from the
+// RFC 5988 Link header with rel="next", or from a cursor in the JSON body.
+// The pages are visited until the API has no next page or the page cap is reached.
+type APIPaginator struct {
+	collector   *Collector
+	cursorPath  string         // dot separated path of the cursor in the JSON body, such as "meta.next"
+	cursorParam string         // query parameter of the cursor, if the cursor is not a URL
+	maxPages    int            // maximum number of pages of a paginated URL, 0 for no limit
+	pages       map[string]int // page numbers of the pending page URLs
+	lock        *sync.Mutex
+}
+
+// ------------------------------------------------------------------------
+
+// NewAPIPaginator returns a pointer to a newly created API paginator.
+// The cursor found at the cursor path is followed as a URL if it looks like one,
+// otherwise it is set as the cursor parameter of the current page URL.
+// If the cursor path is empty, only the Link headers are followed.
+// If maxPages is zero, the pages are followed until exhaustion.
+func (c *Collector) NewAPIPaginator(cursorPath, cursorParam string, maxPages int) *APIPaginator {
+	p := &APIPaginator{
+		collector:   c,
+		cursorPath:  cursorPath,
+		cursorParam: cursorParam,
+		maxPages:    maxPages,
+		pages:       map[string]int{},
+		lock:        &sync.Mutex{},
+	}
+	c.sysCallbacks.Add(ON_RESPONSE, NO_ARG, ResponseCallback(p.handleResponse))
+
+	return p
+}
+
+// ------------------------------------------------------------------------
+
+// Paginate visits the first pages of the URLs and follows their next pages.
+func (p *APIPaginator) Paginate(URLs ...string) error {
+	for _, u := range URLs {
+		p.lock.Lock()
+		p.pages[u] = 1
+		p.lock.Unlock()
+
+		if err := p.collector.scrape(u, "GET", 1, nil, nil, nil, false); err != nil {
+			p.lock.Lock()
+			delete(p.pages, u)
+			p.lock.Unlock()
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// handleResponse visits the next page of a paginated response.
+func (p *APIPaginator) handleResponse(resp *Response) {
+	u := resp.Request.Req.URL.String()
+
+	p.lock.Lock()
+	page, present := p.pages[u]
+	delete(p.pages, u)
+	p.lock.Unlock()
+
+	if !present || (p.maxPages > 0 && page >= p.maxPages) {
+		return
+	}
+
+	next := p.nextPage(resp)
+	if next == "" || next == u {
+		return
+	}
+
+	p.lock.Lock()
+	p.pages[next] = page + 1
+	p.lock.Unlock()
+
+	// The next page keeps the headers of the current one, such as the credentials
+	err := resp.Request.submit(next, "GET", resp.Request.Depth, nil, resp.Request.Req.Header.Clone(), false)
+	if err != nil {
+		p.lock.Lock()
+		delete(p.pages, next)
+		p.lock.Unlock()
+		p.collector.Config.logError(LOG_WARN_LEVEL, err)
+	}
+}
+
+// nextPage returns the absolute URL of the next page, or an empty string if there is none.
+func (p *APIPaginator) nextPage(resp *Response) string {
+	if resp.Resp != nil {
+		if next, ok := ParseLinkHeader(resp.Resp.Header.Values("Link"))["next"]; ok {
+			return resp.Request.AbsoluteURL(next)
+		}
+	}
+
+	if p.cursorPath == "" {
+		return ""
+	}
+
+	cursor := jsonCursor(resp.Body, p.cursorPath)
+	if cursor == "" {
+		return ""
+	}
+
+	if strings.Contains(cursor, "://") || strings.HasPrefix(cursor, "/") || strings.HasPrefix(cursor, "?") {
+		return resp.Request.AbsoluteURL(cursor)
+	}
+
+	if p.cursorParam == "" {
+		return ""
+	}
+
+	next := *resp.Request.Req.URL
+	q := next.Query()
+	q.Set(p.cursorParam, cursor)
+	next.RawQuery = q.Encode()
+
+	return next.String()
+}
+
+// ------------------------------------------------------------------------
+
+// ParseLinkHeader parses the RFC 5988 Link header values and returns the target URLs by relation type.
+// The first link of a relation type wins.
+func ParseLinkHeader(values []string) map[string]string {
+	links := map[string]string{}
+
+	for _, value := range values {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			target = target[1 : len(target)-1]
+
+			for _, param := range parts[1:] {
+				key, val, found := strings.Cut(strings.TrimSpace(param), "=")
+				if !found || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				// A relation parameter can hold several space separated types
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(val), `"`)) {
+					rel = strings.ToLower(rel)
+					if _, present := links[rel]; !present {
+						links[rel] = target
+					}
+				}
+			}
+		}
+	}
+
+	return links
+}
+
+// jsonCursor returns the value at the dot separated path of the JSON document as a string.
+// Array elements are addressed by their index. It returns an empty string for missing and null values.
+func jsonCursor(body []byte, path string) string {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return ""
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			v = node[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return ""
+			}
+			v = node[i]
+		default:
+			return ""
+		}
+	}
+
+	switch cursor := v.(type) {
+	case string:
+		return cursor
+	case json.Number:
+		return cursor.String()
+	}
+
+	return ""
+}
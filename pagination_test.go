@@ -0,0 +1,96 @@
+package colly
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestParseLinkHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   map[string]string
+	}{
+		{"empty", nil, map[string]string{}},
+		{
+			"github",
+			[]string{`<https://api.example.com/items?page=2>; rel="next", <https://api.example.com/items?page=5>; rel="last"`},
+			map[string]string{"next": "https://api.example.com/items?page=2", "last": "https://api.example.com/items?page=5"},
+		},
+		{
+			"multiple relations",
+			[]string{`</items?page=2>; rel="next alternate"`, `</other>; rel=next`},
+			map[string]string{"next": "/items?page=2", "alternate": "/items?page=2"},
+		},
+		{"invalid target", []string{`/items?page=2; rel="next"`}, map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseLinkHeader(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLinkHeader() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_jsonCursor(t *testing.T) {
+	body := []byte(`{"meta":{"next":"abc","count":3,"last":null},"links":[{"href":"/p/2"}]}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"meta.next", "abc"},
+		{"meta.count", "3"},
+		{"meta.last", ""},
+		{"meta.missing", ""},
+		{"links.0.href", "/p/2"},
+		{"links.1.href", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := jsonCursor(body, tt.path); got != tt.want {
+				t.Errorf("jsonCursor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIPaginator_nextPage(t *testing.T) {
+	newResp := func(link string, body string) *Response {
+		u, _ := url.Parse("https://api.example.com/items?limit=10")
+		resp := &Response{
+			Request: &Request{Req: &http.Request{URL: u}, Parser: NewSimpleParser()},
+			Resp:    &http.Response{Header: http.Header{}},
+			Body:    []byte(body),
+		}
+		if link != "" {
+			resp.Resp.Header.Set("Link", link)
+		}
+		return resp
+	}
+
+	p := &APIPaginator{cursorPath: "next", cursorParam: "cursor"}
+
+	tests := []struct {
+		name string
+		resp *Response
+		want string
+	}{
+		{"link header", newResp(`</items?page=2>; rel="next"`, `{"next":"x"}`), "https://api.example.com/items?page=2"},
+		{"cursor token", newResp("", `{"next":"x y"}`), "https://api.example.com/items?cursor=x+y&limit=10"},
+		{"cursor url", newResp("", `{"next":"/items?after=9"}`), "https://api.example.com/items?after=9"},
+		{"exhausted", newResp("", `{"next":null}`), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.nextPage(tt.resp); got != tt.want {
+				t.Errorf("nextPage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package colly
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// ParsePoolStats is a snapshot of the parse worker pool metrics.
+type ParsePoolStats struct {
+	Workers   uint          `json:"workers" bson:"workers,omitempty"`       // Workers is the number of the parse workers.
+	QueueSize uint          `json:"queue_size" bson:"queue_size,omitempty"` // QueueSize is the capacity of the parse queue.
+	Queued    uint          `json:"queued" bson:"queued,omitempty"`         // Queued is the number of the responses waiting for a worker.
+	Parsed    uint64        `json:"parsed" bson:"parsed,omitempty"`         // Parsed is the number of the parsed responses.
+	Errors    uint64        `json:"errors" bson:"errors,omitempty"`         // Errors is the number of the parsing errors.
+	WaitTime  time.Duration `json:"wait_time" bson:"wait_time,omitempty"`   // WaitTime is the total time the responses spent in the queue.
+	ParseTime time.Duration `json:"parse_time" bson:"parse_time,omitempty"` // ParseTime is the total time spent on parsing and the callbacks.
+}
+
+// parsePool runs the CPU-bound response parsing on a fixed number of workers,
+// decoupled from the network I/O. Submitting blocks while the queue is full.
+type parsePool struct {
+	jobs      chan parseJob
+	parse     func(*Response) error
+	done      func()
+	workers   uint
+	parsed    uint64 // atomic
+	errors    uint64 // atomic
+	waitTime  int64  // atomic, nanoseconds
+	parseTime int64  // atomic, nanoseconds
+	closeOnce *sync.Once
+}
+
+// parseJob is a response waiting for parsing.
+type parseJob struct {
+	resp   *Response
+	queued time.Time
+}
+
+// ------------------------------------------------------------------------
+
+// newParsePool returns a pointer to a newly created parse worker pool and starts the workers.
+// The parse function is called by the workers, then the done function after every response.
+func newParsePool(workers, queueSize uint, parse func(*Response) error, done func()) *parsePool {
+	if workers == 0 {
+		workers = 1
+	}
+
+	p := &parsePool{
+		jobs:      make(chan parseJob, queueSize),
+		parse:     parse,
+		done:      done,
+		workers:   workers,
+		closeOnce: &sync.Once{},
+	}
+
+	for i := uint(0); i < workers; i++ {
+		go p.work()
+	}
+
+	return p
+}
+
+// ------------------------------------------------------------------------
+
+// Submit queues a response for parsing. It blocks while the queue is full.
+func (p *parsePool) Submit(resp *Response) {
+	p.jobs <- parseJob{resp: resp, queued: time.Now()}
+}
+
+// Close stops the workers after the queued responses were parsed.
+func (p *parsePool) Close() {
+	p.closeOnce.Do(func() { close(p.jobs) })
+}
+
+// Stats returns a snapshot of the pool metrics.
+func (p *parsePool) Stats() ParsePoolStats {
+	return ParsePoolStats{
+		Workers:   p.workers,
+		QueueSize: uint(cap(p.jobs)),
+		Queued:    uint(len(p.jobs)),
+		Parsed:    atomic.LoadUint64(&p.parsed),
+		Errors:    atomic.LoadUint64(&p.errors),
+		WaitTime:  time.Duration(atomic.LoadInt64(&p.waitTime)),
+		ParseTime: time.Duration(atomic.LoadInt64(&p.parseTime)),
+	}
+}
+
+// work parses the queued responses until the pool is closed.
+func (p *parsePool) work() {
+	for job := range p.jobs {
+		start := time.Now()
+		atomic.AddInt64(&p.waitTime, int64(start.Sub(job.queued)))
+
+		if err := p.parse(job.resp); err != nil {
+			atomic.AddUint64(&p.errors, 1)
+		}
+
+		atomic.AddInt64(&p.parseTime, int64(time.Since(start)))
+		atomic.AddUint64(&p.parsed, 1)
+
+		if p.done != nil {
+			p.done()
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// ParseStats returns the metrics of the parse worker pool, or nil if the pool is not used.
+func (c *Collector) ParseStats() *ParsePoolStats {
+	if c.parsePool == nil {
+		return nil
+	}
+
+	stats := c.parsePool.Stats()
+
+	return &stats
+}
+
+// setParsePool creates the parse worker pool if it is enabled by the configuration.
+func (c *Collector) setParsePool() {
+	if c.Config == nil || c.Config.ParseWorkers == 0 {
+		return
+	}
+
+	c.parsePool = newParsePool(c.Config.ParseWorkers, c.Config.ParseQueueSize, c.handleParse, c.wg.Done)
+}
+
+// dispatchParse parses the response on the parse worker pool, or on the calling goroutine
+// if the pool is not used. The pooled responses are waited for by Wait.
+func (c *Collector) dispatchParse(resp *Response) error {
+	if c.parsePool == nil {
+		return c.handleParse(resp)
+	}
+
+	c.wg.Add(1)
+	c.parsePool.Submit(resp)
+
+	return nil
+}
+
+// handleParse runs the HTML and XML callbacks, then the scraped callbacks of the response.
+// The HTML and XML callbacks are skipped for the cached responses if the configuration says so.
+func (c *Collector) handleParse(resp *Response) error {
+	if c.skipCachedCallbacks(resp) {
+		c.handleOnScraped(resp)
+		return nil
+	}
+
+	htmlErr := c.handleOnHTML(resp)
+	if htmlErr != nil {
+		c.handleOnError(resp, htmlErr, nil)
+	}
+
+	xmlErr := c.handleOnXML(resp)
+	if xmlErr != nil {
+		c.handleOnError(resp, xmlErr, nil)
+	}
+
+	c.handleOnScraped(resp)
+
+	if htmlErr != nil {
+		return htmlErr
+	}
+
+	return xmlErr
+}
@@ -0,0 +1,54 @@
+package colly
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_parsePool(t *testing.T) {
+	const responses = 100
+
+	var active, maxActive int32
+	wg := &sync.WaitGroup{}
+
+	p := newParsePool(4, 2, func(resp *Response) error {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			m := atomic.LoadInt32(&maxActive)
+			if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+				break
+			}
+		}
+		defer atomic.AddInt32(&active, -1)
+
+		if resp.ExtStatusCode%10 == 0 {
+			return errors.New("parse error")
+		}
+		return nil
+	}, wg.Done)
+	defer p.Close()
+
+	for i := 0; i < responses; i++ {
+		wg.Add(1)
+		p.Submit(&Response{ExtStatusCode: uint(i)})
+	}
+	wg.Wait()
+
+	stats := p.Stats()
+	if stats.Parsed != responses {
+		t.Errorf("Parsed = %d, want %d", stats.Parsed, responses)
+	}
+	if stats.Errors != responses/10 {
+		t.Errorf("Errors = %d, want %d", stats.Errors, responses/10)
+	}
+	if stats.Workers != 4 || stats.QueueSize != 2 || stats.Queued != 0 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+	if m := atomic.LoadInt32(&maxActive); m > 4 {
+		t.Errorf("%d concurrent parses, want at most 4", m)
+	}
+}
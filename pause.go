@@ -0,0 +1,291 @@
+package colly
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// domainPauser parks the requests of the paused hosts, or of all hosts while the whole
+// collector is paused, until they are resumed.
+type domainPauser struct {
+	parked map[string][]*Request // parked requests mapped by the paused host names
+	all    bool                  // true while the whole collector is paused
+	held   []*Request            // requests parked while the whole collector is paused
+	lock   *sync.Mutex
+}
+
+// ------------------------------------------------------------------------
+
+// newDomainPauser returns a pointer to a newly created domain pauser.
+func newDomainPauser() *domainPauser {
+	return &domainPauser{
+		parked: map[string][]*Request{},
+		lock:   &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// PauseDomain stops dispatching new requests to the host.
+// The requests made to the host are parked in a side buffer until ResumeDomain is called,
+// the requests already in progress and the requests of other hosts are not affected.
+func (c *Collector) PauseDomain(domain string) {
+	if !c.paused.pause(domain) || !c.HasLogger() {
+		return
+	}
+
+	c.logEvent(LOG_INFO_LEVEL, "pause", 0, map[string]string{
+		"domain": domain,
+	})
+}
+
+// ResumeDomain restarts dispatching requests to the host and resubmits the parked requests
+// in their original order. It returns the first error of the resubmitted requests.
+func (c *Collector) ResumeDomain(domain string) error {
+	parked, ok := c.paused.resume(domain)
+	if !ok {
+		return nil
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_INFO_LEVEL, "resume", 0, map[string]string{
+			"domain": domain,
+			"parked": strconv.Itoa(len(parked)),
+		})
+	}
+
+	var firstErr error
+	for _, r := range parked {
+		if err := r.Retry(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// IsDomainPaused returns true if the host is paused.
+func (c *Collector) IsDomainPaused(domain string) bool {
+	return c.paused.isPaused(domain)
+}
+
+// PausedDomains returns the sorted list of the paused hosts.
+func (c *Collector) PausedDomains() []string {
+	return c.paused.domains()
+}
+
+// Pause stops dispatching new requests of all hosts. The requests are parked until Resume is called,
+// the requests already in progress are finished. Call Wait after Pause to wait for them,
+// e.g. before exporting the state of the collector by ExportState.
+func (c *Collector) Pause() {
+	if !c.paused.pauseAll() || !c.HasLogger() {
+		return
+	}
+
+	c.logEvent(LOG_INFO_LEVEL, "pause_collector", 0, map[string]string{})
+}
+
+// Resume restarts dispatching requests and resubmits the requests parked by Pause
+// in their original order. The requests of the hosts paused by PauseDomain stay parked.
+// It returns the first error of the resubmitted requests.
+func (c *Collector) Resume() error {
+	held, ok := c.paused.resumeAll()
+	if !ok {
+		return nil
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_INFO_LEVEL, "resume_collector", 0, map[string]string{
+			"parked": strconv.Itoa(len(held)),
+		})
+	}
+
+	var firstErr error
+	for _, r := range held {
+		if err := r.Retry(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// IsPaused returns true if the whole collector is paused.
+func (c *Collector) IsPaused() bool {
+	return c.paused.isPausedAll()
+}
+
+// ------------------------------------------------------------------------
+
+// parkRequest parks the request if its host is paused.
+// It returns true if the request was parked and must not be dispatched.
+func (c *Collector) parkRequest(r *Request) bool {
+	if r.Req == nil || r.Req.URL == nil || !c.paused.park(r) {
+		return false
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_DEBUG_LEVEL, "park", r.ID, map[string]string{
+			"url": r.Req.URL.String(),
+		})
+	}
+
+	return true
+}
+
+// ------------------------------------------------------------------------
+
+// pause marks the host as paused. It returns false if it was already paused.
+func (p *domainPauser) pause(domain string) bool {
+	domain = normalizeDomain(domain)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, present := p.parked[domain]; present {
+		return false
+	}
+
+	p.parked[domain] = []*Request{}
+
+	return true
+}
+
+// resume removes the pause of the host and returns its parked requests.
+// It returns false if the host was not paused.
+func (p *domainPauser) resume(domain string) ([]*Request, bool) {
+	domain = normalizeDomain(domain)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	parked, present := p.parked[domain]
+	if !present {
+		return nil, false
+	}
+
+	delete(p.parked, domain)
+
+	return parked, true
+}
+
+// pauseAll marks the whole collector as paused. It returns false if it was already paused.
+func (p *domainPauser) pauseAll() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.all {
+		return false
+	}
+
+	p.all = true
+
+	return true
+}
+
+// resumeAll removes the pause of the collector and returns the requests held during the pause.
+// It returns false if the collector was not paused.
+func (p *domainPauser) resumeAll() ([]*Request, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if !p.all {
+		return nil, false
+	}
+
+	held := p.held
+	p.all = false
+	p.held = nil
+
+	return held, true
+}
+
+// isPausedAll returns true if the whole collector is paused.
+func (p *domainPauser) isPausedAll() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.all
+}
+
+// requests returns the parked requests, the requests held by the collector pause first,
+// followed by the requests of the paused hosts in host order.
+func (p *domainPauser) requests() []*Request {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	domains := make([]string, 0, len(p.parked))
+	for domain := range p.parked {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	reqs := append([]*Request{}, p.held...)
+	for _, domain := range domains {
+		reqs = append(reqs, p.parked[domain]...)
+	}
+
+	return reqs
+}
+
+// park appends the request to the side buffer of the collector pause or of its host if the host is paused.
+func (p *domainPauser) park(r *Request) bool {
+	domain := normalizeDomain(r.Req.URL.Hostname())
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.all {
+		p.held = append(p.held, r)
+		return true
+	}
+
+	parked, present := p.parked[domain]
+	if !present {
+		return false
+	}
+
+	p.parked[domain] = append(parked, r)
+
+	return true
+}
+
+// isPaused returns true if the host is paused.
+func (p *domainPauser) isPaused(domain string) bool {
+	domain = normalizeDomain(domain)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	_, present := p.parked[domain]
+
+	return present
+}
+
+// domains returns the sorted list of the paused hosts.
+func (p *domainPauser) domains() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	domains := make([]string, 0, len(p.parked))
+	for domain := range p.parked {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	return domains
+}
+
+// ------------------------------------------------------------------------
+
+// normalizeDomain returns the lower case host name without the port number.
+func normalizeDomain(domain string) string {
+	u := &url.URL{Host: strings.ToLower(strings.TrimSpace(domain))}
+
+	return u.Hostname()
+}
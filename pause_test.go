@@ -0,0 +1,143 @@
+package colly
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestDomainPauser(t *testing.T) {
+	newReq := func(rawURL string) *Request {
+		req, err := http.NewRequest("GET", rawURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &Request{Req: req}
+	}
+
+	p := newDomainPauser()
+
+	if !p.pause("Example.com") {
+		t.Fatal("pause() = false, want true")
+	}
+	if p.pause("example.com:8080") {
+		t.Error("second pause() = true, want false")
+	}
+
+	r1 := newReq("http://example.com/a")
+	r2 := newReq("http://other.com/b")
+	r3 := newReq("https://EXAMPLE.com:443/c")
+
+	if !p.park(r1) {
+		t.Error("park() of a paused host = false, want true")
+	}
+	if p.park(r2) {
+		t.Error("park() of an active host = true, want false")
+	}
+	if !p.park(r3) {
+		t.Error("park() of a paused host = false, want true")
+	}
+
+	if got, want := p.domains(), []string{"example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("domains() = %v, want %v", got, want)
+	}
+	if !p.isPaused("EXAMPLE.COM") {
+		t.Error("isPaused() = false, want true")
+	}
+
+	parked, ok := p.resume("example.com")
+	if !ok {
+		t.Fatal("resume() = false, want true")
+	}
+	if want := []*Request{r1, r3}; !reflect.DeepEqual(parked, want) {
+		t.Errorf("resume() returned %d requests, want %d in order", len(parked), len(want))
+	}
+
+	if p.isPaused("example.com") {
+		t.Error("isPaused() after resume = true, want false")
+	}
+	if _, ok := p.resume("example.com"); ok {
+		t.Error("second resume() = true, want false")
+	}
+	if p.park(newReq("http://example.com/d")) {
+		t.Error("park() after resume = true, want false")
+	}
+}
+
+func TestDomainPauser_All(t *testing.T) {
+	newReq := func(rawURL string) *Request {
+		req, err := http.NewRequest("GET", rawURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &Request{Req: req}
+	}
+
+	p := newDomainPauser()
+	p.pause("example.com")
+
+	if !p.pauseAll() {
+		t.Fatal("pauseAll() = false, want true")
+	}
+	if p.pauseAll() {
+		t.Error("second pauseAll() = true, want false")
+	}
+
+	r1 := newReq("http://other.com/a")
+	r2 := newReq("http://example.com/b")
+	if !p.park(r1) || !p.park(r2) {
+		t.Fatal("park() while paused = false, want true")
+	}
+	if !p.isPausedAll() {
+		t.Error("isPausedAll() = false, want true")
+	}
+
+	if got, want := p.requests(), []*Request{r1, r2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("requests() returned %d requests, want %d in order", len(got), len(want))
+	}
+
+	held, ok := p.resumeAll()
+	if !ok {
+		t.Fatal("resumeAll() = false, want true")
+	}
+	if want := []*Request{r1, r2}; !reflect.DeepEqual(held, want) {
+		t.Errorf("resumeAll() returned %d requests, want %d in order", len(held), len(want))
+	}
+	if _, ok := p.resumeAll(); ok {
+		t.Error("second resumeAll() = true, want false")
+	}
+
+	// The host pause outlives the collector pause
+	if p.park(r1) {
+		t.Error("park() of an active host after resumeAll = true, want false")
+	}
+	if !p.park(r2) {
+		t.Error("park() of a paused host after resumeAll = false, want true")
+	}
+	if got := p.requests(); len(got) != 1 || got[0] != r2 {
+		t.Errorf("requests() = %v, want the parked request of the paused host", got)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestNormalizeDomain(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   string
+	}{
+		{"example.com", "example.com"},
+		{" Example.COM ", "example.com"},
+		{"example.com:8080", "example.com"},
+		{"[::1]:80", "::1"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeDomain(tt.domain); got != tt.want {
+			t.Errorf("normalizeDomain(%q) = %q, want %q", tt.domain, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package colly
+
+import (
+	"bufio"
+	"bytes"
+	"colly/storage/mem"
+	"encoding/xml"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// PollStorage keeps the last-seen modification times of the sitemap and feed entries.
+// Any key-value storage with this interface can be used, such as the cookie storages.
+type PollStorage interface {
+	Set(key string, data io.Reader) error // Set stores the data by key.
+	Get(key string) (io.Reader, error)    // Get retrieves the data by key.
+}
+
+// PollEntry is an entry of a sitemap or a feed.
+type PollEntry struct {
+	URL      string    `json:"url" bson:"url,omitempty"`           // URL is the location of the entry.
+	Modified time.Time `json:"modified" bson:"modified,omitempty"` // Modified is the last modification time of the entry, zero if unknown.
+}
+
+// Poller polls sitemaps and feeds and visits only their new and updated entries.
+// The polled sources are visited again after every interval by the scheduler of the collector.
+type Poller struct {
+	collector *Collector
+	stg       PollStorage
+	interval  time.Duration
+	sources   map[string]bool // polled source URLs, true for the periodically polled ones
+	stopped   bool
+	lock      *sync.Mutex
+}
+
+// pollDocument is a sitemap, a sitemap index, an RSS or an Atom feed.
+type pollDocument struct {
+	URLs []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"sitemap"`
+	Items []struct {
+		Link    string `xml:"link"`
+		PubDate string `xml:"pubDate"`
+		Date    string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	} `xml:"channel>item"`
+	Entries []struct {
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Updated   string `xml:"updated"`
+		Published string `xml:"published"`
+	} `xml:"entry"`
+}
+
+// ------------------------------------------------------------------------
+
+// pollTimeLayouts are the date formats used by the sitemaps and the feeds.
+var pollTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+}
+
+// ------------------------------------------------------------------------
+
+// NewPoller returns a pointer to a newly created sitemap and feed poller.
+// If no storage is given, the last-seen times are kept in the memory.
+// If the interval is zero, the sources are polled only once.
+func (c *Collector) NewPoller(stg PollStorage, interval time.Duration) *Poller {
+	if stg == nil {
+		stg = mem.NewCookieStorage()
+	}
+
+	p := &Poller{
+		collector: c,
+		stg:       stg,
+		interval:  interval,
+		sources:   map[string]bool{},
+		lock:      &sync.Mutex{},
+	}
+	c.sysCallbacks.Add(ON_RESPONSE, NO_ARG, ResponseCallback(p.handleResponse))
+
+	return p
+}
+
+// ------------------------------------------------------------------------
+
+// Poll visits the sitemaps and feeds, then visits the new and updated entries.
+// The sources are polled again after every interval until Stop is called.
+func (p *Poller) Poll(sources ...string) error {
+	p.lock.Lock()
+	p.stopped = false
+	for _, src := range sources {
+		p.sources[src] = true
+	}
+	p.lock.Unlock()
+
+	for _, src := range sources {
+		if err := p.collector.scrape(src, "GET", 1, nil, nil, nil, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stop stops the periodic polling. The polls already scheduled are not submitted again.
+func (p *Poller) Stop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.stopped = true
+}
+
+// ------------------------------------------------------------------------
+
+// handleResponse parses the responses of the polled sources and visits the changed entries.
+func (p *Poller) handleResponse(resp *Response) {
+	src := resp.Request.Req.URL.String()
+
+	p.lock.Lock()
+	periodic, present := p.sources[src]
+	stopped := p.stopped
+	p.lock.Unlock()
+
+	if !present {
+		return
+	}
+
+	if periodic && !stopped && p.interval > 0 {
+		p.reschedule(resp.Request)
+	}
+
+	entries, sitemaps, err := ParsePollEntries(resp.Body)
+	if err != nil {
+		p.collector.Config.logError(LOG_WARN_LEVEL, err)
+		return
+	}
+
+	// Nested sitemaps are polled only when the index reports a change
+	for _, e := range sitemaps {
+		e.URL = resp.Request.AbsoluteURL(e.URL)
+		p.lock.Lock()
+		if _, present := p.sources[e.URL]; !present {
+			p.sources[e.URL] = false
+		}
+		p.lock.Unlock()
+		p.visit(e, int(resp.Request.Depth))
+	}
+
+	for _, e := range entries {
+		e.URL = resp.Request.AbsoluteURL(e.URL)
+		p.visit(e, int(resp.Request.Depth)+1)
+	}
+}
+
+// reschedule schedules the next poll of a source.
+func (p *Poller) reschedule(r *Request) {
+	next, err := r.Clone("GET", r.Req.URL.String(), nil)
+	if err != nil {
+		p.collector.Config.logError(LOG_WARN_LEVEL, err)
+		return
+	}
+
+	next.Depth = r.Depth
+	next.NotBefore = time.Now().Add(p.interval)
+
+	if err := p.collector.Schedule(next); err != nil {
+		p.collector.Config.logError(LOG_WARN_LEVEL, err)
+	}
+}
+
+// visit visits an entry if it is new or it was modified since the last visit.
+func (p *Poller) visit(e PollEntry, depth int) {
+	if e.URL == "" || !p.changed(e) {
+		return
+	}
+
+	if err := p.collector.scrape(e.URL, "GET", depth, nil, nil, nil, false); err != nil {
+		p.collector.Config.logError(LOG_WARN_LEVEL, err)
+		return
+	}
+
+	if err := p.stg.Set(e.URL, strings.NewReader(e.Modified.UTC().Format(time.RFC3339))); err != nil {
+		p.collector.Config.logError(LOG_WARN_LEVEL, err)
+	}
+}
+
+// changed returns true if the entry was never seen or it was modified since it was last seen.
+func (p *Poller) changed(e PollEntry) bool {
+	rdr, err := p.stg.Get(e.URL)
+	if err != nil || rdr == nil {
+		return true
+	}
+
+	data, err := io.ReadAll(rdr)
+	if err != nil {
+		return true
+	}
+
+	seen, err := time.Parse(time.RFC3339, string(data))
+	if err != nil {
+		return true
+	}
+
+	return e.Modified.After(seen)
+}
+
+// ------------------------------------------------------------------------
+
+// ParsePollEntries parses a sitemap, a sitemap index, an RSS or an Atom feed.
+// It returns the page entries and the nested sitemaps of a sitemap index separately.
+// Compressed and text sitemaps are supported like by StreamSitemap.
+func ParsePollEntries(body []byte) (entries []PollEntry, sitemaps []PollEntry, err error) {
+	rc, err := openFeed(bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rc.Close()
+
+	br := bufio.NewReader(rc)
+	if textFeed(br) {
+		err := streamTextSitemap(br, func(e PollEntry, _ bool) error {
+			entries = append(entries, e)
+			return nil
+		})
+		return entries, nil, err
+	}
+
+	doc := &pollDocument{}
+
+	dec := xml.NewDecoder(br)
+	dec.Strict = false
+	dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	if err := dec.Decode(doc); err != nil {
+		return nil, nil, err
+	}
+
+	for _, u := range doc.URLs {
+		entries = append(entries, PollEntry{URL: strings.TrimSpace(u.Loc), Modified: parsePollTime(u.LastMod)})
+	}
+
+	for _, s := range doc.Sitemaps {
+		sitemaps = append(sitemaps, PollEntry{URL: strings.TrimSpace(s.Loc), Modified: parsePollTime(s.LastMod)})
+	}
+
+	for _, item := range doc.Items {
+		modified := parsePollTime(item.PubDate)
+		if modified.IsZero() {
+			modified = parsePollTime(item.Date)
+		}
+		entries = append(entries, PollEntry{URL: strings.TrimSpace(item.Link), Modified: modified})
+	}
+
+	for _, entry := range doc.Entries {
+		e := PollEntry{Modified: parsePollTime(entry.Updated)}
+		if e.Modified.IsZero() {
+			e.Modified = parsePollTime(entry.Published)
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "" || link.Rel == "alternate" {
+				e.URL = strings.TrimSpace(link.Href)
+				break
+			}
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, sitemaps, nil
+}
+
+// parsePollTime parses a date of a sitemap or a feed, or returns zero time.
+func parsePollTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+
+	for _, layout := range pollTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
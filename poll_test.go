@@ -0,0 +1,110 @@
+package colly
+
+import (
+	"colly/storage/mem"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestParsePollEntries(t *testing.T) {
+	day := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		body         string
+		wantEntries  []PollEntry
+		wantSitemaps []PollEntry
+	}{
+		{
+			name: "sitemap",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc> https://example.com/a </loc><lastmod>2023-05-01</lastmod></url>
+	<url><loc>https://example.com/b</loc></url>
+</urlset>`,
+			wantEntries: []PollEntry{
+				{URL: "https://example.com/a", Modified: day},
+				{URL: "https://example.com/b"},
+			},
+		},
+		{
+			name: "sitemap index",
+			body: `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>https://example.com/s1.xml</loc><lastmod>2023-05-01T00:00:00Z</lastmod></sitemap>
+</sitemapindex>`,
+			wantSitemaps: []PollEntry{
+				{URL: "https://example.com/s1.xml", Modified: day},
+			},
+		},
+		{
+			name: "rss",
+			body: `<rss version="2.0"><channel><title>t</title>
+	<item><link>https://example.com/p</link><pubDate>Mon, 01 May 2023 00:00:00 +0000</pubDate></item>
+</channel></rss>`,
+			wantEntries: []PollEntry{
+				{URL: "https://example.com/p", Modified: day},
+			},
+		},
+		{
+			name: "atom",
+			body: `<feed xmlns="http://www.w3.org/2005/Atom">
+	<entry><link rel="self" href="https://example.com/self"/><link href="https://example.com/e"/><updated>2023-05-01T00:00:00Z</updated></entry>
+</feed>`,
+			wantEntries: []PollEntry{
+				{URL: "https://example.com/e", Modified: day},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, sitemaps, err := ParsePollEntries([]byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range entries {
+				entries[i].Modified = entries[i].Modified.UTC()
+			}
+			if !reflect.DeepEqual(entries, tt.wantEntries) {
+				t.Errorf("entries = %v, want %v", entries, tt.wantEntries)
+			}
+			if !reflect.DeepEqual(sitemaps, tt.wantSitemaps) {
+				t.Errorf("sitemaps = %v, want %v", sitemaps, tt.wantSitemaps)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestPoller_changed(t *testing.T) {
+	day := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	p := &Poller{stg: mem.NewCookieStorage()}
+
+	if !p.changed(PollEntry{URL: "u", Modified: day}) {
+		t.Error("new entry is not changed")
+	}
+
+	p.stg.Set("u", strings.NewReader(day.Format(time.RFC3339)))
+
+	tests := []struct {
+		name     string
+		modified time.Time
+		want     bool
+	}{
+		{"same", day, false},
+		{"older", day.Add(-time.Hour), false},
+		{"newer", day.Add(time.Hour), true},
+		{"unknown", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.changed(PollEntry{URL: "u", Modified: tt.modified}); got != tt.want {
+				t.Errorf("changed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
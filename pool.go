@@ -0,0 +1,156 @@
+package colly
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
+	"golang.org/x/net/html"
+)
+
+// ------------------------------------------------------------------------
+
+// maxPooledBufferSize is the capacity limit of the buffers kept in the pool.
+// Larger buffers are left to the garbage collector to avoid holding memory.
+const maxPooledBufferSize = 4 * 1024 * 1024
+
+// maxPooledLogBufferSize is the capacity limit of the log line buffers kept in the pool.
+const maxPooledLogBufferSize = 64 * 1024
+
+// ------------------------------------------------------------------------
+
+var (
+	bufferPool      = sync.Pool{New: func() any { return &bytes.Buffer{} }}
+	htmlElementPool = sync.Pool{New: func() any { return &HTMLElement{} }}
+	xmlElementPool  = sync.Pool{New: func() any { return &XMLElement{} }}
+	logBufferPool   = sync.Pool{New: func() any { b := make([]byte, 0, 256); return &b }}
+	loggerEventPool = sync.Pool{New: func() any { return &LoggerEvent{} }}
+)
+
+// ------------------------------------------------------------------------
+
+// Release returns the body buffer of the response to the pool.
+// The Body must not be used after calling Release; use RetainBody to keep a copy.
+// Calling Release is optional, unreleased buffers are collected by the garbage collector.
+func (r *Response) Release() {
+	if r.buf == nil {
+		return
+	}
+
+	releaseBuffer(r.buf)
+	r.buf = nil
+	r.Body = nil
+}
+
+// RetainBody returns a copy of the response body that remains valid after Release.
+func (r *Response) RetainBody() []byte {
+	if r.Body == nil {
+		return nil
+	}
+
+	body := make([]byte, len(r.Body))
+	copy(body, r.Body)
+
+	return body
+}
+
+// ------------------------------------------------------------------------
+
+func acquireBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func releaseBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// ------------------------------------------------------------------------
+
+// acquireHTMLElement returns a pooled HTMLElement created from a goquery.Selection Node.
+func acquireHTMLElement(resp *Response, s *goquery.Selection, n *html.Node, idx int) *HTMLElement {
+	e := htmlElementPool.Get().(*HTMLElement)
+	e.Name = n.Data
+	e.Response = resp
+	e.Text = goquery.NewDocumentFromNode(n).Text()
+	e.DOM = s
+	e.Index = idx
+	e.attributes = n.Attr
+
+	return e
+}
+
+func releaseHTMLElement(e *HTMLElement) {
+	*e = HTMLElement{}
+	htmlElementPool.Put(e)
+}
+
+// ------------------------------------------------------------------------
+
+// acquireXMLElementFromXMLNode returns a pooled XMLElement created from a xmlquery.Node.
+func acquireXMLElementFromXMLNode(resp *Response, n *xmlquery.Node) *XMLElement {
+	e := xmlElementPool.Get().(*XMLElement)
+	e.Name = n.Data
+	e.Response = resp
+	e.Text = n.InnerText()
+	e.DOM = n
+	e.attributes = n.Attr
+	e.isHTML = false
+
+	return e
+}
+
+// acquireXMLElementFromHTMLNode returns a pooled XMLElement created from a html.Node.
+func acquireXMLElementFromHTMLNode(resp *Response, n *html.Node) *XMLElement {
+	e := xmlElementPool.Get().(*XMLElement)
+	e.Name = n.Data
+	e.Response = resp
+	e.Text = htmlquery.InnerText(n)
+	e.DOM = n
+	e.attributes = n.Attr
+	e.isHTML = true
+
+	return e
+}
+
+func releaseXMLElement(e *XMLElement) {
+	*e = XMLElement{}
+	xmlElementPool.Put(e)
+}
+
+// ------------------------------------------------------------------------
+
+func acquireLogBuffer() *[]byte {
+	return logBufferPool.Get().(*[]byte)
+}
+
+func releaseLogBuffer(buf *[]byte) {
+	if cap(*buf) > maxPooledLogBufferSize {
+		return
+	}
+
+	*buf = (*buf)[:0]
+	logBufferPool.Put(buf)
+}
+
+// acquireLoggerEvent returns a pooled LoggerEvent.
+func acquireLoggerEvent(eventType string, collectorID uint32, requestID uint32, args map[string]string) *LoggerEvent {
+	e := loggerEventPool.Get().(*LoggerEvent)
+	e.Type = eventType
+	e.CollectorID = collectorID
+	e.RequestID = requestID
+	e.Values = args
+
+	return e
+}
+
+func releaseLoggerEvent(e *LoggerEvent) {
+	*e = LoggerEvent{}
+	loggerEventPool.Put(e)
+}
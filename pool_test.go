@@ -0,0 +1,102 @@
+package colly
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ------------------------------------------------------------------------
+
+var benchBody = []byte(strings.Repeat("<p>Lorem ipsum dolor sit amet.</p>", 2048))
+
+func benchHTTPResponse() *http.Response {
+	u, _ := url.Parse("http://example.com/")
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+		Body:       io.NopCloser(bytes.NewReader(benchBody)),
+		Request:    &http.Request{URL: u},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestResponse_Release(t *testing.T) {
+	r := &Response{buf: acquireBuffer()}
+	r.buf.Write([]byte("body"))
+	r.Body = r.buf.Bytes()
+
+	retained := r.RetainBody()
+	r.Release()
+
+	if r.Body != nil || r.buf != nil {
+		t.Errorf("Response.Release() Body = %v, buf = %v, want nil", r.Body, r.buf)
+	}
+	if string(retained) != "body" {
+		t.Errorf("Response.RetainBody() = %q, want %q", retained, "body")
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func BenchmarkResponseBody(b *testing.B) {
+	req := &Request{}
+
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resp, err := NewResponse(req, benchHTTPResponse(), false, 0)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_ = resp.RetainBody()
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resp, err := NewResponse(req, benchHTTPResponse(), false, 0)
+			if err != nil {
+				b.Fatal(err)
+			}
+			resp.Release()
+		}
+	})
+}
+
+// ------------------------------------------------------------------------
+
+func BenchmarkHTMLElement(b *testing.B) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(benchBody))
+	if err != nil {
+		b.Fatal(err)
+	}
+	sel := doc.Find("p")
+	resp := &Response{}
+
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j, n := range sel.Nodes {
+				_ = NewHTMLElementFromSelectionNode(resp, sel, n, j)
+			}
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j, n := range sel.Nodes {
+				releaseHTMLElement(acquireHTMLElement(resp, sel, n, j))
+			}
+		}
+	})
+}
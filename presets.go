@@ -0,0 +1,267 @@
+package colly
+
+import (
+	"colly/filters"
+	"colly/storage/badger"
+	"colly/storage/sqlite3"
+	"os"
+	"path/filepath"
+)
+
+// ------------------------------------------------------------------------
+
+// PersistentStorages is a set of matching storages of a resumable crawler.
+type PersistentStorages struct {
+	Visits  filters.VisitStorage // Visits is the storage of the visited URLs.
+	Cookies CookieStorage        // Cookies is the storage of the cookie jar.
+	Cache   CacheStorage         // Cache is the storage of the cached responses.
+	Queue   Queue                // Queue is the storage of the job queue.
+}
+
+// ------------------------------------------------------------------------
+
+// Names of the persistent storages
+const (
+	PERSISTENT_SQLITE3_FILE = "colly.db" // SQLite3 database file in the data directory.
+	PERSISTENT_BADGER_DIR   = "badger"   // BadgerDB directory in the data directory.
+
+	PERSISTENT_VISIT_TABLE  = "visits"  // SQLite3 table of the visited URLs.
+	PERSISTENT_COOKIE_TABLE = "cookies" // SQLite3 table of the cookies.
+	PERSISTENT_CACHE_TABLE  = "cache"   // SQLite3 table of the cached responses.
+	PERSISTENT_QUEUE_TABLE  = "queue"   // SQLite3 table of the job queue.
+)
+
+// ------------------------------------------------------------------------
+
+// NewSQLite3Storages returns the visit, cookie, cache and queue storages sharing
+// a single SQLite3 database in the data directory. The directory is created if it doesn't exist.
+// If keepData is false, the storages are cleared on opening.
+func NewSQLite3Storages(dataDir string, keepData bool) (*PersistentStorages, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dataDir, PERSISTENT_SQLITE3_FILE)
+	s := &PersistentStorages{}
+
+	visits, err := sqlite3.NewVisitStorage(path, PERSISTENT_VISIT_TABLE, keepData)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+	s.Visits = visits
+
+	cookies, err := sqlite3.NewCookieStorage(path, PERSISTENT_COOKIE_TABLE, keepData)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+	s.Cookies = cookies
+
+	cache, err := sqlite3.NewCacheStorage(path, PERSISTENT_CACHE_TABLE, keepData)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+	s.Cache = cache
+
+	queue, err := sqlite3.NewFIFOStorage(path, PERSISTENT_QUEUE_TABLE, keepData)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+	s.Queue = queue
+
+	return s, nil
+}
+
+// NewBadgerStorages returns the visit, cookie, cache and queue storages sharing
+// a single BadgerDB database in the data directory. The directory is created if it doesn't exist.
+// If keepData is false, the storages are cleared on opening.
+func NewBadgerStorages(dataDir string, keepData bool) (*PersistentStorages, error) {
+	path := filepath.Join(dataDir, PERSISTENT_BADGER_DIR)
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &PersistentStorages{}
+
+	visits, err := badger.NewVisitStorage(path, keepData)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+	s.Visits = visits
+
+	cookies, err := badger.NewCookieStorage(path, keepData)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+	s.Cookies = cookies
+
+	cache, err := badger.NewCacheStorage(path, keepData)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+	s.Cache = cache
+
+	queue, err := badger.NewFIFOStorage(path, keepData)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+	s.Queue = queue
+
+	return s, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Apply attaches the storages to the collector configuration.
+// The visited URLs are not revisited and the cache expires by the response headers.
+func (s *PersistentStorages) Apply(c *CollectorConfig) error {
+	if s.Visits != nil {
+		if err := c.SetMaxRevisits(0, s.Visits); err != nil {
+			return err
+		}
+	}
+
+	if s.Cookies != nil {
+		if err := c.SetCookieJar(s.Cookies, COOKIE_MODE_STRICT); err != nil {
+			return err
+		}
+	}
+
+	if s.Cache != nil {
+		if err := c.SetCache(s.Cache, NewCacheExpiryByHeader()); err != nil {
+			return err
+		}
+	}
+
+	if s.Queue != nil {
+		if err := c.SetQueue(s.Queue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Clear removes all data from the storages.
+// It returns the first error, but clears all storages.
+func (s *PersistentStorages) Clear() error {
+	var first error
+
+	for _, stg := range s.list() {
+		var err error
+		if q, ok := stg.(Queue); ok {
+			err = q.Clear()
+		} else if b, ok := stg.(interface{ Clear() error }); ok {
+			err = b.Clear()
+		}
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+// Close closes the storages, keeping their data for the next run.
+// It returns the first error, but closes all storages.
+func (s *PersistentStorages) Close() error {
+	var first error
+
+	for _, stg := range s.list() {
+		if closer, ok := stg.(interface{ Close() error }); ok {
+			if err := closer.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+
+	return first
+}
+
+// list returns the opened storages.
+func (s *PersistentStorages) list() []any {
+	stgs := []any{}
+
+	if s.Visits != nil {
+		stgs = append(stgs, s.Visits)
+	}
+	if s.Cookies != nil {
+		stgs = append(stgs, s.Cookies)
+	}
+	if s.Cache != nil {
+		stgs = append(stgs, s.Cache)
+	}
+	if s.Queue != nil {
+		stgs = append(stgs, s.Queue)
+	}
+
+	return stgs
+}
+
+// fail closes the storages opened so far and returns the error.
+func (s *PersistentStorages) fail(err error) error {
+	s.Close()
+
+	return err
+}
+
+// ------------------------------------------------------------------------
+
+// NewPersistentCollector returns a resumable collector keeping the visited URLs, the cookies,
+// the cache and the job queue in a SQLite3 database of the data directory.
+// Call Teardown when the crawl is finished to close the storages.
+func NewPersistentCollector(dataDir string, callbacks EventCallbacks) (*Collector, error) {
+	stgs, err := NewSQLite3Storages(dataDir, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPersistentCollector(stgs, callbacks)
+}
+
+// NewBadgerCollector returns a resumable collector keeping the visited URLs, the cookies,
+// the cache and the job queue in a BadgerDB database of the data directory.
+// Call Teardown when the crawl is finished to close the storages.
+func NewBadgerCollector(dataDir string, callbacks EventCallbacks) (*Collector, error) {
+	stgs, err := NewBadgerStorages(dataDir, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPersistentCollector(stgs, callbacks)
+}
+
+// newPersistentCollector returns a collector using the persistent storages.
+func newPersistentCollector(stgs *PersistentStorages, callbacks EventCallbacks) (*Collector, error) {
+	config := NewConfig()
+	if err := stgs.Apply(config); err != nil {
+		return nil, stgs.fail(err)
+	}
+
+	c := NewCollector(config, callbacks)
+	c.storages = stgs
+
+	return c, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Teardown waits for the running requests and closes the storages of a persistent collector.
+// If purge is true, the stored data is removed first, so the next run starts from scratch.
+func (c *Collector) Teardown(purge bool) error {
+	c.Wait()
+
+	if c.storages == nil {
+		return nil
+	}
+
+	var err error
+	if purge {
+		err = c.storages.Clear()
+	}
+	if cerr := c.storages.Close(); err == nil {
+		err = cerr
+	}
+	c.storages = nil
+
+	return err
+}
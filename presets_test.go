@@ -0,0 +1,57 @@
+package colly
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestNewSQLite3Storages(t *testing.T) {
+	dir := t.TempDir()
+
+	stgs, err := NewSQLite3Storages(dir, true)
+	if err != nil {
+		t.Fatalf("NewSQLite3Storages() error = %v", err)
+	}
+	if err := stgs.Visits.AddVisit("https://example.com/"); err != nil {
+		t.Fatalf("AddVisit() error = %v", err)
+	}
+	if err := stgs.Queue.Push(1, bytes.NewReader([]byte("job"))); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if err := stgs.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// The data is kept for the next run
+	stgs, err = NewSQLite3Storages(dir, true)
+	if err != nil {
+		t.Fatalf("NewSQLite3Storages() error = %v", err)
+	}
+	if n, _ := stgs.Visits.PastVisits("https://example.com/"); n != 1 {
+		t.Errorf("PastVisits() = %d, want 1", n)
+	}
+	r, err := stgs.Queue.Pop(1)
+	if err != nil {
+		t.Fatalf("Pop() error = %v", err)
+	}
+	if b, _ := io.ReadAll(r); string(b) != "job" {
+		t.Errorf("Pop() = %q, want %q", b, "job")
+	}
+
+	// Clearing starts from scratch
+	if err := stgs.Visits.AddVisit("https://example.com/"); err != nil {
+		t.Fatalf("AddVisit() error = %v", err)
+	}
+	if err := stgs.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if n, _ := stgs.Visits.PastVisits("https://example.com/"); n != 0 {
+		t.Errorf("PastVisits() after Clear() = %d, want 0", n)
+	}
+	if err := stgs.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
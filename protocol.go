@@ -0,0 +1,211 @@
+package colly
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ------------------------------------------------------------------------
+
+// ProtocolRules downgrade the protocols used with the legacy hosts that break on HTTP/2,
+// TLS 1.3 or persistent connections, or upgrade the hosts performing better over HTTP/3.
+// The zero value keeps the protocols of the collector.
+type ProtocolRules struct {
+	ForceHTTP1        bool   `json:"force_http1" bson:"force_http1,omitempty"`                 // ForceHTTP1 disables HTTP/2, the requests are sent over HTTP/1.1.
+	MaxTLSVersion     uint16 `json:"max_tls_version" bson:"max_tls_version,omitempty"`         // MaxTLSVersion caps the TLS version, e.g. tls.VersionTLS12. Zero keeps the default.
+	DisableKeepAlives bool   `json:"disable_keep_alives" bson:"disable_keep_alives,omitempty"` // DisableKeepAlives opens a new connection for every request.
+	HTTP3             bool   `json:"http3" bson:"http3,omitempty"`                             // HTTP3 sends the HTTPS requests with the HTTP3Transport of the collector, falling back to the other protocols.
+}
+
+// downgradeKey identifies the HTTP client of a set of protocol rules derived from a base client.
+type downgradeKey struct {
+	base  *http.Client
+	rules ProtocolRules
+}
+
+// ------------------------------------------------------------------------
+
+// NewProtocolOverride returns a pointer to a newly created configuration settings that
+// downgrades the protocols of the requests matching the filter.
+func NewProtocolOverride(filter *Filter, rules ProtocolRules) (*SubConfig, error) {
+	if filter == nil {
+		return nil, ErrNoFilterDefined
+	}
+
+	return &SubConfig{
+		Filter:   filter,
+		Protocol: rules,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// IsZero returns true if the rules keep the protocols of the collector.
+func (p ProtocolRules) IsZero() bool {
+	return p == ProtocolRules{}
+}
+
+// merge completes the rules with the rules of a lower precedence.
+func (p ProtocolRules) merge(other ProtocolRules) ProtocolRules {
+	p.ForceHTTP1 = p.ForceHTTP1 || other.ForceHTTP1
+	p.DisableKeepAlives = p.DisableKeepAlives || other.DisableKeepAlives
+	p.HTTP3 = p.HTTP3 || other.HTTP3
+	if p.MaxTLSVersion == 0 {
+		p.MaxTLSVersion = other.MaxTLSVersion
+	}
+
+	return p
+}
+
+// apply modifies the transport according to the rules.
+func (p ProtocolRules) apply(transport *http.Transport) {
+	if p.DisableKeepAlives {
+		transport.DisableKeepAlives = true
+	}
+
+	if !p.ForceHTTP1 && p.MaxTLSVersion == 0 {
+		return
+	}
+
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	} else {
+		transport.TLSClientConfig = transport.TLSClientConfig.Clone()
+	}
+	cfg := transport.TLSClientConfig
+
+	if p.MaxTLSVersion != 0 {
+		cfg.MaxVersion = p.MaxTLSVersion
+		if cfg.MinVersion > cfg.MaxVersion {
+			cfg.MinVersion = cfg.MaxVersion
+		}
+	}
+
+	// A non-nil empty protocol map disables the HTTP/2 upgrade of the TLS connections
+	if p.ForceHTTP1 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		cfg.NextProtos = []string{"http/1.1"}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// protocolOverride returns the protocol rules of the sub-configurations matching the request.
+// The rules of the earlier sub-configurations take precedence.
+func (c *CollectorConfig) protocolOverride(req *Request) ProtocolRules {
+	var rules ProtocolRules
+
+	for _, sc := range c.SubConfigs {
+		if sc == nil || sc.Filter == nil || sc.Protocol.IsZero() {
+			continue
+		}
+		if sc.Filter.Match(req) != nil {
+			continue
+		}
+
+		rules = rules.merge(sc.Protocol)
+	}
+
+	return rules
+}
+
+// ------------------------------------------------------------------------
+
+// protocolRules returns the protocol rules of the request the client is able to apply.
+// The HTTP3 rule is dropped if the collector has no HTTP/3 transport.
+func (c *Client) protocolRules(req *Request) ProtocolRules {
+	rules := req.collector.Config.protocolOverride(req)
+	if c.http3 == nil {
+		rules.HTTP3 = false
+	}
+
+	return rules
+}
+
+// ------------------------------------------------------------------------
+
+// downgrade returns the HTTP client of the request with the protocol overrides applied to the transport
+// of the base client. The clients are kept by the base client and the rules, so the requests of the same
+// overrides share the connection pool. Custom round trippers can't be modified, they are used as they are,
+// or as the fallback of the HTTP/3 transport.
+func (c *Client) downgrade(base *http.Client, req *Request, rules ProtocolRules) *http.Client {
+	if c.http3 == nil {
+		rules.HTTP3 = false
+	}
+	if rules.IsZero() {
+		return base
+	}
+
+	key := downgradeKey{base: base, rules: rules}
+
+	c.lock.RLock()
+	clt, present := c.downgraded[key]
+	c.lock.RUnlock()
+
+	if present {
+		return clt
+	}
+
+	var transport *http.Transport
+	var order []string
+	var rt http.RoundTripper
+	switch t := base.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	case *headerOrderTransport:
+		transport = t.base.Clone()
+		order = t.order
+	default:
+		if !rules.HTTP3 {
+			return base
+		}
+		rt = t
+	}
+
+	if transport != nil {
+		rules.apply(transport)
+
+		rt = transport
+		if len(order) > 0 {
+			rt = NewHeaderOrderTransport(transport, order)
+		}
+	}
+	if rules.HTTP3 {
+		rt = newHTTP3Fallback(c.http3, rt)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if clt, present = c.downgraded[key]; present {
+		if transport != nil {
+			transport.CloseIdleConnections()
+		}
+		return clt
+	}
+
+	clt = &http.Client{
+		Transport:     rt,
+		Jar:           base.Jar,
+		CheckRedirect: base.CheckRedirect,
+		Timeout:       base.Timeout,
+	}
+	c.downgraded[key] = clt
+
+	if req.collector != nil && req.collector.HasLogger() {
+		req.collector.logEvent(LOG_DEBUG_LEVEL, "protocol_override", req.ID, map[string]string{
+			"host":                req.Req.URL.Host,
+			"force_http1":         strconv.FormatBool(rules.ForceHTTP1),
+			"max_tls_version":     fmt.Sprintf("0x%04x", rules.MaxTLSVersion),
+			"disable_keep_alives": strconv.FormatBool(rules.DisableKeepAlives),
+			"http3":               strconv.FormatBool(rules.HTTP3),
+		})
+	}
+
+	return clt
+}
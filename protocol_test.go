@@ -0,0 +1,111 @@
+package colly
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestProtocolRules_merge(t *testing.T) {
+	first := ProtocolRules{MaxTLSVersion: tls.VersionTLS12}
+	second := ProtocolRules{ForceHTTP1: true, MaxTLSVersion: tls.VersionTLS11}
+
+	want := ProtocolRules{ForceHTTP1: true, MaxTLSVersion: tls.VersionTLS12}
+	if got := (ProtocolRules{}).merge(first).merge(second); got != want {
+		t.Errorf("merge() = %+v, want %+v", got, want)
+	}
+	if !(ProtocolRules{}).IsZero() || want.IsZero() {
+		t.Error("IsZero() mismatch")
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestClient_downgrade(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	c := &Client{
+		lock:       &sync.RWMutex{},
+		downgraded: map[downgradeKey]*http.Client{},
+	}
+	base := srv.Client()
+
+	get := func(clt *http.Client) *http.Response {
+		t.Helper()
+		resp, err := clt.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	req := &Request{Req: httptest.NewRequest("GET", srv.URL, nil)}
+
+	if clt := c.downgrade(base, req, ProtocolRules{}); clt != base {
+		t.Error("downgrade() without rules returned a new client")
+	}
+	if resp := get(base); resp.ProtoMajor != 2 {
+		t.Fatalf("base client protocol = %s, want HTTP/2", resp.Proto)
+	}
+
+	tests := []struct {
+		name      string
+		rules     ProtocolRules
+		wantMajor int
+		wantTLS   uint16
+	}{
+		{"force HTTP/1.1", ProtocolRules{ForceHTTP1: true}, 1, tls.VersionTLS13},
+		{"cap TLS", ProtocolRules{MaxTLSVersion: tls.VersionTLS12}, 2, tls.VersionTLS12},
+		{"both", ProtocolRules{ForceHTTP1: true, MaxTLSVersion: tls.VersionTLS12, DisableKeepAlives: true}, 1, tls.VersionTLS12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clt := c.downgrade(base, req, tt.rules)
+			if clt == base {
+				t.Fatal("downgrade() returned the base client")
+			}
+			if again := c.downgrade(base, req, tt.rules); again != clt {
+				t.Error("downgrade() didn't reuse the client of the rules")
+			}
+
+			resp := get(clt)
+			if resp.ProtoMajor != tt.wantMajor {
+				t.Errorf("protocol = %s, want HTTP/%d", resp.Proto, tt.wantMajor)
+			}
+			if resp.TLS == nil || resp.TLS.Version != tt.wantTLS {
+				t.Errorf("TLS version = %x, want %x", resp.TLS.Version, tt.wantTLS)
+			}
+			if got := clt.Transport.(*http.Transport).DisableKeepAlives; got != tt.rules.DisableKeepAlives {
+				t.Errorf("DisableKeepAlives = %v, want %v", got, tt.rules.DisableKeepAlives)
+			}
+		})
+	}
+
+	// The base transport is not modified
+	if resp := get(base); resp.ProtoMajor != 2 || resp.TLS.Version != tls.VersionTLS13 {
+		t.Errorf("base client after downgrade() = %s TLS %x, want HTTP/2 TLS 1.3", resp.Proto, resp.TLS.Version)
+	}
+
+	custom := &http.Client{Transport: roundTripFunc(http.DefaultTransport.RoundTrip)}
+	if clt := c.downgrade(custom, req, ProtocolRules{ForceHTTP1: true}); clt != custom {
+		t.Error("downgrade() of a custom round tripper returned a new client")
+	}
+}
+
+// roundTripFunc is a custom round tripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
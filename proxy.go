@@ -4,3 +4,13 @@ package colly
 
 // Proxy represents a proxy service.
 type Proxy interface{}
+
+// proxyKey is the context key type of the proxy values.
+type proxyKey uint8
+
+// ------------------------------------------------------------------------
+
+const (
+	// ProxyURLKey is the context key for the request proxy address.
+	ProxyURLKey proxyKey = iota
+)
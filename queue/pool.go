@@ -3,23 +3,12 @@ package queue
 import (
 	"errors"
 	"sync"
-
-	"github.com/gocolly/colly/v2/storage/mem"
 )
 
 // ------------------------------------------------------------------------
 
-// Storage is a thread safe FIFO Storage to serve the worker pool.
-type Storage interface {
-	Push([]byte, <-chan struct{}) error   // Push inserts an item into the storage.
-	Peek(<-chan struct{}) ([]byte, error) // Peek returns the oldest item from the storage without removing it.
-	Pop(<-chan struct{}) ([]byte, error)  // Pop pops the oldest item from the storage or returns error if the storage is empty.
-	Len(<-chan struct{}) (uint, error)    // Len returns the number of entries in the storage.
-}
-
 type pool struct {
-	tasks      chan Work
-	stg        Storage       // thread safe FIFO storage
+	tasks      chan Work     // buffered task queue
 	numWorkers uint          // the number of workers
 	start      sync.Once     // ensure the pool can be started once
 	stop       sync.Once     // ensure the pool can be stopped once
@@ -39,15 +28,10 @@ var (
 // ------------------------------------------------------------------------
 
 // NewPool returns a pointer to a newly created worker pool.
-// A memory storage is used if no storage was given.
-func NewPool(threads uint, stg Storage) *pool {
-	if stg == nil {
-		stg = mem.NewFIFOStorage(maxMemStgLen)
-	}
-
+// The tasks are queued in memory, up to maxMemStgLen tasks.
+func NewPool(threads uint) *pool {
 	return &pool{
 		tasks:      make(chan Work, maxMemStgLen),
-		stg:        stg,
 		numWorkers: threads,
 		start:      sync.Once{},
 		stop:       sync.Once{},
@@ -68,7 +52,7 @@ func (p *pool) Start() {
 
 // Stop instructs the worker pool to stop processing tasks.
 func (p *pool) Stop() {
-	p.start.Do(func() {
+	p.stop.Do(func() {
 		close(p.abort)
 	})
 }
@@ -94,9 +78,9 @@ func (p *pool) AddWork(w Work) {
 
 // ------------------------------------------------------------------------
 
-// Size returns the number of items in the queue.
+// Size returns the number of the queued tasks.
 func (p *pool) Size() (uint, error) {
-	return p.stg.Len()
+	return uint(len(p.tasks)), nil
 }
 
 // ------------------------------------------------------------------------
@@ -1,12 +1,14 @@
 package queue
 
 import (
+	"bytes"
+	"colly"
+	"colly/storage"
+	"colly/storage/mem"
+	"context"
 	"errors"
+	"io"
 	"sync"
-
-	"github.com/gocolly/colly/v2"
-	"github.com/gocolly/colly/v2/domain"
-	"github.com/gocolly/colly/v2/storage/mem"
 )
 
 // ------------------------------------------------------------------------
@@ -20,52 +22,68 @@ type WorkerPool interface {
 	IsEmpty() bool       // IsEmpty returns true if the queue is empty.
 }
 
-// queue implements a domain.Queue interface
+// queue is a request queue processed by a number of threads
 type queue struct {
-	stg       domain.QueueStorage // thread safe queue storage
-	processor domain.JobHandler   // responsible for processing the jobs
-	threads   uint                // the number of processing threads
-	abort     bool                // if true, instructs the queue to stop the job processing
-	lock      *sync.Mutex         // guards wake and running ???
-	wakeChan  chan struct{}
+	stg       colly.Queue   // thread safe queue storage, the items are kept in the thread QUEUE_ID
+	threads   uint          // the number of processing threads
+	maxSize   uint          // the maximum number of queued items, 0 means no limit
+	abort     bool          // if true, instructs the queue to stop the job processing
+	running   bool          // true while the queue is being processed
+	lock      *sync.Mutex   // guards abort, running, spaceChan and the size check of the producers
+	wakeChan  chan struct{} // signals the consumer that new items were added
+	spaceChan chan struct{} // closed and replaced when items are removed, so all waiting producers wake up
 }
 
 // ------------------------------------------------------------------------
 
 const maxLength uint = 100000
 
+// QUEUE_ID is the thread of the queue storage holding the items of the queue.
+const QUEUE_ID uint32 = 0
+
 // ------------------------------------------------------------------------
 
 var (
-	ErrAlreadyStarted = errors.New("the queue is already being processed")
+	ErrAlreadyStarted = errors.New("the queue is already being processed") // ErrAlreadyStarted is thrown when the queue processing was started twice.
+	ErrQueueFull      = errors.New("maximum queue size reached")           // ErrQueueFull is thrown when an item was added to a full queue.
 )
 
 // ------------------------------------------------------------------------
 
 // New returns a pointer to a newly created request queue.
 // A memory storage is used if no storage was given.
-// A WHATWGP parser is used if no parser was given.
-func New(threads uint, stg domain.QueueStorage) (*queue, error) {
+// The optional maxSize limits the number of queued items, AddItem returns ErrQueueFull
+// and AddItemWait blocks if the limit is reached.
+func New(threads uint, stg colly.Queue, maxSize ...uint) (*queue, error) {
+	if threads == 0 {
+		return nil, ErrInvalidNumWorkers
+	}
+
 	if stg == nil {
-		var err error
-		if stg, err = mem.NewQueueStorage(maxLength); err != nil {
-			return nil, err
-		}
+		stg = mem.NewFIFOStorage(maxLength)
+	}
+
+	q := &queue{
+		threads:   threads,
+		stg:       stg,
+		lock:      &sync.Mutex{},
+		abort:     false,
+		wakeChan:  make(chan struct{}, 1),
+		spaceChan: make(chan struct{}),
 	}
 
-	return &queue{
-		threads: threads,
-		stg:     stg,
-		lock:    &sync.Mutex{},
-		abort:   false,
-	}, nil
+	if len(maxSize) > 0 {
+		q.maxSize = maxSize[0]
+	}
+
+	return q, nil
 }
 
 // ------------------------------------------------------------------------
 
 // Size returns the number of items in the queue.
 func (q *queue) Size() (uint, error) {
-	return q.stg.Len()
+	return q.stg.Len(QUEUE_ID)
 }
 
 // ------------------------------------------------------------------------
@@ -79,54 +97,127 @@ func (q *queue) IsEmpty() bool {
 
 // ------------------------------------------------------------------------
 
-// AddItem adds a new item to the queue.
-func (q *queue) AddItem(item domain.Job) error {
-	// Convert the item to bytes
-	bytes, err := item.Encode()
+// AddURL adds a new GET request of the URL to the queue without blocking.
+func (q *queue) AddURL(URL string) error {
+	r, err := colly.NewRequest("GET", URL, nil, nil, nil)
 	if err != nil {
 		return err
 	}
 
-	// Add it to the queue
-	if err := q.stg.Push(bytes); err != nil {
+	return q.AddRequest(r)
+}
+
+// ------------------------------------------------------------------------
+
+// AddRequest adds a new request to the queue without blocking.
+// The request is serialized with its body and context values, see Request.ToBytes.
+func (q *queue) AddRequest(r *colly.Request) error {
+	return q.AddItem(requestJob{r})
+}
+
+// ------------------------------------------------------------------------
+
+// AddItem adds a new item to the queue without blocking.
+// It returns ErrQueueFull if the queue or the underlying storage is full.
+func (q *queue) AddItem(item colly.Job) error {
+	data, err := encode(item)
+	if err != nil {
 		return err
 	}
 
-	// Check if the processor is running
 	q.lock.Lock()
-	processing := q.wakeChan != nil
+	err = q.push(data)
 	q.lock.Unlock()
-
-	// ???
-	if !processing {
-		q.wakeChan <- struct{}{}
+	if err != nil {
+		return err
 	}
 
+	q.wake()
+
 	return nil
 }
 
 // ------------------------------------------------------------------------
 
-// Run starts consumer threads and calls the Collector to perform requests.
-// Run blocks while the queue has active requests.
-// The underlying Storage must not be used directly while Run blocks.
-func (q *queue) Start(c *colly.Collector) error {
-	if err := q.prepareProcess(); err != nil {
+// AddItemWait adds a new item to the queue.
+// If the queue is full, it blocks until space frees up or the context is done.
+func (q *queue) AddItemWait(ctx context.Context, item colly.Job) error {
+	data, err := encode(item)
+	if err != nil {
 		return err
 	}
 
-	requestChan := make(chan *colly.Request)
-	defer close(requestChan)
+	for {
+		// The signal is taken with the size check, so a removal in between is not missed
+		q.lock.Lock()
+		space := q.spaceChan
+		err := q.push(data)
+		q.lock.Unlock()
 
-	completeChan, errChan := make(chan struct{}), make(chan error, 1)
+		if err == nil {
+			q.wake()
 
-	for i := 0; i < q.threads; i++ {
-		go independentRunner(requestChan, completeChan)
+			return nil
+		}
+
+		if !errors.Is(err, ErrQueueFull) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-space:
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Start starts consumer threads and submits the queued requests to the collector, see Collector.Schedule.
+// The requests are held until their NotBefore time. The threads provide the parallelism,
+// so the collector is expected to be synchronous.
+// Start blocks while the queue has items or active requests, or until Stop is called.
+// The processing stops and the error of the context is returned when ctx is done.
+// The running requests are finished before Start returns.
+// The underlying Storage must not be used directly while Start blocks.
+func (q *queue) Start(ctx context.Context, c *colly.Collector) error {
+	if err := q.prepareProcess(); err != nil {
+		return err
+	}
+	defer q.finishProcess()
+
+	requestChan := make(chan *colly.Request)
+	completeChan := make(chan struct{})
+
+	wg := &sync.WaitGroup{}
+	for i := uint(0); i < q.threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range requestChan {
+				c.Schedule(r)
+				completeChan <- struct{}{}
+			}
+		}()
 	}
 
-	go q.loop(c, requestChan, completeChan, errChan)
+	err := q.loop(ctx, requestChan, completeChan)
 
-	return <-errc
+	// The runners are stopped, the completions of the running requests are drained meanwhile
+	close(requestChan)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		select {
+		case <-completeChan:
+		case <-done:
+			return err
+		}
+	}
 }
 
 // ------------------------------------------------------------------------
@@ -136,6 +227,8 @@ func (q *queue) Stop() {
 	q.lock.Lock()
 	q.abort = true
 	q.lock.Unlock()
+
+	q.wake()
 }
 
 // ------------------------------------------------------------------------
@@ -144,47 +237,71 @@ func (q *queue) prepareProcess() error {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
-	if q.wakeChan != nil && q.abort == false {
+	if q.running {
 		return ErrAlreadyStarted
 	}
 
-	q.wakeChan = make(chan struct{})
+	q.running = true
 	q.abort = false
 
 	return nil
 }
 
+func (q *queue) finishProcess() {
+	q.lock.Lock()
+	q.running = false
+	q.lock.Unlock()
+}
+
+func (q *queue) aborted() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.abort
+}
+
 // ------------------------------------------------------------------------
 
-func (q *queue) loop(c *colly.Collector, requestc chan<- *colly.Request, complete <-chan struct{}, errc chan<- error) {
+// loop sends the queued requests to the runners until the queue is empty and no request is active,
+// the queue is stopped or the context is done.
+func (q *queue) loop(ctx context.Context, requestc chan<- *colly.Request, complete <-chan struct{}) error {
 	var active int
 
 	for {
-		size, err := q.storage.Len()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if q.aborted() {
+			return nil
+		}
+
+		size, err := q.Size()
 		if err != nil {
-			errc <- err
-			break
+			return err
 		}
 
-		if size == 0 && active == 0 || !q.running {
-			// Terminate when
-			//   1. No active requests
-			//   2. Emtpy queue
-			errc <- nil
-			break
+		// Terminate when the queue is empty and no request is active
+		if size == 0 && active == 0 {
+			return nil
 		}
 
-		sent := requestc
+		var sent chan<- *colly.Request
 		var req *colly.Request
+		var data []byte
 		if size > 0 {
-			req, err = q.loadRequest(c)
+			data, err = q.pop()
+			if errors.Is(err, storage.ErrStorageEmpty) {
+				continue
+			}
 			if err != nil {
-				// ignore an error returned by GetRequest() or
-				// UnmarshalRequest()
+				return err
+			}
+
+			// The items that are not requests are dropped
+			if req, err = colly.NewRequestFromBytes(data); err != nil {
 				continue
 			}
-		} else {
-			sent = nil
+			sent = requestc
 		}
 
 	Sent:
@@ -193,16 +310,104 @@ func (q *queue) loop(c *colly.Collector, requestc chan<- *colly.Request, complet
 			case sent <- req:
 				active++
 				break Sent
-			case <-q.wake:
-				if sent == nil {
+			case <-q.wakeChan:
+				if sent == nil || q.aborted() {
 					break Sent
 				}
 			case <-complete:
 				active--
-				if sent == nil && active == 0 {
+				// The finished request may have added items
+				if sent == nil {
 					break Sent
 				}
+			case <-ctx.Done():
+				break Sent
 			}
 		}
+
+		// The request that was not sent is kept in the queue
+		if req != nil && sent != nil && (ctx.Err() != nil || q.aborted()) {
+			q.lock.Lock()
+			q.stg.Push(QUEUE_ID, bytes.NewReader(data))
+			q.lock.Unlock()
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// push stores the item if the queue is not full. The caller must hold the lock.
+func (q *queue) push(item []byte) error {
+	if q.maxSize > 0 {
+		size, err := q.Size()
+		if err != nil {
+			return err
+		}
+		if size >= q.maxSize {
+			return ErrQueueFull
+		}
+	}
+
+	if err := q.stg.Push(QUEUE_ID, bytes.NewReader(item)); err != nil {
+		if errors.Is(err, storage.ErrStorageFull) {
+			return ErrQueueFull
+		}
+
+		return err
 	}
+
+	return nil
+}
+
+// pop removes the oldest item of the queue and wakes the waiting producers.
+func (q *queue) pop() ([]byte, error) {
+	rdr, err := q.stg.Pop(QUEUE_ID)
+	if err != nil {
+		return nil, err
+	}
+	q.freeSpace()
+
+	return io.ReadAll(rdr)
+}
+
+// wake signals the consumer about the new items without blocking.
+// Pending signals are merged, so the producers never wait for the consumer.
+func (q *queue) wake() {
+	select {
+	case q.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
+// freeSpace wakes all producers waiting for space in the queue.
+// Every waiter checks the size again, the ones finding the queue full keep waiting.
+func (q *queue) freeSpace() {
+	q.lock.Lock()
+	close(q.spaceChan)
+	q.spaceChan = make(chan struct{})
+	q.lock.Unlock()
+}
+
+// ------------------------------------------------------------------------
+
+// requestJob is a request added to the queue.
+type requestJob struct {
+	r *colly.Request
+}
+
+// Encode serializes the request.
+func (j requestJob) Encode() (io.Reader, error) {
+	data, err := j.r.ToBytes()
+
+	return bytes.NewReader(data), err
+}
+
+// encode returns the bytes of a job.
+func encode(item colly.Job) ([]byte, error) {
+	rdr, err := item.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(rdr)
 }
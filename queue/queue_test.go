@@ -1,105 +1,242 @@
 package queue
 
 import (
-	"math/rand"
+	"bytes"
+	"colly"
+	"colly/storage/mem"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
-
-	"github.com/gocolly/colly/v2"
 )
 
-func TestQueue(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(serverHandler))
-	defer server.Close()
-
-	rng := rand.New(rand.NewSource(12387123712321232))
-	var (
-		items    uint32
-		requests uint32
-		success  uint32
-		failure  uint32
-	)
-	storage := &InMemoryQueueStorage{MaxSize: 100000}
-	q, err := New(10, storage)
+// ------------------------------------------------------------------------
+
+func newTestServer() *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/delay", func(w http.ResponseWriter, r *http.Request) {
+		d, _ := time.ParseDuration(r.URL.Query().Get("t"))
+		time.Sleep(d)
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+
+	return httptest.NewServer(mux)
+}
+
+// rawJob is a queue item holding raw bytes.
+type rawJob []byte
+
+func (j rawJob) Encode() (io.Reader, error) {
+	return bytes.NewReader(j), nil
+}
+
+// ------------------------------------------------------------------------
+
+func TestQueue_Start(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	stg := mem.NewFIFOStorage(1000)
+	q, err := New(4, stg)
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
 	}
+
+	var items, responses, failures atomic.Uint32
 	put := func() {
-		t := time.Duration(rng.Intn(50)) * time.Microsecond
-		url := server.URL + "/delay?t=" + t.String()
-		atomic.AddUint32(&items, 1)
-		q.AddURL(url)
+		n := items.Add(1)
+		if err := q.AddURL(fmt.Sprintf("%s/delay?t=%dms&n=%d", ts.URL, n%5, n)); err != nil {
+			t.Error(err)
+		}
 	}
-	for i := 0; i < 3000; i++ {
+	for i := 0; i < 20; i++ {
 		put()
-		storage.AddRequest([]byte("error request"))
 	}
-	c := colly.NewCollector(
-		colly.AllowURLRevisit(),
-	)
-	c.OnRequest(func(req *colly.Request) {
-		atomic.AddUint32(&requests, 1)
-	})
-	c.OnResponse(func(resp *colly.Response) {
-		if resp.StatusCode == http.StatusOK {
-			atomic.AddUint32(&success, 1)
-		} else {
-			atomic.AddUint32(&failure, 1)
-		}
-		toss := rng.Intn(2) == 0
-		if toss {
+	// The items that are not requests are dropped
+	stg.Push(QUEUE_ID, bytes.NewReader([]byte("error request")))
+
+	c := colly.NewCollector(nil, nil)
+	c.OnResponse(func(r *colly.Response) {
+		// The requests of the callbacks are processed by the same run
+		if responses.Add(1) <= 10 {
 			put()
 		}
 	})
-	c.OnError(func(resp *colly.Response, err error) {
-		atomic.AddUint32(&failure, 1)
-	})
-	err = q.Run(c)
-	if err != nil {
-		t.Fatalf("Queue.Run() return an error: %v", err)
+	c.OnError(func(r *colly.Response, err error) { failures.Add(1) })
+
+	if err := q.Start(context.Background(), c); err != nil {
+		t.Fatalf("Start() error = %v", err)
 	}
-	if items != requests || success+failure != requests || failure > 0 {
-		t.Fatalf("wrong Queue implementation: "+
-			"items = %d, requests = %d, success = %d, failure = %d",
-			items, requests, success, failure)
+
+	if responses.Load() != items.Load() || failures.Load() != 0 {
+		t.Errorf("got %d responses and %d failures of %d items, want all responses", responses.Load(), failures.Load(), items.Load())
+	}
+	if !q.IsEmpty() {
+		t.Error("queue is not empty after Start() returned")
 	}
 }
 
-func serverHandler(w http.ResponseWriter, req *http.Request) {
-	if !serverRoute(w, req) {
-		shutdown(w)
+// ------------------------------------------------------------------------
+
+func TestQueue_AddItem(t *testing.T) {
+	q, _ := New(1, nil, 2)
+	for i := 0; i < 2; i++ {
+		if err := q.AddItem(rawJob("item")); err != nil {
+			t.Fatalf("AddItem() error = %v", err)
+		}
+	}
+	if err := q.AddItem(rawJob("item")); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("AddItem() to a full queue error = %v, want %v", err, ErrQueueFull)
+	}
+
+	// The full storage is reported as a full queue
+	q, _ = New(1, mem.NewFIFOStorage(1))
+	q.AddItem(rawJob("item"))
+	if err := q.AddItem(rawJob("item")); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("AddItem() to a full storage error = %v, want %v", err, ErrQueueFull)
 	}
 }
 
-func serverRoute(w http.ResponseWriter, req *http.Request) bool {
-	if req.URL.Path == "/delay" {
-		return serveDelay(w, req) == nil
+// ------------------------------------------------------------------------
+
+func TestQueue_AddItemWait_cancel(t *testing.T) {
+	q, _ := New(1, nil, 1)
+	q.AddItem(rawJob("item"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.AddItemWait(ctx, rawJob("item")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("AddItemWait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if n, _ := q.Size(); n != 1 {
+		t.Errorf("Size() = %d, want 1", n)
 	}
-	return false
 }
 
-func serveDelay(w http.ResponseWriter, req *http.Request) error {
-	q := req.URL.Query()
-	t, err := time.ParseDuration(q.Get("t"))
-	if err != nil {
-		return err
+// ------------------------------------------------------------------------
+
+func TestQueue_AddItemWait_waiters(t *testing.T) {
+	const size = 3
+
+	q, _ := New(1, nil, size)
+	for i := 0; i < size; i++ {
+		q.AddItem(rawJob("item"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	wg := &sync.WaitGroup{}
+	errs := make(chan error, size)
+	for i := 0; i < size; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- q.AddItemWait(ctx, rawJob("waiter"))
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// A burst of removals wakes all waiting producers
+	for i := 0; i < size; i++ {
+		if _, err := q.pop(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("AddItemWait() error = %v", err)
+		}
+	}
+	if n, _ := q.Size(); n != size {
+		t.Errorf("Size() = %d, want %d", n, size)
 	}
-	time.Sleep(t)
-	w.WriteHeader(http.StatusOK)
-	return nil
 }
 
-func shutdown(w http.ResponseWriter) {
-	taker, ok := w.(http.Hijacker)
-	if !ok {
-		return
+// ------------------------------------------------------------------------
+
+func TestQueue_Start_cancel(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	q, _ := New(2, nil)
+	for i := 0; i < 5; i++ {
+		q.AddURL(fmt.Sprintf("%s/slow?i=%d", ts.URL, i))
 	}
-	raw, _, err := taker.Hijack()
-	if err != nil {
-		return
+
+	cfg := colly.NewConfig()
+	cfg.IdleTimeout = 100 * time.Millisecond
+	c := colly.NewCollector(cfg, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := q.Start(ctx, c); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Start() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Start() returned after %v, want it to stop at the cancellation", elapsed)
+	}
+
+	// The requests that were not sent are kept
+	if n, _ := q.Size(); n != 3 {
+		t.Errorf("Size() after the cancellation = %d, want 3", n)
+	}
+
+	// The queue can be started again
+	q.Stop()
+	if err := q.Start(context.Background(), c); err != nil {
+		t.Errorf("Start() after Stop() error = %v", err)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestQueue_Start_delayed(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	// The execution time is persisted with the item, a queue reloaded from the storage holds it until due
+	stg := mem.NewFIFOStorage(10)
+	saved, _ := New(1, stg)
+	r, _ := colly.NewRequest("GET", ts.URL+"/delay?t=0", nil, nil, nil)
+	due := time.Now().Add(200 * time.Millisecond)
+	r.NotBefore = due
+	if err := saved.AddRequest(r); err != nil {
+		t.Fatal(err)
+	}
+
+	q, _ := New(1, stg)
+	c := colly.NewCollector(nil, nil)
+
+	var fetched atomic.Int64
+	c.OnResponse(func(r *colly.Response) { fetched.Store(time.Now().UnixNano()) })
+
+	if err := q.Start(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+	c.Wait()
+
+	if at := fetched.Load(); at == 0 {
+		t.Error("the delayed request was not fetched")
+	} else if at < due.UnixNano() {
+		t.Errorf("the delayed request was fetched %v before it was due", due.Sub(time.Unix(0, at)))
 	}
-	raw.Close()
 }
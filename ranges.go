@@ -0,0 +1,262 @@
+package colly
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// ContentRange is the byte range of a partial response, parsed from its Content-Range header.
+type ContentRange struct {
+	Start int64 `json:"start" bson:"start"`           // Start is the offset of the first byte of the part.
+	End   int64 `json:"end" bson:"end"`               // End is the offset of the last byte of the part, inclusive.
+	Total int64 `json:"total" bson:"total,omitempty"` // Total is the size of the complete body, -1 if unknown.
+}
+
+// RangeAssembler assembles the body of a resource from the parts of a chunked download.
+// The parts can arrive in any order and they may overlap.
+type RangeAssembler struct {
+	total     int64            // size of the complete body, -1 if unknown
+	validator string           // ETag or Last-Modified of the resource version of the parts
+	parts     map[int64][]byte // received parts by their start offsets
+	lock      *sync.Mutex
+}
+
+// ------------------------------------------------------------------------
+
+// SetRange sets the Range header of the request to download the bytes from start to end, inclusive.
+// Negative end means the rest of the body from start. The server answers with a 206 Partial Content
+// response if it supports ranges, or with the whole body otherwise.
+// Requests with a Range header are not limited by the past visits of their URLs.
+func (r *Request) SetRange(start, end int64) {
+	val := "bytes=" + strconv.FormatInt(start, 10) + "-"
+	if end >= 0 {
+		val += strconv.FormatInt(end, 10)
+	}
+
+	r.Req.Header.Set("Range", val)
+}
+
+// ------------------------------------------------------------------------
+
+// PartialContent returns true if the response is a 206 Partial Content response.
+func (r *Response) PartialContent() bool {
+	return r.Resp != nil && r.Resp.StatusCode == http.StatusPartialContent
+}
+
+// ContentRange returns the byte range of a partial response.
+// It returns nil if the response is not a 206 Partial Content response.
+func (r *Response) ContentRange() (*ContentRange, error) {
+	if !r.PartialContent() {
+		return nil, nil
+	}
+
+	return ParseContentRange(r.Resp.Header.Get("Content-Range"))
+}
+
+// ------------------------------------------------------------------------
+
+// ParseContentRange parses a Content-Range header value, e.g. "bytes 0-499/1234" or "bytes 500-999/*".
+func ParseContentRange(val string) (*ContentRange, error) {
+	unit, spec, found := strings.Cut(strings.TrimSpace(val), " ")
+	if !found || !strings.EqualFold(unit, "bytes") {
+		return nil, ErrInvalidContentRange
+	}
+
+	rng, total, found := strings.Cut(strings.TrimSpace(spec), "/")
+	if !found {
+		return nil, ErrInvalidContentRange
+	}
+	first, last, found := strings.Cut(rng, "-")
+	if !found {
+		return nil, ErrInvalidContentRange
+	}
+
+	cr := &ContentRange{Total: -1}
+	var err error
+	if cr.Start, err = strconv.ParseInt(first, 10, 64); err != nil || cr.Start < 0 {
+		return nil, ErrInvalidContentRange
+	}
+	if cr.End, err = strconv.ParseInt(last, 10, 64); err != nil || cr.End < cr.Start {
+		return nil, ErrInvalidContentRange
+	}
+	if total != "*" {
+		if cr.Total, err = strconv.ParseInt(total, 10, 64); err != nil || cr.Total <= cr.End {
+			return nil, ErrInvalidContentRange
+		}
+	}
+
+	return cr, nil
+}
+
+// ------------------------------------------------------------------------
+
+// NewRangeAssembler returns a pointer to a newly created range assembler.
+func NewRangeAssembler() *RangeAssembler {
+	return &RangeAssembler{
+		total: -1,
+		parts: map[int64][]byte{},
+		lock:  &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Add adds the body of a response to the assembled body. A 206 Partial Content response
+// adds its range, any other response replaces the parts with its whole body. The parts
+// of a different resource version, told by the ETag or Last-Modified header or the total
+// size, are rejected with ErrRangeMismatch. The truncated parts are kept as received.
+func (a *RangeAssembler) Add(resp *Response) error {
+	if resp == nil || resp.Resp == nil {
+		return nil
+	}
+
+	cr, err := resp.ContentRange()
+	if err != nil {
+		return err
+	}
+
+	validator := resp.Resp.Header.Get("ETag")
+	if validator == "" {
+		validator = resp.Resp.Header.Get("Last-Modified")
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if cr == nil {
+		a.total = int64(len(resp.Body))
+		a.validator = validator
+		a.parts = map[int64][]byte{0: append([]byte(nil), resp.Body...)}
+
+		return nil
+	}
+
+	if (a.validator != "" && validator != "" && a.validator != validator) ||
+		(a.total >= 0 && cr.Total >= 0 && a.total != cr.Total) {
+		return ErrRangeMismatch
+	}
+	if validator != "" {
+		a.validator = validator
+	}
+	if cr.Total >= 0 {
+		a.total = cr.Total
+	}
+
+	body := resp.Body
+	if size := cr.End - cr.Start + 1; int64(len(body)) > size {
+		body = body[:size]
+	}
+	if len(body) > len(a.parts[cr.Start]) {
+		a.parts[cr.Start] = append([]byte(nil), body...)
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Validator returns the ETag or Last-Modified value of the assembled resource version,
+// to be sent in the If-Range header of the next part requests.
+func (a *RangeAssembler) Validator() string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.validator
+}
+
+// Total returns the size of the complete body, or -1 if it is not known yet.
+func (a *RangeAssembler) Total() int64 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.total
+}
+
+// ------------------------------------------------------------------------
+
+// Missing returns the first byte range of at most chunkSize bytes that was not received yet.
+// The end is negative if the size of the body is not known and chunkSize is not positive.
+// It returns false if the body is complete.
+func (a *RangeAssembler) Missing(chunkSize int64) (start int64, end int64, ok bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	start = a.covered()
+	if a.total >= 0 && start >= a.total {
+		return 0, 0, false
+	}
+
+	// The gap ends at the next received part or the end of the body
+	limit := a.total
+	for offset := range a.parts {
+		if offset > start && (limit < 0 || offset < limit) {
+			limit = offset
+		}
+	}
+
+	end = -1
+	if chunkSize > 0 {
+		end = start + chunkSize - 1
+	}
+	if limit >= 0 && (end < 0 || end >= limit) {
+		end = limit - 1
+	}
+
+	return start, end, true
+}
+
+// Complete returns true if the parts cover the whole body.
+func (a *RangeAssembler) Complete() bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.total >= 0 && a.covered() >= a.total
+}
+
+// Bytes returns the assembled body, or ErrRangeIncomplete if the parts don't cover the whole body.
+func (a *RangeAssembler) Bytes() ([]byte, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.total < 0 || a.covered() < a.total {
+		return nil, ErrRangeIncomplete
+	}
+
+	body := make([]byte, a.total)
+	for offset, part := range a.parts {
+		if offset < a.total {
+			copy(body[offset:], part)
+		}
+	}
+
+	return body, nil
+}
+
+// ------------------------------------------------------------------------
+
+// covered returns the length of the body received without gaps from the start.
+// The caller must hold the lock.
+func (a *RangeAssembler) covered() int64 {
+	offsets := make([]int64, 0, len(a.parts))
+	for offset := range a.parts {
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	var end int64
+	for _, offset := range offsets {
+		if offset > end {
+			break
+		}
+		if e := offset + int64(len(a.parts[offset])); e > end {
+			end = e
+		}
+	}
+
+	return end
+}
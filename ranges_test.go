@@ -0,0 +1,145 @@
+package colly
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		val     string
+		want    *ContentRange
+		wantErr bool
+	}{
+		{"bytes 0-499/1234", &ContentRange{0, 499, 1234}, false},
+		{"bytes 500-999/*", &ContentRange{500, 999, -1}, false},
+		{"Bytes 0-0/1", &ContentRange{0, 0, 1}, false},
+		{"bytes */1234", nil, true},
+		{"bytes 10-5/100", nil, true},
+		{"bytes 0-100/100", nil, true},
+		{"items 0-5/10", nil, true},
+		{"", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseContentRange(tt.val)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseContentRange(%q) = %v, %v, want %v, error %v", tt.val, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestRequest_SetRange(t *testing.T) {
+	r := &Request{Req: httptest.NewRequest("GET", "http://example.com/file", nil)}
+
+	r.SetRange(0, 99)
+	if got := r.Req.Header.Get("Range"); got != "bytes=0-99" {
+		t.Errorf("Range = %q, want bytes=0-99", got)
+	}
+
+	r.SetRange(100, -1)
+	if got := r.Req.Header.Get("Range"); got != "bytes=100-" {
+		t.Errorf("Range = %q, want bytes=100-", got)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestRangeAssembler(t *testing.T) {
+	part := func(cr string, etag string, body string) *Response {
+		hdr := http.Header{"Content-Range": {cr}}
+		if etag != "" {
+			hdr.Set("ETag", etag)
+		}
+		return &Response{Resp: &http.Response{StatusCode: http.StatusPartialContent, Header: hdr}, Body: []byte(body)}
+	}
+
+	a := NewRangeAssembler()
+	if start, end, ok := a.Missing(4); !ok || start != 0 || end != 3 {
+		t.Errorf("Missing() of an empty body = %d, %d, %v, want 0, 3, true", start, end, ok)
+	}
+
+	if err := a.Add(part("bytes 6-9/10", `"v1"`, "ghij")); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Add(part("bytes 0-3/10", `"v1"`, "abcd")); err != nil {
+		t.Fatal(err)
+	}
+	if start, end, ok := a.Missing(4); !ok || start != 4 || end != 5 {
+		t.Errorf("Missing() of a gap = %d, %d, %v, want 4, 5, true", start, end, ok)
+	}
+	if _, err := a.Bytes(); !errors.Is(err, ErrRangeIncomplete) {
+		t.Errorf("Bytes() error = %v, want %v", err, ErrRangeIncomplete)
+	}
+
+	if err := a.Add(part("bytes 4-5/10", `"v2"`, "ef")); !errors.Is(err, ErrRangeMismatch) {
+		t.Errorf("Add() of another version error = %v, want %v", err, ErrRangeMismatch)
+	}
+	if err := a.Add(part("bytes 3-6/10", `"v1"`, "defg")); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := a.Bytes()
+	if err != nil || string(body) != "abcdefghij" || !a.Complete() {
+		t.Errorf("Bytes() = %q, %v, Complete() = %v, want abcdefghij", body, err, a.Complete())
+	}
+	if _, _, ok := a.Missing(4); ok {
+		t.Error("Missing() of a complete body = true, want false")
+	}
+
+	// The whole body replaces the parts
+	a.Add(&Response{Resp: &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, Body: []byte("whole")})
+	if body, _ := a.Bytes(); string(body) != "whole" || a.Total() != 5 {
+		t.Errorf("Bytes() = %q, Total() = %d, want whole, 5", body, a.Total())
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_rangedDownload(t *testing.T) {
+	content := strings.Repeat("0123456789", 25)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"file"`)
+		http.ServeContent(w, r, "file.bin", time.Time{}, strings.NewReader(content))
+	}))
+	defer srv.Close()
+
+	c := NewCollector(nil, nil)
+
+	a := NewRangeAssembler()
+	statuses := []int{}
+	c.OnResponse(func(r *Response) {
+		statuses = append(statuses, r.Resp.StatusCode)
+		if err := a.Add(r); err != nil {
+			t.Error(err)
+		}
+	})
+	c.OnError(func(r *Response, err error) { t.Errorf("OnError() error = %v", err) })
+
+	// The parts of the same URL are not limited by the past visits
+	for start, end, ok := a.Missing(100); ok && len(statuses) < 5; start, end, ok = a.Missing(100) {
+		hdr := http.Header{"Range": {"bytes=" + strconv.FormatInt(start, 10) + "-" + strconv.FormatInt(end, 10)}}
+		if err := c.Request("GET", srv.URL+"/file.bin", nil, nil, hdr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	body, err := a.Bytes()
+	if err != nil || !bytes.Equal(body, []byte(content)) {
+		t.Errorf("Bytes() = %d bytes, %v, want %d bytes", len(body), err, len(content))
+	}
+	if want := []int{206, 206, 206}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+}
@@ -0,0 +1,166 @@
+package colly
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ------------------------------------------------------------------------
+
+// refreshKey is the context key type of the values used for refresh redirects.
+type refreshKey uint8
+
+// ------------------------------------------------------------------------
+
+const (
+	// RedirectCountKey is the context key for the number of redirects followed by the request.
+	RedirectCountKey refreshKey = iota
+)
+
+// ------------------------------------------------------------------------
+
+var (
+	metaRefreshRegexp = regexp.MustCompile(`(?is)<meta\s[^>]*http-equiv\s*=\s*["']?refresh["']?[^>]*>`)
+	metaContentRegexp = regexp.MustCompile(`(?is)content\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	jsLocationRegexp  = regexp.MustCompile(`(?is)<script[^>]*>\s*(?:window\.|document\.|self\.|top\.)?location(?:\.href)?\s*=\s*["']([^"']+)["']\s*;?\s*</script>|<script[^>]*>\s*(?:window\.|document\.|self\.|top\.)?location\.(?:replace|assign)\(\s*["']([^"']+)["']\s*\)\s*;?\s*</script>`)
+)
+
+// ------------------------------------------------------------------------
+
+// FindRefreshURL returns the target URL of a redirect made by a Refresh header,
+// a <meta http-equiv="refresh"> tag or a trivial location script in the response body.
+// It returns empty string if the response doesn't redirect.
+func FindRefreshURL(resp *Response) string {
+	if resp == nil || resp.Resp == nil {
+		return ""
+	}
+
+	if u := parseRefreshContent(resp.Resp.Header.Get("Refresh")); u != "" {
+		return u
+	}
+
+	if len(resp.Body) == 0 || !hasHdrVal(resp.Resp.Header, "Content-Type", "html") {
+		return ""
+	}
+
+	if tag := metaRefreshRegexp.Find(resp.Body); tag != nil {
+		if m := metaContentRegexp.FindSubmatch(tag); m != nil {
+			for _, content := range m[1:] {
+				if len(content) > 0 {
+					return parseRefreshContent(string(content))
+				}
+			}
+		}
+	}
+
+	if m := jsLocationRegexp.FindSubmatch(resp.Body); m != nil {
+		for _, u := range m[1:] {
+			if len(u) > 0 {
+				return strings.TrimSpace(string(u))
+			}
+		}
+	}
+
+	return ""
+}
+
+// ------------------------------------------------------------------------
+
+// RedirectCount returns the number of redirects, the HTTP redirects and the refreshes,
+// followed by the requests before the request to get to its URL.
+// The HTTP redirects of the request itself are not included.
+func (r *Request) RedirectCount() uint {
+	if r.Ctx == nil {
+		return 0
+	}
+
+	if n, ok := (*r.Ctx).Value(RedirectCountKey).(uint); ok {
+		return n
+	}
+
+	return 0
+}
+
+// ------------------------------------------------------------------------
+
+// followRefresh visits the target URL of a meta refresh or a script redirect.
+// The new request counts as a redirect and it has to pass the filters again.
+func (c *Collector) followRefresh(resp *Response) error {
+	if !c.Config.FollowRefresh || resp.Request == nil {
+		return nil
+	}
+
+	target := FindRefreshURL(resp)
+	if target == "" {
+		return nil
+	}
+
+	target = resp.Request.AbsoluteURL(target)
+	if target == "" || target == resp.Request.Req.URL.String() {
+		return nil
+	}
+
+	count := resp.Request.RedirectCount() + httpRedirects(resp.Resp) + 1
+	if c.Config.MaxRedirects > 0 && count > c.Config.MaxRedirects {
+		return ErrMaxRedirects
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_INFO_LEVEL, "refresh", resp.Request.ID, map[string]string{
+			"url":    resp.Request.Req.URL.String(),
+			"target": target,
+		})
+	}
+
+	parent := context.Background()
+	if resp.Request.Ctx != nil {
+		parent = *resp.Request.Ctx
+	}
+	ctx := context.WithValue(parent, RedirectCountKey, count)
+
+	return c.scrape(target, "GET", int(resp.Request.Depth), nil, &ctx, nil, true)
+}
+
+// httpRedirects returns the number of HTTP redirects followed to get the response.
+func httpRedirects(resp *http.Response) uint {
+	var n uint
+	for resp != nil && resp.Request != nil && resp.Request.Response != nil {
+		resp = resp.Request.Response
+		n++
+	}
+
+	return n
+}
+
+// ------------------------------------------------------------------------
+
+// parseRefreshContent extracts the URL from a refresh value like "5; url=http://example.com/".
+func parseRefreshContent(content string) string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return ""
+	}
+
+	delay, rest, found := strings.Cut(content, ";")
+	if !found {
+		delay, rest, found = strings.Cut(content, ",")
+	}
+
+	if _, err := strconv.ParseFloat(strings.TrimSpace(delay), 64); err != nil || !found {
+		return ""
+	}
+
+	rest = strings.TrimSpace(rest)
+	if len(rest) >= 3 && strings.EqualFold(rest[:3], "url") {
+		rest = strings.TrimSpace(rest[3:])
+		if !strings.HasPrefix(rest, "=") {
+			return ""
+		}
+		rest = strings.TrimSpace(rest[1:])
+	}
+
+	return strings.Trim(rest, `"'`)
+}
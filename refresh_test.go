@@ -0,0 +1,167 @@
+package colly
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_parseRefreshContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "blank", content: "", want: ""},
+		{name: "delay only", content: "5", want: ""},
+		{name: "url", content: "0; url=http://example.com/", want: "http://example.com/"},
+		{name: "uppercase url", content: "3;URL='/next'", want: "/next"},
+		{name: "no url prefix", content: "0; /next", want: "/next"},
+		{name: "comma separator", content: "1, url=/next", want: "/next"},
+		{name: "invalid delay", content: "soon; url=/next", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRefreshContent(tt.content); got != tt.want {
+				t.Errorf("parseRefreshContent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestFindRefreshURL(t *testing.T) {
+	htmlHdr := http.Header{"Content-Type": []string{"text/html; charset=utf-8"}}
+
+	tests := []struct {
+		name string
+		resp *Response
+		want string
+	}{
+		{
+			name: "nil response",
+			resp: nil,
+			want: "",
+		},
+		{
+			name: "refresh header",
+			resp: &Response{
+				Resp: &http.Response{Header: http.Header{"Refresh": []string{"0; url=/header"}}},
+			},
+			want: "/header",
+		},
+		{
+			name: "meta refresh",
+			resp: &Response{
+				Resp: &http.Response{Header: htmlHdr},
+				Body: []byte(`<html><head><meta http-equiv="refresh" content="0; url=/meta"></head></html>`),
+			},
+			want: "/meta",
+		},
+		{
+			name: "location href",
+			resp: &Response{
+				Resp: &http.Response{Header: htmlHdr},
+				Body: []byte(`<html><script>window.location.href = "/script";</script></html>`),
+			},
+			want: "/script",
+		},
+		{
+			name: "location replace",
+			resp: &Response{
+				Resp: &http.Response{Header: htmlHdr},
+				Body: []byte(`<html><script type="text/javascript">location.replace('/replace')</script></html>`),
+			},
+			want: "/replace",
+		},
+		{
+			name: "not html",
+			resp: &Response{
+				Resp: &http.Response{Header: http.Header{"Content-Type": []string{"text/plain"}}},
+				Body: []byte(`<meta http-equiv="refresh" content="0; url=/meta">`),
+			},
+			want: "",
+		},
+		{
+			name: "complex script",
+			resp: &Response{
+				Resp: &http.Response{Header: htmlHdr},
+				Body: []byte(`<html><script>if (x) { location.href = "/cond"; }</script></html>`),
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindRefreshURL(tt.resp); got != tt.want {
+				t.Errorf("FindRefreshURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_followRefresh(t *testing.T) {
+	// The chain alternates HTTP redirects and meta refreshes: /h0 -> /r0 => /h1 -> /r1 => /h2 -> /r2
+	var (
+		lock      sync.Mutex
+		requested []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requested = append(requested, r.URL.Path)
+		lock.Unlock()
+
+		var n int
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/h"):
+			http.Redirect(w, r, "/r"+r.URL.Path[2:], http.StatusFound)
+		case r.URL.Path == "/r2":
+			w.Write([]byte("<html><body>end</body></html>"))
+		default:
+			fmt.Sscanf(r.URL.Path, "/r%d", &n)
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html><head><meta http-equiv="refresh" content="0; url=/h%d"></head></html>`, n+1)
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name         string
+		maxRedirects uint
+		want         []string
+	}{
+		{"unlimited", 0, []string{"/h0", "/r0", "/h1", "/r1", "/h2", "/r2"}},
+		{"limited", 3, []string{"/h0", "/r0", "/h1", "/r1"}},
+		{"limited within a redirect", 2, []string{"/h0", "/r0", "/h1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lock.Lock()
+			requested = nil
+			lock.Unlock()
+
+			cfg := NewConfig()
+			cfg.FollowRefresh = true
+			cfg.MaxRedirects = tt.maxRedirects
+			c := NewCollector(cfg, nil)
+
+			c.Visit(ts.URL + "/h0")
+			c.Wait()
+
+			lock.Lock()
+			defer lock.Unlock()
+			if !reflect.DeepEqual(requested, tt.want) {
+				t.Errorf("requested = %q, want %q", requested, tt.want)
+			}
+		})
+	}
+}
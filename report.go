@@ -0,0 +1,319 @@
+package colly
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io"
+	"mime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// CrawlReport is a summary of the crawl activity, grouped by hosts.
+type CrawlReport struct {
+	CollectorID uint32                 `json:"collector_id" bson:"collector_id,omitempty"` // CollectorID identifies the collector of the report.
+	Created     time.Time              `json:"created" bson:"created,omitempty"`           // Created is the date and time when the report was created.
+	Hosts       map[string]*HostReport `json:"hosts" bson:"hosts,omitempty"`               // Hosts contains the host reports, mapped by the host names.
+	Duplicates  *DuplicateReport       `json:"duplicates" bson:"duplicates,omitempty"`     // Duplicates lists the duplicate-content clusters if the analysis is enabled.
+	ConfigHash  string                 `json:"config_hash" bson:"config_hash,omitempty"`   // ConfigHash identifies the configuration of the collector, see Collector.ConfigHash.
+	Aliases     map[string][]string    `json:"aliases" bson:"aliases,omitempty"`           // Aliases lists the raw URLs collapsed into each canonical page if the alias storage is set.
+}
+
+// HostReport is a summary of the crawl activity of a single host.
+type HostReport struct {
+	Host         string             `json:"host" bson:"host,omitempty"`                   // Host is the name of the host.
+	Pages        uint               `json:"pages" bson:"pages,omitempty"`                 // Pages is the number of the fetched pages.
+	Bytes        uint64             `json:"bytes" bson:"bytes,omitempty"`                 // Bytes is the total size of the fetched response bodies.
+	Blocked      uint               `json:"blocked" bson:"blocked,omitempty"`             // Blocked is the number of requests blocked by the depth limit, filters or robots.txt.
+	Errors       map[string]uint    `json:"errors" bson:"errors,omitempty"`               // Errors is the number of errors, mapped by the error messages.
+	AvgLatency   time.Duration      `json:"avg_latency" bson:"avg_latency,omitempty"`     // AvgLatency is the average time between the request and the response.
+	MaxDepth     uint16             `json:"max_depth" bson:"max_depth,omitempty"`         // MaxDepth is the depth of the deepest fetched page.
+	DeepestPath  string             `json:"deepest_path" bson:"deepest_path,omitempty"`   // DeepestPath is the URL of the deepest fetched page.
+	ContentTypes map[string]uint    `json:"content_types" bson:"content_types,omitempty"` // ContentTypes is the number of the fetched pages, mapped by the media types.
+	Window       *CrawlWindowStatus `json:"window" bson:"window,omitempty"`               // Window is the state of the crawl windows, nil if the host has no crawl windows.
+
+	latencySum   time.Duration
+	latencyCount uint
+}
+
+// reporter collects the crawl activity of a collector.
+type reporter struct {
+	hosts   map[string]*HostReport
+	started map[uint32]time.Time
+	pages   map[string]pageSignature // duplicate-content fingerprints by URL
+	lock    *sync.Mutex
+}
+
+// ------------------------------------------------------------------------
+
+const crawlReportPage = `<!DOCTYPE html>
+<html>
+<head>
+	<title>Colly Crawl Report</title>
+</head>
+<body>
+<h1>Crawl Report #{{.CollectorID}}</h1>
+<p>Created: {{.Created.Format "2006-01-02 15:04:05"}}</p>
+<p>Configuration: {{.ConfigHash}}</p>
+<table border="1" cellpadding="4">
+	<tr>
+		<th>Host</th><th>Pages</th><th>Bytes</th><th>Blocked</th><th>Errors</th>
+		<th>Avg. Latency</th><th>Deepest Path</th><th>Top Content Types</th><th>Crawl Window</th>
+	</tr>
+	{{range .SortedHosts}}
+	<tr>
+		<td>{{.Host}}</td><td>{{.Pages}}</td><td>{{.Bytes}}</td><td>{{.Blocked}}</td>
+		<td>{{range $msg, $n := .Errors}}{{$msg}}: {{$n}}<br>{{end}}</td>
+		<td>{{.AvgLatency}}</td><td>{{.DeepestPath}} ({{.MaxDepth}})</td>
+		<td>{{range .TopContentTypes 3}}{{.}}<br>{{end}}</td>
+		<td>{{with .Window}}{{if .Open}}open{{else}}closed until {{.NextOpen.Format "2006-01-02 15:04:05 MST"}}{{end}}, {{.Held}} held{{end}}</td>
+	</tr>
+	{{end}}
+</table>
+{{with .Duplicates}}
+<h2>Duplicate Content</h2>
+<table border="1" cellpadding="4">
+	<tr><th>Grouping</th><th>Key</th><th>Pages</th></tr>
+	{{range .Canonical}}<tr><td>Canonical ({{.MaxHops}} hops)</td><td>{{.Key}}</td><td>{{range .URLs}}{{.}}<br>{{end}}</td></tr>{{end}}
+	{{range .Title}}<tr><td>Title</td><td>{{.Key}}</td><td>{{range .URLs}}{{.}}<br>{{end}}</td></tr>{{end}}
+	{{range .Content}}<tr><td>Content</td><td>{{.Key}}</td><td>{{range .URLs}}{{.}}<br>{{end}}</td></tr>{{end}}
+</table>
+{{end}}
+{{with .Aliases}}
+<h2>URL Aliases</h2>
+<table border="1" cellpadding="4">
+	<tr><th>Canonical</th><th>Raw URLs</th></tr>
+	{{range $key, $aliases := .}}<tr><td>{{$key}}</td><td>{{range $aliases}}{{.}}<br>{{end}}</td></tr>{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`
+
+// ------------------------------------------------------------------------
+
+var crawlReportTemplate = template.Must(template.New("report").Parse(crawlReportPage))
+
+// ------------------------------------------------------------------------
+
+// newReporter returns a pointer to a newly created crawl activity collector.
+func newReporter() *reporter {
+	return &reporter{
+		hosts:   map[string]*HostReport{},
+		started: map[uint32]time.Time{},
+		pages:   map[string]pageSignature{},
+		lock:    &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Report returns a snapshot of the crawl activity, grouped by hosts.
+// The duplicate-content clusters are included if CollectorConfig.DuplicateAnalysis is set,
+// the URL aliases if CollectorConfig.AliasStorage is set.
+func (c *Collector) Report() *CrawlReport {
+	rep := c.reporter.report(c.ID)
+	rep.ConfigHash = c.ConfigHash()
+	c.setCrawlWindowStatus(rep)
+	if c.Config != nil && c.Config.DuplicateAnalysis != DUPLICATE_NONE {
+		rep.Duplicates = c.reporter.duplicates(c.Config.DuplicateAnalysis)
+	}
+	if c.Config != nil && c.Config.AliasStorage != nil {
+		rep.Aliases = c.aliases()
+	}
+
+	return rep
+}
+
+// ------------------------------------------------------------------------
+
+// SortedHosts returns the host reports, sorted by the host names.
+func (r *CrawlReport) SortedHosts() []*HostReport {
+	hosts := make([]*HostReport, 0, len(r.Hosts))
+	for _, h := range r.Hosts {
+		hosts = append(hosts, h)
+	}
+
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].Host < hosts[j].Host
+	})
+
+	return hosts
+}
+
+// WriteJSON writes the JSON encoded report to w.
+func (r *CrawlReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+// WriteHTML writes the report to w as a HTML page.
+func (r *CrawlReport) WriteHTML(w io.Writer) error {
+	return crawlReportTemplate.Execute(w, r)
+}
+
+// ------------------------------------------------------------------------
+
+// TopContentTypes returns the n most frequent media types of the host.
+func (h *HostReport) TopContentTypes(n int) []string {
+	types := make([]string, 0, len(h.ContentTypes))
+	for t := range h.ContentTypes {
+		types = append(types, t)
+	}
+
+	sort.Slice(types, func(i, j int) bool {
+		if h.ContentTypes[types[i]] == h.ContentTypes[types[j]] {
+			return types[i] < types[j]
+		}
+		return h.ContentTypes[types[i]] > h.ContentTypes[types[j]]
+	})
+
+	if n >= 0 && n < len(types) {
+		types = types[:n]
+	}
+
+	return types
+}
+
+// ------------------------------------------------------------------------
+
+func (h *HostReport) clone() *HostReport {
+	c := *h
+	c.Window = nil
+	c.Errors = make(map[string]uint, len(h.Errors))
+	for k, v := range h.Errors {
+		c.Errors[k] = v
+	}
+	c.ContentTypes = make(map[string]uint, len(h.ContentTypes))
+	for k, v := range h.ContentTypes {
+		c.ContentTypes[k] = v
+	}
+
+	return &c
+}
+
+// ------------------------------------------------------------------------
+
+func (r *reporter) report(collectorID uint32) *CrawlReport {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	rep := &CrawlReport{
+		CollectorID: collectorID,
+		Created:     time.Now(),
+		Hosts:       make(map[string]*HostReport, len(r.hosts)),
+	}
+
+	for host, h := range r.hosts {
+		rep.Hosts[host] = h.clone()
+	}
+
+	return rep
+}
+
+func (r *reporter) host(name string) *HostReport {
+	h, present := r.hosts[name]
+	if !present {
+		h = &HostReport{
+			Host:         name,
+			Errors:       map[string]uint{},
+			ContentTypes: map[string]uint{},
+		}
+		r.hosts[name] = h
+	}
+
+	return h
+}
+
+func (r *reporter) requestStarted(req *Request) {
+	r.lock.Lock()
+	r.started[req.ID] = time.Now()
+	r.lock.Unlock()
+}
+
+// requestAborted forgets the start time of a request aborted before it was sent.
+func (r *reporter) requestAborted(req *Request) {
+	r.lock.Lock()
+	delete(r.started, req.ID)
+	r.lock.Unlock()
+}
+
+func (r *reporter) responseReceived(resp *Response) {
+	if resp.Request == nil || resp.Request.Req == nil || resp.Resp == nil {
+		return
+	}
+
+	req := resp.Request
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	h := r.host(req.OriginalURL().Hostname())
+	h.Pages++
+	h.Bytes += uint64(len(resp.Body))
+
+	if started, present := r.started[req.ID]; present {
+		h.latencySum += time.Since(started)
+		h.latencyCount++
+		h.AvgLatency = h.latencySum / time.Duration(h.latencyCount)
+		delete(r.started, req.ID)
+	}
+
+	if req.Depth >= h.MaxDepth {
+		h.MaxDepth = req.Depth
+		h.DeepestPath = req.OriginalURL().String()
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(resp.Resp.Header.Get("Content-Type")); err == nil {
+		h.ContentTypes[mediaType]++
+	}
+}
+
+func (r *reporter) errorOccurred(req *Request, err error) {
+	if req == nil || req.Req == nil || err == nil {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	h := r.host(req.OriginalURL().Hostname())
+	delete(r.started, req.ID)
+
+	if isBlockingError(err) {
+		h.Blocked++
+
+		return
+	}
+
+	h.Errors[err.Error()]++
+}
+
+// ------------------------------------------------------------------------
+
+// isBlockingError returns true if the request was blocked by the depth limit, a filter or robots.txt.
+func isBlockingError(err error) bool {
+	for _, e := range []error{
+		ErrMaxDepth,
+		ErrRobotsTxtBlocked,
+		ErrForbiddenDomain,
+		ErrFilterURLDisallowed,
+		ErrFilterDomainDisallowed,
+		ErrFilterNoMatch,
+		ErrFilterURLLength,
+		ErrFilterNoRevisit,
+		ErrFilterMaxDepth,
+	} {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+
+	return false
+}
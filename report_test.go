@@ -0,0 +1,58 @@
+package colly
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollector_Report(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	cfg := NewConfig()
+	cfg.IgnoreRobotsTxt = false
+
+	c := NewCollector(cfg, nil)
+
+	if err := c.Visit(ts.URL + "/page"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Visit(ts.URL + "/private"); err == nil {
+		t.Fatal("Visit() of a URL disallowed by robots.txt returned no error")
+	}
+	c.Visit(ts.URL + "/missing")
+
+	u, _ := url.Parse(ts.URL)
+	h := c.Report().Hosts[u.Hostname()]
+	if h == nil {
+		t.Fatalf("Report() has no host %s", u.Hostname())
+	}
+	if h.Pages != 1 || h.Blocked != 1 || len(h.Errors) != 1 {
+		t.Errorf("host report = %d pages, %d blocked, errors %v, want 1, 1, 1 error", h.Pages, h.Blocked, h.Errors)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_Report_aborted(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	c := NewCollector(nil, nil)
+	c.OnRequest(func(r *Request) { r.Abort() })
+
+	for i := 0; i < 3; i++ {
+		c.Visit(ts.URL + "/page?i=" + strconv.Itoa(i))
+	}
+
+	// The start times of the aborted requests are not kept
+	c.reporter.lock.Lock()
+	n := len(c.reporter.started)
+	c.reporter.lock.Unlock()
+	if n != 0 {
+		t.Errorf("started requests after the aborts = %d, want 0", n)
+	}
+}
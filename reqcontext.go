@@ -0,0 +1,77 @@
+package colly
+
+import (
+	"context"
+)
+
+// ------------------------------------------------------------------------
+
+// ContextInitCallback is a type alias for OnContextInit callback functions.
+type ContextInitCallback func(*Request)
+
+// contextInitKey is the context key marking the initialized request contexts.
+type contextInitKey struct{}
+
+// ------------------------------------------------------------------------
+
+// PutTyped stores a value of the type T in the context of the request.
+// The value is inherited by the requests created from the request afterwards,
+// the other requests sharing the context with the request are not affected.
+func PutTyped[T any](r *Request, key any, value T) {
+	parent := context.Background()
+	if r.Ctx != nil {
+		parent = *r.Ctx
+	}
+
+	ctx := context.WithValue(parent, key, value)
+	r.Ctx = &ctx
+}
+
+// GetTyped retrieves a value of the type T from the context of the request.
+// It returns false if the key is not found or the value has another type.
+func GetTyped[T any](r *Request, key any) (T, bool) {
+	var zero T
+	if r == nil || r.Ctx == nil {
+		return zero, false
+	}
+
+	v, ok := (*r.Ctx).Value(key).(T)
+
+	return v, ok
+}
+
+// ------------------------------------------------------------------------
+
+// OnContextInit is convenience method to register a function that initializes the context
+// of the requests started without a parent request, e.g. by Collector.Visit.
+// The values stored by the function, see PutTyped, are inherited by all descendant requests.
+// The position identifies the execution order.
+func (c *Collector) OnContextInit(fn ContextInitCallback, position ...int) {
+	c.Callbacks.Add(ON_CONTEXT_INIT, NO_ARG, fn, position...)
+}
+
+// OnContextInitDetach removes a number of registered context initializer functions.
+// If no position was given, all context initializer functions will be removed.
+func (c *Collector) OnContextInitDetach(position ...int) {
+	c.Callbacks.Remove(ON_CONTEXT_INIT, NO_ARG, position...)
+}
+
+// handleOnContextInit calls the context initializers if the context of the request
+// was not initialized yet. The contexts of the descendant requests are initialized
+// by their ancestors, so the initializers are called once per request tree.
+func (c *Collector) handleOnContextInit(r *Request) {
+	if c.Callbacks.IsEmpty(ON_CONTEXT_INIT) {
+		return
+	}
+
+	if _, done := GetTyped[bool](r, contextInitKey{}); done {
+		return
+	}
+	PutTyped(r, contextInitKey{}, true)
+
+	for _, fn := range c.Callbacks.GetArg(ON_CONTEXT_INIT, NO_ARG) {
+		if callback, ok := fn.(ContextInitCallback); ok {
+			c.runCallback(ON_CONTEXT_INIT, r, fn, func() { callback(r) })
+		}
+	}
+}
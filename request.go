@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"strings"
 	"sync/atomic"
+	"time"
 )
 
 // ------------------------------------------------------------------------
@@ -26,10 +27,56 @@ type Request struct {
 	// Leave it blank to allow automatic character encoding of the response body.
 	// It is empty by default and it can be set in OnRequest callback.
 	CharEncoding string `json:"char_encoding" bson:"char_encoding,omitempty"`
+	// AcceptEncoding is the list of the accepted content codings of the response.
+	// Leave it blank to use the collector settings. It can be set in OnRequest callback.
+	AcceptEncoding []string `json:"accept_encoding" bson:"accept_encoding,omitempty"`
+	// CompressBody overrides the collector settings whether or not the request body
+	// will be gzip compressed. It can be set in OnRequest callback.
+	CompressBody BodyCompression `json:"compress_body" bson:"compress_body,omitempty"`
+	// NotBefore is the earliest execution time of the request, it is persisted with the request.
+	// Zero value means the request can be executed immediately, see Collector.Schedule.
+	NotBefore time.Time `json:"not_before" bson:"not_before,omitempty"`
+	// Priority is the priority of the request in a priority queue, higher priority requests are processed first.
+	// The requests created from a response inherit the priority by the PriorityCallback of the collector.
+	Priority float64 `json:"priority" bson:"priority,omitempty"`
+	// Score is the score of the response assigned by Response.SetScore.
+	Score float64 `json:"score" bson:"score,omitempty"`
+	// IdempotencyKey is sent in the Idempotency-Key header, it is persisted with the request
+	// so the retries of a mutation reuse the same key. See CollectorConfig.IdempotencyKeys.
+	IdempotencyKey string `json:"idempotency_key" bson:"idempotency_key,omitempty"`
+	// IdleTimeout overrides the idle timeout of the collector for the request, see CollectorConfig.IdleTimeout.
+	// It can be set in OnRequest callback.
+	IdleTimeout time.Duration `json:"idle_timeout" bson:"idle_timeout,omitempty"`
+	// Context carries the values passed between the callbacks, e.g. from OnRequest to OnHTML.
+	// It is shared with the response and the requests created from the request.
+	Context *Context `json:"values" bson:"values,omitempty"`
+
+	collector   *Collector
+	abort       bool
+	scored      bool
+	baseURL     *url.URL
+	originalURL *url.URL        // URL before rewriting, see URLRewriter
+	sentURL     *url.URL        // URL before the redirects, see requestedURL
+	cbCtx       context.Context // context of the running timed callback, see CallbackContext
+	stuck       atomic.Bool     // set by the watchdog when the request exceeded its lifetime
+	trapped     bool            // set if the response was detected in a spider trap, see Trapped
+	started     time.Time       // start of the current attempt, see AttemptInfo
+}
 
-	collector *Collector
-	abort     bool
-	baseURL   *url.URL
+// gobRequest is the serialized form of a request, see Request.ToBytes.
+type gobRequest struct {
+	ID             uint32
+	Depth          uint16
+	Method         string
+	URL            string
+	Header         http.Header
+	Body           []byte
+	CharEncoding   string
+	NotBefore      time.Time
+	Priority       float64
+	Score          float64
+	IdempotencyKey string
+	Context        *Context
 }
 
 // type requestHandler struct{}
@@ -52,14 +99,16 @@ func NewRequest(method string, rawURL string, parser Parser, tracer Tracer, body
 		return nil, err
 	}
 
+	values := NewContext()
 	req.URL = URL
-	ctx := context.Background()
+	ctx := withValues(context.Background(), values)
 
 	return &Request{
-		Req:    req,
-		Ctx:    &ctx,
-		Parser: parser,
-		Tracer: tracer,
+		Req:     req,
+		Ctx:     &ctx,
+		Parser:  parser,
+		Tracer:  tracer,
+		Context: values,
 	}, nil
 }
 
@@ -107,14 +156,18 @@ func (r *Request) Clone(method string, rawURL string, body io.ReadCloser) (*Requ
 		req.Header.Set("Host", h)
 	}
 
-	return &Request{
-		ID:        atomic.AddUint32(&r.collector.requestCount, 1),
+	clone := &Request{
+		ID:        r.collector.stats.nextRequestID(),
 		Req:       req,
 		Ctx:       r.Ctx,
 		Parser:    r.Parser,
 		Tracer:    r.Tracer,
+		Context:   r.Context,
 		collector: r.collector,
-	}, nil
+	}
+	clone.Priority = r.collector.childPriority(r, clone)
+
+	return clone, nil
 }
 
 // ------------------------------------------------------------------------
@@ -149,7 +202,7 @@ func (r *Request) Abort() {
 // preserves the Context of the previous request.
 // It also calls the previously provided callbacks.
 func (r *Request) Visit(URL string) error {
-	return r.collector.scrape(r.AbsoluteURL(URL), "GET", r.Depth+1, nil, r.Ctx, nil, true)
+	return r.submit(r.AbsoluteURL(URL), "GET", r.Depth+1, nil, nil, true)
 }
 
 // ------------------------------------------------------------------------
@@ -158,7 +211,7 @@ func (r *Request) Visit(URL string) error {
 // preserves the context of the previous request.
 // It also calls the previously provided callbacks.
 func (r *Request) Post(URL string, reqData map[string]string) error {
-	return r.collector.scrape(r.AbsoluteURL(URL), "POST", r.Depth+1, NewFormReader(reqData), r.Ctx, nil, true)
+	return r.submit(r.AbsoluteURL(URL), "POST", r.Depth+1, NewFormReader(reqData), nil, true)
 }
 
 // ------------------------------------------------------------------------
@@ -167,7 +220,7 @@ func (r *Request) Post(URL string, reqData map[string]string) error {
 // PostRaw preserves the Context of the previous request.
 // It also calls the previously provided callbacks.
 func (r *Request) PostRaw(URL string, reqData []byte) error {
-	return r.collector.scrape(r.AbsoluteURL(URL), "POST", r.Depth+1, bytes.NewReader(reqData), r.Ctx, nil, true)
+	return r.submit(r.AbsoluteURL(URL), "POST", r.Depth+1, bytes.NewReader(reqData), nil, true)
 }
 
 // ------------------------------------------------------------------------
@@ -182,7 +235,7 @@ func (r *Request) PostMultipart(URL string, reqData map[string][]byte) error {
 	hdr.Set("Content-Type", "multipart/form-data; boundary="+boundary)
 	hdr.Set("User-Agent", r.collector.Config.UserAgentCallback())
 
-	return r.collector.scrape(r.AbsoluteURL(URL), "POST", r.Depth+1, NewMultipartReader(boundary, reqData), r.Ctx, hdr, true)
+	return r.submit(r.AbsoluteURL(URL), "POST", r.Depth+1, NewMultipartReader(boundary, reqData), hdr, true)
 }
 
 // ------------------------------------------------------------------------
@@ -190,20 +243,42 @@ func (r *Request) PostMultipart(URL string, reqData map[string][]byte) error {
 // Retry submits HTTP request again with the same parameters.
 func (r *Request) Retry() error {
 	r.Req.Header.Del("Cookie")
-	return r.collector.scrape(r.Req.URL.String(), r.Req.Method, r.Depth, r.Req.Body, r.Ctx, r.Req.Header, false)
+	r.Ctx = r.retryContext()
+	return r.submit(r.Req.URL.String(), r.Req.Method, r.Depth, r.Req.Body, r.Req.Header, false)
 }
 
 // ------------------------------------------------------------------------
 
 // Do submits the request.
 func (r *Request) Do() error {
-	return r.collector.scrape(r.Req.URL.String(), r.Req.Method, r.Depth, r.Req.Body, r.Ctx, r.Req.Header, !r.collector.AllowURLRevisit)
+	return r.submit(r.Req.URL.String(), r.Req.Method, r.Depth, r.Req.Body, r.Req.Header, true)
+}
+
+// submit scrapes the URL with the context of the request.
+// The new request joins the request group of the request, if any.
+func (r *Request) submit(URL string, method string, depth uint16, body io.Reader, hdr http.Header, checkRevisit bool) error {
+	if r.trapped && depth > r.Depth && r.collector.Config.TrapStopFollow {
+		return ErrSpiderTrap
+	}
+
+	ctx, cancel := r.collector.groupSpawn(r)
+
+	err := r.collector.scrape(URL, method, int(depth), body, ctx, hdr, checkRevisit)
+
+	// Synchronous requests have finished by now, even if a path missed their token
+	if err != nil || !r.collector.Config.Async {
+		cancel()
+	}
+
+	return err
 }
 
 // ------------------------------------------------------------------------
 
 // ToBytes converts the request to bytes.
 func (r *Request) ToBytes() ([]byte, error) {
+	r.ensureIdempotencyKey()
+
 	b := &bytes.Buffer{}
 	err := gob.NewEncoder(b).Encode(r)
 
@@ -212,6 +287,93 @@ func (r *Request) ToBytes() ([]byte, error) {
 
 // ------------------------------------------------------------------------
 
+// GobEncode implements the gob.GobEncoder interface. The request is encoded with its
+// URL, method, headers, replayable body and context values.
+func (r *Request) GobEncode() ([]byte, error) {
+	if r.Req == nil {
+		return nil, ErrNoHTTPRequest
+	}
+
+	gr := &gobRequest{
+		ID:             r.ID,
+		Depth:          r.Depth,
+		Method:         r.Req.Method,
+		URL:            r.Req.URL.String(),
+		Header:         r.Req.Header,
+		CharEncoding:   r.CharEncoding,
+		NotBefore:      r.NotBefore,
+		Priority:       r.Priority,
+		Score:          r.Score,
+		IdempotencyKey: r.IdempotencyKey,
+		Context:        r.Context,
+	}
+
+	if r.Req.GetBody != nil {
+		body, err := r.Req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+
+		if gr.Body, err = io.ReadAll(body); err != nil {
+			return nil, err
+		}
+	}
+
+	b := &bytes.Buffer{}
+	err := gob.NewEncoder(b).Encode(gr)
+
+	return b.Bytes(), err
+}
+
+// GobDecode implements the gob.GobDecoder interface. The decoded request is not bound to
+// a collector, it is dispatched by submitting it to one, e.g. by a job queue.
+func (r *Request) GobDecode(data []byte) error {
+	gr := &gobRequest{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(gr); err != nil {
+		return err
+	}
+
+	if gr.Context == nil {
+		gr.Context = NewContext()
+	}
+	ctx := withValues(context.Background(), gr.Context)
+
+	parser := NewWHATWGParser()
+	URL, err := parser.Parse(gr.URL)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, gr.Method, URL.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.URL = URL
+	if gr.Header != nil {
+		req.Header = gr.Header
+	}
+
+	r.ID = gr.ID
+	r.Depth = gr.Depth
+	r.Req = req
+	r.Ctx = &ctx
+	r.Parser = parser
+	r.CharEncoding = gr.CharEncoding
+	r.NotBefore = gr.NotBefore
+	r.Priority = gr.Priority
+	r.Score = gr.Score
+	r.IdempotencyKey = gr.IdempotencyKey
+	r.Context = gr.Context
+	if gr.Body != nil {
+		r.setBody(gr.Body)
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
 // Marshal serializes the Request
 // func (r *Request) Marshal() ([]byte, error) {
 // 	ctx := make(map[string]any)
@@ -246,14 +408,19 @@ func (r *Request) ToBytes() ([]byte, error) {
 
 // ------------------------------------------------------------------------
 
-// AbsoluteURL returns the resolved absolute URL of an URL chunk.
+// AbsoluteURL returns the resolved absolute URL of an URL chunk, relative to the base tag of the page if any.
 // It returns empty string if the URL chunk is a fragment or could not be parsed.
 func (r *Request) AbsoluteURL(rawURL string) string {
-	if strings.HasPrefix(rawURL, "#") {
+	if strings.HasPrefix(rawURL, "#") && (r.collector == nil || !r.collector.Config.FragmentMode.follows(rawURL)) {
 		return ""
 	}
 
-	absURL, err := r.Parser.ParseRef(r.Req.URL.String(), rawURL)
+	base := r.OriginalURL()
+	if r.baseURL != nil {
+		base = r.baseURL
+	}
+
+	absURL, err := r.Parser.ParseRef(base.String(), rawURL)
 	if err != nil {
 		return ""
 	}
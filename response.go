@@ -2,7 +2,7 @@ package colly
 
 import (
 	"bytes"
-	"compress/gzip"
+	"errors"
 	"io"
 	"mime"
 	"net/http"
@@ -19,12 +19,23 @@ import (
 
 // Response is an encapsulated HTTP response, created by a Collector.
 type Response struct {
-	Request       *Request       `json:"request" bson:"request,omitempty"`         // Request is the embedded Request.
-	Resp          *http.Response `json:"response" bson:"response,omitempty"`       // Response is the embedded HTTP response.
-	ExtStatusCode uint           `json:"status_code" bson:"status_code,omitempty"` // ExtStatusCode is the extended response status code.
-	Body          []byte         `json:"body" bson:"body,omitempty"`               // Body is the content of the response.
-	Created       time.Time      `json:"created" bson:"created,omitempty"`         // Received is the date and time when the response was created.
-	Expiry        time.Time      `json:"expiry" bson:"expiry,omitempty"`           // Expiry is the response expiry date and time.
+	Request       *Request       `json:"request" bson:"request,omitempty"`           // Request is the embedded Request.
+	Resp          *http.Response `json:"response" bson:"response,omitempty"`         // Response is the embedded HTTP response.
+	ExtStatusCode uint           `json:"status_code" bson:"status_code,omitempty"`   // ExtStatusCode is the extended response status code.
+	Body          []byte         `json:"body" bson:"body,omitempty"`                 // Body is the content of the response.
+	Created       time.Time      `json:"created" bson:"created,omitempty"`           // Received is the date and time when the response was created.
+	Expiry        time.Time      `json:"expiry" bson:"expiry,omitempty"`             // Expiry is the response expiry date and time.
+	Language      string         `json:"language" bson:"language,omitempty"`         // Language is the detected language of the response body.
+	SniffedType   string         `json:"sniffed_type" bson:"sniffed_type,omitempty"` // SniffedType is the media type sniffed from the response body.
+	Partial       bool           `json:"partial" bson:"partial,omitempty"`           // Partial is true if the body download was aborted by a chunk callback.
+	FromCache     bool           `json:"from_cache" bson:"from_cache,omitempty"`     // FromCache is true if the response was served from the cache.
+	NotModified   bool           `json:"not_modified" bson:"not_modified,omitempty"` // NotModified is true if a conditional revisit found the resource unchanged.
+	Truncated     bool           `json:"truncated" bson:"truncated,omitempty"`       // Truncated is true if the body is shorter than the Content-Length or the connection closed mid-body.
+	Attempts      []AttemptInfo  `json:"attempts" bson:"attempts,omitempty"`         // Attempts are the outcomes of the attempts of the request, the current one last.
+
+	buf     *bytes.Buffer // pooled body buffer
+	nested  uint8         // nesting level of the embedded documents, 0 for the fetched pages
+	archive bool          // marked by Archive for the body archiver
 }
 
 // ------------------------------------------------------------------------
@@ -54,24 +65,32 @@ func (r *Response) setBody(detectCharset bool, bodySize int) (err error) {
 		return nil
 	}
 
-	var rdr io.Reader = r.Resp.Body
+	cr := &countingReader{rdr: r.Resp.Body}
+	var rdr io.Reader = cr
 	if bodySize > 0 {
 		rdr = io.LimitReader(rdr, int64(bodySize))
 	}
 
-	if isCompressed(r.Resp) {
-		rdr, err = gzip.NewReader(rdr)
-		if err != nil {
-			return err
-		}
-		defer rdr.(*gzip.Reader).Close()
+	dec, err := decodeReader(r.Resp, rdr)
+	if err != nil {
+		return err
 	}
-
-	r.Body, err = io.ReadAll(rdr)
-	if err != nil || len(r.Body) == 0 {
-		r.Body = nil
+	defer dec.Close()
+
+	r.buf = acquireBuffer()
+	if _, err = r.buf.ReadFrom(r.bodyReader(dec)); errors.Is(err, errBodyAborted) {
+		r.Partial, err = true, nil
+	} else if errors.Is(err, io.ErrUnexpectedEOF) {
+		// The connection closed mid-body, keep what was received
+		r.Truncated, err = true, nil
+	} else if err == nil && r.shortBody(cr.n, bodySize) {
+		r.Truncated = true
+	}
+	if err != nil || r.buf.Len() == 0 {
+		r.Release()
 		return err
 	}
+	r.Body = r.buf.Bytes()
 
 	contentType := hdrVal(r.Resp.Header, "Content-Type")
 
@@ -239,11 +258,8 @@ func findHeaderTokenValue(hdr string, token string) *int {
 
 func isCompressed(resp *http.Response) bool {
 	enc := hdrVal(resp.Header, "Content-Encoding")
-	path := strings.ToLower(resp.Request.URL.Path)
 
-	return !resp.Uncompressed && (strings.Contains(enc, "gzip") ||
-		(enc == "" && hasHdrVal(resp.Header, "Content-Type", "gzip")) ||
-		strings.HasSuffix(path, ".xml.gz"))
+	return !resp.Uncompressed && strings.Contains(enc, "gzip")
 }
 
 // ------------------------------------------------------------------------
@@ -0,0 +1,158 @@
+package colly
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ------------------------------------------------------------------------
+
+// URLRewriter rewrites the URLs of the requests after filtering, right before they are sent,
+// e.g. to route the requests to a mirror host or a cache proxy.
+// The original URL is kept for the visit accounting, the caching, the reports and resolving the links.
+type URLRewriter interface {
+	Rewrite(u *url.URL) (*url.URL, error) // Rewrite returns the URL to be requested instead of u. It must not modify u.
+}
+
+// URLRewriterFunc is an adapter to use an ordinary function as a URL rewriter.
+type URLRewriterFunc func(u *url.URL) (*url.URL, error)
+
+// hostRewriter replaces the hosts of the URLs by their mirrors
+type hostRewriter struct {
+	hosts map[string]string
+}
+
+// urlRewriterChain applies a number of rewriters in order
+type urlRewriterChain []URLRewriter
+
+// ------------------------------------------------------------------------
+
+// Rewrite calls f(u).
+func (f URLRewriterFunc) Rewrite(u *url.URL) (*url.URL, error) {
+	return f(u)
+}
+
+// ------------------------------------------------------------------------
+
+// NewHostRewriter returns a URL rewriter that replaces the hosts by their mirrors.
+// The hosts are mapped to the mirror hosts, both may include a port.
+func NewHostRewriter(hosts map[string]string) URLRewriter {
+	r := &hostRewriter{hosts: map[string]string{}}
+	for host, mirror := range hosts {
+		r.hosts[strings.ToLower(host)] = mirror
+	}
+
+	return r
+}
+
+// Rewrite replaces the host of the URL if it has a mirror.
+func (r *hostRewriter) Rewrite(u *url.URL) (*url.URL, error) {
+	mirror, present := r.hosts[strings.ToLower(u.Host)]
+	if !present {
+		return u, nil
+	}
+
+	rewritten := *u
+	rewritten.Host = mirror
+
+	return &rewritten, nil
+}
+
+// ------------------------------------------------------------------------
+
+// HTTPSRewriter is a URL rewriter that upgrades the http URLs to https.
+var HTTPSRewriter URLRewriter = URLRewriterFunc(func(u *url.URL) (*url.URL, error) {
+	if u.Scheme != "http" {
+		return u, nil
+	}
+
+	rewritten := *u
+	rewritten.Scheme = "https"
+	if strings.HasSuffix(rewritten.Host, ":80") {
+		rewritten.Host = strings.TrimSuffix(rewritten.Host, ":80")
+	}
+
+	return &rewritten, nil
+})
+
+// ------------------------------------------------------------------------
+
+// ChainURLRewriters returns a URL rewriter applying the rewriters in the given order.
+func ChainURLRewriters(rewriters ...URLRewriter) URLRewriter {
+	return urlRewriterChain(rewriters)
+}
+
+// Rewrite applies the rewriters of the chain in order.
+func (c urlRewriterChain) Rewrite(u *url.URL) (*url.URL, error) {
+	var err error
+
+	for _, r := range c {
+		if u, err = r.Rewrite(u); err != nil {
+			return nil, err
+		}
+	}
+
+	return u, nil
+}
+
+// ------------------------------------------------------------------------
+
+// OriginalURL returns the URL of the request before it was rewritten by the URL rewriter.
+func (r *Request) OriginalURL() *url.URL {
+	if r.originalURL != nil {
+		return r.originalURL
+	}
+
+	if r.Req == nil {
+		return nil
+	}
+
+	return r.Req.URL
+}
+
+// requestedURL returns the URL of the request before the redirects and the rewriting.
+// It is the storage key of the cached responses and the validators, so the redirected
+// responses are stored under the URL they are looked up by.
+func (r *Request) requestedURL() *url.URL {
+	if r.sentURL != nil {
+		return r.sentURL
+	}
+
+	return r.OriginalURL()
+}
+
+// ------------------------------------------------------------------------
+
+// rewriteURL applies the URL rewriter of the collector to the request.
+// The request is aborted if the URL can't be rewritten.
+func (c *Collector) rewriteURL(r *Request) {
+	if c.Config.URLRewriter == nil || r.Req == nil || r.Req.URL == nil || r.originalURL != nil {
+		return
+	}
+
+	u, err := c.Config.URLRewriter.Rewrite(r.Req.URL)
+	if err != nil || u == nil {
+		if err != nil {
+			c.Config.logError(LOG_ERR_LEVEL, err)
+		}
+		r.Abort()
+		return
+	}
+
+	if u.String() == r.Req.URL.String() {
+		return
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_DEBUG_LEVEL, "rewrite", r.ID, map[string]string{
+			"url":       r.Req.URL.String(),
+			"rewritten": u.String(),
+		})
+	}
+
+	r.originalURL = r.Req.URL
+	r.Req.URL = u
+	if r.Req.Header.Get("Host") == "" {
+		r.Req.Host = u.Host
+	}
+}
@@ -0,0 +1,73 @@
+package colly
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestURLRewriters(t *testing.T) {
+	mirror := NewHostRewriter(map[string]string{"Example.com": "mirror.example.net:8080"})
+
+	tests := []struct {
+		name     string
+		rewriter URLRewriter
+		url      string
+		want     string
+	}{
+		{"mirror", mirror, "http://example.com/a?b=1", "http://mirror.example.net:8080/a?b=1"},
+		{"no mirror", mirror, "http://example.org/a", "http://example.org/a"},
+		{"https", HTTPSRewriter, "http://example.com:80/a", "https://example.com/a"},
+		{"https kept", HTTPSRewriter, "https://example.com/a", "https://example.com/a"},
+		{"chain", ChainURLRewriters(mirror, HTTPSRewriter), "http://example.com/a", "https://mirror.example.net:8080/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse(tt.url)
+			got, err := tt.rewriter.Rewrite(u)
+			if err != nil || got.String() != tt.want {
+				t.Errorf("Rewrite() = %v, %v, want %v", got, err, tt.want)
+			}
+			if u.String() != tt.url {
+				t.Errorf("Rewrite() modified the URL to %v", u)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_rewriteURL(t *testing.T) {
+	newReq := func(rawURL string) *Request {
+		u, _ := url.Parse(rawURL)
+		return &Request{Req: &http.Request{URL: u, Host: u.Host, Header: http.Header{}}}
+	}
+
+	c := &Collector{Config: &CollectorConfig{URLRewriter: NewHostRewriter(map[string]string{"example.com": "mirror.example.net"})}}
+
+	r := newReq("https://example.com/page")
+	c.rewriteURL(r)
+	if r.Req.URL.String() != "https://mirror.example.net/page" || r.Req.Host != "mirror.example.net" {
+		t.Errorf("rewriteURL() = %v, host %q", r.Req.URL, r.Req.Host)
+	}
+	if got := r.OriginalURL().String(); got != "https://example.com/page" {
+		t.Errorf("OriginalURL() = %q", got)
+	}
+
+	r = newReq("https://example.org/page")
+	c.rewriteURL(r)
+	if r.OriginalURL() != r.Req.URL {
+		t.Errorf("OriginalURL() = %v, want the request URL", r.OriginalURL())
+	}
+
+	c.Config.URLRewriter = URLRewriterFunc(func(*url.URL) (*url.URL, error) { return nil, errors.New("no mirror") })
+	r = newReq("https://example.com/page")
+	c.rewriteURL(r)
+	if !r.abort || r.Req.URL.String() != "https://example.com/page" {
+		t.Errorf("rewriteURL() = %v, abort %v, want the aborted original request", r.Req.URL, r.abort)
+	}
+}
@@ -0,0 +1,308 @@
+package colly
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/temoto/robotstxt"
+)
+
+// ------------------------------------------------------------------------
+
+// RobotsSitemapCallback is a type alias for OnRobotsSitemap callback functions.
+// It receives the host and a sitemap URL listed in the robots.txt of the host.
+type RobotsSitemapCallback func(host string, sitemap string)
+
+// RobotsRules is the content of the robots.txt of a host applying to the collector's user agent.
+type RobotsRules struct {
+	Host       string                `json:"host" bson:"host"`                         // Host is the host of the robots.txt.
+	StatusCode int                   `json:"status_code" bson:"status_code,omitempty"` // StatusCode is the HTTP status code of the robots.txt response.
+	Agent      string                `json:"agent" bson:"agent,omitempty"`             // Agent is the user agent of the matching group, blank if no group matched.
+	Allow      []string              `json:"allow" bson:"allow,omitempty"`             // Allow is the list of the allowed paths of the matching group.
+	Disallow   []string              `json:"disallow" bson:"disallow,omitempty"`       // Disallow is the list of the disallowed paths of the matching group.
+	CrawlDelay time.Duration         `json:"crawl_delay" bson:"crawl_delay,omitempty"` // CrawlDelay is the crawl-delay of the matching group.
+	Sitemaps   []string              `json:"sitemaps" bson:"sitemaps,omitempty"`       // Sitemaps are the sitemap URLs listed in the robots.txt.
+	data       *robotstxt.RobotsData // parsed robots.txt
+	userAgent  string                // user agent the rules were selected for
+}
+
+// robotsFile is a fetched robots.txt.
+type robotsFile struct {
+	statusCode int
+	body       []byte
+}
+
+// cachedRobots is a robots.txt kept in the robots cache.
+type cachedRobots struct {
+	StatusCode int       `json:"status_code"`
+	Body       []byte    `json:"body"`
+	Fetched    time.Time `json:"fetched"`
+}
+
+// robotsGroup is a group of rules of a number of user agents.
+type robotsGroup struct {
+	agents     []string
+	allow      []string
+	disallow   []string
+	crawlDelay time.Duration
+}
+
+// ------------------------------------------------------------------------
+
+const DEFAULT_ROBOTS_CACHE_TTL = 24 * time.Hour // DEFAULT_ROBOTS_CACHE_TTL is the default lifetime of the cached robots.txt files.
+
+// ------------------------------------------------------------------------
+
+// OnRobotsSitemap is convenience method to register a function that will be executed
+// for every sitemap URL listed in a robots.txt, when the robots.txt of a host is loaded.
+// The position identifies the execution order.
+func (c *Collector) OnRobotsSitemap(fn RobotsSitemapCallback, position ...int) {
+	c.Callbacks.Add(ON_ROBOTS_SITEMAP, NO_ARG, fn, position...)
+}
+
+// OnRobotsSitemapDetach removes a number of registered robots.txt sitemap callback functions.
+// If no position was given, all robots.txt sitemap callback functions will be removed.
+func (c *Collector) OnRobotsSitemapDetach(position ...int) {
+	c.Callbacks.Remove(ON_ROBOTS_SITEMAP, NO_ARG, position...)
+}
+
+// handleOnRobotsSitemap calls the robots.txt sitemap callbacks with the sitemaps of the robots.txt.
+func (c *Collector) handleOnRobotsSitemap(host string, statusCode int, body []byte) {
+	if c.Callbacks.IsEmpty(ON_ROBOTS_SITEMAP) {
+		return
+	}
+
+	host = strings.ToLower(host)
+	for _, sitemap := range parseRobotsRules(statusCode, body, "").Sitemaps {
+		for _, fn := range c.Callbacks.GetArg(ON_ROBOTS_SITEMAP, NO_ARG) {
+			if callback, ok := fn.(RobotsSitemapCallback); ok {
+				callback(host, sitemap)
+			}
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// RobotsFor returns the robots.txt rules of the host applying to the collector's user agent.
+// host is the host of the URLs, including the port if there is one. It returns false if
+// the robots.txt of the host was not loaded yet. The returned rules can be modified freely.
+func (c *Collector) RobotsFor(host string) (*RobotsRules, bool) {
+	host = strings.ToLower(host)
+
+	c.lock.RLock()
+	f, present := c.robotsText[host]
+	data := c.robotsMap[host]
+	c.lock.RUnlock()
+
+	if !present {
+		return nil, false
+	}
+
+	userAgent := ""
+	if c.Config.UserAgentCallback != nil {
+		userAgent = c.Config.UserAgentCallback()
+	}
+
+	rules := parseRobotsRules(f.statusCode, f.body, userAgent)
+	rules.Host = host
+	rules.data = data
+	rules.userAgent = userAgent
+
+	return rules, true
+}
+
+// ------------------------------------------------------------------------
+
+// Allowed tells whether the path, including the query, can be crawled by the matching group.
+func (r *RobotsRules) Allowed(path string) bool {
+	if r.data == nil {
+		return true
+	}
+
+	return r.data.FindGroup(r.userAgent).Test(path)
+}
+
+// ------------------------------------------------------------------------
+
+// setRobots parses and stores the robots.txt of the host. The robots.txt is
+// used for the access checks and it is available for RobotsFor afterwards.
+func (c *Collector) setRobots(host string, statusCode int, body []byte) (*robotstxt.RobotsData, error) {
+	data, err := robotstxt.FromStatusAndBytes(statusCode, body)
+	if err != nil {
+		return nil, err
+	}
+
+	host = strings.ToLower(host)
+
+	c.lock.Lock()
+	c.robotsMap[host] = data
+	c.robotsText[host] = &robotsFile{statusCode: statusCode, body: body}
+	c.lock.Unlock()
+
+	return data, nil
+}
+
+// ------------------------------------------------------------------------
+
+// cachedRobots returns the robots.txt of the host of the URL from the robots cache.
+// It returns false if there is no robots cache, the robots.txt is not cached or it expired.
+func (c *Collector) cachedRobots(u *url.URL) (int, []byte, bool) {
+	stg := c.Config.RobotsCache
+	key := robotsCacheKey(u)
+	if stg == nil || !stg.Has(key) {
+		return 0, nil, false
+	}
+
+	rdr, err := stg.Fetch(key)
+	if err != nil {
+		c.handleOnStorageError(STORAGE_ROBOTS, err)
+		return 0, nil, false
+	}
+
+	item := &cachedRobots{}
+	if err := json.NewDecoder(rdr).Decode(item); err != nil {
+		c.handleOnStorageError(STORAGE_ROBOTS, err)
+		return 0, nil, false
+	}
+
+	ttl := c.Config.RobotsCacheTTL
+	if ttl <= 0 {
+		ttl = DEFAULT_ROBOTS_CACHE_TTL
+	}
+	if time.Since(item.Fetched) > ttl {
+		return 0, nil, false
+	}
+
+	return item.StatusCode, item.Body, true
+}
+
+// cacheRobots stores the robots.txt of the host of the URL in the robots cache.
+// The robots.txt files failing with a server error are not cached, they are fetched again.
+func (c *Collector) cacheRobots(u *url.URL, statusCode int, body []byte) {
+	stg := c.Config.RobotsCache
+	if stg == nil || statusCode >= http.StatusInternalServerError {
+		return
+	}
+
+	data, err := json.Marshal(&cachedRobots{StatusCode: statusCode, Body: body, Fetched: time.Now()})
+	if err == nil {
+		err = stg.Put(robotsCacheKey(u), bytes.NewReader(data))
+	}
+	c.handleOnStorageError(STORAGE_ROBOTS, err)
+}
+
+// robotsCacheKey returns the robots cache key of the host of the URL.
+func robotsCacheKey(u *url.URL) string {
+	return "robots:" + strings.ToLower(u.Scheme+"://"+u.Host)
+}
+
+// ------------------------------------------------------------------------
+
+// parseRobotsRules extracts the rules of the group matching the user agent and the sitemaps
+// of the robots.txt. The group is selected like robotstxt does: the longest agent being
+// a prefix of the user agent wins, the "*" group is the fallback. The unavailable robots.txt
+// files allow everything, the ones failing with a server error disallow everything.
+func parseRobotsRules(statusCode int, body []byte, userAgent string) *RobotsRules {
+	rules := &RobotsRules{StatusCode: statusCode}
+
+	switch {
+	case statusCode >= http.StatusInternalServerError:
+		rules.Agent = "*"
+		rules.Disallow = []string{"/"}
+		return rules
+	case statusCode >= http.StatusBadRequest:
+		return rules
+	}
+
+	var (
+		groups  []*robotsGroup
+		current *robotsGroup
+		inRules bool // rules were added to the current group
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		key, val, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+
+		switch key {
+		case "user-agent", "useragent":
+			if current == nil || inRules {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inRules = false
+			}
+			current.agents = append(current.agents, strings.ToLower(val))
+		case "allow", "disallow", "crawl-delay", "crawldelay":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if val == "" {
+				continue
+			}
+			switch key {
+			case "allow":
+				current.allow = append(current.allow, val)
+			case "disallow":
+				current.disallow = append(current.disallow, val)
+			default:
+				if sec, err := strconv.ParseFloat(val, 64); err == nil && sec >= 0 {
+					current.crawlDelay = time.Duration(sec * float64(time.Second))
+				}
+			}
+		case "sitemap":
+			if val != "" {
+				rules.Sitemaps = append(rules.Sitemaps, val)
+			}
+		}
+	}
+
+	if g, agent := matchRobotsGroup(groups, userAgent); g != nil {
+		rules.Agent = agent
+		rules.Allow = g.allow
+		rules.Disallow = g.disallow
+		rules.CrawlDelay = g.crawlDelay
+	}
+
+	return rules
+}
+
+// matchRobotsGroup returns the group matching the user agent and its matching agent.
+func matchRobotsGroup(groups []*robotsGroup, userAgent string) (*robotsGroup, string) {
+	userAgent = strings.ToLower(userAgent)
+
+	var (
+		match  *robotsGroup
+		agent  string
+		length int
+	)
+	for _, g := range groups {
+		for _, a := range g.agents {
+			switch {
+			case a == "*" && length == 0:
+				match, agent, length = g, a, 1
+			case a != "*" && a != "" && strings.HasPrefix(userAgent, a) && len(a) > length:
+				match, agent, length = g, a, len(a)
+			}
+		}
+	}
+
+	return match, agent
+}
@@ -0,0 +1,167 @@
+package colly
+
+import (
+	"colly/storage/mem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+var robotsTestData = `# robots.txt
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Crawl-delay: 2
+
+User-agent: GoodBot
+User-agent: Other
+Disallow: /admin # comment
+Crawl-delay: 0.5
+
+Sitemap: https://example.com/sitemap.xml
+Sitemap: https://example.com/news.xml
+`
+
+// ------------------------------------------------------------------------
+
+func TestParseRobotsRules(t *testing.T) {
+	sitemaps := []string{"https://example.com/sitemap.xml", "https://example.com/news.xml"}
+
+	tests := []struct {
+		name      string
+		status    int
+		userAgent string
+		want      *RobotsRules
+	}{
+		{
+			name:      "wildcard",
+			status:    http.StatusOK,
+			userAgent: "colly",
+			want:      &RobotsRules{StatusCode: 200, Agent: "*", Allow: []string{"/private/public"}, Disallow: []string{"/private"}, CrawlDelay: 2 * time.Second, Sitemaps: sitemaps},
+		},
+		{
+			name:      "agent",
+			status:    http.StatusOK,
+			userAgent: "GoodBot/1.0",
+			want:      &RobotsRules{StatusCode: 200, Agent: "goodbot", Disallow: []string{"/admin"}, CrawlDelay: 500 * time.Millisecond, Sitemaps: sitemaps},
+		},
+		{
+			name:   "not found",
+			status: http.StatusNotFound,
+			want:   &RobotsRules{StatusCode: 404},
+		},
+		{
+			name:   "server error",
+			status: http.StatusServiceUnavailable,
+			want:   &RobotsRules{StatusCode: 503, Agent: "*", Disallow: []string{"/"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRobotsRules(tt.status, []byte(robotsTestData), tt.userAgent); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRobotsRules() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_RobotsFor(t *testing.T) {
+	c := NewCollector(nil, nil)
+	c.Config.SetUserAgent("GoodBot/1.0")
+
+	if _, ok := c.RobotsFor("example.com"); ok {
+		t.Error("RobotsFor() returned the rules of a host without robots.txt")
+	}
+
+	if _, err := c.setRobots("Example.com", http.StatusOK, []byte(robotsTestData)); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, ok := c.RobotsFor("example.COM")
+	if !ok {
+		t.Fatal("RobotsFor() didn't return the loaded rules")
+	}
+	if rules.Host != "example.com" || rules.Agent != "goodbot" || len(rules.Sitemaps) != 2 {
+		t.Errorf("RobotsFor() = %+v", rules)
+	}
+
+	for path, allowed := range map[string]bool{"/private": true, "/admin/users": false, "/": true} {
+		if got := rules.Allowed(path); got != allowed {
+			t.Errorf("Allowed(%q) = %v, want %v", path, got, allowed)
+		}
+	}
+
+	if c.robotsMap["example.com"] == nil {
+		t.Error("setRobots() didn't set the robots.txt of the access checks")
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_loadRobots(t *testing.T) {
+	fetched := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fetched++
+		w.Write([]byte(robotsTestData))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cacheStg := mem.NewCacheStorage()
+	newCollector := func() (*Collector, *[]string) {
+		cfg := NewConfig()
+		cfg.IgnoreRobotsTxt = false
+		cfg.RobotsCache = cacheStg
+
+		c := NewCollector(cfg, nil)
+		sitemaps := []string{}
+		c.OnRobotsSitemap(func(host string, sitemap string) {
+			sitemaps = append(sitemaps, host+" "+sitemap)
+		})
+
+		return c, &sitemaps
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	want := []string{host + " https://example.com/sitemap.xml", host + " https://example.com/news.xml"}
+
+	// The robots.txt is fetched once and shared by the collectors through the cache
+	for i := 0; i < 2; i++ {
+		c, sitemaps := newCollector()
+		if err := c.Visit(srv.URL + "/private"); !errors.Is(err, ErrRobotsTxtBlocked) {
+			t.Errorf("Visit() error = %v, want %v", err, ErrRobotsTxtBlocked)
+		}
+		if err := c.Visit(srv.URL + "/page"); err != nil {
+			t.Errorf("Visit() error = %v", err)
+		}
+		if !reflect.DeepEqual(*sitemaps, want) {
+			t.Errorf("sitemaps = %v, want %v", *sitemaps, want)
+		}
+	}
+	if fetched != 1 {
+		t.Errorf("robots.txt fetched %d times, want 1", fetched)
+	}
+
+	// The expired robots.txt is fetched again
+	c, _ := newCollector()
+	c.Config.RobotsCacheTTL = time.Nanosecond
+	if err := c.Visit(srv.URL + "/page"); err != nil {
+		t.Errorf("Visit() error = %v", err)
+	}
+	if fetched != 2 {
+		t.Errorf("robots.txt fetched %d times, want 2", fetched)
+	}
+}
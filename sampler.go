@@ -0,0 +1,195 @@
+package colly
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// CaptureStorage is a storage sink of the request/response dumps.
+// The cache storages satisfy this interface.
+type CaptureStorage interface {
+	Put(key string, data io.Reader) error // Put stores a dump.
+}
+
+// Sampler captures the full request and response dumps of a sample of the requests.
+// It can be enabled, disabled and tuned while the collector is running.
+type Sampler struct {
+	sink     CaptureStorage
+	filter   *Filter
+	rate     float64
+	redacted map[string]bool
+	enabled  atomic.Bool
+	lock     *sync.RWMutex
+}
+
+// ------------------------------------------------------------------------
+
+// DefaultRedactedHeaders is the list of headers whose values are masked in the dumps.
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Proxy-Authorization",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+}
+
+// redactedValue replaces the secret header values in the dumps.
+const redactedValue = "[REDACTED]"
+
+// ------------------------------------------------------------------------
+
+// NewSampler returns a pointer to a newly created, enabled sampler.
+// The rate is the fraction of the requests to be captured between 0 and 1.
+// If a filter is given, every request allowed by the filter will be captured regardless of the rate.
+func NewSampler(sink CaptureStorage, rate float64, filter ...*Filter) (*Sampler, error) {
+	if sink == nil {
+		return nil, ErrSamplerNoStorage
+	}
+
+	s := &Sampler{
+		sink: sink,
+		lock: &sync.RWMutex{},
+	}
+
+	if len(filter) > 0 {
+		s.filter = filter[0]
+	}
+
+	if err := s.SetRate(rate); err != nil {
+		return nil, err
+	}
+	s.SetRedactedHeaders(DefaultRedactedHeaders...)
+	s.enabled.Store(true)
+
+	return s, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Enable turns on capturing.
+func (s *Sampler) Enable() {
+	s.enabled.Store(true)
+}
+
+// Disable turns off capturing.
+func (s *Sampler) Disable() {
+	s.enabled.Store(false)
+}
+
+// Enabled returns true if capturing is turned on.
+func (s *Sampler) Enabled() bool {
+	return s != nil && s.enabled.Load()
+}
+
+// SetRate sets the fraction of the requests to be captured.
+func (s *Sampler) SetRate(rate float64) error {
+	if rate < 0 || rate > 1 {
+		return fmt.Errorf("sample rate must be between 0 and 1: %v was given", rate)
+	}
+
+	s.lock.Lock()
+	s.rate = rate
+	s.lock.Unlock()
+
+	return nil
+}
+
+// SetFilter sets the filter of the requests to be always captured. Nil removes the filter.
+func (s *Sampler) SetFilter(filter *Filter) {
+	s.lock.Lock()
+	s.filter = filter
+	s.lock.Unlock()
+}
+
+// SetRedactedHeaders replaces the list of headers whose values are masked in the dumps.
+func (s *Sampler) SetRedactedHeaders(headers ...string) {
+	redacted := make(map[string]bool, len(headers))
+	for _, h := range headers {
+		redacted[http.CanonicalHeaderKey(h)] = true
+	}
+
+	s.lock.Lock()
+	s.redacted = redacted
+	s.lock.Unlock()
+}
+
+// ------------------------------------------------------------------------
+
+// sample returns true if the request should be captured.
+func (s *Sampler) sample(req *Request) bool {
+	if !s.Enabled() {
+		return false
+	}
+
+	s.lock.RLock()
+	filter, rate := s.filter, s.rate
+	s.lock.RUnlock()
+
+	if filter != nil && filter.Match(req) == nil {
+		return true
+	}
+
+	return rate > 0 && rand.Float64() < rate
+}
+
+// dumpRequest returns the redacted dump of the outgoing HTTP request.
+func (s *Sampler) dumpRequest(req *http.Request) ([]byte, error) {
+	dump, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.redact(dump), nil
+}
+
+// capture stores the request dump, the response headers and the processed response body.
+func (s *Sampler) capture(req *Request, reqDump []byte, resp *Response) error {
+	data := &bytes.Buffer{}
+	data.Write(reqDump)
+	data.WriteString("\r\n\r\n")
+
+	if resp != nil && resp.Resp != nil {
+		hdr, err := httputil.DumpResponse(resp.Resp, false)
+		if err != nil {
+			return err
+		}
+		data.Write(s.redact(hdr))
+		data.Write(resp.Body)
+	}
+
+	key := fmt.Sprintf("%s-%d", time.Now().UTC().Format("20060102T150405.000000000"), req.ID)
+
+	return s.sink.Put(key, data)
+}
+
+// redact masks the values of the secret headers in the dump.
+// Only the header section is changed, the body is left untouched.
+func (s *Sampler) redact(dump []byte) []byte {
+	hdr, body := dump, []byte(nil)
+	if i := bytes.Index(dump, []byte("\r\n\r\n")); i >= 0 {
+		hdr, body = dump[:i], dump[i:]
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	lines := strings.Split(string(hdr), "\r\n")
+	for i, line := range lines {
+		if j := strings.IndexByte(line, ':'); j > 0 && s.redacted[http.CanonicalHeaderKey(line[:j])] {
+			lines[i] = line[:j] + ": " + redactedValue
+		}
+	}
+
+	return append([]byte(strings.Join(lines, "\r\n")), body...)
+}
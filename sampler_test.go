@@ -0,0 +1,115 @@
+package colly
+
+import (
+	"colly/storage/mem"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestSampler_redact(t *testing.T) {
+	s, _ := NewSampler(mem.NewCacheStorage(), 1)
+
+	tests := []struct {
+		name string
+		dump string
+		want string
+	}{
+		{
+			name: "request",
+			dump: "GET / HTTP/1.1\r\nHost: example.com\r\nAuthorization: Basic dXNlcjpwYXNz\r\ncookie: a=1\r\n\r\nAuthorization: body",
+			want: "GET / HTTP/1.1\r\nHost: example.com\r\nAuthorization: [REDACTED]\r\ncookie: [REDACTED]\r\n\r\nAuthorization: body",
+		},
+		{
+			name: "response",
+			dump: "HTTP/1.1 200 OK\r\nSet-Cookie: sid=secret\r\nContent-Type: text/html\r\n\r\n",
+			want: "HTTP/1.1 200 OK\r\nSet-Cookie: [REDACTED]\r\nContent-Type: text/html\r\n\r\n",
+		},
+		{
+			name: "no secrets",
+			dump: "HTTP/1.1 204 No Content\r\n\r\n",
+			want: "HTTP/1.1 204 No Content\r\n\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(s.redact([]byte(tt.dump))); got != tt.want {
+				t.Errorf("Sampler.redact() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestSampler_sample(t *testing.T) {
+	req := &Request{}
+
+	var nilSampler *Sampler
+	if nilSampler.sample(req) {
+		t.Errorf("nil Sampler.sample() = true, want false")
+	}
+
+	if _, err := NewSampler(mem.NewCacheStorage(), 1.5); err == nil {
+		t.Errorf("NewSampler() error = nil, want error")
+	}
+
+	s, _ := NewSampler(mem.NewCacheStorage(), 1)
+	if !s.sample(req) {
+		t.Errorf("Sampler.sample() = false, want true")
+	}
+
+	s.Disable()
+	if s.sample(req) {
+		t.Errorf("disabled Sampler.sample() = true, want false")
+	}
+
+	s.Enable()
+	_ = s.SetRate(0)
+	if s.sample(req) {
+		t.Errorf("Sampler.sample() with zero rate = true, want false")
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestSampler_capture(t *testing.T) {
+	stg := mem.NewCacheStorage()
+	s, _ := NewSampler(stg, 1)
+
+	httpReq, _ := http.NewRequest("POST", "http://example.com/login", strings.NewReader("user=a"))
+	httpReq.Header.Set("Authorization", "Bearer secret")
+
+	reqDump, err := s.dumpRequest(httpReq)
+	if err != nil {
+		t.Fatalf("Sampler.dumpRequest() error = %v", err)
+	}
+
+	// The request body must remain readable after dumping
+	if body, _ := io.ReadAll(httpReq.Body); string(body) != "user=a" {
+		t.Errorf("request body after dump = %q, want %q", body, "user=a")
+	}
+
+	resp := &Response{
+		Resp: &http.Response{
+			StatusCode: 200,
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Set-Cookie": {"sid=secret"}},
+			Body:       http.NoBody,
+		},
+		Body: []byte("welcome"),
+	}
+
+	if err := s.capture(&Request{ID: 7}, reqDump, resp); err != nil {
+		t.Fatalf("Sampler.capture() error = %v", err)
+	}
+
+	if n, _ := stg.Len(); n != 1 {
+		t.Fatalf("storage length = %d, want 1", n)
+	}
+}
@@ -0,0 +1,230 @@
+package colly
+
+import (
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// ScheduledJob is a job with an earliest execution time.
+type ScheduledJob interface {
+	Due() time.Time // Due returns the earliest execution time of the job.
+}
+
+// timerWheel is a hashed timer wheel that holds the scheduled jobs until they are due.
+// Every slot covers a tick, the jobs scheduled further than a full turn wait for more rounds.
+type timerWheel struct {
+	tick    time.Duration
+	slots   [][]*wheelItem
+	pos     int
+	count   int
+	running bool
+	fire    func(ScheduledJob)
+	lock    *sync.Mutex
+}
+
+// wheelItem is a scheduled job in a timer wheel slot.
+type wheelItem struct {
+	job    ScheduledJob
+	rounds int
+}
+
+// ------------------------------------------------------------------------
+
+const (
+	defWheelTick  = 100 * time.Millisecond // the default resolution of the timer wheel
+	defWheelSlots = 512                    // the default number of the timer wheel slots
+)
+
+// ------------------------------------------------------------------------
+
+// newTimerWheel returns a pointer to a newly created timer wheel.
+// The fire function is called in a new goroutine for every due job,
+// so a slow job doesn't hold back the others and the ticks of the wheel.
+func newTimerWheel(tick time.Duration, slots int, fire func(ScheduledJob)) *timerWheel {
+	if tick <= 0 {
+		tick = defWheelTick
+	}
+
+	if slots <= 0 {
+		slots = defWheelSlots
+	}
+
+	return &timerWheel{
+		tick:  tick,
+		slots: make([][]*wheelItem, slots),
+		fire:  fire,
+		lock:  &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Add schedules the job. The wheel goroutine is started if it is not running.
+func (w *timerWheel) Add(job ScheduledJob) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.add(job)
+
+	if !w.running {
+		w.running = true
+		go w.run()
+	}
+}
+
+// Len returns the number of the jobs waiting in the wheel.
+func (w *timerWheel) Len() int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.count
+}
+
+// ------------------------------------------------------------------------
+
+// add puts the job in the slot of its due time. The caller must hold the lock.
+func (w *timerWheel) add(job ScheduledJob) {
+	ticks := int((time.Until(job.Due()) + w.tick - 1) / w.tick)
+	if ticks < 1 {
+		ticks = 1
+	}
+
+	n := len(w.slots)
+	slot := (w.pos + ticks) % n
+	w.slots[slot] = append(w.slots[slot], &wheelItem{
+		job:    job,
+		rounds: (ticks - 1) / n,
+	})
+	w.count++
+}
+
+// run advances the wheel every tick until no jobs are left.
+func (w *timerWheel) run() {
+	ticker := time.NewTicker(w.tick)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		due, done := w.advance()
+
+		for _, job := range due {
+			go w.fire(job)
+		}
+
+		if done {
+			return
+		}
+	}
+}
+
+// advance moves the wheel to the next slot and returns the jobs that are due.
+// It returns true if the wheel is empty and the goroutine has to stop.
+func (w *timerWheel) advance() ([]ScheduledJob, bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.pos = (w.pos + 1) % len(w.slots)
+	items := w.slots[w.pos]
+	w.slots[w.pos] = nil
+
+	var due []ScheduledJob
+	for _, item := range items {
+		if item.rounds > 0 {
+			item.rounds--
+			w.slots[w.pos] = append(w.slots[w.pos], item)
+			continue
+		}
+
+		w.count--
+		if time.Now().Before(item.job.Due()) {
+			// The ticker fell behind the clock, keep the job until it is really due
+			w.add(item.job)
+			continue
+		}
+		due = append(due, item.job)
+	}
+
+	if w.count == 0 {
+		w.running = false
+	}
+
+	return due, !w.running
+}
+
+// ------------------------------------------------------------------------
+
+// Due returns the earliest execution time of the request.
+func (r *Request) Due() time.Time {
+	return r.NotBefore
+}
+
+// VisitAfter creates a request like Visit, but it is dispatched only after the given delay.
+// The request is held by the scheduler of the collector until it is due.
+func (r *Request) VisitAfter(URL string, d time.Duration) error {
+	URL = r.AbsoluteURL(URL)
+	if URL == "" {
+		return ErrMissingURL
+	}
+
+	req, err := r.Clone("GET", URL, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Depth = r.Depth + 1
+	req.NotBefore = time.Now().Add(d)
+
+	return r.collector.Schedule(req)
+}
+
+// ------------------------------------------------------------------------
+
+// Schedule holds the request until its NotBefore time, then submits it.
+// The request is submitted immediately if it is already due.
+// The scheduled requests are waited for by Wait.
+func (c *Collector) Schedule(r *Request) error {
+	if r == nil || r.Req == nil {
+		return ErrNoHTTPRequest
+	}
+
+	if r.collector == nil {
+		r.collector = c
+	}
+
+	if !time.Now().Before(r.NotBefore) {
+		return r.Do()
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_DEBUG_LEVEL, "schedule", r.ID, map[string]string{
+			"url": r.Req.URL.String(),
+			"due": r.NotBefore.Format(time.RFC3339),
+		})
+	}
+
+	c.wg.Add(1)
+	c.scheduler.Add(r)
+
+	return nil
+}
+
+// ScheduledCount returns the number of the requests waiting for their execution time.
+func (c *Collector) ScheduledCount() int {
+	return c.scheduler.Len()
+}
+
+// fireScheduled submits a due request of the scheduler, it runs in its own goroutine.
+func (c *Collector) fireScheduled(job ScheduledJob) {
+	defer c.wg.Done()
+
+	r, ok := job.(*Request)
+	if !ok {
+		return
+	}
+
+	// Scheduled requests are re-checks, so they are not blocked by the visited state
+	if err := r.Retry(); err != nil {
+		c.Config.logError(LOG_WARN_LEVEL, err)
+	}
+}
@@ -0,0 +1,114 @@
+package colly
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+type testJob struct {
+	id  int
+	due time.Time
+}
+
+func (j *testJob) Due() time.Time {
+	return j.due
+}
+
+// ------------------------------------------------------------------------
+
+func TestTimerWheel(t *testing.T) {
+	var (
+		lock  sync.Mutex
+		fired = map[int]time.Time{}
+		done  = make(chan struct{}, 10)
+	)
+
+	// Small wheel to exercise the multi-round items
+	w := newTimerWheel(5*time.Millisecond, 4, func(job ScheduledJob) {
+		lock.Lock()
+		fired[job.(*testJob).id] = time.Now()
+		lock.Unlock()
+		done <- struct{}{}
+	})
+
+	start := time.Now()
+	jobs := []*testJob{
+		{id: 1, due: start.Add(-time.Second)},
+		{id: 2, due: start.Add(12 * time.Millisecond)},
+		{id: 3, due: start.Add(60 * time.Millisecond)},
+		{id: 4, due: start.Add(20 * time.Millisecond)},
+	}
+	for _, job := range jobs {
+		w.Add(job)
+	}
+
+	if got := w.Len(); got != len(jobs) {
+		t.Errorf("Len() = %d, want %d", got, len(jobs))
+	}
+
+	for range jobs {
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("scheduled jobs were not fired in time")
+		}
+	}
+
+	lock.Lock()
+	for _, job := range jobs {
+		at, ok := fired[job.id]
+		if !ok {
+			t.Errorf("job %d was not fired", job.id)
+			continue
+		}
+		if at.Before(job.due) {
+			t.Errorf("job %d fired %s before it was due", job.id, job.due.Sub(at))
+		}
+	}
+	lock.Unlock()
+
+	if got := w.Len(); got != 0 {
+		t.Errorf("Len() after firing = %d, want 0", got)
+	}
+
+	// The wheel restarts after it stopped for being empty
+	time.Sleep(20 * time.Millisecond)
+	w.Add(&testJob{id: 5, due: time.Now().Add(5 * time.Millisecond)})
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job added to a stopped wheel was not fired")
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestTimerWheel_slowJob(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan int, 2)
+
+	w := newTimerWheel(5*time.Millisecond, 4, func(job ScheduledJob) {
+		if job.(*testJob).id == 1 {
+			<-release
+		}
+		done <- job.(*testJob).id
+	})
+	defer close(release)
+
+	now := time.Now()
+	w.Add(&testJob{id: 1, due: now})
+	w.Add(&testJob{id: 2, due: now.Add(20 * time.Millisecond)})
+
+	// The blocked job doesn't stall the jobs due later
+	select {
+	case id := <-done:
+		if id != 2 {
+			t.Errorf("fired job = %d, want 2", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("a slow job stalled the wheel")
+	}
+}
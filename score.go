@@ -0,0 +1,72 @@
+package colly
+
+import (
+	"io"
+)
+
+// ------------------------------------------------------------------------
+
+// PriorityJob is a job with a queue priority.
+type PriorityJob interface {
+	Job
+	JobPriority() float64 // JobPriority returns the priority of the job, higher priority jobs are processed first.
+}
+
+// PriorityQueue is a queue storage that orders the items by priority.
+type PriorityQueue interface {
+	Queue
+	PushPriority(uint32, float64, io.Reader) error // PushPriority adds a value to a dispatch queue with the given priority.
+}
+
+// PriorityCallback returns the priority of a request created from a scored parent request.
+// It is called with the parent request, whose Score was set by a response callback.
+type PriorityCallback func(parent *Request, child *Request) float64
+
+// ------------------------------------------------------------------------
+
+// InheritScore is the default PriorityCallback. The child request gets the score of the parent
+// as priority, or the priority of the parent if the parent response was not scored.
+func InheritScore(parent *Request, _ *Request) float64 {
+	if parent.scored {
+		return parent.Score
+	}
+
+	return parent.Priority
+}
+
+// DecayScore returns a PriorityCallback that propagates the score of the parent multiplied
+// by the decay factor, so the priority of unscored branches fades over the generations.
+func DecayScore(decay float64) PriorityCallback {
+	return func(parent *Request, _ *Request) float64 {
+		if parent.scored {
+			return parent.Score
+		}
+
+		return parent.Priority * decay
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// SetScore assigns a score to the response, such as the relevance of the page content.
+// The score is fed into the priority of the requests created from the response, see PriorityCallback.
+func (r *Response) SetScore(score float64) {
+	r.Request.Score = score
+	r.Request.scored = true
+}
+
+// JobPriority returns the priority of the request in a priority queue.
+func (r *Request) JobPriority() float64 {
+	return r.Priority
+}
+
+// ------------------------------------------------------------------------
+
+// childPriority returns the priority of a request created from the parent request.
+func (c *Collector) childPriority(parent *Request, child *Request) float64 {
+	if c.Config != nil && c.Config.PriorityCallback != nil {
+		return c.Config.PriorityCallback(parent, child)
+	}
+
+	return InheritScore(parent, child)
+}
@@ -0,0 +1,33 @@
+package colly
+
+import (
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestPriorityCallbacks(t *testing.T) {
+	scored := &Request{Priority: 2}
+	(&Response{Request: scored}).SetScore(8)
+
+	unscored := &Request{Priority: 2}
+
+	tests := []struct {
+		name   string
+		fn     PriorityCallback
+		parent *Request
+		want   float64
+	}{
+		{"inherit scored", InheritScore, scored, 8},
+		{"inherit unscored", InheritScore, unscored, 2},
+		{"decay scored", DecayScore(0.5), scored, 8},
+		{"decay unscored", DecayScore(0.5), unscored, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.parent, &Request{}); got != tt.want {
+				t.Errorf("PriorityCallback() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
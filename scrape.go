@@ -0,0 +1,443 @@
+package colly
+
+import (
+	"bytes"
+	"colly/filters"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ------------------------------------------------------------------------
+
+// Visit starts the collecting job by creating a GET request to the URL.
+// If CheckHead is set, a HEAD request is sent first and the GET request is
+// only made if the HEAD request succeeded.
+// Visit also calls the previously provided callbacks.
+func (c *Collector) Visit(URL string) error {
+	return c.visit(URL, nil)
+}
+
+// ------------------------------------------------------------------------
+
+// VisitContext is like Visit, but the request and its descendant requests are bound to the context.
+// When the context is done, the waiting and in-flight requests are cancelled and the
+// error of the context, e.g. context.Canceled, is passed to the OnError callbacks.
+func (c *Collector) VisitContext(ctx context.Context, URL string) error {
+	return c.visit(URL, &ctx)
+}
+
+// visit creates the GET request of the URL, preceded by a HEAD request if CheckHead is set.
+// The GET request of an asynchronous collector is chained to the completion of the HEAD request.
+func (c *Collector) visit(URL string, ctx *context.Context) error {
+	_, err := c.checkedVisit(URL, ctx)
+
+	return err
+}
+
+// checkedVisit is visit that also returns true if the request passed the checks of prepare,
+// so the returned error is a fetch error.
+func (c *Collector) checkedVisit(URL string, ctx *context.Context) (bool, error) {
+	if !c.Config.CheckHead {
+		return c.dispatch(URL, "GET", 1, nil, ctx, nil, true)
+	}
+
+	r, err := c.prepare(URL, "HEAD", 1, nil, ctx, nil, true)
+	if err != nil {
+		return false, err
+	}
+
+	c.wg.Add(1)
+	if !c.Config.Async {
+		if err := c.fetch(r); err != nil {
+			return true, err
+		}
+		return c.dispatch(URL, "GET", 1, nil, ctx, nil, true)
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		if err := c.fetch(r); err == nil {
+			c.scrape(URL, "GET", 1, nil, ctx, nil, true)
+		}
+	}()
+
+	return true, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Head starts a collector job by creating a HEAD request.
+// HEAD requests are not limited by the past visits of the URL.
+func (c *Collector) Head(URL string) error {
+	return c.scrape(URL, "HEAD", 1, nil, nil, nil, false)
+}
+
+// ------------------------------------------------------------------------
+
+// Post starts a collector job by creating a POST request with form data.
+// Post also calls the previously provided callbacks.
+func (c *Collector) Post(URL string, reqData map[string]string) error {
+	return c.scrape(URL, "POST", 1, NewFormReader(reqData), nil, nil, true)
+}
+
+// ------------------------------------------------------------------------
+
+// PostRaw starts a collector job by creating a POST request with raw binary data.
+// PostRaw also calls the previously provided callbacks.
+func (c *Collector) PostRaw(URL string, reqData []byte) error {
+	return c.scrape(URL, "POST", 1, bytes.NewReader(reqData), nil, nil, true)
+}
+
+// ------------------------------------------------------------------------
+
+// PostMultipart starts a collector job by creating a Multipart POST request
+// with raw binary data. PostMultipart also calls the previously provided callbacks.
+func (c *Collector) PostMultipart(URL string, reqData map[string][]byte) error {
+	boundary := RandomString(30)
+
+	hdr := c.requestHeader(nil)
+	hdr.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+
+	return c.scrape(URL, "POST", 1, NewMultipartReader(boundary, reqData), nil, hdr, true)
+}
+
+// ------------------------------------------------------------------------
+
+// Request starts a collector job by creating a custom HTTP request
+// where method, context, headers and request data can be specified.
+// Set body, ctx, hdr parameters to nil if you don't want to use them.
+// The request is cancelled when ctx is done, see VisitContext.
+func (c *Collector) Request(method string, URL string, body io.Reader, ctx *context.Context, hdr http.Header) error {
+	return c.scrape(URL, method, 1, body, ctx, hdr, true)
+}
+
+// ------------------------------------------------------------------------
+
+// Wait returns when the collector jobs are finished, including the requests
+// made by the callbacks, the scheduled requests and the pooled parse jobs.
+func (c *Collector) Wait() {
+	c.wg.Wait()
+}
+
+// ------------------------------------------------------------------------
+
+// Clone returns a new collector with a copy of the configuration settings and no callbacks.
+// The clone shares the HTTP client, the loaded robots.txt files and the storages of the
+// configuration settings, e.g. the cache and the visit storages, with the original collector.
+func (c *Collector) Clone() *Collector {
+	config := *c.Config
+	client := c.Client()
+
+	clone := NewCollector(&config, nil)
+	clone.Ctx = c.Ctx
+	clone.store = c.store
+	clone.client = client
+	clone.robotsMap = c.robotsMap
+	clone.robotsText = c.robotsText
+	clone.lock = c.lock
+
+	return clone
+}
+
+// ------------------------------------------------------------------------
+
+// Client returns the HTTP client of the collector. The client is created from the
+// configuration settings on the first request, so the settings can be changed until then.
+func (c *Collector) Client() *Client {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.client == nil {
+		c.client = NewClient(c.Config, c.Config.ClientOptions...)
+	}
+
+	return c.client
+}
+
+// ------------------------------------------------------------------------
+
+// scrape creates a request, checks it against the depth limit, the filters and the robots.txt
+// rules, then fetches it. The asynchronous collectors fetch the requests on a new goroutine.
+// If checkRevisit is set, the GET requests are limited by the revisit filters and their visits are recorded.
+func (c *Collector) scrape(u string, method string, depth int, body io.Reader, ctx *context.Context, hdr http.Header, checkRevisit bool) error {
+	_, err := c.dispatch(u, method, depth, body, ctx, hdr, checkRevisit)
+
+	return err
+}
+
+// dispatch is scrape that also returns true if the request passed the checks of prepare,
+// so the returned error is a fetch error.
+func (c *Collector) dispatch(u string, method string, depth int, body io.Reader, ctx *context.Context, hdr http.Header, checkRevisit bool) (bool, error) {
+	r, err := c.prepare(u, method, depth, body, ctx, hdr, checkRevisit)
+	if err != nil {
+		return false, err
+	}
+
+	c.wg.Add(1)
+	if c.Config.Async {
+		go c.fetch(r)
+		return true, nil
+	}
+
+	return true, c.fetch(r)
+}
+
+// prepare creates a request and checks it against the depth limit, the filters and the robots.txt rules.
+func (c *Collector) prepare(u string, method string, depth int, body io.Reader, ctx *context.Context, hdr http.Header, checkRevisit bool) (*Request, error) {
+	r, err := c.newRequest(u, method, depth, body, ctx, hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Dry runs check the filters when the request is dispatched, see dryRunCheck
+	if !c.Config.DryRun {
+		// The parts of the ranged downloads share the URL of the resource
+		if err := c.checkRequest(r, checkRevisit && method == "GET" && r.Req.Header.Get("Range") == ""); err != nil {
+			return nil, c.handleOnRejected(r, err)
+		}
+	}
+	c.recordAlias(r.Req.URL.String(), u)
+
+	return r, nil
+}
+
+// fetch sends the request and runs the callbacks of the events in order:
+// request, response headers, error or response, HTML and XML, scraped.
+func (c *Collector) fetch(r *Request) error {
+	defer c.wg.Done()
+
+	// Requests of a cancelled crawl are not sent
+	if err := r.Req.Context().Err(); err != nil {
+		return c.handleOnError(nil, err, r)
+	}
+
+	c.handleOnRequest(r)
+	if r.abort {
+		return nil
+	}
+
+	if r.Req.Method == "POST" && r.Req.Header.Get("Content-Type") == "" {
+		r.Req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if r.Req.Header.Get("Accept") == "" {
+		r.Req.Header.Set("Accept", "*/*")
+	}
+	if r.Tracer != nil {
+		r.Req = WithTrace(r.Req, r.Tracer)
+	}
+
+	checkHdr := func(req *http.Request, statusCode int, header http.Header) bool {
+		// The links of the redirected responses are resolved against the final URL,
+		// the requested URL is kept as the storage key, see requestedURL
+		if req.URL != r.Req.URL {
+			if r.sentURL == nil {
+				r.sentURL = r.OriginalURL()
+			}
+			r.Req.URL = req.URL
+		}
+
+		c.handleOnResponseHeaders(&Response{
+			Request: r,
+			Resp: &http.Response{
+				Status:     strconv.Itoa(statusCode) + " " + http.StatusText(statusCode),
+				StatusCode: statusCode,
+				Header:     header,
+				Request:    req,
+			},
+		})
+
+		return !r.abort
+	}
+
+	resp, err := c.Client().Do(r, int(c.Config.MaxBodySize), checkHdr)
+	if herr := c.handleOnError(resp, err, r); herr != nil || err != nil {
+		return herr
+	}
+
+	if !c.handleOnResponse(resp) {
+		return nil
+	}
+
+	return c.dispatchParse(resp)
+}
+
+// ------------------------------------------------------------------------
+
+// newRequest returns a pointer to a newly created request of the collector.
+// The request inherits the context of the collector, if any, unless a context is given.
+// The HTTP request is bound to the context, so cancelling it cancels the request.
+func (c *Collector) newRequest(u string, method string, depth int, body io.Reader, ctx *context.Context, hdr http.Header) (*Request, error) {
+	parser := c.Config.Parser
+	if parser == nil {
+		parser = NewWHATWGParser()
+	}
+
+	URL, err := parser.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx == nil {
+		parent := context.Background()
+		if c.Ctx != nil {
+			parent = *c.Ctx
+		}
+		ctx = &parent
+	}
+
+	// The requests without a parent get new values, the descendants share them
+	values := valuesOf(*ctx)
+	if values == nil {
+		values = NewContext()
+		vctx := withValues(*ctx, values)
+		ctx = &vctx
+	}
+
+	req, err := http.NewRequestWithContext(*ctx, method, URL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.URL = URL
+	req.Header = c.requestHeader(hdr)
+
+	// The Host header is ignored by the HTTP client, it uses the Host field instead
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+
+	return &Request{
+		ID:        c.stats.nextRequestID(),
+		Depth:     uint16(depth),
+		Req:       req,
+		Ctx:       ctx,
+		Parser:    parser,
+		Tracer:    c.Config.Tracer,
+		Context:   values,
+		collector: c,
+	}, nil
+}
+
+// requestHeader returns the request headers, or the headers of the configuration settings if hdr is nil.
+// The user agent of the configuration settings is added if the headers don't have one.
+func (c *Collector) requestHeader(hdr http.Header) http.Header {
+	if hdr == nil {
+		hdr = http.Header{}
+		if c.Config.HeaderCallback != nil {
+			hdr = c.Config.HeaderCallback().Clone()
+		}
+	}
+
+	if _, present := hdr["User-Agent"]; !present && c.Config.UserAgentCallback != nil {
+		hdr.Set("User-Agent", c.Config.UserAgentCallback())
+	}
+
+	return hdr
+}
+
+// ------------------------------------------------------------------------
+
+// checkRequest checks the request against the depth limit, the filters and the robots.txt rules
+// of its host. If checkRevisit is set, the request is limited by the revisit filters and its visit
+// is recorded, otherwise the past visits of the URL are ignored.
+func (c *Collector) checkRequest(r *Request, checkRevisit bool) error {
+	if c.Config.MaxDepth > 0 && uint(r.Depth) > c.Config.MaxDepth {
+		return ErrMaxDepth
+	}
+
+	if c.Config.Filter != nil {
+		if err := c.Config.Filter.match(r, !checkRevisit); err != nil {
+			return err
+		}
+	}
+
+	if !c.Config.IgnoreRobotsTxt && r.Req.Method != "HEAD" {
+		if err := c.checkRobots(r); err != nil {
+			return err
+		}
+	}
+
+	if checkRevisit {
+		c.recordVisit(r)
+	}
+
+	return nil
+}
+
+// recordVisit adds the visit of the request to the visit storages of the revisit filters.
+// The shared visit registry is updated when the request is dispatched, see recordSharedVisit.
+func (c *Collector) recordVisit(r *Request) {
+	if c.Config.Filter == nil {
+		return
+	}
+
+	var shared filters.VisitStorage
+	if c.Config.VisitRegistry != nil {
+		shared = c.Config.VisitRegistry.Storage()
+	}
+
+	key := filters.VisitKey(r.Req.URL.String())
+	for _, stg := range c.Config.Filter.VisitStorages() {
+		if stg == shared {
+			continue
+		}
+		if err := stg.AddVisit(key); err != nil {
+			c.handleOnStorageError(STORAGE_VISITS, err)
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// checkRobots checks the request against the robots.txt of its host.
+// The robots.txt is fetched if it was not loaded yet.
+func (c *Collector) checkRobots(r *Request) error {
+	if !c.robotsLoaded(r.Req.URL.Host) {
+		if err := c.loadRobots(r.Req.Context(), r.Req.URL); err != nil {
+			return err
+		}
+	}
+
+	return c.robotsAllowed(r)
+}
+
+// loadRobots fetches and stores the robots.txt of the host of the URL.
+// The robots.txt is taken from the robots cache if it is there and not expired.
+func (c *Collector) loadRobots(ctx context.Context, u *url.URL) error {
+	statusCode, body, cached := c.cachedRobots(u)
+	if !cached {
+		req, err := http.NewRequestWithContext(ctx, "GET", u.Scheme+"://"+u.Host+"/robots.txt", nil)
+		if err != nil {
+			return err
+		}
+
+		if c.Config.UserAgentCallback != nil {
+			req.Header.Set("User-Agent", c.Config.UserAgentCallback())
+		}
+
+		resp, err := c.Client().Clt.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if body, err = io.ReadAll(resp.Body); err != nil {
+			return err
+		}
+		statusCode = resp.StatusCode
+
+		c.cacheRobots(u, statusCode, body)
+	}
+
+	if _, err := c.setRobots(u.Host, statusCode, body); err != nil {
+		return err
+	}
+
+	c.handleOnRobotsSitemap(u.Host, statusCode, body)
+
+	return nil
+}
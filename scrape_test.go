@@ -0,0 +1,815 @@
+package colly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func newScrapeTestServer() *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Home</title></head><body><a href="/page">Page</a></body></html>`))
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Page</title></head></html>`))
+	})
+	mux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("private"))
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(r.Method + " " + r.Header.Get("Content-Type") + " " + string(body)))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/redirected/", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/redirected/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<a href="test">test</a>`))
+	})
+	mux.HandleFunc("/base", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><base href="` + r.URL.Query().Get("href") + `"></head><body><a href="z">link</a></body></html>`))
+	})
+	mux.HandleFunc("/tabs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><base href=\"/foo\tbar/\"></head><body><a href=\"x\ny\">link</a></body></html>"))
+	})
+	mux.HandleFunc("/foobar/xy", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("xy"))
+	})
+	mux.HandleFunc("/100%25", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("100 percent"))
+	})
+	mux.HandleFunc("/set_cookie", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "test", Value: "testv"})
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/check_cookie", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("test"); err != nil || c.Value != "testv" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/headers", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host + " " + r.Header.Get("Test") + " " + r.Header.Get("User-Agent")))
+	})
+	mux.HandleFunc("/500", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("<p>error</p>"))
+	})
+	mux.HandleFunc("/xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><page><title>Test Page</title><paragraph type="description">first</paragraph><paragraph type="description">second</paragraph></page>`))
+	})
+	mux.HandleFunc("/large_binary", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		for {
+			if _, err := w.Write(make([]byte, 1024)); err != nil {
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+
+	return httptest.NewServer(mux)
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_Visit(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	c := NewCollector(nil, nil)
+
+	var events []string
+	c.OnRequest(func(r *Request) { events = append(events, "request "+r.Req.URL.Path) })
+	c.OnResponseHeaders(func(r *Response) { events = append(events, "headers "+r.Request.Req.URL.Path) })
+	c.OnResponse(func(r *Response) { events = append(events, "response "+r.Request.Req.URL.Path) })
+	c.OnHTML("a[href]", func(e *HTMLElement) {
+		events = append(events, "html "+e.Text)
+		e.Response.Request.Visit(e.Attr("href"))
+	})
+	c.OnScraped(func(r *Response) { events = append(events, "scraped "+r.Request.Req.URL.Path) })
+
+	if err := c.Visit(ts.URL + "/"); err != nil {
+		t.Fatalf("Visit() error = %v", err)
+	}
+	c.Wait()
+
+	want := []string{
+		"request /", "headers /", "response /", "html Page",
+		"request /page", "headers /page", "response /page", "scraped /page",
+		"scraped /",
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+
+	if c.RequestCount() != 2 || c.ResponseCount() != 2 {
+		t.Errorf("RequestCount() = %d, ResponseCount() = %d, want 2, 2", c.RequestCount(), c.ResponseCount())
+	}
+}
+
+func TestCollector_VisitContext(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := NewCollector(nil, nil)
+
+	var requested []string
+	var errs []error
+	var followErr error
+	c.OnRequest(func(r *Request) { requested = append(requested, r.Req.URL.Path) })
+	c.OnError(func(r *Response, err error) { errs = append(errs, err) })
+	c.OnHTML("a[href]", func(e *HTMLElement) {
+		// The descendant requests are bound to the context of the visit
+		cancel()
+		followErr = e.Response.Request.Visit(e.Attr("href"))
+	})
+
+	if err := c.VisitContext(ctx, ts.URL+"/"); err != nil {
+		t.Fatalf("VisitContext() error = %v", err)
+	}
+	c.Wait()
+
+	if !reflect.DeepEqual(requested, []string{"/"}) {
+		t.Errorf("requested = %v, want [/]", requested)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], context.Canceled) || !errors.Is(followErr, context.Canceled) {
+		t.Errorf("OnError() errors = %v, Visit() error = %v, want %v", errs, followErr, context.Canceled)
+	}
+}
+
+func TestCollector_VisitContext_cancel(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cfg := NewConfig()
+	cfg.Async = true
+	c := NewCollector(cfg, nil)
+
+	var lock sync.Mutex
+	var errs []error
+	c.OnResponse(func(r *Response) { t.Errorf("got response of %s after the cancellation", r.Request.Req.URL) })
+	c.OnError(func(r *Response, err error) {
+		lock.Lock()
+		errs = append(errs, err)
+		lock.Unlock()
+	})
+
+	start := time.Now()
+	for i := 1; i <= 3; i++ {
+		if err := c.VisitContext(ctx, fmt.Sprintf("%s/slow?i=%d", ts.URL, i)); err != nil {
+			t.Fatalf("VisitContext() error = %v", err)
+		}
+	}
+
+	// The running requests are aborted, Wait doesn't block until the responses
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	c.Wait()
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Wait() returned after %v, want the cancellation to abort the requests", elapsed)
+	}
+	if len(errs) != 3 {
+		t.Fatalf("OnError() errors = %v, want 3", errs)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("OnError() error = %v, want %v", err, context.Canceled)
+		}
+	}
+}
+
+func TestCollector_Visit_checks(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	cfg := NewConfig()
+	cfg.Cache = nil
+	cfg.IgnoreRobotsTxt = false
+	cfg.MaxDepth = 1
+	if err := cfg.SetMaxRevisits(0); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCollector(cfg, nil)
+
+	var errs []error
+	c.OnError(func(r *Response, err error) { errs = append(errs, err) })
+
+	if err := c.Visit(ts.URL + "/page"); err != nil {
+		t.Fatalf("Visit() error = %v", err)
+	}
+	if err := c.Visit(ts.URL + "/page"); !errors.Is(err, ErrFilterNoRevisit) {
+		t.Errorf("Visit() revisit error = %v, want %v", err, ErrFilterNoRevisit)
+	}
+	if err := c.Head(ts.URL + "/page"); err != nil {
+		t.Errorf("Head() error = %v, HEAD requests are not limited by the past visits", err)
+	}
+	if err := c.Visit(ts.URL + "/private"); !errors.Is(err, ErrRobotsTxtBlocked) {
+		t.Errorf("Visit() robots.txt error = %v, want %v", err, ErrRobotsTxtBlocked)
+	}
+	if err := c.scrape(ts.URL+"/", "GET", 2, nil, nil, nil, true); !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("scrape() depth error = %v, want %v", err, ErrMaxDepth)
+	}
+
+	if err := c.Visit(ts.URL + "/missing"); err == nil {
+		t.Error("Visit() returned no error for a missing page")
+	}
+	// The rejections run the error callbacks too
+	want := []error{ErrFilterNoRevisit, ErrRobotsTxtBlocked, ErrMaxDepth, nil}
+	if len(errs) != len(want) {
+		t.Fatalf("OnError called %d times, want %d", len(errs), len(want))
+	}
+	for i, err := range want {
+		if err != nil && !errors.Is(errs[i], err) {
+			t.Errorf("OnError() error #%d = %v, want %v", i, errs[i], err)
+		}
+	}
+	if got := c.Stats().Rejected; got != 3 {
+		t.Errorf("Stats().Rejected = %d, want 3", got)
+	}
+
+	if err := cfg.SetAllowedDomains([]string{"example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Visit(ts.URL + "/"); err == nil {
+		t.Error("Visit() returned no error for a filtered domain")
+	}
+}
+
+func TestCollector_Visit_checkHead(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	for _, async := range []bool{false, true} {
+		cfg := NewConfig()
+		cfg.CheckHead = true
+		cfg.Async = async
+
+		c := NewCollector(cfg, nil)
+
+		var lock sync.Mutex
+		var requests []string
+		c.OnRequest(func(r *Request) {
+			lock.Lock()
+			requests = append(requests, r.Req.Method+" "+r.Req.URL.Path)
+			lock.Unlock()
+		})
+
+		// The GET request is not sent if the HEAD request failed
+		c.Visit(ts.URL + "/missing")
+		c.Wait()
+		if err := c.Visit(ts.URL + "/page"); err != nil {
+			t.Fatalf("Visit() error = %v", err)
+		}
+		c.Wait()
+
+		want := []string{"HEAD /missing", "HEAD /page", "GET /page"}
+		if !reflect.DeepEqual(requests, want) {
+			t.Errorf("async %v: requests = %v, want %v", async, requests, want)
+		}
+	}
+}
+
+func TestCollector_Post(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	c := NewCollector(nil, nil)
+
+	var bodies []string
+	c.OnResponse(func(r *Response) { bodies = append(bodies, string(r.Body)) })
+
+	if err := c.Post(ts.URL+"/echo", map[string]string{"name": "colly"}); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	if err := c.PostRaw(ts.URL+"/echo", []byte("raw")); err != nil {
+		t.Fatalf("PostRaw() error = %v", err)
+	}
+
+	want := []string{
+		"POST application/x-www-form-urlencoded name=colly",
+		"POST application/x-www-form-urlencoded raw",
+	}
+	if !reflect.DeepEqual(bodies, want) {
+		t.Errorf("bodies = %q, want %q", bodies, want)
+	}
+}
+
+func TestCollector_Visit_cache(t *testing.T) {
+	var hits int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/target", http.StatusFound)
+			return
+		}
+		hits++
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("cached"))
+	}))
+	defer ts.Close()
+
+	// The redirected responses are stored under the requested URL
+	for _, path := range []string{"/", "/redirect"} {
+		hits = 0
+		c := NewCollector(nil, nil)
+
+		var fromCache []bool
+		c.OnResponse(func(r *Response) { fromCache = append(fromCache, r.FromCache) })
+
+		for i := 0; i < 2; i++ {
+			if err := c.Visit(ts.URL + path); err != nil {
+				t.Fatalf("Visit(%s) error = %v", path, err)
+			}
+		}
+
+		if hits != 1 || !reflect.DeepEqual(fromCache, []bool{false, true}) {
+			t.Errorf("%s: server hits = %d, from cache = %v, want 1, [false true]", path, hits, fromCache)
+		}
+	}
+}
+
+func TestCollector_Clone(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	cfg := NewConfig()
+	cfg.Async = true
+
+	c := NewCollector(cfg, nil)
+	c.OnResponse(func(r *Response) { t.Error("callbacks of the original collector were called by the clone") })
+
+	clone := c.Clone()
+	clone.Config.MaxDepth = 1
+	if c.Config.MaxDepth != 0 {
+		t.Error("Clone() shares the configuration settings")
+	}
+	if clone.Client() != c.Client() {
+		t.Error("Clone() doesn't share the HTTP client")
+	}
+
+	var lock sync.Mutex
+	var paths []string
+	clone.OnResponse(func(r *Response) {
+		lock.Lock()
+		paths = append(paths, r.Request.Req.URL.Path)
+		lock.Unlock()
+	})
+
+	for _, path := range []string{"/", "/page"} {
+		if err := clone.Visit(ts.URL + path); err != nil {
+			t.Fatalf("Visit() error = %v", err)
+		}
+	}
+	clone.Wait()
+
+	if len(paths) != 2 {
+		t.Errorf("asynchronous clone got responses of %v, want 2", paths)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_Visit_redirect(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	cfg := NewConfig()
+	cfg.Filter = NewFilter()
+	if err := cfg.Filter.AddURLGlob(FILTER_METHOD_EXCLUDE, []string{ts.URL + "/redirected/test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCollector(cfg, nil)
+
+	var paths []string
+	var followErr error
+	c.OnResponseHeaders(func(r *Response) { paths = append(paths, r.Request.Req.URL.Path) })
+	c.OnResponse(func(r *Response) { paths = append(paths, r.Request.Req.URL.Path) })
+	c.OnHTML("a[href]", func(e *HTMLElement) {
+		// The links are resolved against the final URL
+		u := e.Response.Request.AbsoluteURL(e.Attr("href"))
+		if u != ts.URL+"/redirected/test" {
+			t.Errorf("AbsoluteURL() = %s, want %s", u, ts.URL+"/redirected/test")
+		}
+		followErr = e.Response.Request.Visit(u)
+	})
+
+	if err := c.Visit(ts.URL + "/redirect"); err != nil {
+		t.Fatalf("Visit() error = %v", err)
+	}
+
+	if want := []string{"/redirected/", "/redirected/"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	if !errors.Is(followErr, ErrFilterURLDisallowed) {
+		t.Errorf("Visit() of a disallowed URL error = %v, want %v", followErr, ErrFilterURLDisallowed)
+	}
+}
+
+func TestCollector_Visit_baseTag(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	tests := []struct {
+		href string
+		want string
+	}{
+		{"http://xy.com/", "http://xy.com/z"},
+		{"/foobar/", ts.URL + "/foobar/z"},
+	}
+
+	for _, tt := range tests {
+		c := NewCollector(nil, nil)
+
+		var got []string
+		c.OnHTML("a[href]", func(e *HTMLElement) { got = append(got, e.Response.Request.AbsoluteURL(e.Attr("href"))) })
+		c.OnXML("//a", func(e *XMLElement) { got = append(got, e.Response.Request.AbsoluteURL(e.Attr("href"))) })
+
+		if err := c.Visit(ts.URL + "/base?href=" + url.QueryEscape(tt.href)); err != nil {
+			t.Fatalf("Visit() error = %v", err)
+		}
+
+		if want := []string{tt.want, tt.want}; !reflect.DeepEqual(got, want) {
+			t.Errorf("base %s: AbsoluteURL() = %v, want %v", tt.href, got, want)
+		}
+	}
+}
+
+func TestCollector_Visit_URLs(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	c := NewCollector(nil, nil)
+
+	var visited []string
+	c.OnResponse(func(r *Response) { visited = append(visited, r.Request.Req.URL.RequestURI()) })
+	c.OnHTML("a[href]", func(e *HTMLElement) {
+		if e.Response.Request.Req.URL.Path != "/tabs" {
+			return
+		}
+		if err := e.Response.Request.Visit(e.Attr("href")); err != nil {
+			t.Errorf("Visit() error = %v", err)
+		}
+	})
+
+	// The tabs and newlines are removed, the lone percent signs are escaped in the path only
+	for _, path := range []string{"/tabs", "/100%", "/?a=100%zz"} {
+		if err := c.Visit(ts.URL + path); err != nil {
+			t.Errorf("Visit(%s) error = %v", path, err)
+		}
+	}
+
+	if want := []string{"/tabs", "/foobar/xy", "/100%25", "/?a=100%zz"}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("visited = %v, want %v", visited, want)
+	}
+}
+
+func TestCollector_Visit_cookies(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	c := NewCollector(nil, nil)
+
+	if err := c.Visit(ts.URL + "/set_cookie"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Visit(ts.URL + "/check_cookie"); err != nil {
+		t.Errorf("Visit() with the cookie error = %v", err)
+	}
+}
+
+func TestCollector_Visit_robots(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	closed := newScrapeTestServer()
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		ignore  bool
+		wantErr error
+	}{
+		{"allowed", ts.URL + "/page", false, nil},
+		{"disallowed", ts.URL + "/private", false, ErrRobotsTxtBlocked},
+		{"disallowed with query", ts.URL + "/private?q=1", false, ErrRobotsTxtBlocked},
+		{"ignored", ts.URL + "/private", true, nil},
+		{"unreachable robots.txt", closed.URL + "/page", false, errors.New("connection error")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.IgnoreRobotsTxt = tt.ignore
+
+			c := NewCollector(cfg, nil)
+
+			responses := 0
+			c.OnResponse(func(r *Response) { responses++ })
+
+			err := c.Visit(tt.url)
+			switch {
+			case tt.wantErr == nil && (err != nil || responses != 1):
+				t.Errorf("Visit() error = %v, responses = %d, want 1 response", err, responses)
+			case tt.wantErr != nil && (err == nil || responses != 0):
+				t.Errorf("Visit() error = %v, responses = %d, want an error", err, responses)
+			case tt.wantErr == ErrRobotsTxtBlocked && !errors.Is(err, ErrRobotsTxtBlocked):
+				t.Errorf("Visit() error = %v, want %v", err, ErrRobotsTxtBlocked)
+			}
+		})
+	}
+}
+
+func TestCollector_Visit_domains(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	host := strings.TrimPrefix(ts.URL, "http://")
+	host = host[:strings.LastIndex(host, ":")]
+
+	allowed := NewConfig()
+	if err := allowed.SetAllowedDomains([]string{host}); err != nil {
+		t.Fatal(err)
+	}
+	disallowed := NewConfig()
+	if err := disallowed.SetDisallowedDomains([]string{host}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCollector(allowed, nil)
+	if err := c.Visit(ts.URL + "/"); err != nil {
+		t.Errorf("Visit() of an allowed domain error = %v", err)
+	}
+	if err := c.Visit("http://example.com/"); !errors.Is(err, ErrFilterNoMatch) {
+		t.Errorf("Visit() of another domain error = %v, want %v", err, ErrFilterNoMatch)
+	}
+
+	// The rejected URLs are not recorded as visited
+	c = NewCollector(disallowed, nil)
+	for i := 0; i < 2; i++ {
+		if err := c.Visit(ts.URL + "/"); !errors.Is(err, ErrFilterDomainDisallowed) {
+			t.Errorf("Visit() of a disallowed domain error = %v, want %v", err, ErrFilterDomainDisallowed)
+		}
+	}
+}
+
+func TestCollector_Visit_revisit(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	for _, limited := range []bool{false, true} {
+		cfg := NewConfig()
+		cfg.Cache = nil
+		if limited {
+			if err := cfg.SetMaxRevisits(0); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		c := NewCollector(cfg, nil)
+
+		requests := 0
+		c.OnRequest(func(r *Request) { requests++ })
+
+		for i := 0; i < 2; i++ {
+			c.Visit(ts.URL + "/page")
+			c.Post(ts.URL+"/echo", map[string]string{"name": "colly"})
+		}
+
+		// The POST requests are not limited by the past visits
+		want := 4
+		if limited {
+			want = 3
+		}
+		if requests != want {
+			t.Errorf("limited %v: requests = %d, want %d", limited, requests, want)
+		}
+	}
+}
+
+func TestCollector_Visit_headers(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	tests := []struct {
+		name string
+		ua   string
+		hdr  http.Header
+		want string
+	}{
+		{"default", "", nil, " colly v3"},
+		{"user agent", "Example/1.0", nil, " Example/1.0"},
+		{"empty header", "Example/1.0", http.Header{}, " Example/1.0"},
+		{"blank user agent", "Example/1.0", http.Header{"User-Agent": {""}}, " "},
+		{"header user agent", "Example/1.0", http.Header{"User-Agent": {"Example/2.0"}}, " Example/2.0"},
+		{"custom headers", "", http.Header{"Host": {"example.com"}, "Test": {"Testing"}}, "Testing colly v3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			if tt.ua != "" {
+				cfg.SetUserAgent(tt.ua)
+			}
+
+			c := NewCollector(cfg, nil)
+
+			var got string
+			c.OnResponse(func(r *Response) { got = string(r.Body) })
+
+			if err := c.Request("GET", ts.URL+"/headers", nil, nil, tt.hdr); err != nil {
+				t.Fatal(err)
+			}
+
+			host := strings.TrimPrefix(ts.URL, "http://")
+			if tt.hdr.Get("Host") != "" {
+				host = tt.hdr.Get("Host")
+			}
+			if want := host + " " + tt.want; got != want {
+				t.Errorf("headers = %q, want %q", got, want)
+			}
+		})
+	}
+
+	// The headers of the configuration settings are sent with the visits
+	cfg := NewConfig()
+	cfg.SetCustomHeaders(map[string]string{"Test": "Config"})
+
+	c := NewCollector(cfg, nil)
+
+	var got string
+	c.OnResponse(func(r *Response) { got = string(r.Body) })
+	c.Visit(ts.URL + "/headers")
+
+	if !strings.Contains(got, " Config ") {
+		t.Errorf("headers = %q, want the Test header of the configuration settings", got)
+	}
+}
+
+func TestCollector_Visit_parseStatus(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	c := NewCollector(nil, nil)
+
+	parsed := 0
+	c.OnHTML("p", func(e *HTMLElement) { parsed++ })
+
+	c.Visit(ts.URL + "/500")
+	if parsed != 0 {
+		t.Error("error response parsed by default")
+	}
+
+	c.Config.ParseAllResponses()
+	if err := c.Visit(ts.URL + "/500"); err != nil {
+		t.Errorf("Visit() of a parsed error response error = %v", err)
+	}
+	if parsed != 1 {
+		t.Error("error response not parsed with ParseAllResponses")
+	}
+}
+
+func TestCollector_OnXML(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	tests := []struct {
+		path  string
+		query string
+		want  []string
+	}{
+		{"/", "/html/head/title", []string{"Home"}},
+		{"/xml", "//page/paragraph[@type='description']", []string{"first", "second"}},
+	}
+
+	for _, tt := range tests {
+		c := NewCollector(nil, nil)
+
+		var got []string
+		c.OnXML(tt.query, func(e *XMLElement) { got = append(got, e.Text) })
+
+		if err := c.Visit(ts.URL + tt.path); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("OnXML(%s) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestCollector_OnResponseHeaders_abort(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	c := NewCollector(nil, nil)
+
+	headers := false
+	c.OnResponseHeaders(func(r *Response) {
+		headers = true
+		if r.Resp.Header.Get("Content-Type") == "application/octet-stream" {
+			r.Request.Abort()
+		}
+	})
+	c.OnResponse(func(r *Response) { t.Error("OnResponse called after Abort") })
+
+	c.Visit(ts.URL + "/large_binary")
+
+	if !headers {
+		t.Error("OnResponseHeaders not called")
+	}
+}
+
+func TestCollector_Visit_depth(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	cfg := NewConfig()
+	cfg.Cache = nil
+	cfg.MaxDepth = 2
+
+	c := NewCollector(cfg, nil)
+
+	// The visits of the collector start at depth 1, the visits of the requests increase it
+	responses := 0
+	c.OnResponse(func(r *Response) {
+		responses++
+		if responses < 10 {
+			c.Visit(ts.URL + "/page")
+		}
+	})
+	c.Visit(ts.URL + "/page")
+	if responses != 10 {
+		t.Errorf("responses = %d, want 10 without depth", responses)
+	}
+
+	clone := c.Clone()
+	responses = 0
+	clone.OnResponse(func(r *Response) {
+		responses++
+		r.Request.Visit(ts.URL + "/page")
+	})
+	clone.Visit(ts.URL + "/page")
+	if responses != 2 {
+		t.Errorf("responses = %d, want 2 with MaxDepth 2", responses)
+	}
+}
+
+func TestCollector_VisitContext_timeout(t *testing.T) {
+	ts := newScrapeTestServer()
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	c := NewCollector(nil, nil)
+
+	var errs []error
+	c.OnResponse(func(r *Response) { t.Error("OnResponse called, want OnError") })
+	c.OnError(func(r *Response, err error) { errs = append(errs, err) })
+
+	if err := c.VisitContext(ctx, ts.URL+"/slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("VisitContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], context.DeadlineExceeded) {
+		t.Errorf("OnError() errors = %v, want %v", errs, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,254 @@
+package colly
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ------------------------------------------------------------------------
+
+// SelectorMiss is the nearest-miss diagnostics of a selector matching nothing on a page.
+type SelectorMiss struct {
+	Selector      string   `json:"selector" bson:"selector"`                       // Selector is the selector matching nothing.
+	MatchedPrefix string   `json:"matched_prefix" bson:"matched_prefix,omitempty"` // MatchedPrefix is the longest leading part of a descendant selector matching the page.
+	PrefixMatches int      `json:"prefix_matches" bson:"prefix_matches,omitempty"` // PrefixMatches is the number of the elements matching the prefix.
+	Missing       []string `json:"missing" bson:"missing,omitempty"`               // Missing is the list of the tags, IDs and classes of the selector not found on the page.
+	Suggestion    string   `json:"suggestion" bson:"suggestion,omitempty"`         // Suggestion is a similar selector matching the page, e.g. with a renamed class.
+}
+
+// selectorToken is a tag, ID or class name in a selector.
+type selectorToken struct {
+	kind  byte // '.' for classes, '#' for IDs and 0 for tags
+	name  string
+	start int
+	end   int
+}
+
+// ------------------------------------------------------------------------
+
+var (
+	selectorAttrRegexp  = regexp.MustCompile(`\[[^\]]*\]`)
+	selectorTokenRegexp = regexp.MustCompile(`[.#:]?-?[_a-zA-Z][-_a-zA-Z0-9]*`)
+)
+
+// ------------------------------------------------------------------------
+
+// DiagnoseSelector returns the nearest-miss diagnostics of a selector on a document.
+// It finds the longest matching prefix of a descendant selector, the tags, IDs and classes
+// missing from the document and suggests a similar selector replacing them by their closest names.
+func DiagnoseSelector(doc *goquery.Document, selector string) *SelectorMiss {
+	miss := &SelectorMiss{Selector: selector}
+	if doc == nil {
+		return miss
+	}
+
+	miss.MatchedPrefix, miss.PrefixMatches = matchedSelectorPrefix(doc, selector)
+
+	tags, ids, classes := documentNames(doc)
+	tokens := selectorTokens(selector)
+	suggestion := selector
+	replaced := false
+
+	for i := len(tokens) - 1; i >= 0; i-- {
+		t := tokens[i]
+
+		names := tags
+		switch t.kind {
+		case '.':
+			names = classes
+		case '#':
+			names = ids
+		}
+
+		name := t.name
+		if t.kind == 0 {
+			name = strings.ToLower(name)
+		}
+		if _, present := names[name]; present {
+			continue
+		}
+
+		label := t.name
+		if t.kind != 0 {
+			label = string(t.kind) + t.name
+		}
+		miss.Missing = append([]string{label}, miss.Missing...)
+
+		if closest := closestName(name, names); closest != "" {
+			suggestion = suggestion[:t.start] + closest + suggestion[t.end:]
+			replaced = true
+		}
+	}
+
+	if replaced && doc.Find(suggestion).Length() > 0 {
+		miss.Suggestion = suggestion
+	}
+
+	return miss
+}
+
+// ------------------------------------------------------------------------
+
+// logSelectorMiss logs the nearest-miss diagnostics of an OnHTML selector matching nothing.
+func (c *Collector) logSelectorMiss(resp *Response, doc *goquery.Document, selector string) {
+	if !c.HasLogger() {
+		return
+	}
+
+	miss := DiagnoseSelector(doc, selector)
+	args := map[string]string{
+		"selector": selector,
+		"url":      resp.Request.Req.URL.String(),
+	}
+	if miss.MatchedPrefix != "" {
+		args["matched_prefix"] = miss.MatchedPrefix
+		args["prefix_matches"] = strconv.Itoa(miss.PrefixMatches)
+	}
+	if len(miss.Missing) > 0 {
+		args["missing"] = strings.Join(miss.Missing, " ")
+	}
+	if miss.Suggestion != "" {
+		args["suggestion"] = miss.Suggestion
+	}
+
+	c.logEvent(LOG_DEBUG_LEVEL, "selector_miss", resp.Request.ID, args)
+}
+
+// ------------------------------------------------------------------------
+
+// matchedSelectorPrefix returns the longest leading part of a descendant selector
+// matching the document and the number of the matching elements.
+func matchedSelectorPrefix(doc *goquery.Document, selector string) (string, int) {
+	if strings.ContainsAny(selector, ",[") {
+		return "", 0
+	}
+
+	fields := strings.Fields(selector)
+	for i := len(fields) - 1; i > 0; i-- {
+		if last := fields[i-1]; last == ">" || last == "+" || last == "~" {
+			continue
+		}
+
+		prefix := strings.Join(fields[:i], " ")
+		if n := doc.Find(prefix).Length(); n > 0 {
+			return prefix, n
+		}
+	}
+
+	return "", 0
+}
+
+// selectorTokens returns the tags, IDs and classes of a selector, skipping the
+// attribute selectors and the pseudo-classes.
+func selectorTokens(selector string) []selectorToken {
+	masked := selectorAttrRegexp.ReplaceAllStringFunc(selector, func(s string) string {
+		return strings.Repeat(" ", len(s))
+	})
+
+	tokens := []selectorToken{}
+	for _, loc := range selectorTokenRegexp.FindAllStringIndex(masked, -1) {
+		start, end := loc[0], loc[1]
+
+		switch masked[start] {
+		case ':':
+			continue
+		case '.', '#':
+			tokens = append(tokens, selectorToken{kind: masked[start], name: masked[start+1 : end], start: start + 1, end: end})
+		default:
+			// Tags start a compound selector, other names are pseudo-class arguments
+			if start > 0 && !strings.ContainsRune(" \t\n>+~,(", rune(masked[start-1])) {
+				continue
+			}
+			tokens = append(tokens, selectorToken{name: masked[start:end], start: start, end: end})
+		}
+	}
+
+	return tokens
+}
+
+// documentNames returns the sets of the tags, IDs and classes used in the document.
+func documentNames(doc *goquery.Document) (tags, ids, classes map[string]struct{}) {
+	tags, ids, classes = map[string]struct{}{}, map[string]struct{}{}, map[string]struct{}{}
+
+	doc.Find("*").Each(func(_ int, s *goquery.Selection) {
+		tags[goquery.NodeName(s)] = struct{}{}
+
+		if id, found := s.Attr("id"); found && id != "" {
+			ids[id] = struct{}{}
+		}
+		if class, found := s.Attr("class"); found {
+			for _, name := range strings.Fields(class) {
+				classes[name] = struct{}{}
+			}
+		}
+	})
+
+	return tags, ids, classes
+}
+
+// closestName returns the name with the smallest edit distance, if the distance
+// is at most a third of the length of the name, or a blank string if none is close enough.
+// Names containing each other, like "price" and "price-new", are considered close.
+func closestName(name string, names map[string]struct{}) string {
+	candidates := make([]string, 0, len(names))
+	for n := range names {
+		candidates = append(candidates, n)
+	}
+	sort.Strings(candidates)
+
+	maxDist := len(name) / 3
+	if maxDist < 1 {
+		maxDist = 1
+	}
+
+	closest, minDist := "", maxDist+1
+	for _, n := range candidates {
+		d := editDistance(name, n)
+		if d > maxDist && len(name) >= 3 && len(n) >= 3 && (strings.Contains(n, name) || strings.Contains(name, n)) {
+			d = maxDist
+		}
+		if d < minDist {
+			closest, minDist = n, d
+		}
+	}
+
+	return closest
+}
+
+// editDistance returns the Levenshtein distance of two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+
+	return a
+}
@@ -0,0 +1,70 @@
+package colly
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ------------------------------------------------------------------------
+
+func TestDiagnoseSelector(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(`<html><body>
+		<div id="main" class="content">
+			<ul class="product-list"><li class="product-item"><span class="price-new">1</span></li></ul>
+		</div>
+	</body></html>`))
+
+	tests := []struct {
+		name     string
+		selector string
+		want     *SelectorMiss
+	}{
+		{
+			name:     "renamed class",
+			selector: "div.content ul.product-list li.product-item span.price",
+			want: &SelectorMiss{
+				Selector:      "div.content ul.product-list li.product-item span.price",
+				MatchedPrefix: "div.content ul.product-list li.product-item",
+				PrefixMatches: 1,
+				Missing:       []string{".price"},
+				Suggestion:    "div.content ul.product-list li.product-item span.price-new",
+			},
+		},
+		{
+			name:     "renamed id and tag",
+			selector: "#mian > ul li.product-item a",
+			want: &SelectorMiss{
+				Selector: "#mian > ul li.product-item a",
+				Missing:  []string{"#mian", "a"},
+			},
+		},
+		{
+			name:     "attributes and pseudo-classes",
+			selector: "li.product-itm:nth-child(1)[data-x]",
+			want: &SelectorMiss{
+				Selector: "li.product-itm:nth-child(1)[data-x]",
+				Missing:  []string{".product-itm"},
+			},
+		},
+		{
+			name:     "typo suggestion",
+			selector: "#mainn li",
+			want: &SelectorMiss{
+				Selector:   "#mainn li",
+				Missing:    []string{"#mainn"},
+				Suggestion: "#main li",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiagnoseSelector(doc, tt.selector); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiagnoseSelector() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package colly
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// ------------------------------------------------------------------------
+
+// SelectorPlanStats is the usage of the selector plan cache, see CollectorConfig.CacheSelectorPlans.
+type SelectorPlanStats struct {
+	Hits   uint64 `json:"hits" bson:"hits"`     // Hits is the number of the selectors resolved from the cache.
+	Misses uint64 `json:"misses" bson:"misses"` // Misses is the number of the cacheable selectors matched by goquery.
+	Plans  int    `json:"plans" bson:"plans"`   // Plans is the number of the cached plans.
+}
+
+// selectorPlans caches the matches of the OnHTML selectors by the structure of the documents,
+// so the pages generated from the same template are matched once.
+type selectorPlans struct {
+	plans  map[planKey][]nodePath // matched nodes by document structure and selector
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	lock   *sync.RWMutex
+}
+
+// planKey identifies a selector on the documents of the same structure.
+type planKey struct {
+	fingerprint uint64 // structural fingerprint of the document
+	selector    string
+}
+
+// nodePath is the list of the child indexes leading from the document node to a node.
+type nodePath []int
+
+// ------------------------------------------------------------------------
+
+// SELECTOR_PLAN_MAX_ENTRIES is the maximum number of the cached selector plans.
+// The cache is cleared when it is full.
+const SELECTOR_PLAN_MAX_ENTRIES = 10000
+
+// structuralPseudoClasses are the pseudo-classes depending only on the document structure,
+// which is covered by the document fingerprint.
+var structuralPseudoClasses = map[string]bool{
+	"empty":            true,
+	"first-child":      true,
+	"first-of-type":    true,
+	"has":              true,
+	"haschild":         true,
+	"last-child":       true,
+	"last-of-type":     true,
+	"not":              true,
+	"nth-child":        true,
+	"nth-last-child":   true,
+	"nth-last-of-type": true,
+	"nth-of-type":      true,
+	"only-child":       true,
+	"only-of-type":     true,
+	"root":             true,
+}
+
+// ------------------------------------------------------------------------
+
+// newSelectorPlans returns a pointer to a newly created selector plan cache.
+func newSelectorPlans() *selectorPlans {
+	return &selectorPlans{
+		plans: map[planKey][]nodePath{},
+		lock:  &sync.RWMutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// SelectorPlanStats returns the usage of the selector plan cache.
+func (c *Collector) SelectorPlanStats() SelectorPlanStats {
+	return c.selectors.stats()
+}
+
+// ------------------------------------------------------------------------
+
+// find returns the nodes of the document matching the selector. The matches are taken from
+// the cache if the selector was matched on a document of the same structure before.
+// A zero fingerprint turns off the cache.
+func (sp *selectorPlans) find(doc *goquery.Document, fingerprint uint64, selector string) *goquery.Selection {
+	if fingerprint == 0 || len(doc.Nodes) == 0 || !cacheableSelector(selector) {
+		return doc.Find(selector)
+	}
+
+	key := planKey{fingerprint: fingerprint, selector: selector}
+
+	sp.lock.RLock()
+	paths, present := sp.plans[key]
+	sp.lock.RUnlock()
+
+	if present {
+		nodes := make([]*html.Node, 0, len(paths))
+		r := pathResolver{root: doc.Nodes[0]}
+		for _, p := range paths {
+			n := r.resolve(p)
+			if n == nil {
+				// fingerprint collision, fall back to matching
+				present = false
+				break
+			}
+			nodes = append(nodes, n)
+		}
+		if present {
+			sp.hits.Add(1)
+			// the nodes are unique and in document order, no need for FindNodes' checks
+			sel := doc.Slice(0, 0)
+			sel.Nodes = nodes
+			return sel
+		}
+	}
+
+	sp.misses.Add(1)
+	sel := doc.Find(selector)
+
+	paths = make([]nodePath, len(sel.Nodes))
+	for i, n := range sel.Nodes {
+		paths[i] = newNodePath(doc.Nodes[0], n)
+	}
+
+	sp.lock.Lock()
+	if len(sp.plans) >= SELECTOR_PLAN_MAX_ENTRIES {
+		sp.plans = map[planKey][]nodePath{}
+	}
+	sp.plans[key] = paths
+	sp.lock.Unlock()
+
+	return sel
+}
+
+// stats returns the usage of the cache.
+func (sp *selectorPlans) stats() SelectorPlanStats {
+	sp.lock.RLock()
+	plans := len(sp.plans)
+	sp.lock.RUnlock()
+
+	return SelectorPlanStats{
+		Hits:   sp.hits.Load(),
+		Misses: sp.misses.Load(),
+		Plans:  plans,
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// documentFingerprint returns the structural fingerprint of the document: a hash of the element
+// tree with the tag names, IDs and classes of the elements, and the positions of the text nodes.
+// Documents with the same fingerprint are matched the same way by the cacheable selectors.
+func documentFingerprint(root *html.Node) uint64 {
+	h := fnv.New64a()
+	buf := make([]byte, 0, 64)
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		buf = buf[:0]
+		switch n.Type {
+		case html.ElementNode:
+			buf = append(buf, '<')
+			buf = append(buf, n.Data...)
+			for _, a := range n.Attr {
+				if a.Namespace == "" && (a.Key == "id" || a.Key == "class") {
+					buf = append(buf, ' ')
+					buf = append(buf, a.Key...)
+					buf = append(buf, '=')
+					buf = append(buf, a.Val...)
+				}
+			}
+			buf = append(buf, '>')
+		case html.TextNode:
+			buf = append(buf, '#')
+		default:
+			buf = append(buf, '!')
+		}
+		h.Write(buf)
+
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			walk(ch)
+		}
+		h.Write([]byte{'/'})
+	}
+	walk(root)
+
+	if sum := h.Sum64(); sum != 0 {
+		return sum
+	}
+
+	return 1
+}
+
+// cacheableSelector tells whether the matches of the selector depend only on the document
+// structure covered by the fingerprint: the selectors with attribute conditions and with
+// the pseudo-classes checking the text or the state of the elements are not cacheable.
+func cacheableSelector(selector string) bool {
+	if strings.ContainsAny(selector, "[\"'") {
+		return false
+	}
+
+	for rest := selector; ; {
+		i := strings.IndexByte(rest, ':')
+		if i < 0 {
+			return true
+		}
+		rest = rest[i+1:]
+
+		end := 0
+		for end < len(rest) && (rest[end] == '-' || isAlpha(rest[end])) {
+			end++
+		}
+		if !structuralPseudoClasses[strings.ToLower(rest[:end])] {
+			return false
+		}
+		rest = rest[end:]
+	}
+}
+
+// isAlpha tells whether the byte is an ASCII letter.
+func isAlpha(b byte) bool {
+	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// ------------------------------------------------------------------------
+
+// newNodePath returns the path from the root to the node.
+func newNodePath(root *html.Node, n *html.Node) nodePath {
+	var p nodePath
+	for ; n != nil && n != root; n = n.Parent {
+		i := 0
+		for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+			i++
+		}
+		p = append(p, i)
+	}
+
+	for i, j := 0, len(p)-1; i < j; i, j = i+1, j-1 {
+		p[i], p[j] = p[j], p[i]
+	}
+
+	return p
+}
+
+// pathResolver resolves the paths of a document in document order, walking from
+// the nodes of the previous path instead of the root where the paths share a prefix.
+type pathResolver struct {
+	root  *html.Node
+	prev  nodePath     // previous path
+	nodes []*html.Node // nodes along the previous path
+}
+
+// resolve returns the node at the path, or nil if there is none.
+func (r *pathResolver) resolve(p nodePath) *html.Node {
+	k := 0
+	for k < len(p) && k < len(r.prev) && p[k] == r.prev[k] {
+		k++
+	}
+
+	for level := k; level < len(p); level++ {
+		var n *html.Node
+		idx := p[level]
+		switch {
+		case level == k && level < len(r.prev) && idx > r.prev[level]:
+			// a following sibling of the node of the previous path
+			n = r.nodes[level]
+			idx -= r.prev[level]
+		case level == 0:
+			n = r.root.FirstChild
+		default:
+			n = r.nodes[level-1].FirstChild
+		}
+		for ; n != nil && idx > 0; idx-- {
+			n = n.NextSibling
+		}
+		if n == nil {
+			r.prev, r.nodes = nil, r.nodes[:0]
+			return nil
+		}
+		r.nodes = append(r.nodes[:level], n)
+	}
+	r.nodes = r.nodes[:len(p)]
+	r.prev = p
+
+	if len(p) == 0 {
+		return r.root
+	}
+	return r.nodes[len(p)-1]
+}
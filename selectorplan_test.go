@@ -0,0 +1,171 @@
+package colly
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ------------------------------------------------------------------------
+
+func templatePage(title string, rows int) string {
+	var b strings.Builder
+	b.WriteString(`<html><head><title>` + title + `</title></head><body><div id="main"><ul class="items">`)
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&b, `<li class="item"><a href="/p/%s/%d">%s %d</a><span class="price">%d</span></li>`, title, i, title, i, i*10)
+	}
+	b.WriteString(`</ul></div><div class="footer"><a href="/about">About</a></div></body></html>`)
+	return b.String()
+}
+
+func mustDocument(t testing.TB, page string) *goquery.Document {
+	t.Helper()
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// ------------------------------------------------------------------------
+
+func Test_cacheableSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"a", true},
+		{"#main ul.items > li a", true},
+		{"li:first-child, li:nth-child(2n+1)", true},
+		{"li:not(.item):has(span)", true},
+		{"a[href]", false},
+		{"a:contains('About')", false},
+		{"input:checked", false},
+		{"p::first-line", false},
+	}
+
+	for _, tt := range tests {
+		if got := cacheableSelector(tt.selector); got != tt.want {
+			t.Errorf("cacheableSelector(%q) = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func Test_documentFingerprint(t *testing.T) {
+	a := documentFingerprint(mustDocument(t, templatePage("first", 3)).Nodes[0])
+	b := documentFingerprint(mustDocument(t, templatePage("second", 3)).Nodes[0])
+	c := documentFingerprint(mustDocument(t, templatePage("first", 4)).Nodes[0])
+	d := documentFingerprint(mustDocument(t, strings.Replace(templatePage("first", 3), `class="item"`, `class="ad"`, 1)).Nodes[0])
+
+	if a != b {
+		t.Error("pages of the same template have different fingerprints")
+	}
+	if a == c {
+		t.Error("pages with different number of rows have the same fingerprint")
+	}
+	if a == d {
+		t.Error("pages with different classes have the same fingerprint")
+	}
+}
+
+func Test_selectorPlans_find(t *testing.T) {
+	selectors := []string{
+		"li.item a",
+		"li:nth-child(2n) .price",
+		"#main li:last-child",
+		".footer a",
+		"a[href^='/p/']",
+		"table td",
+	}
+
+	sp := newSelectorPlans()
+	for _, title := range []string{"first", "second", "third"} {
+		doc := mustDocument(t, templatePage(title, 5))
+		fp := documentFingerprint(doc.Nodes[0])
+
+		for _, selector := range selectors {
+			want := doc.Find(selector)
+			got := sp.find(doc, fp, selector)
+
+			if got.Length() != want.Length() {
+				t.Fatalf("%s %q: got %d nodes, want %d", title, selector, got.Length(), want.Length())
+			}
+			for i := range want.Nodes {
+				if got.Nodes[i] != want.Nodes[i] {
+					t.Errorf("%s %q: node %d differs", title, selector, i)
+				}
+			}
+		}
+	}
+
+	// 5 cacheable selectors matched on the first page, resolved from the cache on the other two
+	s := sp.stats()
+	if s.Misses != 5 || s.Hits != 10 || s.Plans != 5 {
+		t.Errorf("stats = %+v, want 5 misses, 10 hits and 5 plans", s)
+	}
+
+	// a zero fingerprint turns off the cache
+	doc := mustDocument(t, templatePage("fourth", 5))
+	sp.find(doc, 0, selectors[0])
+	if s2 := sp.stats(); s2 != s {
+		t.Errorf("stats = %+v, want %+v", s2, s)
+	}
+}
+
+func Test_pathResolver(t *testing.T) {
+	doc := mustDocument(t, templatePage("page", 4))
+	root := doc.Nodes[0]
+	nodes := doc.Find("li, a, span, .footer").Nodes
+
+	// document order, reversed order and a missing node
+	r := pathResolver{root: root}
+	for _, order := range [][]int{{0, 1, 2, 3, 4, 5}, {5, 3, 1, 4, 2, 0}} {
+		for _, i := range order {
+			if got := r.resolve(newNodePath(root, nodes[i])); got != nodes[i] {
+				t.Errorf("node %d resolved to %v", i, got)
+			}
+		}
+	}
+	if got := r.resolve(nodePath{0, 1, 1, 0, 0, 99}); got != nil {
+		t.Errorf("missing node resolved to %v", got)
+	}
+	if got := r.resolve(newNodePath(root, nodes[2])); got != nodes[2] {
+		t.Errorf("node after a missing one resolved to %v", got)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func BenchmarkSelectorPlans(b *testing.B) {
+	selectors := []string{
+		"li.item a",
+		"li:nth-child(2n) .price",
+		"#main li:last-child",
+		".footer a",
+		"div > ul > li > span",
+	}
+	doc := mustDocument(b, templatePage("page", 200))
+
+	b.Run("find", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, selector := range selectors {
+				doc.Find(selector)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		sp := newSelectorPlans()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			fp := documentFingerprint(doc.Nodes[0])
+			for _, selector := range selectors {
+				sp.find(doc, fp, selector)
+			}
+		}
+	})
+}
@@ -0,0 +1,241 @@
+package colly
+
+import (
+	"colly/storage"
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// SessionAffinity tells how the requests are bound to persistent HTTP clients.
+type SessionAffinity uint8
+
+// sessionKey is the context key type of the session identity.
+type sessionKey uint8
+
+// ProxyFunc is a proxy service that selects the proxy URL of a request.
+// Session clients call it only once and keep using the same proxy.
+type ProxyFunc func(*http.Request) (*url.URL, error)
+
+// sessionClient is a session client with the time of its last use.
+type sessionClient struct {
+	clt  *http.Client
+	used atomic.Int64 // unix time of the last use in nanoseconds
+}
+
+// ------------------------------------------------------------------------
+
+const (
+	SESSION_AFFINITY_OFF      SessionAffinity = iota // All requests share the same client and connection pool.
+	SESSION_AFFINITY_DOMAIN                          // Every host has its own client, connection, cookie jar and proxy.
+	SESSION_AFFINITY_IDENTITY                        // Every identity has its own client, see Request.SetIdentity.
+)
+
+const (
+	// SessionIdentityKey is the context key for the session identity of the request.
+	SessionIdentityKey sessionKey = iota
+)
+
+// SESSION_COOKIE_PREFIX is the key prefix of the cookies of the session clients in the cookie storage.
+// The cookies of a session are keyed by the prefix, the host or identity of the session and the eTLD+1.
+const SESSION_COOKIE_PREFIX = "session" + storage.NAMESPACE_SEPARATOR
+
+// ------------------------------------------------------------------------
+
+// SetIdentity binds the request and the requests created from it to a session identity.
+// It is used by SESSION_AFFINITY_IDENTITY mode, the host is used if the identity is blank.
+func (r *Request) SetIdentity(identity string) {
+	parent := context.Background()
+	if r.Ctx != nil {
+		parent = *r.Ctx
+	}
+
+	ctx := context.WithValue(parent, SessionIdentityKey, identity)
+	r.Ctx = &ctx
+}
+
+// Identity returns the session identity of the request.
+func (r *Request) Identity() string {
+	if r.Ctx == nil {
+		return ""
+	}
+
+	identity, _ := (*r.Ctx).Value(SessionIdentityKey).(string)
+
+	return identity
+}
+
+// ------------------------------------------------------------------------
+
+// CloseSessions closes the idle connections and removes the session clients
+// and the clients of the protocol overrides.
+func (c *Client) CloseSessions() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for key, s := range c.sessions {
+		s.clt.CloseIdleConnections()
+		delete(c.sessions, key)
+	}
+	for key, clt := range c.downgraded {
+		clt.CloseIdleConnections()
+		delete(c.downgraded, key)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// session returns the HTTP client of the request by the session affinity mode.
+func (c *Client) session(req *Request, affinity SessionAffinity) *http.Client {
+	var key string
+
+	switch affinity {
+	case SESSION_AFFINITY_DOMAIN:
+		key = req.Req.URL.Host
+	case SESSION_AFFINITY_IDENTITY:
+		if key = req.Identity(); key == "" {
+			key = req.Req.URL.Host
+		}
+	default:
+		return c.Clt
+	}
+
+	now := time.Now()
+
+	c.lock.RLock()
+	s, present := c.sessions[key]
+	c.lock.RUnlock()
+
+	if present {
+		s.used.Store(now.UnixNano())
+		return s.clt
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if s, present = c.sessions[key]; !present {
+		c.evictSessions(now)
+
+		s = &sessionClient{clt: c.newSession(req, key)}
+		c.sessions[key] = s
+	}
+	s.used.Store(now.UnixNano())
+
+	return s.clt
+}
+
+// evictSessions closes and removes the session clients that were idle for longer than
+// the session idle timeout. The sessions are checked at most once per half timeout.
+// The caller must hold the lock.
+func (c *Client) evictSessions(now time.Time) {
+	if c.sessionTTL <= 0 || now.Sub(c.sessionsSwept) < c.sessionTTL/2 {
+		return
+	}
+	c.sessionsSwept = now
+
+	idle := now.Add(-c.sessionTTL).UnixNano()
+	for key, s := range c.sessions {
+		if s.used.Load() < idle {
+			s.clt.CloseIdleConnections()
+			delete(c.sessions, key)
+		}
+	}
+}
+
+// newSession returns a new HTTP client with a dedicated transport, cookie jar and proxy.
+// Custom round trippers are shared by the sessions, only the cookie jar is dedicated.
+func (c *Client) newSession(req *Request, key string) *http.Client {
+	var transport *http.Transport
+	var order []string
+	switch t := c.Clt.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	case *headerOrderTransport:
+		transport = t.base.Clone()
+		order = t.order
+	default:
+		return c.newSessionClient(t, key)
+	}
+
+	// Keep reusing a single connection with resumable TLS sessions,
+	// the shared session cache of the collector is kept if there is one
+	transport.MaxConnsPerHost = 1
+	transport.MaxIdleConnsPerHost = 1
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	if transport.TLSClientConfig.ClientSessionCache == nil {
+		transport.TLSClientConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
+
+	// Pin the proxy that was selected for the first request of the session,
+	// a rotating proxy of the transport would select a new one for every new connection
+	selectProxy := transport.Proxy
+	if proxy, ok := c.Proxy.(ProxyFunc); ok {
+		selectProxy = proxy
+	}
+	if selectProxy != nil {
+		if proxyURL, err := selectProxy(req.Req); err == nil && proxyURL != nil {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+
+	var rt http.RoundTripper = transport
+	if len(order) > 0 {
+		rt = NewHeaderOrderTransport(transport, order)
+	}
+
+	return c.newSessionClient(rt, key)
+}
+
+// newSessionClient returns a new HTTP client with the round tripper and a dedicated cookie jar.
+// The cookies are kept in the storage of the cookie jar of the collector under the session key,
+// so they are persisted, exported and purged with the other cookies. The jars without a known
+// storage are replaced by in-memory jars, their cookies are lost when the session is evicted.
+func (c *Client) newSessionClient(rt http.RoundTripper, key string) *http.Client {
+	clt := &http.Client{
+		Transport:     rt,
+		CheckRedirect: c.Clt.CheckRedirect,
+		Timeout:       c.Clt.Timeout,
+	}
+
+	switch jar := c.Clt.Jar.(type) {
+	case nil:
+	case *cookieJar:
+		clt.Jar = jar.session(key)
+	default:
+		clt.Jar, _ = NewCookieJar(nil, nil)
+	}
+
+	return clt
+}
+
+// ------------------------------------------------------------------------
+
+// session returns a jar sharing the storage, the mode and the policies of the jar,
+// which keeps the cookies of the session under its own keys.
+func (j *cookieJar) session(key string) http.CookieJar {
+	if j.storage == nil {
+		jar, _ := NewCookieJar(nil, nil)
+		return jar
+	}
+
+	return &cookieJar{
+		psList:     j.psList,
+		mode:       j.mode,
+		lock:       &sync.Mutex{},
+		storage:    j.storage,
+		prefix:     SESSION_COOKIE_PREFIX + key + storage.NAMESPACE_SEPARATOR,
+		policy:     j.policy,
+		duplicates: j.duplicates,
+	}
+}
@@ -0,0 +1,199 @@
+package colly
+
+import (
+	"colly/storage/mem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestClient_session(t *testing.T) {
+	newReq := func(rawURL string, identity string) *Request {
+		u, _ := url.Parse(rawURL)
+		r := &Request{Req: &http.Request{URL: u}}
+		if identity != "" {
+			r.SetIdentity(identity)
+		}
+		return r
+	}
+
+	c := &Client{
+		Clt:      &http.Client{},
+		lock:     &sync.RWMutex{},
+		sessions: map[string]*sessionClient{},
+	}
+
+	a1 := newReq("https://a.example.com/1", "")
+	a2 := newReq("https://a.example.com/2", "")
+	b := newReq("https://b.example.com/", "")
+
+	if c.session(a1, SESSION_AFFINITY_OFF) != c.Clt {
+		t.Errorf("session() with affinity off is not the shared client")
+	}
+
+	if c.session(a1, SESSION_AFFINITY_DOMAIN) != c.session(a2, SESSION_AFFINITY_DOMAIN) {
+		t.Errorf("session() returned different clients for the same host")
+	}
+	if c.session(a1, SESSION_AFFINITY_DOMAIN) == c.session(b, SESSION_AFFINITY_DOMAIN) {
+		t.Errorf("session() returned the same client for different hosts")
+	}
+	if c.session(a1, SESSION_AFFINITY_DOMAIN) == c.Clt {
+		t.Errorf("session() with domain affinity is the shared client")
+	}
+
+	x1 := newReq("https://a.example.com/", "x")
+	x2 := newReq("https://b.example.com/", "x")
+	y := newReq("https://a.example.com/", "y")
+
+	if c.session(x1, SESSION_AFFINITY_IDENTITY) != c.session(x2, SESSION_AFFINITY_IDENTITY) {
+		t.Errorf("session() returned different clients for the same identity")
+	}
+	if c.session(x1, SESSION_AFFINITY_IDENTITY) == c.session(y, SESSION_AFFINITY_IDENTITY) {
+		t.Errorf("session() returned the same client for different identities")
+	}
+
+	c.CloseSessions()
+	if len(c.sessions) != 0 {
+		t.Errorf("CloseSessions() left %d sessions", len(c.sessions))
+	}
+}
+
+type stubTransport struct{}
+
+func (t *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestClient_session_customTransport(t *testing.T) {
+	rt := &stubTransport{}
+	c := &Client{
+		Clt:      &http.Client{Transport: rt, Jar: &cookieJar{}},
+		lock:     &sync.RWMutex{},
+		sessions: map[string]*sessionClient{},
+	}
+
+	u, _ := url.Parse("https://a.example.com/")
+	clt := c.session(&Request{Req: &http.Request{URL: u}}, SESSION_AFFINITY_DOMAIN)
+	if clt.Transport != rt {
+		t.Errorf("session() transport = %T, want the custom round tripper", clt.Transport)
+	}
+	if clt.Jar == nil || clt.Jar == c.Clt.Jar {
+		t.Errorf("session() did not get a dedicated cookie jar")
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestClient_session_proxy(t *testing.T) {
+	// The rotating proxy of the transport selects a new proxy on every call
+	var calls int
+	rotating := func(*http.Request) (*url.URL, error) {
+		calls++
+		return url.Parse(fmt.Sprintf("http://proxy%d.example.com:3128", calls))
+	}
+
+	c := &Client{
+		Clt:      &http.Client{Transport: &http.Transport{Proxy: rotating}},
+		lock:     &sync.RWMutex{},
+		sessions: map[string]*sessionClient{},
+	}
+
+	u, _ := url.Parse("https://a.example.com/")
+	req := &http.Request{URL: u}
+	clt := c.session(&Request{Req: req}, SESSION_AFFINITY_DOMAIN)
+
+	transport := clt.Transport.(*http.Transport)
+	for i := 0; i < 3; i++ {
+		if proxyURL, _ := transport.Proxy(req); proxyURL == nil || proxyURL.Host != "proxy1.example.com:3128" {
+			t.Errorf("session proxy = %v, want the first selected proxy", proxyURL)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("rotating proxy calls = %d, want 1", calls)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestClient_session_cookieStorage(t *testing.T) {
+	stg := mem.NewCookieStorage()
+	jar, _ := NewCookieJar(stg, nil)
+	c := &Client{
+		Clt:      &http.Client{Jar: jar},
+		lock:     &sync.RWMutex{},
+		sessions: map[string]*sessionClient{},
+	}
+
+	u, _ := url.Parse("https://www.example.com/")
+	clt := c.session(&Request{Req: &http.Request{URL: u}}, SESSION_AFFINITY_DOMAIN)
+	clt.Jar.SetCookies(u, []*http.Cookie{{Name: "sid", Value: "1"}})
+
+	// The session cookies are kept apart from the cookies of the collector in the same storage
+	if n := len(jar.Cookies(u)); n != 0 {
+		t.Errorf("collector cookies = %d, want 0", n)
+	}
+
+	var keys []string
+	stg.ExportPrefix("", func(key string, data []byte) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if want := SESSION_COOKIE_PREFIX + "www.example.com|example.com"; len(keys) != 1 || keys[0] != want {
+		t.Errorf("stored keys = %q, want %q", keys, want)
+	}
+
+	// A new session of the same host finds the stored cookies
+	c.CloseSessions()
+	clt = c.session(&Request{Req: &http.Request{URL: u}}, SESSION_AFFINITY_DOMAIN)
+	if cookies := clt.Jar.Cookies(u); len(cookies) != 1 || cookies[0].Value != "1" {
+		t.Errorf("session cookies after the restart = %v", cookies)
+	}
+
+	if err := jar.(*cookieJar).RemoveDomain("example.com"); err != nil {
+		t.Fatalf("RemoveDomain() error = %v", err)
+	}
+	if cookies := clt.Jar.Cookies(u); len(cookies) != 0 {
+		t.Errorf("session cookies after RemoveDomain() = %v", cookies)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestClient_session_evict(t *testing.T) {
+	c := &Client{
+		Clt:        &http.Client{},
+		lock:       &sync.RWMutex{},
+		sessions:   map[string]*sessionClient{},
+		sessionTTL: 20 * time.Millisecond,
+	}
+
+	newReq := func(host string) *Request {
+		u, _ := url.Parse("https://" + host + "/")
+		return &Request{Req: &http.Request{URL: u}}
+	}
+
+	for i := 0; i < 3; i++ {
+		c.session(newReq(fmt.Sprintf("host%d.example.com", i)), SESSION_AFFINITY_DOMAIN)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	kept := c.session(newReq("host0.example.com"), SESSION_AFFINITY_DOMAIN)
+	c.session(newReq("new.example.com"), SESSION_AFFINITY_DOMAIN)
+
+	var hosts []string
+	for key := range c.sessions {
+		hosts = append(hosts, key)
+	}
+	if len(hosts) != 2 || c.sessions["host0.example.com"] == nil || c.sessions["new.example.com"] == nil {
+		t.Errorf("sessions after the eviction = %s, want host0 and new", strings.Join(hosts, ", "))
+	}
+	if c.session(newReq("host0.example.com"), SESSION_AFFINITY_DOMAIN) != kept {
+		t.Errorf("the used session was replaced")
+	}
+}
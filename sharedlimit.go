@@ -0,0 +1,43 @@
+package colly
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// SharedRateLimiter is a rate limit state shared by the collector instances, so the instances crawling
+// the same host keep one delay between their requests together instead of one delay each.
+// The storage/mem and storage/sqlite3 packages provide implementations for the collectors of a process
+// and of a host. A networked store, e.g. Redis, implements Reserve with an atomic script doing the same.
+type SharedRateLimiter interface {
+	Reserve(key string, interval time.Duration) (time.Duration, error) // Reserve books the next request start of the key and returns the time to wait until the start.
+}
+
+// ------------------------------------------------------------------------
+
+// acquireShared blocks until a request of the bucket can be started by the shared rate limit,
+// keeping the concurrent requests of the instance below maxThreads. It returns a function
+// to release the bucket after the request was finished. If the shared limiter fails,
+// the local delay is kept instead. The wait is cut short if the context is done.
+func (c *Client) acquireShared(ctx context.Context, req *Request, limiter SharedRateLimiter, key string, delay time.Duration, maxThreads uint) (func(), error) {
+	release, err := c.limiter.Acquire(ctx, key, 0, maxThreads)
+	if err != nil || delay <= 0 {
+		return release, err
+	}
+
+	wait, err := limiter.Reserve(key, delay)
+	if err != nil {
+		req.collector.Config.logError(LOG_WARN_LEVEL, fmt.Errorf("shared rate limit of %q: %w", key, err))
+		wait = delay
+	}
+
+	if err := sleepContext(ctx, wait); err != nil {
+		release()
+		return nil, err
+	}
+
+	return release, nil
+}
@@ -0,0 +1,59 @@
+package colly
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+type testSharedLimiter struct {
+	keys []string
+	wait time.Duration
+	err  error
+}
+
+func (l *testSharedLimiter) Reserve(key string, interval time.Duration) (time.Duration, error) {
+	l.keys = append(l.keys, key)
+	return l.wait, l.err
+}
+
+// ------------------------------------------------------------------------
+
+func TestClient_acquireShared(t *testing.T) {
+	tests := []struct {
+		name     string
+		delay    time.Duration
+		limiter  *testSharedLimiter
+		reserved int
+		minWait  time.Duration
+	}{
+		{"no delay", 0, &testSharedLimiter{}, 0, 0},
+		{"booked start", 10 * time.Millisecond, &testSharedLimiter{wait: 30 * time.Millisecond}, 1, 30 * time.Millisecond},
+		{"limiter error", 20 * time.Millisecond, &testSharedLimiter{err: errors.New("unavailable")}, 1, 20 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{limiter: newRateLimiter()}
+			req := &Request{collector: NewCollector(nil, nil)}
+
+			start := time.Now()
+			release, err := c.acquireShared(context.Background(), req, tt.limiter, "example.com", tt.delay, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			release()
+			elapsed := time.Since(start)
+
+			if len(tt.limiter.keys) != tt.reserved {
+				t.Errorf("reserved %d times, want %d", len(tt.limiter.keys), tt.reserved)
+			}
+			if elapsed < tt.minWait {
+				t.Errorf("waited %v, want at least %v", elapsed, tt.minWait)
+			}
+		})
+	}
+}
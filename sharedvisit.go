@@ -0,0 +1,15 @@
+package colly
+
+// ------------------------------------------------------------------------
+
+// recordSharedVisit records the visit of the request in the shared visit registry,
+// tagged by the role of the collector.
+func (c *Collector) recordSharedVisit(r *Request) {
+	if c.Config.VisitRegistry == nil || r.Req == nil || r.Req.URL == nil {
+		return
+	}
+
+	if err := c.Config.VisitRegistry.AddVisit(c.Config.Role, r.OriginalURL().String()); err != nil {
+		c.handleOnStorageError(STORAGE_VISITS, err)
+	}
+}
@@ -0,0 +1,287 @@
+package colly
+
+import (
+	"bufio"
+	"bytes"
+	"colly/storage/mem"
+	"encoding/xml"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// SitemapEntryFunc is called for every entry of a streamed sitemap. The sitemap flag is true
+// for the child sitemaps of a sitemap index. Returning an error stops the streaming.
+type SitemapEntryFunc func(e PollEntry, sitemap bool) error
+
+// SitemapProgressCallback is a callback function to follow the progress of a sitemap crawl.
+type SitemapProgressCallback func(p SitemapProgress)
+
+// SitemapProgress is a snapshot of the progress of a sitemap crawl.
+type SitemapProgress struct {
+	Sitemap   string `json:"sitemap" bson:"sitemap,omitempty"`     // Sitemap is the URL of the last processed sitemap.
+	URLs      uint   `json:"urls" bson:"urls,omitempty"`           // URLs is the number of the URLs submitted from the last processed sitemap.
+	Submitted uint   `json:"submitted" bson:"submitted,omitempty"` // Submitted is the number of the URLs submitted from all sitemaps.
+	Done      uint   `json:"done" bson:"done,omitempty"`           // Done is the number of the processed sitemaps.
+	Skipped   uint   `json:"skipped" bson:"skipped,omitempty"`     // Skipped is the number of the sitemaps completed by an earlier crawl.
+	Total     uint   `json:"total" bson:"total,omitempty"`         // Total is the number of the known sitemaps.
+}
+
+// SitemapCrawler crawls very large sites through their sitemap indexes. The sitemaps are
+// parsed as streams and the page URLs are submitted in bounded batches, so the URLs are never
+// all kept in the memory by the crawler. The completed sitemaps are remembered in the storage,
+// so an interrupted crawl skips them when it's started again.
+type SitemapCrawler struct {
+	collector  *Collector
+	stg        PollStorage     // completion times of the sitemaps
+	batch      int             // maximum number of the decoded URLs buffered before they are submitted
+	sitemaps   map[string]bool // pending sitemap URLs
+	progress   SitemapProgress
+	onProgress SitemapProgressCallback
+	lock       *sync.Mutex
+}
+
+// sitemapEntry is a <url> or a <sitemap> element of a sitemap.
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// ------------------------------------------------------------------------
+
+// SITEMAP_DONE_PREFIX is the storage key prefix of the completed sitemaps.
+const SITEMAP_DONE_PREFIX = "sitemap_done:"
+
+// DEF_SITEMAP_BATCH is the default number of the URLs buffered before they are submitted.
+const DEF_SITEMAP_BATCH = 1000
+
+// ------------------------------------------------------------------------
+
+// NewSitemapCrawler returns a pointer to a newly created sitemap crawler.
+// If no storage is given, the completed sitemaps are kept in the memory, so they are not
+// remembered between the runs. If the batch size is zero, DEF_SITEMAP_BATCH is used.
+// The submitted URLs are kept by the job queue of the collector, use a disk backed queue,
+// e.g. SetMemoryCap, to keep the memory bounded.
+func (c *Collector) NewSitemapCrawler(stg PollStorage, batch int) *SitemapCrawler {
+	if stg == nil {
+		stg = mem.NewCookieStorage()
+	}
+	if batch <= 0 {
+		batch = DEF_SITEMAP_BATCH
+	}
+
+	s := &SitemapCrawler{
+		collector: c,
+		stg:       stg,
+		batch:     batch,
+		sitemaps:  map[string]bool{},
+		lock:      &sync.Mutex{},
+	}
+	c.sysCallbacks.Add(ON_RESPONSE, NO_ARG, ResponseCallback(s.handleResponse))
+
+	return s
+}
+
+// ------------------------------------------------------------------------
+
+// OnProgress registers a function that is called after every processed sitemap.
+func (s *SitemapCrawler) OnProgress(fn SitemapProgressCallback) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.onProgress = fn
+}
+
+// Progress returns a snapshot of the progress of the crawl.
+func (s *SitemapCrawler) Progress() SitemapProgress {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.progress
+}
+
+// Crawl visits the sitemaps or sitemap indexes, then the child sitemaps and the pages.
+// The sitemaps completed by an earlier crawl are skipped.
+func (s *SitemapCrawler) Crawl(sitemaps ...string) error {
+	for _, u := range sitemaps {
+		if !s.register(u) {
+			continue
+		}
+
+		if err := s.collector.scrape(u, "GET", 1, nil, nil, nil, false); err != nil {
+			s.unregister(u)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// handleResponse streams a sitemap response, submitting the pages and the child sitemaps.
+func (s *SitemapCrawler) handleResponse(resp *Response) {
+	u := resp.Request.Req.URL.String()
+
+	s.lock.Lock()
+	_, present := s.sitemaps[u]
+	s.lock.Unlock()
+
+	if !present {
+		return
+	}
+
+	var batch []string
+	var urls uint
+	depth := int(resp.Request.Depth)
+
+	flush := func() {
+		for _, page := range batch {
+			if err := s.collector.scrape(page, "GET", depth+1, nil, nil, nil, true); err != nil {
+				s.collector.Config.logError(LOG_WARN_LEVEL, err)
+			}
+		}
+		urls += uint(len(batch))
+		batch = batch[:0]
+	}
+
+	err := StreamSitemap(bytes.NewReader(resp.Body), func(e PollEntry, sitemap bool) error {
+		e.URL = resp.Request.AbsoluteURL(e.URL)
+		if e.URL == "" {
+			return nil
+		}
+
+		if sitemap {
+			if s.register(e.URL) {
+				if err := s.collector.scrape(e.URL, "GET", depth, nil, nil, nil, false); err != nil {
+					s.unregister(e.URL)
+					s.collector.Config.logError(LOG_WARN_LEVEL, err)
+				}
+			}
+			return nil
+		}
+
+		if batch = append(batch, e.URL); len(batch) >= s.batch {
+			flush()
+		}
+
+		return nil
+	})
+	flush()
+
+	s.unregister(u)
+
+	// Incomplete sitemaps are processed again by the next crawl
+	if err != nil {
+		s.collector.Config.logError(LOG_WARN_LEVEL, err)
+		return
+	}
+
+	if err := s.stg.Set(SITEMAP_DONE_PREFIX+u, strings.NewReader(time.Now().UTC().Format(time.RFC3339))); err != nil {
+		s.collector.Config.logError(LOG_WARN_LEVEL, err)
+	}
+
+	s.lock.Lock()
+	s.progress.Sitemap = u
+	s.progress.URLs = urls
+	s.progress.Submitted += urls
+	s.progress.Done++
+	progress, fn := s.progress, s.onProgress
+	s.lock.Unlock()
+
+	if fn != nil {
+		fn(progress)
+	}
+}
+
+// register adds a sitemap to the pending ones. It returns false if the sitemap
+// is already pending or it was completed by an earlier crawl.
+func (s *SitemapCrawler) register(u string) bool {
+	done := s.completed(u)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, present := s.sitemaps[u]; present {
+		return false
+	}
+
+	s.progress.Total++
+	if done {
+		s.progress.Skipped++
+		return false
+	}
+	s.sitemaps[u] = true
+
+	return true
+}
+
+// unregister removes a sitemap from the pending ones.
+func (s *SitemapCrawler) unregister(u string) {
+	s.lock.Lock()
+	delete(s.sitemaps, u)
+	s.lock.Unlock()
+}
+
+// completed returns true if the sitemap was completed by an earlier crawl.
+func (s *SitemapCrawler) completed(u string) bool {
+	rdr, err := s.stg.Get(SITEMAP_DONE_PREFIX + u)
+	if err != nil || rdr == nil {
+		return false
+	}
+
+	data, err := io.ReadAll(rdr)
+
+	return err == nil && len(data) > 0
+}
+
+// ------------------------------------------------------------------------
+
+// StreamSitemap parses a sitemap or a sitemap index element by element, calling the function
+// for every entry, so the entries are never all kept in the memory. Gzipped sitemaps are
+// decompressed on the fly, of the zip packed sitemaps the first file is parsed.
+// Text sitemaps, listing one URL per line, are supported too.
+func StreamSitemap(r io.Reader, fn SitemapEntryFunc) error {
+	rc, err := openFeed(r)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	br := bufio.NewReader(rc)
+	if textFeed(br) {
+		return streamTextSitemap(br, fn)
+	}
+
+	dec := xml.NewDecoder(br)
+	dec.Strict = false
+	dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || (start.Name.Local != "url" && start.Name.Local != "sitemap") {
+			continue
+		}
+
+		e := sitemapEntry{}
+		if err := dec.DecodeElement(&e, &start); err != nil {
+			return err
+		}
+
+		entry := PollEntry{URL: strings.TrimSpace(e.Loc), Modified: parsePollTime(e.LastMod)}
+		if err := fn(entry, start.Name.Local == "sitemap"); err != nil {
+			return err
+		}
+	}
+}
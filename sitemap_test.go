@@ -0,0 +1,103 @@
+package colly
+
+import (
+	"archive/zip"
+	"bytes"
+	"colly/storage/mem"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestStreamSitemap(t *testing.T) {
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>https://example.com/sitemap-1.xml.gz</loc></sitemap>
+	<sitemap><loc> https://example.com/sitemap-2.xml.gz </loc><lastmod>2024-01-02</lastmod></sitemap>
+</sitemapindex>`
+	const urlset = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>https://example.com/a</loc></url>
+	<url><loc>https://example.com/b</loc></url>
+	<url><loc>https://example.com/c</loc></url>
+</urlset>`
+
+	const text = "\uFEFF https://example.com/a\r\n\n# comment\nhttps://example.com/b\nhttps://example.com/c\n"
+
+	gz := &bytes.Buffer{}
+	w := gzip.NewWriter(gz)
+	w.Write([]byte(urlset))
+	w.Close()
+
+	gzText := &bytes.Buffer{}
+	w = gzip.NewWriter(gzText)
+	w.Write([]byte(text))
+	w.Close()
+
+	zipped := &bytes.Buffer{}
+	zw := zip.NewWriter(zipped)
+	zw.Create("export/")
+	f, _ := zw.Create("export/sitemap.xml")
+	f.Write([]byte(urlset))
+	zw.Close()
+
+	tests := []struct {
+		name     string
+		body     []byte
+		sitemaps []string
+		pages    []string
+	}{
+		{"index", []byte(index), []string{"https://example.com/sitemap-1.xml.gz", "https://example.com/sitemap-2.xml.gz"}, nil},
+		{"urlset", []byte(urlset), nil, []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}},
+		{"gzipped urlset", gz.Bytes(), nil, []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}},
+		{"zipped urlset", zipped.Bytes(), nil, []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}},
+		{"text", []byte(text), nil, []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}},
+		{"gzipped text", gzText.Bytes(), nil, []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sitemaps, pages []string
+			err := StreamSitemap(bytes.NewReader(tt.body), func(e PollEntry, sitemap bool) error {
+				if sitemap {
+					sitemaps = append(sitemaps, e.URL)
+				} else {
+					pages = append(pages, e.URL)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("StreamSitemap() error = %v", err)
+			}
+			if !reflect.DeepEqual(sitemaps, tt.sitemaps) || !reflect.DeepEqual(pages, tt.pages) {
+				t.Errorf("StreamSitemap() = %v, %v, want %v, %v", sitemaps, pages, tt.sitemaps, tt.pages)
+			}
+		})
+	}
+}
+
+func TestSitemapCrawler_register(t *testing.T) {
+	s := &SitemapCrawler{
+		stg:      mem.NewCookieStorage(),
+		sitemaps: map[string]bool{},
+		lock:     &sync.Mutex{},
+	}
+	s.stg.Set(SITEMAP_DONE_PREFIX+"https://example.com/done.xml", strings.NewReader("2024-01-02T00:00:00Z"))
+
+	if !s.register("https://example.com/new.xml") {
+		t.Errorf("register() of a new sitemap = false")
+	}
+	if s.register("https://example.com/new.xml") {
+		t.Errorf("register() of a pending sitemap = true")
+	}
+	if s.register("https://example.com/done.xml") {
+		t.Errorf("register() of a completed sitemap = true")
+	}
+
+	if p := s.Progress(); p.Total != 2 || p.Skipped != 1 {
+		t.Errorf("Progress() = %+v, want 2 known and 1 skipped sitemaps", p)
+	}
+}
@@ -0,0 +1,191 @@
+package colly
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// ------------------------------------------------------------------------
+
+// SnapshotNormalizer rewrites HTML pages to a stable, diff-friendly form, so the extraction
+// can be regression-tested against stored page fixtures. The volatile attributes and text
+// are removed, the attributes are sorted, the whitespace is collapsed, the comments are dropped
+// and every node is written on its own indented line.
+type SnapshotNormalizer struct {
+	attrs    []string         // volatile attribute names, the ones ending with an asterisk match by prefix
+	patterns []*regexp.Regexp // volatile text patterns of the text and the attribute values
+	lock     *sync.RWMutex
+}
+
+// ------------------------------------------------------------------------
+
+const SNAPSHOT_PLACEHOLDER = "{volatile}" // SNAPSHOT_PLACEHOLDER replaces the volatile text patterns.
+
+// ------------------------------------------------------------------------
+
+var (
+	// DefaultVolatileAttrs is the list of the attributes removed by the default snapshot normalizer.
+	DefaultVolatileAttrs = []string{"nonce", "integrity", "data-nonce", "data-csrf*", "data-timestamp", "data-time*", "data-request-id"}
+
+	// snapshotVerbatim is the list of the elements with whitespace sensitive content.
+	snapshotVerbatim = map[string]bool{"pre": true, "textarea": true, "script": true, "style": true}
+
+	// snapshotVoid is the list of the elements without closing tags.
+	snapshotVoid = map[string]bool{
+		"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+		"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+	}
+
+	defaultSnapshotNormalizer = NewSnapshotNormalizer(DefaultVolatileAttrs...)
+)
+
+// ------------------------------------------------------------------------
+
+// NewSnapshotNormalizer returns a pointer to a newly created snapshot normalizer
+// removing the given attributes, see AddAttrs for the syntax.
+func NewSnapshotNormalizer(attrs ...string) *SnapshotNormalizer {
+	n := &SnapshotNormalizer{
+		lock: &sync.RWMutex{},
+	}
+	n.AddAttrs(attrs...)
+
+	return n
+}
+
+// ------------------------------------------------------------------------
+
+// AddAttrs adds volatile attribute names to remove from the elements.
+// Names ending with an asterisk match by prefix, e.g. "data-ts-*".
+func (n *SnapshotNormalizer) AddAttrs(attrs ...string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for _, a := range attrs {
+		if a = strings.TrimSpace(a); a != "" {
+			n.attrs = append(n.attrs, a)
+		}
+	}
+}
+
+// AddPattern adds a regular expression matching volatile text, e.g. timestamps or session IDs.
+// The matches in the text and the attribute values are replaced with SNAPSHOT_PLACEHOLDER.
+func (n *SnapshotNormalizer) AddPattern(expr string) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.patterns = append(n.patterns, re)
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// NormalizeHTML returns the snapshot of the HTML page made by the default snapshot normalizer.
+func NormalizeHTML(body []byte) ([]byte, error) {
+	return defaultSnapshotNormalizer.Normalize(body)
+}
+
+// Normalize returns the stable snapshot of the HTML page. The same page with different volatile
+// attributes, attribute order or whitespace results the same snapshot.
+func (n *SnapshotNormalizer) Normalize(body []byte) ([]byte, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	buf := &bytes.Buffer{}
+	for c := doc.FirstChild; c != nil; c = c.NextSibling {
+		n.render(buf, c, 0, false)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ------------------------------------------------------------------------
+
+// render writes the node and its descendants to the buffer. The text of the verbatim
+// elements is kept as is. The caller must hold the read lock.
+func (n *SnapshotNormalizer) render(buf *bytes.Buffer, node *html.Node, depth int, verbatim bool) {
+	indent := strings.Repeat("  ", depth)
+
+	switch node.Type {
+	case html.DoctypeNode:
+		buf.WriteString("<!DOCTYPE " + node.Data + ">\n")
+
+	case html.TextNode:
+		text := node.Data
+		if !verbatim {
+			text = strings.Join(strings.Fields(text), " ")
+		} else {
+			text = strings.TrimSpace(text)
+		}
+		if text == "" {
+			return
+		}
+		text = n.replace(text)
+		if node.Parent == nil || (node.Parent.Data != "script" && node.Parent.Data != "style") {
+			text = html.EscapeString(text)
+		}
+		buf.WriteString(indent + text + "\n")
+
+	case html.ElementNode:
+		buf.WriteString(indent + "<" + node.Data)
+		for _, a := range n.attributes(node.Attr) {
+			buf.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+		}
+		buf.WriteString(">\n")
+
+		if snapshotVoid[node.Data] {
+			return
+		}
+
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			n.render(buf, c, depth+1, verbatim || snapshotVerbatim[node.Data])
+		}
+		buf.WriteString(indent + "</" + node.Data + ">\n")
+	}
+}
+
+// attributes returns the sorted attributes of an element without the volatile ones.
+func (n *SnapshotNormalizer) attributes(attrs []html.Attribute) []html.Attribute {
+	kept := make([]html.Attribute, 0, len(attrs))
+
+	for _, a := range attrs {
+		if a.Namespace != "" {
+			a.Key = a.Namespace + ":" + a.Key
+		}
+		if matchParam(a.Key, n.attrs) {
+			continue
+		}
+		a.Val = n.replace(strings.Join(strings.Fields(a.Val), " "))
+		kept = append(kept, a)
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].Key < kept[j].Key
+	})
+
+	return kept
+}
+
+// replace replaces the volatile text patterns with the placeholder.
+func (n *SnapshotNormalizer) replace(text string) string {
+	for _, re := range n.patterns {
+		text = re.ReplaceAllLiteralString(text, SNAPSHOT_PLACEHOLDER)
+	}
+
+	return text
+}
@@ -0,0 +1,70 @@
+package colly
+
+import (
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestSnapshotNormalizer_Normalize(t *testing.T) {
+	n := NewSnapshotNormalizer(DefaultVolatileAttrs...)
+	if err := n.AddPattern(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z`); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.AddPattern(`(`); err == nil {
+		t.Error("AddPattern() with invalid expression returned no error")
+	}
+
+	first := `<!DOCTYPE html><html><head>
+		<script nonce="abc123">var a = 1;</script>
+		<!-- generated 2023-04-01T10:00:00Z -->
+	</head><body>
+		<p   class="lead"  id="intro">Hello,
+			<b>world</b>!</p>
+		<pre>  keep
+  this</pre>
+		<img src="/a.png" alt="A"  data-timestamp="1680343200">
+		<span title="built 2023-04-01T10:00:00Z">Updated 2023-04-01T10:00:00Z</span>
+	</body></html>`
+
+	second := `<!DOCTYPE html><html><head><script nonce="xyz789">var a = 1;</script></head>
+	<body><p id="intro" class="lead">Hello, <b>world</b>!</p><pre>  keep
+  this</pre><img alt="A" src="/a.png" data-timestamp="1680999999"><span title="built 2023-05-02T11:30:00Z">Updated 2023-05-02T11:30:00Z</span></body></html>`
+
+	want := `<!DOCTYPE html>
+<html>
+  <head>
+    <script>
+      var a = 1;
+    </script>
+  </head>
+  <body>
+    <p class="lead" id="intro">
+      Hello,
+      <b>
+        world
+      </b>
+      !
+    </p>
+    <pre>
+      keep
+  this
+    </pre>
+    <img alt="A" src="/a.png">
+    <span title="built {volatile}">
+      Updated {volatile}
+    </span>
+  </body>
+</html>
+`
+
+	for name, page := range map[string]string{"first": first, "second": second} {
+		got, err := n.Normalize([]byte(page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("Normalize(%s) =\n%s\nwant\n%s", name, got, want)
+		}
+	}
+}
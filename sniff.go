@@ -0,0 +1,110 @@
+package colly
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ------------------------------------------------------------------------
+
+// sniffSampleSize is the maximum number of body bytes used for content type sniffing.
+const sniffSampleSize = 1024
+
+// ------------------------------------------------------------------------
+
+var (
+	htmlSignatures = [][]byte{[]byte("<!doctype html"), []byte("<html"), []byte("<head"), []byte("<body"), []byte("<div"), []byte("<p>"), []byte("<a ")}
+	xmlSignatures  = [][]byte{[]byte("<?xml"), []byte("<rss"), []byte("<feed"), []byte("<urlset"), []byte("<sitemapindex"), []byte("<svg")}
+)
+
+// ------------------------------------------------------------------------
+
+// SniffContentType guesses the media type of the body.
+// It uses http.DetectContentType and refines its textual results with HTML, XML and JSON heuristics.
+func SniffContentType(body []byte) string {
+	sample := body
+	if len(sample) > sniffSampleSize {
+		sample = sample[:sniffSampleSize]
+	}
+
+	detected := http.DetectContentType(sample)
+	mediaType, _, _ := mime.ParseMediaType(detected)
+	if mediaType != "text/plain" && mediaType != "text/xml" && mediaType != "application/octet-stream" {
+		return detected
+	}
+
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(sample, []byte("\xef\xbb\xbf")))
+	if len(trimmed) == 0 {
+		return detected
+	}
+
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		if json.Valid(bytes.TrimSpace(body)) {
+			return "application/json"
+		}
+	}
+
+	if trimmed[0] == '<' {
+		lower := bytes.ToLower(trimmed)
+		for _, sig := range htmlSignatures {
+			if bytes.Contains(lower, sig) {
+				return "text/html; charset=utf-8"
+			}
+		}
+		for _, sig := range xmlSignatures {
+			if bytes.HasPrefix(lower, sig) {
+				return "text/xml; charset=utf-8"
+			}
+		}
+	}
+
+	return detected
+}
+
+// ------------------------------------------------------------------------
+
+// ContentType returns the sniffed media type of the response if it was set,
+// the declared Content-Type header otherwise, in lower case.
+func (r *Response) ContentType() string {
+	if r.SniffedType != "" {
+		return strings.ToLower(r.SniffedType)
+	}
+
+	if r.Resp == nil {
+		return ""
+	}
+
+	return hdrVal(r.Resp.Header, "Content-Type")
+}
+
+// ------------------------------------------------------------------------
+
+// sniffContentType records the sniffed media type of the response
+// if the declared Content-Type is missing or text/plain.
+func (c *Collector) sniffContentType(resp *Response) {
+	if !c.Config.SniffContentType || resp.Resp == nil || len(resp.Body) == 0 {
+		return
+	}
+
+	if forced, _ := c.Config.contentOverride(resp.Request); forced != "" {
+		return
+	}
+
+	declared := hdrVal(resp.Resp.Header, "Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(declared); declared != "" && mediaType != "text/plain" {
+		return
+	}
+
+	resp.SniffedType = SniffContentType(resp.Body)
+
+	if c.HasLogger() && !strings.HasPrefix(resp.SniffedType, "text/plain") {
+		c.logEvent(LOG_DEBUG_LEVEL, "sniff", resp.Request.ID, map[string]string{
+			"url":      resp.Request.Req.URL.String(),
+			"declared": declared,
+			"sniffed":  resp.SniffedType,
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package colly
+
+import (
+	"net/http"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestSniffContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"empty", "", "text/plain; charset=utf-8"},
+		{"plain text", "hello world", "text/plain; charset=utf-8"},
+		{"html doctype", "<!DOCTYPE html><html><body>x</body></html>", "text/html; charset=utf-8"},
+		{"html fragment", "  <div class=\"a\"><p>text</p></div>", "text/html; charset=utf-8"},
+		{"xhtml", "<?xml version=\"1.0\"?><html xmlns=\"http://www.w3.org/1999/xhtml\"></html>", "text/html; charset=utf-8"},
+		{"xml", "<?xml version=\"1.0\"?><root/>", "text/xml; charset=utf-8"},
+		{"rss", "<rss version=\"2.0\"><channel></channel></rss>", "text/xml; charset=utf-8"},
+		{"sitemap", "\xef\xbb\xbf<urlset xmlns=\"http://www.sitemaps.org/schemas/sitemap/0.9\"></urlset>", "text/xml; charset=utf-8"},
+		{"json object", "{\"a\": [1, 2]}", "application/json"},
+		{"json array", " [1, 2, 3] ", "application/json"},
+		{"broken json", "{\"a\": ", "text/plain; charset=utf-8"},
+		{"png", "\x89PNG\x0D\x0A\x1A\x0A", "image/png"},
+	}
+
+	for _, tt := range tests {
+		if got := SniffContentType([]byte(tt.body)); got != tt.want {
+			t.Errorf("%s: SniffContentType() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestResponse_ContentType(t *testing.T) {
+	resp := &Response{Resp: &http.Response{Header: http.Header{"Content-Type": {"Text/Plain"}}}}
+	if got := resp.ContentType(); got != "text/plain" {
+		t.Errorf("ContentType() = %q, want %q", got, "text/plain")
+	}
+
+	resp.SniffedType = "text/html; charset=utf-8"
+	if got := resp.ContentType(); got != resp.SniffedType {
+		t.Errorf("ContentType() = %q, want %q", got, resp.SniffedType)
+	}
+}
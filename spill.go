@@ -0,0 +1,448 @@
+package colly
+
+import (
+	"bytes"
+	"colly/storage"
+	"colly/storage/badger"
+	"colly/storage/filesys"
+	"colly/storage/mem"
+	"errors"
+	"io"
+	"path/filepath"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// MemoryGovernor caps the memory used by the in-memory tier of the spill storages.
+// The storages sharing a governor share the cap, the items beyond the cap are spilled to disk.
+type MemoryGovernor struct {
+	max     uint64 // maximum number of bytes kept in the memory
+	used    uint64 // number of bytes kept in the memory
+	spilled uint64 // number of the items written to disk
+	lock    *sync.Mutex
+}
+
+// MemoryStats is a point-in-time snapshot of a memory governor.
+type MemoryStats struct {
+	Max     uint64 `json:"max" bson:"max,omitempty"`         // Max is the memory cap in bytes.
+	Used    uint64 `json:"used" bson:"used,omitempty"`       // Used is the number of bytes kept in the memory.
+	Spilled uint64 `json:"spilled" bson:"spilled,omitempty"` // Spilled is the number of the items written to disk.
+}
+
+// spillQueue is a queue storage that keeps the items in the memory up to the cap
+// of its governor and spills the rest to the disk queue.
+type spillQueue struct {
+	gov      *MemoryGovernor
+	mem      Queue
+	disk     Queue
+	memBytes map[uint32]uint64 // bytes kept in the memory by dispatch queue
+	diskLen  map[uint32]uint   // items on the disk by dispatch queue, loaded on first use
+	lock     *sync.Mutex
+}
+
+// spillCache is a cache storage that keeps the items in the memory up to the cap
+// of its governor and spills the rest to the disk storage.
+type spillCache struct {
+	gov   *MemoryGovernor
+	mem   CacheStorage
+	disk  CacheStorage
+	sizes map[string]uint64 // sizes of the items kept in the memory
+	lock  *sync.Mutex
+}
+
+// ------------------------------------------------------------------------
+
+// Spill storage directories
+const (
+	SPILL_QUEUE_DIR = "queue" // Directory of the spilled queue items in the spill directory.
+	SPILL_CACHE_DIR = "cache" // Directory of the spilled cache items in the spill directory.
+)
+
+// ------------------------------------------------------------------------
+
+// NewMemoryGovernor returns a pointer to a newly created memory governor with the cap in bytes.
+func NewMemoryGovernor(maxBytes uint64) *MemoryGovernor {
+	return &MemoryGovernor{
+		max:  maxBytes,
+		lock: &sync.Mutex{},
+	}
+}
+
+// Stats returns a snapshot of the memory usage.
+func (g *MemoryGovernor) Stats() MemoryStats {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return MemoryStats{Max: g.max, Used: g.used, Spilled: g.spilled}
+}
+
+// reserve reserves memory for an item. It returns false if the item has to be spilled.
+func (g *MemoryGovernor) reserve(n uint64) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.used+n > g.max {
+		return false
+	}
+	g.used += n
+
+	return true
+}
+
+// spill counts an item written to disk.
+func (g *MemoryGovernor) spill() {
+	g.lock.Lock()
+	g.spilled++
+	g.lock.Unlock()
+}
+
+// release releases the memory of an item.
+func (g *MemoryGovernor) release(n uint64) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if n > g.used {
+		n = g.used
+	}
+	g.used -= n
+}
+
+// ------------------------------------------------------------------------
+
+// NewSpillQueue returns a queue storage that spills to the disk queue beyond the memory cap.
+// The FIFO order is kept, the items are pulled back from the disk when the memory tier is empty.
+func NewSpillQueue(gov *MemoryGovernor, disk Queue) (Queue, error) {
+	if gov == nil || disk == nil {
+		return nil, storage.ErrMissingParams
+	}
+
+	return &spillQueue{
+		gov:      gov,
+		mem:      mem.NewFIFOStorage(disk.Capacity()),
+		disk:     disk,
+		memBytes: map[uint32]uint64{},
+		diskLen:  map[uint32]uint{},
+		lock:     &sync.Mutex{},
+	}, nil
+}
+
+// Clear removes all entries from a number of dispatch queues or the whole queue if no ID was given.
+func (q *spillQueue) Clear(ids ...uint32) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := q.mem.Clear(ids...); err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		for _, n := range q.memBytes {
+			q.gov.release(n)
+		}
+		q.memBytes = map[uint32]uint64{}
+		q.diskLen = map[uint32]uint{}
+
+		return q.disk.Clear()
+	}
+
+	for _, id := range ids {
+		q.gov.release(q.memBytes[id])
+		delete(q.memBytes, id)
+		delete(q.diskLen, id)
+	}
+
+	return q.disk.Clear(ids...)
+}
+
+// Close closes the queue.
+func (q *spillQueue) Close() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for id, n := range q.memBytes {
+		q.gov.release(n)
+		delete(q.memBytes, id)
+	}
+
+	return q.disk.Close()
+}
+
+// Len returns the number of items in a dispatch queue.
+func (q *spillQueue) Len(id uint32) (uint, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	n, err := q.mem.Len(id)
+	if err != nil {
+		return 0, err
+	}
+
+	d, err := q.onDisk(id)
+
+	return n + d, err
+}
+
+// Push appends a value at the end/tail of a dispatch queue.
+func (q *spillQueue) Push(id uint32, item io.Reader) error {
+	data, err := io.ReadAll(item)
+	if err != nil {
+		return err
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	d, err := q.onDisk(id)
+	if err != nil {
+		return err
+	}
+
+	// The items must not overtake the spilled ones
+	size := uint64(len(data))
+	if d == 0 && q.gov.reserve(size) {
+		if err := q.mem.Push(id, bytes.NewReader(data)); err != nil {
+			q.gov.release(size)
+			return err
+		}
+		q.memBytes[id] += size
+		return nil
+	}
+
+	if err := q.disk.Push(id, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	q.diskLen[id]++
+	q.gov.spill()
+
+	return nil
+}
+
+// Pop removes and returns the oldest value in a dispatch queue.
+func (q *spillQueue) Pop(id uint32) (io.Reader, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if n, _ := q.mem.Len(id); n > 0 {
+		rdr, err := q.mem.Pop(id)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(rdr)
+		if err != nil {
+			return nil, err
+		}
+		q.gov.release(uint64(len(data)))
+		q.memBytes[id] -= uint64(len(data))
+
+		return bytes.NewReader(data), nil
+	}
+
+	rdr, err := q.disk.Pop(id)
+	if err == nil && q.diskLen[id] > 0 {
+		q.diskLen[id]--
+	}
+	if errors.Is(err, storage.ErrStorageEmpty) {
+		q.diskLen[id] = 0
+	}
+
+	return rdr, err
+}
+
+// Capacity returns the maximum capacity of a dispatch queue.
+func (q *spillQueue) Capacity() uint {
+	return q.disk.Capacity()
+}
+
+// onDisk returns the number of the items of the dispatch queue on the disk.
+// The caller must hold the lock.
+func (q *spillQueue) onDisk(id uint32) (uint, error) {
+	if n, ok := q.diskLen[id]; ok {
+		return n, nil
+	}
+
+	n, err := q.disk.Len(id)
+	if err != nil {
+		return 0, err
+	}
+	q.diskLen[id] = n
+
+	return n, nil
+}
+
+// ------------------------------------------------------------------------
+
+// NewSpillCacheStorage returns a cache storage that spills to the disk storage beyond the memory cap.
+// The spilled items are pulled back to the memory when they are fetched and the memory allows it.
+func NewSpillCacheStorage(gov *MemoryGovernor, disk CacheStorage) (CacheStorage, error) {
+	if gov == nil || disk == nil {
+		return nil, storage.ErrMissingParams
+	}
+
+	return &spillCache{
+		gov:   gov,
+		mem:   mem.NewCacheStorage(),
+		disk:  disk,
+		sizes: map[string]uint64{},
+		lock:  &sync.Mutex{},
+	}, nil
+}
+
+// Put stores an item in the memory, or on the disk if the memory is full.
+func (s *spillCache) Put(key string, data io.Reader) error {
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.remove(key); err != nil {
+		return err
+	}
+
+	size := uint64(len(b))
+	if !s.gov.reserve(size) {
+		s.gov.spill()
+		return s.disk.Put(key, bytes.NewReader(b))
+	}
+
+	if err := s.mem.Put(key, bytes.NewReader(b)); err != nil {
+		s.gov.release(size)
+		return err
+	}
+	s.sizes[key] = size
+
+	return nil
+}
+
+// Fetch retrieves an item from the memory or the disk.
+func (s *spillCache) Fetch(key string) (io.Reader, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.sizes[key]; ok {
+		return s.mem.Fetch(key)
+	}
+
+	rdr, err := s.disk.Fetch(key)
+	if err != nil || rdr == nil {
+		return rdr, err
+	}
+
+	b, err := io.ReadAll(rdr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pull the item back if the memory allows it
+	size := uint64(len(b))
+	if s.gov.reserve(size) {
+		if s.mem.Put(key, bytes.NewReader(b)) == nil && s.disk.Remove(key) == nil {
+			s.sizes[key] = size
+		} else {
+			s.mem.Remove(key)
+			s.gov.release(size)
+		}
+	}
+
+	return bytes.NewReader(b), nil
+}
+
+// Has returns true if the key exists in the memory or on the disk.
+func (s *spillCache) Has(key string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.sizes[key]; ok {
+		return true
+	}
+
+	return s.disk.Has(key)
+}
+
+// Remove deletes an item from the memory and the disk.
+func (s *spillCache) Remove(key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.remove(key)
+}
+
+// Clear deletes all items from the memory and the disk.
+func (s *spillCache) Clear() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for key, size := range s.sizes {
+		s.gov.release(size)
+		delete(s.sizes, key)
+	}
+
+	if err := s.mem.Clear(); err != nil {
+		return err
+	}
+
+	return s.disk.Clear()
+}
+
+// remove deletes an item from the memory and the disk. The caller must hold the lock.
+func (s *spillCache) remove(key string) error {
+	if size, ok := s.sizes[key]; ok {
+		if err := s.mem.Remove(key); err != nil {
+			return err
+		}
+		s.gov.release(size)
+		delete(s.sizes, key)
+	}
+
+	if !s.disk.Has(key) {
+		return nil
+	}
+
+	return s.disk.Remove(key)
+}
+
+// ------------------------------------------------------------------------
+
+// SetMemoryCap caps the memory used by the job queue and the cache. The items beyond the cap
+// are spilled to a BadgerDB queue and a file cache in the spill directory, which are cleared on opening.
+// The cache is only set if an expiry handler is given.
+func (c *CollectorConfig) SetMemoryCap(maxBytes uint64, spillDir string, expHandler CacheExpiryHandler) error {
+	gov := NewMemoryGovernor(maxBytes)
+
+	fifo, err := badger.NewFIFOStorage(filepath.Join(spillDir, SPILL_QUEUE_DIR), false)
+	if err != nil {
+		return err
+	}
+
+	queue, err := NewSpillQueue(gov, fifo)
+	if err != nil {
+		return err
+	}
+	if err := c.SetQueue(queue); err != nil {
+		queue.Close()
+		return err
+	}
+
+	if expHandler != nil {
+		disk, err := filesys.NewCacheStorage(filepath.Join(spillDir, SPILL_CACHE_DIR))
+		if err != nil {
+			return err
+		}
+
+		stg, err := NewSpillCacheStorage(gov, disk)
+		if err != nil {
+			return err
+		}
+		if err := c.SetCache(stg, expHandler); err != nil {
+			return err
+		}
+	}
+
+	c.MemoryGovernor = gov
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package colly
+
+import (
+	"colly/storage/mem"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_spillQueue(t *testing.T) {
+	gov := NewMemoryGovernor(8)
+	disk := mem.NewFIFOStorage(100)
+	q, err := NewSpillQueue(gov, disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range []string{"aaaa", "bbbb", "cccc", "d"} {
+		if err := q.Push(1, strings.NewReader(item)); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+
+	if n, _ := disk.Len(1); n != 2 {
+		t.Errorf("disk has %d items, want the 2 items beyond the cap", n)
+	}
+	if n, _ := q.Len(1); n != 4 {
+		t.Errorf("Len() = %d, want 4", n)
+	}
+
+	got := []string{}
+	for i := 0; i < 2; i++ {
+		got = append(got, popString(t, q))
+	}
+	// The memory is free, but the new item must not overtake the spilled ones
+	if err := q.Push(1, strings.NewReader("e")); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		got = append(got, popString(t, q))
+	}
+
+	if want := []string{"aaaa", "bbbb", "cccc", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Pop() order = %v, want %v", got, want)
+	}
+	if stats := gov.Stats(); stats.Used != 0 || stats.Spilled != 3 {
+		t.Errorf("Stats() = %+v, want no memory used and 3 spilled items", stats)
+	}
+}
+
+func Test_spillCache(t *testing.T) {
+	gov := NewMemoryGovernor(8)
+	disk := mem.NewCacheStorage()
+	s, err := NewSpillCacheStorage(gov, disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Put("a", strings.NewReader("aaaaaa"))
+	s.Put("b", strings.NewReader("bbbbbb"))
+	if !disk.Has("b") || disk.Has("a") {
+		t.Errorf("the item beyond the cap was not spilled")
+	}
+
+	// Removing a frees the memory, fetching b pulls it back
+	s.Remove("a")
+	rdr, err := s.Fetch("b")
+	if err != nil || rdr == nil {
+		t.Fatalf("Fetch() = %v, %v", rdr, err)
+	}
+	if b, _ := io.ReadAll(rdr); string(b) != "bbbbbb" {
+		t.Errorf("Fetch() = %q, want %q", b, "bbbbbb")
+	}
+	if disk.Has("b") || !s.Has("b") || gov.Stats().Used != 6 {
+		t.Errorf("the fetched item was not pulled back, stats = %+v", gov.Stats())
+	}
+
+	if err := s.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Has("b") || gov.Stats().Used != 0 {
+		t.Errorf("Clear() kept items, stats = %+v", gov.Stats())
+	}
+}
+
+func popString(t *testing.T, q Queue) string {
+	rdr, err := q.Pop(1)
+	if err != nil {
+		t.Fatalf("Pop() error = %v", err)
+	}
+	b, _ := io.ReadAll(rdr)
+	return string(b)
+}
@@ -0,0 +1,132 @@
+package colly
+
+import (
+	"sync/atomic"
+)
+
+// ------------------------------------------------------------------------
+
+// CollectorStats is a point-in-time snapshot of the collector counters.
+type CollectorStats struct {
+	Requests  uint32 `json:"requests" bson:"requests,omitempty"`   // Requests is the number of the created requests.
+	Responses uint32 `json:"responses" bson:"responses,omitempty"` // Responses is the number of the received responses.
+	Errors    uint32 `json:"errors" bson:"errors,omitempty"`       // Errors is the number of the failed requests.
+	Scraped   uint32 `json:"scraped" bson:"scraped,omitempty"`     // Scraped is the number of the completely processed responses.
+	Bytes     uint64 `json:"bytes" bson:"bytes,omitempty"`         // Bytes is the total size of the received response bodies.
+	Truncated uint32 `json:"truncated" bson:"truncated,omitempty"` // Truncated is the number of the truncated responses.
+	Negative  uint32 `json:"negative" bson:"negative,omitempty"`   // Negative is the number of the requests skipped by the negative cache.
+	Stuck     uint32 `json:"stuck" bson:"stuck,omitempty"`         // Stuck is the number of the requests cancelled by the watchdog.
+	Trapped   uint32 `json:"trapped" bson:"trapped,omitempty"`     // Trapped is the number of the responses detected in spider traps.
+	Rejected  uint32 `json:"rejected" bson:"rejected,omitempty"`   // Rejected is the number of the requests rejected before they were sent.
+}
+
+// collectorStats holds the collector counters.
+// The counters are owned by the collector and can be updated concurrently
+// by the request goroutines, therefore every field is an atomic type.
+type collectorStats struct {
+	requests  atomic.Uint32
+	responses atomic.Uint32
+	errors    atomic.Uint32
+	scraped   atomic.Uint32
+	bytes     atomic.Uint64
+	truncated atomic.Uint32
+	negative  atomic.Uint32
+	stuck     atomic.Uint32
+	trapped   atomic.Uint32
+	rejected  atomic.Uint32
+}
+
+// ------------------------------------------------------------------------
+
+// newCollectorStats returns a pointer to a newly created set of collector counters.
+func newCollectorStats() *collectorStats {
+	return &collectorStats{}
+}
+
+// ------------------------------------------------------------------------
+
+// Stats returns a snapshot of the collector counters.
+// It is safe to call Stats while the collector is running.
+func (c *Collector) Stats() CollectorStats {
+	return c.stats.snapshot()
+}
+
+// RequestCount returns the number of the requests created by the collector.
+func (c *Collector) RequestCount() uint32 {
+	return c.stats.requests.Load()
+}
+
+// ResponseCount returns the number of the responses received by the collector.
+func (c *Collector) ResponseCount() uint32 {
+	return c.stats.responses.Load()
+}
+
+// ------------------------------------------------------------------------
+
+// nextRequestID increments the request counter and returns the new value
+// as the identifier of the next request.
+func (s *collectorStats) nextRequestID() uint32 {
+	return s.requests.Add(1)
+}
+
+func (s *collectorStats) responseReceived(size int) {
+	s.responses.Add(1)
+	if size > 0 {
+		s.bytes.Add(uint64(size))
+	}
+}
+
+func (s *collectorStats) errorOccurred() {
+	s.errors.Add(1)
+}
+
+func (s *collectorStats) responseScraped() {
+	s.scraped.Add(1)
+}
+
+func (s *collectorStats) responseTruncated() {
+	s.truncated.Add(1)
+}
+
+func (s *collectorStats) negativeCacheHit() {
+	s.negative.Add(1)
+}
+
+func (s *collectorStats) requestStuck() {
+	s.stuck.Add(1)
+}
+
+func (s *collectorStats) responseTrapped() {
+	s.trapped.Add(1)
+}
+
+func (s *collectorStats) requestRejected() {
+	s.rejected.Add(1)
+}
+
+// subscribe counts the signals of the event bus.
+func (s *collectorStats) subscribe(bus *EventBus) {
+	HandleTyped(bus, func(p ResponseSignal) { s.responseReceived(p.Size) }, TOPIC_RESPONSE)
+	bus.Handle(func(BusMessage) { s.responseScraped() }, TOPIC_SCRAPED)
+	bus.Handle(func(BusMessage) { s.responseTruncated() }, TOPIC_TRUNCATED)
+	bus.Handle(func(BusMessage) { s.requestStuck() }, TOPIC_STUCK)
+	bus.Handle(func(BusMessage) { s.responseTrapped() }, TOPIC_TRAP)
+	bus.Handle(func(BusMessage) { s.requestRejected() }, TOPIC_REJECTED)
+}
+
+// snapshot returns the current values of the counters.
+// The counters are read one by one, so the snapshot is consistent per field only.
+func (s *collectorStats) snapshot() CollectorStats {
+	return CollectorStats{
+		Requests:  s.requests.Load(),
+		Responses: s.responses.Load(),
+		Errors:    s.errors.Load(),
+		Scraped:   s.scraped.Load(),
+		Bytes:     s.bytes.Load(),
+		Truncated: s.truncated.Load(),
+		Negative:  s.negative.Load(),
+		Stuck:     s.stuck.Load(),
+		Trapped:   s.trapped.Load(),
+		Rejected:  s.rejected.Load(),
+	}
+}
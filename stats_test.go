@@ -0,0 +1,96 @@
+package colly
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_collectorStats(t *testing.T) {
+	const workers, rounds = 16, 1000
+
+	s := newCollectorStats()
+	ids := make(chan uint32, workers*rounds)
+	wg := &sync.WaitGroup{}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				ids <- s.nextRequestID()
+				s.responseReceived(10)
+				s.responseScraped()
+				if i%10 == 0 {
+					s.errorOccurred()
+				}
+				_ = s.snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := map[uint32]bool{}
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("nextRequestID() returned duplicate ID %d", id)
+		}
+		seen[id] = true
+	}
+
+	want := CollectorStats{
+		Requests:  workers * rounds,
+		Responses: workers * rounds,
+		Errors:    workers * rounds / 10,
+		Scraped:   workers * rounds,
+		Bytes:     workers * rounds * 10,
+	}
+	if got := s.snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot() = %+v, want %+v", got, want)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_StatsAsync(t *testing.T) {
+	const workers = 32
+
+	c := NewCollector(nil, nil)
+	c.OnResponse(func(r *Response) {
+		_ = c.Stats()
+	})
+
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "http://example.com/", nil)
+			resp := &Response{
+				Request: &Request{ID: c.stats.nextRequestID(), Req: req, collector: c},
+				Resp:    &http.Response{StatusCode: http.StatusOK, Header: http.Header{}},
+				Body:    []byte("hello"),
+			}
+			c.handleOnResponse(resp)
+			c.handleOnScraped(resp)
+		}()
+	}
+	wg.Wait()
+
+	want := CollectorStats{
+		Requests:  workers,
+		Responses: workers,
+		Scraped:   workers,
+		Bytes:     workers * 5,
+	}
+	if got := c.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+	if c.RequestCount() != workers || c.ResponseCount() != workers {
+		t.Errorf("RequestCount() = %d, ResponseCount() = %d, want %d", c.RequestCount(), c.ResponseCount(), workers)
+	}
+}
@@ -261,3 +261,32 @@ func (s *stgBase) Len(prefix []byte) (uint, error) {
 
 	return count, nil
 }
+
+// ------------------------------------------------------------------------
+
+// CountPrefix returns the number and the size of the entries with keys starting with the prefix.
+// The size of the values is included only if values is true.
+func (s *stgBase) CountPrefix(prefix string, values bool) (storage.PrefixStats, error) {
+	var stats storage.PrefixStats
+
+	p := make([]byte, 0, len(s.config.prefix)+len(prefix))
+	p = append(append(p, s.config.prefix...), prefix...)
+
+	err := s.db.dbh.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: p})
+		defer it.Close()
+
+		for it.Rewind(); it.ValidForPrefix(p); it.Next() {
+			item := it.Item()
+			stats.Count++
+			stats.Bytes += uint64(item.KeySize()) - uint64(len(s.config.prefix))
+			if values {
+				stats.Bytes += uint64(item.ValueSize())
+			}
+		}
+
+		return nil
+	})
+
+	return stats, err
+}
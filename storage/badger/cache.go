@@ -2,6 +2,7 @@ package badger
 
 import (
 	"bytes"
+	"colly/storage"
 	"io"
 )
 
@@ -93,3 +94,17 @@ func (s *stgCache) Has(key string) bool {
 func (s *stgCache) Remove(key string) error {
 	return s.s.DropPrefix([]byte(key))
 }
+
+// ------------------------------------------------------------------------
+
+// RemovePrefix deletes the stored cached items with keys starting with the prefix.
+func (s *stgCache) RemovePrefix(prefix string) error {
+	return s.s.DropPrefix([]byte(prefix))
+}
+
+// ------------------------------------------------------------------------
+
+// CountPrefix returns the number and the size of the stored cached items with keys starting with the prefix.
+func (s *stgCache) CountPrefix(prefix string) (storage.PrefixStats, error) {
+	return s.s.CountPrefix(prefix, true)
+}
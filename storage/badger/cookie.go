@@ -78,3 +78,10 @@ func (s *stgCookie) Get(key string) (io.Reader, error) {
 func (s *stgCookie) Remove(key string) error {
 	return s.s.DropPrefix([]byte(key))
 }
+
+// ------------------------------------------------------------------------
+
+// RemovePrefix deletes the stored cookies with keys starting with the prefix.
+func (s *stgCookie) RemovePrefix(prefix string) error {
+	return s.s.DropPrefix([]byte(prefix))
+}
@@ -1,6 +1,7 @@
 package badger
 
 import (
+	"colly/storage"
 	"encoding/binary"
 )
 
@@ -101,3 +102,18 @@ func uintToBytes(i uint) []byte {
 func bytesToUint(b []byte) uint {
 	return uint(binary.BigEndian.Uint64(b))
 }
+
+// ------------------------------------------------------------------------
+
+// RemovePrefix deletes the stored visits with keys starting with the prefix.
+func (s *stgVisit) RemovePrefix(prefix string) error {
+	return s.s.DropPrefix([]byte(prefix))
+}
+
+// ------------------------------------------------------------------------
+
+// CountPrefix returns the number and the size of the stored visits with keys starting with the prefix.
+// The visit counters are not included in the size.
+func (s *stgVisit) CountPrefix(prefix string) (storage.PrefixStats, error) {
+	return s.s.CountPrefix(prefix, false)
+}
@@ -0,0 +1,176 @@
+package filesys
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ------------------------------------------------------------------------
+
+// AtomicFile is a file written to a temporary file in the directory of its path and moved to the path
+// by Commit, so a crash never leaves a half-written file at the path. The earlier file at the path,
+// if any, is kept until the commit. The temporary files left by crashes are removed by RecoverTempFiles.
+// The commit flushes the file and its directory to the disk, which costs two fsync calls per file.
+type AtomicFile struct {
+	file   *os.File // temporary file
+	path   string   // target path
+	done   bool     // committed or aborted
+	noSync bool     // skip the flushes to the disk, see SkipSync
+}
+
+// ------------------------------------------------------------------------
+
+// TEMP_SUFFIX is the file name suffix of the temporary files of the atomic writes.
+const TEMP_SUFFIX = ".tmp~"
+
+// ------------------------------------------------------------------------
+
+// CreateAtomic returns a pointer to a newly created atomic file of the path with the file permission.
+// The directory of the path must exist.
+func CreateAtomic(path string, perm fs.FileMode) (*AtomicFile, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*"+TEMP_SUFFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := file.Chmod(perm); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	return &AtomicFile{
+		file: file,
+		path: path,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Name returns the target path of the file.
+func (f *AtomicFile) Name() string {
+	return f.path
+}
+
+// SkipSync disables the flushes to the disk on Commit. The commit is still atomic
+// if the process crashes, but the file may be lost or empty after a power failure.
+func (f *AtomicFile) SkipSync() {
+	f.noSync = true
+}
+
+// Write writes to the temporary file.
+func (f *AtomicFile) Write(p []byte) (int, error) {
+	if f.done {
+		return 0, os.ErrClosed
+	}
+
+	return f.file.Write(p)
+}
+
+// Commit flushes the temporary file to the disk and moves it to the target path.
+// The temporary file is removed if the commit fails.
+func (f *AtomicFile) Commit() error {
+	if f.done {
+		return os.ErrClosed
+	}
+	f.done = true
+
+	var err error
+	if !f.noSync {
+		err = f.file.Sync()
+	}
+	if cerr := f.file.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.file.Name(), f.path)
+	}
+	if err != nil {
+		os.Remove(f.file.Name())
+		return err
+	}
+
+	// The rename is durable when the directory entry is flushed, not all platforms support it
+	if !f.noSync {
+		syncDir(filepath.Dir(f.path))
+	}
+
+	return nil
+}
+
+// Abort removes the temporary file and keeps the earlier file at the target path.
+// It does nothing after Commit, so it can be deferred.
+func (f *AtomicFile) Abort() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+
+	f.file.Close()
+
+	return os.Remove(f.file.Name())
+}
+
+// ------------------------------------------------------------------------
+
+// WriteAtomic writes the file of the path with the function atomically, see AtomicFile.
+// The file is not changed if the function fails.
+func WriteAtomic(path string, perm fs.FileMode, fn func(w io.Writer) error) error {
+	f, err := CreateAtomic(path, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+
+	if err := fn(f); err != nil {
+		return err
+	}
+
+	return f.Commit()
+}
+
+// WriteFileAtomic writes the data to the file of the path atomically, see AtomicFile.
+func WriteFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	return WriteAtomic(path, perm, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// ------------------------------------------------------------------------
+
+// RecoverTempFiles removes the temporary files of the atomic writes interrupted by a crash
+// from the directory and its subdirectories, and returns their paths. It walks the whole tree,
+// so call it once on startup, before any process writes to the directory: the temporary files
+// of the writes in progress are removed too.
+func RecoverTempFiles(dir string) ([]string, error) {
+	var removed []string
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), TEMP_SUFFIX) {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		removed = append(removed, path)
+
+		return nil
+	})
+
+	return removed, err
+}
+
+// syncDir flushes the entries of the directory to the disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
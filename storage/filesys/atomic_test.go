@@ -0,0 +1,132 @@
+package filesys
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestWriteAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	if err := WriteFileAtomic(path, []byte("first"), FILE_PERM); err != nil {
+		t.Fatalf("WriteFileAtomic() error = %v", err)
+	}
+
+	// A failed write keeps the earlier file
+	errFailed := errors.New("failed")
+	err := WriteAtomic(path, FILE_PERM, func(w io.Writer) error {
+		w.Write([]byte("half"))
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Errorf("WriteAtomic() error = %v, want %v", err, errFailed)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "first" {
+		t.Errorf("file after a failed write = %q, want first", data)
+	}
+
+	if err := WriteFileAtomic(path, []byte("second"), FILE_PERM); err != nil {
+		t.Fatalf("WriteFileAtomic() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "second" {
+		t.Errorf("file after a write = %q, want second", data)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory has %d entries, want 1 without temporary files", len(entries))
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestAtomicFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "item")
+	if err := os.WriteFile(path, []byte("old"), FILE_PERM); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := CreateAtomic(path, FILE_PERM)
+	if err != nil {
+		t.Fatalf("CreateAtomic() error = %v", err)
+	}
+	f.Write([]byte("new"))
+
+	// The target is not changed until the commit
+	if data, _ := os.ReadFile(path); string(data) != "old" {
+		t.Errorf("file before Commit() = %q, want old", data)
+	}
+	if err := f.Abort(); err != nil {
+		t.Errorf("Abort() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "old" {
+		t.Errorf("file after Abort() = %q, want old", data)
+	}
+	if _, err := f.Write([]byte("x")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write() after Abort() error = %v, want %v", err, os.ErrClosed)
+	}
+
+	f, _ = CreateAtomic(path, FILE_PERM)
+	f.SkipSync()
+	f.Write([]byte("new"))
+	if err := f.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if err := f.Abort(); err != nil {
+		t.Errorf("Abort() after Commit() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("file after Commit() = %q, want new", data)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestRecoverTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "ab")
+	os.MkdirAll(sub, DIR_PERM)
+
+	// The temporary files of the interrupted writes are left behind
+	for _, path := range []string{filepath.Join(dir, "state.json"), filepath.Join(sub, "abcd")} {
+		f, err := CreateAtomic(path, FILE_PERM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte("half"))
+		f.file.Close()
+	}
+	os.WriteFile(filepath.Join(sub, "abce"), []byte("item"), FILE_PERM)
+
+	removed, err := RecoverTempFiles(dir)
+	if err != nil {
+		t.Fatalf("RecoverTempFiles() error = %v", err)
+	}
+	if len(removed) != 2 {
+		t.Fatalf("RecoverTempFiles() = %v, want 2 files", removed)
+	}
+	for _, path := range removed {
+		if !strings.HasSuffix(path, TEMP_SUFFIX) {
+			t.Errorf("RecoverTempFiles() removed %s", path)
+		}
+	}
+
+	var left []string
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			left = append(left, filepath.Base(path))
+		}
+		return err
+	})
+	if want := []string{"abce"}; !reflect.DeepEqual(left, want) {
+		t.Errorf("files after RecoverTempFiles() = %v, want %v", left, want)
+	}
+}
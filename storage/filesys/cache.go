@@ -5,8 +5,10 @@ import (
 	"colly/storage"
 	"io"
 	"io/fs"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 )
 
@@ -70,6 +72,18 @@ func NewCacheStorage(path string, dirAndFilePermissions ...fs.FileMode) (*stgCac
 
 // ------------------------------------------------------------------------
 
+// Recover removes the temporary files of the items half-written by a crash, see RecoverTempFiles.
+// It walks the whole cache tree, so it is not done by NewCacheStorage. The temporary files take
+// disk space only, they are never fetched as items.
+func (s *stgCache) Recover() ([]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return RecoverTempFiles(s.path)
+}
+
+// ------------------------------------------------------------------------
+
 // Close closes the filesystem cache storage.
 func (s *stgCache) Close() error {
 	if s.closed {
@@ -113,14 +127,17 @@ func (s *stgCache) Len() (uint, error) {
 
 // ------------------------------------------------------------------------
 
-// Put stores an item in the cache storage.
+// Put stores an item in the cache storage. The item is written atomically without flushing it
+// to the disk: a crash of the process never leaves a half-written item, but the items written
+// shortly before a power failure may be lost, like any other cached response.
 func (s *stgCache) Put(key string, item io.Reader) error {
 	if s.closed {
 		return storage.ErrStorageClosed
 	}
 
-	if len(key) < 4 {
-		return storage.ErrInvalidKey
+	path, err := s.itemPath(key)
+	if err != nil {
+		return err
 	}
 
 	data, err := io.ReadAll(item)
@@ -131,32 +148,25 @@ func (s *stgCache) Put(key string, item io.Reader) error {
 		return nil
 	}
 
-	key = SanitizeFileName(key)
-	dir := filepath.Join(s.path, key[:2])
-
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
-	if err := os.MkdirAll(dir, s.dirPerm); err != nil {
+	if err := os.MkdirAll(filepath.Dir(path), s.dirPerm); err != nil {
 		return err
 	}
 
-	path := filepath.Join(dir, key)
-	file, err := os.Create(path + "~")
+	f, err := CreateAtomic(path, s.filePerm)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	defer f.Abort()
 
-	if file.Chmod(s.filePerm); err != nil {
+	f.SkipSync()
+	if _, err := f.Write(data); err != nil {
 		return err
 	}
 
-	if _, err := file.Write(data); err != nil {
-		return err
-	}
-
-	return os.Rename(path+"~", path)
+	return f.Commit()
 }
 
 // ------------------------------------------------------------------------
@@ -167,13 +177,11 @@ func (s *stgCache) Fetch(key string) (io.Reader, error) {
 		return nil, storage.ErrStorageClosed
 	}
 
-	if len(key) < 4 {
-		return nil, storage.ErrInvalidKey
+	path, err := s.itemPath(key)
+	if err != nil {
+		return nil, err
 	}
 
-	key = SanitizeFileName(key)
-	path := filepath.Join(s.path, key[:2], key)
-
 	s.lock.RLock()
 	data, err := os.ReadFile(path)
 	s.lock.RUnlock()
@@ -193,12 +201,11 @@ func (s *stgCache) Fetch(key string) (io.Reader, error) {
 
 // Has returns true if the key exists in the storage.
 func (s *stgCache) Has(key string) bool {
-	if len(key) < 4 {
+	path, err := s.itemPath(key)
+	if err != nil {
 		return false
 	}
 
-	path := filepath.Join(s.path, key[:2], key)
-
 	s.lock.RLock()
 	info, err := os.Stat(path)
 	s.lock.RUnlock()
@@ -210,14 +217,161 @@ func (s *stgCache) Has(key string) bool {
 
 // Remove deletes a stored item by key.
 func (s *stgCache) Remove(key string) error {
-	if len(key) < 4 {
-		return storage.ErrInvalidKey
+	path, err := s.itemPath(key)
+	if err != nil {
+		return err
 	}
 
-	path := filepath.Join(s.path, key[:2], key)
-
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
 	return os.Remove(path)
 }
+
+// ------------------------------------------------------------------------
+
+// RemovePrefix removes all items with keys starting with the prefix, see walkPrefix.
+func (s *stgCache) RemovePrefix(prefix string) error {
+	if s.closed {
+		return storage.ErrStorageClosed
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.walkPrefix(prefix, func(path string, _ fs.DirEntry) error {
+		return os.RemoveAll(path)
+	})
+}
+
+// ------------------------------------------------------------------------
+
+// CountPrefix returns the number and the size of the items with keys starting with the prefix, see walkPrefix.
+// The size of the keys is counted by the file names.
+func (s *stgCache) CountPrefix(prefix string) (storage.PrefixStats, error) {
+	var stats storage.PrefixStats
+
+	if s.closed {
+		return stats, storage.ErrStorageClosed
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	err := s.walkPrefix(prefix, func(path string, d fs.DirEntry) error {
+		return filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || strings.HasSuffix(path, TEMP_SUFFIX) {
+				return err
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			stats.Count++
+			stats.Bytes += uint64(len(d.Name())) + uint64(info.Size())
+
+			return nil
+		})
+	})
+
+	return stats, err
+}
+
+// ------------------------------------------------------------------------
+
+// itemPath returns the file path of an item. The keys scoped by a domain or a namespace,
+// see storage.DomainKey and storage.NamespacePrefix, are stored in the directory of their scope,
+// so the items of the scope can be found without the original keys, the file names are sanitized.
+func (s *stgCache) itemPath(key string) (string, error) {
+	dir := s.path
+	if i := scopeLen(key); i > 0 {
+		dir = filepath.Join(dir, scopeDir(key[:i]))
+		key = key[i:]
+	}
+
+	if len(key) < 4 {
+		return "", storage.ErrInvalidKey
+	}
+
+	name := SanitizeFileName(key)
+
+	return filepath.Join(dir, name[:2], name), nil
+}
+
+// walkPrefix calls the function for every scope directory or item file holding the items with keys
+// starting with the prefix. The scope of the keys is matched exactly, the rest of the keys is matched
+// by the file names, so only the prefixes made of letters and digits match it reliably.
+func (s *stgCache) walkPrefix(prefix string, fn func(path string, d fs.DirEntry) error) error {
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		path := filepath.Join(s.path, e.Name())
+
+		rest := prefix
+		if scope, ok := dirScope(e.Name()); ok {
+			if strings.HasPrefix(scope, prefix) {
+				if err := fn(path, e); err != nil {
+					return err
+				}
+				continue
+			}
+			if !strings.HasPrefix(prefix, scope) {
+				continue
+			}
+			rest = prefix[len(scope):]
+		} else if scopeLen(prefix) > 0 {
+			continue
+		}
+
+		if err := walkFiles(path, rest, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkFiles calls the function for every item file with name starting with the prefix
+// in the shard directories of a scope, or in the unscoped shard directory.
+func walkFiles(dir string, prefix string, fn func(path string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasPrefix(d.Name(), prefix) {
+			return err
+		}
+
+		return fn(path, d)
+	})
+}
+
+// ------------------------------------------------------------------------
+
+// scopeLen returns the length of the scope of a key, the part up to the last domain or namespace separator,
+// or 0 if the key is not scoped.
+func scopeLen(key string) int {
+	return strings.LastIndexAny(key, storage.DOMAIN_SEPARATOR+storage.NAMESPACE_SEPARATOR) + 1
+}
+
+// scopeDir returns the directory name of a key scope. The names end with the domain separator,
+// which never appears in the sanitized names of the shard directories.
+func scopeDir(scope string) string {
+	return url.QueryEscape(scope) + storage.DOMAIN_SEPARATOR
+}
+
+// dirScope returns the key scope of a directory name, and false if the directory is a shard directory.
+func dirScope(name string) (string, bool) {
+	name, ok := strings.CutSuffix(name, storage.DOMAIN_SEPARATOR)
+	if !ok {
+		return "", false
+	}
+
+	scope, err := url.QueryUnescape(name)
+
+	return scope, err == nil
+}
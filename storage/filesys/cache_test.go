@@ -0,0 +1,76 @@
+package filesys
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stgCache_RemovePrefix(t *testing.T) {
+	s, err := NewCacheStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{
+		"example.com#0123abcd",
+		"example.com#4567abcd",
+		"example.community#0123abcd",
+		"tenant|example.com#0123abcd",
+		"0123abcd",
+	}
+	for _, key := range keys {
+		if err := s.Put(key, strings.NewReader("data")); err != nil {
+			t.Fatalf("Put(%q) error = %v", key, err)
+		}
+		if !s.Has(key) {
+			t.Errorf("Has(%q) = false after Put", key)
+		}
+	}
+
+	tests := []struct {
+		prefix string
+		want   uint
+	}{
+		{"example.com#", 2},
+		{"example.com#01", 1},
+		{"example.com", 3},
+		{"tenant|", 1},
+		{"01", 1},
+		{"", 5},
+	}
+	for _, tt := range tests {
+		stats, err := s.CountPrefix(tt.prefix)
+		if err != nil || stats.Count != tt.want {
+			t.Errorf("CountPrefix(%q) = %+v, error = %v, want %d items", tt.prefix, stats, err, tt.want)
+		}
+	}
+
+	if err := s.RemovePrefix("example.com#"); err != nil {
+		t.Fatalf("RemovePrefix() error = %v", err)
+	}
+	for _, key := range keys {
+		want := !strings.HasPrefix(key, "example.com#")
+		rdr, err := s.Fetch(key)
+		if err != nil {
+			t.Fatalf("Fetch(%q) error = %v", key, err)
+		}
+		if (rdr != nil) != want {
+			t.Errorf("Fetch(%q) found = %v after RemovePrefix, want %v", key, rdr != nil, want)
+		}
+		if rdr != nil {
+			if data, _ := io.ReadAll(rdr); string(data) != "data" {
+				t.Errorf("Fetch(%q) = %q, want data", key, data)
+			}
+		}
+	}
+
+	if err := s.RemovePrefix("tenant|"); err != nil {
+		t.Fatalf("RemovePrefix() error = %v", err)
+	}
+	if stats, _ := s.CountPrefix(""); stats.Count != 2 {
+		t.Errorf("CountPrefix() after RemovePrefix = %d items, want 2", stats.Count)
+	}
+}
@@ -0,0 +1,141 @@
+package mem
+
+import (
+	"colly/storage"
+	"sort"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// In-memory alias storage
+type stgAlias struct {
+	lock    *sync.RWMutex
+	aliases map[string]string // canonical keys by the raw URLs
+}
+
+// ------------------------------------------------------------------------
+
+// NewAliasStorage returns a pointer to a newly created in-memory alias storage.
+func NewAliasStorage() *stgAlias {
+	return &stgAlias{
+		lock:    &sync.RWMutex{},
+		aliases: map[string]string{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the in-memory alias storage.
+func (s *stgAlias) Close() error {
+	if s.aliases == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.aliases = nil
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all entries from the in-memory alias storage.
+func (s *stgAlias) Clear() error {
+	if s.aliases == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.aliases = map[string]string{}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// AddAlias maps a raw URL to a canonical key.
+func (s *stgAlias) AddAlias(key string, alias string) error {
+	if s.aliases == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.aliases[alias] = key
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Canonical returns the canonical key of a raw URL.
+func (s *stgAlias) Canonical(alias string) (string, error) {
+	if s.aliases == nil {
+		return "", storage.ErrStorageClosed
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.aliases[alias], nil
+}
+
+// ------------------------------------------------------------------------
+
+// Aliases returns the sorted raw URLs mapped to the canonical key.
+func (s *stgAlias) Aliases(key string) ([]string, error) {
+	if s.aliases == nil {
+		return nil, storage.ErrStorageClosed
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var aliases []string
+	for alias, k := range s.aliases {
+		if k == key {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+
+	return aliases, nil
+}
+
+// ------------------------------------------------------------------------
+
+// ExportAliases calls the function for every canonical key with its sorted raw URLs.
+func (s *stgAlias) ExportAliases(fn func(key string, aliases []string) error) error {
+	if s.aliases == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.lock.RLock()
+	groups := map[string][]string{}
+	for alias, key := range s.aliases {
+		groups[key] = append(groups[key], alias)
+	}
+	s.lock.RUnlock()
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		aliases := groups[key]
+		sort.Strings(aliases)
+		if err := fn(key, aliases); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package mem
+
+import (
+	"colly/storage"
+	"reflect"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stgAlias(t *testing.T) {
+	s := NewAliasStorage()
+
+	s.AddAlias("https://example.com/a", "https://example.com/a?utm_source=x")
+	s.AddAlias("https://example.com/a", "https://EXAMPLE.com/a")
+	s.AddAlias("https://example.com/b", "https://example.com/b?ref=1")
+	s.AddAlias("https://example.com/b", "https://example.com/a?ref=moved")
+	s.AddAlias("https://example.com/a", "https://example.com/a?ref=moved")
+
+	if got, _ := s.Canonical("https://EXAMPLE.com/a"); got != "https://example.com/a" {
+		t.Errorf("Canonical() = %q, want https://example.com/a", got)
+	}
+	if got, _ := s.Canonical("https://example.com/c"); got != "" {
+		t.Errorf("Canonical() of an unknown URL = %q, want blank", got)
+	}
+
+	want := []string{"https://EXAMPLE.com/a", "https://example.com/a?ref=moved", "https://example.com/a?utm_source=x"}
+	if got, _ := s.Aliases("https://example.com/a"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Aliases() = %v, want %v", got, want)
+	}
+
+	var keys []string
+	s.ExportAliases(func(key string, aliases []string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if want := []string{"https://example.com/a", "https://example.com/b"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("ExportAliases() keys = %v, want %v", keys, want)
+	}
+
+	s.Close()
+	if err := s.AddAlias("k", "a"); err != storage.ErrStorageClosed {
+		t.Errorf("AddAlias() on closed storage error = %v, want %v", err, storage.ErrStorageClosed)
+	}
+}
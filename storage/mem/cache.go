@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"colly/storage"
 	"io"
+	"strings"
 	"sync"
 )
 
@@ -133,3 +134,46 @@ func (s *stgCache) Remove(key string) error {
 
 	return nil
 }
+
+// ------------------------------------------------------------------------
+
+// RemovePrefix deletes all stored items with keys starting with the prefix.
+func (s *stgCache) RemovePrefix(prefix string) error {
+	if s.cache == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for key := range s.cache {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.cache, key)
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// CountPrefix returns the number and the size of the stored items with keys starting with the prefix.
+func (s *stgCache) CountPrefix(prefix string) (storage.PrefixStats, error) {
+	var stats storage.PrefixStats
+
+	if s.cache == nil {
+		return stats, storage.ErrStorageClosed
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for key, data := range s.cache {
+		if strings.HasPrefix(key, prefix) {
+			stats.Count++
+			stats.Bytes += uint64(len(key) + len(data))
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,212 @@
+package mem
+
+import (
+	"bytes"
+	"colly/storage"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// In-memory cookie storage
+type stgCookie struct {
+	lock    *sync.RWMutex
+	cookies map[string][]byte
+}
+
+// ------------------------------------------------------------------------
+
+// NewCookieStorage returns a pointer to a newly created in-memory cookie storage.
+func NewCookieStorage() *stgCookie {
+	return &stgCookie{
+		lock:    &sync.RWMutex{},
+		cookies: map[string][]byte{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the in-memory cookie storage.
+func (s *stgCookie) Close() error {
+	if s.cookies == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.lock.Lock()
+	s.cookies = nil
+	s.lock.Unlock()
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all entries from the in-memory cookie storage.
+func (s *stgCookie) Clear() error {
+	if s.cookies == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.lock.Lock()
+	s.cookies = map[string][]byte{}
+	s.lock.Unlock()
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Len returns the number of hosts in the in-memory cookie storage.
+func (s *stgCookie) Len() (uint, error) {
+	if s.cookies == nil {
+		return 0, storage.ErrStorageClosed
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return uint(len(s.cookies)), nil
+}
+
+// ------------------------------------------------------------------------
+
+// Set stores cookies for a given host.
+func (s *stgCookie) Set(key string, cookies io.Reader) error {
+	if s.cookies == nil {
+		return storage.ErrStorageClosed
+	}
+
+	data, err := io.ReadAll(cookies)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.cookies[key] = data
+	s.lock.Unlock()
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Get retrieves stored cookies for a given host.
+func (s *stgCookie) Get(key string) (io.Reader, error) {
+	if s.cookies == nil {
+		return nil, storage.ErrStorageClosed
+	}
+
+	s.lock.RLock()
+	data := s.cookies[key]
+	s.lock.RUnlock()
+
+	return bytes.NewReader(data), nil
+}
+
+// ------------------------------------------------------------------------
+
+// GetVersion retrieves stored cookies for a given host with their version.
+func (s *stgCookie) GetVersion(key string) (io.Reader, string, error) {
+	if s.cookies == nil {
+		return nil, "", storage.ErrStorageClosed
+	}
+
+	s.lock.RLock()
+	data := s.cookies[key]
+	s.lock.RUnlock()
+
+	return bytes.NewReader(data), storage.ContentVersion(data), nil
+}
+
+// ------------------------------------------------------------------------
+
+// CompareAndSet stores cookies for a given host, or deletes them if the cookies are nil,
+// if they were not modified since the version was retrieved.
+func (s *stgCookie) CompareAndSet(key string, cookies io.Reader, version string) error {
+	if s.cookies == nil {
+		return storage.ErrStorageClosed
+	}
+
+	var data []byte
+	if cookies != nil {
+		var err error
+		if data, err = io.ReadAll(cookies); err != nil {
+			return err
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if storage.ContentVersion(s.cookies[key]) != version {
+		return storage.ErrVersionConflict
+	}
+
+	if cookies == nil {
+		delete(s.cookies, key)
+	} else {
+		s.cookies[key] = data
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Remove deletes stored cookies for a given host.
+func (s *stgCookie) Remove(key string) error {
+	s.lock.Lock()
+	delete(s.cookies, key)
+	s.lock.Unlock()
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// RemovePrefix deletes all stored items with keys starting with the prefix.
+func (s *stgCookie) RemovePrefix(prefix string) error {
+	if s.cookies == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for key := range s.cookies {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.cookies, key)
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// ExportPrefix calls the function for every stored host with key starting with the prefix.
+// The function is called after the entries were copied, so it may use the storage.
+func (s *stgCookie) ExportPrefix(prefix string, fn func(key string, data []byte) error) error {
+	if s.cookies == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.lock.RLock()
+	cookies := make(map[string][]byte, len(s.cookies))
+	for key, data := range s.cookies {
+		if strings.HasPrefix(key, prefix) {
+			cookies[key] = data
+		}
+	}
+	s.lock.RUnlock()
+
+	for key, data := range cookies {
+		if err := fn(key, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package mem
+
+import (
+	"colly/storage"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stgCookie_CompareAndSet(t *testing.T) {
+	s := NewCookieStorage()
+
+	_, v0, err := s.GetVersion("example.com")
+	if err != nil || v0 != "" {
+		t.Fatalf("GetVersion() of a missing key = %q, %v, want blank version", v0, err)
+	}
+
+	if err := s.CompareAndSet("example.com", strings.NewReader("a"), v0); err != nil {
+		t.Fatalf("CompareAndSet() error = %v", err)
+	}
+
+	// A writer with the outdated version loses
+	if err := s.CompareAndSet("example.com", strings.NewReader("b"), v0); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Errorf("CompareAndSet() with a stale version error = %v, want %v", err, storage.ErrVersionConflict)
+	}
+
+	rdr, v1, err := s.GetVersion("example.com")
+	if err != nil || v1 == "" {
+		t.Fatalf("GetVersion() = %q, %v", v1, err)
+	}
+	if b, _ := io.ReadAll(rdr); string(b) != "a" {
+		t.Errorf("GetVersion() data = %q, want %q", b, "a")
+	}
+
+	if err := s.CompareAndSet("example.com", nil, v1); err != nil {
+		t.Fatalf("CompareAndSet() removal error = %v", err)
+	}
+	if _, v, _ := s.GetVersion("example.com"); v != "" {
+		t.Errorf("the entry was not removed, version = %q", v)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"colly/storage"
 	"io"
+	"strings"
 	"sync"
 )
 
@@ -13,6 +14,7 @@ import (
 // stgMultiFIFO is an in-memory multi-thread FIFO storage
 type stgMultiFIFO struct {
 	threads  map[uint32]*stgFIFO
+	scopes   map[string]*stgMultiFIFO // threads of the scopes, created on demand
 	capacity uint
 	lock     *sync.RWMutex
 }
@@ -59,6 +61,7 @@ func (s *stgMultiFIFO) Clear(ids ...uint32) error {
 
 	if len(ids) == 0 {
 		s.threads = map[uint32]*stgFIFO{}
+		s.scopes = nil
 
 		return nil
 	}
@@ -147,6 +150,109 @@ func (s *stgMultiFIFO) Peek(id uint32) (io.Reader, error) {
 
 // ------------------------------------------------------------------------
 
+// ScopedClear removes all entries from a number of threads of a scope,
+// or removes all threads of the scope if no ID was given.
+func (s *stgMultiFIFO) ScopedClear(scope string, ids ...uint32) error {
+	if scope == "" {
+		return storage.ErrBlankKey
+	}
+
+	if len(ids) == 0 {
+		s.lock.Lock()
+		delete(s.scopes, scope)
+		s.lock.Unlock()
+
+		return nil
+	}
+
+	if t := s.scope(scope, false); t != nil {
+		return t.Clear(ids...)
+	}
+
+	return nil
+}
+
+// ScopedLen returns the number of items in a thread of a scope.
+func (s *stgMultiFIFO) ScopedLen(scope string, id uint32) (uint, error) {
+	if t := s.scope(scope, false); t != nil {
+		return t.Len(id)
+	}
+
+	return 0, nil
+}
+
+// ScopedPush appends a value at the end/tail of a thread of a scope.
+func (s *stgMultiFIFO) ScopedPush(scope string, id uint32, item io.Reader) error {
+	if scope == "" {
+		return storage.ErrBlankKey
+	}
+
+	return s.scope(scope, true).Push(id, item)
+}
+
+// ScopedPop removes and returns the oldest value in a thread of a scope.
+func (s *stgMultiFIFO) ScopedPop(scope string, id uint32) (io.Reader, error) {
+	if t := s.scope(scope, false); t != nil {
+		return t.Pop(id)
+	}
+
+	return nil, storage.ErrStorageEmpty
+}
+
+// ScopedExportQueue calls the function for every item of a thread of a scope, see ExportQueue.
+func (s *stgMultiFIFO) ScopedExportQueue(scope string, id uint32, fn func(priority float64, data []byte) error) error {
+	if t := s.scope(scope, false); t != nil {
+		return t.ExportQueue(id, fn)
+	}
+
+	return nil
+}
+
+// RemovePrefix removes the threads of the scopes starting with the prefix,
+// or all threads if the prefix is blank.
+func (s *stgMultiFIFO) RemovePrefix(prefix string) error {
+	if prefix == "" {
+		return s.Clear()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for scope := range s.scopes {
+		if strings.HasPrefix(scope, prefix) {
+			delete(s.scopes, scope)
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// The scope method returns the threads of a scope, or nil if the scope has no threads and create is false.
+func (s *stgMultiFIFO) scope(scope string, create bool) *stgMultiFIFO {
+	if !create {
+		s.lock.RLock()
+		defer s.lock.RUnlock()
+
+		return s.scopes[scope]
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	t, present := s.scopes[scope]
+	if !present {
+		if s.scopes == nil {
+			s.scopes = map[string]*stgMultiFIFO{}
+		}
+		t = NewFIFOStorage(s.capacity)
+		s.scopes[scope] = t
+	}
+
+	return t
+}
+
 // The addThread method adds a new thread if it doesn't exist.
 func (s *stgMultiFIFO) addThread(id uint32) {
 	s.lock.Lock()
@@ -240,3 +346,37 @@ func (s *stgFIFO) peek() (io.Reader, error) {
 
 	return bytes.NewReader(s.head.data), nil
 }
+
+// ------------------------------------------------------------------------
+
+// ExportQueue calls the function for every item of a thread in the FIFO order, with zero priority.
+// The function is called after the items were copied, so it may use the storage.
+func (s *stgMultiFIFO) ExportQueue(id uint32, fn func(priority float64, data []byte) error) error {
+	s.lock.RLock()
+	t, present := s.threads[id]
+	s.lock.RUnlock()
+	if !present {
+		return nil
+	}
+
+	for _, data := range t.items() {
+		if err := fn(0, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// The items method returns the values of the thread in the FIFO order.
+func (s *stgFIFO) items() [][]byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	items := make([][]byte, 0, s.count)
+	for node := s.head; node != nil; node = node.next {
+		items = append(items, node.data)
+	}
+
+	return items
+}
@@ -0,0 +1,137 @@
+package mem
+
+import (
+	"colly/storage"
+	"sort"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// In-memory graph storage
+type stgGraph struct {
+	lock  *sync.RWMutex
+	edges []*storage.GraphEdge
+	nodes map[string]*storage.GraphNode
+}
+
+// ------------------------------------------------------------------------
+
+// NewGraphStorage returns a pointer to a newly created in-memory graph storage.
+func NewGraphStorage() *stgGraph {
+	return &stgGraph{
+		lock:  &sync.RWMutex{},
+		nodes: map[string]*storage.GraphNode{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the in-memory graph storage.
+func (s *stgGraph) Close() error {
+	if s.nodes == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.edges = nil
+	s.nodes = nil
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all edges and nodes from the in-memory graph storage.
+func (s *stgGraph) Clear() error {
+	if s.nodes == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.edges = nil
+	s.nodes = map[string]*storage.GraphNode{}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// AddEdge stores a link discovered on a page.
+func (s *stgGraph) AddEdge(edge *storage.GraphEdge) error {
+	if s.nodes == nil {
+		return storage.ErrStorageClosed
+	}
+
+	e := *edge
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.edges = append(s.edges, &e)
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// AddNode stores the outcome of a request, replacing the earlier outcome of the URL.
+func (s *stgGraph) AddNode(node *storage.GraphNode) error {
+	if s.nodes == nil {
+		return storage.ErrStorageClosed
+	}
+
+	n := *node
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.nodes[n.URL] = &n
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Edges returns the copies of the stored edges in their recording order.
+func (s *stgGraph) Edges() ([]*storage.GraphEdge, error) {
+	if s.nodes == nil {
+		return nil, storage.ErrStorageClosed
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	edges := make([]*storage.GraphEdge, len(s.edges))
+	for i, e := range s.edges {
+		edge := *e
+		edges[i] = &edge
+	}
+
+	return edges, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Nodes returns the copies of the stored nodes sorted by their URL.
+func (s *stgGraph) Nodes() ([]*storage.GraphNode, error) {
+	if s.nodes == nil {
+		return nil, storage.ErrStorageClosed
+	}
+
+	s.lock.RLock()
+	nodes := make([]*storage.GraphNode, 0, len(s.nodes))
+	for _, n := range s.nodes {
+		node := *n
+		nodes = append(nodes, &node)
+	}
+	s.lock.RUnlock()
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].URL < nodes[j].URL })
+
+	return nodes, nil
+}
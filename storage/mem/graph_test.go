@@ -0,0 +1,60 @@
+package mem
+
+import (
+	"colly/storage"
+	"reflect"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stgGraph(t *testing.T) {
+	s := NewGraphStorage()
+
+	edges := []*storage.GraphEdge{
+		{From: "https://example.com/", To: "https://example.com/b", Depth: 2, Anchor: "B"},
+		{From: "https://example.com/", To: "https://example.com/a", Depth: 2, Anchor: "A"},
+		{From: "https://example.com/", To: "https://example.com/a", Depth: 2, Anchor: "A again"},
+	}
+	for _, e := range edges {
+		if err := s.AddEdge(e); err != nil {
+			t.Fatalf("AddEdge() error = %v", err)
+		}
+	}
+
+	s.AddNode(&storage.GraphNode{URL: "https://example.com/b", Depth: 2, StatusCode: 500, Error: "boom"})
+	s.AddNode(&storage.GraphNode{URL: "https://example.com/b", Depth: 2, StatusCode: 200})
+	s.AddNode(&storage.GraphNode{URL: "https://example.com/a", Depth: 2, StatusCode: 404})
+
+	gotEdges, err := s.Edges()
+	if err != nil {
+		t.Fatalf("Edges() error = %v", err)
+	}
+	if !reflect.DeepEqual(gotEdges, edges) {
+		t.Errorf("Edges() = %v, want %v", gotEdges, edges)
+	}
+	gotEdges[0].Anchor = "modified"
+	if again, _ := s.Edges(); again[0].Anchor != "B" {
+		t.Error("Edges() returned the stored edges")
+	}
+
+	wantNodes := []*storage.GraphNode{
+		{URL: "https://example.com/a", Depth: 2, StatusCode: 404},
+		{URL: "https://example.com/b", Depth: 2, StatusCode: 200},
+	}
+	if got, err := s.Nodes(); err != nil || !reflect.DeepEqual(got, wantNodes) {
+		t.Errorf("Nodes() = %v, %v, want %v", got, err, wantNodes)
+	}
+
+	if err := s.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if got, _ := s.Edges(); len(got) != 0 {
+		t.Errorf("Edges() after Clear() = %v", got)
+	}
+
+	s.Close()
+	if err := s.AddEdge(edges[0]); err != storage.ErrStorageClosed {
+		t.Errorf("AddEdge() after Close() error = %v, want %v", err, storage.ErrStorageClosed)
+	}
+}
@@ -0,0 +1,121 @@
+package mem
+
+import (
+	"colly/storage"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// In-memory crawl lock, shared by the collectors of the same process
+type stgLock struct {
+	lock   *sync.Mutex
+	owners map[string]string
+	expiry map[string]time.Time
+}
+
+// ------------------------------------------------------------------------
+
+// NewLockStorage returns a pointer to a newly created in-memory crawl lock storage.
+func NewLockStorage() *stgLock {
+	return &stgLock{
+		lock:   &sync.Mutex{},
+		owners: map[string]string{},
+		expiry: map[string]time.Time{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the in-memory crawl lock storage.
+func (s *stgLock) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.owners == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.owners = nil
+	s.expiry = nil
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all locks from the in-memory crawl lock storage.
+func (s *stgLock) Clear() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.owners == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.owners = map[string]string{}
+	s.expiry = map[string]time.Time{}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Acquire takes the lock if it is free, expired or held by the owner.
+func (s *stgLock) Acquire(name string, owner string, ttl time.Duration) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.owners == nil {
+		return false, storage.ErrStorageClosed
+	}
+
+	if cur, present := s.owners[name]; present && cur != owner && time.Now().Before(s.expiry[name]) {
+		return false, nil
+	}
+
+	s.owners[name] = owner
+	s.expiry[name] = time.Now().Add(ttl)
+
+	return true, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Renew extends the lock if it is still held by the owner.
+func (s *stgLock) Renew(name string, owner string, ttl time.Duration) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.owners == nil {
+		return false, storage.ErrStorageClosed
+	}
+
+	if s.owners[name] != owner {
+		return false, nil
+	}
+
+	s.expiry[name] = time.Now().Add(ttl)
+
+	return true, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Release frees the lock if it is held by the owner.
+func (s *stgLock) Release(name string, owner string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.owners == nil {
+		return storage.ErrStorageClosed
+	}
+
+	if s.owners[name] == owner {
+		delete(s.owners, name)
+		delete(s.expiry, name)
+	}
+
+	return nil
+}
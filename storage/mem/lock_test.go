@@ -0,0 +1,68 @@
+package mem
+
+import (
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stgLock_Acquire(t *testing.T) {
+	s := NewLockStorage()
+	if ok, _ := s.Acquire("job", "expired", -time.Second); !ok {
+		t.Fatalf("stgLock.Acquire() = false, want true")
+	}
+
+	tests := []struct {
+		name    string
+		lock    string
+		owner   string
+		want    bool
+		wantErr bool
+	}{
+		{"expired lock", "job", "a", true, false},
+		{"held by other", "job", "b", false, false},
+		{"held by owner", "job", "a", true, false},
+		{"other lock", "other", "b", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Acquire(tt.lock, tt.owner, time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("stgLock.Acquire() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("stgLock.Acquire() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_stgLock_RenewRelease(t *testing.T) {
+	s := NewLockStorage()
+	_, _ = s.Acquire("job", "a", time.Minute)
+
+	if ok, _ := s.Renew("job", "b", time.Minute); ok {
+		t.Errorf("stgLock.Renew() by other = true, want false")
+	}
+	if ok, _ := s.Renew("job", "a", time.Minute); !ok {
+		t.Errorf("stgLock.Renew() by owner = false, want true")
+	}
+
+	_ = s.Release("job", "b")
+	if ok, _ := s.Acquire("job", "b", time.Minute); ok {
+		t.Errorf("stgLock.Acquire() after release by other = true, want false")
+	}
+
+	_ = s.Release("job", "a")
+	if ok, _ := s.Acquire("job", "b", time.Minute); !ok {
+		t.Errorf("stgLock.Acquire() after release by owner = false, want true")
+	}
+
+	_ = s.Close()
+	if _, err := s.Acquire("job", "a", time.Minute); err == nil {
+		t.Errorf("stgLock.Acquire() on closed storage error = nil, want error")
+	}
+}
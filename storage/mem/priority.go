@@ -0,0 +1,306 @@
+// In-memory priority queue storage.
+package mem
+
+import (
+	"bytes"
+	"colly/storage"
+	"container/heap"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// stgMultiPriority is an in-memory multi-thread priority queue storage
+type stgMultiPriority struct {
+	threads  map[uint32]*stgPriority
+	scopes   map[string]*stgMultiPriority // threads of the scopes, created on demand
+	capacity uint
+	lock     *sync.Mutex
+}
+
+// stgPriority is a priority queue, the items with the same priority are kept in FIFO order
+type stgPriority struct {
+	items priorityItems
+	seq   uint64
+}
+
+// priorityItem is an item in the priority queue
+type priorityItem struct {
+	data     []byte
+	priority float64
+	seq      uint64
+}
+
+// priorityItems implements the heap.Interface
+type priorityItems []*priorityItem
+
+// ------------------------------------------------------------------------
+
+// NewPriorityStorage returns a pointer to a newly created in-memory priority queue storage.
+// Items with higher priority are popped first.
+func NewPriorityStorage(capacity uint) *stgMultiPriority {
+	return &stgMultiPriority{
+		threads:  map[uint32]*stgPriority{},
+		capacity: capacity,
+		lock:     &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Close method is required to implement the Queue interface.
+func (s *stgMultiPriority) Close() error {
+	return s.Clear()
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all entries from a number of threads of the priority queue storage,
+// or removes all entries from all threads if no ID was given.
+func (s *stgMultiPriority) Clear(ids ...uint32) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(ids) == 0 {
+		s.threads = map[uint32]*stgPriority{}
+		s.scopes = nil
+
+		return nil
+	}
+
+	for _, id := range ids {
+		delete(s.threads, id)
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Capacity returns the maximum number of items that can be stored in a thread.
+func (s *stgMultiPriority) Capacity() uint {
+	return s.capacity
+}
+
+// ------------------------------------------------------------------------
+
+// Len returns the number of items in a thread.
+func (s *stgMultiPriority) Len(id uint32) (uint, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if t, present := s.threads[id]; present {
+		return uint(len(t.items)), nil
+	}
+
+	return 0, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Push adds a value with zero priority.
+func (s *stgMultiPriority) Push(id uint32, item io.Reader) error {
+	return s.PushPriority(id, 0, item)
+}
+
+// ------------------------------------------------------------------------
+
+// PushPriority adds a value with the given priority.
+func (s *stgMultiPriority) PushPriority(id uint32, priority float64, item io.Reader) error {
+	data, err := io.ReadAll(item)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	t, present := s.threads[id]
+	if !present {
+		t = &stgPriority{}
+		s.threads[id] = t
+	}
+
+	if uint(len(t.items)) >= s.capacity {
+		return storage.ErrStorageFull
+	}
+
+	t.seq++
+	heap.Push(&t.items, &priorityItem{
+		data:     data,
+		priority: priority,
+		seq:      t.seq,
+	})
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Pop removes and returns the value with the highest priority.
+func (s *stgMultiPriority) Pop(id uint32) (io.Reader, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	t, present := s.threads[id]
+	if !present || len(t.items) == 0 {
+		return nil, storage.ErrStorageEmpty
+	}
+
+	item := heap.Pop(&t.items).(*priorityItem)
+
+	return bytes.NewReader(item.data), nil
+}
+
+// ------------------------------------------------------------------------
+
+// ExportQueue calls the function for every item of a thread in the pop order, with its priority.
+// The function is called after the items were copied, so it may use the storage.
+func (s *stgMultiPriority) ExportQueue(id uint32, fn func(priority float64, data []byte) error) error {
+	s.lock.Lock()
+	var items priorityItems
+	if t, present := s.threads[id]; present {
+		items = append(items, t.items...)
+	}
+	s.lock.Unlock()
+
+	sort.Sort(items)
+	for _, item := range items {
+		if err := fn(item.priority, item.data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// ScopedClear removes all entries from a number of threads of a scope,
+// or removes all threads of the scope if no ID was given.
+func (s *stgMultiPriority) ScopedClear(scope string, ids ...uint32) error {
+	if scope == "" {
+		return storage.ErrBlankKey
+	}
+
+	if len(ids) == 0 {
+		s.lock.Lock()
+		delete(s.scopes, scope)
+		s.lock.Unlock()
+
+		return nil
+	}
+
+	if t := s.scope(scope, false); t != nil {
+		return t.Clear(ids...)
+	}
+
+	return nil
+}
+
+// ScopedLen returns the number of items in a thread of a scope.
+func (s *stgMultiPriority) ScopedLen(scope string, id uint32) (uint, error) {
+	if t := s.scope(scope, false); t != nil {
+		return t.Len(id)
+	}
+
+	return 0, nil
+}
+
+// ScopedPush adds a value with zero priority to a thread of a scope.
+func (s *stgMultiPriority) ScopedPush(scope string, id uint32, item io.Reader) error {
+	return s.ScopedPushPriority(scope, id, 0, item)
+}
+
+// ScopedPushPriority adds a value with the given priority to a thread of a scope.
+func (s *stgMultiPriority) ScopedPushPriority(scope string, id uint32, priority float64, item io.Reader) error {
+	if scope == "" {
+		return storage.ErrBlankKey
+	}
+
+	return s.scope(scope, true).PushPriority(id, priority, item)
+}
+
+// ScopedPop removes and returns the value with the highest priority in a thread of a scope.
+func (s *stgMultiPriority) ScopedPop(scope string, id uint32) (io.Reader, error) {
+	if t := s.scope(scope, false); t != nil {
+		return t.Pop(id)
+	}
+
+	return nil, storage.ErrStorageEmpty
+}
+
+// ScopedExportQueue calls the function for every item of a thread of a scope, see ExportQueue.
+func (s *stgMultiPriority) ScopedExportQueue(scope string, id uint32, fn func(priority float64, data []byte) error) error {
+	if t := s.scope(scope, false); t != nil {
+		return t.ExportQueue(id, fn)
+	}
+
+	return nil
+}
+
+// RemovePrefix removes the threads of the scopes starting with the prefix,
+// or all threads if the prefix is blank.
+func (s *stgMultiPriority) RemovePrefix(prefix string) error {
+	if prefix == "" {
+		return s.Clear()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for scope := range s.scopes {
+		if strings.HasPrefix(scope, prefix) {
+			delete(s.scopes, scope)
+		}
+	}
+
+	return nil
+}
+
+// scope returns the threads of a scope, or nil if the scope has no threads and create is false.
+func (s *stgMultiPriority) scope(scope string, create bool) *stgMultiPriority {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	t, present := s.scopes[scope]
+	if !present && create {
+		if s.scopes == nil {
+			s.scopes = map[string]*stgMultiPriority{}
+		}
+		t = NewPriorityStorage(s.capacity)
+		s.scopes[scope] = t
+	}
+
+	return t
+}
+
+// ------------------------------------------------------------------------
+
+// Len, Less, Swap, Push and Pop implement the heap.Interface.
+func (pi priorityItems) Len() int { return len(pi) }
+
+func (pi priorityItems) Less(i, j int) bool {
+	if pi[i].priority != pi[j].priority {
+		return pi[i].priority > pi[j].priority
+	}
+
+	return pi[i].seq < pi[j].seq
+}
+
+func (pi priorityItems) Swap(i, j int) { pi[i], pi[j] = pi[j], pi[i] }
+
+func (pi *priorityItems) Push(x any) { *pi = append(*pi, x.(*priorityItem)) }
+
+func (pi *priorityItems) Pop() any {
+	old := *pi
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*pi = old[:n-1]
+
+	return item
+}
@@ -0,0 +1,101 @@
+package mem
+
+import (
+	"colly/storage"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stgMultiPriority_Order(t *testing.T) {
+	s := NewPriorityStorage(10)
+
+	items := []struct {
+		data     string
+		priority float64
+	}{
+		{"low", -1},
+		{"first", 0},
+		{"high", 5},
+		{"second", 0},
+		{"higher", 7.5},
+	}
+	for _, item := range items {
+		if err := s.PushPriority(1, item.priority, strings.NewReader(item.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, _ := s.Len(1); n != uint(len(items)) {
+		t.Errorf("Len() = %d, want %d", n, len(items))
+	}
+
+	for _, want := range []string{"higher", "high", "first", "second", "low"} {
+		rdr, err := s.Pop(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := io.ReadAll(rdr); string(got) != want {
+			t.Errorf("Pop() = %q, want %q", got, want)
+		}
+	}
+
+	if _, err := s.Pop(1); !errors.Is(err, storage.ErrStorageEmpty) {
+		t.Errorf("Pop() error = %v, want %v", err, storage.ErrStorageEmpty)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_stgMultiPriority_Capacity(t *testing.T) {
+	s := NewPriorityStorage(1)
+
+	if err := s.Push(1, strings.NewReader("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Push(1, strings.NewReader("b")); !errors.Is(err, storage.ErrStorageFull) {
+		t.Errorf("Push() error = %v, want %v", err, storage.ErrStorageFull)
+	}
+	if err := s.Push(2, strings.NewReader("c")); err != nil {
+		t.Errorf("Push() to another thread error = %v", err)
+	}
+
+	s.Clear(1)
+	if n, _ := s.Len(1); n != 0 {
+		t.Errorf("Len() after Clear = %d, want 0", n)
+	}
+	if n, _ := s.Len(2); n != 1 {
+		t.Errorf("Len() of other thread after Clear = %d, want 1", n)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_stgMultiPriority_ExportQueue(t *testing.T) {
+	s := NewPriorityStorage(10)
+	s.PushPriority(1, -1, strings.NewReader("low"))
+	s.PushPriority(1, 2, strings.NewReader("high"))
+	s.PushPriority(1, -1, strings.NewReader("lower"))
+	s.PushPriority(2, 9, strings.NewReader("other"))
+
+	var got []string
+	err := s.ExportQueue(1, func(priority float64, data []byte) error {
+		got = append(got, fmt.Sprintf("%s:%g", data, priority))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportQueue() error = %v", err)
+	}
+	if want := []string{"high:2", "low:-1", "lower:-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ExportQueue() = %q, want %q", got, want)
+	}
+
+	if n, _ := s.Len(1); n != 3 {
+		t.Errorf("Len() after ExportQueue() = %d, want 3", n)
+	}
+}
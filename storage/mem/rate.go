@@ -0,0 +1,79 @@
+package mem
+
+import (
+	"colly/storage"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// In-memory rate limit storage, shared by the collectors of the same process
+type stgRate struct {
+	lock *sync.Mutex
+	next map[string]time.Time // earliest start of the next request by key
+}
+
+// ------------------------------------------------------------------------
+
+// NewRateStorage returns a pointer to a newly created in-memory rate limit storage.
+func NewRateStorage() *stgRate {
+	return &stgRate{
+		lock: &sync.Mutex{},
+		next: map[string]time.Time{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the in-memory rate limit storage.
+func (s *stgRate) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.next == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.next = nil
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all reservations from the in-memory rate limit storage.
+func (s *stgRate) Clear() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.next == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.next = map[string]time.Time{}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Reserve books the next request start of the key and returns the time to wait until the start.
+// The starts of the key are at least the interval apart.
+func (s *stgRate) Reserve(key string, interval time.Duration) (time.Duration, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.next == nil {
+		return 0, storage.ErrStorageClosed
+	}
+
+	now := time.Now()
+	start := s.next[key]
+	if start.Before(now) {
+		start = now
+	}
+	s.next[key] = start.Add(interval)
+
+	return start.Sub(now), nil
+}
@@ -0,0 +1,47 @@
+package mem
+
+import (
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stgRate_Reserve(t *testing.T) {
+	s := NewRateStorage()
+
+	tests := []struct {
+		name    string
+		key     string
+		minWait time.Duration
+		maxWait time.Duration
+	}{
+		{"first request", "example.com", 0, 0},
+		{"second request", "example.com", 900 * time.Millisecond, time.Second},
+		{"third request", "example.com", 1900 * time.Millisecond, 2 * time.Second},
+		{"other key", "example.org", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Reserve(tt.key, time.Second)
+			if err != nil {
+				t.Fatalf("stgRate.Reserve() error = %v", err)
+			}
+			if got < tt.minWait || got > tt.maxWait {
+				t.Errorf("stgRate.Reserve() = %v, want between %v and %v", got, tt.minWait, tt.maxWait)
+			}
+		})
+	}
+
+	if err := s.Clear(); err != nil {
+		t.Fatalf("stgRate.Clear() error = %v", err)
+	}
+	if got, _ := s.Reserve("example.com", time.Second); got != 0 {
+		t.Errorf("stgRate.Reserve() after Clear = %v, want 0", got)
+	}
+
+	_ = s.Close()
+	if _, err := s.Reserve("example.com", time.Second); err == nil {
+		t.Errorf("stgRate.Reserve() after Close error = nil")
+	}
+}
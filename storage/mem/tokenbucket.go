@@ -0,0 +1,100 @@
+package mem
+
+import (
+	"colly/storage"
+	"math"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// In-memory token bucket storage, shared by the collectors of the same process
+type stgTokenBucket struct {
+	lock    *sync.Mutex
+	buckets map[string]*tokenBucket // token buckets by key
+}
+
+// tokenBucket is the state of a token bucket
+type tokenBucket struct {
+	tokens float64   // available tokens, negative for the tokens reserved in advance
+	last   time.Time // time of the last refill
+}
+
+// ------------------------------------------------------------------------
+
+// NewTokenBucketStorage returns a pointer to a newly created in-memory token bucket storage.
+func NewTokenBucketStorage() *stgTokenBucket {
+	return &stgTokenBucket{
+		lock:    &sync.Mutex{},
+		buckets: map[string]*tokenBucket{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the in-memory token bucket storage.
+func (s *stgTokenBucket) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.buckets == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.buckets = nil
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all buckets from the in-memory token bucket storage.
+func (s *stgTokenBucket) Clear() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.buckets == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.buckets = map[string]*tokenBucket{}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Take takes a token of the bucket of the key and returns the time to wait until the token is available.
+// The bucket is refilled with rate tokens per second up to burst tokens. The tokens taken from
+// an empty bucket are reserved in advance, so the waiting requests are spaced by the rate.
+func (s *stgTokenBucket) Take(key string, rate float64, burst uint) (time.Duration, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.buckets == nil {
+		return 0, storage.ErrStorageClosed
+	}
+	if rate <= 0 {
+		return 0, nil
+	}
+
+	capacity := math.Max(float64(burst), 1)
+	now := time.Now()
+
+	b, present := s.buckets[key]
+	if !present {
+		b = &tokenBucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0, nil
+	}
+
+	return time.Duration(-b.tokens / rate * float64(time.Second)), nil
+}
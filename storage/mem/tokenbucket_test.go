@@ -0,0 +1,48 @@
+package mem
+
+import (
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stgTokenBucket_Take(t *testing.T) {
+	s := NewTokenBucketStorage()
+
+	tests := []struct {
+		name    string
+		key     string
+		minWait time.Duration
+		maxWait time.Duration
+	}{
+		{"first token", "*.example.com", 0, 0},
+		{"second token of the burst", "*.example.com", 0, 0},
+		{"empty bucket", "*.example.com", 400 * time.Millisecond, 500 * time.Millisecond},
+		{"reserved in advance", "*.example.com", 900 * time.Millisecond, time.Second},
+		{"other key", "example.org", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Take(tt.key, 2, 2)
+			if err != nil {
+				t.Fatalf("stgTokenBucket.Take() error = %v", err)
+			}
+			if got < tt.minWait || got > tt.maxWait {
+				t.Errorf("stgTokenBucket.Take() = %v, want between %v and %v", got, tt.minWait, tt.maxWait)
+			}
+		})
+	}
+
+	if err := s.Clear(); err != nil {
+		t.Fatalf("stgTokenBucket.Clear() error = %v", err)
+	}
+	if got, _ := s.Take("*.example.com", 2, 2); got != 0 {
+		t.Errorf("stgTokenBucket.Take() after Clear = %v, want 0", got)
+	}
+
+	_ = s.Close()
+	if _, err := s.Take("*.example.com", 2, 2); err == nil {
+		t.Errorf("stgTokenBucket.Take() after Close error = nil")
+	}
+}
@@ -2,6 +2,7 @@ package mem
 
 import (
 	"colly/storage"
+	"strings"
 	"sync"
 )
 
@@ -112,3 +113,73 @@ func (s *stgVisit) Remove(key string) error {
 
 	return nil
 }
+
+// ------------------------------------------------------------------------
+
+// RemovePrefix deletes all stored items with keys starting with the prefix.
+func (s *stgVisit) RemovePrefix(prefix string) error {
+	if s.visits == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for key := range s.visits {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.visits, key)
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// CountPrefix returns the number and the size of the stored visits with keys starting with the prefix.
+func (s *stgVisit) CountPrefix(prefix string) (storage.PrefixStats, error) {
+	var stats storage.PrefixStats
+
+	if s.visits == nil {
+		return stats, storage.ErrStorageClosed
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for key := range s.visits {
+		if strings.HasPrefix(key, prefix) {
+			stats.Count++
+			stats.Bytes += uint64(len(key))
+		}
+	}
+
+	return stats, nil
+}
+
+// ------------------------------------------------------------------------
+
+// ExportVisits calls the function for every stored visit with key starting with the prefix.
+// The function is called after the entries were copied, so it may use the storage.
+func (s *stgVisit) ExportVisits(prefix string, fn func(key string, visits uint) error) error {
+	if s.visits == nil {
+		return storage.ErrStorageClosed
+	}
+
+	s.lock.RLock()
+	visits := make(map[string]uint, len(s.visits))
+	for key, n := range s.visits {
+		if strings.HasPrefix(key, prefix) {
+			visits[key] = n
+		}
+	}
+	s.lock.RUnlock()
+
+	for key, n := range visits {
+		if err := fn(key, n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -362,3 +362,29 @@ func Test_stgVisit_PastVisits(t *testing.T) {
 		})
 	}
 }
+
+func Test_stgVisit_ExportVisits(t *testing.T) {
+	s := NewVisitStorage()
+	s.AddVisit("a|x")
+	s.AddVisit("a|x")
+	s.AddVisit("a|y")
+	s.AddVisit("b|z")
+
+	got := map[string]uint{}
+	err := s.ExportVisits("a|", func(key string, visits uint) error {
+		got[key] = visits
+		return s.AddVisit(key)
+	})
+	if err != nil {
+		t.Fatalf("stgVisit.ExportVisits() error = %v", err)
+	}
+
+	if want := map[string]uint{"a|x": 2, "a|y": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("stgVisit.ExportVisits() = %v, want %v", got, want)
+	}
+
+	s.Close()
+	if err := s.ExportVisits("", func(string, uint) error { return nil }); err == nil {
+		t.Error("stgVisit.ExportVisits() of a closed storage error = nil")
+	}
+}
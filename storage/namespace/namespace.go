@@ -0,0 +1,344 @@
+// Namespace wrappers to isolate the data of several collectors in shared storages.
+package namespace
+
+import (
+	"colly/storage"
+	"io"
+	"strings"
+)
+
+// ------------------------------------------------------------------------
+
+// KeyStorage is a key-value storage that can remove the entries of a key prefix.
+type KeyStorage interface {
+	Remove(key string) error          // Remove removes an entry by key.
+	RemovePrefix(prefix string) error // RemovePrefix removes all entries with keys starting with the prefix.
+}
+
+// CacheStorage is a shared storage of the cached responses.
+type CacheStorage interface {
+	KeyStorage
+	Put(key string, data io.Reader) error         // Put stores a response.
+	Fetch(key string) (data io.Reader, err error) // Fetch retrieves a response from the storage.
+	Has(key string) bool                          // Has returns true if the key exists in the storage.
+}
+
+// CookieStorage is a shared storage of the cookies.
+type CookieStorage interface {
+	KeyStorage
+	Set(key string, entries io.Reader) error // Set sets the entries in binary format.
+	Get(key string) (io.Reader, error)       // Get retrieves the entries in binary format.
+}
+
+// VisitStorage is a shared storage of the visits.
+type VisitStorage interface {
+	KeyStorage
+	AddVisit(key string) error           // AddVisit stores an URL that is visited.
+	PastVisits(key string) (uint, error) // PastVisits returns how many times the URL was visited before.
+}
+
+// QueueStorage is a shared storage of the dispatch queues that keys the queues by a scope besides their IDs.
+// The scopes of the namespaces are the namespace prefixes, see storage.NamespacePrefix, so the queues
+// of a namespace are purged by RemovePrefix.
+type QueueStorage interface {
+	storage.Purger
+	ScopedClear(scope string, ids ...uint32) error            // ScopedClear removes all entries from a number of dispatch queues of a scope, or the whole scope if no ID was given.
+	ScopedLen(scope string, id uint32) (uint, error)          // ScopedLen returns the number of items in a dispatch queue of a scope.
+	ScopedPush(scope string, id uint32, data io.Reader) error // ScopedPush appends a value at the end/tail of a dispatch queue of a scope.
+	ScopedPop(scope string, id uint32) (io.Reader, error)     // ScopedPop removes and returns the next value of a dispatch queue of a scope.
+	Capacity() uint                                           // Capacity returns the maximum capcity of a dispatch queue.
+}
+
+// PriorityQueueStorage is a shared storage of the dispatch queues that orders the items by priority.
+type PriorityQueueStorage interface {
+	QueueStorage
+	ScopedPushPriority(scope string, id uint32, priority float64, data io.Reader) error // ScopedPushPriority adds a value to a dispatch queue of a scope with the given priority.
+}
+
+// QueueExporter is a shared queue storage that can enumerate the items of a dispatch queue of a scope.
+type QueueExporter interface {
+	ScopedExportQueue(scope string, id uint32, fn func(priority float64, data []byte) error) error // ScopedExportQueue calls the function for every item of a dispatch queue of a scope in the pop order.
+}
+
+// ------------------------------------------------------------------------
+
+// stgKeys prefixes the keys of a shared storage with the namespace
+type stgKeys struct {
+	prefix string
+	stg    KeyStorage
+}
+
+type stgCache struct {
+	stgKeys
+	stg CacheStorage
+}
+
+type stgCookie struct {
+	stgKeys
+	stg CookieStorage
+}
+
+type stgVisit struct {
+	stgKeys
+	stg VisitStorage
+}
+
+// stgQueue keeps the dispatch queues of the namespace in their scope of a shared queue storage
+type stgQueue struct {
+	prefix string
+	stg    QueueStorage
+}
+
+// ------------------------------------------------------------------------
+
+// NewCacheStorage returns a cache storage that isolates the namespace in the shared storage.
+func NewCacheStorage(namespace string, stg CacheStorage) (*stgCache, error) {
+	keys, err := newKeys(namespace, stg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stgCache{
+		stgKeys: keys,
+		stg:     stg,
+	}, nil
+}
+
+// NewCookieStorage returns a cookie storage that isolates the namespace in the shared storage.
+func NewCookieStorage(namespace string, stg CookieStorage) (*stgCookie, error) {
+	keys, err := newKeys(namespace, stg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stgCookie{
+		stgKeys: keys,
+		stg:     stg,
+	}, nil
+}
+
+// NewVisitStorage returns a visit storage that isolates the namespace in the shared storage.
+func NewVisitStorage(namespace string, stg VisitStorage) (*stgVisit, error) {
+	keys, err := newKeys(namespace, stg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stgVisit{
+		stgKeys: keys,
+		stg:     stg,
+	}, nil
+}
+
+// NewQueueStorage returns a queue storage that isolates the namespace in the shared storage.
+func NewQueueStorage(namespace string, stg QueueStorage) (*stgQueue, error) {
+	prefix, err := storage.NamespacePrefix(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if stg == nil {
+		return nil, storage.ErrMissingParams
+	}
+
+	return &stgQueue{
+		prefix: prefix,
+		stg:    stg,
+	}, nil
+}
+
+// newKeys validates the namespace and the storage
+func newKeys(namespace string, stg KeyStorage) (stgKeys, error) {
+	prefix, err := storage.NamespacePrefix(namespace)
+	if err != nil {
+		return stgKeys{}, err
+	}
+
+	if stg == nil {
+		return stgKeys{}, storage.ErrMissingParams
+	}
+
+	return stgKeys{prefix: prefix, stg: stg}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Close doesn't close the shared storage, it must be closed by its owner.
+func (s *stgKeys) Close() error {
+	return nil
+}
+
+// Clear removes the entries of the namespace from the shared storage.
+func (s *stgKeys) Clear() error {
+	return s.stg.RemovePrefix(s.prefix)
+}
+
+// Remove removes an entry of the namespace by key.
+func (s *stgKeys) Remove(key string) error {
+	return s.stg.Remove(s.prefix + key)
+}
+
+// RemovePrefix removes the entries of the namespace with keys starting with the prefix.
+func (s *stgKeys) RemovePrefix(prefix string) error {
+	return s.stg.RemovePrefix(s.prefix + prefix)
+}
+
+// CountPrefix returns the number and the size of the entries of the namespace with keys starting with the prefix.
+// It returns ErrNotImplemented if the shared storage doesn't implement the storage.Counter interface.
+func (s *stgKeys) CountPrefix(prefix string) (storage.PrefixStats, error) {
+	c, ok := s.stg.(storage.Counter)
+	if !ok {
+		return storage.PrefixStats{}, storage.ErrNotImplemented
+	}
+
+	stats, err := c.CountPrefix(s.prefix + prefix)
+	stats.Bytes -= uint64(stats.Count) * uint64(len(s.prefix))
+
+	return stats, err
+}
+
+// ------------------------------------------------------------------------
+
+// Put stores a response in the namespace.
+func (s *stgCache) Put(key string, data io.Reader) error {
+	return s.stg.Put(s.prefix+key, data)
+}
+
+// Fetch retrieves a response of the namespace.
+func (s *stgCache) Fetch(key string) (io.Reader, error) {
+	return s.stg.Fetch(s.prefix + key)
+}
+
+// Has returns true if the key exists in the namespace.
+func (s *stgCache) Has(key string) bool {
+	return s.stg.Has(s.prefix + key)
+}
+
+// ------------------------------------------------------------------------
+
+// Set sets the cookie entries of the namespace.
+func (s *stgCookie) Set(key string, entries io.Reader) error {
+	return s.stg.Set(s.prefix+key, entries)
+}
+
+// Get retrieves the cookie entries of the namespace.
+func (s *stgCookie) Get(key string) (io.Reader, error) {
+	return s.stg.Get(s.prefix + key)
+}
+
+// GetVersion retrieves the cookie entries of the namespace with their version.
+// It returns ErrNotImplemented if the shared storage doesn't implement the storage.Versioned interface.
+func (s *stgCookie) GetVersion(key string) (io.Reader, string, error) {
+	v, ok := s.stg.(storage.Versioned)
+	if !ok {
+		return nil, "", storage.ErrNotImplemented
+	}
+
+	return v.GetVersion(s.prefix + key)
+}
+
+// CompareAndSet sets the cookie entries of the namespace if their version is unchanged.
+// It returns ErrNotImplemented if the shared storage doesn't implement the storage.Versioned interface.
+func (s *stgCookie) CompareAndSet(key string, entries io.Reader, version string) error {
+	v, ok := s.stg.(storage.Versioned)
+	if !ok {
+		return storage.ErrNotImplemented
+	}
+
+	return v.CompareAndSet(s.prefix+key, entries, version)
+}
+
+// ExportPrefix calls the function for every cookie entry of the namespace with key starting with the prefix.
+// The keys are passed without the namespace. It returns ErrNotImplemented if the shared storage
+// doesn't implement the storage.Exporter interface.
+func (s *stgCookie) ExportPrefix(prefix string, fn func(key string, data []byte) error) error {
+	e, ok := s.stg.(storage.Exporter)
+	if !ok {
+		return storage.ErrNotImplemented
+	}
+
+	return e.ExportPrefix(s.prefix+prefix, func(key string, data []byte) error {
+		return fn(strings.TrimPrefix(key, s.prefix), data)
+	})
+}
+
+// ------------------------------------------------------------------------
+
+// AddVisit stores an URL that is visited in the namespace.
+func (s *stgVisit) AddVisit(key string) error {
+	return s.stg.AddVisit(s.prefix + key)
+}
+
+// PastVisits returns how many times the URL was visited before in the namespace.
+func (s *stgVisit) PastVisits(key string) (uint, error) {
+	return s.stg.PastVisits(s.prefix + key)
+}
+
+// ExportVisits calls the function for every visited URL of the namespace with key starting with the prefix.
+// The keys are passed without the namespace. It returns ErrNotImplemented if the shared storage
+// doesn't implement the storage.VisitExporter interface.
+func (s *stgVisit) ExportVisits(prefix string, fn func(key string, visits uint) error) error {
+	e, ok := s.stg.(storage.VisitExporter)
+	if !ok {
+		return storage.ErrNotImplemented
+	}
+
+	return e.ExportVisits(s.prefix+prefix, func(key string, visits uint) error {
+		return fn(strings.TrimPrefix(key, s.prefix), visits)
+	})
+}
+
+// ------------------------------------------------------------------------
+
+// Close doesn't close the shared storage, it must be closed by its owner.
+func (s *stgQueue) Close() error {
+	return nil
+}
+
+// Clear removes all entries from a number of dispatch queues of the namespace,
+// or from all dispatch queues of the namespace if no ID was given.
+func (s *stgQueue) Clear(ids ...uint32) error {
+	return s.stg.ScopedClear(s.prefix, ids...)
+}
+
+// Capacity returns the maximum capacity of a dispatch queue.
+func (s *stgQueue) Capacity() uint {
+	return s.stg.Capacity()
+}
+
+// Len returns the number of items in a dispatch queue of the namespace.
+func (s *stgQueue) Len(id uint32) (uint, error) {
+	return s.stg.ScopedLen(s.prefix, id)
+}
+
+// Push appends a value at the end of a dispatch queue of the namespace.
+func (s *stgQueue) Push(id uint32, data io.Reader) error {
+	return s.stg.ScopedPush(s.prefix, id, data)
+}
+
+// PushPriority adds a value to a dispatch queue of the namespace with the given priority.
+// The priority is ignored if the shared storage is not a priority queue.
+func (s *stgQueue) PushPriority(id uint32, priority float64, data io.Reader) error {
+	pq, ok := s.stg.(PriorityQueueStorage)
+	if !ok {
+		return s.Push(id, data)
+	}
+
+	return pq.ScopedPushPriority(s.prefix, id, priority, data)
+}
+
+// Pop removes and returns the next value of a dispatch queue of the namespace.
+func (s *stgQueue) Pop(id uint32) (io.Reader, error) {
+	return s.stg.ScopedPop(s.prefix, id)
+}
+
+// ExportQueue calls the function for every item of a dispatch queue of the namespace in the pop order.
+// It returns ErrNotImplemented if the shared storage doesn't implement the QueueExporter interface.
+func (s *stgQueue) ExportQueue(id uint32, fn func(priority float64, data []byte) error) error {
+	e, ok := s.stg.(QueueExporter)
+	if !ok {
+		return storage.ErrNotImplemented
+	}
+
+	return e.ScopedExportQueue(s.prefix, id, fn)
+}
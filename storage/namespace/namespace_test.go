@@ -0,0 +1,294 @@
+package namespace
+
+import (
+	"colly/storage"
+	"colly/storage/filesys"
+	"colly/storage/mem"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestNewCacheStorage(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		wantErr   error
+	}{
+		{"valid", "tenant", nil},
+		{"blank", "", storage.ErrInvalidNamespace},
+		{"separator", "a|b", storage.ErrInvalidNamespace},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewCacheStorage(tt.namespace, mem.NewCacheStorage()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("NewCacheStorage() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_stgCache_Isolation(t *testing.T) {
+	shared := mem.NewCacheStorage()
+	a, _ := NewCacheStorage("a", shared)
+	b, _ := NewCacheStorage("b", shared)
+
+	if err := a.Put("key", strings.NewReader("A")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Put("key", strings.NewReader("B")); err != nil {
+		t.Fatal(err)
+	}
+
+	for stg, want := range map[*stgCache]string{a: "A", b: "B"} {
+		rdr, err := stg.Fetch("key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := io.ReadAll(rdr); string(got) != want {
+			t.Errorf("Fetch() = %q, want %q", got, want)
+		}
+	}
+
+	if err := a.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if a.Has("key") || !b.Has("key") {
+		t.Errorf("Clear() removed the wrong namespace")
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_stgVisit_Isolation(t *testing.T) {
+	shared := mem.NewVisitStorage()
+	a, _ := NewVisitStorage("a", shared)
+	b, _ := NewVisitStorage("b", shared)
+
+	a.AddVisit("url")
+	a.AddVisit("url")
+	b.AddVisit("url")
+
+	if n, _ := a.PastVisits("url"); n != 2 {
+		t.Errorf("a.PastVisits() = %d, want 2", n)
+	}
+	if n, _ := b.PastVisits("url"); n != 1 {
+		t.Errorf("b.PastVisits() = %d, want 1", n)
+	}
+
+	if err := storage.PurgeNamespace("b", shared); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := b.PastVisits("url"); n != 0 {
+		t.Errorf("b.PastVisits() after purge = %d, want 0", n)
+	}
+	if n, _ := shared.Len(); n != 1 {
+		t.Errorf("shared.Len() after purge = %d, want 1", n)
+	}
+}
+
+func Test_stgVisit_ExportVisits(t *testing.T) {
+	shared := mem.NewVisitStorage()
+	a, _ := NewVisitStorage("a", shared)
+	b, _ := NewVisitStorage("b", shared)
+
+	a.AddVisit("url")
+	a.AddVisit("url")
+	b.AddVisit("other")
+
+	got := map[string]uint{}
+	err := a.ExportVisits("", func(key string, visits uint) error {
+		got[key] = visits
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportVisits() error = %v", err)
+	}
+	if want := map[string]uint{"url": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ExportVisits() = %v, want %v", got, want)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_stgQueue_Isolation(t *testing.T) {
+	shared := mem.NewFIFOStorage(10)
+	a, _ := NewQueueStorage("a", shared)
+	b, _ := NewQueueStorage("b", shared)
+
+	a.Push(1, strings.NewReader("A"))
+	b.Push(1, strings.NewReader("B"))
+
+	if n, _ := a.Len(1); n != 1 {
+		t.Errorf("a.Len() = %d, want 1", n)
+	}
+
+	rdr, err := b.Pop(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(rdr); string(got) != "B" {
+		t.Errorf("b.Pop() = %q, want %q", got, "B")
+	}
+
+	b.Push(2, strings.NewReader("B"))
+	if err := a.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := a.Len(1); n != 0 {
+		t.Errorf("a.Len() after clear = %d, want 0", n)
+	}
+	if n, _ := b.Len(2); n != 1 {
+		t.Errorf("b.Len() after clearing a = %d, want 1", n)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_stgQueue_Purge(t *testing.T) {
+	type sharedQueue interface {
+		QueueStorage
+		Len(uint32) (uint, error)
+		Push(uint32, io.Reader) error
+	}
+
+	for name, shared := range map[string]sharedQueue{
+		"fifo":     mem.NewFIFOStorage(10),
+		"priority": mem.NewPriorityStorage(10),
+	} {
+		t.Run(name, func(t *testing.T) {
+			a, _ := NewQueueStorage("a", shared)
+			b, _ := NewQueueStorage("b", shared)
+			a.Push(1, strings.NewReader("A"))
+			a.Push(2, strings.NewReader("A"))
+			b.Push(1, strings.NewReader("B"))
+			shared.Push(1, strings.NewReader("shared"))
+
+			// The queues of a namespace are found without the wrapper that pushed them, e.g. after a restart
+			if err := storage.PurgeNamespace("a", shared); err != nil {
+				t.Fatalf("PurgeNamespace() error = %v", err)
+			}
+
+			a, _ = NewQueueStorage("a", shared)
+			for _, id := range []uint32{1, 2} {
+				if n, _ := a.Len(id); n != 0 {
+					t.Errorf("a.Len(%d) after PurgeNamespace() = %d, want 0", id, n)
+				}
+			}
+			if n, _ := b.Len(1); n != 1 {
+				t.Errorf("b.Len() after PurgeNamespace() = %d, want 1", n)
+			}
+			if n, _ := shared.Len(1); n != 1 {
+				t.Errorf("shared Len() after PurgeNamespace() = %d, want 1", n)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_stgCache_Filesys(t *testing.T) {
+	shared, err := filesys.NewCacheStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := NewCacheStorage("a", shared)
+	b, _ := NewCacheStorage("b", shared)
+
+	key := storage.DomainKey("example.com", "0123abcd")
+	a.Put(key, strings.NewReader("A"))
+	b.Put(key, strings.NewReader("B"))
+
+	if rdr, _ := a.Fetch(key); rdr == nil {
+		t.Fatal("a.Fetch() found no item")
+	} else if got, _ := io.ReadAll(rdr); string(got) != "A" {
+		t.Errorf("a.Fetch() = %q, want A", got)
+	}
+
+	if stats, err := storage.CountDomain("example.com", a); err != nil || stats.Count != 1 {
+		t.Errorf("CountDomain() = %+v, error = %v, want 1 item", stats, err)
+	}
+
+	if err := storage.PurgeNamespace("a", shared); err != nil {
+		t.Fatalf("PurgeNamespace() error = %v", err)
+	}
+	if a.Has(key) || !b.Has(key) {
+		t.Errorf("Has() after PurgeNamespace() = %v, %v, want false, true", a.Has(key), b.Has(key))
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_stgQueue_PushPriority(t *testing.T) {
+	a, _ := NewQueueStorage("a", mem.NewPriorityStorage(10))
+
+	a.PushPriority(1, 1, strings.NewReader("low"))
+	a.PushPriority(1, 5, strings.NewReader("high"))
+
+	rdr, err := a.Pop(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(rdr); string(got) != "high" {
+		t.Errorf("a.Pop() = %q, want %q", got, "high")
+	}
+
+	// The priority is ignored by the FIFO storages
+	b, _ := NewQueueStorage("b", mem.NewFIFOStorage(10))
+	if err := b.PushPriority(1, 5, strings.NewReader("B")); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := b.Len(1); n != 1 {
+		t.Errorf("b.Len() = %d, want 1", n)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestPurgeNamespace_NotImplemented(t *testing.T) {
+	if err := storage.PurgeNamespace("a", mem.NewLockStorage()); !errors.Is(err, storage.ErrNotImplemented) {
+		t.Errorf("PurgeNamespace() error = %v, want %v", err, storage.ErrNotImplemented)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_stgKeys_Domain(t *testing.T) {
+	shared := mem.NewCacheStorage()
+	a, _ := NewCacheStorage("a", shared)
+	b, _ := NewCacheStorage("b", shared)
+
+	a.Put(storage.DomainKey("example.com", "1"), strings.NewReader("AAAA"))
+	a.Put(storage.DomainKey("example.com", "2"), strings.NewReader("AA"))
+	a.Put(storage.DomainKey("example.org", "1"), strings.NewReader("A"))
+	b.Put(storage.DomainKey("example.com", "1"), strings.NewReader("B"))
+
+	stats, err := storage.CountDomain("Example.com", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := storage.PrefixStats{Count: 2, Bytes: 2*uint64(len("example.com#1")) + 6}
+	if stats != want {
+		t.Errorf("CountDomain() = %+v, want %+v", stats, want)
+	}
+
+	if err := storage.PurgeDomain("example.com", a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Has(storage.DomainKey("example.com", "1")) || a.Has(storage.DomainKey("example.com", "2")) {
+		t.Errorf("PurgeDomain() kept entries of the domain")
+	}
+	if !a.Has(storage.DomainKey("example.org", "1")) || !b.Has(storage.DomainKey("example.com", "1")) {
+		t.Errorf("PurgeDomain() removed entries of other domains or namespaces")
+	}
+
+	if _, err := storage.CountDomain("a#b", a); !errors.Is(err, storage.ErrInvalidDomain) {
+		t.Errorf("CountDomain() error = %v, want %v", err, storage.ErrInvalidDomain)
+	}
+}
@@ -0,0 +1,120 @@
+package redis
+
+import (
+	"bytes"
+	"colly/storage"
+	"context"
+	"io"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+type stgCache struct {
+	s *stgBase
+}
+
+// ------------------------------------------------------------------------
+
+// NewCacheStorage returns a pointer to a newly created Redis cache storage.
+// The items are stored with the keys prefixed by the collector ID, and Redis
+// removes them after the TTL. 0 TTL keeps the items until they are removed.
+func NewCacheStorage(addr string, collectorID uint32, ttl time.Duration, keepData bool) (*stgCache, error) {
+	if ttl < 0 {
+		return nil, storage.ErrInvalidTTL
+	}
+
+	cfg := config{
+		prefix:      storagePrefix(collectorID, TYPE_CACHE),
+		ttl:         ttl,
+		clearOnOpen: !keepData,
+	}
+
+	s, err := NewBaseStorage(addr, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stgCache{
+		s: s,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the Redis cache storage.
+func (s *stgCache) Close() error {
+	return s.s.Close()
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all items from the Redis cache storage.
+func (s *stgCache) Clear() error {
+	return s.s.Clear()
+}
+
+// ------------------------------------------------------------------------
+
+// Len returns the number of items in the Redis cache storage.
+func (s *stgCache) Len() (uint, error) {
+	return s.s.Len("")
+}
+
+// ------------------------------------------------------------------------
+
+// Put stores an item in the cache storage.
+func (s *stgCache) Put(key string, item io.Reader) error {
+	data, err := io.ReadAll(item)
+	if err != nil {
+		return err
+	}
+
+	return s.s.Set(key, data)
+}
+
+// ------------------------------------------------------------------------
+
+// Fetch retrieves a cached item from the storage.
+func (s *stgCache) Fetch(key string) (io.Reader, error) {
+	data, err := s.s.Get(key)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(data), nil
+}
+
+// ------------------------------------------------------------------------
+
+// Has returns true if the key exists in the storage.
+func (s *stgCache) Has(key string) bool {
+	if s.s.closed || key == "" {
+		return false
+	}
+
+	n, err := s.s.db.dbh.Exists(context.Background(), s.s.key(key)).Result()
+
+	return err == nil && n > 0
+}
+
+// ------------------------------------------------------------------------
+
+// Remove deletes a stored item by key.
+func (s *stgCache) Remove(key string) error {
+	return s.s.Delete(key)
+}
+
+// ------------------------------------------------------------------------
+
+// RemovePrefix deletes the stored cached items with keys starting with the prefix.
+func (s *stgCache) RemovePrefix(prefix string) error {
+	return s.s.DropPrefix(prefix)
+}
+
+// ------------------------------------------------------------------------
+
+// CountPrefix returns the number and the size of the stored cached items with keys starting with the prefix.
+func (s *stgCache) CountPrefix(prefix string) (storage.PrefixStats, error) {
+	return s.s.CountPrefix(prefix, true)
+}
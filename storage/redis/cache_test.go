@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stgCache(t *testing.T) {
+	s, err := NewCacheStorage(testAddr(t), 1, time.Minute, false)
+	if err != nil {
+		t.Fatalf("NewCacheStorage() error = %v", err)
+	}
+	defer s.Close()
+
+	if err := s.Put("page1", strings.NewReader("content")); err != nil {
+		t.Fatalf("stgCache.Put() error = %v", err)
+	}
+	s.Put("page2", strings.NewReader("other"))
+
+	if !s.Has("page1") || s.Has("none") || s.Has("") {
+		t.Errorf("stgCache.Has() of page1, none and blank key = %v, %v, %v, want true, false, false", s.Has("page1"), s.Has("none"), s.Has(""))
+	}
+
+	rdr, err := s.Fetch("page1")
+	if err != nil {
+		t.Fatalf("stgCache.Fetch() error = %v", err)
+	}
+	if got, _ := io.ReadAll(rdr); string(got) != "content" {
+		t.Errorf("stgCache.Fetch() = %q, want content", got)
+	}
+	if rdr, err := s.Fetch("none"); rdr != nil || err != nil {
+		t.Errorf("stgCache.Fetch() of unknown key = %v, %v, want nil, nil", rdr, err)
+	}
+
+	if ttl := s.s.db.dbh.TTL(context.Background(), s.s.key("page1")).Val(); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("stgCache.Put() TTL = %v, want (0, 1m]", ttl)
+	}
+
+	// The size includes the keys and the values
+	size := uint64(len("page1content") + len("page2other"))
+	if stats, _ := s.CountPrefix("page"); stats.Count != 2 || stats.Bytes != size {
+		t.Errorf("stgCache.CountPrefix() = %+v, want 2 items of %d bytes", stats, size)
+	}
+
+	if err := s.Remove("page1"); err != nil {
+		t.Errorf("stgCache.Remove() error = %v", err)
+	}
+	if n, _ := s.Len(); n != 1 {
+		t.Errorf("stgCache.Len() after Remove = %d, want 1", n)
+	}
+}
@@ -0,0 +1,165 @@
+package redis
+
+import (
+	"bytes"
+	"colly/storage"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ------------------------------------------------------------------------
+
+type stgCookie struct {
+	s *stgBase
+}
+
+// ------------------------------------------------------------------------
+
+// NewCookieStorage returns a pointer to a newly created Redis cookie storage.
+// The cookies are stored with the keys prefixed by the collector ID.
+func NewCookieStorage(addr string, collectorID uint32, keepData bool) (*stgCookie, error) {
+	cfg := config{
+		prefix:      storagePrefix(collectorID, TYPE_COOKIE),
+		clearOnOpen: !keepData,
+	}
+
+	s, err := NewBaseStorage(addr, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stgCookie{
+		s: s,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the Redis cookie storage.
+func (s *stgCookie) Close() error {
+	return s.s.Close()
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all entries from the Redis cookie storage.
+func (s *stgCookie) Clear() error {
+	return s.s.Clear()
+}
+
+// ------------------------------------------------------------------------
+
+// Len returns the number of hosts in the Redis cookie storage.
+func (s *stgCookie) Len() (uint, error) {
+	return s.s.Len("")
+}
+
+// ------------------------------------------------------------------------
+
+// Set stores cookies for a given host.
+func (s *stgCookie) Set(key string, cookies io.Reader) error {
+	data, err := io.ReadAll(cookies)
+	if err != nil {
+		return err
+	}
+
+	return s.s.Set(key, data)
+}
+
+// ------------------------------------------------------------------------
+
+// Get retrieves stored cookies for a given host.
+func (s *stgCookie) Get(key string) (io.Reader, error) {
+	data, err := s.s.Get(key)
+
+	return bytes.NewReader(data), err
+}
+
+// ------------------------------------------------------------------------
+
+// GetVersion retrieves stored cookies for a given host with their version.
+func (s *stgCookie) GetVersion(key string) (io.Reader, string, error) {
+	data, err := s.s.Get(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return bytes.NewReader(data), storage.ContentVersion(data), nil
+}
+
+// ------------------------------------------------------------------------
+
+// CompareAndSet stores cookies for a given host, or deletes them if the cookies are nil,
+// if they were not modified since the version was retrieved.
+// The host key is watched by a Redis transaction, so the check holds across the processes.
+func (s *stgCookie) CompareAndSet(key string, cookies io.Reader, version string) error {
+	if s.s.closed {
+		return storage.ErrStorageClosed
+	}
+	if key == "" {
+		return storage.ErrBlankKey
+	}
+
+	var data []byte
+	if cookies != nil {
+		var err error
+		if data, err = io.ReadAll(cookies); err != nil {
+			return err
+		}
+	}
+
+	ctx := context.Background()
+	dbKey := s.s.key(key)
+
+	err := s.s.db.dbh.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, dbKey).Bytes()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		if storage.ContentVersion(current) != version {
+			return storage.ErrVersionConflict
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if cookies == nil {
+				pipe.Del(ctx, dbKey)
+			} else {
+				pipe.Set(ctx, dbKey, data, s.s.config.ttl)
+			}
+			return nil
+		})
+
+		return err
+	}, dbKey)
+
+	// The key was modified between the check and the transaction
+	if errors.Is(err, redis.TxFailedErr) {
+		return storage.ErrVersionConflict
+	}
+
+	return err
+}
+
+// ------------------------------------------------------------------------
+
+// Remove deletes stored cookies for a given host.
+func (s *stgCookie) Remove(key string) error {
+	return s.s.Delete(key)
+}
+
+// ------------------------------------------------------------------------
+
+// RemovePrefix deletes the stored cookies with keys starting with the prefix.
+func (s *stgCookie) RemovePrefix(prefix string) error {
+	return s.s.DropPrefix(prefix)
+}
+
+// ------------------------------------------------------------------------
+
+// ExportPrefix calls the function for every stored host with key starting with the prefix.
+func (s *stgCookie) ExportPrefix(prefix string, fn func(key string, data []byte) error) error {
+	return s.s.Export(prefix, fn)
+}
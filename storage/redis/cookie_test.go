@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"colly/storage"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stgCookie_CompareAndSet(t *testing.T) {
+	s, err := NewCookieStorage(testAddr(t), 1, false)
+	if err != nil {
+		t.Fatalf("NewCookieStorage() error = %v", err)
+	}
+	defer s.Close()
+
+	// A missing host has the version of the empty content
+	_, version, err := s.GetVersion("example.com")
+	if err != nil {
+		t.Fatalf("stgCookie.GetVersion() error = %v", err)
+	}
+	if err := s.CompareAndSet("example.com", strings.NewReader("a=1"), version); err != nil {
+		t.Fatalf("stgCookie.CompareAndSet() error = %v", err)
+	}
+
+	// The old version is rejected after the update
+	if err := s.CompareAndSet("example.com", strings.NewReader("a=2"), version); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Errorf("stgCookie.CompareAndSet() error = %v, want %v", err, storage.ErrVersionConflict)
+	}
+
+	rdr, version, _ := s.GetVersion("example.com")
+	if got, _ := io.ReadAll(rdr); string(got) != "a=1" {
+		t.Errorf("stgCookie.GetVersion() = %q, want a=1", got)
+	}
+
+	// Nil cookies delete the host
+	if err := s.CompareAndSet("example.com", nil, version); err != nil {
+		t.Errorf("stgCookie.CompareAndSet() with nil cookies error = %v", err)
+	}
+	if n, _ := s.Len(); n != 0 {
+		t.Errorf("stgCookie.Len() after delete = %d, want 0", n)
+	}
+	if err := s.CompareAndSet("", strings.NewReader("a=1"), version); err == nil {
+		t.Errorf("stgCookie.CompareAndSet() with blank key error = nil, want error")
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_stgCookie_ExportPrefix(t *testing.T) {
+	s, err := NewCookieStorage(testAddr(t), 1, false)
+	if err != nil {
+		t.Fatalf("NewCookieStorage() error = %v", err)
+	}
+	defer s.Close()
+
+	s.Set("a.example.com", strings.NewReader("a=1"))
+	s.Set("b.example.com", strings.NewReader("b=1"))
+	s.Set("example.org", strings.NewReader("c=1"))
+
+	got := map[string]string{}
+	err = s.ExportPrefix("a.", func(key string, data []byte) error {
+		got[key] = string(data)
+		return nil
+	})
+	if want := map[string]string{"a.example.com": "a=1"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("stgCookie.ExportPrefix() = %v, %v, want %v", got, err, want)
+	}
+
+	if err := s.RemovePrefix("a."); err != nil {
+		t.Errorf("stgCookie.RemovePrefix() error = %v", err)
+	}
+	if n, _ := s.Len(); n != 2 {
+		t.Errorf("stgCookie.Len() after RemovePrefix = %d, want 2", n)
+	}
+}
@@ -0,0 +1,256 @@
+package redis
+
+import (
+	"bytes"
+	"colly/storage"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ------------------------------------------------------------------------
+
+// stgFIFO is a Redis multi-thread FIFO storage, every thread is stored in a list
+type stgFIFO struct {
+	s        *stgBase
+	capacity uint
+}
+
+// ------------------------------------------------------------------------
+
+// DEFAULT_FIFO_CAPACITY is the capacity of the FIFO threads created with 0 capacity.
+const DEFAULT_FIFO_CAPACITY = 1000000000
+
+// pushScript appends an item to a list if the list is shorter than the capacity.
+// It returns the length of the list, or -1 if the list is full.
+var pushScript = redis.NewScript(`
+if redis.call("LLEN", KEYS[1]) >= tonumber(ARGV[2]) then
+	return -1
+end
+return redis.call("RPUSH", KEYS[1], ARGV[1])
+`)
+
+// ------------------------------------------------------------------------
+
+// NewFIFOStorage returns a pointer to a newly created Redis FIFO storage.
+// The threads are stored with the keys prefixed by the collector ID.
+// The capacity limits the number of items of a thread, 0 means DEFAULT_FIFO_CAPACITY.
+func NewFIFOStorage(addr string, collectorID uint32, capacity uint, keepData bool) (*stgFIFO, error) {
+	if capacity == 0 {
+		capacity = DEFAULT_FIFO_CAPACITY
+	}
+
+	cfg := config{
+		prefix:      storagePrefix(collectorID, TYPE_FIFO),
+		clearOnOpen: !keepData,
+	}
+
+	s, err := NewBaseStorage(addr, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stgFIFO{
+		s:        s,
+		capacity: capacity,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the Redis FIFO storage.
+func (s *stgFIFO) Close() error {
+	return s.s.Close()
+}
+
+// ------------------------------------------------------------------------
+
+// Capacity returns the maximum number of items that can be stored in a thread of the FIFO storage.
+func (s *stgFIFO) Capacity() uint {
+	return s.capacity
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all entries from a number of threads of the Redis FIFO storage,
+// or removes all entries from all threads if no ID was given.
+func (s *stgFIFO) Clear(ids ...uint32) error {
+	if len(ids) == 0 {
+		return s.s.Clear()
+	}
+
+	for _, id := range ids {
+		if err := s.s.Delete(scopedKey("", id)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Len returns the number of items in a thread of the Redis FIFO storage.
+func (s *stgFIFO) Len(id uint32) (uint, error) {
+	return s.ScopedLen("", id)
+}
+
+// ------------------------------------------------------------------------
+
+// Push appends an item at the end/tail of a thread.
+func (s *stgFIFO) Push(id uint32, item io.Reader) error {
+	return s.push("", id, item)
+}
+
+// ------------------------------------------------------------------------
+
+// Pop removes and returns the oldest item of a thread or returns error if the thread is empty.
+func (s *stgFIFO) Pop(id uint32) (io.Reader, error) {
+	return s.ScopedPop("", id)
+}
+
+// ------------------------------------------------------------------------
+
+// Peek returns the oldest item of a thread without removing it.
+func (s *stgFIFO) Peek(id uint32) (io.Reader, error) {
+	if s.s.closed {
+		return nil, storage.ErrStorageClosed
+	}
+
+	data, err := s.s.db.dbh.LIndex(context.Background(), s.s.key(scopedKey("", id)), 0).Bytes()
+
+	return fifoItem(data, err)
+}
+
+// ------------------------------------------------------------------------
+
+// ExportQueue calls the function for every item of a thread in the FIFO order, with zero priority.
+func (s *stgFIFO) ExportQueue(id uint32, fn func(priority float64, data []byte) error) error {
+	return s.ScopedExportQueue("", id, fn)
+}
+
+// ------------------------------------------------------------------------
+
+// ScopedClear removes all entries from a number of threads of a scope,
+// or removes all threads of the scope if no ID was given.
+func (s *stgFIFO) ScopedClear(scope string, ids ...uint32) error {
+	if scope == "" {
+		return storage.ErrBlankKey
+	}
+
+	if len(ids) == 0 {
+		return s.s.DropPrefix(scope)
+	}
+
+	for _, id := range ids {
+		if err := s.s.Delete(scopedKey(scope, id)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ScopedLen returns the number of items in a thread of a scope.
+func (s *stgFIFO) ScopedLen(scope string, id uint32) (uint, error) {
+	if s.s.closed {
+		return 0, storage.ErrStorageClosed
+	}
+
+	n, err := s.s.db.dbh.LLen(context.Background(), s.s.key(scopedKey(scope, id))).Result()
+
+	return uint(n), err
+}
+
+// ScopedPush appends an item at the end/tail of a thread of a scope.
+func (s *stgFIFO) ScopedPush(scope string, id uint32, item io.Reader) error {
+	if scope == "" {
+		return storage.ErrBlankKey
+	}
+
+	return s.push(scope, id, item)
+}
+
+// ScopedPop removes and returns the oldest item of a thread of a scope.
+func (s *stgFIFO) ScopedPop(scope string, id uint32) (io.Reader, error) {
+	if s.s.closed {
+		return nil, storage.ErrStorageClosed
+	}
+
+	data, err := s.s.db.dbh.LPop(context.Background(), s.s.key(scopedKey(scope, id))).Bytes()
+
+	return fifoItem(data, err)
+}
+
+// ScopedExportQueue calls the function for every item of a thread of a scope, see ExportQueue.
+func (s *stgFIFO) ScopedExportQueue(scope string, id uint32, fn func(priority float64, data []byte) error) error {
+	if s.s.closed {
+		return storage.ErrStorageClosed
+	}
+
+	items, err := s.s.db.dbh.LRange(context.Background(), s.s.key(scopedKey(scope, id)), 0, -1).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, data := range items {
+		if err := fn(0, []byte(data)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemovePrefix removes the threads of the scopes starting with the prefix,
+// or all threads if the prefix is blank.
+func (s *stgFIFO) RemovePrefix(prefix string) error {
+	return s.s.DropPrefix(prefix)
+}
+
+// ------------------------------------------------------------------------
+
+// push appends an item at the end/tail of a thread of a scope if the thread is not full.
+func (s *stgFIFO) push(scope string, id uint32, item io.Reader) error {
+	if s.s.closed {
+		return storage.ErrStorageClosed
+	}
+
+	data, err := io.ReadAll(item)
+	if err != nil {
+		return err
+	}
+
+	n, err := pushScript.Run(context.Background(), s.s.db.dbh, []string{s.s.key(scopedKey(scope, id))}, data, s.capacity).Int64()
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return storage.ErrStorageFull
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// scopedKey returns the storage key of a thread of a scope, the threads without scope are keyed by their IDs.
+// The scope should end with a separator, see storage.NamespacePrefix.
+func scopedKey(scope string, id uint32) string {
+	return scope + strconv.FormatUint(uint64(id), 10)
+}
+
+// fifoItem returns the reader of an item, or ErrStorageEmpty if the thread had no item.
+func fifoItem(data []byte, err error) (io.Reader, error) {
+	if errors.Is(err, redis.Nil) {
+		return nil, storage.ErrStorageEmpty
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(data), nil
+}
@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"colly/storage"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stgFIFO(t *testing.T) {
+	s, err := NewFIFOStorage(testAddr(t), 1, 2, false)
+	if err != nil {
+		t.Fatalf("NewFIFOStorage() error = %v", err)
+	}
+	defer s.Close()
+
+	for _, item := range []string{"a", "b"} {
+		if err := s.Push(1, strings.NewReader(item)); err != nil {
+			t.Fatalf("stgFIFO.Push() error = %v", err)
+		}
+	}
+	if err := s.Push(1, strings.NewReader("c")); !errors.Is(err, storage.ErrStorageFull) {
+		t.Errorf("stgFIFO.Push() error = %v, want %v", err, storage.ErrStorageFull)
+	}
+	if err := s.Push(2, strings.NewReader("d")); err != nil {
+		t.Errorf("stgFIFO.Push() to another thread error = %v", err)
+	}
+
+	if n, _ := s.Len(1); n != 2 {
+		t.Errorf("stgFIFO.Len() = %d, want 2", n)
+	}
+	if rdr, err := s.Peek(1); err != nil {
+		t.Errorf("stgFIFO.Peek() error = %v", err)
+	} else if got, _ := io.ReadAll(rdr); string(got) != "a" {
+		t.Errorf("stgFIFO.Peek() = %q, want a", got)
+	}
+
+	var exported []string
+	err = s.ExportQueue(1, func(priority float64, data []byte) error {
+		exported = append(exported, string(data))
+		return nil
+	})
+	if err != nil || strings.Join(exported, ",") != "a,b" {
+		t.Errorf("stgFIFO.ExportQueue() = %q, %v, want a,b", exported, err)
+	}
+
+	for _, want := range []string{"a", "b"} {
+		rdr, err := s.Pop(1)
+		if err != nil {
+			t.Fatalf("stgFIFO.Pop() error = %v", err)
+		}
+		if got, _ := io.ReadAll(rdr); string(got) != want {
+			t.Errorf("stgFIFO.Pop() = %q, want %q", got, want)
+		}
+	}
+	if _, err := s.Pop(1); !errors.Is(err, storage.ErrStorageEmpty) {
+		t.Errorf("stgFIFO.Pop() error = %v, want %v", err, storage.ErrStorageEmpty)
+	}
+
+	if err := s.Clear(2); err != nil {
+		t.Errorf("stgFIFO.Clear() error = %v", err)
+	}
+	if n, _ := s.Len(2); n != 0 {
+		t.Errorf("stgFIFO.Len() after Clear = %d, want 0", n)
+	}
+}
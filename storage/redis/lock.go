@@ -0,0 +1,132 @@
+package redis
+
+import (
+	"colly/storage"
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ------------------------------------------------------------------------
+
+type stgLock struct {
+	s *stgBase
+}
+
+// ------------------------------------------------------------------------
+
+// renewScript extends the lock if it is held by the owner, atomically.
+// KEYS[1] is the lock, ARGV are the owner and the TTL in milliseconds.
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript removes the lock if it is held by the owner, atomically.
+// KEYS[1] is the lock, ARGV[1] is the owner.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// ------------------------------------------------------------------------
+
+// NewLockStorage returns a pointer to a newly created Redis crawl lock storage.
+// The locks are shared by all collectors and processes connected to the database.
+// The existing locks are kept, so a restarted process can't take over a running crawl.
+func NewLockStorage(addr string) (*stgLock, error) {
+	cfg := config{
+		prefix:      storagePrefix(0, TYPE_LOCK),
+		clearOnOpen: false,
+	}
+
+	s, err := NewBaseStorage(addr, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stgLock{
+		s: s,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the Redis crawl lock storage.
+func (s *stgLock) Close() error {
+	return s.s.Close()
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all locks from the Redis crawl lock storage.
+func (s *stgLock) Clear() error {
+	return s.s.Clear()
+}
+
+// ------------------------------------------------------------------------
+
+// Acquire takes the lock if it is free, expired or held by the owner.
+// The expired locks are removed by Redis, so a free lock is set if it doesn't exist.
+func (s *stgLock) Acquire(name string, owner string, ttl time.Duration) (bool, error) {
+	if s.s.closed {
+		return false, storage.ErrStorageClosed
+	}
+	if name == "" {
+		return false, storage.ErrBlankKey
+	}
+
+	ok, err := s.s.db.dbh.SetNX(context.Background(), s.s.key(name), owner, lockTTL(ttl)).Result()
+	if err != nil || ok {
+		return ok, err
+	}
+
+	// Held by someone, extended if it is the owner
+	return s.Renew(name, owner, ttl)
+}
+
+// ------------------------------------------------------------------------
+
+// Renew extends the lock if it is still held by the owner.
+func (s *stgLock) Renew(name string, owner string, ttl time.Duration) (bool, error) {
+	if s.s.closed {
+		return false, storage.ErrStorageClosed
+	}
+	if name == "" {
+		return false, storage.ErrBlankKey
+	}
+
+	n, err := renewScript.Run(context.Background(), s.s.db.dbh, []string{s.s.key(name)}, owner, lockTTL(ttl).Milliseconds()).Int64()
+
+	return n == 1, err
+}
+
+// ------------------------------------------------------------------------
+
+// Release frees the lock if it is held by the owner.
+func (s *stgLock) Release(name string, owner string) error {
+	if s.s.closed {
+		return storage.ErrStorageClosed
+	}
+	if name == "" {
+		return storage.ErrBlankKey
+	}
+
+	return releaseScript.Run(context.Background(), s.s.db.dbh, []string{s.s.key(name)}, owner).Err()
+}
+
+// ------------------------------------------------------------------------
+
+// lockTTL returns the expiry of a lock, at least a millisecond as Redis doesn't keep keys with 0 or negative expiry.
+func lockTTL(ttl time.Duration) time.Duration {
+	if ttl < time.Millisecond {
+		return time.Millisecond
+	}
+
+	return ttl
+}
@@ -0,0 +1,411 @@
+package redis
+
+import (
+	"colly/storage"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ------------------------------------------------------------------------
+
+// dbconn encapsulates the Redis client and its connection pool
+type dbconn struct {
+	addr     string
+	dbh      *redis.Client // Database handle
+	useCount uint16
+}
+
+// stgBase is a generic Redis storage
+type stgBase struct {
+	db     *dbconn
+	config *config
+	closed bool
+}
+
+// Storage config
+type config struct {
+	prefix      string        // Key prefix of the storage, see storagePrefix
+	ttl         time.Duration // Expiry of the stored entries, 0 means no expiry
+	clearOnOpen bool
+}
+
+type dataType string
+
+// ------------------------------------------------------------------------
+
+const (
+	TYPE_VISIT  dataType = "visit"
+	TYPE_COOKIE dataType = "cookie"
+	TYPE_FIFO   dataType = "fifo"
+	TYPE_CACHE  dataType = "cache"
+	TYPE_TOKEN  dataType = "token"
+	TYPE_LOCK   dataType = "lock"
+)
+
+// KEY_PREFIX is the common prefix of the keys stored by the collectors.
+const KEY_PREFIX = "colly"
+
+// scanCount is the number of keys requested by a SCAN iteration.
+const scanCount = 1000
+
+// ------------------------------------------------------------------------
+
+// Database list indexed by address
+var connections = map[string]*dbconn{}
+
+// Maximum number of storages connected to the same database.
+// 0 value means no limit.
+var maxUseCount uint16 = 100
+
+// Maximum number of socket connections of a Redis client.
+// 0 value means the go-redis default, 10 connections per CPU.
+var poolSize = 0
+
+var connLock = &sync.Mutex{}
+
+// ------------------------------------------------------------------------
+
+// connect attaches a storage to a database.
+// The address is either a host:port pair or a redis:// URL with the credentials and the database number.
+// The storages connected to the same address share the client and its connection pool.
+func connect(addr string) (*dbconn, error) {
+	if addr == "" {
+		return nil, storage.ErrBlankPath
+	}
+
+	connLock.Lock()
+	defer connLock.Unlock()
+
+	conn, present := connections[addr]
+	if !present {
+		opt, err := clientOptions(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		dbh := redis.NewClient(opt)
+		if err := dbh.Ping(context.Background()).Err(); err != nil {
+			dbh.Close()
+
+			return nil, err
+		}
+
+		conn = &dbconn{
+			addr:     addr,
+			dbh:      dbh,
+			useCount: 0,
+		}
+		connections[addr] = conn
+	}
+
+	if maxUseCount > 0 && conn.useCount >= maxUseCount {
+		return nil, storage.ErrStorageLimit
+	}
+	conn.useCount++
+
+	return conn, nil
+}
+
+// clientOptions returns the client options of the address.
+func clientOptions(addr string) (*redis.Options, error) {
+	var opt *redis.Options
+
+	if strings.Contains(addr, "://") {
+		var err error
+		if opt, err = redis.ParseURL(addr); err != nil {
+			return nil, err
+		}
+	} else {
+		opt = &redis.Options{Addr: addr}
+	}
+
+	if poolSize > 0 {
+		opt.PoolSize = poolSize
+	}
+
+	return opt, nil
+}
+
+// ------------------------------------------------------------------------
+
+// disconnect detaches a storage from the database
+// and closes the client if no more storages are connected
+func (dbc *dbconn) disconnect() {
+	connLock.Lock()
+	defer connLock.Unlock()
+
+	dbc.useCount--
+
+	// Remove dbc if this was the last connected storage
+	if dbc.useCount <= 0 {
+		dbc.dbh.Close()
+		delete(connections, dbc.addr)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// storagePrefix returns the key prefix of a storage type of a collector,
+// so several collectors can share the same database.
+func storagePrefix(collectorID uint32, t dataType) string {
+	return KEY_PREFIX + ":" + strconv.FormatUint(uint64(collectorID), 10) + ":" + string(t) + ":"
+}
+
+// ------------------------------------------------------------------------
+
+// NewBaseStorage returns a pointer to a newly created Redis storage.
+func NewBaseStorage(addr string, config *config) (*stgBase, error) {
+	if config == nil || config.prefix == "" {
+		return nil, storage.ErrMissingParams
+	}
+
+	db, err := connect(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &stgBase{
+		db:     db,
+		config: config,
+		closed: false,
+	}
+
+	// Clear the data if required
+	if s.config.clearOnOpen {
+		if err := s.DropPrefix(""); err != nil {
+			s.db.disconnect()
+
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the Redis storage.
+func (s *stgBase) Close() error {
+	if s.closed {
+		return storage.ErrStorageClosed
+	}
+
+	s.db.disconnect()
+	s.db = nil
+	s.closed = true
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all entries from the Redis storage.
+func (s *stgBase) Clear() error {
+	return s.DropPrefix("")
+}
+
+// ------------------------------------------------------------------------
+
+// DropPrefix drops all the keys with the provided prefix.
+func (s *stgBase) DropPrefix(prefix string) error {
+	if s.closed {
+		return storage.ErrStorageClosed
+	}
+
+	ctx := context.Background()
+
+	return s.scan(prefix, func(keys []string) error {
+		return s.db.dbh.Unlink(ctx, keys...).Err()
+	})
+}
+
+// ------------------------------------------------------------------------
+
+// Delete removes a key from the storage.
+func (s *stgBase) Delete(key string) error {
+	if s.closed {
+		return storage.ErrStorageClosed
+	}
+	if key == "" {
+		return storage.ErrBlankKey
+	}
+
+	return s.db.dbh.Del(context.Background(), s.key(key)).Err()
+}
+
+// ------------------------------------------------------------------------
+
+// Set adds a key-value pair to the storage.
+// The entry expires after the TTL of the storage, if any.
+func (s *stgBase) Set(key string, value []byte) error {
+	if s.closed {
+		return storage.ErrStorageClosed
+	}
+	if key == "" {
+		return storage.ErrBlankKey
+	}
+
+	return s.db.dbh.Set(context.Background(), s.key(key), value, s.config.ttl).Err()
+}
+
+// ------------------------------------------------------------------------
+
+// Get looks for key and returns the corresponding value.
+// If key is not found, nil will be returned.
+func (s *stgBase) Get(key string) ([]byte, error) {
+	if s.closed {
+		return nil, storage.ErrStorageClosed
+	}
+	if key == "" {
+		return nil, storage.ErrBlankKey
+	}
+
+	value, err := s.db.dbh.Get(context.Background(), s.key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+
+	return value, err
+}
+
+// ------------------------------------------------------------------------
+
+// Len returns the number of entries in the Redis storage.
+func (s *stgBase) Len(prefix string) (uint, error) {
+	if s.closed {
+		return 0, storage.ErrStorageClosed
+	}
+
+	var count uint
+	err := s.scan(prefix, func(keys []string) error {
+		count += uint(len(keys))
+		return nil
+	})
+
+	return count, err
+}
+
+// ------------------------------------------------------------------------
+
+// CountPrefix returns the number and the size of the entries with keys starting with the prefix.
+// The size of the values is included only if values is true.
+func (s *stgBase) CountPrefix(prefix string, values bool) (storage.PrefixStats, error) {
+	var stats storage.PrefixStats
+
+	if s.closed {
+		return stats, storage.ErrStorageClosed
+	}
+
+	ctx := context.Background()
+	err := s.scan(prefix, func(keys []string) error {
+		for _, key := range keys {
+			stats.Count++
+			stats.Bytes += uint64(len(key) - len(s.config.prefix))
+		}
+
+		if !values {
+			return nil
+		}
+
+		pipe := s.db.dbh.Pipeline()
+		lens := make([]*redis.IntCmd, len(keys))
+		for i, key := range keys {
+			lens[i] = pipe.StrLen(ctx, key)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+		for _, l := range lens {
+			stats.Bytes += uint64(l.Val())
+		}
+
+		return nil
+	})
+
+	return stats, err
+}
+
+// ------------------------------------------------------------------------
+
+// Export calls the function for every entry with key starting with the prefix.
+// The keys are passed to the function without the storage prefix.
+func (s *stgBase) Export(prefix string, fn func(key string, value []byte) error) error {
+	if s.closed {
+		return storage.ErrStorageClosed
+	}
+
+	ctx := context.Background()
+
+	return s.scan(prefix, func(keys []string) error {
+		values, err := s.db.dbh.MGet(ctx, keys...).Result()
+		if err != nil {
+			return err
+		}
+
+		for i, v := range values {
+			// The entry expired or was removed since the scan
+			str, ok := v.(string)
+			if !ok {
+				continue
+			}
+			if err := fn(strings.TrimPrefix(keys[i], s.config.prefix), []byte(str)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ------------------------------------------------------------------------
+
+// key returns the database key of a storage key.
+func (s *stgBase) key(key string) string {
+	return s.config.prefix + key
+}
+
+// scan calls the function with the batches of the database keys starting with the prefix.
+func (s *stgBase) scan(prefix string, fn func(keys []string) error) error {
+	ctx := context.Background()
+	match := escapePattern(s.key(prefix)) + "*"
+
+	var cursor uint64
+	for {
+		keys, next, err := s.db.dbh.Scan(ctx, cursor, match, scanCount).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// escapePattern escapes the special characters of a glob-style pattern.
+func escapePattern(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '^', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,26 @@
+package redis
+
+import (
+	"os"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+// TEST_ADDR_ENV is the environment variable of the test database address.
+// The tests are skipped if it is not set, e.g. COLLY_TEST_REDIS=localhost:6379.
+const TEST_ADDR_ENV = "COLLY_TEST_REDIS"
+
+// ------------------------------------------------------------------------
+
+// testAddr returns the address of the test database or skips the test.
+func testAddr(t *testing.T) string {
+	t.Helper()
+
+	addr := os.Getenv(TEST_ADDR_ENV)
+	if addr == "" {
+		t.Skipf("%s is not set", TEST_ADDR_ENV)
+	}
+
+	return addr
+}
@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"colly/storage"
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ------------------------------------------------------------------------
+
+type stgTokenBucket struct {
+	s *stgBase
+}
+
+// ------------------------------------------------------------------------
+
+// takeScript refills the bucket of the key and takes a token, atomically.
+// KEYS[1] is the bucket, ARGV are the rate per second, the burst and the current time in microseconds.
+// It returns the wait in microseconds until the token is available.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(state[1]) or burst
+local last = tonumber(state[2]) or now
+if now > last then
+	tokens = math.min(burst, tokens + (now - last) / 1e6 * rate)
+	last = now
+end
+tokens = tokens - 1
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "last", tostring(last))
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+
+if tokens >= 0 then
+	return 0
+end
+return math.ceil(-tokens / rate * 1e6)
+`)
+
+// ------------------------------------------------------------------------
+
+// NewTokenBucketStorage returns a pointer to a newly created Redis token bucket storage.
+// The buckets are shared by all collectors and processes connected to the database,
+// so they can share a request budget. The idle buckets expire when they are full again.
+func NewTokenBucketStorage(addr string, keepData bool) (*stgTokenBucket, error) {
+	cfg := config{
+		prefix:      storagePrefix(0, TYPE_TOKEN),
+		clearOnOpen: !keepData,
+	}
+
+	s, err := NewBaseStorage(addr, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stgTokenBucket{
+		s: s,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the Redis token bucket storage.
+func (s *stgTokenBucket) Close() error {
+	return s.s.Close()
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all buckets from the Redis token bucket storage.
+func (s *stgTokenBucket) Clear() error {
+	return s.s.Clear()
+}
+
+// ------------------------------------------------------------------------
+
+// Take takes a token of the bucket of the key and returns the time to wait until the token is available.
+// The bucket is refilled with rate tokens per second up to burst tokens. The script runs atomically,
+// so the processes sharing the database share the bucket. Their clocks are expected to be in sync.
+func (s *stgTokenBucket) Take(key string, rate float64, burst uint) (time.Duration, error) {
+	if s.s.closed {
+		return 0, storage.ErrStorageClosed
+	}
+	if key == "" {
+		return 0, storage.ErrBlankKey
+	}
+	if rate <= 0 {
+		return 0, nil
+	}
+	if burst == 0 {
+		burst = 1
+	}
+
+	now := time.Now().UnixMicro()
+	wait, err := takeScript.Run(context.Background(), s.s.db.dbh, []string{s.s.key(key)}, rate, burst, now).Int64()
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(wait) * time.Microsecond, nil
+}
@@ -0,0 +1,43 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stgTokenBucket_Take(t *testing.T) {
+	s, err := NewTokenBucketStorage(testAddr(t), false)
+	if err != nil {
+		t.Fatalf("NewTokenBucketStorage() error = %v", err)
+	}
+	defer s.Close()
+
+	// The burst is available at once
+	for i := 0; i < 2; i++ {
+		if wait, err := s.Take("example.com", 10, 2); err != nil || wait != 0 {
+			t.Errorf("stgTokenBucket.Take() = %v, %v, want 0", wait, err)
+		}
+	}
+
+	// The next token comes after 1/rate
+	wait, err := s.Take("example.com", 10, 2)
+	if err != nil {
+		t.Fatalf("stgTokenBucket.Take() error = %v", err)
+	}
+	if wait <= 0 || wait > 100*time.Millisecond {
+		t.Errorf("stgTokenBucket.Take() = %v, want (0, 100ms]", wait)
+	}
+
+	// The buckets are separate and a 0 rate is unlimited
+	if wait, _ := s.Take("example.org", 10, 2); wait != 0 {
+		t.Errorf("stgTokenBucket.Take() of another bucket = %v, want 0", wait)
+	}
+	if wait, _ := s.Take("example.com", 0, 2); wait != 0 {
+		t.Errorf("stgTokenBucket.Take() with 0 rate = %v, want 0", wait)
+	}
+	if _, err := s.Take("", 10, 2); err == nil {
+		t.Errorf("stgTokenBucket.Take() with blank key error = nil, want error")
+	}
+}
@@ -0,0 +1,119 @@
+package redis
+
+import (
+	"colly/storage"
+	"context"
+	"strconv"
+)
+
+// ------------------------------------------------------------------------
+
+type stgVisit struct {
+	s *stgBase
+}
+
+// ------------------------------------------------------------------------
+
+// NewVisitStorage returns a pointer to a newly created Redis visit storage.
+// The visits are stored with the keys prefixed by the collector ID.
+func NewVisitStorage(addr string, collectorID uint32, keepData bool) (*stgVisit, error) {
+	cfg := config{
+		prefix:      storagePrefix(collectorID, TYPE_VISIT),
+		clearOnOpen: !keepData,
+	}
+
+	s, err := NewBaseStorage(addr, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stgVisit{
+		s: s,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the Redis visit storage.
+func (s *stgVisit) Close() error {
+	return s.s.Close()
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all entries from the Redis visit storage.
+func (s *stgVisit) Clear() error {
+	return s.s.Clear()
+}
+
+// ------------------------------------------------------------------------
+
+// Len returns the number of request visits in the Redis visit storage.
+func (s *stgVisit) Len() (uint, error) {
+	return s.s.Len("")
+}
+
+// ------------------------------------------------------------------------
+
+// AddVisit stores a request ID that is visited by the Collector.
+// The visit counter is incremented atomically, so several crawlers can share the storage.
+func (s *stgVisit) AddVisit(key string) error {
+	if s.s.closed {
+		return storage.ErrStorageClosed
+	}
+	if key == "" {
+		return storage.ErrBlankKey
+	}
+
+	return s.s.db.dbh.Incr(context.Background(), s.s.key(key)).Err()
+}
+
+// ------------------------------------------------------------------------
+
+// PastVisits returns the number of the past visits of the request.
+func (s *stgVisit) PastVisits(key string) (uint, error) {
+	b, err := s.s.Get(key)
+	if err != nil || b == nil {
+		return 0, err
+	}
+
+	visits, err := strconv.ParseUint(string(b), 10, 64)
+
+	return uint(visits), err
+}
+
+// ------------------------------------------------------------------------
+
+// Remove deletes a stored item by key.
+func (s *stgVisit) Remove(key string) error {
+	return s.s.Delete(key)
+}
+
+// ------------------------------------------------------------------------
+
+// RemovePrefix deletes the stored visits with keys starting with the prefix.
+func (s *stgVisit) RemovePrefix(prefix string) error {
+	return s.s.DropPrefix(prefix)
+}
+
+// ------------------------------------------------------------------------
+
+// CountPrefix returns the number and the size of the stored visits with keys starting with the prefix.
+// The visit counters are not included in the size.
+func (s *stgVisit) CountPrefix(prefix string) (storage.PrefixStats, error) {
+	return s.s.CountPrefix(prefix, false)
+}
+
+// ------------------------------------------------------------------------
+
+// ExportVisits calls the function for every stored visit with key starting with the prefix.
+func (s *stgVisit) ExportVisits(prefix string, fn func(key string, visits uint) error) error {
+	return s.s.Export(prefix, func(key string, value []byte) error {
+		visits, err := strconv.ParseUint(string(value), 10, 64)
+		if err != nil {
+			return err
+		}
+
+		return fn(key, uint(visits))
+	})
+}
@@ -0,0 +1,54 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stgVisit(t *testing.T) {
+	s, err := NewVisitStorage(testAddr(t), 1, false)
+	if err != nil {
+		t.Fatalf("NewVisitStorage() error = %v", err)
+	}
+	defer s.Close()
+
+	for _, key := range []string{"a1", "a1", "a2", "b1"} {
+		if err := s.AddVisit(key); err != nil {
+			t.Fatalf("stgVisit.AddVisit() error = %v", err)
+		}
+	}
+	if err := s.AddVisit(""); err == nil {
+		t.Errorf("stgVisit.AddVisit() with blank key error = nil, want error")
+	}
+
+	if n, _ := s.PastVisits("a1"); n != 2 {
+		t.Errorf("stgVisit.PastVisits() = %d, want 2", n)
+	}
+	if n, _ := s.PastVisits("none"); n != 0 {
+		t.Errorf("stgVisit.PastVisits() of unknown key = %d, want 0", n)
+	}
+	if n, _ := s.Len(); n != 3 {
+		t.Errorf("stgVisit.Len() = %d, want 3", n)
+	}
+
+	got := map[string]uint{}
+	err = s.ExportVisits("a", func(key string, visits uint) error {
+		got[key] = visits
+		return nil
+	})
+	if want := map[string]uint{"a1": 2, "a2": 1}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("stgVisit.ExportVisits() = %v, %v, want %v", got, err, want)
+	}
+	if stats, _ := s.CountPrefix("a"); stats.Count != 2 {
+		t.Errorf("stgVisit.CountPrefix() count = %d, want 2", stats.Count)
+	}
+
+	if err := s.RemovePrefix("a"); err != nil {
+		t.Errorf("stgVisit.RemovePrefix() error = %v", err)
+	}
+	if n, _ := s.Len(); n != 1 {
+		t.Errorf("stgVisit.Len() after RemovePrefix = %d, want 1", n)
+	}
+}
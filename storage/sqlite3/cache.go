@@ -2,6 +2,7 @@ package sqlite3
 
 import (
 	"bytes"
+	"colly/storage"
 	"database/sql"
 	"io"
 )
@@ -24,9 +25,11 @@ var (
 		"drop":   `DROP TABLE IF EXISTS "<table>"`,
 		"trim":   `DELETE FROM "<table>"`,
 		"insert": `INSERT INTO "<table>" ("key", "response") VALUES (?, ?) ON CONFLICT("key") DO UPDATE SET "response" = "excluded"."response"`,
-		"select": `SELECT "caches" FROM "<table>" WHERE "key" = ?`,
+		"select": `SELECT "response" FROM "<table>" WHERE "key" = ?`,
 		"delete": `DELETE FROM "<table>" WHERE "key" = ?`,
+		"purge":  `DELETE FROM "<table>" WHERE substr("key", 1, length(?1)) = ?1`,
 		"count":  `SELECT COUNT(*) FROM "<table>"`,
+		"stats":  `SELECT COUNT(*), COALESCE(SUM(length("key") + length("response")), 0) FROM "<table>" WHERE substr("key", 1, length(?1)) = ?1`,
 		"check":  `SELECT COUNT(*) FROM "<table>" WHERE "key" = ?`,
 	}
 )
@@ -131,3 +134,17 @@ func (s *stgCache) Remove(key string) error {
 
 	return err
 }
+
+// ------------------------------------------------------------------------
+
+// RemovePrefix deletes the stored cached items with keys starting with the prefix.
+func (s *stgCache) RemovePrefix(prefix string) error {
+	return s.s.RemovePrefix(prefix)
+}
+
+// ------------------------------------------------------------------------
+
+// CountPrefix returns the number and the size of the stored cached items with keys starting with the prefix.
+func (s *stgCache) CountPrefix(prefix string) (storage.PrefixStats, error) {
+	return s.s.CountPrefix(prefix)
+}
@@ -2,7 +2,12 @@ package sqlite3
 
 import (
 	"bytes"
+	"colly/storage"
+	"database/sql"
+	"errors"
 	"io"
+
+	driver "github.com/mattn/go-sqlite3"
 )
 
 // ------------------------------------------------------------------------
@@ -29,7 +34,9 @@ var (
 		"insert": `INSERT INTO "<table>" ("host", "cookies") VALUES (?, ?) ON CONFLICT("host") DO UPDATE SET "cookies" = "excluded"."cookies"`,
 		"select": `SELECT "cookies" FROM "<table>" WHERE "host" = ?`,
 		"delete": `DELETE FROM "<table>" WHERE "host" = ?`,
+		"purge":  `DELETE FROM "<table>" WHERE substr("host", 1, length(?1)) = ?1`,
 		"count":  `SELECT COUNT(*) FROM "<table>"`,
+		"export": `SELECT "host", "cookies" FROM "<table>" WHERE substr("host", 1, length(?1)) = ?1`,
 	}
 )
 
@@ -105,6 +112,68 @@ func (s *stgCookie) Get(key string) (io.Reader, error) {
 
 // ------------------------------------------------------------------------
 
+// GetVersion retrieves stored cookies for a given host with their version.
+func (s *stgCookie) GetVersion(key string) (io.Reader, string, error) {
+	var data = []byte{}
+
+	s.s.lock.Lock()
+	err := s.s.stmts["select"].QueryRow(key).Scan(&data)
+	s.s.lock.Unlock()
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return bytes.NewReader(nil), "", nil
+	}
+
+	return bytes.NewReader(data), storage.ContentVersion(data), err
+}
+
+// ------------------------------------------------------------------------
+
+// CompareAndSet stores cookies for a given host, or deletes them if the cookies are nil,
+// if they were not modified since the version was retrieved. The check and the write
+// run in a transaction, a write lock held by another process is reported as a conflict.
+func (s *stgCookie) CompareAndSet(key string, cookies io.Reader, version string) error {
+	var data []byte
+	if cookies != nil {
+		var err error
+		if data, err = io.ReadAll(cookies); err != nil {
+			return err
+		}
+	}
+
+	s.s.lock.Lock()
+	defer s.s.lock.Unlock()
+
+	tx, err := s.s.db.dbh.Begin()
+	if err != nil {
+		return lockConflict(err)
+	}
+	defer tx.Rollback()
+
+	var current []byte
+	err = tx.Stmt(s.s.stmts["select"]).QueryRow(key).Scan(&current)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return lockConflict(err)
+	}
+
+	if storage.ContentVersion(current) != version {
+		return storage.ErrVersionConflict
+	}
+
+	if cookies == nil {
+		_, err = tx.Stmt(s.s.stmts["delete"]).Exec(key)
+	} else {
+		_, err = tx.Stmt(s.s.stmts["insert"]).Exec(key, data)
+	}
+	if err != nil {
+		return lockConflict(err)
+	}
+
+	return lockConflict(tx.Commit())
+}
+
+// ------------------------------------------------------------------------
+
 // Remove deletes stored cookies for a given host.
 func (s *stgCookie) Remove(key string) error {
 	s.s.lock.Lock()
@@ -113,3 +182,54 @@ func (s *stgCookie) Remove(key string) error {
 
 	return err
 }
+
+// ------------------------------------------------------------------------
+
+// RemovePrefix deletes the stored cookies with keys starting with the prefix.
+func (s *stgCookie) RemovePrefix(prefix string) error {
+	return s.s.RemovePrefix(prefix)
+}
+
+// ------------------------------------------------------------------------
+
+// ExportPrefix calls the function for every stored host with key starting with the prefix.
+// The function is called after the entries were read, so it may use the storage.
+func (s *stgCookie) ExportPrefix(prefix string, fn func(key string, data []byte) error) error {
+	type cookies struct {
+		key  string
+		data []byte
+	}
+	var entries []cookies
+
+	err := s.s.Query("export", func(scan func(...any) error) error {
+		var e cookies
+		if err := scan(&e.key, &e.data); err != nil {
+			return err
+		}
+		entries = append(entries, e)
+		return nil
+	}, prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if err := fn(e.key, e.data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// lockConflict reports the database locks held by other connections as version conflicts.
+func lockConflict(err error) error {
+	var sqlErr driver.Error
+	if errors.As(err, &sqlErr) && (sqlErr.Code == driver.ErrBusy || sqlErr.Code == driver.ErrLocked) {
+		return storage.ErrVersionConflict
+	}
+
+	return err
+}
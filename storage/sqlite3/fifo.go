@@ -22,15 +22,19 @@ const defaultFIFOTableName = "fifo"
 
 var (
 	cmdFIFO = map[string]string{
-		"create":      `CREATE TABLE IF NOT EXISTS "<table>" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "thread" INTEGER NOT NULL, "data" BLOB)`,
+		"create":      `CREATE TABLE IF NOT EXISTS "<table>" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "scope" TEXT NOT NULL DEFAULT '', "thread" INTEGER NOT NULL, "data" BLOB)`,
+		"migrate":     `ALTER TABLE "<table>" ADD COLUMN "scope" TEXT NOT NULL DEFAULT ''`,
 		"drop":        `DROP TABLE IF EXISTS "<table>"`,
-		"trim_thread": `DELETE FROM "<table>" WHERE "thread" = ?`,
+		"trim_thread": `DELETE FROM "<table>" WHERE "scope" = ? AND "thread" = ?`,
+		"trim_scope":  `DELETE FROM "<table>" WHERE "scope" = ?`,
 		"trim":        `DELETE FROM "<table>"`,
-		"insert":      `INSERT INTO "<table>" ("thread", "data") VALUES (?, ?)`,
-		"select":      `SELECT "data" FROM "<table>" WHERE "id" = (SELECT MIN("id") FROM "<table>" WHERE "thread" = ?)`,
-		"pop":         `DELETE FROM "<table>" WHERE "id" = (SELECT MIN("id") FROM "<table>" WHERE "thread" = ?) RETURNING "data"`,
-		"multipop":    `DELETE FROM "<table>" WHERE "thread" = ? ORDER BY "id" ASC LIMIT ? RETURNING "data"`,
-		"count":       `SELECT COUNT(*) FROM "<table>" WHERE "thread" = ?`,
+		"purge":       `DELETE FROM "<table>" WHERE substr("scope", 1, length(?1)) = ?1`,
+		"insert":      `INSERT INTO "<table>" ("scope", "thread", "data") VALUES (?, ?, ?)`,
+		"select":      `SELECT "data" FROM "<table>" WHERE "id" = (SELECT MIN("id") FROM "<table>" WHERE "scope" = ? AND "thread" = ?)`,
+		"pop":         `DELETE FROM "<table>" WHERE "id" = (SELECT MIN("id") FROM "<table>" WHERE "scope" = ? AND "thread" = ?) RETURNING "data"`,
+		"multipop":    `DELETE FROM "<table>" WHERE "id" IN (SELECT "id" FROM "<table>" WHERE "scope" = ? AND "thread" = ? ORDER BY "id" ASC LIMIT ?) RETURNING "data"`,
+		"count":       `SELECT COUNT(*) FROM "<table>" WHERE "scope" = ? AND "thread" = ?`,
+		"export":      `SELECT 0, "data" FROM "<table>" WHERE "scope" = ? AND "thread" = ? ORDER BY "id" ASC`,
 	}
 )
 
@@ -73,7 +77,7 @@ func (s *stgFIFO) Clear(ids ...uint32) error {
 	defer s.s.lock.Unlock()
 
 	for _, id := range ids {
-		err := s.s.Cmd("trim_thread", id)
+		err := s.s.Cmd("trim_thread", "", id)
 		if err != nil {
 			return err
 		}
@@ -93,43 +97,21 @@ func (s *stgFIFO) Capacity() uint {
 
 // Len returns the number of hosts in the SQLite3 FIFO storage.
 func (s *stgFIFO) Len(id uint32) (uint, error) {
-	return s.s.Len(id)
+	return s.s.Len("", id)
 }
 
 // ------------------------------------------------------------------------
 
 // Push inserts an item into the SQLite3 FIFO storage.
 func (s *stgFIFO) Push(id uint32, item io.Reader) error {
-	data, err := io.ReadAll(item)
-	if err != nil {
-		return err
-	}
-
-	s.s.lock.Lock()
-	_, err = s.s.stmts["insert"].Exec(id, data)
-	s.s.lock.Unlock()
-
-	return err
+	return s.push("", id, item)
 }
 
 // ------------------------------------------------------------------------
 
 // Pop pops the oldest item from the FIFO storage or returns error if the storage is empty.
 func (s *stgFIFO) Pop(id uint32) (io.Reader, error) {
-	var data = []byte{}
-
-	s.s.lock.Lock()
-	err := s.s.stmts["pop"].QueryRow(id).Scan(&data)
-	s.s.lock.Unlock()
-	if err != nil {
-		if err == sql.ErrNoRows {
-			err = storage.ErrStorageEmpty
-		}
-
-		return nil, err
-	}
-
-	return bytes.NewReader(data), nil
+	return s.pop("", id)
 }
 
 // ------------------------------------------------------------------------
@@ -142,7 +124,7 @@ func (s *stgFIFO) MultiPop(id uint32, n uint) ([]io.Reader, error) {
 	}
 
 	s.s.lock.Lock()
-	rows, err := s.s.stmts["multipop"].Query(id, n)
+	rows, err := s.s.stmts["multipop"].Query("", id, n)
 	s.s.lock.Unlock()
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -174,7 +156,7 @@ func (s *stgFIFO) Peek(id uint32) (io.Reader, error) {
 	var data = []byte{}
 
 	s.s.lock.Lock()
-	err := s.s.stmts["select"].QueryRow(id).Scan(&data)
+	err := s.s.stmts["select"].QueryRow("", id).Scan(&data)
 	s.s.lock.Unlock()
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -186,3 +168,134 @@ func (s *stgFIFO) Peek(id uint32) (io.Reader, error) {
 
 	return bytes.NewReader(data), nil
 }
+
+// ------------------------------------------------------------------------
+
+// ExportQueue calls the function for every item of a thread in the FIFO order, with zero priority.
+// The function is called after the items were read, so it may use the storage.
+func (s *stgFIFO) ExportQueue(id uint32, fn func(priority float64, data []byte) error) error {
+	return exportItems(s.s, "", id, fn)
+}
+
+// ------------------------------------------------------------------------
+
+// ScopedClear removes all entries from a number of threads of a scope,
+// or removes all threads of the scope if no ID was given.
+func (s *stgFIFO) ScopedClear(scope string, ids ...uint32) error {
+	return clearScope(s.s, scope, ids...)
+}
+
+// ScopedLen returns the number of items in a thread of a scope.
+func (s *stgFIFO) ScopedLen(scope string, id uint32) (uint, error) {
+	return s.s.Len(scope, id)
+}
+
+// ScopedPush inserts an item into a thread of a scope.
+func (s *stgFIFO) ScopedPush(scope string, id uint32, item io.Reader) error {
+	if scope == "" {
+		return storage.ErrBlankKey
+	}
+
+	return s.push(scope, id, item)
+}
+
+// ScopedPop pops the oldest item from a thread of a scope.
+func (s *stgFIFO) ScopedPop(scope string, id uint32) (io.Reader, error) {
+	return s.pop(scope, id)
+}
+
+// ScopedExportQueue calls the function for every item of a thread of a scope, see ExportQueue.
+func (s *stgFIFO) ScopedExportQueue(scope string, id uint32, fn func(priority float64, data []byte) error) error {
+	return exportItems(s.s, scope, id, fn)
+}
+
+// RemovePrefix removes the threads of the scopes starting with the prefix,
+// or all threads if the prefix is blank.
+func (s *stgFIFO) RemovePrefix(prefix string) error {
+	return s.s.RemovePrefix(prefix)
+}
+
+// ------------------------------------------------------------------------
+
+func (s *stgFIFO) push(scope string, id uint32, item io.Reader) error {
+	data, err := io.ReadAll(item)
+	if err != nil {
+		return err
+	}
+
+	s.s.lock.Lock()
+	_, err = s.s.stmts["insert"].Exec(scope, id, data)
+	s.s.lock.Unlock()
+
+	return err
+}
+
+func (s *stgFIFO) pop(scope string, id uint32) (io.Reader, error) {
+	var data = []byte{}
+
+	s.s.lock.Lock()
+	err := s.s.stmts["pop"].QueryRow(scope, id).Scan(&data)
+	s.s.lock.Unlock()
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = storage.ErrStorageEmpty
+		}
+
+		return nil, err
+	}
+
+	return bytes.NewReader(data), nil
+}
+
+// ------------------------------------------------------------------------
+
+// clearScope removes the items of a number of threads of a scope, or all items of the scope if no ID was given.
+func clearScope(s *stgBase, scope string, ids ...uint32) error {
+	if scope == "" {
+		return storage.ErrBlankKey
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(ids) == 0 {
+		return s.Cmd("trim_scope", scope)
+	}
+
+	for _, id := range ids {
+		if err := s.Cmd("trim_thread", scope, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportItems calls the function for every item of a thread returned by the export command.
+func exportItems(s *stgBase, scope string, id uint32, fn func(priority float64, data []byte) error) error {
+	type queueItem struct {
+		priority float64
+		data     []byte
+	}
+	var items []queueItem
+
+	err := s.Query("export", func(scan func(...any) error) error {
+		var item queueItem
+		if err := scan(&item.priority, &item.data); err != nil {
+			return err
+		}
+		items = append(items, item)
+		return nil
+	}, scope, id)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := fn(item.priority, item.data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package sqlite3
+
+import "colly/storage"
+
+// ------------------------------------------------------------------------
+
+// stgGraph keeps the edges and the nodes in two tables, the node table is named after the edge table.
+type stgGraph struct {
+	edges *stgBase
+	nodes *stgBase
+}
+
+// ------------------------------------------------------------------------
+
+const (
+	defaultGraphTableName = "graph"
+	graphNodeTableSuffix  = "_nodes"
+)
+
+// ------------------------------------------------------------------------
+
+var (
+	cmdGraphEdge = map[string]string{
+		"create": `CREATE TABLE IF NOT EXISTS "<table>" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "from" TEXT NOT NULL, "to" TEXT NOT NULL, "depth" INT, "anchor" TEXT)`,
+		"drop":   `DROP TABLE IF EXISTS "<table>"`,
+		"trim":   `DELETE FROM "<table>"`,
+		"insert": `INSERT INTO "<table>" ("from", "to", "depth", "anchor") VALUES (?, ?, ?, ?)`,
+		"select": `SELECT "from", "to", COALESCE("depth", 0), COALESCE("anchor", '') FROM "<table>" ORDER BY "id"`,
+		"count":  `SELECT COUNT(*) FROM "<table>"`,
+	}
+
+	cmdGraphNode = map[string]string{
+		"create": `CREATE TABLE IF NOT EXISTS "<table>" ("url" TEXT PRIMARY KEY NOT NULL, "depth" INT, "status" INT, "error" TEXT)`,
+		"drop":   `DROP TABLE IF EXISTS "<table>"`,
+		"trim":   `DELETE FROM "<table>"`,
+		"insert": `INSERT INTO "<table>" ("url", "depth", "status", "error") VALUES (?, ?, ?, ?) ON CONFLICT("url") DO UPDATE SET "depth" = excluded."depth", "status" = excluded."status", "error" = excluded."error"`,
+		"select": `SELECT "url", COALESCE("depth", 0), COALESCE("status", 0), COALESCE("error", '') FROM "<table>" ORDER BY "url"`,
+		"count":  `SELECT COUNT(*) FROM "<table>"`,
+	}
+)
+
+// ------------------------------------------------------------------------
+
+// NewGraphStorage returns a pointer to a newly created SQLite3 graph storage.
+// The nodes are stored in the table named after the edge table with the "_nodes" suffix.
+func NewGraphStorage(path string, table string, keepData bool) (*stgGraph, error) {
+	table = setTable(table, defaultGraphTableName)
+
+	edges, err := NewBaseStorage(path, &config{
+		table:       table,
+		dropOnClose: false,
+		clearOnOpen: !keepData,
+	}, cmdGraphEdge)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := NewBaseStorage(path, &config{
+		table:       table + graphNodeTableSuffix,
+		dropOnClose: false,
+		clearOnOpen: !keepData,
+	}, cmdGraphNode)
+	if err != nil {
+		edges.Close()
+
+		return nil, err
+	}
+
+	return &stgGraph{
+		edges: edges,
+		nodes: nodes,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the SQLite3 graph storage.
+func (s *stgGraph) Close() error {
+	err := s.edges.Close()
+	if nerr := s.nodes.Close(); err == nil {
+		err = nerr
+	}
+
+	return err
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all edges and nodes from the SQLite3 graph storage.
+func (s *stgGraph) Clear() error {
+	if err := s.edges.Clear(); err != nil {
+		return err
+	}
+
+	return s.nodes.Clear()
+}
+
+// ------------------------------------------------------------------------
+
+// AddEdge stores a link discovered on a page.
+func (s *stgGraph) AddEdge(edge *storage.GraphEdge) error {
+	s.edges.lock.Lock()
+	defer s.edges.lock.Unlock()
+
+	return s.edges.Cmd("insert", edge.From, edge.To, edge.Depth, edge.Anchor)
+}
+
+// ------------------------------------------------------------------------
+
+// AddNode stores the outcome of a request, replacing the earlier outcome of the URL.
+func (s *stgGraph) AddNode(node *storage.GraphNode) error {
+	s.nodes.lock.Lock()
+	defer s.nodes.lock.Unlock()
+
+	return s.nodes.Cmd("insert", node.URL, node.Depth, node.StatusCode, node.Error)
+}
+
+// ------------------------------------------------------------------------
+
+// Edges returns the stored edges in their recording order.
+func (s *stgGraph) Edges() ([]*storage.GraphEdge, error) {
+	var edges []*storage.GraphEdge
+
+	err := s.edges.Query("select", func(scan func(...any) error) error {
+		e := &storage.GraphEdge{}
+		if err := scan(&e.From, &e.To, &e.Depth, &e.Anchor); err != nil {
+			return err
+		}
+		edges = append(edges, e)
+		return nil
+	})
+
+	return edges, err
+}
+
+// ------------------------------------------------------------------------
+
+// Nodes returns the stored nodes sorted by their URL.
+func (s *stgGraph) Nodes() ([]*storage.GraphNode, error) {
+	var nodes []*storage.GraphNode
+
+	err := s.nodes.Query("select", func(scan func(...any) error) error {
+		n := &storage.GraphNode{}
+		if err := scan(&n.URL, &n.Depth, &n.StatusCode, &n.Error); err != nil {
+			return err
+		}
+		nodes = append(nodes, n)
+		return nil
+	})
+
+	return nodes, err
+}
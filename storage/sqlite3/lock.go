@@ -0,0 +1,113 @@
+package sqlite3
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+type stgLock struct {
+	s *stgBase
+}
+
+// ------------------------------------------------------------------------
+
+const defaultLockTableName = "crawl_locks"
+
+// ------------------------------------------------------------------------
+
+var (
+	cmdLock = map[string]string{
+		"create": `CREATE TABLE IF NOT EXISTS "<table>" ("name" TEXT PRIMARY KEY NOT NULL, "owner" TEXT NOT NULL, "expiry" INT NOT NULL)`,
+		"drop":   `DROP TABLE IF EXISTS "<table>"`,
+		"trim":   `DELETE FROM "<table>"`,
+		"insert": `INSERT INTO "<table>" ("name", "owner", "expiry") VALUES (?, ?, ?) ON CONFLICT("name") DO UPDATE SET "owner" = excluded."owner", "expiry" = excluded."expiry" WHERE "<table>"."owner" = excluded."owner" OR "<table>"."expiry" < ?`,
+		"update": `UPDATE "<table>" SET "expiry" = ? WHERE "name" = ? AND "owner" = ?`,
+		"delete": `DELETE FROM "<table>" WHERE "name" = ? AND "owner" = ?`,
+		"count":  `SELECT COUNT(*) FROM "<table>"`,
+	}
+)
+
+// ------------------------------------------------------------------------
+
+// NewLockStorage returns a pointer to a newly created SQLite3 crawl lock storage.
+// The collectors sharing the database file share the locks.
+func NewLockStorage(path string, table string) (*stgLock, error) {
+	cfg := config{
+		table:       setTable(table, defaultLockTableName),
+		dropOnClose: false,
+		clearOnOpen: false,
+	}
+
+	s, err := NewBaseStorage(path, &cfg, cmdLock)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stgLock{
+		s: s,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the SQLite3 crawl lock storage.
+func (s *stgLock) Close() error {
+	return s.s.Close()
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all locks from the SQLite3 crawl lock storage.
+func (s *stgLock) Clear() error {
+	return s.s.Clear()
+}
+
+// ------------------------------------------------------------------------
+
+// Acquire takes the lock if it is free, expired or held by the owner.
+func (s *stgLock) Acquire(name string, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	s.s.lock.Lock()
+	res, err := s.s.stmts["insert"].Exec(name, owner, now.Add(ttl).UnixNano(), now.UnixNano())
+	s.s.lock.Unlock()
+
+	return affected(res, err)
+}
+
+// ------------------------------------------------------------------------
+
+// Renew extends the lock if it is still held by the owner.
+func (s *stgLock) Renew(name string, owner string, ttl time.Duration) (bool, error) {
+	s.s.lock.Lock()
+	res, err := s.s.stmts["update"].Exec(time.Now().Add(ttl).UnixNano(), name, owner)
+	s.s.lock.Unlock()
+
+	return affected(res, err)
+}
+
+// ------------------------------------------------------------------------
+
+// Release frees the lock if it is held by the owner.
+func (s *stgLock) Release(name string, owner string) error {
+	s.s.lock.Lock()
+	_, err := s.s.stmts["delete"].Exec(name, owner)
+	s.s.lock.Unlock()
+
+	return err
+}
+
+// ------------------------------------------------------------------------
+
+// affected returns true if the statement changed a row.
+func affected(res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n > 0, err
+}
@@ -0,0 +1,223 @@
+// SQLite3 priority queue storage.
+package sqlite3
+
+import (
+	"bytes"
+	"colly/storage"
+	"database/sql"
+	"io"
+)
+
+// ------------------------------------------------------------------------
+
+// stgPriority is a multi-thread priority queue storage, the items with the same priority are kept in FIFO order
+type stgPriority struct {
+	s *stgBase
+}
+
+// ------------------------------------------------------------------------
+
+const defaultPriorityTableName = "priority_queue"
+
+// ------------------------------------------------------------------------
+
+var (
+	cmdPriority = map[string]string{
+		"create":      `CREATE TABLE IF NOT EXISTS "<table>" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "scope" TEXT NOT NULL DEFAULT '', "thread" INTEGER NOT NULL, "priority" REAL NOT NULL DEFAULT 0, "data" BLOB)`,
+		"migrate":     `ALTER TABLE "<table>" ADD COLUMN "scope" TEXT NOT NULL DEFAULT ''`,
+		"index":       `CREATE INDEX IF NOT EXISTS "<table>_scope_order" ON "<table>" ("scope", "thread", "priority" DESC, "id" ASC)`,
+		"drop":        `DROP TABLE IF EXISTS "<table>"`,
+		"trim_thread": `DELETE FROM "<table>" WHERE "scope" = ? AND "thread" = ?`,
+		"trim_scope":  `DELETE FROM "<table>" WHERE "scope" = ?`,
+		"trim":        `DELETE FROM "<table>"`,
+		"purge":       `DELETE FROM "<table>" WHERE substr("scope", 1, length(?1)) = ?1`,
+		"insert":      `INSERT INTO "<table>" ("scope", "thread", "priority", "data") VALUES (?, ?, ?, ?)`,
+		"select":      `SELECT "data" FROM "<table>" WHERE "scope" = ? AND "thread" = ? ORDER BY "priority" DESC, "id" ASC LIMIT 1`,
+		"pop":         `DELETE FROM "<table>" WHERE "id" = (SELECT "id" FROM "<table>" WHERE "scope" = ? AND "thread" = ? ORDER BY "priority" DESC, "id" ASC LIMIT 1) RETURNING "data"`,
+		"count":       `SELECT COUNT(*) FROM "<table>" WHERE "scope" = ? AND "thread" = ?`,
+		"export":      `SELECT "priority", "data" FROM "<table>" WHERE "scope" = ? AND "thread" = ? ORDER BY "priority" DESC, "id" ASC`,
+	}
+)
+
+// ------------------------------------------------------------------------
+
+// NewPriorityStorage returns a pointer to a newly created SQLite3 priority queue storage.
+// Items with higher priority are popped first.
+func NewPriorityStorage(path string, table string, keepData bool) (*stgPriority, error) {
+	cfg := config{
+		table:       setTable(table, defaultPriorityTableName),
+		dropOnClose: false,
+		clearOnOpen: !keepData,
+	}
+
+	s, err := NewBaseStorage(path, &cfg, cmdPriority)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Cmd("index"); err != nil {
+		s.Close()
+
+		return nil, err
+	}
+
+	return &stgPriority{
+		s: s,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the SQLite3 priority queue storage.
+func (s *stgPriority) Close() error {
+	return s.s.Close()
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all entries from a number of threads of the SQLite3 priority queue storage,
+// or removes all entries from all threads if no ID was given.
+func (s *stgPriority) Clear(ids ...uint32) error {
+	if len(ids) == 0 {
+		return s.s.Clear()
+	}
+
+	s.s.lock.Lock()
+	defer s.s.lock.Unlock()
+
+	for _, id := range ids {
+		err := s.s.Cmd("trim_thread", "", id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// Capacity returns the maximum number of items that can be stored in a thread.
+func (s *stgPriority) Capacity() uint {
+	return 1000000000
+}
+
+// ------------------------------------------------------------------------
+
+// Len returns the number of items in a thread of the SQLite3 priority queue storage.
+func (s *stgPriority) Len(id uint32) (uint, error) {
+	return s.s.Len("", id)
+}
+
+// ------------------------------------------------------------------------
+
+// Push adds an item with zero priority.
+func (s *stgPriority) Push(id uint32, item io.Reader) error {
+	return s.PushPriority(id, 0, item)
+}
+
+// ------------------------------------------------------------------------
+
+// PushPriority adds an item with the given priority.
+func (s *stgPriority) PushPriority(id uint32, priority float64, item io.Reader) error {
+	return s.push("", id, priority, item)
+}
+
+// ------------------------------------------------------------------------
+
+// Pop removes and returns the item with the highest priority or returns error if the thread is empty.
+func (s *stgPriority) Pop(id uint32) (io.Reader, error) {
+	return s.queryItem("pop", "", id)
+}
+
+// ------------------------------------------------------------------------
+
+// Peek returns the item with the highest priority without removing it.
+func (s *stgPriority) Peek(id uint32) (io.Reader, error) {
+	return s.queryItem("select", "", id)
+}
+
+// ------------------------------------------------------------------------
+
+// ExportQueue calls the function for every item of a thread in the pop order, with its priority.
+// The function is called after the items were read, so it may use the storage.
+func (s *stgPriority) ExportQueue(id uint32, fn func(priority float64, data []byte) error) error {
+	return exportItems(s.s, "", id, fn)
+}
+
+// ------------------------------------------------------------------------
+
+// ScopedClear removes all entries from a number of threads of a scope,
+// or removes all threads of the scope if no ID was given.
+func (s *stgPriority) ScopedClear(scope string, ids ...uint32) error {
+	return clearScope(s.s, scope, ids...)
+}
+
+// ScopedLen returns the number of items in a thread of a scope.
+func (s *stgPriority) ScopedLen(scope string, id uint32) (uint, error) {
+	return s.s.Len(scope, id)
+}
+
+// ScopedPush adds an item with zero priority to a thread of a scope.
+func (s *stgPriority) ScopedPush(scope string, id uint32, item io.Reader) error {
+	return s.ScopedPushPriority(scope, id, 0, item)
+}
+
+// ScopedPushPriority adds an item with the given priority to a thread of a scope.
+func (s *stgPriority) ScopedPushPriority(scope string, id uint32, priority float64, item io.Reader) error {
+	if scope == "" {
+		return storage.ErrBlankKey
+	}
+
+	return s.push(scope, id, priority, item)
+}
+
+// ScopedPop removes and returns the item with the highest priority in a thread of a scope.
+func (s *stgPriority) ScopedPop(scope string, id uint32) (io.Reader, error) {
+	return s.queryItem("pop", scope, id)
+}
+
+// ScopedExportQueue calls the function for every item of a thread of a scope, see ExportQueue.
+func (s *stgPriority) ScopedExportQueue(scope string, id uint32, fn func(priority float64, data []byte) error) error {
+	return exportItems(s.s, scope, id, fn)
+}
+
+// RemovePrefix removes the threads of the scopes starting with the prefix,
+// or all threads if the prefix is blank.
+func (s *stgPriority) RemovePrefix(prefix string) error {
+	return s.s.RemovePrefix(prefix)
+}
+
+// ------------------------------------------------------------------------
+
+// push adds an item with the given priority to a thread of a scope.
+func (s *stgPriority) push(scope string, id uint32, priority float64, item io.Reader) error {
+	data, err := io.ReadAll(item)
+	if err != nil {
+		return err
+	}
+
+	s.s.lock.Lock()
+	_, err = s.s.stmts["insert"].Exec(scope, id, priority, data)
+	s.s.lock.Unlock()
+
+	return err
+}
+
+// queryItem returns the item selected by the command, or ErrStorageEmpty if the thread is empty.
+func (s *stgPriority) queryItem(cmd string, scope string, id uint32) (io.Reader, error) {
+	var data = []byte{}
+
+	s.s.lock.Lock()
+	err := s.s.stmts[cmd].QueryRow(scope, id).Scan(&data)
+	s.s.lock.Unlock()
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = storage.ErrStorageEmpty
+		}
+
+		return nil, err
+	}
+
+	return bytes.NewReader(data), nil
+}
@@ -0,0 +1,198 @@
+package sqlite3
+
+import (
+	"colly/storage"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_stgPriority_Order(t *testing.T) {
+	s, err := NewPriorityStorage(filepath.Join(t.TempDir(), "queue.db"), "", false)
+	if err != nil {
+		t.Fatalf("NewPriorityStorage() error = %v", err)
+	}
+	defer s.Close()
+
+	items := []struct {
+		data     string
+		priority float64
+	}{
+		{"low", -1},
+		{"first", 0},
+		{"high", 5},
+		{"second", 0},
+		{"higher", 7.5},
+	}
+	for _, item := range items {
+		if err := s.PushPriority(1, item.priority, strings.NewReader(item.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, _ := s.Len(1); n != uint(len(items)) {
+		t.Errorf("Len() = %d, want %d", n, len(items))
+	}
+	if rdr, err := s.Peek(1); err != nil {
+		t.Errorf("Peek() error = %v", err)
+	} else if got, _ := io.ReadAll(rdr); string(got) != "higher" {
+		t.Errorf("Peek() = %q, want higher", got)
+	}
+
+	for _, want := range []string{"higher", "high", "first", "second", "low"} {
+		rdr, err := s.Pop(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := io.ReadAll(rdr); string(got) != want {
+			t.Errorf("Pop() = %q, want %q", got, want)
+		}
+	}
+
+	if _, err := s.Pop(1); !errors.Is(err, storage.ErrStorageEmpty) {
+		t.Errorf("Pop() error = %v, want %v", err, storage.ErrStorageEmpty)
+	}
+	if _, err := s.Peek(1); !errors.Is(err, storage.ErrStorageEmpty) {
+		t.Errorf("Peek() error = %v, want %v", err, storage.ErrStorageEmpty)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_stgPriority_Threads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.db")
+	s, err := NewPriorityStorage(path, "", false)
+	if err != nil {
+		t.Fatalf("NewPriorityStorage() error = %v", err)
+	}
+
+	s.Push(1, strings.NewReader("a"))
+	s.Push(1, strings.NewReader("b"))
+	s.Push(2, strings.NewReader("c"))
+
+	s.Clear(1)
+	if n, _ := s.Len(1); n != 0 {
+		t.Errorf("Len() after Clear = %d, want 0", n)
+	}
+	if n, _ := s.Len(2); n != 1 {
+		t.Errorf("Len() of other thread after Clear = %d, want 1", n)
+	}
+	s.Close()
+
+	// The items are kept by a reopened storage only if required
+	s, err = NewPriorityStorage(path, "", true)
+	if err != nil {
+		t.Fatalf("NewPriorityStorage() error = %v", err)
+	}
+	if n, _ := s.Len(2); n != 1 {
+		t.Errorf("Len() after reopening with keepData = %d, want 1", n)
+	}
+	s.Close()
+
+	s, err = NewPriorityStorage(path, "", false)
+	if err != nil {
+		t.Fatalf("NewPriorityStorage() error = %v", err)
+	}
+	defer s.Close()
+	if n, _ := s.Len(2); n != 0 {
+		t.Errorf("Len() after reopening = %d, want 0", n)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_stgPriority_ExportQueue(t *testing.T) {
+	s, err := NewPriorityStorage(filepath.Join(t.TempDir(), "queue.db"), "", false)
+	if err != nil {
+		t.Fatalf("NewPriorityStorage() error = %v", err)
+	}
+	defer s.Close()
+
+	s.PushPriority(1, -1, strings.NewReader("low"))
+	s.PushPriority(1, 2, strings.NewReader("high"))
+	s.PushPriority(1, -1, strings.NewReader("lower"))
+	s.PushPriority(2, 9, strings.NewReader("other"))
+
+	var got []string
+	err = s.ExportQueue(1, func(priority float64, data []byte) error {
+		got = append(got, fmt.Sprintf("%s:%g", data, priority))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportQueue() error = %v", err)
+	}
+	if want := []string{"high:2", "low:-1", "lower:-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ExportQueue() = %q, want %q", got, want)
+	}
+
+	if n, _ := s.Len(1); n != 3 {
+		t.Errorf("Len() after ExportQueue() = %d, want 3", n)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func Test_stgPriority_Scoped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.db")
+
+	// The tables of the earlier versions get the scope column
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE "priority_queue" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "thread" INTEGER NOT NULL, "priority" REAL NOT NULL DEFAULT 0, "data" BLOB)`)
+	if err == nil {
+		_, err = db.Exec(`INSERT INTO "priority_queue" ("thread", "priority", "data") VALUES (1, 0, 'old')`)
+	}
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewPriorityStorage(path, "", true)
+	if err != nil {
+		t.Fatalf("NewPriorityStorage() error = %v", err)
+	}
+	defer s.Close()
+
+	s.ScopedPushPriority("a|", 1, 1, strings.NewReader("A1"))
+	s.ScopedPushPriority("a|", 1, 5, strings.NewReader("A5"))
+	s.ScopedPush("a|", 2, strings.NewReader("A"))
+	s.ScopedPush("b|", 1, strings.NewReader("B"))
+
+	if n, _ := s.Len(1); n != 1 {
+		t.Errorf("Len() of the unscoped thread = %d, want 1", n)
+	}
+	if n, _ := s.ScopedLen("a|", 1); n != 2 {
+		t.Errorf("ScopedLen() = %d, want 2", n)
+	}
+	if rdr, err := s.ScopedPop("a|", 1); err != nil {
+		t.Errorf("ScopedPop() error = %v", err)
+	} else if got, _ := io.ReadAll(rdr); string(got) != "A5" {
+		t.Errorf("ScopedPop() = %q, want A5", got)
+	}
+
+	if err := s.RemovePrefix("a|"); err != nil {
+		t.Fatalf("RemovePrefix() error = %v", err)
+	}
+	for _, id := range []uint32{1, 2} {
+		if n, _ := s.ScopedLen("a|", id); n != 0 {
+			t.Errorf("ScopedLen(%d) after RemovePrefix = %d, want 0", id, n)
+		}
+	}
+	if n, _ := s.ScopedLen("b|", 1); n != 1 {
+		t.Errorf("ScopedLen() of another scope after RemovePrefix = %d, want 1", n)
+	}
+	if rdr, err := s.Pop(1); err != nil {
+		t.Errorf("Pop() error = %v", err)
+	} else if got, _ := io.ReadAll(rdr); string(got) != "old" {
+		t.Errorf("Pop() = %q, want old", got)
+	}
+}
@@ -0,0 +1,85 @@
+package sqlite3
+
+import (
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+type stgRate struct {
+	s *stgBase
+}
+
+// ------------------------------------------------------------------------
+
+const defaultRateTableName = "rate_limits"
+
+// ------------------------------------------------------------------------
+
+var (
+	cmdRate = map[string]string{
+		"create":  `CREATE TABLE IF NOT EXISTS "<table>" ("key" TEXT PRIMARY KEY NOT NULL, "next" INT NOT NULL)`,
+		"drop":    `DROP TABLE IF EXISTS "<table>"`,
+		"trim":    `DELETE FROM "<table>"`,
+		"reserve": `INSERT INTO "<table>" ("key", "next") VALUES (?, ?) ON CONFLICT("key") DO UPDATE SET "next" = MAX("<table>"."next", ?) + ? RETURNING "next"`,
+		"count":   `SELECT COUNT(*) FROM "<table>"`,
+	}
+)
+
+// ------------------------------------------------------------------------
+
+// NewRateStorage returns a pointer to a newly created SQLite3 rate limit storage.
+// The collectors sharing the database file share the rate limits.
+func NewRateStorage(path string, table string) (*stgRate, error) {
+	cfg := config{
+		table:       setTable(table, defaultRateTableName),
+		dropOnClose: false,
+		clearOnOpen: false,
+	}
+
+	s, err := NewBaseStorage(path, &cfg, cmdRate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stgRate{
+		s: s,
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+
+// Close closes the SQLite3 rate limit storage.
+func (s *stgRate) Close() error {
+	return s.s.Close()
+}
+
+// ------------------------------------------------------------------------
+
+// Clear removes all reservations from the SQLite3 rate limit storage.
+func (s *stgRate) Clear() error {
+	return s.s.Clear()
+}
+
+// ------------------------------------------------------------------------
+
+// Reserve books the next request start of the key and returns the time to wait until the start.
+// The starts of the key are at least the interval apart. The reservation is a single statement,
+// so it is atomic across the processes sharing the database.
+func (s *stgRate) Reserve(key string, interval time.Duration) (time.Duration, error) {
+	var next int64
+	now := time.Now().UnixNano()
+
+	s.s.lock.Lock()
+	err := s.s.stmts["reserve"].QueryRow(key, now+int64(interval), now, int64(interval)).Scan(&next)
+	s.s.lock.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	if wait := time.Duration(next - int64(interval) - now); wait > 0 {
+		return wait, nil
+	}
+
+	return 0, nil
+}
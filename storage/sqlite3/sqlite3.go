@@ -130,14 +130,21 @@ func NewBaseStorage(path string, config *config, commands map[string]string) (*s
 		closed: false,
 	}
 
-	if err := s.addStatements(commands); err != nil {
-		s.db.disconnect()
+	// Create the tables if necessary, the other statements can be prepared only after
+	if cmd, present := commands["create"]; present {
+		if _, err := db.dbh.Exec(strings.ReplaceAll(cmd, placeholderTable, config.table)); err != nil {
+			db.disconnect()
 
-		return nil, err
+			return nil, err
+		}
+	}
+
+	// Upgrade the tables created by the earlier versions, the statement fails if the table is up to date
+	if cmd, present := commands["migrate"]; present {
+		db.dbh.Exec(strings.ReplaceAll(cmd, placeholderTable, config.table))
 	}
 
-	// Create the tables if necessary
-	if err := s.Cmd("create"); err != nil {
+	if err := s.addStatements(commands); err != nil {
 		s.db.disconnect()
 
 		return nil, err
@@ -187,6 +194,65 @@ func (s *stgBase) Clear() error {
 
 // ------------------------------------------------------------------------
 
+// RemovePrefix removes all entries with keys starting with the prefix from the SQLite3 storage.
+func (s *stgBase) RemovePrefix(prefix string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.Cmd("purge", prefix)
+}
+
+// ------------------------------------------------------------------------
+
+// CountPrefix returns the number and the size of the entries with keys starting with the prefix.
+func (s *stgBase) CountPrefix(prefix string) (storage.PrefixStats, error) {
+	var (
+		stats storage.PrefixStats
+		cmd   = "stats"
+	)
+
+	stmt, present := s.stmts[cmd]
+	if !present {
+		return stats, storage.ErrMissingCmd(cmd)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err := stmt.QueryRow(prefix).Scan(&stats.Count, &stats.Bytes)
+
+	return stats, err
+}
+
+// ------------------------------------------------------------------------
+
+// Query runs a prepared query and calls the function with the scanner of every returned row.
+func (s *stgBase) Query(cmd string, fn func(scan func(...any) error) error, args ...any) error {
+	stmt, present := s.stmts[cmd]
+	if !present {
+		return storage.ErrMissingCmd(cmd)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows.Scan); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ------------------------------------------------------------------------
+
 // Len returns the number of entries in the SQLite3 storage.
 func (s *stgBase) Len(args ...any) (uint, error) {
 	cmd := "count"
@@ -247,6 +313,10 @@ func (s *stgBase) addStatements(commands map[string]string) error {
 	}
 
 	for key, cmd := range commands {
+		if key == "migrate" {
+			continue
+		}
+
 		stmt, err := s.db.dbh.Prepare(strings.ReplaceAll(cmd, placeholderTable, s.config.table))
 		if err != nil {
 			return err
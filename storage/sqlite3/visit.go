@@ -1,5 +1,7 @@
 package sqlite3
 
+import "colly/storage"
+
 // ------------------------------------------------------------------------
 
 type stgVisit struct {
@@ -20,7 +22,10 @@ var (
 		"insert": `INSERT INTO "<table>" ("key", "visits") VALUES (?, 1) ON CONFLICT("key") DO UPDATE SET "visits" = "visits" + 1`,
 		"select": `SELECT COALESCE("visits", 0) AS "visits" FROM "<table>" WHERE "key" = ?`,
 		"delete": `DELETE FROM "<table>" WHERE "key" = ?`,
+		"purge":  `DELETE FROM "<table>" WHERE substr("key", 1, length(?1)) = ?1`,
 		"count":  `SELECT COUNT(*) FROM "<table>"`,
+		"stats":  `SELECT COUNT(*), COALESCE(SUM(length("key")), 0) FROM "<table>" WHERE substr("key", 1, length(?1)) = ?1`,
+		"export": `SELECT "key", COALESCE("visits", 0) FROM "<table>" WHERE substr("key", 1, length(?1)) = ?1`,
 	}
 )
 
@@ -104,3 +109,49 @@ func (s *stgVisit) Remove(key string) error {
 
 	return err
 }
+
+// ------------------------------------------------------------------------
+
+// RemovePrefix deletes the stored visits with keys starting with the prefix.
+func (s *stgVisit) RemovePrefix(prefix string) error {
+	return s.s.RemovePrefix(prefix)
+}
+
+// ------------------------------------------------------------------------
+
+// CountPrefix returns the number and the size of the stored visits with keys starting with the prefix.
+func (s *stgVisit) CountPrefix(prefix string) (storage.PrefixStats, error) {
+	return s.s.CountPrefix(prefix)
+}
+
+// ------------------------------------------------------------------------
+
+// ExportVisits calls the function for every stored visit with key starting with the prefix.
+// The function is called after the entries were read, so it may use the storage.
+func (s *stgVisit) ExportVisits(prefix string, fn func(key string, visits uint) error) error {
+	type visit struct {
+		key    string
+		visits uint
+	}
+	var visits []visit
+
+	err := s.s.Query("export", func(scan func(...any) error) error {
+		var v visit
+		if err := scan(&v.key, &v.visits); err != nil {
+			return err
+		}
+		visits = append(visits, v)
+		return nil
+	}, prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range visits {
+		if err := fn(v.key, v.visits); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -3,6 +3,10 @@ package storage
 import (
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"strings"
 )
 
 // ------------------------------------------------------------------------
@@ -13,6 +17,99 @@ type BaseStorage interface {
 	Close() error // Close closes the storage ensures writes all pending updates.
 }
 
+// Purger is a storage that can remove the entries of a key prefix.
+// It is used to purge the data of a namespace from a shared storage.
+type Purger interface {
+	RemovePrefix(prefix string) error // RemovePrefix removes all entries with keys starting with the prefix.
+}
+
+// Counter is a storage that can account for the entries of a key prefix.
+// It is used to report the data stored about a domain.
+type Counter interface {
+	CountPrefix(prefix string) (PrefixStats, error) // CountPrefix returns the number and the size of the entries with keys starting with the prefix.
+}
+
+// Versioned is a key-value storage with optimistic concurrency control. The version of an entry
+// changes with its content, so the writers sharing the storage, even from several processes,
+// can detect the concurrent modifications of their read-modify-write cycles.
+type Versioned interface {
+	GetVersion(key string) (data io.Reader, version string, err error) // GetVersion retrieves an entry with its version, blank for a missing entry.
+	CompareAndSet(key string, data io.Reader, version string) error    // CompareAndSet writes an entry, or removes it if data is nil, only if its version is unchanged.
+}
+
+// Exporter is a key-value storage that can enumerate the entries of a key prefix.
+// It is used to hand the state of a crawl over to another process.
+type Exporter interface {
+	ExportPrefix(prefix string, fn func(key string, data []byte) error) error // ExportPrefix calls the function for every entry with key starting with the prefix.
+}
+
+// VisitExporter is a visit storage that can enumerate the visit counters of a key prefix.
+// It is used to hand the state of a crawl over to another process.
+type VisitExporter interface {
+	ExportVisits(prefix string, fn func(key string, visits uint) error) error // ExportVisits calls the function for every visited URL with key starting with the prefix.
+}
+
+// QueueExporter is a queue storage that can enumerate the items of a dispatch queue without removing them.
+// It is used to hand the state of a crawl over to another process.
+type QueueExporter interface {
+	ExportQueue(id uint32, fn func(priority float64, data []byte) error) error // ExportQueue calls the function for every item of a dispatch queue in the pop order, with its priority, 0 in FIFO queues.
+}
+
+// GraphStorage records the link graph of a crawl: the links discovered on the pages as edges,
+// and the outcome of the requests as nodes. The storages keep every edge, a page may link to a URL several times.
+type GraphStorage interface {
+	BaseStorage
+	AddEdge(edge *GraphEdge) error // AddEdge records a link discovered on a page.
+	AddNode(node *GraphNode) error // AddNode records the outcome of a request, it replaces the earlier outcome of the URL.
+}
+
+// GraphReader is a graph storage that can enumerate the recorded graph.
+// It is used to export the graph and to report the broken links.
+type GraphReader interface {
+	Edges() ([]*GraphEdge, error) // Edges returns the recorded edges in their recording order.
+	Nodes() ([]*GraphNode, error) // Nodes returns the recorded nodes sorted by their URL.
+}
+
+// AliasStorage records the raw URLs collapsed into the canonical keys by the URL normalization
+// and the canonical links, so the reports can show the raw URLs of every canonical page.
+type AliasStorage interface {
+	BaseStorage
+	AddAlias(key string, alias string) error                         // AddAlias maps a raw URL to a canonical key, it replaces the earlier key of the raw URL.
+	Canonical(alias string) (string, error)                          // Canonical returns the canonical key of a raw URL, blank if the URL is not an alias.
+	Aliases(key string) ([]string, error)                            // Aliases returns the sorted raw URLs mapped to the canonical key.
+	ExportAliases(fn func(key string, aliases []string) error) error // ExportAliases calls the function for every canonical key with its sorted raw URLs, in the order of the keys.
+}
+
+// GraphEdge is a link from a page to a discovered URL.
+type GraphEdge struct {
+	From   string `json:"from" bson:"from"`               // From is the URL of the linking page.
+	To     string `json:"to" bson:"to"`                   // To is the absolute URL of the link target.
+	Depth  uint16 `json:"depth" bson:"depth,omitempty"`   // Depth is the depth of the link target, one more than the depth of the page.
+	Anchor string `json:"anchor" bson:"anchor,omitempty"` // Anchor is the normalized anchor text of the link.
+}
+
+// GraphNode is the outcome of the request of a URL.
+type GraphNode struct {
+	URL        string `json:"url" bson:"url"`                           // URL is the requested URL.
+	Depth      uint16 `json:"depth" bson:"depth,omitempty"`             // Depth is the depth of the request.
+	StatusCode int    `json:"status_code" bson:"status_code,omitempty"` // StatusCode is the response status code, zero if no response was received.
+	Error      string `json:"error" bson:"error,omitempty"`             // Error is the message of the failure, blank for the successful requests.
+}
+
+// PrefixStats is the accounting of the entries of a key prefix.
+type PrefixStats struct {
+	Count uint   `json:"count" bson:"count,omitempty"` // Count is the number of the entries.
+	Bytes uint64 `json:"bytes" bson:"bytes,omitempty"` // Bytes is the size of the keys and the stored data, visit counters excluded.
+}
+
+// ------------------------------------------------------------------------
+
+// NAMESPACE_SEPARATOR separates the namespace from the keys in a shared storage.
+const NAMESPACE_SEPARATOR = "|"
+
+// DOMAIN_SEPARATOR separates the domain from the keys of the domain-scoped storages.
+const DOMAIN_SEPARATOR = "#"
+
 // ------------------------------------------------------------------------
 
 // Errors
@@ -31,6 +128,141 @@ var (
 	ErrMissingParams    = errors.New("storage parameters are missing")
 	ErrMissingStatement = errors.New("statement is missing")
 	ErrInvalidLength    = errors.New("max queue length must be positive or zero for no limit")
+	ErrInvalidTTL       = errors.New("expiry must be positive or zero for no expiry")
 	ErrInvalidNumber    = errors.New("minumum one item should be requested from the queue")
+	ErrInvalidNamespace = errors.New("namespace must not be blank or contain the separator")
+	ErrInvalidDomain    = errors.New("domain must not be blank or contain the separator")
+	ErrVersionConflict  = errors.New("the entry was modified concurrently")
 	ErrMissingCmd       = func(cmd string) error { return fmt.Errorf("%s command is missing", cmd) }
 )
+
+// ------------------------------------------------------------------------
+
+// NamespacePrefix returns the key prefix of the namespace in a shared storage.
+func NamespacePrefix(namespace string) (string, error) {
+	if namespace == "" || strings.Contains(namespace, NAMESPACE_SEPARATOR) {
+		return "", ErrInvalidNamespace
+	}
+
+	return namespace + NAMESPACE_SEPARATOR, nil
+}
+
+// ------------------------------------------------------------------------
+
+// ContentVersion returns the version of the entry data for the Versioned storages,
+// blank for a missing or empty entry.
+func ContentVersion(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	h := fnv.New64a()
+	h.Write(data)
+
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// ------------------------------------------------------------------------
+
+// PurgeNamespace removes all entries of the namespace from the shared storages.
+// It returns ErrNotImplemented if a storage doesn't implement the Purger interface.
+func PurgeNamespace(namespace string, storages ...any) error {
+	prefix, err := NamespacePrefix(namespace)
+	if err != nil {
+		return err
+	}
+
+	for _, stg := range storages {
+		p, ok := stg.(Purger)
+		if !ok {
+			return fmt.Errorf("%w: %T cannot purge a namespace", ErrNotImplemented, stg)
+		}
+
+		if err := p.RemovePrefix(prefix); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// DomainPrefix returns the key prefix of the domain in the domain-scoped storages.
+// The domain is the lower case host name of the URLs, without a port.
+func DomainPrefix(domain string) (string, error) {
+	domain = strings.ToLower(domain)
+	if domain == "" || strings.Contains(domain, DOMAIN_SEPARATOR) {
+		return "", ErrInvalidDomain
+	}
+
+	return domain + DOMAIN_SEPARATOR, nil
+}
+
+// DomainKey returns the storage key of an entry of the domain.
+// If the domain is invalid, the key is returned unscoped.
+func DomainKey(domain string, key string) string {
+	prefix, err := DomainPrefix(domain)
+	if err != nil {
+		return key
+	}
+
+	return prefix + key
+}
+
+// ------------------------------------------------------------------------
+
+// PurgeDomain removes all entries of the domain from the storages. A failing storage doesn't stop
+// the purge of the others, the errors are joined. ErrNotImplemented is returned for the storages
+// that don't implement the Purger interface.
+func PurgeDomain(domain string, storages ...any) error {
+	prefix, err := DomainPrefix(domain)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, stg := range storages {
+		p, ok := stg.(Purger)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %T cannot purge a domain", ErrNotImplemented, stg))
+			continue
+		}
+
+		if err := p.RemovePrefix(prefix); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ------------------------------------------------------------------------
+
+// CountDomain returns the total number and size of the entries of the domain in the storages.
+// It returns ErrNotImplemented if a storage doesn't implement the Counter interface.
+func CountDomain(domain string, storages ...any) (PrefixStats, error) {
+	var total PrefixStats
+
+	prefix, err := DomainPrefix(domain)
+	if err != nil {
+		return total, err
+	}
+
+	for _, stg := range storages {
+		c, ok := stg.(Counter)
+		if !ok {
+			return total, fmt.Errorf("%w: %T cannot count a domain", ErrNotImplemented, stg)
+		}
+
+		stats, err := c.CountPrefix(prefix)
+		if err != nil {
+			return total, err
+		}
+
+		total.Count += stats.Count
+		total.Bytes += stats.Bytes
+	}
+
+	return total, nil
+}
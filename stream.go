@@ -0,0 +1,146 @@
+package colly
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ------------------------------------------------------------------------
+
+// streamBody is a request body streamed from a reader without buffering.
+type streamBody struct {
+	io.Reader
+	size   int64     // number of the bytes to send, -1 if unknown
+	closer io.Closer // closed with the body, nil if the reader is not a closer
+}
+
+// ------------------------------------------------------------------------
+
+// streamContentTypes are the content types of the common bulk upload file extensions
+// that are missing or differ in the system MIME tables.
+var streamContentTypes = map[string]string{
+	".csv":    "text/csv",
+	".tsv":    "text/tab-separated-values",
+	".ndjson": "application/x-ndjson",
+	".jsonl":  "application/x-ndjson",
+	".json":   "application/json",
+}
+
+// ------------------------------------------------------------------------
+
+// PostStream continues a collector job by creating a POST request streaming the body from the reader,
+// and preserves the context of the previous request. The body is not buffered: the Content-Length header
+// is set if the size of the reader is known, e.g. for files and in-memory readers, otherwise the body is
+// sent with chunked transfer encoding. The reader is closed after sending if it is an io.Closer.
+// Streamed bodies can't be replayed, so they are not resent on redirects and retries.
+func (r *Request) PostStream(URL string, contentType string, body io.Reader) error {
+	hdr := http.Header{}
+	hdr.Set("Content-Type", contentType)
+	if r.collector.Config.UserAgentCallback != nil {
+		hdr.Set("User-Agent", r.collector.Config.UserAgentCallback())
+	}
+
+	return r.submit(r.AbsoluteURL(URL), "POST", r.Depth+1, newStreamBody(body), hdr, true)
+}
+
+// PostFile continues a collector job by creating a POST request streaming the body from the file,
+// see PostStream. If the content type is blank, it is derived from the file extension,
+// e.g. text/csv for .csv and application/x-ndjson for .ndjson and .jsonl files.
+func (r *Request) PostFile(URL string, contentType string, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	if contentType == "" {
+		contentType = fileContentType(path)
+	}
+
+	if err := r.PostStream(URL, contentType, f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// newStreamBody returns a streamed body of the reader with its size, if it can be determined.
+func newStreamBody(rdr io.Reader) *streamBody {
+	b := &streamBody{
+		Reader: rdr,
+		size:   -1,
+	}
+
+	if c, ok := rdr.(io.Closer); ok {
+		b.closer = c
+	}
+
+	switch v := rdr.(type) {
+	case *os.File:
+		if st, err := v.Stat(); err == nil && st.Mode().IsRegular() {
+			if pos, err := v.Seek(0, io.SeekCurrent); err == nil {
+				b.size = st.Size() - pos
+			}
+		}
+	case interface{ Len() int }:
+		b.size = int64(v.Len())
+	}
+
+	return b
+}
+
+// Close closes the underlying reader if it is a closer.
+func (b *streamBody) Close() error {
+	if b.closer == nil {
+		return nil
+	}
+
+	return b.closer.Close()
+}
+
+// gzipped returns a reader of the gzip compressed body, compressed on the fly.
+func (b *streamBody) gzipped() io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		zw := gzip.NewWriter(pw)
+		_, err := io.Copy(zw, b)
+		if cerr := zw.Close(); err == nil {
+			err = cerr
+		}
+		b.Close()
+		pw.CloseWithError(err)
+	}()
+
+	return pr
+}
+
+// ------------------------------------------------------------------------
+
+// applyStreamLength sets the content length of a streamed body of a known size.
+func (r *Request) applyStreamLength() {
+	if b, ok := r.Req.Body.(*streamBody); ok && b.size > 0 && r.Req.ContentLength <= 0 {
+		r.Req.ContentLength = b.size
+	}
+}
+
+// fileContentType returns the content type of the file by its extension.
+func fileContentType(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ct, present := streamContentTypes[ext]; present {
+		return ct
+	}
+
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+
+	return "application/octet-stream"
+}
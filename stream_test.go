@@ -0,0 +1,135 @@
+package colly
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestNewStreamBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.ndjson")
+	if err := os.WriteFile(path, []byte("{\"a\":1}\n{\"a\":2}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.Seek(8, io.SeekStart)
+
+	tests := []struct {
+		name   string
+		rdr    io.Reader
+		size   int64
+		closer bool
+	}{
+		{name: "file", rdr: f, size: 8, closer: true},
+		{name: "strings", rdr: strings.NewReader("a,b\n1,2\n"), size: 8},
+		{name: "buffer", rdr: bytes.NewBufferString("abc"), size: 3},
+		{name: "unknown", rdr: io.MultiReader(strings.NewReader("abc")), size: -1},
+		{name: "pipe", rdr: io.NopCloser(strings.NewReader("abc")), size: -1, closer: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newStreamBody(tt.rdr)
+			if b.size != tt.size || (b.closer != nil) != tt.closer {
+				t.Errorf("newStreamBody() size = %d, closer = %v, want %d, %v", b.size, b.closer != nil, tt.size, tt.closer)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestFileContentType(t *testing.T) {
+	for path, want := range map[string]string{
+		"data/items.CSV":  "text/csv",
+		"items.jsonl":     "application/x-ndjson",
+		"items.ndjson":    "application/x-ndjson",
+		"query.json":      "application/json",
+		"archive.unknown": "application/octet-stream",
+	} {
+		if got := fileContentType(path); got != want {
+			t.Errorf("fileContentType(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestRequest_streamedBody(t *testing.T) {
+	type received struct {
+		length   int64
+		encoding []string
+		body     string
+	}
+	got := make(chan received, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rdr io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			rdr = zr
+		}
+		body, _ := io.ReadAll(rdr)
+		got <- received{length: r.ContentLength, encoding: r.TransferEncoding, body: string(body)}
+	}))
+	defer srv.Close()
+
+	data := strings.Repeat("1,2,3\n", 1000)
+
+	tests := []struct {
+		name     string
+		rdr      io.Reader
+		compress BodyCompression
+		length   int64
+	}{
+		{name: "known size", rdr: strings.NewReader(data), length: int64(len(data))},
+		{name: "chunked", rdr: io.MultiReader(strings.NewReader(data)), length: -1},
+		{name: "compressed", rdr: strings.NewReader(data), compress: BODY_COMPRESSION_ON, length: -1},
+		{name: "not compressed automatically", rdr: strings.NewReader(data), compress: BODY_COMPRESSION_AUTO, length: int64(len(data))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", srv.URL, newStreamBody(tt.rdr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &Request{Req: req, CompressBody: tt.compress}
+
+			if _, err := r.applyCompression(nil, 1, false); err != nil {
+				t.Fatal(err)
+			}
+			r.applyStreamLength()
+
+			resp, err := http.DefaultClient.Do(r.Req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			rec := <-got
+			if rec.length != tt.length || rec.body != data {
+				t.Errorf("received length = %d, %d bytes, want %d, %d bytes", rec.length, len(rec.body), tt.length, len(data))
+			}
+			if chunked := len(rec.encoding) > 0 && rec.encoding[0] == "chunked"; chunked != (tt.length < 0) {
+				t.Errorf("TransferEncoding = %v", rec.encoding)
+			}
+		})
+	}
+}
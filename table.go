@@ -0,0 +1,277 @@
+package colly
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ------------------------------------------------------------------------
+
+// tableSpan is a cell spanning the next rows of its column.
+type tableSpan struct {
+	rows int    // number of the next rows covered by the cell
+	text string // text of the cell
+}
+
+// ------------------------------------------------------------------------
+
+// Table returns the rows of the table element, or of the first table inside the element,
+// as a grid of the stripped cell texts. The header rows are included. The cells spanning
+// several columns or rows are repeated in every covered position, so all rows are aligned.
+func (h *HTMLElement) Table() [][]string {
+	grid, _ := parseTable(h.tableSelection())
+
+	return grid
+}
+
+// TableWithHeader returns the header and the body rows of the table element, or of the first
+// table inside the element. The header rows are the rows of the thead element and the leading
+// rows made of th cells only. If there are several header rows, the last one is returned,
+// the spanning cells of the others are repeated in it. The header is nil if no header is found.
+func (h *HTMLElement) TableWithHeader() ([]string, [][]string) {
+	grid, headers := parseTable(h.tableSelection())
+	if headers == 0 {
+		return nil, grid
+	}
+
+	return grid[headers-1], grid[headers:]
+}
+
+// UnmarshalTable maps the body rows of the table element, or of the first table inside the
+// element, to the structs of the slice pointed by v. The columns are mapped to the fields
+// by their "table" tag or by their name, matching the header names case-insensitively.
+// The fields with a "-" tag are ignored.
+// Supported field types: string, bool, int*, uint*, float*
+//
+// Example struct declaration:
+//
+//	type Price struct {
+//		Product string  `table:"Product name"`
+//		Price   float64 `table:"Price (USD)"`
+//		Stock   int
+//	}
+func (h *HTMLElement) UnmarshalTable(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return ErrTableInvalidTarget
+	}
+
+	slice := rv.Elem()
+	et := slice.Type().Elem()
+	isPtr := et.Kind() == reflect.Ptr
+	if isPtr {
+		et = et.Elem()
+	}
+	if et.Kind() != reflect.Struct {
+		return ErrTableInvalidTarget
+	}
+
+	table := h.tableSelection()
+	if table.Length() == 0 {
+		return ErrTableNotFound
+	}
+
+	grid, headers := parseTable(table)
+	if headers == 0 {
+		return nil
+	}
+
+	// Map the columns to the fields
+	columns := map[string]int{}
+	for i, name := range grid[headers-1] {
+		name = strings.ToLower(name)
+		if _, present := columns[name]; !present {
+			columns[name] = i
+		}
+	}
+
+	fields := map[int]int{}
+	for i := 0; i < et.NumField(); i++ {
+		f := et.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Tag.Get("table")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		if col, present := columns[strings.ToLower(strings.TrimSpace(name))]; present {
+			fields[i] = col
+		}
+	}
+
+	for _, row := range grid[headers:] {
+		item := reflect.New(et)
+		for field, col := range fields {
+			if col >= len(row) {
+				continue
+			}
+			if err := setTableField(item.Elem().Field(field), row[col]); err != nil {
+				return err
+			}
+		}
+
+		if isPtr {
+			slice.Set(reflect.Append(slice, item))
+		} else {
+			slice.Set(reflect.Append(slice, item.Elem()))
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// tableSelection returns the element if it is a table, or the first table inside it.
+func (h *HTMLElement) tableSelection() *goquery.Selection {
+	if h.DOM == nil {
+		return &goquery.Selection{}
+	}
+
+	if goquery.NodeName(h.DOM) == "table" {
+		return h.DOM.First()
+	}
+
+	return h.DOM.Find("table").First()
+}
+
+// parseTable returns the rows of the table as a grid with the spanning cells repeated,
+// and the number of the leading header rows. The rows of the nested tables are skipped.
+func parseTable(table *goquery.Selection) (grid [][]string, headers int) {
+	var spans []tableSpan
+	inHeader := true
+
+	table.ChildrenFiltered("thead, tbody, tfoot, tr").Each(func(_ int, s *goquery.Selection) {
+		rows := s
+		if goquery.NodeName(s) != "tr" {
+			rows = s.ChildrenFiltered("tr")
+		}
+		thead := goquery.NodeName(s) == "thead"
+
+		rows.Each(func(_ int, tr *goquery.Selection) {
+			var row []string
+			col := 0
+
+			// Fill the positions covered by the spanning cells of the previous rows
+			fill := func() {
+				for col < len(spans) && spans[col].rows > 0 {
+					row = append(row, spans[col].text)
+					spans[col].rows--
+					col++
+				}
+			}
+
+			onlyTH := true
+			tr.ChildrenFiltered("td, th").Each(func(_ int, cell *goquery.Selection) {
+				fill()
+
+				if goquery.NodeName(cell) != "th" {
+					onlyTH = false
+				}
+
+				text := strings.TrimSpace(cell.Text())
+				colspan := tableSpanAttr(cell, "colspan")
+				rowspan := tableSpanAttr(cell, "rowspan")
+
+				for i := 0; i < colspan; i++ {
+					row = append(row, text)
+					if rowspan > 1 {
+						for len(spans) <= col {
+							spans = append(spans, tableSpan{})
+						}
+						spans[col] = tableSpan{rows: rowspan - 1, text: text}
+					}
+					col++
+				}
+			})
+			fill()
+
+			if row == nil {
+				return
+			}
+
+			if inHeader && (thead || onlyTH) {
+				headers++
+			} else {
+				inHeader = false
+			}
+			grid = append(grid, row)
+		})
+	})
+
+	return grid, headers
+}
+
+// tableSpanAttr returns the value of a colspan or rowspan attribute, or 1 if it's invalid.
+func tableSpanAttr(cell *goquery.Selection, name string) int {
+	val, ok := cell.Attr(name)
+	if !ok {
+		return 1
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil || n < 1 {
+		return 1
+	}
+
+	// Browsers clamp the spans to these limits
+	if name == "colspan" && n > 1000 {
+		return 1000
+	}
+	if n > 65534 {
+		return 65534
+	}
+
+	return n
+}
+
+// setTableField sets a struct field from the text of a table cell.
+func setTableField(f reflect.Value, text string) error {
+	if text == "" {
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(text)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.ReplaceAll(text, ",", ""), 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.ReplaceAll(text, ",", ""), 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.ReplaceAll(text, ",", ""), f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package colly
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ------------------------------------------------------------------------
+
+var tableTestData = `<div>
+<table>
+	<thead>
+		<tr><th rowspan="2">Product</th><th colspan="2">Price</th></tr>
+		<tr><th>Net</th><th>Gross</th></tr>
+	</thead>
+	<tbody>
+		<tr><td rowspan="2">Apple</td><td>1.00</td><td>1.20</td></tr>
+		<tr><td>2.00</td><td>2.40</td></tr>
+		<tr><td>Pear</td><td colspan="2">1,000</td></tr>
+		<tr><td>Plum<table><tr><td>nested</td></tr></table></td><td></td><td>3</td></tr>
+	</tbody>
+</table>
+</div>`
+
+// ------------------------------------------------------------------------
+
+func newTableTestElement(t *testing.T, data string) *HTMLElement {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &HTMLElement{Name: "div", DOM: doc.Find("div").First()}
+}
+
+// ------------------------------------------------------------------------
+
+func TestHTMLElement_Table(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		header []string
+		rows   [][]string
+	}{
+		{
+			name:   "spans",
+			data:   tableTestData,
+			header: []string{"Product", "Net", "Gross"},
+			rows: [][]string{
+				{"Apple", "1.00", "1.20"},
+				{"Apple", "2.00", "2.40"},
+				{"Pear", "1,000", "1,000"},
+				{"Plumnested", "", "3"},
+			},
+		},
+		{
+			name:   "th row",
+			data:   `<div><table><tr><th>a</th><th>b</th></tr><tr><td>1</td><td>2</td></tr><tr><th>x</th><td>3</td></tr></table></div>`,
+			header: []string{"a", "b"},
+			rows:   [][]string{{"1", "2"}, {"x", "3"}},
+		},
+		{
+			name: "no header",
+			data: `<div><table><tr><td>1</td><td rowspan="x">2</td></tr></table></div>`,
+			rows: [][]string{{"1", "2"}},
+		},
+		{
+			name: "no table",
+			data: `<div><p>1</p></div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTableTestElement(t, tt.data)
+
+			header, rows := e.TableWithHeader()
+			if !reflect.DeepEqual(header, tt.header) {
+				t.Errorf("header = %q, want %q", header, tt.header)
+			}
+			if !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("rows = %q, want %q", rows, tt.rows)
+			}
+
+			grid := e.Table()
+			if len(grid) < len(tt.rows) || !reflect.DeepEqual(grid[len(grid)-len(tt.rows):], tt.rows) {
+				t.Errorf("Table() = %q, want the rows at the end", grid)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestHTMLElement_UnmarshalTable(t *testing.T) {
+	type price struct {
+		Product string
+		Net     float64 `table:"net"`
+		Gross   string  `table:"-"`
+		Total   int
+		hidden  string
+	}
+
+	e := newTableTestElement(t, strings.Replace(tableTestData, "<td></td>", "<td>n/a</td>", 1))
+
+	got := []price{}
+	if err := e.UnmarshalTable(&got); err == nil {
+		t.Fatal("UnmarshalTable() should fail with the unparsable float")
+	}
+
+	e = newTableTestElement(t, tableTestData)
+	got = []price{}
+	if err := e.UnmarshalTable(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []price{
+		{Product: "Apple", Net: 1},
+		{Product: "Apple", Net: 2},
+		{Product: "Pear", Net: 1000},
+		{Product: "Plumnested"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalTable() = %+v, want %+v", got, want)
+	}
+
+	ptrs := []*price{}
+	if err := e.UnmarshalTable(&ptrs); err != nil || len(ptrs) != 4 || *ptrs[2] != want[2] {
+		t.Errorf("UnmarshalTable() with pointers = %v, %v", ptrs, err)
+	}
+
+	if err := e.UnmarshalTable(got); err != ErrTableInvalidTarget {
+		t.Errorf("UnmarshalTable() with a slice = %v, want %v", err, ErrTableInvalidTarget)
+	}
+	if err := newTableTestElement(t, `<div></div>`).UnmarshalTable(&got); err != ErrTableNotFound {
+		t.Errorf("UnmarshalTable() without table = %v, want %v", err, ErrTableNotFound)
+	}
+}
@@ -0,0 +1,144 @@
+package colly
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// hostThrottle scales the request delays of the hosts, as adjusted by the callbacks.
+type hostThrottle struct {
+	factors map[string]float64 // delay factors mapped by the lowercase host names
+	lock    *sync.Mutex
+}
+
+// ------------------------------------------------------------------------
+
+// Throttle limits
+const (
+	THROTTLE_MIN_FACTOR = 0.1                    // Lowest delay factor of a host.
+	THROTTLE_MAX_FACTOR = 100.0                  // Highest delay factor of a host.
+	THROTTLE_BASE_DELAY = 100 * time.Millisecond // Delay scaled by the factor if the host has no delay configured.
+)
+
+// ------------------------------------------------------------------------
+
+// newHostThrottle returns a pointer to a newly created host throttle.
+func newHostThrottle() *hostThrottle {
+	return &hostThrottle{
+		factors: map[string]float64{},
+		lock:    &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// AdjustRate multiplies the request delay of the host by the factor and returns the resulting
+// delay factor of the host. Factors above 1 slow down, factors below 1 speed up the requests.
+// The adjustments are cumulative and the result is kept between THROTTLE_MIN_FACTOR and THROTTLE_MAX_FACTOR.
+// A host without a configured delay is slowed down from THROTTLE_BASE_DELAY.
+// It is safe to call from the OnResponse and OnError callbacks, e.g. after parsing the rate limit headers.
+func (c *Collector) AdjustRate(host string, factor float64) float64 {
+	f, changed := c.throttle.adjust(host, factor)
+	if changed && c.HasLogger() {
+		c.logEvent(LOG_INFO_LEVEL, "throttle", 0, map[string]string{
+			"host":   host,
+			"factor": strconv.FormatFloat(f, 'g', 4, 64),
+		})
+	}
+
+	return f
+}
+
+// ResetRate restores the configured request delay of the host.
+func (c *Collector) ResetRate(host string) {
+	c.throttle.reset(host)
+}
+
+// RateFactor returns the delay factor of the host, 1 if the rate was not adjusted.
+func (c *Collector) RateFactor(host string) float64 {
+	return c.throttle.factor(host)
+}
+
+// Throttle adjusts the request delay of the host of the request by the factor.
+// See Collector.AdjustRate.
+func (r *Request) Throttle(factor float64) float64 {
+	if r.collector == nil || r.Req == nil || r.Req.URL == nil {
+		return 1
+	}
+
+	return r.collector.AdjustRate(r.Req.URL.Host, factor)
+}
+
+// ------------------------------------------------------------------------
+
+// adjust multiplies the delay factor of the host and returns the new factor
+// and whether it was changed.
+func (t *hostThrottle) adjust(host string, factor float64) (float64, bool) {
+	host = strings.ToLower(host)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	old, present := t.factors[host]
+	if !present {
+		old = 1
+	}
+	if factor <= 0 {
+		return old, false
+	}
+
+	f := old * factor
+	if f < THROTTLE_MIN_FACTOR {
+		f = THROTTLE_MIN_FACTOR
+	} else if f > THROTTLE_MAX_FACTOR {
+		f = THROTTLE_MAX_FACTOR
+	}
+
+	if f == 1 {
+		delete(t.factors, host)
+	} else {
+		t.factors[host] = f
+	}
+
+	return f, f != old
+}
+
+// reset removes the delay factor of the host.
+func (t *hostThrottle) reset(host string) {
+	t.lock.Lock()
+	delete(t.factors, strings.ToLower(host))
+	t.lock.Unlock()
+}
+
+// factor returns the delay factor of the host.
+func (t *hostThrottle) factor(host string) float64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if f, present := t.factors[strings.ToLower(host)]; present {
+		return f
+	}
+
+	return 1
+}
+
+// scale returns the request delay of the host multiplied by its delay factor.
+func (t *hostThrottle) scale(host string, delay time.Duration) time.Duration {
+	if t == nil {
+		return delay
+	}
+
+	f := t.factor(host)
+	switch {
+	case f == 1:
+		return delay
+	case delay <= 0 && f > 1:
+		delay = THROTTLE_BASE_DELAY
+	}
+
+	return time.Duration(float64(delay) * f)
+}
@@ -0,0 +1,54 @@
+package colly
+
+import (
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_hostThrottle(t *testing.T) {
+	tests := []struct {
+		name    string
+		factors []float64
+		delay   time.Duration
+		want    time.Duration
+	}{
+		{name: "not adjusted", delay: time.Second, want: time.Second},
+		{name: "slow down", factors: []float64{2}, delay: time.Second, want: 2 * time.Second},
+		{name: "cumulative", factors: []float64{2, 2, 0.5}, delay: time.Second, want: 2 * time.Second},
+		{name: "speed up", factors: []float64{0.5}, delay: time.Second, want: 500 * time.Millisecond},
+		{name: "back to normal", factors: []float64{4, 0.25}, delay: time.Second, want: time.Second},
+		{name: "lower limit", factors: []float64{0.01}, delay: time.Second, want: 100 * time.Millisecond},
+		{name: "upper limit", factors: []float64{1000}, delay: time.Millisecond, want: 100 * time.Millisecond},
+		{name: "invalid factor", factors: []float64{0, -1}, delay: time.Second, want: time.Second},
+		{name: "no delay slow down", factors: []float64{3}, want: 3 * THROTTLE_BASE_DELAY},
+		{name: "no delay speed up", factors: []float64{0.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := newHostThrottle()
+			for _, f := range tt.factors {
+				th.adjust("Example.com", f)
+			}
+
+			if got := th.scale("example.com", tt.delay); got != tt.want {
+				t.Errorf("scale() = %v, want %v", got, tt.want)
+			}
+			if got := th.scale("other.com", tt.delay); got != tt.delay {
+				t.Errorf("scale() of other host = %v, want %v", got, tt.delay)
+			}
+		})
+	}
+}
+
+func Test_hostThrottle_reset(t *testing.T) {
+	th := newHostThrottle()
+	th.adjust("example.com", 4)
+	th.reset("EXAMPLE.COM")
+
+	if got := th.factor("example.com"); got != 1 {
+		t.Errorf("factor() after reset = %v, want 1", got)
+	}
+}
@@ -0,0 +1,110 @@
+package colly
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync/atomic"
+)
+
+// ------------------------------------------------------------------------
+
+// TLSSessionCache is a TLS client session cache shared by all transports of a collector,
+// so the sessions can be resumed across the connection pool, the sessions and the proxies.
+// It counts the full and the resumed handshakes of the TLS configurations it was applied to.
+type TLSSessionCache struct {
+	cache   tls.ClientSessionCache
+	full    uint64 // atomic
+	resumed uint64 // atomic
+}
+
+// TLSStats is a snapshot of the TLS handshake counters.
+type TLSStats struct {
+	Full    uint64 `json:"full" bson:"full,omitempty"`       // Full is the number of the full handshakes.
+	Resumed uint64 `json:"resumed" bson:"resumed,omitempty"` // Resumed is the number of the handshakes resuming a cached session.
+}
+
+// ------------------------------------------------------------------------
+
+// NewTLSSessionCache returns a pointer to a newly created shared TLS session cache
+// holding at most capacity sessions. If capacity is zero or negative, the default capacity is used.
+func NewTLSSessionCache(capacity int) *TLSSessionCache {
+	return &TLSSessionCache{
+		cache: tls.NewLRUClientSessionCache(capacity),
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// Get implements the tls.ClientSessionCache interface.
+func (s *TLSSessionCache) Get(sessionKey string) (*tls.ClientSessionState, bool) {
+	return s.cache.Get(sessionKey)
+}
+
+// Put implements the tls.ClientSessionCache interface.
+func (s *TLSSessionCache) Put(sessionKey string, cs *tls.ClientSessionState) {
+	s.cache.Put(sessionKey, cs)
+}
+
+// ------------------------------------------------------------------------
+
+// Apply returns a copy of the TLS configuration using the shared session cache.
+// The handshakes made with the returned configuration are counted by the cache.
+// A blank configuration is used if cfg is nil.
+func (s *TLSSessionCache) Apply(cfg *tls.Config) *tls.Config {
+	if cfg == nil {
+		cfg = &tls.Config{}
+	} else {
+		cfg = cfg.Clone()
+	}
+
+	cfg.ClientSessionCache = s
+
+	verify := cfg.VerifyConnection
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if cs.DidResume {
+			atomic.AddUint64(&s.resumed, 1)
+		} else {
+			atomic.AddUint64(&s.full, 1)
+		}
+
+		if verify != nil {
+			return verify(cs)
+		}
+
+		return nil
+	}
+
+	return cfg
+}
+
+// Stats returns a snapshot of the handshake counters.
+func (s *TLSSessionCache) Stats() TLSStats {
+	return TLSStats{
+		Full:    atomic.LoadUint64(&s.full),
+		Resumed: atomic.LoadUint64(&s.resumed),
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// tlsTransport returns a transport with the TLS configuration and the session cache of the
+// configuration based on the custom transport if there is one, or nil if the default transport can be used.
+func (c *CollectorConfig) tlsTransport(rt http.RoundTripper, tlsConfig *tls.Config) *http.Transport {
+	custom, _ := rt.(*http.Transport)
+	if tlsConfig == nil && c.TLSSessionCache == nil {
+		return custom
+	}
+
+	transport := http.DefaultTransport.(*http.Transport)
+	if custom != nil {
+		transport = custom
+	}
+	transport = transport.Clone()
+	if c.TLSSessionCache != nil {
+		transport.TLSClientConfig = c.TLSSessionCache.Apply(tlsConfig)
+	} else if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig.Clone()
+	}
+
+	return transport
+}
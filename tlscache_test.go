@@ -0,0 +1,38 @@
+package colly
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestTLSSessionCache(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	cache := NewTLSSessionCache(0)
+	base := ts.Client().Transport.(*http.Transport)
+
+	// Separate transports, like the session transports, share the cache
+	for i := 0; i < 2; i++ {
+		transport := base.Clone()
+		transport.TLSClientConfig = cache.Apply(base.TLSClientConfig)
+
+		resp, err := (&http.Client{Transport: transport}).Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+		transport.CloseIdleConnections()
+	}
+
+	if got, want := cache.Stats(), (TLSStats{Full: 1, Resumed: 1}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+package colly
+
+import (
+	"colly/storage/mem"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobwas/glob"
+)
+
+// ------------------------------------------------------------------------
+
+// Limiter paces the requests of the hosts. If CollectorConfig.Limiter is set, the client waits
+// for it before every request instead of sleeping for the delays of the client configurations.
+type Limiter interface {
+	Wait(ctx context.Context, host string) error // Wait blocks until a request of the host can be sent, or the context is done.
+}
+
+// TokenBucketStorage keeps the token buckets of a token bucket limiter. The buckets of a shared
+// storage, e.g. the one of the storage/redis package, are shared by the collectors and the processes
+// using it, so they can share a request budget. The storage/mem package provides one for a process.
+type TokenBucketStorage interface {
+	Take(key string, rate float64, burst uint) (time.Duration, error) // Take takes a token of the bucket and returns the time to wait until the token is available.
+}
+
+// TokenBucketRule is the request budget of the hosts matching a domain glob.
+type TokenBucketRule struct {
+	Glob  string  `json:"glob" bson:"glob"`             // Glob is the domain glob of the hosts, e.g. "*.example.com".
+	Rate  float64 `json:"rate" bson:"rate"`             // Rate is the number of the requests per second.
+	Burst uint    `json:"burst" bson:"burst,omitempty"` // Burst is the number of the requests that can be sent at once, at least 1.
+
+	glob glob.Glob
+}
+
+// TokenBucketLimiter is a Limiter with a token bucket per domain glob. The hosts matching
+// the same glob share the bucket, the hosts matching no glob are not limited.
+type TokenBucketLimiter struct {
+	rules   []*TokenBucketRule
+	storage TokenBucketStorage
+	lock    *sync.RWMutex
+}
+
+// ------------------------------------------------------------------------
+
+// NewTokenBucketLimiter returns a pointer to a newly created token bucket limiter
+// keeping its buckets in the storage. Nil storage keeps them in memory.
+func NewTokenBucketLimiter(stg TokenBucketStorage) *TokenBucketLimiter {
+	if stg == nil {
+		stg = mem.NewTokenBucketStorage()
+	}
+
+	return &TokenBucketLimiter{
+		storage: stg,
+		lock:    &sync.RWMutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// AddRule adds the request budget of the hosts matching the domain glob.
+// The rules are matched in the order they were added.
+func (l *TokenBucketLimiter) AddRule(domainGlob string, rate float64, burst uint) error {
+	if rate <= 0 {
+		return fmt.Errorf("%w: rate of %q", ErrInvalidRate, domainGlob)
+	}
+
+	g, err := glob.Compile(strings.ToLower(domainGlob))
+	if err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.rules = append(l.rules, &TokenBucketRule{
+		Glob:  domainGlob,
+		Rate:  rate,
+		Burst: burst,
+		glob:  g,
+	})
+
+	return nil
+}
+
+// Rules returns a copy of the rules of the limiter.
+func (l *TokenBucketLimiter) Rules() []TokenBucketRule {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	rules := make([]TokenBucketRule, len(l.rules))
+	for i, r := range l.rules {
+		rules[i] = *r
+	}
+
+	return rules
+}
+
+// ------------------------------------------------------------------------
+
+// Wait implements the Limiter interface. It takes a token of the bucket of the first rule
+// matching the host and waits until the token is available. The port of the host is ignored.
+func (l *TokenBucketLimiter) Wait(ctx context.Context, host string) error {
+	rule := l.match(host)
+	if rule == nil {
+		return nil
+	}
+
+	wait, err := l.storage.Take(rule.Glob, rule.Rate, rule.Burst)
+	if err != nil {
+		return err
+	}
+
+	return sleepContext(ctx, wait)
+}
+
+// match returns the first rule matching the host, or nil if there is none.
+func (l *TokenBucketLimiter) match(host string) *TokenBucketRule {
+	host = strings.ToLower(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	for _, r := range l.rules {
+		if r.glob.Match(host) {
+			return r
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package colly
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestTokenBucketLimiter_Wait(t *testing.T) {
+	l := NewTokenBucketLimiter(nil)
+	if err := l.AddRule("*.example.com", 0, 1); !errors.Is(err, ErrInvalidRate) {
+		t.Errorf("AddRule() with zero rate error = %v, want %v", err, ErrInvalidRate)
+	}
+	if err := l.AddRule("*.example.com", 10, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	start := time.Now()
+	for _, host := range []string{"a.example.com", "B.Example.com:8080", "c.example.com", "example.org", "example.org"} {
+		if err := l.Wait(ctx, host); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The third request of the shared bucket waits for a refill, the unmatched host doesn't wait
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond || elapsed > 200*time.Millisecond {
+		t.Errorf("waited %v, want about 100ms", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.Wait(cancelled, "a.example.com"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() with cancelled context error = %v, want %v", err, context.Canceled)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_sharedLimiter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	l := NewTokenBucketLimiter(nil)
+	if err := l.AddRule("127.0.0.1", 20, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	newCollector := func() *Collector {
+		cfg := NewConfig()
+		cfg.Async = true
+		cfg.Limiter = l
+		return NewCollector(cfg, nil)
+	}
+	first, second := newCollector(), newCollector()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		first.Visit(srv.URL + "/first/" + string(rune('a'+i)))
+		second.Visit(srv.URL + "/second/" + string(rune('a'+i)))
+	}
+	first.Wait()
+	second.Wait()
+
+	// The six requests of the two collectors share one budget of 20 requests per second
+	if elapsed := time.Since(start); elapsed < 240*time.Millisecond {
+		t.Errorf("6 requests took %v, want at least 250ms", elapsed)
+	}
+	if got := first.ResponseCount() + second.ResponseCount(); got != 6 {
+		t.Errorf("%d responses, want 6", got)
+	}
+}
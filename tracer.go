@@ -14,8 +14,17 @@ type Tracer interface {
 	WithContext(ctx context.Context) context.Context // WithContext returns a new context based on the provided parent context.
 }
 
+// ProtocolTracer is a tracer that records the protocol of the responses,
+// e.g. to compare the timings of the hosts served over HTTP/3 and of the fallbacks.
+type ProtocolTracer interface {
+	Tracer
+	TraceProtocol(proto string, fallback bool) // TraceProtocol records the protocol of a response, fallback is true if HTTP/3 failed.
+}
+
 // simpleTracer provides a simple data structure for storing an http trace.
 type simpleTracer struct {
+	protocol     string
+	fallback     bool
 	start        time.Time
 	connectStart time.Duration
 	connectDone  time.Duration
@@ -52,3 +61,11 @@ func NewSimpleTracer() *simpleTracer {
 func (t *simpleTracer) WithContext(ctx context.Context) context.Context {
 	return httptrace.WithClientTrace(ctx, t.ct)
 }
+
+// ------------------------------------------------------------------------
+
+// TraceProtocol records the protocol of a response, fallback is true if HTTP/3 failed.
+func (t *simpleTracer) TraceProtocol(proto string, fallback bool) {
+	t.protocol = proto
+	t.fallback = fallback
+}
@@ -0,0 +1,97 @@
+package colly
+
+import (
+	"hash/fnv"
+	"strconv"
+	"sync"
+)
+
+// ------------------------------------------------------------------------
+
+// trapDetector watches the bodies of the consecutive responses of the hosts. Sites serving
+// the same content for many distinct URLs, e.g. infinite calendars, are spider traps.
+type trapDetector struct {
+	hosts map[string]*bodyWindow // window of the latest responses by host
+	lock  *sync.Mutex
+}
+
+// bodyWindow is the run of the identical consecutive bodies of a host.
+type bodyWindow struct {
+	digest uint64 // digest of the latest body
+	run    uint   // number of the consecutive responses with the digest
+}
+
+// ------------------------------------------------------------------------
+
+// newTrapDetector returns a pointer to a newly created trap detector.
+func newTrapDetector() *trapDetector {
+	return &trapDetector{
+		hosts: map[string]*bodyWindow{},
+		lock:  &sync.Mutex{},
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// add records the body of a response of the host and returns the number of the consecutive
+// responses of the host with an identical body, including this one.
+func (td *trapDetector) add(host string, body []byte) uint {
+	h := fnv.New64a()
+	h.Write(body)
+	digest := h.Sum64()
+
+	td.lock.Lock()
+	defer td.lock.Unlock()
+
+	w, present := td.hosts[host]
+	if !present {
+		w = &bodyWindow{}
+		td.hosts[host] = w
+	}
+
+	if w.run > 0 && w.digest == digest {
+		w.run++
+	} else {
+		w.digest = digest
+		w.run = 1
+	}
+
+	return w.run
+}
+
+// ------------------------------------------------------------------------
+
+// Trapped returns true if the response of the request was detected in a spider trap,
+// see CollectorConfig.TrapWindow.
+func (r *Request) Trapped() bool {
+	return r.trapped
+}
+
+// ------------------------------------------------------------------------
+
+// detectTrap marks the request of the response as trapped if the last TrapWindow responses
+// of its host had identical bodies. The trap is logged when the window fills up.
+func (c *Collector) detectTrap(resp *Response) {
+	window := c.Config.TrapWindow
+	if window == 0 || len(resp.Body) == 0 || resp.Request == nil {
+		return
+	}
+
+	req := resp.Request
+	run := c.traps.add(req.Req.URL.Host, resp.Body)
+	if run < window {
+		return
+	}
+
+	req.trapped = true
+	c.bus.Publish(TOPIC_TRAP, TrapSignal{RequestSignal: req.signal(), Host: req.Req.URL.Host, Identical: run})
+
+	if run == window && c.HasLogger() {
+		c.logEvent(LOG_WARN_LEVEL, "spider_trap", req.ID, map[string]string{
+			"url":         req.Req.URL.String(),
+			"host":        req.Req.URL.Host,
+			"identical":   strconv.FormatUint(uint64(run), 10),
+			"stop_follow": strconv.FormatBool(c.Config.TrapStopFollow),
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package colly
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func Test_trapDetector_add(t *testing.T) {
+	td := newTrapDetector()
+
+	steps := []struct {
+		host string
+		body string
+		want uint
+	}{
+		{"a", "same", 1},
+		{"a", "same", 2},
+		{"b", "same", 1},
+		{"a", "same", 3},
+		{"a", "other", 1},
+		{"a", "same", 1},
+		{"b", "same", 2},
+	}
+
+	for i, s := range steps {
+		if got := td.add(s.host, []byte(s.body)); got != s.want {
+			t.Errorf("step %d: add(%q, %q) = %d, want %d", i, s.host, s.body, got, s.want)
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_detectTrap(t *testing.T) {
+	// Every page of the calendar is identical and links to a new URL
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><a href="next/">Next month</a></body></html>`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		stopFollow   bool
+		wantRequests int
+		wantTrapped  uint32
+	}{
+		{"stop following", true, 3, 1},
+		{"keep following", false, 5, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.MaxDepth = 5
+			cfg.TrapWindow = 3
+			cfg.TrapStopFollow = tt.stopFollow
+
+			c := NewCollector(cfg, nil)
+
+			requests := 0
+			var followErr error
+			c.OnRequest(func(r *Request) { requests++ })
+			c.OnHTML("a[href]", func(e *HTMLElement) {
+				if err := e.Response.Request.Visit(e.Attr("href")); err != nil && followErr == nil {
+					followErr = err
+				}
+			})
+
+			if err := c.Visit(srv.URL + "/calendar/"); err != nil {
+				t.Fatal(err)
+			}
+			c.Wait()
+
+			if requests != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", requests, tt.wantRequests)
+			}
+			if got := c.Stats().Trapped; got != tt.wantTrapped {
+				t.Errorf("Stats().Trapped = %d, want %d", got, tt.wantTrapped)
+			}
+			if tt.stopFollow && !errors.Is(followErr, ErrSpiderTrap) {
+				t.Errorf("Visit() error = %v, want %v", followErr, ErrSpiderTrap)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package colly
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// ------------------------------------------------------------------------
+
+// truncationKey is the context key type of the values used for truncated response retries.
+type truncationKey uint8
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	rdr io.Reader
+	n   int64 // number of the bytes read
+}
+
+// ------------------------------------------------------------------------
+
+const (
+	// TruncatedRetryKey is the context key for the number of retries of a truncated response.
+	TruncatedRetryKey truncationKey = iota
+)
+
+// ------------------------------------------------------------------------
+
+// TruncatedRetries returns the number of times the request was made again
+// because its response was truncated.
+func (r *Request) TruncatedRetries() uint {
+	if r.Ctx == nil {
+		return 0
+	}
+
+	if n, ok := (*r.Ctx).Value(TruncatedRetryKey).(uint); ok {
+		return n
+	}
+
+	return 0
+}
+
+// ------------------------------------------------------------------------
+
+// handleTruncated counts and logs a truncated response, then requests it again if
+// the retries are enabled. It returns true if the request was submitted again,
+// the truncated response should not be processed then.
+func (c *Collector) handleTruncated(resp *Response) bool {
+	c.bus.Publish(TOPIC_TRUNCATED, resp.Request.signal())
+
+	req := resp.Request
+	count := req.TruncatedRetries() + 1
+	retry := count <= c.Config.MaxTruncatedRetries
+
+	if c.HasLogger() {
+		c.logEvent(LOG_WARN_LEVEL, "truncated", req.ID, map[string]string{
+			"url":            req.Req.URL.String(),
+			"content_length": strconv.FormatInt(resp.Resp.ContentLength, 10),
+			"received":       strconv.Itoa(len(resp.Body)),
+			"retry":          strconv.FormatBool(retry),
+		})
+	}
+
+	if !retry {
+		return false
+	}
+
+	parent := context.Background()
+	if req.Ctx != nil {
+		parent = *req.Ctx
+	}
+	ctx := context.WithValue(parent, TruncatedRetryKey, count)
+
+	// The body was read by the first attempt
+	body, ok := replayBody(req.Req)
+	if !ok {
+		c.Config.logError(LOG_WARN_LEVEL, fmt.Errorf("truncated response of %s not retried: request body can't be replayed", req.Req.URL))
+		return false
+	}
+
+	hdr := req.Req.Header.Clone()
+	hdr.Del("Cookie")
+
+	if err := c.scrape(req.Req.URL.String(), req.Req.Method, int(req.Depth), body, &ctx, hdr, false); err != nil {
+		c.Config.logError(LOG_WARN_LEVEL, err)
+		return false
+	}
+
+	return true
+}
+
+// replayBody returns a new reader of the body of the sent request, or false if the body
+// can't be read again. The requests without a body return a nil reader.
+func replayBody(req *http.Request) (io.Reader, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, false
+	}
+
+	return bytes.NewReader(data), true
+}
+
+// ------------------------------------------------------------------------
+
+// shortBody returns true if fewer bytes were received than the Content-Length announced.
+// The bodies cut by the body size limit and the bodiless responses are not short.
+func (r *Response) shortBody(received int64, bodySize int) bool {
+	cl := r.Resp.ContentLength
+	if cl <= 0 || received >= cl {
+		return false
+	}
+
+	if bodySize > 0 && received >= int64(bodySize) {
+		return false
+	}
+
+	if r.Request != nil && r.Request.Req != nil && r.Request.Req.Method == http.MethodHead {
+		return false
+	}
+
+	return r.Resp.StatusCode != http.StatusNoContent && r.Resp.StatusCode != http.StatusNotModified
+}
+
+// ------------------------------------------------------------------------
+
+// Read implements the io.Reader interface.
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.rdr.Read(p)
+	cr.n += int64(n)
+
+	return n, err
+}
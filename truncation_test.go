@@ -0,0 +1,121 @@
+package colly
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// ------------------------------------------------------------------------
+
+func TestNewResponse_truncated(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		body      io.Reader
+		length    int64
+		bodySize  int
+		truncated bool
+	}{
+		{name: "complete", method: "GET", body: strings.NewReader("hello"), length: 5},
+		{name: "unknown length", method: "GET", body: strings.NewReader("hello"), length: -1},
+		{name: "short", method: "GET", body: strings.NewReader("hel"), length: 5, truncated: true},
+		{name: "closed mid-body", method: "GET", body: io.MultiReader(strings.NewReader("hel"), iotest.ErrReader(io.ErrUnexpectedEOF)), length: -1, truncated: true},
+		{name: "body size limit", method: "GET", body: strings.NewReader("hello"), length: 5, bodySize: 3},
+		{name: "head", method: "HEAD", body: http.NoBody, length: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, "http://example.com/", nil)
+			resp, err := NewResponse(&Request{Req: req}, &http.Response{
+				StatusCode:    http.StatusOK,
+				Header:        http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+				Body:          io.NopCloser(tt.body),
+				ContentLength: tt.length,
+			}, false, tt.bodySize)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Truncated != tt.truncated {
+				t.Errorf("Truncated = %v, want %v", resp.Truncated, tt.truncated)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_handleTruncated(t *testing.T) {
+	c := NewCollector(nil, nil)
+
+	called := false
+	c.OnResponse(func(r *Response) {
+		called = true
+	})
+
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	ctx := context.WithValue(context.Background(), TruncatedRetryKey, uint(2))
+	resp := &Response{
+		Request:   &Request{ID: c.stats.nextRequestID(), Req: req, Ctx: &ctx, collector: c},
+		Resp:      &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, ContentLength: 10},
+		Body:      []byte("hello"),
+		Truncated: true,
+	}
+
+	// The retries are used up, the truncated response is processed
+	c.Config.MaxTruncatedRetries = 2
+	c.handleOnResponse(resp)
+
+	if !called {
+		t.Error("OnResponse was not called with the truncated response")
+	}
+	if n := resp.Request.TruncatedRetries(); n != 2 {
+		t.Errorf("TruncatedRetries() = %d, want 2", n)
+	}
+	if got := c.Stats().Truncated; got != 1 {
+		t.Errorf("Stats().Truncated = %d, want 1", got)
+	}
+}
+
+func TestCollector_handleTruncated_post(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		// The first response is cut short
+		w.Header().Set("Content-Length", "10")
+		if len(bodies) == 1 {
+			w.Write([]byte("hello"))
+			return
+		}
+		w.Write([]byte("helloworld"))
+	}))
+	defer ts.Close()
+
+	cfg := NewConfig()
+	cfg.MaxTruncatedRetries = 1
+
+	c := NewCollector(cfg, nil)
+
+	var got []string
+	c.OnResponse(func(r *Response) { got = append(got, string(r.Body)) })
+
+	if err := c.PostRaw(ts.URL+"/form", []byte("a=1")); err != nil {
+		t.Fatalf("PostRaw() error = %v", err)
+	}
+
+	if want := []string{"a=1", "a=1"}; !reflect.DeepEqual(bodies, want) {
+		t.Errorf("request bodies = %q, want %q", bodies, want)
+	}
+	if len(got) != 1 || got[0] != "helloworld" {
+		t.Errorf("responses = %q, want the complete body of the retry", got)
+	}
+}
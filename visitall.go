@@ -0,0 +1,141 @@
+package colly
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ------------------------------------------------------------------------
+
+// VisitResult is the outcome of a URL submitted by VisitAll.
+type VisitResult struct {
+	URL        string `json:"url" bson:"url"`                                     // URL is the submitted URL.
+	Accepted   bool   `json:"accepted" bson:"accepted,omitempty"`                 // Accepted tells whether the request passed the filters.
+	Reason     string `json:"reason,omitempty" bson:"reason,omitempty"`           // Reason is why the URL was rejected.
+	Err        error  `json:"-" bson:"-"`                                         // Err is the rejection error, it can be checked with errors.Is.
+	FetchError string `json:"fetch_error,omitempty" bson:"fetch_error,omitempty"` // FetchError is the message of the failed fetch of an accepted URL.
+	FetchErr   error  `json:"-" bson:"-"`                                         // FetchErr is the fetch error of an accepted URL, it is only known in synchronous mode.
+}
+
+// VisitReport is the per-URL report of VisitAll.
+type VisitReport struct {
+	Accepted int            `json:"accepted" bson:"accepted"`         // Accepted is the number of the accepted URLs.
+	Rejected int            `json:"rejected" bson:"rejected"`         // Rejected is the number of the rejected URLs.
+	Failed   int            `json:"failed" bson:"failed"`             // Failed is the number of the accepted URLs whose fetch failed.
+	Results  []VisitResult  `json:"results" bson:"results,omitempty"` // Results are the outcomes in the order of the URLs.
+	Reasons  map[string]int `json:"reasons" bson:"reasons,omitempty"` // Reasons is the number of the rejected URLs by reason.
+}
+
+// ------------------------------------------------------------------------
+
+// VisitAll creates GET requests to the URLs like Visit does, and returns the outcome of each URL.
+// The URLs are parsed and deduplicated before any request is made, the repeated ones are
+// rejected with ErrVisitDuplicate, then they are submitted one by one like Visit.
+// If some URLs were rejected, the error wraps ErrVisitRejected.
+// In synchronous mode the URLs are fetched one after the other, the fetch errors are reported
+// separately from the rejections and they don't fail VisitAll.
+func (c *Collector) VisitAll(urls []string) (*VisitReport, error) {
+	return c.visitAll(urls, func(u string) (bool, error) {
+		return c.checkedVisit(u, nil)
+	})
+}
+
+// visitAll submits the unique valid URLs and collects the outcomes.
+// The submit function returns true if the URL passed the filters, so its error is a fetch error.
+func (c *Collector) visitAll(urls []string, submit func(string) (bool, error)) (*VisitReport, error) {
+	rep := &VisitReport{
+		Results: make([]VisitResult, len(urls)),
+		Reasons: map[string]int{},
+	}
+
+	// Parse and deduplicate the whole batch first, so the filters and
+	// the visit storages only see the URLs that are going to be requested
+	pending := make([]int, 0, len(urls))
+	seen := make(map[string]struct{}, len(urls))
+	for i, rawURL := range urls {
+		res := &rep.Results[i]
+		res.URL = rawURL
+
+		if rawURL == "" {
+			res.Err = ErrMissingURL
+			continue
+		}
+
+		u, err := c.Config.Parser.Parse(rawURL)
+		if err != nil {
+			res.Err = err
+			continue
+		}
+		if u.Host == "" {
+			res.Err = fmt.Errorf("%w: %q", ErrMissingURL, rawURL)
+			continue
+		}
+
+		key := u.String()
+		if _, present := seen[key]; present {
+			res.Err = ErrVisitDuplicate
+			continue
+		}
+		seen[key] = struct{}{}
+
+		res.URL = key
+		pending = append(pending, i)
+	}
+
+	for _, i := range pending {
+		res := &rep.Results[i]
+		accepted, err := submit(res.URL)
+		if accepted {
+			res.Accepted = true
+			res.FetchErr = err
+		} else {
+			res.Err = err
+		}
+	}
+
+	for i := range rep.Results {
+		res := &rep.Results[i]
+		if res.FetchErr != nil {
+			res.FetchError = res.FetchErr.Error()
+			rep.Failed++
+		}
+
+		if res.Err == nil {
+			res.Accepted = true
+			rep.Accepted++
+			continue
+		}
+
+		res.Reason = res.Err.Error()
+		rep.Rejected++
+		rep.Reasons[visitReason(res.Err)]++
+	}
+
+	if c.HasLogger() {
+		c.logEvent(LOG_INFO_LEVEL, "visit_all", 0, map[string]string{
+			"urls":     strconv.Itoa(len(urls)),
+			"accepted": strconv.Itoa(rep.Accepted),
+			"rejected": strconv.Itoa(rep.Rejected),
+			"failed":   strconv.Itoa(rep.Failed),
+		})
+	}
+
+	if rep.Rejected > 0 {
+		return rep, fmt.Errorf("%w: %d of %d", ErrVisitRejected, rep.Rejected, len(urls))
+	}
+
+	return rep, nil
+}
+
+// ------------------------------------------------------------------------
+
+// visitReason returns the aggregation key of a rejection error, which is the message of the
+// innermost wrapped error, so the URL specific details of the wrapping errors are dropped.
+func visitReason(err error) string {
+	for inner := errors.Unwrap(err); inner != nil; inner = errors.Unwrap(err) {
+		err = inner
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,109 @@
+package colly
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollector_visitAll(t *testing.T) {
+	c := NewCollector(nil, nil)
+
+	var submitted []string
+	errNotFound := errors.New("Not Found")
+	submit := func(u string) (bool, error) {
+		submitted = append(submitted, u)
+		switch u {
+		case "http://blocked.com/":
+			return false, ErrFilterDomainDisallowed
+		case "http://example.com/b":
+			return true, errNotFound
+		}
+		return true, nil
+	}
+
+	rep, err := c.visitAll([]string{
+		"http://example.com/a",
+		"http://blocked.com/",
+		"http://example.com/a",
+		"",
+		"http://example.com/b",
+		"http://exa mple.com/",
+	}, submit)
+	if !errors.Is(err, ErrVisitRejected) {
+		t.Errorf("visitAll() error = %v, want %v", err, ErrVisitRejected)
+	}
+
+	if want := []string{"http://example.com/a", "http://blocked.com/", "http://example.com/b"}; !reflect.DeepEqual(submitted, want) {
+		t.Errorf("submitted = %q, want %q", submitted, want)
+	}
+
+	if rep.Accepted != 2 || rep.Rejected != 4 || rep.Failed != 1 {
+		t.Errorf("Accepted, Rejected, Failed = %d, %d, %d, want 2, 4, 1", rep.Accepted, rep.Rejected, rep.Failed)
+	}
+	if res := rep.Results[4]; !res.Accepted || res.FetchErr != errNotFound || res.FetchError != "Not Found" || res.Err != nil {
+		t.Errorf("Results[4] of a failed fetch = %+v", res)
+	}
+
+	for i, want := range []error{nil, ErrFilterDomainDisallowed, ErrVisitDuplicate, ErrMissingURL, nil, nil} {
+		res := rep.Results[i]
+		if want != nil && !errors.Is(res.Err, want) {
+			t.Errorf("Results[%d].Err = %v, want %v", i, res.Err, want)
+		}
+		if res.Accepted != (res.Err == nil) || (res.Reason == "") != (res.Err == nil) {
+			t.Errorf("Results[%d] = %+v", i, res)
+		}
+	}
+
+	if _, ok := rep.Reasons[errNotFound.Error()]; ok {
+		t.Errorf("Reasons = %v, want no fetch errors", rep.Reasons)
+	}
+	for _, reason := range []string{ErrFilterDomainDisallowed.Error(), ErrVisitDuplicate.Error(), ErrMissingURL.Error()} {
+		if rep.Reasons[reason] != 1 {
+			t.Errorf("Reasons[%q] = %d, want 1", reason, rep.Reasons[reason])
+		}
+	}
+
+	if rep, err := c.visitAll([]string{"http://example.com/"}, submit); err != nil || rep.Accepted != 1 {
+		t.Errorf("visitAll() = %+v, %v", rep, err)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_VisitAll(t *testing.T) {
+	var heads atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			heads.Add(1)
+		}
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	cfg := NewConfig()
+	cfg.CheckHead = true
+	c := NewCollector(cfg, nil)
+
+	// The fetch errors are not rejections, the CheckHead requests are made like in Visit
+	rep, err := c.VisitAll([]string{ts.URL + "/", ts.URL + "/missing"})
+	if err != nil {
+		t.Fatalf("VisitAll() error = %v", err)
+	}
+	if rep.Accepted != 2 || rep.Rejected != 0 || rep.Failed != 1 {
+		t.Errorf("Accepted, Rejected, Failed = %d, %d, %d, want 2, 0, 1", rep.Accepted, rep.Rejected, rep.Failed)
+	}
+	if res := rep.Results[1]; res.FetchErr == nil || res.Err != nil {
+		t.Errorf("Results[1] = %+v, want a fetch error", res)
+	}
+	if n := heads.Load(); n != 2 {
+		t.Errorf("HEAD requests = %d, want 2", n)
+	}
+}
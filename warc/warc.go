@@ -0,0 +1,409 @@
+// Package warc implements a writer of Web ARChive (WARC 1.1) files.
+package warc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// Record is a WARC record.
+type Record struct {
+	Type        string            // Type is the WARC record type, e.g. "response".
+	ID          string            // ID is the record identifier, it is generated if blank.
+	Date        time.Time         // Date is the capture date, it is set to the current time if zero.
+	TargetURI   string            // TargetURI is the original URI of the captured content.
+	ContentType string            // ContentType is the media type of the record block.
+	Headers     map[string]string // Headers are additional WARC named fields.
+	Block       []byte            // Block is the content of the record.
+}
+
+// Writer writes WARC records to files, rotating the files by size.
+type Writer struct {
+	dir      string
+	prefix   string
+	maxSize  int64
+	compress bool
+
+	file    *os.File
+	buf     *bufio.Writer
+	size    int64
+	serial  int
+	created []string
+	lock    *sync.Mutex
+}
+
+// countWriter counts the bytes written to the underlying writer.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+// ------------------------------------------------------------------------
+
+// WARC record types
+const (
+	TYPE_WARCINFO = "warcinfo"
+	TYPE_RESPONSE = "response"
+	TYPE_REQUEST  = "request"
+	TYPE_METADATA = "metadata"
+	TYPE_RESOURCE = "resource"
+)
+
+const version = "WARC/1.1"
+
+// ------------------------------------------------------------------------
+
+var (
+	ErrWriterClosed = errors.New("WARC writer is closed")   // ErrWriterClosed is thrown when an attempt was made to write to a closed writer.
+	ErrBlankDir     = errors.New("no WARC directory given") // ErrBlankDir is thrown when the writer was created without an output directory.
+)
+
+// ------------------------------------------------------------------------
+
+// NewWriter returns a pointer to a newly created WARC writer.
+// The files are created in dir, named by the prefix, the timestamp and a serial number.
+// The records are appended to the files, so a crash loses the buffered records only,
+// the files are flushed to the disk when they are rotated or closed.
+// A new file is started when the current file exceeds maxSize bytes, 0 means no rotation.
+// If compress is true, every record is compressed as a separate gzip member.
+func NewWriter(dir string, prefix string, maxSize int64, compress bool) (*Writer, error) {
+	if dir == "" {
+		return nil, ErrBlankDir
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	if prefix == "" {
+		prefix = "colly"
+	}
+
+	w := &Writer{
+		dir:      dir,
+		prefix:   prefix,
+		maxSize:  maxSize,
+		compress: compress,
+		lock:     &sync.Mutex{},
+	}
+
+	return w, nil
+}
+
+// ------------------------------------------------------------------------
+
+// WriteRecord writes a record to the current file, opening a new file if necessary.
+func (w *Writer) WriteRecord(r *Record) error {
+	return w.WriteRecords(r)
+}
+
+// WriteRecords writes the records to the current file in one go, opening a new file if necessary.
+// The records of the other writers are not interleaved, and the file is not rotated between them.
+func (w *Writer) WriteRecords(records ...*Record) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.dir == "" {
+		return ErrWriterClosed
+	}
+
+	if w.file == nil || (w.maxSize > 0 && w.size >= w.maxSize) {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range records {
+		if err := w.write(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteExchange writes a request and a response record of a HTTP transaction, the pair is
+// never split by a rotation. The bodies are stored as given, so the encoding related headers
+// are adjusted to the body.
+func (w *Writer) WriteExchange(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) error {
+	date := time.Now()
+	uri := req.URL.String()
+
+	respRecord := &Record{
+		Type:        TYPE_RESPONSE,
+		ID:          NewRecordID(),
+		Date:        date,
+		TargetURI:   uri,
+		ContentType: "application/http;msgtype=response",
+		Block:       ResponseBlock(resp, respBody),
+	}
+
+	reqRecord := &Record{
+		Type:        TYPE_REQUEST,
+		Date:        date,
+		TargetURI:   uri,
+		ContentType: "application/http;msgtype=request",
+		Headers:     map[string]string{"WARC-Concurrent-To": respRecord.ID},
+		Block:       RequestBlock(req, reqBody),
+	}
+
+	return w.WriteRecords(respRecord, reqRecord)
+}
+
+// ------------------------------------------------------------------------
+
+// Flush writes the buffered data to the current file.
+func (w *Writer) Flush() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.buf == nil {
+		return nil
+	}
+
+	return w.buf.Flush()
+}
+
+// Rotate closes the current file, the next record will be written to a new file.
+func (w *Writer) Rotate() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.closeFile()
+}
+
+// Close flushes and closes the current file.
+func (w *Writer) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	err := w.closeFile()
+	w.dir = ""
+
+	return err
+}
+
+// Files returns the paths of the files created by the writer.
+func (w *Writer) Files() []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return append([]string(nil), w.created...)
+}
+
+// ------------------------------------------------------------------------
+
+// open closes the current file and creates a new one starting with a warcinfo record.
+func (w *Writer) open() error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+
+	w.serial++
+	name := fmt.Sprintf("%s-%s-%05d.warc", w.prefix, time.Now().UTC().Format("20060102150405"), w.serial)
+	if w.compress {
+		name += ".gz"
+	}
+
+	// The existing files are never overwritten
+	f, err := os.OpenFile(filepath.Join(w.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	w.file = f
+	w.buf = bufio.NewWriter(f)
+	w.size = 0
+	w.created = append(w.created, f.Name())
+
+	return w.write(&Record{
+		Type:        TYPE_WARCINFO,
+		ContentType: "application/warc-fields",
+		Headers:     map[string]string{"WARC-Filename": name},
+		Block:       []byte("software: colly\r\nformat: WARC File Format 1.1\r\n"),
+	})
+}
+
+func (w *Writer) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+
+	err := w.buf.Flush()
+	if err == nil {
+		err = w.file.Sync()
+	}
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+
+	w.file = nil
+	w.buf = nil
+
+	return err
+}
+
+// write serializes the record to the current file.
+func (w *Writer) write(r *Record) error {
+	data := &bytes.Buffer{}
+	r.encode(data)
+
+	counter := &countWriter{w: w.buf}
+
+	if w.compress {
+		zw := gzip.NewWriter(counter)
+		if _, err := zw.Write(data.Bytes()); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	} else if _, err := counter.Write(data.Bytes()); err != nil {
+		return err
+	}
+
+	w.size += counter.n
+
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// encode writes the record header and block in WARC format.
+func (r *Record) encode(w *bytes.Buffer) {
+	if r.ID == "" {
+		r.ID = NewRecordID()
+	}
+
+	if r.Date.IsZero() {
+		r.Date = time.Now()
+	}
+
+	w.WriteString(version + "\r\n")
+	w.WriteString("WARC-Type: " + r.Type + "\r\n")
+	w.WriteString("WARC-Record-ID: " + r.ID + "\r\n")
+	w.WriteString("WARC-Date: " + r.Date.UTC().Format(time.RFC3339) + "\r\n")
+	if r.TargetURI != "" {
+		w.WriteString("WARC-Target-URI: " + r.TargetURI + "\r\n")
+	}
+	if r.ContentType != "" {
+		w.WriteString("Content-Type: " + r.ContentType + "\r\n")
+	}
+	w.WriteString("WARC-Block-Digest: " + digest(r.Block) + "\r\n")
+
+	keys := make([]string, 0, len(r.Headers))
+	for k := range r.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		w.WriteString(k + ": " + r.Headers[k] + "\r\n")
+	}
+
+	w.WriteString("Content-Length: " + strconv.Itoa(len(r.Block)) + "\r\n\r\n")
+	w.Write(r.Block)
+	w.WriteString("\r\n\r\n")
+}
+
+// ------------------------------------------------------------------------
+
+// ResponseBlock returns the HTTP response message of a response record.
+func ResponseBlock(resp *http.Response, body []byte) []byte {
+	b := &bytes.Buffer{}
+	proto := resp.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	status := resp.Status
+	if status == "" {
+		status = strconv.Itoa(resp.StatusCode) + " " + http.StatusText(resp.StatusCode)
+	}
+
+	fmt.Fprintf(b, "%s %s\r\n", proto, status)
+	writeHeader(b, resp.Header, len(body))
+	b.Write(body)
+
+	return b.Bytes()
+}
+
+// RequestBlock returns the HTTP request message of a request record.
+func RequestBlock(req *http.Request, body []byte) []byte {
+	b := &bytes.Buffer{}
+	proto := req.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+
+	fmt.Fprintf(b, "%s %s %s\r\n", req.Method, req.URL.RequestURI(), proto)
+
+	hdr := req.Header.Clone()
+	if hdr == nil {
+		hdr = http.Header{}
+	}
+	if hdr.Get("Host") == "" {
+		hdr.Set("Host", req.URL.Host)
+	}
+	writeHeader(b, hdr, len(body))
+	b.Write(body)
+
+	return b.Bytes()
+}
+
+// writeHeader writes the HTTP headers with the Content-Length of the stored body.
+func writeHeader(b *bytes.Buffer, hdr http.Header, bodyLen int) {
+	hdr = hdr.Clone()
+	if hdr == nil {
+		hdr = http.Header{}
+	}
+	hdr.Del("Content-Encoding")
+	hdr.Del("Transfer-Encoding")
+	hdr.Del("Content-Length")
+	if bodyLen > 0 {
+		hdr.Set("Content-Length", strconv.Itoa(bodyLen))
+	}
+
+	_ = hdr.Write(b)
+	b.WriteString("\r\n")
+}
+
+// ------------------------------------------------------------------------
+
+// NewRecordID returns a new random record identifier.
+func NewRecordID() string {
+	var u [16]byte
+	_, _ = rand.Read(u[:])
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+// digest returns the SHA-1 digest of the data in the WARC labelled base32 format.
+func digest(data []byte) string {
+	sum := sha1.Sum(data)
+
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err
+}
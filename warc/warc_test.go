@@ -0,0 +1,188 @@
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestWriter_WriteExchange(t *testing.T) {
+	tests := []struct {
+		name     string
+		compress bool
+	}{
+		{"plain", false},
+		{"gzip", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewWriter(t.TempDir(), "test", 0, tt.compress)
+			if err != nil {
+				t.Fatalf("NewWriter() error = %v", err)
+			}
+
+			u, _ := url.Parse("http://example.com/page?id=1")
+			req := &http.Request{Method: "GET", URL: u, Header: http.Header{"User-Agent": {"colly"}}}
+			resp := &http.Response{
+				StatusCode: 200,
+				Status:     "200 OK",
+				Proto:      "HTTP/1.1",
+				Header:     http.Header{"Content-Type": {"text/html"}, "Content-Encoding": {"gzip"}},
+			}
+
+			if err := w.WriteExchange(req, nil, resp, []byte("<html>hello</html>")); err != nil {
+				t.Fatalf("WriteExchange() error = %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if err := w.WriteRecord(&Record{Type: TYPE_METADATA}); err != ErrWriterClosed {
+				t.Errorf("WriteRecord() after Close error = %v, want %v", err, ErrWriterClosed)
+			}
+
+			files := w.Files()
+			if len(files) != 1 || strings.HasSuffix(files[0], ".gz") != tt.compress {
+				t.Fatalf("Files() = %v", files)
+			}
+
+			data := readFile(t, files[0], tt.compress)
+			if n := strings.Count(data, "WARC/1.1\r\n"); n != 3 {
+				t.Errorf("number of records = %d, want 3", n)
+			}
+
+			for _, want := range []string{
+				"WARC-Type: warcinfo\r\n",
+				"WARC-Type: response\r\n",
+				"WARC-Type: request\r\n",
+				"WARC-Target-URI: http://example.com/page?id=1\r\n",
+				"WARC-Concurrent-To: <urn:uuid:",
+				"HTTP/1.1 200 OK\r\nContent-Length: 18\r\nContent-Type: text/html\r\n\r\n<html>hello</html>",
+				"GET /page?id=1 HTTP/1.1\r\nHost: example.com\r\nUser-Agent: colly\r\n\r\n",
+			} {
+				if !strings.Contains(data, want) {
+					t.Errorf("WARC file does not contain %q", want)
+				}
+			}
+
+			if strings.Contains(data, "Content-Encoding") {
+				t.Errorf("WARC file contains the Content-Encoding header of the decoded body")
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestWriter_Rotate(t *testing.T) {
+	w, _ := NewWriter(t.TempDir(), "", 100, true)
+	defer w.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := w.WriteRecord(&Record{Type: TYPE_RESOURCE, Block: bytes.Repeat([]byte("x"), 200)}); err != nil {
+			t.Fatalf("WriteRecord() error = %v", err)
+		}
+	}
+
+	if n := len(w.Files()); n != 3 {
+		t.Errorf("Files() after size rotation = %d, want 3", n)
+	}
+
+	_ = w.Rotate()
+	_ = w.WriteRecord(&Record{Type: TYPE_RESOURCE})
+	if n := len(w.Files()); n != 4 {
+		t.Errorf("Files() after Rotate() = %d, want 4", n)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestWriter_WriteExchange_rotation(t *testing.T) {
+	w, _ := NewWriter(t.TempDir(), "test", 100, false)
+
+	u, _ := url.Parse("http://example.com/form")
+	req := &http.Request{Method: "POST", URL: u, Header: http.Header{}}
+	resp := &http.Response{StatusCode: 200, Header: http.Header{}}
+
+	// The pairs exceed the maximum size, the files are rotated between the pairs only
+	for i := 0; i < 3; i++ {
+		if err := w.WriteExchange(req, []byte("q=1"), resp, bytes.Repeat([]byte("x"), 200)); err != nil {
+			t.Fatalf("WriteExchange() error = %v", err)
+		}
+	}
+	w.Close()
+
+	files := w.Files()
+	if len(files) != 3 {
+		t.Fatalf("Files() = %d, want 3", len(files))
+	}
+	for _, f := range files {
+		data := readFile(t, f, false)
+		if !strings.Contains(data, "WARC-Type: response\r\n") || !strings.Contains(data, "WARC-Type: request\r\n") {
+			t.Errorf("file %s does not contain a request/response pair", f)
+		}
+		if !strings.HasSuffix(data, "POST /form HTTP/1.1\r\nContent-Length: 3\r\nHost: example.com\r\n\r\nq=1\r\n\r\n") {
+			t.Errorf("file %s does not end with the request record", f)
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestWriter_Flush(t *testing.T) {
+	dir := t.TempDir()
+
+	w, _ := NewWriter(dir, "test", 0, false)
+	defer w.Close()
+
+	// The flushed records of an open file are readable, another writer of the directory doesn't touch them
+	if err := w.WriteRecord(&Record{Type: TYPE_RESOURCE, Block: []byte("first")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewWriter(dir, "other", 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	if data := readFile(t, w.Files()[0], false); !strings.Contains(data, "first") {
+		t.Errorf("flushed file = %q, want the first record", data)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func readFile(t *testing.T, path string, compressed bool) string {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if compressed {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
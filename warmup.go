@@ -0,0 +1,122 @@
+package colly
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// WarmUpResult is the outcome of warming up the connection to a host.
+type WarmUpResult struct {
+	Origin   string        `json:"origin" bson:"origin,omitempty"`     // Origin is the scheme and the host of the warmed up connection.
+	Duration time.Duration `json:"duration" bson:"duration,omitempty"` // Duration is the time of the DNS resolution, connection and TLS handshake.
+	Err      error         `json:"error" bson:"error,omitempty"`       // Err is the error of the warm-up, if any.
+}
+
+// ------------------------------------------------------------------------
+
+const defWarmUpThreads uint = 4
+
+// ------------------------------------------------------------------------
+
+// TopOrigins returns the n most frequent origins (scheme://host) of the seed URLs.
+// Origins with the same frequency keep the order of their first appearance. 0 returns all origins.
+func TopOrigins(seeds []string, n uint) []string {
+	counts := map[string]int{}
+	var origins []string
+
+	for _, seed := range seeds {
+		u, err := url.Parse(strings.TrimSpace(seed))
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+
+		origin := u.Scheme + "://" + strings.ToLower(u.Host)
+		if counts[origin] == 0 {
+			origins = append(origins, origin)
+		}
+		counts[origin]++
+	}
+
+	sort.SliceStable(origins, func(i, j int) bool {
+		return counts[origins[i]] > counts[origins[j]]
+	})
+
+	if n > 0 && uint(len(origins)) > n {
+		origins = origins[:n]
+	}
+
+	return origins
+}
+
+// ------------------------------------------------------------------------
+
+// WarmUp pre-resolves the hosts and pre-establishes the connections, including the TLS handshakes,
+// to the most frequent origins of the seed URLs before the crawl starts. The connections are kept
+// idle in the pool of the HTTP transport. The number of the origins and the concurrency are set by
+// the WarmUpHosts and WarmUpThreads settings of the collector configuration.
+func (c *Client) WarmUp(ctx context.Context, seeds ...string) []WarmUpResult {
+	if c.warmUpHosts == 0 {
+		return nil
+	}
+
+	origins := TopOrigins(seeds, c.warmUpHosts)
+	results := make([]WarmUpResult, len(origins))
+
+	threads := c.warmUpThreads
+	if threads == 0 {
+		threads = defWarmUpThreads
+	}
+
+	sem := make(chan struct{}, threads)
+	wg := &sync.WaitGroup{}
+
+	for i, origin := range origins {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, origin string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			start := time.Now()
+			err := c.warmUp(ctx, origin)
+			results[i] = WarmUpResult{Origin: origin, Duration: time.Since(start), Err: err}
+		}(i, origin)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// warmUp opens a connection to the origin with a HEAD request and leaves it in the idle pool.
+func (c *Client) warmUp(ctx context.Context, origin string) error {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", origin+"/", nil)
+	if err != nil {
+		return err
+	}
+
+	transport := c.Clt.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+
+	// The connection is reused only if the body was fully read and closed
+	io.Copy(io.Discard, resp.Body)
+
+	return resp.Body.Close()
+}
@@ -0,0 +1,75 @@
+package colly
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// ------------------------------------------------------------------------
+
+func TestTopOrigins(t *testing.T) {
+	seeds := []string{
+		"https://a.com/1",
+		"http://b.com/1",
+		"https://A.com/2",
+		"http://b.com/2",
+		"https://c.com/",
+		"http://b.com/3",
+		"ftp://d.com/",
+		"/relative",
+	}
+
+	tests := []struct {
+		name string
+		n    uint
+		want []string
+	}{
+		{"all", 0, []string{"http://b.com", "https://a.com", "https://c.com"}},
+		{"top 2", 2, []string{"http://b.com", "https://a.com"}},
+		{"more than available", 10, []string{"http://b.com", "https://a.com", "https://c.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TopOrigins(seeds, tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TopOrigins() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestClient_WarmUp(t *testing.T) {
+	var conns int32
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	clt := &Client{Clt: ts.Client(), warmUpHosts: 1}
+
+	results := clt.WarmUp(context.Background(), ts.URL+"/a", ts.URL+"/b")
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("WarmUp() = %v", results)
+	}
+
+	resp, err := clt.Clt.Get(ts.URL + "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Errorf("connections = %d, want the warmed up connection reused", n)
+	}
+}
@@ -0,0 +1,95 @@
+package colly
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+// watchRequest registers the request as in flight. If the lifetime of the requests is limited,
+// the request gets a cancellable context and a watchdog timer cancelling it when the lifetime
+// is exceeded, e.g. by a connection trickling the response body slowly enough to dodge the timeouts.
+func (c *Collector) watchRequest(r *Request) {
+	r.started = time.Now()
+
+	lifetime := c.Config.MaxRequestLifetime
+	if lifetime <= 0 || r.Req == nil {
+		c.inFlight.start(r, r.started, nil)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Req.Context())
+	r.Req = r.Req.WithContext(ctx)
+
+	timer := time.AfterFunc(lifetime, func() {
+		c.requestStuck(r, lifetime)
+		cancel()
+	})
+
+	c.inFlight.start(r, r.started, func() {
+		timer.Stop()
+		cancel()
+	})
+}
+
+// requestStuck flags, counts and logs a request that exceeded its lifetime.
+func (c *Collector) requestStuck(r *Request, lifetime time.Duration) {
+	r.stuck.Store(true)
+	c.bus.Publish(TOPIC_STUCK, r.signal())
+
+	if c.Config.logEnabled(LOG_WARN_LEVEL) {
+		c.logEvent(LOG_WARN_LEVEL, "stuck", r.ID, map[string]string{
+			"url":      r.Req.URL.String(),
+			"lifetime": lifetime.String(),
+			"attempt":  strconv.FormatUint(uint64(r.Attempt()), 10),
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+
+// stuckError returns the error of a request cancelled by the watchdog as an error of the
+// ErrRequestStuck class, or the error itself if the request was not stuck.
+func (r *Request) stuckError(err error) error {
+	if err == nil || r == nil || !r.stuck.Load() {
+		return err
+	}
+
+	return fmt.Errorf("%w: %v", ErrRequestStuck, err)
+}
+
+// requeueStuck submits a stuck request again if the stuck requests are requeued and
+// the request was not requeued before. It returns true if the request was submitted.
+func (c *Collector) requeueStuck(r *Request) bool {
+	if !c.Config.RequeueStuck || r.Req == nil {
+		return false
+	}
+
+	parent := context.Background()
+	if r.Ctx != nil {
+		parent = *r.Ctx
+	}
+	if requeued, _ := parent.Value(StuckRetryKey).(bool); requeued {
+		return false
+	}
+	ctx := context.WithValue(parent, StuckRetryKey, true)
+
+	// The body was read by the stuck attempt
+	body, ok := replayBody(r.Req)
+	if !ok {
+		return false
+	}
+
+	hdr := r.Req.Header.Clone()
+	hdr.Del("Cookie")
+
+	if err := c.scrape(r.Req.URL.String(), r.Req.Method, int(r.Depth), body, &ctx, hdr, false); err != nil {
+		c.Config.logError(LOG_WARN_LEVEL, err)
+		return false
+	}
+
+	return true
+}
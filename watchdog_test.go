@@ -0,0 +1,75 @@
+package colly
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------------------
+
+func TestCollector_watchRequest(t *testing.T) {
+	c := NewCollector(nil, nil)
+	c.Config.MaxRequestLifetime = 20 * time.Millisecond
+
+	newReq := func(id uint32) *Request {
+		req, _ := http.NewRequest("GET", "http://example.com/", nil)
+		return &Request{ID: id, Req: req, collector: c}
+	}
+
+	// A request finished in time is not flagged
+	fast := newReq(1)
+	c.watchRequest(fast)
+	c.inFlight.finish(fast)
+	time.Sleep(40 * time.Millisecond)
+	if fast.stuck.Load() {
+		t.Error("the finished request was flagged as stuck")
+	}
+
+	// A hanging request is cancelled
+	slow := newReq(2)
+	c.watchRequest(slow)
+	if n := len(c.Snapshot().InFlight); n != 1 {
+		t.Errorf("%d requests in flight, want 1", n)
+	}
+
+	select {
+	case <-slow.Req.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("the stuck request was not cancelled")
+	}
+
+	if !slow.stuck.Load() || c.Stats().Stuck != 1 {
+		t.Errorf("stuck = %v, Stats().Stuck = %d", slow.stuck.Load(), c.Stats().Stuck)
+	}
+	if err := slow.stuckError(context.Canceled); !errors.Is(err, ErrRequestStuck) {
+		t.Errorf("stuckError() = %v, want %v", err, ErrRequestStuck)
+	}
+	if err := fast.stuckError(context.Canceled); err != context.Canceled {
+		t.Errorf("stuckError() of a request in time = %v", err)
+	}
+}
+
+// ------------------------------------------------------------------------
+
+func TestCollector_requeueStuck(t *testing.T) {
+	c := NewCollector(nil, nil)
+
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	ctx := context.WithValue(context.Background(), StuckRetryKey, true)
+	r := &Request{ID: 1, Req: req, Ctx: &ctx, collector: c}
+
+	if c.requeueStuck(r) {
+		t.Error("requeueStuck() requeued with RequeueStuck turned off")
+	}
+
+	c.Config.RequeueStuck = true
+	if c.requeueStuck(r) {
+		t.Error("requeueStuck() requeued a request for the second time")
+	}
+	if n := r.Attempt(); n != 2 {
+		t.Errorf("Attempt() of a requeued request = %d, want 2", n)
+	}
+}